@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"io"
+	"log"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	cacheBackend "github.com/greeddj/go-galaxy/internal/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/inspect"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// Cache returns the CLI command that reports on the cache.
+func Cache() *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "Report on the cache",
+		Subcommands: []*cli.Command{
+			cacheStats(),
+			cacheProjects(),
+			cacheMigrate(),
+		},
+	}
+}
+
+// cacheStats returns the subcommand that prints hit/miss counters.
+func cacheStats() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.CacheStatsFlags()...)
+	flags = append(flags, helpers.S3Flags()...)
+
+	return &cli.Command{
+		Name:  "stats",
+		Usage: "Show cache hit/miss counters for the last run and across runs",
+		Flags: flags,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			// The report goes to stdout, so keep the spinner out of it.
+			p := newProgress(cfg, cfg.Verbose, !cfg.Verbose)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			runtime.DebugAnsibleConfig(cfg)
+			if err := inspect.Stats(c.Context, cfg, runtime, c.String("format")); err != nil {
+				progress.Errorf("Error: %s", err.Error())
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// cacheProjects returns the subcommand that reports recorded projects and their owners.
+func cacheProjects() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.CacheProjectsFlags()...)
+	flags = append(flags, helpers.S3Flags()...)
+
+	return &cli.Command{
+		Name:  "projects",
+		Usage: "List recorded projects with their owner and last run, oldest first",
+		Flags: flags,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			// The report goes to stdout, so keep the spinner out of it.
+			p := newProgress(cfg, cfg.Verbose, !cfg.Verbose)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			runtime.DebugAnsibleConfig(cfg)
+			err = inspect.Projects(c.Context, cfg, runtime, inspect.ProjectsOptions{
+				Owner:     c.String("owner"),
+				TTL:       cfg.ProjectTTL,
+				StaleOnly: c.Bool("stale"),
+				Format:    c.String("format"),
+			})
+			if err != nil {
+				progress.Errorf("Error: %s", err.Error())
+			}
+			return err
+		},
+	}
+}
+
+// cacheMigrate returns the subcommand that copies the cache between backends.
+func cacheMigrate() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.S3Flags()...)
+	flags = append(flags, helpers.LockFlags()...)
+
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Copy state and artifacts from --cache-migrate-from into the configured backend",
+		Flags: flags,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			p := newProgress(cfg, cfg.Verbose, cfg.Quiet)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			report, err := cacheBackend.Migrate(c.Context, cfg, runtime)
+			if err != nil {
+				p.Errorf("Error: %s", err.Error())
+				return err
+			}
+			p.Okf("Migrated %s → %s: %d artifacts copied, %d already present, %d projects",
+				report.From, report.To, report.ArtifactsCopied, report.ArtifactsSkipped, report.Projects)
+			return nil
+		},
+	}
+}
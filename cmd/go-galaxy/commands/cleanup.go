@@ -7,7 +7,6 @@ import (
 	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
 	"github.com/greeddj/go-galaxy/internal/galaxy/cleanup"
 	"github.com/greeddj/go-galaxy/internal/galaxy/config"
-	"github.com/greeddj/go-galaxy/internal/galaxy/fetch"
 	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
 	"github.com/greeddj/go-galaxy/internal/progress"
 	"github.com/urfave/cli/v2"
@@ -17,11 +16,13 @@ import (
 func Cleanup() *cli.Command {
 	flags := helpers.CommonFlags()
 	flags = append(flags, helpers.S3Flags()...)
+	flags = append(flags, helpers.LockFlags()...)
+	flags = append(flags, helpers.CleanupFlags()...)
 
 	return &cli.Command{
 		Name:    "cleanup",
 		Aliases: []string{"c"},
-		Usage:   "Cleanup unused cached collections across all projects",
+		Usage:   "Cleanup unused cached collections across all projects or one --project",
 		Flags:   flags,
 		Action: func(c *cli.Context) error {
 			cfg, err := config.BuildCollectionConfig(c)
@@ -29,14 +30,14 @@ func Cleanup() *cli.Command {
 				progress.Errorf("%s", err.Error())
 				return err
 			}
-			p := progress.New(cfg.Verbose, cfg.Quiet)
+			p := newProgress(cfg, cfg.Verbose, cfg.Quiet)
 			if cfg.Verbose {
 				log.SetOutput(p)
 			} else {
 				log.SetOutput(io.Discard)
 			}
 			defer p.Close()
-			runtime := infra.New(p, fetch.New(cfg.Timeout))
+			runtime := infra.NewFromConfig(p, cfg)
 			runtime.DebugAnsibleConfig(cfg)
 			return cleanup.Start(c.Context, cfg, runtime)
 		},
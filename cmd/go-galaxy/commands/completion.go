@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	galaxyHelpers "github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/inspect"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// bashCompletion passes the word being completed to go-galaxy, so collection
+// names can be looked up by prefix instead of listing the whole server. A
+// partial flag is passed as a bare "-": the CLI parser rejects unknown flags
+// even while completing, and the shell filters the full list anyway.
+const bashCompletion = `_go_galaxy_complete() {
+  local cur word words
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  if [[ ${COMP_CWORD} -ge 2 ]]; then
+    word="${cur}"
+    [[ ${word} == -* ]] && word="-"
+    words=$("${COMP_WORDS[@]:0:COMP_CWORD}" "${word}" --generate-bash-completion 2>/dev/null)
+  else
+    words=$("${COMP_WORDS[0]}" --generate-bash-completion 2>/dev/null)
+  fi
+  COMPREPLY=($(compgen -W "${words}" -- "${cur}"))
+  if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == *. ]] && type compopt >/dev/null 2>&1; then
+    compopt -o nospace
+  fi
+}
+complete -o default -F _go_galaxy_complete go-galaxy
+`
+
+// zshCompletion reuses the bash script through zsh's bash compatibility layer.
+const zshCompletion = "autoload -U +X bashcompinit && bashcompinit\n" + bashCompletion
+
+// Completion returns the CLI command that prints a shell completion script.
+func Completion() *cli.Command {
+	return &cli.Command{
+		Name:      "completion",
+		Usage:     "Print the shell completion script",
+		ArgsUsage: "<bash|zsh>",
+		Action: func(c *cli.Context) error {
+			var script string
+			switch shell := strings.ToLower(strings.TrimSpace(c.Args().First())); shell {
+			case "bash":
+				script = bashCompletion
+			case "zsh":
+				script = zshCompletion
+			default:
+				err := fmt.Errorf("%w: %q", galaxyHelpers.ErrUnsupportedShell, shell)
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			_, err := io.WriteString(c.App.Writer, script)
+			return err
+		},
+	}
+}
+
+// completeCollection prints collection names from the configured server for
+// the word being completed; flags complete as usual. It prints nothing once
+// the collection argument is given or when the server does not answer.
+func completeCollection(c *cli.Context) {
+	var prefix string
+	if len(os.Args) > 2 {
+		prefix = os.Args[len(os.Args)-2]
+	}
+	if strings.HasPrefix(prefix, "-") {
+		cli.DefaultCompleteWithFlags(c.Command)(c)
+		return
+	}
+	if c.NArg() > 1 {
+		return
+	}
+	log.SetOutput(io.Discard)
+	cfg, err := config.BuildCollectionConfig(c)
+	if err != nil {
+		return
+	}
+	p := newProgress(cfg, false, true)
+	defer p.Close()
+	runtime := infra.NewFromConfig(p, cfg)
+	for _, name := range inspect.Complete(c.Context, cfg, runtime, prefix) {
+		_, _ = fmt.Fprintln(c.App.Writer, name)
+	}
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"io"
+	"log"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/inspect"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// Conformance returns the CLI command that compares the resolver with recorded ansible-galaxy resolutions.
+func Conformance() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.ConformanceFlags()...)
+
+	return &cli.Command{
+		Name:  "conformance",
+		Usage: "Resolve canonical requirements files and report where the result differs from ansible-galaxy",
+		Flags: flags,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			// The report goes to stdout, so keep the spinner out of it.
+			p := newProgress(cfg, cfg.Verbose, !cfg.Verbose)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			err = inspect.Conformance(c.Context, cfg, runtime, inspect.ConformanceOptions{
+				Corpus: c.String("corpus"),
+				Format: c.String("format"),
+			})
+			if err != nil {
+				progress.Errorf("Error: %s", err.Error())
+			}
+			return err
+		},
+	}
+}
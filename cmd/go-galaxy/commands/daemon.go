@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"io"
+	"log"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/daemon"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// Daemon returns the CLI command that serves resolve/install over HTTP with a warm store.
+func Daemon() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.CollectionFlags()...)
+	flags = append(flags, helpers.DaemonFlags()...)
+	flags = append(flags, helpers.S3Flags()...)
+	flags = append(flags, helpers.LockFlags()...)
+
+	return &cli.Command{
+		Name:  "daemon",
+		Usage: "Keep the store in memory and serve /resolve, /install and /status",
+		Flags: flags,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			// A long-running server has no use for a spinner.
+			p := newProgress(cfg, cfg.Verbose, !cfg.Verbose)
+			p.AddSecrets(c.String("token"))
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			runtime.DebugAnsibleConfig(cfg)
+			progress.Okf("Daemon starting on %s", c.String("listen"))
+			err = daemon.Run(c.Context, cfg, runtime, daemon.Options{
+				Listen:        c.String("listen"),
+				WatchInterval: c.Duration("watch-interval"),
+				ProxyListen:   c.String("proxy-listen"),
+				Token:         c.String("token"),
+				AllowPaths:    c.StringSlice("allow-path"),
+			})
+			if err != nil {
+				progress.Errorf("Error: %s", err.Error())
+			}
+			return err
+		},
+	}
+}
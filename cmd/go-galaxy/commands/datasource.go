@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"io"
+	"log"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/inspect"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// Datasource returns the CLI command that prints releases in Renovate custom datasource format.
+func Datasource() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.ServerFlags()...)
+	flags = append(flags, helpers.S3Flags()...)
+
+	return &cli.Command{
+		Name:         "datasource",
+		Usage:        "Print a collection's releases as a Renovate custom datasource JSON",
+		ArgsUsage:    "<namespace.name>",
+		Flags:        flags,
+		BashComplete: completeCollection,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			// The result goes to stdout, so keep the spinner out of it.
+			p := newProgress(cfg, cfg.Verbose, !cfg.Verbose)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			runtime.DebugAnsibleConfig(cfg)
+			err = inspect.Datasource(c.Context, cfg, runtime, inspect.DatasourceOptions{
+				Name: c.Args().First(),
+			})
+			if err != nil {
+				progress.Errorf("Error: %s", err.Error())
+			}
+			return err
+		},
+	}
+}
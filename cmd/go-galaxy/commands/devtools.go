@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"io"
+	"log"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// Devtools returns the CLI command that groups tools for go-galaxy development.
+func Devtools() *cli.Command {
+	return &cli.Command{
+		Name:  "devtools",
+		Usage: "Tools for developing and benchmarking go-galaxy",
+		Subcommands: []*cli.Command{
+			devtoolsGenCache(),
+		},
+	}
+}
+
+// devtoolsGenCache returns the subcommand that fabricates a synthetic cache.
+func devtoolsGenCache() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.ServerFlags()...)
+	flags = append(flags, helpers.GenCacheFlags()...)
+	flags = append(flags, helpers.S3Flags()...)
+	flags = append(flags, helpers.LockFlags()...)
+
+	return &cli.Command{
+		Name:  "gen-cache",
+		Usage: "Fill the cache with generated collections, metadata and artifacts for benchmarks",
+		Flags: flags,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			p := newProgress(cfg, cfg.Verbose, cfg.Quiet)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			err = collections.GenerateCache(c.Context, cfg, runtime, collections.GenCacheOptions{
+				Collections:  c.Int("collections"),
+				Versions:     c.Int("versions"),
+				Deps:         c.Int("deps"),
+				Seed:         c.Uint64("seed"),
+				Requirements: c.String("requirements-out"),
+			})
+			if err != nil {
+				runtime.Output.Errorf("Error: %s", err.Error())
+			}
+			return err
+		},
+	}
+}
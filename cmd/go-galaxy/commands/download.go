@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"io"
+	"log"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// Download returns the CLI command that warms the cache from lockfiles.
+func Download() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.ServerFlags()...)
+	flags = append(flags, helpers.DownloadFlags()...)
+	flags = append(flags, helpers.S3Flags()...)
+	flags = append(flags, helpers.LockFlags()...)
+
+	return &cli.Command{
+		Name:  "download",
+		Usage: "Download every collection pinned by lockfiles into the cache, without resolving",
+		Flags: flags,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			p := newProgress(cfg, cfg.Verbose, cfg.Quiet)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			err = collections.WarmFromLock(c.Context, cfg, runtime, c.StringSlice("from-lock"))
+			if err != nil {
+				runtime.Output.Errorf("Error: %s", err.Error())
+			}
+			return err
+		},
+	}
+}
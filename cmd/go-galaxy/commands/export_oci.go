@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"io"
+	"log"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/oci"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// ExportOCI returns the CLI command that pushes the installed collections tree as an image.
+func ExportOCI() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.ExportOCIFlags()...)
+
+	return &cli.Command{
+		Name:  "export-oci",
+		Usage: "Push installed collections as a single-layer OCI image",
+		Flags: flags,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			p := newProgress(cfg, cfg.Verbose, cfg.Quiet)
+			p.AddSecrets(c.String("registry-password"))
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			return oci.Export(c.Context, cfg, runtime, oci.Options{
+				Tag:       c.String("tag"),
+				Prefix:    c.String("prefix"),
+				Platform:  c.String("platform"),
+				Username:  c.String("registry-username"),
+				Password:  c.String("registry-password"),
+				PlainHTTP: c.Bool("plain-http"),
+			})
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"io"
+	"log"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/inspect"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// Info returns the CLI command that shows remote and local state of a collection.
+func Info() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.ServerFlags()...)
+	flags = append(flags, helpers.InfoFlags()...)
+	flags = append(flags, helpers.S3Flags()...)
+
+	return &cli.Command{
+		Name:         "info",
+		Usage:        "Show versions, dependencies and install state of a collection",
+		ArgsUsage:    "<namespace.name[:constraint]>",
+		Flags:        flags,
+		BashComplete: completeCollection,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			// The report goes to stdout, so keep the spinner out of it.
+			p := newProgress(cfg, cfg.Verbose, !cfg.Verbose)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			runtime.DebugAnsibleConfig(cfg)
+			err = inspect.Info(c.Context, cfg, runtime, inspect.InfoOptions{
+				Ref:      c.Args().First(),
+				Versions: c.Int("versions"),
+				Format:   c.String("format"),
+			})
+			if err != nil {
+				progress.Errorf("Error: %s", err.Error())
+			}
+			return err
+		},
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
 	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
 	"github.com/greeddj/go-galaxy/internal/galaxy/config"
-	"github.com/greeddj/go-galaxy/internal/galaxy/fetch"
 	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
 	"github.com/greeddj/go-galaxy/internal/progress"
 	"github.com/urfave/cli/v2"
@@ -17,7 +16,9 @@ import (
 func Install() *cli.Command {
 	flags := helpers.CommonFlags()
 	flags = append(flags, helpers.CollectionFlags()...)
+	flags = append(flags, helpers.InstallFlags()...)
 	flags = append(flags, helpers.S3Flags()...)
+	flags = append(flags, helpers.LockFlags()...)
 
 	return &cli.Command{
 		Name:    "install",
@@ -30,14 +31,14 @@ func Install() *cli.Command {
 				progress.Errorf("%s", err.Error())
 				return err
 			}
-			p := progress.New(cfg.Verbose, cfg.Quiet)
+			p := newProgress(cfg, cfg.Verbose, cfg.Quiet)
 			if cfg.Verbose {
 				log.SetOutput(p)
 			} else {
 				log.SetOutput(io.Discard)
 			}
 			defer p.Close()
-			runtime := infra.New(p, fetch.New(cfg.Timeout))
+			runtime := infra.NewFromConfig(p, cfg)
 			runtime.DebugAnsibleConfig(cfg)
 			return collections.Start(c.Context, cfg, runtime)
 		},
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"io"
+	"log"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/inspect"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// List returns the CLI command that lists installed collections.
+func List() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.ServerFlags()...)
+	flags = append(flags, helpers.ListFlags()...)
+	flags = append(flags, helpers.S3Flags()...)
+
+	return &cli.Command{
+		Name:    "list",
+		Aliases: []string{"ls"},
+		Usage:   "List collections installed in the download path",
+		Flags:   flags,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			// The report goes to stdout, so keep the spinner out of it.
+			p := newProgress(cfg, cfg.Verbose, !cfg.Verbose)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			runtime.DebugAnsibleConfig(cfg)
+			err = inspect.List(c.Context, cfg, runtime, inspect.ListOptions{
+				Remote:   c.Bool("remote"),
+				Format:   c.String("format"),
+				Licenses: c.Bool("licenses"),
+			})
+			if err != nil {
+				progress.Errorf("Error: %s", err.Error())
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"io"
+	"log"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	galaxyHelpers "github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// Preflight returns the CLI command that checks a cache backend is ready for an offline install.
+func Preflight() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.ServerFlags()...)
+	flags = append(flags, helpers.PreflightFlags()...)
+	flags = append(flags, helpers.S3Flags()...)
+	flags = append(flags, helpers.LockFlags()...)
+
+	return &cli.Command{
+		Name:  "preflight",
+		Usage: "Report what the cache lacks for an offline install of a lockfile",
+		Flags: flags,
+		Action: func(c *cli.Context) error {
+			if !c.Bool("offline-target") {
+				progress.Errorf("%s", galaxyHelpers.ErrPreflightCheckRequired.Error())
+				return galaxyHelpers.ErrPreflightCheckRequired
+			}
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			p := newProgress(cfg, cfg.Verbose, cfg.Quiet)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			err = collections.Preflight(c.Context, cfg, runtime, c.String("from-lock"))
+			if err != nil {
+				runtime.Output.Errorf("Error: %s", err.Error())
+			}
+			return err
+		},
+	}
+}
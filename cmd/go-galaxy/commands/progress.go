@@ -0,0 +1,14 @@
+package commands
+
+import (
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+// newProgress returns the output of a command run with cfg, masking the
+// credentials cfg holds in everything it prints.
+func newProgress(cfg *config.Config, verbose, quiet bool) *progress.Progress {
+	p := progress.New(verbose, quiet)
+	p.AddSecrets(cfg.Secrets()...)
+	return p
+}
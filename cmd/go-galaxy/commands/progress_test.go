@@ -0,0 +1,27 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+)
+
+func TestNewProgressRedactsConfigSecrets(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{
+		S3Cache: config.S3CacheConfig{SecretKey: "s3-secret-value"},
+		Sources: []config.SourceConfig{{Name: "hub", URL: "https://hub.example.com/", Token: "hub-token-value", Password: "hub-password"}},
+	}
+	p := newProgress(cfg, false, true)
+	defer p.Close()
+	got := p.Redact("fetch https://hub.example.com/ as hub-token-value, hub-password, s3-secret-value failed")
+	for _, secret := range []string{"hub-token-value", "hub-password", "s3-secret-value"} {
+		if strings.Contains(got, secret) {
+			t.Fatalf("%q left in %q", secret, got)
+		}
+	}
+	if !strings.Contains(got, "https://hub.example.com/") {
+		t.Fatalf("expected the server URL kept, got %q", got)
+	}
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	galaxyHelpers "github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/requirements"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// Requirements returns the CLI command that groups requirements file tools.
+func Requirements() *cli.Command {
+	return &cli.Command{
+		Name:  "requirements",
+		Usage: "Work with requirements files",
+		Subcommands: []*cli.Command{
+			requirementsMigrate(),
+		},
+	}
+}
+
+// requirementsMigrate returns the subcommand that converts legacy
+// requirements inputs to a requirements.yml.
+func requirementsMigrate() *cli.Command {
+	return &cli.Command{
+		Name:      "migrate",
+		Usage:     "Convert a plain text collection list or an ansible-builder definition to a requirements.yml",
+		ArgsUsage: "<file>",
+		Flags:     helpers.RequirementsMigrateFlags(),
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				progress.Errorf("%s", galaxyHelpers.ErrMigrateInputRequired.Error())
+				return galaxyHelpers.ErrMigrateInputRequired
+			}
+			m, err := requirements.Migrate(c.Args().First())
+			if err != nil {
+				progress.Errorf("Error: %s", err.Error())
+				return err
+			}
+			data, err := requirements.MarshalCollections(m.Collections)
+			if err != nil {
+				progress.Errorf("Error: %s", err.Error())
+				return err
+			}
+			if m.RolesFound {
+				_, _ = fmt.Fprintf(c.App.ErrWriter, "⚠️ roles in %s are not migrated: go-galaxy installs collections only\n", c.Args().First())
+			}
+			out := c.String("output")
+			if out == "" {
+				_, err = c.App.Writer.Write(data)
+				return err
+			}
+			//nolint:gosec // out is a user-provided output path.
+			if err := os.WriteFile(out, data, galaxyHelpers.FileMod); err != nil {
+				progress.Errorf("Error: %s", err.Error())
+				return err
+			}
+			progress.Okf("Wrote %d collections from %s input to %s", len(m.Collections), m.Format, out)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"io"
+	"log"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/inspect"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// ResolveVersion returns the CLI command that prints the version selected for a constraint.
+func ResolveVersion() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.ServerFlags()...)
+	flags = append(flags, helpers.ResolveVersionFlags()...)
+	flags = append(flags, helpers.S3Flags()...)
+
+	return &cli.Command{
+		Name:         "resolve-version",
+		Usage:        "Print the version the resolver selects for a constraint",
+		ArgsUsage:    "<namespace.name> [constraint]",
+		Flags:        flags,
+		BashComplete: completeCollection,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			// The result goes to stdout, so keep the spinner out of it.
+			p := newProgress(cfg, cfg.Verbose, !cfg.Verbose)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			runtime.DebugAnsibleConfig(cfg)
+			err = inspect.ResolveVersion(c.Context, cfg, runtime, inspect.ResolveVersionOptions{
+				Name:       c.Args().Get(0),
+				Constraint: c.Args().Get(1),
+				All:        c.Bool("all"),
+				Format:     c.String("format"),
+			})
+			if err != nil {
+				progress.Errorf("Error: %s", err.Error())
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"io"
+	"log"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/inspect"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// Search returns the CLI command that searches the Galaxy server for collections.
+func Search() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.ServerFlags()...)
+	flags = append(flags, helpers.SearchFlags()...)
+
+	return &cli.Command{
+		Name:      "search",
+		Usage:     "Search the Galaxy server for collections",
+		ArgsUsage: "<term>",
+		Flags:     flags,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			// Results go to stdout, so keep the spinner out of them.
+			p := newProgress(cfg, cfg.Verbose, !cfg.Verbose)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			runtime.DebugAnsibleConfig(cfg)
+			err = inspect.Search(c.Context, cfg, runtime, inspect.SearchOptions{
+				Term:   strings.Join(c.Args().Slice(), " "),
+				Limit:  c.Int("limit"),
+				Format: c.String("format"),
+			})
+			if err != nil {
+				progress.Errorf("Error: %s", err.Error())
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"io"
+	"log"
+
+	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/inspect"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/urfave/cli/v2"
+)
+
+// Store returns the CLI command that inspects the snapshot store.
+func Store() *cli.Command {
+	return &cli.Command{
+		Name:  "store",
+		Usage: "Inspect the cached snapshot store",
+		Subcommands: []*cli.Command{
+			storeDump(),
+		},
+	}
+}
+
+// storeDump returns the subcommand that dumps store buckets.
+func storeDump() *cli.Command {
+	flags := helpers.CommonFlags()
+	flags = append(flags, helpers.StoreDumpFlags()...)
+	flags = append(flags, helpers.S3Flags()...)
+
+	return &cli.Command{
+		Name:  "dump",
+		Usage: "Dump store buckets as JSON or YAML",
+		Flags: flags,
+		Action: func(c *cli.Context) error {
+			cfg, err := config.BuildCollectionConfig(c)
+			if err != nil {
+				progress.Errorf("%s", err.Error())
+				return err
+			}
+			// The dump goes to stdout, so keep the spinner out of it.
+			p := newProgress(cfg, cfg.Verbose, !cfg.Verbose)
+			if cfg.Verbose {
+				log.SetOutput(p)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			defer p.Close()
+			runtime := infra.NewFromConfig(p, cfg)
+			runtime.DebugAnsibleConfig(cfg)
+			err = inspect.Dump(c.Context, cfg, runtime, inspect.DumpOptions{
+				Buckets: c.StringSlice("bucket"),
+				Prefix:  c.String("prefix"),
+				Format:  c.String("format"),
+			})
+			if err != nil {
+				progress.Errorf("Error: %s", err.Error())
+			}
+			return err
+		},
+	}
+}
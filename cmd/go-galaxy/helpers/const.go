@@ -3,13 +3,17 @@ package helpers
 import "time"
 
 const (
-	dirSuffix                   = ".cache/go-galaxy"
-	defaultHomeDir              = "/root"
 	defaultTimeout              = 30 * time.Second
 	defaultServerURL            = "https://galaxy.ansible.com"
-	defaultCollectionsPath      = ".collections"
 	defaultRequirementsFilePath = "requirements.yml"
 	defaultAnsibleConfigPath    = "ansible.cfg"
+	defaultDaemonListen         = "127.0.0.1:8787"
+	defaultDaemonWatchInterval  = 5 * time.Second
+	defaultLeaseDuration        = time.Minute
+	defaultSearchLimit          = 20
+	defaultInfoVersions         = 20
+	defaultOCIPrefix            = "/usr/share/ansible/collections"
+	defaultOCIPlatform          = "linux/amd64"
 	defaultVersion              = "latest"
 	defaultBuilder              = "go"
 	userAgent                   = "go-galaxy"
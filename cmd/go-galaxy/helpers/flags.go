@@ -1,8 +1,13 @@
 package helpers
 
 import (
+	"fmt"
+	"os"
 	"runtime"
+	"strings"
 
+	galaxyHelpers "github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/progress"
 	"github.com/urfave/cli/v2"
 )
 
@@ -20,21 +25,76 @@ func CommonFlags() []cli.Flag {
 			Usage:   "Quiet mode, not working with verbose",
 			EnvVars: []string{"GO_GALAXY_QUIET"},
 		},
+		&cli.StringFlag{
+			Name:    "output-style",
+			Usage:   "Message markers: emoji or plain (ASCII tags such as [OK], [WARN] and [FAIL], no colors)",
+			Value:   progress.StyleEmoji,
+			EnvVars: []string{progress.StyleEnv},
+			Action:  exportOutputStyle,
+		},
 		&cli.BoolFlag{
 			Name:  "dry-run",
 			Usage: "Enable dry-run mode",
 		},
 		&cli.StringFlag{
 			Name:    "cache-dir",
-			Usage:   "Local cache directory",
-			Value:   defaultCacheDir(),
+			Usage:   "Local cache directory (default: $ANSIBLE_HOME/galaxy_cache/go-galaxy, $XDG_CACHE_HOME/go-galaxy or ~/.cache/go-galaxy)",
 			EnvVars: []string{"GO_GALAXY_CACHE_DIR", "ANSIBLE_GALAXY_CACHE_DIR"},
 		},
+		&cli.StringFlag{
+			Name:    "cache-namespace",
+			Usage:   "Isolate cache state, artifacts and locks under a namespace",
+			EnvVars: []string{"GO_GALAXY_CACHE_NAMESPACE"},
+		},
+		&cli.StringFlag{
+			Name:    "cache-backend",
+			Usage:   "Cache backend: local, local-json, s3, a registered name or exec:/path/to/plugin (default: s3 when --s3-bucket is set)",
+			EnvVars: []string{"GO_GALAXY_CACHE_BACKEND"},
+		},
+		&cli.StringFlag{
+			Name:    "cache-encryption-key",
+			Usage:   "Encrypt the local cache at rest with a 32-byte key (hex or base64)",
+			EnvVars: []string{"GO_GALAXY_CACHE_ENCRYPTION_KEY"},
+		},
+		&cli.StringFlag{
+			Name:    "cache-migrate-from",
+			Usage:   "Backend being migrated from: read through to it on misses and write to both (see cache migrate)",
+			EnvVars: []string{"GO_GALAXY_CACHE_MIGRATE_FROM"},
+		},
+		&cli.StringFlag{
+			Name:    "tmp-dir",
+			Usage:   "Directory for staging remote cache artifacts (default: system temp dir)",
+			EnvVars: []string{"GO_GALAXY_TMP_DIR"},
+		},
+		&cli.Float64Flag{
+			Name:    "chaos-s3-error-rate",
+			Usage:   "Answer this fraction (0..1) of S3 cache requests with a synthetic 503, for testing retry and soft-fail settings",
+			EnvVars: []string{"GO_GALAXY_CHAOS_S3_ERROR_RATE"},
+			Hidden:  true,
+		},
+		&cli.Float64Flag{
+			Name:    "chaos-cache-error-rate",
+			Usage:   "Fail this fraction (0..1) of cache backend calls, whatever the backend, for testing soft-fail settings",
+			EnvVars: []string{"GO_GALAXY_CHAOS_CACHE_ERROR_RATE"},
+			Hidden:  true,
+		},
+		&cli.DurationFlag{
+			Name:    "chaos-slow-download",
+			Usage:   "Delay every collection artifact download by this long, for testing timeouts and budgets",
+			EnvVars: []string{"GO_GALAXY_CHAOS_SLOW_DOWNLOAD"},
+			Hidden:  true,
+		},
+		&cli.Uint64Flag{
+			Name:    "chaos-seed",
+			Usage:   "Seed for the injected failures, to repeat a run exactly (default: random)",
+			EnvVars: []string{"GO_GALAXY_CHAOS_SEED"},
+			Hidden:  true,
+		},
 	}
 }
 
-// CollectionFlags defines CLI flags for collection install behavior.
-func CollectionFlags() []cli.Flag {
+// ServerFlags defines CLI flags for reaching the Galaxy server.
+func ServerFlags() []cli.Flag {
 	return []cli.Flag{
 		&cli.StringFlag{
 			Name:    "server",
@@ -42,31 +102,67 @@ func CollectionFlags() []cli.Flag {
 			Value:   defaultServerURL,
 			EnvVars: []string{"GO_GALAXY_SERVER", "ANSIBLE_GALAXY_SERVER"},
 		},
+		&cli.StringSliceFlag{
+			Name:    "server-mirror",
+			Usage:   "Mirror of the Galaxy server, picked per request by recent health (repeatable)",
+			EnvVars: []string{"GO_GALAXY_SERVER_MIRRORS"},
+		},
 		&cli.DurationFlag{
 			Name:    "timeout",
 			Usage:   "Timeout duration",
 			Value:   defaultTimeout,
 			EnvVars: []string{"GO_GALAXY_SERVER_TIMEOUT", "ANSIBLE_GALAXY_SERVER_TIMEOUT"},
 		},
+		&cli.IntFlag{
+			Name:    "http-max-idle-conns-per-host",
+			Usage:   "Idle connections kept per Galaxy host",
+			Value:   10,
+			EnvVars: []string{"GO_GALAXY_HTTP_MAX_IDLE_CONNS_PER_HOST"},
+		},
+		&cli.IntFlag{
+			Name:    "http-max-conns-per-host",
+			Usage:   "Connections per Galaxy host, 0 for no limit",
+			EnvVars: []string{"GO_GALAXY_HTTP_MAX_CONNS_PER_HOST"},
+		},
+		&cli.BoolFlag{
+			Name:    "http2-disabled",
+			Usage:   "Use HTTP/1.1 only",
+			EnvVars: []string{"GO_GALAXY_HTTP2_DISABLED"},
+		},
+		&cli.BoolFlag{
+			Name:    "http3",
+			Usage:   "Try HTTP/3 (QUIC) for Galaxy and S3 downloads, falling back to TCP",
+			EnvVars: []string{"GO_GALAXY_HTTP3"},
+		},
 		&cli.StringFlag{
-			Name:    "download-path",
-			Aliases: []string{"p"},
-			Usage:   "Path to download collections to",
-			Value:   defaultCollectionsPath,
-			EnvVars: []string{"GO_GALAXY_COLLECTIONS_PATH", "ANSIBLE_COLLECTIONS_PATH"},
+			Name:    "ansible-config",
+			Usage:   "Path to ansible.cfg file",
+			Value:   defaultAnsibleConfigPath,
+			EnvVars: []string{"GO_GALAXY_ANSIBLE_CONFIG", "ANSIBLE_CONFIG"},
 		},
+	}
+}
+
+// CollectionFlags defines CLI flags for collection install behavior.
+func CollectionFlags() []cli.Flag {
+	return append(ServerFlags(), []cli.Flag{
+		downloadPathFlag(),
 		&cli.StringFlag{
 			Name:    "requirements-file",
 			Aliases: []string{"r"},
-			Usage:   "Path to requirements.yml file",
+			Usage:   "Path to requirements.yml file, or - to read it from stdin",
 			Value:   defaultRequirementsFilePath,
 			EnvVars: []string{"GO_GALAXY_REQUIREMENTS_FILE", "ANSIBLE_GALAXY_REQUIREMENTS_FILE"},
 		},
 		&cli.StringFlag{
-			Name:    "ansible-config",
-			Usage:   "Path to ansible.cfg file",
-			Value:   defaultAnsibleConfigPath,
-			EnvVars: []string{"GO_GALAXY_ANSIBLE_CONFIG", "ANSIBLE_CONFIG"},
+			Name:    "requirements-inline",
+			Usage:   "Requirements YAML given directly, instead of a requirements file",
+			EnvVars: []string{"GO_GALAXY_REQUIREMENTS_INLINE"},
+		},
+		&cli.BoolFlag{
+			Name:    "strict",
+			Usage:   "Fail on unknown keys in the requirements file instead of ignoring them",
+			EnvVars: []string{"GO_GALAXY_STRICT"},
 		},
 		&cli.IntFlag{
 			Name:    "workers",
@@ -74,6 +170,119 @@ func CollectionFlags() []cli.Flag {
 			Value:   runtime.NumCPU(),
 			EnvVars: []string{"GO_GALAXY_WORKERS"},
 		},
+		&cli.IntFlag{
+			Name:    "resolve-workers",
+			Usage:   "Number of concurrent metadata requests while resolving (default: twice --workers)",
+			EnvVars: []string{"GO_GALAXY_RESOLVE_WORKERS"},
+		},
+		&cli.IntFlag{
+			Name:    "download-workers",
+			Usage:   "Number of concurrent artifact downloads (default: --workers)",
+			EnvVars: []string{"GO_GALAXY_DOWNLOAD_WORKERS"},
+		},
+		&cli.IntFlag{
+			Name:    "install-workers",
+			Usage:   "Number of collections installed concurrently (default: --workers)",
+			EnvVars: []string{"GO_GALAXY_INSTALL_WORKERS"},
+		},
+		&cli.StringFlag{
+			Name:    "galaxy-info",
+			Usage:   "GALAXY.yml written per collection: default, extended (adds dependencies and artifact sha256), ansible or none",
+			Value:   "default",
+			EnvVars: []string{"GO_GALAXY_GALAXY_INFO"},
+		},
+		&cli.StringFlag{
+			Name:    "chmod-files",
+			Usage:   "Octal mode set on every extracted file, regardless of archive modes and umask (e.g. 0644)",
+			EnvVars: []string{"GO_GALAXY_CHMOD_FILES"},
+		},
+		&cli.StringFlag{
+			Name:    "chmod-dirs",
+			Usage:   "Octal mode set on every extracted directory, regardless of umask; must include 0700 (e.g. 0755)",
+			EnvVars: []string{"GO_GALAXY_CHMOD_DIRS"},
+		},
+		&cli.StringFlag{
+			Name:    "install-template",
+			Usage:   "Directory of each collection under ansible_collections, e.g. {namespace}/{name}-{version}, with an active-collections.json index",
+			Value:   "{namespace}/{name}",
+			EnvVars: []string{"GO_GALAXY_INSTALL_TEMPLATE"},
+		},
+		&cli.BoolFlag{
+			Name:    "collections-path-relative",
+			Usage:   "Record the project's requirements file and collections path relative to the project directory, for checkouts that move",
+			EnvVars: []string{"GO_GALAXY_COLLECTIONS_PATH_RELATIVE"},
+		},
+		&cli.StringFlag{
+			Name:    "health-check",
+			Usage:   "Ping the server API before resolving: fail (abort when unhealthy), snapshot (fall back to the stored resolution) or off",
+			Value:   "fail",
+			EnvVars: []string{"GO_GALAXY_HEALTH_CHECK"},
+		},
+		&cli.StringFlag{
+			Name:    "deps-source",
+			Usage:   "Where collection dependencies come from when both disagree: metadata (server version metadata) or manifest (MANIFEST.json)",
+			Value:   "metadata",
+			EnvVars: []string{"GO_GALAXY_DEPS_SOURCE"},
+		},
+		&cli.StringFlag{
+			Name:    "replacements-file",
+			Usage:   "YAML mapping of renamed collections to their successors, resolved in their place",
+			EnvVars: []string{"GO_GALAXY_REPLACEMENTS_FILE"},
+		},
+		&cli.StringFlag{
+			Name:    "verify-skip",
+			Usage:   "Before skipping an installed collection, check files against its FILES.json: off, sample or all",
+			Value:   "off",
+			EnvVars: []string{"GO_GALAXY_VERIFY_SKIP"},
+		},
+		&cli.StringFlag{
+			Name:    "info-check",
+			Usage:   "Installed collection without .info/GALAXY.yml: require reinstalls it, manifest trusts a matching MANIFEST.json",
+			Value:   "require",
+			EnvVars: []string{"GO_GALAXY_INFO_CHECK"},
+		},
+		&cli.StringFlag{
+			Name:    "max-total-download",
+			Usage:   "Budget for artifact bytes downloaded in one install, e.g. 500MiB (default: no limit)",
+			EnvVars: []string{"GO_GALAXY_MAX_TOTAL_DOWNLOAD"},
+		},
+		&cli.DurationFlag{
+			Name:    "max-install-time",
+			Usage:   "Budget for the duration of one install, e.g. 5m (default: no limit)",
+			EnvVars: []string{"GO_GALAXY_MAX_INSTALL_TIME"},
+		},
+		&cli.StringFlag{
+			Name:    "budget-policy",
+			Usage:   "What an exceeded budget does: abort or warn",
+			Value:   "abort",
+			EnvVars: []string{"GO_GALAXY_BUDGET_POLICY"},
+		},
+		&cli.StringFlag{
+			Name:    "artifact-hash",
+			Usage:   "Hash used as the internal artifact identity: sha256, sha512 or blake3 (the server's sha256 is always verified)",
+			Value:   "sha256",
+			EnvVars: []string{"GO_GALAXY_ARTIFACT_HASH"},
+		},
+		&cli.IntFlag{
+			Name:    "decompress-blocks",
+			Usage:   "Gzip read-ahead blocks per extraction (default: derived from workers and GOMAXPROCS)",
+			EnvVars: []string{"GO_GALAXY_DECOMPRESS_BLOCKS"},
+		},
+		&cli.IntFlag{
+			Name:    "decompress-block-size",
+			Usage:   "Gzip read-ahead block size in KiB (default: 250)",
+			EnvVars: []string{"GO_GALAXY_DECOMPRESS_BLOCK_SIZE"},
+		},
+		&cli.IntFlag{
+			Name:    "archive-max-entries",
+			Usage:   "Entries allowed in one collection archive (default: 100000)",
+			EnvVars: []string{"GO_GALAXY_ARCHIVE_MAX_ENTRIES"},
+		},
+		&cli.IntFlag{
+			Name:    "archive-max-depth",
+			Usage:   "Path components allowed in one archive entry (default: 64)",
+			EnvVars: []string{"GO_GALAXY_ARCHIVE_MAX_DEPTH"},
+		},
 		&cli.BoolFlag{
 			Name:    "no-cache",
 			Usage:   "Disable local caching",
@@ -89,12 +298,47 @@ func CollectionFlags() []cli.Flag {
 			Usage:   "Clear local cache before installing",
 			EnvVars: []string{"GO_GALAXY_CLEAR_CACHE"},
 		},
+		&cli.StringSliceFlag{
+			Name:    "bust",
+			Usage:   "Clear cached metadata and artifacts of one collection (namespace.name) before installing, repeatable",
+			EnvVars: []string{"GO_GALAXY_BUST"},
+		},
 		&cli.BoolFlag{
 			Name:    "no-deps",
 			Usage:   "Do not install dependencies",
 			EnvVars: []string{"GO_GALAXY_NO_DEPS"},
 		},
-	}
+		&cli.StringFlag{
+			Name:    "lockfile",
+			Usage:   "With --no-deps, install the requirements and their dependencies at the versions and edges recorded in this install-manifest.json",
+			EnvVars: []string{"GO_GALAXY_LOCKFILE"},
+		},
+		&cli.BoolFlag{
+			Name:    "frozen",
+			Usage:   "Fail if resolution picks any version not in the stored resolved snapshot",
+			EnvVars: []string{"GO_GALAXY_FROZEN"},
+		},
+		&cli.BoolFlag{
+			Name:    "no-retry",
+			Usage:   "Do not retry failed collections at the end of the run",
+			EnvVars: []string{"GO_GALAXY_NO_RETRY"},
+		},
+		&cli.BoolFlag{
+			Name:    "cache-soft-fail",
+			Usage:   "Continue with a temporary local cache when the cache backend is unavailable",
+			EnvVars: []string{"GO_GALAXY_CACHE_SOFT_FAIL"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "only",
+			Usage:   "Install only requirements matching a glob (e.g. mycorp.*), repeatable",
+			EnvVars: []string{"GO_GALAXY_ONLY"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "skip",
+			Usage:   "Skip requirements matching a glob, repeatable",
+			EnvVars: []string{"GO_GALAXY_SKIP"},
+		},
+	}...)
 }
 
 // S3Flags defines CLI flags for S3 cache configuration.
@@ -135,6 +379,17 @@ func S3Flags() []cli.Flag {
 			Usage:   "S3 session token for caching",
 			EnvVars: []string{"GO_GALAXY_S3_SESSION_TOKEN", "AWS_SESSION_TOKEN"},
 		},
+		&cli.IntFlag{
+			Name:    "s3-max-idle-conns-per-host",
+			Usage:   "Idle connections kept per S3 host",
+			Value:   64,
+			EnvVars: []string{"GO_GALAXY_S3_MAX_IDLE_CONNS_PER_HOST"},
+		},
+		&cli.IntFlag{
+			Name:    "s3-max-conns-per-host",
+			Usage:   "Connections per S3 host, 0 for no limit",
+			EnvVars: []string{"GO_GALAXY_S3_MAX_CONNS_PER_HOST"},
+		},
 		&cli.BoolFlag{
 			Name:    "s3-path-style-disabled",
 			Usage:   "Path style addressing for S3",
@@ -142,3 +397,435 @@ func S3Flags() []cli.Flag {
 		},
 	}
 }
+
+// StoreDumpFlags defines CLI flags for store dump output.
+func StoreDumpFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "bucket",
+			Usage: "Store bucket to dump (meta, resolved, graph, installed, requirements, roots, api_cache, deps_cache, versions_cache), all if not set",
+		},
+		&cli.StringFlag{
+			Name:  "prefix",
+			Usage: "Dump only keys with the given prefix",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: json or yaml",
+			Value: "json",
+		},
+	}
+}
+
+// CacheStatsFlags defines CLI flags for cache stats output.
+func CacheStatsFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: text, json or yaml",
+			Value: "text",
+		},
+	}
+}
+
+// VerifyFlags defines CLI flags for the verify command.
+func VerifyFlags() []cli.Flag {
+	return []cli.Flag{
+		downloadPathFlag(),
+		&cli.StringFlag{
+			Name:  "manifest",
+			Usage: "Install manifest to verify against (default: <download-path>/install-manifest.json)",
+		},
+	}
+}
+
+// ExportOCIFlags defines CLI flags for the export-oci command.
+func ExportOCIFlags() []cli.Flag {
+	return []cli.Flag{
+		downloadPathFlag(),
+		&cli.StringFlag{
+			Name:     "tag",
+			Usage:    "Image reference to push, e.g. registry.example.com/ee-collections:sha",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "prefix",
+			Usage: "Directory inside the image that receives ansible_collections",
+			Value: defaultOCIPrefix,
+		},
+		&cli.StringFlag{
+			Name:  "platform",
+			Usage: "Image platform as os/arch",
+			Value: defaultOCIPlatform,
+		},
+		&cli.StringFlag{
+			Name:    "registry-username",
+			Usage:   "Registry username",
+			EnvVars: []string{"GO_GALAXY_REGISTRY_USERNAME"},
+		},
+		&cli.StringFlag{
+			Name:    "registry-password",
+			Usage:   "Registry password or token",
+			EnvVars: []string{"GO_GALAXY_REGISTRY_PASSWORD"},
+		},
+		&cli.BoolFlag{
+			Name:  "plain-http",
+			Usage: "Talk to the registry over HTTP instead of HTTPS",
+		},
+	}
+}
+
+// downloadPathFlag defines the collections path flag shared by install and verify.
+func downloadPathFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "download-path",
+		Aliases: []string{"p"},
+		Usage:   "Path to download collections to (default: $ANSIBLE_HOME/collections or .collections)",
+		EnvVars: []string{"GO_GALAXY_COLLECTIONS_PATH", "ANSIBLE_COLLECTIONS_PATH"},
+	}
+}
+
+// SearchFlags defines CLI flags for the search command.
+func SearchFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "Maximum number of results",
+			Value: defaultSearchLimit,
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: text, json or yaml",
+			Value: "text",
+		},
+	}
+}
+
+// ConformanceFlags defines CLI flags for the conformance command.
+func ConformanceFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "corpus",
+			Usage: "Directory of conformance cases to run instead of the built-in corpus",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: text, json or yaml",
+			Value: "text",
+		},
+	}
+}
+
+// DownloadFlags defines CLI flags for the download command.
+func DownloadFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "from-lock",
+			Usage:    "Install manifest or pinned requirements file to cache every collection of (repeatable)",
+			Required: true,
+		},
+		&cli.IntFlag{
+			Name:    "workers",
+			Usage:   "Number of concurrent downloads",
+			Value:   runtime.NumCPU(),
+			EnvVars: []string{"GO_GALAXY_WORKERS"},
+		},
+	}
+}
+
+// PreflightFlags defines CLI flags for the preflight command.
+func PreflightFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "offline-target",
+			Usage: "Check that the cache backend holds everything an offline install of the lockfile needs",
+		},
+		&cli.StringFlag{
+			Name:     "from-lock",
+			Usage:    "Install manifest the offline install will pass as --lockfile",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:    "requirements-file",
+			Aliases: []string{"r"},
+			Usage:   "Path to requirements.yml file, or - to read it from stdin",
+			Value:   defaultRequirementsFilePath,
+			EnvVars: []string{"GO_GALAXY_REQUIREMENTS_FILE", "ANSIBLE_GALAXY_REQUIREMENTS_FILE"},
+		},
+		&cli.StringFlag{
+			Name:    "requirements-inline",
+			Usage:   "Requirements YAML given directly, instead of a requirements file",
+			EnvVars: []string{"GO_GALAXY_REQUIREMENTS_INLINE"},
+		},
+		&cli.IntFlag{
+			Name:    "workers",
+			Usage:   "Number of concurrent artifact checks",
+			Value:   runtime.NumCPU(),
+			EnvVars: []string{"GO_GALAXY_WORKERS"},
+		},
+	}
+}
+
+// GenCacheFlags defines CLI flags for the devtools gen-cache command.
+func GenCacheFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:  "collections",
+			Usage: "Number of collections to generate",
+			Value: 100,
+		},
+		&cli.IntFlag{
+			Name:  "versions",
+			Usage: "Number of versions per collection",
+			Value: 10,
+		},
+		&cli.IntFlag{
+			Name:  "deps",
+			Usage: "Number of earlier collections each collection depends on",
+			Value: 3,
+		},
+		&cli.Uint64Flag{
+			Name:  "seed",
+			Usage: "Seed for the dependency graph; the same seed generates the same cache",
+			Value: 1,
+		},
+		&cli.StringFlag{
+			Name:  "requirements-out",
+			Usage: "Write a requirements file naming every generated collection to this path",
+		},
+		&cli.IntFlag{
+			Name:    "workers",
+			Usage:   "Number of collections generated concurrently",
+			Value:   runtime.NumCPU(),
+			EnvVars: []string{"GO_GALAXY_WORKERS"},
+		},
+	}
+}
+
+// InfoFlags defines CLI flags for the info command.
+func InfoFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:  "versions",
+			Usage: "Number of newest versions to list (0 lists all)",
+			Value: defaultInfoVersions,
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: text, json or yaml",
+			Value: "text",
+		},
+	}
+}
+
+// CleanupFlags defines CLI flags specific to the cleanup command.
+func CleanupFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "project",
+			Usage:   "Only clean up the recorded project at this path or with this requirements file",
+			EnvVars: []string{"GO_GALAXY_CLEANUP_PROJECT"},
+		},
+		projectTTLFlag(),
+		&cli.StringFlag{
+			Name:    "quarantine-dir",
+			Usage:   "Move removed collections into a dated folder under this directory instead of deleting them",
+			EnvVars: []string{"GO_GALAXY_QUARANTINE_DIR"},
+		},
+		&cli.IntFlag{
+			Name:    "quarantine-days",
+			Usage:   "Purge quarantine folders older than this many days",
+			Value:   7,
+			EnvVars: []string{"GO_GALAXY_QUARANTINE_DAYS"},
+		},
+	}
+}
+
+// CacheProjectsFlags defines CLI flags for the cache projects report.
+func CacheProjectsFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "owner",
+			Usage: "Only projects whose owner repository, user or user@host matches this glob",
+		},
+		projectTTLFlag(),
+		&cli.BoolFlag{
+			Name:  "stale",
+			Usage: "Only projects that have not run within --project-ttl",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: text, json or yaml",
+			Value: "text",
+		},
+	}
+}
+
+func projectTTLFlag() cli.Flag {
+	return &cli.DurationFlag{
+		Name:    "project-ttl",
+		Usage:   "Treat projects that have not run for this long as stale (e.g. 720h); 0 keeps every project",
+		EnvVars: []string{"GO_GALAXY_PROJECT_TTL"},
+	}
+}
+
+// ListFlags defines CLI flags for the list command.
+func ListFlags() []cli.Flag {
+	return []cli.Flag{
+		downloadPathFlag(),
+		&cli.BoolFlag{
+			Name:  "remote",
+			Usage: "Check every installed version against its server and fail if any is no longer published",
+		},
+		&cli.BoolFlag{
+			Name:  "licenses",
+			Usage: "Report the licenses declared in each installed MANIFEST.json: counts per license, collections without one, and every collection",
+		},
+		&cli.IntFlag{
+			Name:    "workers",
+			Usage:   "Number of concurrent server checks",
+			Value:   runtime.NumCPU(),
+			EnvVars: []string{"GO_GALAXY_WORKERS"},
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: text, json or yaml; csv with --licenses",
+			Value: "text",
+		},
+	}
+}
+
+// RequirementsMigrateFlags defines CLI flags for the requirements migrate command.
+func RequirementsMigrateFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Write the requirements.yml to this file instead of stdout",
+		},
+	}
+}
+
+// ResolveVersionFlags defines CLI flags for the resolve-version command.
+func ResolveVersionFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "Also list every version satisfying the constraint",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: json or yaml",
+			Value: "json",
+		},
+	}
+}
+
+// InstallFlags defines CLI flags specific to the install command.
+func InstallFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "bundle",
+			Usage:   "Write the resolved ansible_collections tree and manifest to this tar.gz instead of the download path",
+			EnvVars: []string{"GO_GALAXY_BUNDLE"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "artifact",
+			Usage:   "Install this collection tarball instead of downloading it (repeatable); dependencies still resolve from the server",
+			EnvVars: []string{"GO_GALAXY_ARTIFACT"},
+		},
+		&cli.StringFlag{
+			Name:    "trace",
+			Usage:   "Write every resolver decision to this file as JSON lines: constraints per collection, candidate versions, cache hits and picks",
+			EnvVars: []string{"GO_GALAXY_TRACE"},
+		},
+		&cli.StringFlag{
+			Name:    "profile",
+			Usage:   "Write CPU and heap pprof profiles of the snapshot load, resolve, extract and snapshot save phases into this directory",
+			EnvVars: []string{"GO_GALAXY_PROFILE"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "target-ansible-core",
+			Usage:   "ansible-core version every collection's requires_ansible must allow (repeatable); recorded in the install manifest",
+			EnvVars: []string{"GO_GALAXY_TARGET_ANSIBLE_CORE"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "target-python",
+			Usage:   "Python version the install targets (repeatable); recorded in the install manifest",
+			EnvVars: []string{"GO_GALAXY_TARGET_PYTHON"},
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "How to report the requirements hash and resolution digest at the end: text, or json on stdout",
+			Value:   "text",
+			EnvVars: []string{"GO_GALAXY_OUTPUT"},
+		},
+	}
+}
+
+// DaemonFlags defines CLI flags for the daemon API server.
+func DaemonFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "listen",
+			Usage:   "Address for the daemon HTTP API",
+			Value:   defaultDaemonListen,
+			EnvVars: []string{"GO_GALAXY_DAEMON_LISTEN"},
+		},
+		&cli.DurationFlag{
+			Name:    "watch-interval",
+			Usage:   "How often to check the requirements file and persist the store",
+			Value:   defaultDaemonWatchInterval,
+			EnvVars: []string{"GO_GALAXY_DAEMON_WATCH_INTERVAL"},
+		},
+		&cli.StringFlag{
+			Name:    "proxy-listen",
+			Usage:   "Also serve a read-through Galaxy metadata proxy backed by the API cache on this address (off when empty)",
+			EnvVars: []string{"GO_GALAXY_DAEMON_PROXY_LISTEN"},
+		},
+		&cli.StringFlag{
+			Name:    "token",
+			Usage:   "Bearer token every daemon API request must carry (required unless --listen is a loopback address)",
+			EnvVars: []string{"GO_GALAXY_DAEMON_TOKEN"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "allow-path",
+			Usage:   "Directory that requirements_file and download_path overrides may point into (repeatable; overrides are refused without one)",
+			EnvVars: []string{"GO_GALAXY_DAEMON_ALLOW_PATH"},
+		},
+	}
+}
+
+// LockFlags defines CLI flags for the Kubernetes Lease cache lock.
+func LockFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "lock-lease",
+			Usage:   "Kubernetes Lease name to use as the cache lock instead of the backend lock",
+			EnvVars: []string{"GO_GALAXY_LOCK_LEASE"},
+		},
+		&cli.StringFlag{
+			Name:    "lock-lease-namespace",
+			Usage:   "Kubernetes namespace of the lock Lease (defaults to the pod namespace)",
+			EnvVars: []string{"GO_GALAXY_LOCK_LEASE_NAMESPACE"},
+		},
+		&cli.DurationFlag{
+			Name:    "lock-lease-duration",
+			Usage:   "Lease duration; the holder renews it every third of the duration",
+			Value:   defaultLeaseDuration,
+			EnvVars: []string{"GO_GALAXY_LOCK_LEASE_DURATION"},
+		},
+	}
+}
+
+// exportOutputStyle validates --output-style and exports it, so that every
+// printer, including those used before a command builds its config, follows it.
+func exportOutputStyle(_ *cli.Context, value string) error {
+	style := strings.ToLower(strings.TrimSpace(value))
+	switch style {
+	case progress.StyleEmoji, progress.StylePlain:
+		return os.Setenv(progress.StyleEnv, style)
+	default:
+		return fmt.Errorf("%w: %q (use %s or %s)", galaxyHelpers.ErrInvalidOutputStyle, value, progress.StyleEmoji, progress.StylePlain)
+	}
+}
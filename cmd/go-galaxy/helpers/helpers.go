@@ -5,8 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
 	"runtime"
 	"time"
 )
@@ -66,12 +64,3 @@ func latestTag() string {
 	}
 	return payload.Tag
 }
-
-// defaultCacheDir returns the default cache directory path.
-func defaultCacheDir() string {
-	home, err := os.UserHomeDir()
-	if err != nil || home == "" {
-		return filepath.Join(defaultHomeDir, dirSuffix)
-	}
-	return filepath.Join(home, dirSuffix)
-}
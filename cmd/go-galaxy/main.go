@@ -2,12 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/greeddj/go-galaxy/cmd/go-galaxy/commands"
 	"github.com/greeddj/go-galaxy/cmd/go-galaxy/helpers"
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	galaxyHelpers "github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/progress"
 	"github.com/urfave/cli/v2"
 )
 
@@ -33,15 +37,40 @@ func run() int {
 	app.DefaultCommand = "install"
 	app.HideHelpCommand = true
 	app.UseShortOptionHandling = true
+	app.EnableBashCompletion = true
 	app.Commands = []*cli.Command{
 		commands.Install(),
+		commands.Download(),
+		commands.Preflight(),
 		commands.Cleanup(),
+		commands.Store(),
+		commands.Cache(),
+		commands.Daemon(),
+		commands.Search(),
+		commands.Info(),
+		commands.List(),
+		commands.ResolveVersion(),
+		commands.Datasource(),
+		commands.Verify(),
+		commands.ExportOCI(),
+		commands.Conformance(),
+		commands.Requirements(),
+		commands.Devtools(),
+		commands.Completion(),
 	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	defer stop()
+	ctx, stopGuard := cacheManager.WithLockGuard(ctx)
+	defer stopGuard()
 
 	if err := app.RunContext(ctx, os.Args); err != nil {
+		// A lost cache lock cancels the run; report why instead of the cancellation.
+		if cause := context.Cause(ctx); errors.Is(cause, galaxyHelpers.ErrLockLost) {
+			progress.Errorf("Error: %s", cause)
+			err = cause
+		}
+		progress.Hints(galaxyHelpers.Hints(err))
 		return 1
 	}
 	return 0
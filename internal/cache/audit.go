@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// auditedBackend records mutating operations to the backend's audit journal.
+// Journal failures never fail the audited operation.
+type auditedBackend struct {
+	cacheManager.Backend
+
+	sink cacheManager.AuditSink
+}
+
+// withAudit wraps b when it can persist audit events.
+func withAudit(b cacheManager.Backend) cacheManager.Backend {
+	sink, ok := b.(cacheManager.AuditSink)
+	if !ok {
+		return b
+	}
+	return &auditedBackend{Backend: b, sink: sink}
+}
+
+// SaveStore persists the store and records the save.
+func (b *auditedBackend) SaveStore(ctx context.Context, st *store.Store) error {
+	if err := b.Backend.SaveStore(ctx, st); err != nil {
+		return err
+	}
+	_ = b.sink.AppendAudit(ctx, cacheManager.NewAuditEvent(cacheManager.AuditStoreSave, "", ""))
+	return nil
+}
+
+// ClearFiles removes cached artifacts and records the clear.
+func (b *auditedBackend) ClearFiles(ctx context.Context) error {
+	if err := b.Backend.ClearFiles(ctx); err != nil {
+		return err
+	}
+	_ = b.sink.AppendAudit(ctx, cacheManager.NewAuditEvent(cacheManager.AuditCacheClear, "", ""))
+	return nil
+}
+
+// Artifacts returns an artifact store that records commits and deletions. It
+// lists artifacts only when the wrapped store can.
+func (b *auditedBackend) Artifacts() cacheManager.ArtifactStore {
+	artifacts := b.Backend.Artifacts()
+	if artifacts == nil {
+		return nil
+	}
+	audited := &auditedArtifacts{ArtifactStore: artifacts, sink: b.sink}
+	if lister, ok := artifacts.(cacheManager.ArtifactLister); ok {
+		return &listingAuditedArtifacts{auditedArtifacts: audited, lister: lister}
+	}
+	return audited
+}
+
+// AppendAudit exposes the wrapped sink.
+func (b *auditedBackend) AppendAudit(ctx context.Context, event cacheManager.AuditEvent) error {
+	return b.sink.AppendAudit(ctx, event)
+}
+
+// LoadStoreWith forwards to the wrapped backend when it can load part of the store.
+func (b *auditedBackend) LoadStoreWith(ctx context.Context, opts store.LoadOptions) (*store.Store, error) {
+	return cacheManager.LoadStoreWith(ctx, b.Backend, opts)
+}
+
+// LoadResolution forwards to the wrapped backend when it keeps resolutions.
+func (b *auditedBackend) LoadResolution(ctx context.Context, reqHash string) (*store.Resolution, error) {
+	return cacheManager.LoadResolution(ctx, b.Backend, reqHash)
+}
+
+// SaveResolution forwards to the wrapped backend when it keeps resolutions.
+func (b *auditedBackend) SaveResolution(ctx context.Context, res *store.Resolution) error {
+	return cacheManager.SaveResolution(ctx, b.Backend, res)
+}
+
+// auditedArtifacts records artifact commits and deletions.
+type auditedArtifacts struct {
+	cacheManager.ArtifactStore
+
+	sink cacheManager.AuditSink
+}
+
+// Commit stores the artifact and records its key and hash.
+func (s *auditedArtifacts) Commit(ctx context.Context, key, tmpPath string, meta map[string]string) (cacheManager.ArtifactFile, error) {
+	file, err := s.ArtifactStore.Commit(ctx, key, tmpPath, meta)
+	if err != nil {
+		return file, err
+	}
+	_ = s.sink.AppendAudit(ctx, cacheManager.NewAuditEvent(cacheManager.AuditArtifactCommit, key, meta["sha256"]))
+	return file, nil
+}
+
+// Delete removes the artifact and records the deletion.
+func (s *auditedArtifacts) Delete(ctx context.Context, key string) error {
+	if err := s.ArtifactStore.Delete(ctx, key); err != nil {
+		return err
+	}
+	_ = s.sink.AppendAudit(ctx, cacheManager.NewAuditEvent(cacheManager.AuditArtifactDelete, key, ""))
+	return nil
+}
+
+// listingAuditedArtifacts is an auditedArtifacts over a store that can list.
+type listingAuditedArtifacts struct {
+	*auditedArtifacts
+
+	lister cacheManager.ArtifactLister
+}
+
+// List forwards to the wrapped store.
+func (s *listingAuditedArtifacts) List(ctx context.Context) ([]string, error) {
+	return s.lister.List(ctx)
+}
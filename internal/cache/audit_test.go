@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/cache/local"
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+func TestAuditedBackendJournal(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	backend := withAudit(local.New(dir, nil))
+	if err := backend.Open(ctx); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = backend.Close(ctx)
+	})
+
+	artifacts := backend.Artifacts()
+	tmp, _, err := artifacts.TempFile(ctx, ".download-")
+	if err != nil {
+		t.Fatalf("TempFile error: %v", err)
+	}
+	_ = tmp.Close()
+	if _, err := artifacts.Commit(ctx, "a-b-1.0.0.tar.gz", tmp.Name(), map[string]string{"sha256": "abc"}); err != nil {
+		t.Fatalf("Commit error: %v", err)
+	}
+	if err := artifacts.Delete(ctx, "a-b-1.0.0.tar.gz"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if err := backend.SaveStore(ctx, store.New()); err != nil {
+		t.Fatalf("SaveStore error: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, helpers.StoreAuditLog))
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var ops []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event cacheManager.AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		if event.PID != os.Getpid() || event.Time.IsZero() {
+			t.Fatalf("event missing identity: %+v", event)
+		}
+		ops = append(ops, event.Op)
+	}
+	want := []string{cacheManager.AuditArtifactCommit, cacheManager.AuditArtifactDelete, cacheManager.AuditStoreSave}
+	if len(ops) != len(want) {
+		t.Fatalf("ops = %v, want %v", ops, want)
+	}
+	for i := range want {
+		if ops[i] != want[i] {
+			t.Fatalf("ops = %v, want %v", ops, want)
+		}
+	}
+}
+
+func TestNewForwardsPartialLoads(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	cfg := &config.Config{CacheDir: t.TempDir()}
+	const url = "https://galaxy.example/api/v3/collections/a/b/"
+
+	backend, err := New(cfg, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := backend.Open(ctx); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	st := store.New()
+	cacheManager.SeedAPICache(st, url, []byte(`{}`))
+	st.SetVersionsCache(url+"versions/", []string{"1.0.0"})
+	if err := backend.SaveStore(ctx, st); err != nil {
+		t.Fatalf("SaveStore: %v", err)
+	}
+	if err := backend.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for _, migrateFrom := range []string{"", mirrorBackend} {
+		cfg := *cfg
+		cfg.CacheMigrateFrom = migrateFrom
+		backend, err := New(&cfg, nil)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		if _, ok := backend.(cacheManager.PartialLoader); !ok {
+			t.Fatalf("backend %T (migrate from %q) does not forward LoadStoreWith", backend, migrateFrom)
+		}
+		if err := backend.Open(ctx); err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		lazy, err := cacheManager.LoadStoreWith(ctx, backend, store.LoadOptions{LazyAPICache: true})
+		if err != nil {
+			t.Fatalf("LoadStoreWith: %v", err)
+		}
+		if len(lazy.APICache) != 0 {
+			t.Fatalf("API cache loaded eagerly through %T: %d entries", backend, len(lazy.APICache))
+		}
+		if _, ok := cacheManager.CachedAPIBody(lazy, url); !ok {
+			t.Fatalf("lazy API cache entry missing through %T", backend)
+		}
+		if err := backend.Close(ctx); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+}
+
+// opaqueBackend is a local backend whose artifact store cannot list, the
+// way a plugin's cannot.
+type opaqueBackend struct {
+	*local.Backend
+}
+
+func (b opaqueBackend) Artifacts() cacheManager.ArtifactStore {
+	return struct{ cacheManager.ArtifactStore }{b.Backend.Artifacts()}
+}
+
+func TestAuditedArtifactsListOnlyWhenWrappedStoreCan(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	opaque := withAudit(opaqueBackend{Backend: local.New(t.TempDir(), nil)})
+	if _, ok := opaque.(*auditedBackend); !ok {
+		t.Fatalf("expected the backend to be audited, got %T", opaque)
+	}
+	if _, ok := opaque.Artifacts().(cacheManager.ArtifactLister); ok {
+		t.Fatalf("audited artifacts of a non-listing store claim to list")
+	}
+
+	backend := withAudit(local.New(t.TempDir(), nil))
+	if err := backend.Open(ctx); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = backend.Close(ctx)
+	})
+	artifacts := backend.Artifacts()
+	tmp, _, err := artifacts.TempFile(ctx, ".download-")
+	if err != nil {
+		t.Fatalf("TempFile error: %v", err)
+	}
+	_ = tmp.Close()
+	if _, err := artifacts.Commit(ctx, "a-b-1.0.0.tar.gz", tmp.Name(), nil); err != nil {
+		t.Fatalf("Commit error: %v", err)
+	}
+	lister, ok := artifacts.(cacheManager.ArtifactLister)
+	if !ok {
+		t.Fatalf("audited artifacts of a listing store do not list")
+	}
+	keys, err := lister.List(ctx)
+	if err != nil || len(keys) != 1 || keys[0] != "a-b-1.0.0.tar.gz" {
+		t.Fatalf("List = %v, %v", keys, err)
+	}
+}
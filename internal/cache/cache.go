@@ -1,18 +1,30 @@
 package cache
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 
+	"github.com/greeddj/go-galaxy/internal/cache/lease"
 	"github.com/greeddj/go-galaxy/internal/cache/local"
+	"github.com/greeddj/go-galaxy/internal/cache/plugin"
 	"github.com/greeddj/go-galaxy/internal/cache/s3"
 	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
 	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/crypt"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
 	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
 )
 
 var (
 	errConfigNil     = errors.New("config is nil")
 	errHTTPClientNil = errors.New("http client is nil")
+
+	errArtifactListUnsupported = errors.New("artifact store cannot list artifacts")
 )
 
 // New selects and constructs a cache backend based on configuration.
@@ -20,15 +32,140 @@ func New(cfg *config.Config, runtime *infra.Infra) (cacheManager.Backend, error)
 	if cfg == nil {
 		return nil, errConfigNil
 	}
-	if cfg.S3Cache.Enabled {
-		if runtime == nil || runtime.HTTP == nil {
-			return nil, errHTTPClientNil
+	backend, err := newBackend(cfg, runtime)
+	if err != nil {
+		return nil, err
+	}
+	backend = withAudit(withChaos(backend, cfg.CacheChaos))
+	if cfg.CacheMigrateFrom != "" {
+		if backend, err = newMigratingBackend(cfg, runtime, backend); err != nil {
+			return nil, err
+		}
+	}
+	if !cfg.LeaseLock.Enabled {
+		return backend, nil
+	}
+	locker, err := lease.New(cfg.LeaseLock)
+	if err != nil {
+		return nil, err
+	}
+	return lease.Wrap(backend, locker), nil
+}
+
+// listArtifacts enumerates the keys of s when it can list them.
+func listArtifacts(ctx context.Context, s cacheManager.ArtifactStore) ([]string, error) {
+	lister, ok := s.(cacheManager.ArtifactLister)
+	if !ok {
+		return nil, errArtifactListUnsupported
+	}
+	return lister.List(ctx)
+}
+
+// newBackend constructs the storage backend without lock overrides.
+func newBackend(cfg *config.Config, runtime *infra.Infra) (cacheManager.Backend, error) {
+	name := backendName(cfg)
+	if len(cfg.CacheEncryptionKey) > 0 && !isLocalBackend(name) {
+		return nil, helpers.ErrCacheEncryptionUnsupported
+	}
+	if path, ok := strings.CutPrefix(name, execBackendPrefix); ok {
+		tempDir, err := stagingDir(cfg, runtime)
+		if err != nil {
+			return nil, err
 		}
-		tempDir := ""
-		if runtime.TempDir != nil {
-			tempDir = runtime.TempDir()
+		return plugin.New(path, cfg.CacheNamespace, tempDir)
+	}
+	factory, ok := lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q (registered: %s)", helpers.ErrUnknownCacheBackend, name, strings.Join(Registered(), ", "))
+	}
+	return factory(cfg, runtime)
+}
+
+// backendName returns the configured backend, defaulting to s3 when a bucket is set.
+func backendName(cfg *config.Config) string {
+	if cfg.CacheBackend != "" {
+		return cfg.CacheBackend
+	}
+	if cfg.S3Cache.Enabled {
+		return BackendS3
+	}
+	return BackendLocal
+}
+
+// newS3Backend builds the built-in S3 backend.
+func newS3Backend(cfg *config.Config, runtime *infra.Infra) (cacheManager.Backend, error) {
+	if runtime == nil || runtime.HTTP == nil {
+		return nil, errHTTPClientNil
+	}
+	tempDir, err := stagingDir(cfg, runtime)
+	if err != nil {
+		return nil, err
+	}
+	s3Cfg := cfg.S3Cache
+	s3Cfg.Prefix = namespacedPrefix(s3Cfg.Prefix, cfg.CacheNamespace)
+	client := runtime.HTTP
+	if runtime.S3HTTP != nil {
+		client = runtime.S3HTTP
+	}
+	return s3.New(s3Cfg, client, tempDir)
+}
+
+// stagingDir returns the directory remote backends stage artifacts in.
+// An explicit --tmp-dir wins over the runtime temp directory.
+func stagingDir(cfg *config.Config, runtime *infra.Infra) (string, error) {
+	if cfg.TempDir != "" {
+		if err := os.MkdirAll(cfg.TempDir, helpers.DirMod); err != nil {
+			return "", fmt.Errorf("create temp dir: %w", err)
 		}
-		return s3.New(cfg.S3Cache, runtime.HTTP, tempDir)
+		return cfg.TempDir, nil
+	}
+	if runtime != nil && runtime.TempDir != nil {
+		return runtime.TempDir(), nil
+	}
+	return "", nil
+}
+
+// newLocalBackend builds the built-in filesystem backend.
+func newLocalBackend(cfg *config.Config, _ *infra.Infra) (cacheManager.Backend, error) {
+	cipher, err := crypt.New(cfg.CacheEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return local.New(namespacedCacheDir(cfg.CacheDir, cfg.CacheNamespace), cipher), nil
+}
+
+// newLocalJSONBackend builds the filesystem backend with a single-file store.
+func newLocalJSONBackend(cfg *config.Config, _ *infra.Infra) (cacheManager.Backend, error) {
+	cipher, err := crypt.New(cfg.CacheEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return local.NewJSON(namespacedCacheDir(cfg.CacheDir, cfg.CacheNamespace), cipher), nil
+}
+
+// isLocalBackend reports whether name is one of the built-in filesystem backends.
+func isLocalBackend(name string) bool {
+	return name == BackendLocal || name == BackendLocalJSON
+}
+
+// LocalDir returns the local cache directory of cfg's cache namespace, where
+// files kept beside the cache, such as collections built from git, belong.
+func LocalDir(cfg *config.Config) string {
+	return namespacedCacheDir(cfg.CacheDir, cfg.CacheNamespace)
+}
+
+// namespacedCacheDir returns the local cache directory for a namespace.
+func namespacedCacheDir(cacheDir, namespace string) string {
+	if namespace == "" || cacheDir == "" {
+		return cacheDir
+	}
+	return filepath.Join(cacheDir, helpers.StoreNamespacesDir, namespace)
+}
+
+// namespacedPrefix returns the S3 key prefix for a namespace.
+func namespacedPrefix(prefix, namespace string) string {
+	if namespace == "" {
+		return prefix
 	}
-	return local.New(cfg.CacheDir), nil
+	return path.Join(strings.Trim(prefix, "/"), helpers.StoreNamespacesDir, namespace)
 }
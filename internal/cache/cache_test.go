@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+)
+
+func TestNamespacedLayout(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		namespace string
+		cacheDir  string
+		prefix    string
+		wantDir   string
+		wantKey   string
+	}{
+		{namespace: "", cacheDir: "/var/cache/go-galaxy", prefix: "galaxy/", wantDir: "/var/cache/go-galaxy", wantKey: "galaxy/"},
+		{namespace: "team-a", cacheDir: "/var/cache/go-galaxy", prefix: "galaxy/",
+			wantDir: "/var/cache/go-galaxy/namespaces/team-a", wantKey: "galaxy/namespaces/team-a"},
+		{namespace: "CI_2.x", cacheDir: "/var/cache/go-galaxy", prefix: "/galaxy/cache/",
+			wantDir: "/var/cache/go-galaxy/namespaces/CI_2.x", wantKey: "galaxy/cache/namespaces/CI_2.x"},
+		{namespace: "team-a", cacheDir: "/var/cache/go-galaxy", prefix: "", wantDir: "/var/cache/go-galaxy/namespaces/team-a",
+			wantKey: "namespaces/team-a"},
+		{namespace: "team-a", cacheDir: "", prefix: "", wantDir: "", wantKey: "namespaces/team-a"},
+	}
+	for _, tc := range cases {
+		wantDir := filepath.FromSlash(tc.wantDir)
+		if got := namespacedCacheDir(filepath.FromSlash(tc.cacheDir), tc.namespace); got != wantDir {
+			t.Fatalf("namespacedCacheDir(%q, %q) = %q, want %q", tc.cacheDir, tc.namespace, got, wantDir)
+		}
+		cfg := &config.Config{CacheDir: filepath.FromSlash(tc.cacheDir), CacheNamespace: tc.namespace}
+		if got := LocalDir(cfg); got != wantDir {
+			t.Fatalf("LocalDir(%q, %q) = %q, want %q", tc.cacheDir, tc.namespace, got, wantDir)
+		}
+		if got := namespacedPrefix(tc.prefix, tc.namespace); got != tc.wantKey {
+			t.Fatalf("namespacedPrefix(%q, %q) = %q, want %q", tc.prefix, tc.namespace, got, tc.wantKey)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"context"
+	"math/rand/v2"
+	"os"
+	"sync"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// chaos draws which cache calls fail. It is shared by a backend and its
+// artifact store, so one seed gives one sequence across both.
+type chaos struct {
+	rate float64
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// fail returns helpers.ErrChaosInjected for the share of calls the rate selects.
+func (c *chaos) fail() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rng.Float64() < c.rate {
+		return helpers.ErrChaosInjected
+	}
+	return nil
+}
+
+// chaosBackend fails a share of backend calls before they reach the wrapped
+// backend, whatever it stores to. Close and the audit journal are never failed.
+type chaosBackend struct {
+	cacheManager.Backend
+
+	chaos *chaos
+}
+
+// withChaos wraps b when cfg injects cache errors.
+func withChaos(b cacheManager.Backend, cfg config.ChaosConfig) cacheManager.Backend {
+	if cfg.ErrorRate <= 0 {
+		return b
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		//nolint:gosec // picks which calls fail, not security sensitive.
+		seed = rand.Uint64()
+	}
+	//nolint:gosec // a seeded generator keeps injected failures repeatable.
+	return &chaosBackend{Backend: b, chaos: &chaos{rate: cfg.ErrorRate, rng: rand.New(rand.NewPCG(seed, seed))}}
+}
+
+// Open opens the wrapped backend unless the call is failed.
+func (b *chaosBackend) Open(ctx context.Context) error {
+	if err := b.chaos.fail(); err != nil {
+		return err
+	}
+	return b.Backend.Open(ctx)
+}
+
+// Lock locks the wrapped backend unless the call is failed.
+func (b *chaosBackend) Lock(ctx context.Context) (func() error, error) {
+	if err := b.chaos.fail(); err != nil {
+		return nil, err
+	}
+	return b.Backend.Lock(ctx)
+}
+
+// LoadStore loads the store unless the call is failed.
+func (b *chaosBackend) LoadStore(ctx context.Context) (*store.Store, error) {
+	if err := b.chaos.fail(); err != nil {
+		return nil, err
+	}
+	return b.Backend.LoadStore(ctx)
+}
+
+// SaveStore saves the store unless the call is failed.
+func (b *chaosBackend) SaveStore(ctx context.Context, st *store.Store) error {
+	if err := b.chaos.fail(); err != nil {
+		return err
+	}
+	return b.Backend.SaveStore(ctx, st)
+}
+
+// ClearFiles clears cached artifacts unless the call is failed.
+func (b *chaosBackend) ClearFiles(ctx context.Context) error {
+	if err := b.chaos.fail(); err != nil {
+		return err
+	}
+	return b.Backend.ClearFiles(ctx)
+}
+
+// RecordProject records the project unless the call is failed.
+func (b *chaosBackend) RecordProject(ctx context.Context, run store.ProjectRun) error {
+	if err := b.chaos.fail(); err != nil {
+		return err
+	}
+	return b.Backend.RecordProject(ctx, run)
+}
+
+// LoadProjectRegistry loads the project registry unless the call is failed.
+func (b *chaosBackend) LoadProjectRegistry(ctx context.Context) (*store.ProjectRegistry, error) {
+	if err := b.chaos.fail(); err != nil {
+		return nil, err
+	}
+	return b.Backend.LoadProjectRegistry(ctx)
+}
+
+// Artifacts returns an artifact store that fails a share of its calls too.
+func (b *chaosBackend) Artifacts() cacheManager.ArtifactStore {
+	artifacts := b.Backend.Artifacts()
+	if artifacts == nil {
+		return nil
+	}
+	wrapped := &chaosArtifacts{ArtifactStore: artifacts, chaos: b.chaos}
+	if lister, ok := artifacts.(cacheManager.ArtifactLister); ok {
+		return &listingChaosArtifacts{chaosArtifacts: wrapped, lister: lister}
+	}
+	return wrapped
+}
+
+// AppendAudit forwards to the wrapped backend when it keeps an audit journal.
+func (b *chaosBackend) AppendAudit(ctx context.Context, event cacheManager.AuditEvent) error {
+	return cacheManager.AppendAudit(ctx, b.Backend, event)
+}
+
+// LoadStoreWith loads part of the store unless the call is failed.
+func (b *chaosBackend) LoadStoreWith(ctx context.Context, opts store.LoadOptions) (*store.Store, error) {
+	if err := b.chaos.fail(); err != nil {
+		return nil, err
+	}
+	return cacheManager.LoadStoreWith(ctx, b.Backend, opts)
+}
+
+// LoadResolution loads a shared resolution unless the call is failed.
+func (b *chaosBackend) LoadResolution(ctx context.Context, reqHash string) (*store.Resolution, error) {
+	if err := b.chaos.fail(); err != nil {
+		return nil, err
+	}
+	return cacheManager.LoadResolution(ctx, b.Backend, reqHash)
+}
+
+// SaveResolution saves a shared resolution unless the call is failed.
+func (b *chaosBackend) SaveResolution(ctx context.Context, res *store.Resolution) error {
+	if err := b.chaos.fail(); err != nil {
+		return err
+	}
+	return cacheManager.SaveResolution(ctx, b.Backend, res)
+}
+
+// chaosArtifacts fails a share of artifact store calls.
+type chaosArtifacts struct {
+	cacheManager.ArtifactStore
+
+	chaos *chaos
+}
+
+// Has checks for the artifact unless the call is failed.
+func (s *chaosArtifacts) Has(ctx context.Context, key string) (bool, error) {
+	if err := s.chaos.fail(); err != nil {
+		return false, err
+	}
+	return s.ArtifactStore.Has(ctx, key)
+}
+
+// Fetch fetches the artifact unless the call is failed.
+func (s *chaosArtifacts) Fetch(ctx context.Context, key string) (cacheManager.ArtifactFile, error) {
+	if err := s.chaos.fail(); err != nil {
+		return cacheManager.ArtifactFile{}, err
+	}
+	return s.ArtifactStore.Fetch(ctx, key)
+}
+
+// TempFile creates a staging file unless the call is failed.
+func (s *chaosArtifacts) TempFile(ctx context.Context, prefix string) (*os.File, func(), error) {
+	if err := s.chaos.fail(); err != nil {
+		return nil, nil, err
+	}
+	return s.ArtifactStore.TempFile(ctx, prefix)
+}
+
+// Commit stores the artifact unless the call is failed.
+func (s *chaosArtifacts) Commit(ctx context.Context, key, tmpPath string, meta map[string]string) (cacheManager.ArtifactFile, error) {
+	if err := s.chaos.fail(); err != nil {
+		return cacheManager.ArtifactFile{}, err
+	}
+	return s.ArtifactStore.Commit(ctx, key, tmpPath, meta)
+}
+
+// Delete removes the artifact unless the call is failed.
+func (s *chaosArtifacts) Delete(ctx context.Context, key string) error {
+	if err := s.chaos.fail(); err != nil {
+		return err
+	}
+	return s.ArtifactStore.Delete(ctx, key)
+}
+
+// listingChaosArtifacts is a chaosArtifacts over a store that can list.
+type listingChaosArtifacts struct {
+	*chaosArtifacts
+
+	lister cacheManager.ArtifactLister
+}
+
+// List lists artifacts unless the call is failed.
+func (s *listingChaosArtifacts) List(ctx context.Context) ([]string, error) {
+	if err := s.chaos.fail(); err != nil {
+		return nil, err
+	}
+	return s.lister.List(ctx)
+}
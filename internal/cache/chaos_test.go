@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/cache/local"
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+func TestChaosBackendFailsEveryCallAtFullRate(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	inner := local.New(t.TempDir(), nil)
+	if got := withChaos(inner, config.ChaosConfig{}); got != inner {
+		t.Fatalf("expected no wrapper without an error rate")
+	}
+	backend := withChaos(inner, config.ChaosConfig{ErrorRate: 1, Seed: 7})
+	if err := backend.Open(ctx); !errors.Is(err, helpers.ErrChaosInjected) {
+		t.Fatalf("expected ErrChaosInjected from Open, got %v", err)
+	}
+	if _, err := backend.LoadStore(ctx); !errors.Is(err, helpers.ErrChaosInjected) {
+		t.Fatalf("expected ErrChaosInjected from LoadStore, got %v", err)
+	}
+	if _, err := cacheManager.LoadResolution(ctx, backend, "hash"); !errors.Is(err, helpers.ErrChaosInjected) {
+		t.Fatalf("expected ErrChaosInjected from LoadResolution, got %v", err)
+	}
+	if _, err := backend.Artifacts().Has(ctx, "a-b-1.0.0.tar.gz"); !errors.Is(err, helpers.ErrChaosInjected) {
+		t.Fatalf("expected ErrChaosInjected from Has, got %v", err)
+	}
+	if _, err := listArtifacts(ctx, backend.Artifacts()); !errors.Is(err, helpers.ErrChaosInjected) {
+		t.Fatalf("expected ErrChaosInjected from List, got %v", err)
+	}
+}
+
+func TestChaosBackendIsRepeatable(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	outcomes := func() []bool {
+		backend := local.New(t.TempDir(), nil)
+		if err := backend.Open(ctx); err != nil {
+			t.Fatalf("Open error: %v", err)
+		}
+		t.Cleanup(func() {
+			_ = backend.Close(ctx)
+		})
+		artifacts := withChaos(backend, config.ChaosConfig{ErrorRate: 0.5, Seed: 42}).Artifacts()
+		out := make([]bool, 0, 20)
+		for range 20 {
+			_, err := artifacts.Has(ctx, "a-b-1.0.0.tar.gz")
+			if err != nil && !errors.Is(err, helpers.ErrChaosInjected) {
+				t.Fatalf("Has error: %v", err)
+			}
+			out = append(out, err != nil)
+		}
+		return out
+	}
+	first, second := outcomes(), outcomes()
+	failed := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("call %d: failed %v then %v with the same seed", i, first[i], second[i])
+		}
+		if first[i] {
+			failed++
+		}
+	}
+	if failed == 0 || failed == len(first) {
+		t.Fatalf("expected some but not all of %d calls to fail, got %d", len(first), failed)
+	}
+}
@@ -0,0 +1,364 @@
+package lease
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// Locker implements the cache lock with a coordination.k8s.io/v1 Lease.
+// Ownership changes use resourceVersion preconditions, so two contenders can
+// never both take over an expired lease, and a live holder keeps renewTime fresh.
+type Locker struct {
+	cfg      config.LeaseLockConfig
+	client   *http.Client
+	baseURL  string
+	token    string
+	identity string
+	onSteal  func(ctx context.Context, previousHolder string)
+}
+
+// leaseObject is the subset of the Lease resource used by the lock.
+type leaseObject struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int    `json:"leaseTransitions,omitempty"`
+}
+
+// New builds a Locker using the in-cluster service account.
+func New(cfg config.LeaseLockConfig) (*Locker, error) {
+	if cfg.Name == "" {
+		return nil, errLeaseNameEmpty
+	}
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errLeaseNotInCluster
+	}
+	token, err := os.ReadFile(filepath.Join(serviceAccountDir, tokenFile))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errLeaseNotInCluster, err)
+	}
+	if cfg.Namespace == "" {
+		ns, err := os.ReadFile(filepath.Join(serviceAccountDir, namespaceFile))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errLeaseNamespaceEmpty, err)
+		}
+		cfg.Namespace = strings.TrimSpace(string(ns))
+	}
+	if cfg.Namespace == "" {
+		return nil, errLeaseNamespaceEmpty
+	}
+	cfg.Duration = max(cfg.Duration, minLeaseDuration)
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if ca, err := os.ReadFile(filepath.Join(serviceAccountDir, caFile)); err == nil {
+		pool.AppendCertsFromPEM(ca)
+	}
+	client := &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+	}
+
+	hostname, _ := os.Hostname()
+	return &Locker{
+		cfg:      cfg,
+		client:   client,
+		baseURL:  "https://" + net.JoinHostPort(host, port),
+		token:    strings.TrimSpace(string(token)),
+		identity: fmt.Sprintf("%s/%d", hostname, os.Getpid()),
+	}, nil
+}
+
+// Lock acquires the lease and keeps renewing it until the returned release is called.
+// A lease held by another process is taken over only once it has gone a
+// full lease duration without a renewal; see awaitExpiry.
+func (l *Locker) Lock(ctx context.Context) (func() error, error) {
+	current, err := l.get(ctx)
+	if err == nil && current.Spec.HolderIdentity != "" && current.Spec.HolderIdentity != l.identity {
+		current, err = l.awaitExpiry(ctx, current)
+	}
+	now := time.Now().UTC()
+	switch {
+	case err == nil:
+		previous := current.Spec.HolderIdentity
+		if previous != l.identity {
+			current.Spec.LeaseTransitions++
+		}
+		current.Spec.HolderIdentity = l.identity
+		current.Spec.LeaseDurationSeconds = int(l.cfg.Duration / time.Second)
+		current.Spec.AcquireTime = now.Format(microTimeLayout)
+		current.Spec.RenewTime = now.Format(microTimeLayout)
+		current, err = l.put(ctx, current)
+		if err == nil && previous != "" && previous != l.identity && l.onSteal != nil {
+			l.onSteal(ctx, previous)
+		}
+	case errors.Is(err, errLeaseNotFound):
+		current, err = l.create(ctx, leaseObject{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   leaseMetadata{Name: l.cfg.Name, Namespace: l.cfg.Namespace},
+			Spec: leaseSpec{
+				HolderIdentity:       l.identity,
+				LeaseDurationSeconds: int(l.cfg.Duration / time.Second),
+				AcquireTime:          now.Format(microTimeLayout),
+				RenewTime:            now.Format(microTimeLayout),
+			},
+		})
+	}
+	if errors.Is(err, errLeaseConflict) {
+		return nil, fmt.Errorf("%w: lease %s/%s was taken concurrently", helpers.ErrAnotherInstanceIsRunning, l.cfg.Namespace, l.cfg.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l.keepAlive(ctx, current), nil
+}
+
+// awaitExpiry watches a lease held by another process and returns it once
+// the record has stayed unchanged for a lease duration, or as soon as it is
+// released. A renewal in the meantime means the holder is alive. Expiry is
+// timed on the local clock from when the record was first seen, as client-go
+// leader election does, so the holder's clock never decides it.
+func (l *Locker) awaitExpiry(ctx context.Context, current leaseObject) (leaseObject, error) {
+	duration := time.Duration(current.Spec.LeaseDurationSeconds) * time.Second
+	if duration <= 0 {
+		duration = l.cfg.Duration
+	}
+	observed := time.Now()
+	for {
+		wait := min(duration/renewDivisor, duration-time.Since(observed))
+		if wait <= 0 {
+			return current, nil
+		}
+		select {
+		case <-ctx.Done():
+			return leaseObject{}, ctx.Err()
+		case <-time.After(wait):
+		}
+		next, err := l.get(ctx)
+		if errors.Is(err, errLeaseNotFound) {
+			return leaseObject{}, errLeaseConflict
+		}
+		if err != nil {
+			return leaseObject{}, err
+		}
+		if next.Spec.HolderIdentity == "" {
+			return next, nil
+		}
+		if next.Spec != current.Spec {
+			return leaseObject{}, fmt.Errorf("%w: lease %s/%s held by %s",
+				helpers.ErrAnotherInstanceIsRunning, l.cfg.Namespace, l.cfg.Name, next.Spec.HolderIdentity)
+		}
+		current = next
+	}
+}
+
+// keepAlive renews the lease in the background and returns its release func.
+// When the lease is taken over, or renewals keep failing until it would
+// lapse before the next attempt, the lock is lost: the work guarded by ctx
+// is canceled and release reports ErrLockLost.
+func (l *Locker) keepAlive(ctx context.Context, held leaseObject) func() error {
+	// Renewal and release must outlive a canceled run context.
+	bg := context.WithoutCancel(ctx)
+	interval := l.cfg.Duration / renewDivisor
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	var (
+		mu   sync.Mutex
+		lost error
+	)
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		renewed := time.Now()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			mu.Lock()
+			next := held
+			next.Spec.RenewTime = time.Now().UTC().Format(microTimeLayout)
+			updated, err := l.put(bg, next)
+			switch {
+			case err == nil:
+				held, renewed = updated, time.Now()
+			case errors.Is(err, errLeaseConflict) || time.Since(renewed)+interval >= l.cfg.Duration:
+				lost = fmt.Errorf("%w: lease %s/%s: %w", helpers.ErrLockLost, l.cfg.Namespace, l.cfg.Name, err)
+			}
+			mu.Unlock()
+			if lost != nil {
+				cacheManager.LockLost(ctx, lost)
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	var releaseErr error
+	return func() error {
+		once.Do(func() {
+			close(stop)
+			<-done
+			mu.Lock()
+			defer mu.Unlock()
+			if lost != nil {
+				releaseErr = lost
+				return
+			}
+			held.Spec.HolderIdentity = ""
+			held.Spec.RenewTime = time.Now().UTC().Format(microTimeLayout)
+			if _, err := l.put(bg, held); err != nil && !errors.Is(err, errLeaseConflict) {
+				releaseErr = err
+			}
+		})
+		return releaseErr
+	}
+}
+
+func (l *Locker) get(ctx context.Context) (leaseObject, error) {
+	return l.do(ctx, http.MethodGet, l.leaseURL(), nil)
+}
+
+func (l *Locker) create(ctx context.Context, obj leaseObject) (leaseObject, error) {
+	return l.do(ctx, http.MethodPost, l.collectionURL(), &obj)
+}
+
+func (l *Locker) put(ctx context.Context, obj leaseObject) (leaseObject, error) {
+	return l.do(ctx, http.MethodPut, l.leaseURL(), &obj)
+}
+
+func (l *Locker) collectionURL() string {
+	return l.baseURL + fmt.Sprintf(leasesPath, url.PathEscape(l.cfg.Namespace))
+}
+
+func (l *Locker) leaseURL() string {
+	return l.collectionURL() + "/" + url.PathEscape(l.cfg.Name)
+}
+
+// do sends a Lease API request and decodes the returned object.
+func (l *Locker) do(ctx context.Context, method, target string, obj *leaseObject) (leaseObject, error) {
+	var body io.Reader = http.NoBody
+	if obj != nil {
+		payload, err := json.Marshal(obj)
+		if err != nil {
+			return leaseObject{}, err
+		}
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return leaseObject{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+l.token)
+	req.Header.Set("Accept", "application/json")
+	if obj != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return leaseObject{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound:
+		return leaseObject{}, errLeaseNotFound
+	case http.StatusConflict:
+		return leaseObject{}, errLeaseConflict
+	default:
+		return leaseObject{}, fmt.Errorf("%w: %s %s (%s)", errLeaseRequestFailed, method, target, resp.Status)
+	}
+	var out leaseObject
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return leaseObject{}, err
+	}
+	return out, nil
+}
+
+// backend overrides the lock of a wrapped cache backend.
+type backend struct {
+	cacheManager.Backend
+
+	locker *Locker
+}
+
+// Wrap returns b with its lock replaced by the lease lock.
+// Takeovers of an expired lease are recorded when b keeps an audit journal.
+func Wrap(b cacheManager.Backend, locker *Locker) cacheManager.Backend {
+	if sink, ok := b.(cacheManager.AuditSink); ok {
+		locker.onSteal = func(ctx context.Context, previousHolder string) {
+			event := cacheManager.NewAuditEvent(cacheManager.AuditLockSteal, locker.cfg.Namespace+"/"+locker.cfg.Name, "")
+			event.Detail = "expired lease held by " + previousHolder
+			_ = sink.AppendAudit(ctx, event)
+		}
+	}
+	return &backend{Backend: b, locker: locker}
+}
+
+// Lock acquires the lease instead of the backend lock.
+func (b *backend) Lock(ctx context.Context) (func() error, error) {
+	return b.locker.Lock(ctx)
+}
+
+// AppendAudit forwards to the wrapped backend when it keeps an audit journal.
+func (b *backend) AppendAudit(ctx context.Context, event cacheManager.AuditEvent) error {
+	return cacheManager.AppendAudit(ctx, b.Backend, event)
+}
+
+// LoadStoreWith forwards to the wrapped backend when it can load part of the store.
+func (b *backend) LoadStoreWith(ctx context.Context, opts store.LoadOptions) (*store.Store, error) {
+	return cacheManager.LoadStoreWith(ctx, b.Backend, opts)
+}
+
+// LoadResolution forwards to the wrapped backend when it keeps resolutions.
+func (b *backend) LoadResolution(ctx context.Context, reqHash string) (*store.Resolution, error) {
+	return cacheManager.LoadResolution(ctx, b.Backend, reqHash)
+}
+
+// SaveResolution forwards to the wrapped backend when it keeps resolutions.
+func (b *backend) SaveResolution(ctx context.Context, res *store.Resolution) error {
+	return cacheManager.SaveResolution(ctx, b.Backend, res)
+}
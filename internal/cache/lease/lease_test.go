@@ -0,0 +1,185 @@
+package lease
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/cache/local"
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+func TestLockAcquireContendRelease(t *testing.T) {
+	t.Parallel()
+	srv := newFakeAPIServer()
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	first := testLocker(ts, "a/1")
+	second := testLocker(ts, "b/2")
+
+	release, err := first.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("first Lock error: %v", err)
+	}
+	if _, err := second.Lock(context.Background()); !errors.Is(err, helpers.ErrAnotherInstanceIsRunning) {
+		t.Fatalf("expected contention error, got %v", err)
+	}
+	if err := release(); err != nil {
+		t.Fatalf("release error: %v", err)
+	}
+	releaseSecond, err := second.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("second Lock after release error: %v", err)
+	}
+	_ = releaseSecond()
+}
+
+func TestLockTakesOverUnrenewedLease(t *testing.T) {
+	t.Parallel()
+	srv := newFakeAPIServer()
+	// The dead holder's clock ran ahead: by its renewTime the lease looks live for a day.
+	srv.lease = &leaseObject{
+		Metadata: leaseMetadata{Name: "go-galaxy", Namespace: "ci", ResourceVersion: "7"},
+		Spec:     leaseSpec{HolderIdentity: "dead/1", RenewTime: time.Now().Add(24 * time.Hour).UTC().Format(microTimeLayout)},
+	}
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	locker := testLocker(ts, "a/1")
+	var stolen string
+	locker.onSteal = func(_ context.Context, previous string) { stolen = previous }
+	start := time.Now()
+	release, err := locker.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	defer func() {
+		_ = release()
+	}()
+	if elapsed := time.Since(start); elapsed < locker.cfg.Duration {
+		t.Fatalf("expected the lease to be watched for %s before the takeover, took %s", locker.cfg.Duration, elapsed)
+	}
+	if stolen != "dead/1" {
+		t.Fatalf("expected a takeover from dead/1, got %q", stolen)
+	}
+}
+
+func TestKeepAliveCancelsWorkWhenRenewalFails(t *testing.T) {
+	t.Parallel()
+	srv := newFakeAPIServer()
+	var failing atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		srv.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	ctx, cancel := cacheManager.WithLockGuard(context.Background())
+	defer cancel()
+	locker := testLocker(ts, "a/1")
+	release, err := locker.Lock(ctx)
+	if err != nil {
+		t.Fatalf("Lock error: %v", err)
+	}
+	failing.Store(true)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * locker.cfg.Duration):
+		t.Fatal("expected the guarded context to be canceled once renewals failed")
+	}
+	if cause := context.Cause(ctx); !errors.Is(cause, helpers.ErrLockLost) {
+		t.Fatalf("expected ErrLockLost as the cause, got %v", cause)
+	}
+	if err := release(); !errors.Is(err, helpers.ErrLockLost) {
+		t.Fatalf("expected release to report ErrLockLost, got %v", err)
+	}
+}
+
+func testLocker(ts *httptest.Server, identity string) *Locker {
+	return &Locker{
+		cfg:      config.LeaseLockConfig{Enabled: true, Name: "go-galaxy", Namespace: "ci", Duration: 300 * time.Millisecond},
+		client:   ts.Client(),
+		baseURL:  ts.URL,
+		identity: identity,
+	}
+}
+
+// fakeAPIServer stores a single Lease and enforces resourceVersion preconditions.
+type fakeAPIServer struct {
+	mu      sync.Mutex
+	lease   *leaseObject
+	version int
+}
+
+func newFakeAPIServer() *fakeAPIServer {
+	return &fakeAPIServer{}
+}
+
+func (s *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		if s.lease == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(s.lease)
+	case http.MethodPost, http.MethodPut:
+		var obj leaseObject
+		if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPost && s.lease != nil {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if r.Method == http.MethodPut && (s.lease == nil || obj.Metadata.ResourceVersion != s.lease.Metadata.ResourceVersion) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.version++
+		obj.Metadata.ResourceVersion = strconv.Itoa(s.version)
+		s.lease = &obj
+		_ = json.NewEncoder(w).Encode(s.lease)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestWrapForwardsOptionalInterfaces(t *testing.T) {
+	t.Parallel()
+	inner := local.New(t.TempDir(), nil)
+	wrapped := Wrap(inner, &Locker{cfg: config.LeaseLockConfig{Name: "go-galaxy", Namespace: "ci"}})
+	if _, ok := wrapped.(cacheManager.PartialLoader); !ok {
+		t.Fatalf("lease wrapper does not forward LoadStoreWith")
+	}
+	if _, ok := wrapped.(cacheManager.AuditSink); !ok {
+		t.Fatalf("lease wrapper does not forward AppendAudit")
+	}
+	ctx := context.Background()
+	if err := wrapped.Open(ctx); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = wrapped.Close(ctx)
+	})
+	if _, err := cacheManager.LoadStoreWith(ctx, wrapped, store.LoadOptions{LazyAPICache: true}); err != nil {
+		t.Fatalf("LoadStoreWith: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package lease
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	errLeaseNotInCluster   = errors.New("lease lock requires running inside a Kubernetes pod")
+	errLeaseNameEmpty      = errors.New("lease name is empty")
+	errLeaseNamespaceEmpty = errors.New("lease namespace is empty")
+	errLeaseRequestFailed  = errors.New("lease request failed")
+	errLeaseConflict       = errors.New("lease was modified concurrently")
+	errLeaseNotFound       = errors.New("lease not found")
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	tokenFile         = "token"
+	caFile            = "ca.crt"
+	namespaceFile     = "namespace"
+	leasesPath        = "/apis/coordination.k8s.io/v1/namespaces/%s/leases"
+	microTimeLayout   = "2006-01-02T15:04:05.000000Z07:00"
+	minLeaseDuration  = 15 * time.Second
+	renewDivisor      = 3
+	requestTimeout    = 10 * time.Second
+)
@@ -6,28 +6,37 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
 	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/crypt"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
 )
 
 // Artifacts implements ArtifactStore for filesystem-backed artifacts.
 type Artifacts struct {
 	cacheDir string
+	cipher   *crypt.Cipher
 }
 
 // NewArtifacts returns a local artifact store rooted at cacheDir.
-func NewArtifacts(cacheDir string) *Artifacts {
-	return &Artifacts{cacheDir: cacheDir}
+// When cipher is non-nil, artifacts are encrypted at rest.
+func NewArtifacts(cacheDir string, cipher *crypt.Cipher) *Artifacts {
+	return &Artifacts{cacheDir: cacheDir, cipher: cipher}
 }
 
 // Has reports whether the artifact exists in the local cache.
 func (s *Artifacts) Has(_ context.Context, key string) (bool, error) {
-	path, err := s.path(key)
+	path, err := s.resolve(key)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
 		return false, err
 	}
-	_, err = os.Stat(path)
+	sealed, err := crypt.IsSealedFile(path)
 	if err == nil {
-		return true, nil
+		// Artifacts written with a different encryption setting are treated as missing.
+		return sealed == (s.cipher != nil), nil
 	}
 	if os.IsNotExist(err) {
 		return false, nil
@@ -36,15 +45,18 @@ func (s *Artifacts) Has(_ context.Context, key string) (bool, error) {
 }
 
 // Fetch returns a cached artifact file by key.
-func (s *Artifacts) Fetch(_ context.Context, key string) (cacheManager.ArtifactFile, error) {
-	path, err := s.path(key)
+func (s *Artifacts) Fetch(ctx context.Context, key string) (cacheManager.ArtifactFile, error) {
+	path, err := s.resolve(key)
 	if err != nil {
 		return cacheManager.ArtifactFile{}, err
 	}
 	if _, err := os.Stat(path); err != nil {
 		return cacheManager.ArtifactFile{}, err
 	}
-	return cacheManager.ArtifactFile{Path: path}, nil
+	if s.cipher == nil {
+		return cacheManager.ArtifactFile{Path: path}, nil
+	}
+	return s.decrypt(ctx, path)
 }
 
 // TempFile creates a temporary file for staging an artifact.
@@ -63,30 +75,139 @@ func (s *Artifacts) TempFile(_ context.Context, prefix string) (*os.File, func()
 	return file, cleanup, nil
 }
 
-// Commit moves a temporary artifact into its final cache location.
-func (s *Artifacts) Commit(_ context.Context, key, tmpPath string, _ map[string]string) (cacheManager.ArtifactFile, error) {
+// Commit moves a temporary artifact into its final cache location. With a
+// sha256 in meta the artifact is stored as a content-addressed blob that
+// the key points at, shared with every other key holding the same bytes.
+func (s *Artifacts) Commit(_ context.Context, key, tmpPath string, meta map[string]string) (cacheManager.ArtifactFile, error) {
 	path, err := s.path(key)
 	if err != nil {
 		return cacheManager.ArtifactFile{}, err
 	}
-	if err := os.Rename(tmpPath, path); err != nil {
+	if sum := strings.TrimSpace(meta[archive.HashSHA256]); validBlobSum(sum) {
+		return s.commitBlob(path, sum, tmpPath)
+	}
+	if s.cipher != nil {
+		return s.encrypt(tmpPath, path)
+	}
+	if err := helpers.MoveFile(tmpPath, path); err != nil {
 		return cacheManager.ArtifactFile{}, err
 	}
 	return cacheManager.ArtifactFile{Path: path}, nil
 }
 
+// commitBlob stores tmpPath as the blob sum, unless an intact copy exists,
+// and points the key file at path to it.
+func (s *Artifacts) commitBlob(path, sum, tmpPath string) (cacheManager.ArtifactFile, error) {
+	blob, err := s.blobPath(sum)
+	if err != nil {
+		return cacheManager.ArtifactFile{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(blob), dirMod); err != nil {
+		return cacheManager.ArtifactFile{}, err
+	}
+	previous, hadPointer, _ := readPointer(path)
+	reuse := s.reusableBlob(blob, sum)
+	file := cacheManager.ArtifactFile{Path: blob}
+	switch {
+	case reuse && s.cipher != nil:
+		file = cacheManager.ArtifactFile{Path: tmpPath, Cleanup: func() { _ = os.Remove(tmpPath) }}
+	case reuse:
+		_ = os.Remove(tmpPath)
+	case s.cipher != nil:
+		if file, err = s.encrypt(tmpPath, blob); err != nil {
+			return cacheManager.ArtifactFile{}, err
+		}
+	default:
+		if err := helpers.MoveFile(tmpPath, blob); err != nil {
+			return cacheManager.ArtifactFile{}, err
+		}
+	}
+	if err := writePointer(path, sum); err != nil {
+		if file.Cleanup != nil {
+			file.Cleanup()
+		}
+		return cacheManager.ArtifactFile{}, err
+	}
+	if hadPointer && previous != sum {
+		_ = s.releaseBlob(previous)
+	}
+	return file, nil
+}
+
+// List returns the keys of cached artifacts.
+func (s *Artifacts) List(_ context.Context) ([]string, error) {
+	dir, err := s.dir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".tar.gz") {
+			keys = append(keys, entry.Name())
+		}
+	}
+	return keys, nil
+}
+
 // Delete removes an artifact from the local cache.
+// A blob is removed with the last key pointing at it.
 func (s *Artifacts) Delete(_ context.Context, key string) error {
 	path, err := s.path(key)
 	if err != nil {
 		return err
 	}
+	sum, isPointer, _ := readPointer(path)
 	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
 		return err
 	}
+	if isPointer {
+		return s.releaseBlob(sum)
+	}
 	return nil
 }
 
+// encrypt stores an encrypted copy of tmpPath at path.
+// The plaintext staging file is returned for immediate use and removed by Cleanup.
+func (s *Artifacts) encrypt(tmpPath, path string) (cacheManager.ArtifactFile, error) {
+	staged := path + ".tmp"
+	if err := s.cipher.EncryptFile(tmpPath, staged); err != nil {
+		_ = os.Remove(staged)
+		return cacheManager.ArtifactFile{}, err
+	}
+	if err := os.Rename(staged, path); err != nil {
+		_ = os.Remove(staged)
+		return cacheManager.ArtifactFile{}, err
+	}
+	cleanup := func() {
+		_ = os.Remove(tmpPath)
+	}
+	return cacheManager.ArtifactFile{Path: tmpPath, Cleanup: cleanup}, nil
+}
+
+// decrypt writes a plaintext copy of the encrypted artifact to a temporary file.
+func (s *Artifacts) decrypt(ctx context.Context, path string) (cacheManager.ArtifactFile, error) {
+	tmpFile, cleanup, err := s.TempFile(ctx, ".download-")
+	if err != nil {
+		return cacheManager.ArtifactFile{}, err
+	}
+	if err := tmpFile.Close(); err != nil {
+		cleanup()
+		return cacheManager.ArtifactFile{}, err
+	}
+	if err := s.cipher.DecryptFile(path, tmpFile.Name()); err != nil {
+		cleanup()
+		return cacheManager.ArtifactFile{}, err
+	}
+	return cacheManager.ArtifactFile{Path: tmpFile.Name(), Cleanup: cleanup}, nil
+}
+
 // dir returns the base cache directory for artifacts.
 func (s *Artifacts) dir() (string, error) {
 	trimmed := strings.TrimSpace(s.cacheDir)
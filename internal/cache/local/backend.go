@@ -2,9 +2,14 @@ package local
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
 
 	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/crypt"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
 	"github.com/greeddj/go-galaxy/internal/galaxy/store"
 )
 
@@ -12,17 +17,32 @@ import (
 type Backend struct {
 	cacheDir  string
 	dbs       *store.DBs
+	journal   *store.Journal
 	artifacts *Artifacts
+	cipher    *crypt.Cipher
+	// jsonStore keeps the store in one JSON file instead of Bolt databases.
+	jsonStore bool
 }
 
 // New creates a Backend rooted at cacheDir.
-func New(cacheDir string) *Backend {
+// A non-nil cipher encrypts snapshots and artifacts at rest.
+func New(cacheDir string, cipher *crypt.Cipher) *Backend {
 	return &Backend{
 		cacheDir:  cacheDir,
-		artifacts: NewArtifacts(cacheDir),
+		journal:   store.OpenJournal(cacheDir, cipher),
+		artifacts: NewArtifacts(cacheDir, cipher),
+		cipher:    cipher,
 	}
 }
 
+// NewJSON creates a Backend rooted at cacheDir that keeps the store in a
+// single gzipped JSON file, for short-lived runners that do not need Bolt.
+func NewJSON(cacheDir string, cipher *crypt.Cipher) *Backend {
+	b := New(cacheDir, cipher)
+	b.jsonStore = true
+	return b
+}
+
 // Open initializes local backend storage.
 func (b *Backend) Open(_ context.Context) error {
 	return b.ensureOpen()
@@ -30,6 +50,7 @@ func (b *Backend) Open(_ context.Context) error {
 
 // Close releases any open resources.
 func (b *Backend) Close(_ context.Context) error {
+	_ = b.journal.Close()
 	if b.dbs == nil {
 		return nil
 	}
@@ -39,26 +60,85 @@ func (b *Backend) Close(_ context.Context) error {
 }
 
 // Lock obtains an exclusive lock for the cache directory.
-func (b *Backend) Lock(_ context.Context) (func() error, error) {
+func (b *Backend) Lock(ctx context.Context) (func() error, error) {
 	if b.cacheDir == "" {
 		return nil, errCacheDirEmpty
 	}
-	return store.AcquireLock(b.cacheDir)
+	return store.AcquireLock(b.cacheDir, func(stalePID int) {
+		event := cacheManager.NewAuditEvent(cacheManager.AuditLockSteal, helpers.StoreDBLock, "")
+		event.Detail = fmt.Sprintf("stale pid %d", stalePID)
+		_ = b.AppendAudit(ctx, event)
+	})
+}
+
+// AppendAudit appends an event to the audit journal in the cache directory.
+func (b *Backend) AppendAudit(_ context.Context, event cacheManager.AuditEvent) error {
+	if b.cacheDir == "" {
+		return errCacheDirEmpty
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	//nolint:gosec // the journal path is derived from cacheDir.
+	f, err := os.OpenFile(filepath.Join(b.cacheDir, helpers.StoreAuditLog), os.O_CREATE|os.O_WRONLY|os.O_APPEND, helpers.FileMod)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadStore loads the persistent snapshot store, replays installs journaled
+// by a run that ended before saving it, and journals further installs.
+func (b *Backend) LoadStore(ctx context.Context) (*store.Store, error) {
+	return b.LoadStoreWith(ctx, store.LoadOptions{})
 }
 
-// LoadStore loads the persistent snapshot store.
-func (b *Backend) LoadStore(_ context.Context) (*store.Store, error) {
+// LoadStoreWith is LoadStore limited to the parts of the store selected by opts.
+func (b *Backend) LoadStoreWith(_ context.Context, opts store.LoadOptions) (*store.Store, error) {
 	if err := b.ensureOpen(); err != nil {
 		return nil, err
 	}
-	return store.Load(b.dbs)
+	var st *store.Store
+	var err error
+	if b.jsonStore {
+		st, err = store.LoadJSONFile(store.JSONFilePath(b.cacheDir), b.cipher)
+	} else {
+		st, err = store.LoadWith(b.dbs, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.journal.Replay(st); err != nil {
+		return nil, err
+	}
+	st.AttachJournal(b.journal)
+	return st, nil
 }
 
-// SaveStore persists the snapshot store.
+// SaveStore persists the snapshot store and then drops the journal it covers.
 func (b *Backend) SaveStore(_ context.Context, st *store.Store) error {
 	if err := b.ensureOpen(); err != nil {
 		return err
 	}
+	if err := b.journal.Retire(); err != nil {
+		return err
+	}
+	if err := b.saveStore(st); err != nil {
+		return err
+	}
+	return b.journal.Commit()
+}
+
+// saveStore writes st to the Bolt databases or the JSON file.
+func (b *Backend) saveStore(st *store.Store) error {
+	if b.jsonStore {
+		return store.SaveJSONFile(store.JSONFilePath(b.cacheDir), b.cipher, st)
+	}
 	return store.Save(b.dbs, st)
 }
 
@@ -71,11 +151,11 @@ func (b *Backend) ClearFiles(_ context.Context) error {
 }
 
 // RecordProject records the project in the local registry.
-func (b *Backend) RecordProject(_ context.Context, requirementsFile, downloadPath string) error {
+func (b *Backend) RecordProject(_ context.Context, run store.ProjectRun) error {
 	if b.cacheDir == "" {
 		return errCacheDirEmpty
 	}
-	return store.RecordProject(b.cacheDir, requirementsFile, downloadPath)
+	return store.RecordProject(b.cacheDir, run)
 }
 
 // LoadProjectRegistry loads the local project registry.
@@ -102,10 +182,14 @@ func (b *Backend) ensureOpen() error {
 	if err := os.MkdirAll(b.cacheDir, dirMod); err != nil {
 		return err
 	}
+	if b.jsonStore {
+		return nil
+	}
 	dbs, err := store.OpenDBs(b.cacheDir)
 	if err != nil {
 		return err
 	}
+	dbs.SetCipher(b.cipher)
 	b.dbs = dbs
 	return nil
 }
@@ -0,0 +1,154 @@
+package local
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	"github.com/greeddj/go-galaxy/internal/galaxy/crypt"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+// Artifacts committed with a sha256 are stored once under
+// blobs/sha256/<2-char shard>/<sha256>; the friendly key is a small pointer
+// file naming the blob, so identical artifacts mirrored or republished under
+// several keys share one copy and a blob's name is its checksum.
+
+const (
+	// pointerMagic starts every pointer file.
+	pointerMagic = "go-galaxy-blob sha256:"
+	// blobShardLen is how many leading hex digits name a blob's shard directory.
+	blobShardLen = 2
+	// pointerMaxSize bounds how much of a key file is read to detect a pointer.
+	pointerMaxSize = 256
+)
+
+// blobPath returns where the blob with sha256 sum lives.
+func (s *Artifacts) blobPath(sum string) (string, error) {
+	dir, err := s.dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, helpers.StoreBlobsDir, archive.HashSHA256, sum[:blobShardLen], sum), nil
+}
+
+// validBlobSum reports whether sum can name a blob.
+func validBlobSum(sum string) bool {
+	if len(sum) != hex.EncodedLen(sha256.Size) || sum != strings.ToLower(sum) {
+		return false
+	}
+	_, err := hex.DecodeString(sum)
+	return err == nil
+}
+
+// readPointer returns the blob sum a key file points at. Artifacts stored
+// before content addressing, or without a sha256, are plain files and
+// report false.
+func readPointer(path string) (string, bool, error) {
+	//nolint:gosec // path is derived from the cache directory and artifact key.
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	head := make([]byte, pointerMaxSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", false, err
+	}
+	sum, ok := bytes.CutPrefix(bytes.TrimSpace(head[:n]), []byte(pointerMagic))
+	if !ok || !validBlobSum(string(sum)) {
+		return "", false, nil
+	}
+	return string(sum), true, nil
+}
+
+// resolve returns the file holding the artifact stored under key: its blob
+// for pointer entries, the key file itself otherwise.
+func (s *Artifacts) resolve(key string) (string, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	sum, ok, err := readPointer(path)
+	if err != nil || !ok {
+		return path, err
+	}
+	return s.blobPath(sum)
+}
+
+// writePointer atomically points the key file at path to the blob sum.
+func writePointer(path, sum string) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".pointer-")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	if _, err := tmpFile.WriteString(pointerMagic + sum + "\n"); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// reusableBlob reports whether the blob at path can serve new references.
+// Plain blobs are checked against their name, so a corrupted copy is
+// replaced instead of shared; encrypted blobs fail authentication on read.
+func (s *Artifacts) reusableBlob(path, sum string) bool {
+	sealed, err := crypt.IsSealedFile(path)
+	if err != nil || sealed != (s.cipher != nil) {
+		return false
+	}
+	if s.cipher != nil {
+		return true
+	}
+	actual, err := archive.FileHashSHA256(path)
+	return err == nil && actual == sum
+}
+
+// releaseBlob removes the blob sum once no key file points at it.
+func (s *Artifacts) releaseBlob(sum string) error {
+	dir, err := s.dir()
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".tar.gz") {
+			continue
+		}
+		other, ok, err := readPointer(filepath.Join(dir, entry.Name()))
+		if err == nil && ok && other == sum {
+			return nil
+		}
+	}
+	path, err := s.blobPath(sum)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	_ = os.Remove(filepath.Dir(path))
+	return nil
+}
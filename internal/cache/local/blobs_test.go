@@ -0,0 +1,116 @@
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+func commitPayload(t *testing.T, s *Artifacts, key, payload string) {
+	t.Helper()
+	tmp, cleanup, err := s.TempFile(context.Background(), ".download-")
+	if err != nil {
+		t.Fatalf("TempFile error: %v", err)
+	}
+	defer cleanup()
+	if _, err := tmp.WriteString(payload); err != nil {
+		t.Fatalf("write payload: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		t.Fatalf("close payload: %v", err)
+	}
+	sum, err := archive.FileHashSHA256(tmp.Name())
+	if err != nil {
+		t.Fatalf("hash payload: %v", err)
+	}
+	file, err := s.Commit(context.Background(), key, tmp.Name(), map[string]string{archive.HashSHA256: sum})
+	if err != nil {
+		t.Fatalf("Commit error: %v", err)
+	}
+	if file.Cleanup != nil {
+		file.Cleanup()
+	}
+}
+
+func countBlobs(t *testing.T, dir string) int {
+	t.Helper()
+	var blobs int
+	err := filepath.WalkDir(filepath.Join(dir, helpers.StoreBlobsDir), func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			blobs++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk blobs: %v", err)
+	}
+	return blobs
+}
+
+func TestCommitDeduplicatesByContent(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := NewArtifacts(dir, nil)
+	commitPayload(t, s, "a-b-1.0.0~one.tar.gz", "same bytes")
+	commitPayload(t, s, "a-b-1.0.0~two.tar.gz", "same bytes")
+	commitPayload(t, s, "c-d-1.0.0~one.tar.gz", "other bytes")
+	if got := countBlobs(t, dir); got != 2 {
+		t.Fatalf("blobs = %d, want 2", got)
+	}
+
+	file, err := s.Fetch(ctx, "a-b-1.0.0~two.tar.gz")
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	data, err := os.ReadFile(file.Path)
+	if err != nil || string(data) != "same bytes" {
+		t.Fatalf("fetched %q, %v", data, err)
+	}
+
+	if err := s.Delete(ctx, "a-b-1.0.0~one.tar.gz"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if ok, err := s.Has(ctx, "a-b-1.0.0~two.tar.gz"); err != nil || !ok {
+		t.Fatalf("a blob still referenced must be kept: %v, %v", ok, err)
+	}
+	if err := s.Delete(ctx, "a-b-1.0.0~two.tar.gz"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if got := countBlobs(t, dir); got != 1 {
+		t.Fatalf("blobs after deleting the last pointer = %d, want 1", got)
+	}
+}
+
+func TestCommitReplacesCorruptedBlob(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := NewArtifacts(dir, nil)
+	commitPayload(t, s, "a-b-1.0.0.tar.gz", "payload")
+	blob, err := s.resolve("a-b-1.0.0.tar.gz")
+	if err != nil {
+		t.Fatalf("resolve error: %v", err)
+	}
+	if err := os.WriteFile(blob, []byte("corrupted"), 0o600); err != nil {
+		t.Fatalf("corrupt blob: %v", err)
+	}
+	commitPayload(t, s, "a-b-1.0.0~mirror.tar.gz", "payload")
+	file, err := s.Fetch(ctx, "a-b-1.0.0.tar.gz")
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if data, _ := os.ReadFile(file.Path); string(data) != "payload" {
+		t.Fatalf("blob not repaired: %q", data)
+	}
+}
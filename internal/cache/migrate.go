@@ -0,0 +1,395 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// MigrationReport summarizes a wholesale cache migration.
+type MigrationReport struct {
+	From             string `json:"from"`
+	To               string `json:"to"`
+	Projects         int    `json:"projects"`
+	ArtifactsCopied  int    `json:"artifacts_copied"`
+	ArtifactsSkipped int    `json:"artifacts_skipped"`
+}
+
+// migratingBackend serves a backend transition: reads prefer the new backend
+// and fall back to the old one, writes go to both. Writes to the old backend
+// are best effort so it can be decommissioned at any time.
+type migratingBackend struct {
+	cacheManager.Backend
+
+	legacy cacheManager.Backend
+}
+
+// legacyConfig returns the configuration for the backend being migrated from.
+func legacyConfig(cfg *config.Config) (*config.Config, error) {
+	from := cfg.CacheMigrateFrom
+	if from == backendName(cfg) {
+		return nil, fmt.Errorf("%w: %q", helpers.ErrMigrationSameBackend, from)
+	}
+	legacy := *cfg
+	legacy.CacheMigrateFrom = ""
+	legacy.CacheBackend = from
+	legacy.LeaseLock.Enabled = false
+	if !isLocalBackend(from) {
+		legacy.CacheEncryptionKey = nil
+	}
+	return &legacy, nil
+}
+
+// newMigratingBackend pairs the configured backend with the one named by --cache-migrate-from.
+func newMigratingBackend(cfg *config.Config, runtime *infra.Infra, primary cacheManager.Backend) (cacheManager.Backend, error) {
+	legacyCfg, err := legacyConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := newBackend(legacyCfg, runtime)
+	if err != nil {
+		return nil, fmt.Errorf("migration source: %w", err)
+	}
+	return &migratingBackend{Backend: primary, legacy: withAudit(legacy)}, nil
+}
+
+// Open opens both backends.
+func (b *migratingBackend) Open(ctx context.Context) error {
+	if err := b.Backend.Open(ctx); err != nil {
+		return err
+	}
+	if err := b.legacy.Open(ctx); err != nil {
+		return fmt.Errorf("migration source: %w", err)
+	}
+	return nil
+}
+
+// Close closes both backends.
+func (b *migratingBackend) Close(ctx context.Context) error {
+	return errors.Join(b.Backend.Close(ctx), b.legacy.Close(ctx))
+}
+
+// Lock locks both backends so runs still on the old backend do not race the writes.
+func (b *migratingBackend) Lock(ctx context.Context) (func() error, error) {
+	release, err := b.Backend.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	releaseLegacy, err := b.legacy.Lock(ctx)
+	if err != nil {
+		_ = release()
+		return nil, fmt.Errorf("migration source: %w", err)
+	}
+	return func() error {
+		return errors.Join(releaseLegacy(), release())
+	}, nil
+}
+
+// LoadStore loads the new backend's store, falling back to the old one while the new one is empty.
+func (b *migratingBackend) LoadStore(ctx context.Context) (*store.Store, error) {
+	st, err := b.Backend.LoadStore(ctx)
+	if err != nil || !st.IsEmpty() {
+		return st, err
+	}
+	if legacy, err := b.legacy.LoadStore(ctx); err == nil {
+		return legacy, nil
+	}
+	return st, nil
+}
+
+// LoadStoreWith is LoadStore limited to the parts of the new backend's store
+// selected by opts. A partial store that looks empty may only lack the parts
+// left out, so that case goes through LoadStore and its fallback.
+func (b *migratingBackend) LoadStoreWith(ctx context.Context, opts store.LoadOptions) (*store.Store, error) {
+	st, err := cacheManager.LoadStoreWith(ctx, b.Backend, opts)
+	if err != nil || !st.IsEmpty() {
+		return st, err
+	}
+	return b.LoadStore(ctx)
+}
+
+// AppendAudit records the event in the new backend's journal, when it keeps one.
+func (b *migratingBackend) AppendAudit(ctx context.Context, event cacheManager.AuditEvent) error {
+	return cacheManager.AppendAudit(ctx, b.Backend, event)
+}
+
+// SaveStore saves the store to both backends.
+func (b *migratingBackend) SaveStore(ctx context.Context, st *store.Store) error {
+	if err := b.Backend.SaveStore(ctx, st); err != nil {
+		return err
+	}
+	_ = b.legacy.SaveStore(ctx, st)
+	return nil
+}
+
+// ClearFiles clears artifacts in both backends.
+func (b *migratingBackend) ClearFiles(ctx context.Context) error {
+	if err := b.Backend.ClearFiles(ctx); err != nil {
+		return err
+	}
+	_ = b.legacy.ClearFiles(ctx)
+	return nil
+}
+
+// RecordProject records the project in both backends.
+func (b *migratingBackend) RecordProject(ctx context.Context, run store.ProjectRun) error {
+	if err := b.Backend.RecordProject(ctx, run); err != nil {
+		return err
+	}
+	_ = b.legacy.RecordProject(ctx, run)
+	return nil
+}
+
+// LoadResolution prefers the new backend's resolution and falls back to the old one.
+func (b *migratingBackend) LoadResolution(ctx context.Context, reqHash string) (*store.Resolution, error) {
+	res, err := cacheManager.LoadResolution(ctx, b.Backend, reqHash)
+	if err != nil || res != nil {
+		return res, err
+	}
+	if legacy, err := cacheManager.LoadResolution(ctx, b.legacy, reqHash); err == nil {
+		return legacy, nil
+	}
+	return nil, nil
+}
+
+// SaveResolution saves the resolution to both backends.
+func (b *migratingBackend) SaveResolution(ctx context.Context, res *store.Resolution) error {
+	if err := cacheManager.SaveResolution(ctx, b.Backend, res); err != nil {
+		return err
+	}
+	_ = cacheManager.SaveResolution(ctx, b.legacy, res)
+	return nil
+}
+
+// Artifacts returns an artifact store that reads through to and writes into both backends.
+func (b *migratingBackend) Artifacts() cacheManager.ArtifactStore {
+	primary := b.Backend.Artifacts()
+	legacy := b.legacy.Artifacts()
+	if primary == nil || legacy == nil {
+		return primary
+	}
+	return &migratingArtifacts{ArtifactStore: primary, legacy: legacy}
+}
+
+// migratingArtifacts implements the artifact side of migratingBackend.
+type migratingArtifacts struct {
+	cacheManager.ArtifactStore
+
+	legacy cacheManager.ArtifactStore
+}
+
+// Has reports whether either backend holds the artifact.
+func (s *migratingArtifacts) Has(ctx context.Context, key string) (bool, error) {
+	ok, err := s.ArtifactStore.Has(ctx, key)
+	if err == nil && ok {
+		return true, nil
+	}
+	if legacyOK, legacyErr := s.legacy.Has(ctx, key); legacyErr == nil && legacyOK {
+		return true, nil
+	}
+	return ok, err
+}
+
+// Fetch reads from the new backend and falls back to the old one,
+// copying artifacts found only there into the new backend.
+func (s *migratingArtifacts) Fetch(ctx context.Context, key string) (cacheManager.ArtifactFile, error) {
+	file, err := s.ArtifactStore.Fetch(ctx, key)
+	if err == nil {
+		return file, nil
+	}
+	legacyFile, legacyErr := s.legacy.Fetch(ctx, key)
+	if legacyErr != nil {
+		return file, err
+	}
+	_ = copyArtifact(ctx, s.ArtifactStore, key, legacyFile)
+	return legacyFile, nil
+}
+
+// Commit stores the artifact in both backends.
+func (s *migratingArtifacts) Commit(ctx context.Context, key, tmpPath string, meta map[string]string) (cacheManager.ArtifactFile, error) {
+	// The new backend may move tmpPath, so the old one gets its copy first.
+	_ = copyArtifact(ctx, s.legacy, key, cacheManager.ArtifactFile{Path: tmpPath, Meta: meta})
+	return s.ArtifactStore.Commit(ctx, key, tmpPath, meta)
+}
+
+// List returns the keys held by either backend. The old backend's keys are
+// best effort, like its writes.
+func (s *migratingArtifacts) List(ctx context.Context) ([]string, error) {
+	keys, err := listArtifacts(ctx, s.ArtifactStore)
+	if err != nil {
+		return nil, err
+	}
+	if legacy, err := listArtifacts(ctx, s.legacy); err == nil {
+		keys = append(keys, legacy...)
+		slices.Sort(keys)
+		keys = slices.Compact(keys)
+	}
+	return keys, nil
+}
+
+// Delete removes the artifact from both backends.
+func (s *migratingArtifacts) Delete(ctx context.Context, key string) error {
+	if err := s.ArtifactStore.Delete(ctx, key); err != nil {
+		return err
+	}
+	_ = s.legacy.Delete(ctx, key)
+	return nil
+}
+
+// copyArtifact stages a copy of src in dst and commits it under key.
+func copyArtifact(ctx context.Context, dst cacheManager.ArtifactStore, key string, src cacheManager.ArtifactFile) error {
+	tmpFile, cleanup, err := dst.TempFile(ctx, ".migrate-")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	//nolint:gosec // src.Path is a cache staging file.
+	in, err := os.Open(src.Path)
+	if err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	_, err = io.Copy(tmpFile, in)
+	_ = in.Close()
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	committed, err := dst.Commit(ctx, key, tmpFile.Name(), maps.Clone(src.Meta))
+	if err != nil {
+		return err
+	}
+	if committed.Cleanup != nil {
+		committed.Cleanup()
+	}
+	return nil
+}
+
+// Migrate copies the store, project registry and artifacts from the
+// --cache-migrate-from backend into the configured backend.
+func Migrate(ctx context.Context, cfg *config.Config, runtime *infra.Infra) (MigrationReport, error) {
+	if cfg == nil {
+		return MigrationReport{}, errConfigNil
+	}
+	if cfg.CacheMigrateFrom == "" {
+		return MigrationReport{}, helpers.ErrMigrationSourceRequired
+	}
+	sourceCfg, err := legacyConfig(cfg)
+	if err != nil {
+		return MigrationReport{}, err
+	}
+	targetCfg := *cfg
+	targetCfg.CacheMigrateFrom = ""
+	report := MigrationReport{From: sourceCfg.CacheBackend, To: backendName(&targetCfg)}
+
+	source, releaseSource, err := openLocked(ctx, sourceCfg, runtime)
+	if err != nil {
+		return report, fmt.Errorf("migration source: %w", err)
+	}
+	defer func() {
+		_ = releaseSource()
+		_ = source.Close(context.WithoutCancel(ctx))
+	}()
+	target, releaseTarget, err := openLocked(ctx, &targetCfg, runtime)
+	if err != nil {
+		return report, err
+	}
+	defer func() {
+		_ = releaseTarget()
+		_ = target.Close(context.WithoutCancel(ctx))
+	}()
+
+	runtime.Output.Printf("🚚 copy storage")
+	st, err := source.LoadStore(ctx)
+	if err != nil {
+		return report, err
+	}
+	if err := target.SaveStore(ctx, st); err != nil {
+		return report, err
+	}
+	registry, err := source.LoadProjectRegistry(ctx)
+	if err != nil {
+		return report, err
+	}
+	for projectPath, project := range registry.Projects {
+		run := store.ProjectRun{
+			RequirementsFile: project.RequirementsPath(projectPath),
+			DownloadPath:     project.CollectionsDir(projectPath),
+			RelativePaths:    project.Relative(),
+		}
+		if err := target.RecordProject(ctx, run); err != nil {
+			return report, err
+		}
+		report.Projects++
+	}
+	if err := migrateArtifacts(ctx, runtime, source.Artifacts(), target.Artifacts(), &report); err != nil {
+		return report, err
+	}
+	// Persist indexes or other state the artifact copies touched.
+	return report, target.SaveStore(ctx, st)
+}
+
+// openLocked builds, opens and locks a backend.
+func openLocked(ctx context.Context, cfg *config.Config, runtime *infra.Infra) (cacheManager.Backend, func() error, error) {
+	backend, err := New(cfg, runtime)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := backend.Open(ctx); err != nil {
+		return nil, nil, err
+	}
+	release, err := backend.Lock(ctx)
+	if err != nil {
+		_ = backend.Close(ctx)
+		return nil, nil, err
+	}
+	return backend, release, nil
+}
+
+// migrateArtifacts copies every artifact the target does not have yet.
+func migrateArtifacts(
+	ctx context.Context,
+	runtime *infra.Infra,
+	source, target cacheManager.ArtifactStore,
+	report *MigrationReport,
+) error {
+	if source == nil || target == nil {
+		return errArtifactListUnsupported
+	}
+	keys, err := listArtifacts(ctx, source)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if ok, err := target.Has(ctx, key); err == nil && ok {
+			report.ArtifactsSkipped++
+			continue
+		}
+		runtime.Output.Printf("🚚 copy %s", key)
+		file, err := source.Fetch(ctx, key)
+		if err != nil {
+			return fmt.Errorf("fetch %s: %w", key, err)
+		}
+		err = copyArtifact(ctx, target, key, file)
+		if file.Cleanup != nil {
+			file.Cleanup()
+		}
+		if err != nil {
+			return fmt.Errorf("copy %s: %w", key, err)
+		}
+		report.ArtifactsCopied++
+	}
+	return nil
+}
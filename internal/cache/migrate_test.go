@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/cache/local"
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+const mirrorBackend = "test-mirror"
+
+//nolint:gochecknoinits // registers a second filesystem backend to migrate into.
+func init() {
+	Register(mirrorBackend, func(cfg *config.Config, _ *infra.Infra) (cacheManager.Backend, error) {
+		return local.New(filepath.Join(cfg.CacheDir, "mirror"), nil), nil
+	})
+}
+
+func commitTestArtifact(t *testing.T, artifacts cacheManager.ArtifactStore, key, body string) {
+	t.Helper()
+	tmp, _, err := artifacts.TempFile(context.Background(), ".download-")
+	if err != nil {
+		t.Fatalf("TempFile: %v", err)
+	}
+	if _, err := tmp.WriteString(body); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	_ = tmp.Close()
+	if _, err := artifacts.Commit(context.Background(), key, tmp.Name(), nil); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+}
+
+func TestMigratingArtifactsReadThroughAndDualWrite(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	primaryDir, legacyDir := t.TempDir(), t.TempDir()
+	legacy := local.NewArtifacts(legacyDir, nil)
+	commitTestArtifact(t, legacy, "a-b-1.0.0.tar.gz", "old")
+	artifacts := &migratingArtifacts{ArtifactStore: local.NewArtifacts(primaryDir, nil), legacy: legacy}
+
+	if ok, err := artifacts.Has(ctx, "a-b-1.0.0.tar.gz"); err != nil || !ok {
+		t.Fatalf("Has = %v, %v", ok, err)
+	}
+	file, err := artifacts.Fetch(ctx, "a-b-1.0.0.tar.gz")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if data, _ := os.ReadFile(file.Path); string(data) != "old" {
+		t.Fatalf("unexpected artifact %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(primaryDir, "a-b-1.0.0.tar.gz")); err != nil {
+		t.Fatalf("expected read-through copy in the new backend: %v", err)
+	}
+
+	commitTestArtifact(t, artifacts, "c-d-1.0.0.tar.gz", "new")
+	for _, dir := range []string{primaryDir, legacyDir} {
+		if _, err := os.Stat(filepath.Join(dir, "c-d-1.0.0.tar.gz")); err != nil {
+			t.Fatalf("expected dual write into %s: %v", dir, err)
+		}
+	}
+
+	commitTestArtifact(t, legacy, "e-f-1.0.0.tar.gz", "old only")
+	keys, err := artifacts.List(ctx)
+	if want := []string{"a-b-1.0.0.tar.gz", "c-d-1.0.0.tar.gz", "e-f-1.0.0.tar.gz"}; err != nil || !slices.Equal(keys, want) {
+		t.Fatalf("List = %v, %v; want %v", keys, err, want)
+	}
+}
+
+func TestMigrateCopiesStoreAndArtifacts(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := local.New(dir, nil)
+	if err := source.Open(ctx); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	st := store.New()
+	st.SetInstalled("a.b@1.0.0", store.InstalledEntry{InstallPath: "/x"})
+	if err := source.SaveStore(ctx, st); err != nil {
+		t.Fatalf("SaveStore: %v", err)
+	}
+	commitTestArtifact(t, source.Artifacts(), "a-b-1.0.0.tar.gz", "payload")
+	_ = source.Close(ctx)
+
+	cfg := &config.Config{CacheDir: dir, CacheBackend: mirrorBackend, CacheMigrateFrom: BackendLocal}
+	runtime := infra.New(progress.New(false, true), nil)
+	report, err := Migrate(ctx, cfg, runtime)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if report.ArtifactsCopied != 1 || report.From != BackendLocal || report.To != mirrorBackend {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "mirror", "a-b-1.0.0.tar.gz")); err != nil {
+		t.Fatalf("expected migrated artifact: %v", err)
+	}
+	target := local.New(filepath.Join(dir, "mirror"), nil)
+	migrated, err := target.LoadStore(ctx)
+	if err != nil {
+		t.Fatalf("LoadStore: %v", err)
+	}
+	_ = target.Close(ctx)
+	if _, ok := migrated.GetInstalled("a.b@1.0.0"); !ok {
+		t.Fatalf("expected migrated store entry")
+	}
+
+	again, err := Migrate(ctx, cfg, runtime)
+	if err != nil || again.ArtifactsSkipped != 1 || again.ArtifactsCopied != 0 {
+		t.Fatalf("expected second migration to skip, got %+v, %v", again, err)
+	}
+}
@@ -0,0 +1,340 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// Request is one line of JSON written to the plugin's stdin.
+type Request struct {
+	ID               int64               `json:"id"`
+	Op               string              `json:"op"`
+	Namespace        string              `json:"namespace,omitempty"`
+	Key              string              `json:"key,omitempty"`
+	Path             string              `json:"path,omitempty"`
+	Meta             map[string]string   `json:"meta,omitempty"`
+	Store            json.RawMessage     `json:"store,omitempty"`
+	RequirementsFile string              `json:"requirements_file,omitempty"`
+	DownloadPath     string              `json:"download_path,omitempty"`
+	RelativePaths    bool                `json:"relative_paths,omitempty"`
+	Owner            *store.ProjectOwner `json:"owner,omitempty"`
+}
+
+// Response is one line of JSON the plugin writes to stdout for each request.
+type Response struct {
+	ID       int64             `json:"id"`
+	OK       bool              `json:"ok"`
+	Error    string            `json:"error,omitempty"`
+	Found    bool              `json:"found,omitempty"`
+	Meta     map[string]string `json:"meta,omitempty"`
+	Store    json.RawMessage   `json:"store,omitempty"`
+	Registry json.RawMessage   `json:"registry,omitempty"`
+}
+
+// Backend delegates cache operations to an external process speaking
+// line-delimited JSON over stdio. Artifacts are exchanged as local file paths.
+type Backend struct {
+	path      string
+	namespace string
+	tempDir   string
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	pipe   io.Closer
+	stdout *bufio.Scanner
+	nextID int64
+
+	artifacts *Artifacts
+}
+
+// New returns a plugin backend for the executable at path.
+func New(path, namespace, tempDir string) (*Backend, error) {
+	if path == "" {
+		return nil, errPluginPathEmpty
+	}
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	b := &Backend{path: path, namespace: namespace, tempDir: tempDir}
+	b.artifacts = &Artifacts{backend: b}
+	return b, nil
+}
+
+// Open starts the plugin process and sends the open request.
+func (b *Backend) Open(ctx context.Context) error {
+	b.mu.Lock()
+	if b.cmd != nil {
+		b.mu.Unlock()
+		return nil
+	}
+	//nolint:gosec // the plugin path is explicitly configured by the user.
+	cmd := exec.Command(b.path)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxResponseSize)
+	b.cmd, b.stdin, b.pipe, b.stdout = cmd, stdin, stdout, scanner
+	b.mu.Unlock()
+
+	_, err = b.call(ctx, Request{Op: opOpen, Namespace: b.namespace})
+	return err
+}
+
+// Close sends the close request and waits for the plugin to exit; it is
+// killed if ctx ends first.
+func (b *Backend) Close(ctx context.Context) error {
+	b.mu.Lock()
+	running := b.cmd != nil
+	b.mu.Unlock()
+	if !running {
+		return nil
+	}
+	_, callErr := b.call(ctx, Request{Op: opClose})
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cmd == nil {
+		return callErr
+	}
+	_ = b.stdin.Close()
+	stop := context.AfterFunc(ctx, b.kill())
+	waitErr := b.cmd.Wait()
+	stop()
+	b.cmd = nil
+	if callErr != nil {
+		return callErr
+	}
+	return waitErr
+}
+
+// Lock asks the plugin for the cache lock.
+func (b *Backend) Lock(ctx context.Context) (func() error, error) {
+	if _, err := b.call(ctx, Request{Op: opLock}); err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := b.call(context.WithoutCancel(ctx), Request{Op: opUnlock})
+		return err
+	}, nil
+}
+
+// LoadStore loads the store JSON from the plugin.
+func (b *Backend) LoadStore(ctx context.Context) (*store.Store, error) {
+	resp, err := b.call(ctx, Request{Op: opLoadStore})
+	if err != nil {
+		return nil, err
+	}
+	st := store.New()
+	if len(resp.Store) == 0 || string(resp.Store) == "null" {
+		return st, nil
+	}
+	if err := json.Unmarshal(resp.Store, st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// SaveStore sends the store JSON to the plugin.
+func (b *Backend) SaveStore(ctx context.Context, st *store.Store) error {
+	if st == nil {
+		return nil
+	}
+	payload, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	_, err = b.call(ctx, Request{Op: opSaveStore, Store: payload})
+	return err
+}
+
+// ClearFiles asks the plugin to drop cached artifacts.
+func (b *Backend) ClearFiles(ctx context.Context) error {
+	_, err := b.call(ctx, Request{Op: opClearFiles})
+	return err
+}
+
+// RecordProject asks the plugin to record the project.
+func (b *Backend) RecordProject(ctx context.Context, run store.ProjectRun) error {
+	owner := store.CurrentProjectOwner()
+	_, err := b.call(ctx, Request{
+		Op:               opRecordProject,
+		RequirementsFile: run.RequirementsFile,
+		DownloadPath:     run.DownloadPath,
+		RelativePaths:    run.RelativePaths,
+		Owner:            &owner,
+	})
+	return err
+}
+
+// LoadProjectRegistry loads the project registry JSON from the plugin.
+func (b *Backend) LoadProjectRegistry(ctx context.Context) (*store.ProjectRegistry, error) {
+	resp, err := b.call(ctx, Request{Op: opLoadProjectRegistry})
+	if err != nil {
+		return nil, err
+	}
+	registry := &store.ProjectRegistry{}
+	if len(resp.Registry) > 0 && string(resp.Registry) != "null" {
+		if err := json.Unmarshal(resp.Registry, registry); err != nil {
+			return nil, err
+		}
+	}
+	if registry.Projects == nil {
+		registry.Projects = make(map[string]store.ProjectRecord)
+	}
+	return registry, nil
+}
+
+// Artifacts returns the plugin artifact store.
+func (b *Backend) Artifacts() cacheManager.ArtifactStore {
+	return b.artifacts
+}
+
+// call sends one request and reads its response; calls are serialized. A
+// plugin that has not answered when ctx ends is killed, and later calls
+// fail with errPluginNotRunning.
+func (b *Backend) call(ctx context.Context, req Request) (Response, error) {
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cmd == nil {
+		return Response{}, errPluginNotRunning
+	}
+	stop := context.AfterFunc(ctx, b.kill())
+	resp, err := b.roundTrip(req)
+	if !stop() {
+		_ = b.cmd.Wait()
+		b.cmd = nil
+		return Response{}, fmt.Errorf("%w: %s: %w", errPluginFailed, req.Op, context.Cause(ctx))
+	}
+	return resp, err
+}
+
+// kill returns a func that stops the plugin process and closes its stdout,
+// so a call blocked reading a response returns even if a child of the plugin
+// holds the pipe. It runs without b.mu, which the blocked call holds.
+func (b *Backend) kill() func() {
+	cmd, pipe := b.cmd, b.pipe
+	return func() {
+		_ = cmd.Process.Kill()
+		_ = pipe.Close()
+	}
+}
+
+// roundTrip writes req and reads the response to it; b.mu must be held.
+func (b *Backend) roundTrip(req Request) (Response, error) {
+	b.nextID++
+	req.ID = b.nextID
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, err
+	}
+	if _, err := b.stdin.Write(append(payload, '\n')); err != nil {
+		return Response{}, err
+	}
+	if !b.stdout.Scan() {
+		if err := b.stdout.Err(); err != nil {
+			return Response{}, err
+		}
+		return Response{}, fmt.Errorf("%w: %s: unexpected EOF", errPluginFailed, req.Op)
+	}
+	var resp Response
+	if err := json.Unmarshal(b.stdout.Bytes(), &resp); err != nil {
+		return Response{}, err
+	}
+	if resp.ID != req.ID {
+		return Response{}, fmt.Errorf("%w: %d != %d", errPluginBadResponse, resp.ID, req.ID)
+	}
+	if !resp.OK {
+		return resp, fmt.Errorf("%w: %s: %s", errPluginFailed, req.Op, resp.Error)
+	}
+	return resp, nil
+}
+
+// Artifacts implements ArtifactStore by exchanging files with the plugin.
+type Artifacts struct {
+	backend *Backend
+}
+
+// Has asks the plugin whether the artifact exists.
+func (s *Artifacts) Has(ctx context.Context, key string) (bool, error) {
+	resp, err := s.backend.call(ctx, Request{Op: opArtifactHas, Key: key})
+	if err != nil {
+		return false, err
+	}
+	return resp.Found, nil
+}
+
+// Fetch asks the plugin to write the artifact to a temporary file.
+func (s *Artifacts) Fetch(ctx context.Context, key string) (cacheManager.ArtifactFile, error) {
+	tmpFile, cleanup, err := s.TempFile(ctx, ".artifact-")
+	if err != nil {
+		return cacheManager.ArtifactFile{}, err
+	}
+	if err := tmpFile.Close(); err != nil {
+		cleanup()
+		return cacheManager.ArtifactFile{}, err
+	}
+	resp, err := s.backend.call(ctx, Request{Op: opArtifactFetch, Key: key, Path: tmpFile.Name()})
+	if err != nil {
+		cleanup()
+		return cacheManager.ArtifactFile{}, err
+	}
+	if !resp.Found {
+		cleanup()
+		return cacheManager.ArtifactFile{}, fmt.Errorf("%w: %s", os.ErrNotExist, key)
+	}
+	return cacheManager.ArtifactFile{Path: tmpFile.Name(), Cleanup: cleanup, Meta: resp.Meta}, nil
+}
+
+// TempFile creates a local staging file.
+func (s *Artifacts) TempFile(_ context.Context, prefix string) (*os.File, func(), error) {
+	file, err := os.CreateTemp(s.backend.tempDir, prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		_ = os.Remove(file.Name())
+	}
+	return file, cleanup, nil
+}
+
+// Commit hands the staged artifact to the plugin; the local file stays usable until Cleanup.
+func (s *Artifacts) Commit(ctx context.Context, key, tmpPath string, meta map[string]string) (cacheManager.ArtifactFile, error) {
+	if _, err := s.backend.call(ctx, Request{Op: opArtifactCommit, Key: key, Path: tmpPath, Meta: meta}); err != nil {
+		return cacheManager.ArtifactFile{}, err
+	}
+	cleanup := func() {
+		_ = os.Remove(tmpPath)
+	}
+	return cacheManager.ArtifactFile{Path: tmpPath, Cleanup: cleanup, Meta: meta}, nil
+}
+
+// Delete asks the plugin to remove the artifact.
+func (s *Artifacts) Delete(ctx context.Context, key string) error {
+	_, err := s.backend.call(ctx, Request{Op: opArtifactDelete, Key: key})
+	return err
+}
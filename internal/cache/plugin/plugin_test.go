@@ -0,0 +1,150 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// TestHelperPlugin is re-executed as the plugin process by TestBackendRoundTrip.
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv("GO_GALAXY_PLUGIN_HELPER") != "1" {
+		t.Skip("helper process")
+	}
+	artifacts := map[string][]byte{}
+	var state json.RawMessage
+	in := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout)
+	for in.Scan() {
+		var req Request
+		if err := json.Unmarshal(in.Bytes(), &req); err != nil {
+			os.Exit(2)
+		}
+		resp := Response{ID: req.ID, OK: true}
+		switch req.Op {
+		case opSaveStore:
+			state = req.Store
+		case opLoadStore:
+			resp.Store = state
+		case opArtifactCommit:
+			data, err := os.ReadFile(req.Path)
+			if err != nil {
+				resp.OK, resp.Error = false, err.Error()
+			}
+			artifacts[req.Key] = data
+		case opArtifactHas:
+			_, resp.Found = artifacts[req.Key]
+		case opArtifactFetch:
+			data, ok := artifacts[req.Key]
+			resp.Found = ok
+			if ok {
+				if err := os.WriteFile(req.Path, data, 0o600); err != nil {
+					resp.OK, resp.Error = false, err.Error()
+				}
+			}
+		}
+		_ = out.Encode(resp)
+	}
+	os.Exit(0)
+}
+
+func TestBackendRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "plugin.sh")
+	body := fmt.Sprintf("#!/bin/sh\nexec %q -test.run=TestHelperPlugin\n", os.Args[0])
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	t.Setenv("GO_GALAXY_PLUGIN_HELPER", "1")
+
+	ctx := context.Background()
+	b, err := New(script, "", dir)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	if err := b.Open(ctx); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	defer func() {
+		_ = b.Close(ctx)
+	}()
+
+	st := store.New()
+	st.SetGraph("a.b:1.0.0", []string{"c.d:2.0.0"})
+	if err := b.SaveStore(ctx, st); err != nil {
+		t.Fatalf("SaveStore error: %v", err)
+	}
+	loaded, err := b.LoadStore(ctx)
+	if err != nil {
+		t.Fatalf("LoadStore error: %v", err)
+	}
+	if deps := loaded.GraphSnapshot()["a.b:1.0.0"]; len(deps) != 1 || deps[0] != "c.d:2.0.0" {
+		t.Fatalf("unexpected graph: %v", loaded.GraphSnapshot())
+	}
+
+	artifacts := b.Artifacts()
+	tmp, _, err := artifacts.TempFile(ctx, ".download-")
+	if err != nil {
+		t.Fatalf("TempFile error: %v", err)
+	}
+	_, _ = tmp.WriteString("tarball")
+	_ = tmp.Close()
+	committed, err := artifacts.Commit(ctx, "a-b-1.0.0.tar.gz", tmp.Name(), nil)
+	if err != nil {
+		t.Fatalf("Commit error: %v", err)
+	}
+	committed.Cleanup()
+	if ok, err := artifacts.Has(ctx, "a-b-1.0.0.tar.gz"); err != nil || !ok {
+		t.Fatalf("Has = %v, %v", ok, err)
+	}
+	fetched, err := artifacts.Fetch(ctx, "a-b-1.0.0.tar.gz")
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	defer fetched.Cleanup()
+	data, err := os.ReadFile(fetched.Path)
+	if err != nil || string(data) != "tarball" {
+		t.Fatalf("fetched %q, %v", data, err)
+	}
+}
+
+func TestBackendKillsHungPlugin(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	script := filepath.Join(dir, "plugin.sh")
+	body := "#!/bin/sh\nread line\necho '{\"id\":1,\"ok\":true}'\nread line\nexec sleep 60\n"
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	b, err := New(script, "", dir)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	if err := b.Open(context.Background()); err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := b.Lock(ctx); !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, errPluginFailed) {
+		t.Fatalf("expected the hung call to fail with its context, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("call returned after %s", elapsed)
+	}
+	if _, err := b.LoadStore(context.Background()); !errors.Is(err, errPluginNotRunning) {
+		t.Fatalf("expected errPluginNotRunning after the kill, got %v", err)
+	}
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package plugin
+
+import "errors"
+
+var (
+	errPluginPathEmpty   = errors.New("cache plugin path is empty")
+	errPluginNotRunning  = errors.New("cache plugin is not running")
+	errPluginFailed      = errors.New("cache plugin request failed")
+	errPluginBadResponse = errors.New("cache plugin response id mismatch")
+)
+
+const (
+	opOpen                = "open"
+	opClose               = "close"
+	opLock                = "lock"
+	opUnlock              = "unlock"
+	opLoadStore           = "load_store"
+	opSaveStore           = "save_store"
+	opClearFiles          = "clear_files"
+	opRecordProject       = "record_project"
+	opLoadProjectRegistry = "load_project_registry"
+	opArtifactHas         = "artifact_has"
+	opArtifactFetch       = "artifact_fetch"
+	opArtifactCommit      = "artifact_commit"
+	opArtifactDelete      = "artifact_delete"
+
+	maxResponseSize = 256 << 20
+)
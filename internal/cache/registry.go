@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+const (
+	// BackendLocal is the built-in filesystem backend.
+	BackendLocal = "local"
+	// BackendLocalJSON is the filesystem backend keeping the store in one JSON file.
+	BackendLocalJSON = "local-json"
+	// BackendS3 is the built-in S3 backend.
+	BackendS3 = "s3"
+
+	execBackendPrefix = "exec:"
+)
+
+// Factory constructs a cache backend from configuration.
+type Factory func(cfg *config.Config, runtime *infra.Infra) (cacheManager.Backend, error)
+
+//nolint:gochecknoglobals // compile-time backend registry, filled by Register.
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		BackendLocal:     newLocalBackend,
+		BackendLocalJSON: newLocalJSONBackend,
+		BackendS3:        newS3Backend,
+	}
+)
+
+// Register makes a backend available under name for --cache-backend.
+// It is meant to be called from an init function of a package linked into the binary
+// and panics on duplicate or empty registrations.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" || factory == nil {
+		panic("cache: Register with empty name or nil factory")
+	}
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("cache: backend %q registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// Registered returns the sorted names of registered backends.
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// lookup returns the factory registered under name.
+func lookup(name string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := registry[name]
+	return factory, ok
+}
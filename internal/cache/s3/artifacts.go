@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"sort"
 	"strings"
 
 	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
@@ -16,16 +17,22 @@ import (
 
 // Artifacts implements ArtifactStore backed by S3 objects.
 type Artifacts struct {
-	client  *Client
-	prefix  string
-	tmpBase string
+	client   *Client
+	prefix   string
+	indexKey string
+	tmpBase  string
+	index    artifactIndex
 }
 
 // Has reports whether the artifact exists in S3.
+// It answers from the artifact index and falls back to a HEAD request when the index cannot be read.
 func (s *Artifacts) Has(ctx context.Context, key string) (bool, error) {
 	if s.client == nil {
 		return false, errS3ClientNil
 	}
+	if ok, err := s.lookup(ctx, key); err == nil {
+		return ok, nil
+	}
 	_, err := s.client.headObject(ctx, s.objectKey(key))
 	if err == nil {
 		return true, nil
@@ -49,6 +56,9 @@ func (s *Artifacts) Fetch(ctx context.Context, key string) (cacheManager.Artifac
 	if err != nil {
 		_ = tmpFile.Close()
 		cleanupIfNeeded(cleanup)
+		if errors.Is(err, errS3NotFound) {
+			s.forgetIndex(key)
+		}
 		return cacheManager.ArtifactFile{}, err
 	}
 	if err := tmpFile.Close(); err != nil {
@@ -59,6 +69,7 @@ func (s *Artifacts) Fetch(ctx context.Context, key string) (cacheManager.Artifac
 		cleanupIfNeeded(cleanup)
 		return cacheManager.ArtifactFile{}, err
 	}
+	s.recordIndex(key, 0, hex.EncodeToString(sum))
 	return cacheManager.ArtifactFile{Path: tmpFile.Name(), Cleanup: cleanup, Meta: meta}, nil
 }
 
@@ -134,18 +145,41 @@ func (s *Artifacts) Commit(ctx context.Context, key, tmpPath string, meta map[st
 	if err := s.client.putObject(ctx, s.objectKey(key), file, info.Size(), "application/gzip", "", meta, false, payloadHash); err != nil {
 		return cacheManager.ArtifactFile{}, err
 	}
+	s.recordIndex(key, info.Size(), payloadHash)
 	cleanup := func() {
 		_ = os.Remove(tmpPath)
 	}
 	return cacheManager.ArtifactFile{Path: tmpPath, Cleanup: cleanup, Meta: meta}, nil
 }
 
+// List returns the keys of cached artifacts from the artifact index.
+func (s *Artifacts) List(ctx context.Context) ([]string, error) {
+	if s.client == nil {
+		return nil, errS3ClientNil
+	}
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	if err := s.loadIndexLocked(ctx); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(s.index.entries))
+	for key := range s.index.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
 // Delete removes an artifact from S3.
 func (s *Artifacts) Delete(ctx context.Context, key string) error {
 	if s.client == nil {
 		return errS3ClientNil
 	}
-	return s.client.deleteObject(ctx, s.objectKey(key))
+	if err := s.client.deleteObject(ctx, s.objectKey(key)); err != nil {
+		return err
+	}
+	s.forgetIndex(key)
+	return nil
 }
 
 // objectKey builds a full S3 object key for an artifact key.
@@ -14,10 +14,12 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
 	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
 	"github.com/greeddj/go-galaxy/internal/galaxy/store"
 	gzip "github.com/klauspost/pgzip"
 )
@@ -30,6 +32,7 @@ type Backend struct {
 	prefix     string
 	artifacts  *Artifacts
 	tempDir    string
+	auditSeq   atomic.Int64
 }
 
 // New creates an S3-backed cache backend for the given config.
@@ -66,9 +69,10 @@ func (b *Backend) Open(ctx context.Context) error {
 		return err
 	}
 	b.artifacts = &Artifacts{
-		client:  client,
-		prefix:  b.key(artifactsPrefix),
-		tmpBase: b.tempDir,
+		client:   client,
+		prefix:   b.key(artifactsPrefix),
+		indexKey: b.key(statePrefix, indexObject),
+		tmpBase:  b.tempDir,
 	}
 	return nil
 }
@@ -130,7 +134,10 @@ func (b *Backend) SaveStore(ctx context.Context, st *store.Store) error {
 	}
 	key := b.key(statePrefix, storeObject)
 	reader := bytes.NewReader(buf.Bytes())
-	return b.client.putObject(ctx, key, reader, int64(buf.Len()), "application/json", "gzip", nil, false, "")
+	if err := b.client.putObject(ctx, key, reader, int64(buf.Len()), "application/json", "gzip", nil, false, ""); err != nil {
+		return err
+	}
+	return b.artifacts.flushIndex(ctx)
 }
 
 // ClearFiles removes cached artifacts from S3.
@@ -148,56 +155,48 @@ func (b *Backend) ClearFiles(ctx context.Context) error {
 			return err
 		}
 	}
+	b.artifacts.resetIndex()
 	return nil
 }
 
-// RecordProject records the project metadata in S3.
-func (b *Backend) RecordProject(ctx context.Context, requirementsFile, downloadPath string) error {
+// LoadResolution reads the resolution stored for reqHash, or nil when there is none.
+func (b *Backend) LoadResolution(ctx context.Context, reqHash string) (*store.Resolution, error) {
 	if err := b.Open(ctx); err != nil {
-		return err
-	}
-	registry, err := b.LoadProjectRegistry(ctx)
-	if err != nil {
-		return err
-	}
-	if registry.Projects == nil {
-		registry.Projects = make(map[string]store.ProjectRecord)
+		return nil, err
 	}
-	absReq, err := filepath.Abs(requirementsFile)
+	data, err := b.readObject(ctx, b.resolutionKey(reqHash))
 	if err != nil {
-		absReq = requirementsFile
+		if errors.Is(err, errS3NotFound) {
+			return nil, nil
+		}
+		return nil, err
 	}
-	projectPath := filepath.Dir(absReq)
-	collectionsPath := resolveCollectionsPath(projectPath, downloadPath)
-	registry.Projects[projectPath] = store.ProjectRecord{
-		RequirementsFile: absReq,
-		CollectionsPath:  collectionsPath,
-		LastRun:          time.Now().UTC(),
+	var res store.Resolution
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
 	}
-	return b.saveProjectRegistry(ctx, registry)
+	return &res, nil
 }
 
-// LoadProjectRegistry loads the project registry from S3.
-func (b *Backend) LoadProjectRegistry(ctx context.Context) (*store.ProjectRegistry, error) {
+// SaveResolution writes res as its own object keyed by its requirements hash.
+func (b *Backend) SaveResolution(ctx context.Context, res *store.Resolution) error {
+	if res == nil || res.RequirementsHash == "" {
+		return nil
+	}
 	if err := b.Open(ctx); err != nil {
-		return nil, err
+		return err
 	}
-	key := b.key(statePrefix, projectsObject)
-	data, err := b.readObject(ctx, key)
+	payload, err := json.Marshal(res)
 	if err != nil {
-		if errors.Is(err, errS3NotFound) {
-			return &store.ProjectRegistry{Projects: make(map[string]store.ProjectRecord)}, nil
-		}
-		return nil, err
-	}
-	var registry store.ProjectRegistry
-	if err := json.Unmarshal(data, &registry); err != nil {
-		return &store.ProjectRegistry{Projects: make(map[string]store.ProjectRecord)}, nil
-	}
-	if registry.Projects == nil {
-		registry.Projects = make(map[string]store.ProjectRecord)
+		return err
 	}
-	return &registry, nil
+	reader := bytes.NewReader(payload)
+	return b.client.putObject(ctx, b.resolutionKey(res.RequirementsHash), reader, int64(len(payload)), "application/json", "", nil, false, "")
+}
+
+// resolutionKey returns the object key of the resolution for reqHash.
+func (b *Backend) resolutionKey(reqHash string) string {
+	return b.key(statePrefix, resolutionsDir, path.Base(reqHash)+".json")
 }
 
 // Artifacts returns the S3-backed artifact store.
@@ -249,9 +248,28 @@ func (b *Backend) handleExistingLock(ctx context.Context, lockKey string, releas
 		}
 		return nil, err
 	}
+	event := cacheManager.NewAuditEvent(cacheManager.AuditLockSteal, lockKey, "")
+	event.Detail = fmt.Sprintf("expired lock from host %s pid %s", headers.Get("X-Amz-Meta-Host"), headers.Get("X-Amz-Meta-Pid"))
+	_ = b.AppendAudit(ctx, event)
 	return release, nil
 }
 
+// AppendAudit writes an audit event as its own object under the audit prefix.
+// S3 has no append, so one object per event keeps concurrent writers from clobbering each other.
+func (b *Backend) AppendAudit(ctx context.Context, event cacheManager.AuditEvent) error {
+	if err := b.Open(ctx); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	seq := b.auditSeq.Add(1)
+	name := fmt.Sprintf("%s-%s-%d-%d.json", event.Time.Format("20060102T150405.000000000Z"), event.Host, event.PID, seq)
+	key := b.key(helpers.StoreAuditPrefix, name)
+	return b.client.putObject(ctx, key, bytes.NewReader(payload), int64(len(payload)), "application/json", "", nil, false, "")
+}
+
 // putLock writes a lock object with metadata for this process.
 func (b *Backend) putLock(ctx context.Context, lockKey string) error {
 	host, _ := os.Hostname()
@@ -292,20 +310,6 @@ func (b *Backend) readObject(ctx context.Context, key string) ([]byte, error) {
 	return io.ReadAll(buffered)
 }
 
-// saveProjectRegistry writes the project registry to S3.
-func (b *Backend) saveProjectRegistry(ctx context.Context, registry *store.ProjectRegistry) error {
-	if registry == nil {
-		return nil
-	}
-	payload, err := json.MarshalIndent(registry, "", "  ")
-	if err != nil {
-		return err
-	}
-	key := b.key(statePrefix, projectsObject)
-	reader := bytes.NewReader(payload)
-	return b.client.putObject(ctx, key, reader, int64(len(payload)), "application/json", "", nil, false, "")
-}
-
 // key builds a key under the configured S3 prefix.
 func (b *Backend) key(parts ...string) string {
 	if len(parts) == 0 {
@@ -153,20 +153,29 @@ func (c *Client) deleteObject(ctx context.Context, key string) error {
 
 // listObjects returns object keys under the given prefix.
 func (c *Client) listObjects(ctx context.Context, prefix string) ([]string, error) {
-	keys := []string{}
+	entries, err := c.listObjectEntries(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return appendKeys([]string{}, entries), nil
+}
+
+// listObjectEntries returns object entries (key, size, mtime) under the given prefix.
+func (c *Client) listObjectEntries(ctx context.Context, prefix string) ([]listBucketContent, error) {
+	var entries []listBucketContent
 	var token string
 	for {
 		result, err := c.listObjectsPage(ctx, prefix, token)
 		if err != nil {
 			return nil, err
 		}
-		keys = appendKeys(keys, result.Contents)
+		entries = append(entries, result.Contents...)
 		if !result.IsTruncated || result.NextContinuationToken == "" {
 			break
 		}
 		token = result.NextContinuationToken
 	}
-	return keys, nil
+	return entries, nil
 }
 
 func resolvePayloadHash(body io.ReadSeeker, payloadHash string) (string, error) {
@@ -355,7 +364,9 @@ type listBucketResult struct {
 
 // listBucketContent represents an object entry in a ListBucket response.
 type listBucketContent struct {
-	Key string `xml:"Key"`
+	Key          string    `xml:"Key"`
+	Size         int64     `xml:"Size"`
+	LastModified time.Time `xml:"LastModified"`
 }
 
 // listBucketPrefix represents a common prefix entry in a ListBucket response.
@@ -0,0 +1,106 @@
+package s3
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+)
+
+const fakeBucket = "cache"
+
+// fakeS3 is an in-memory, path-style S3 endpoint for backend tests.
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	requests map[string]int
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	t.Helper()
+	fake := &fakeS3{objects: make(map[string][]byte), requests: make(map[string]int)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	return fake, srv
+}
+
+func (f *fakeS3) config(endpoint string) config.S3CacheConfig {
+	return config.S3CacheConfig{
+		Enabled:   true,
+		Endpoint:  endpoint,
+		Region:    "us-east-1",
+		Bucket:    fakeBucket,
+		AccessKey: "key",
+		SecretKey: "secret",
+		PathStyle: true,
+	}
+}
+
+func (f *fakeS3) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[method]
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+fakeBucket), "/")
+	if key == "" {
+		f.serveBucket(w, r)
+		return
+	}
+	f.requests[r.Method]++
+	data, ok := f.objects[key]
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	case http.MethodPut:
+		if ok && r.Header.Get("If-None-Match") == "*" {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) serveBucket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		return
+	}
+	f.requests["LIST"]++
+	prefix := r.URL.Query().Get("prefix")
+	result := listBucketResult{}
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		result.Contents = append(result.Contents, listBucketContent{
+			Key:          key,
+			Size:         int64(len(f.objects[key])),
+			LastModified: time.Now().UTC(),
+		})
+	}
+	_ = xml.NewEncoder(w).Encode(result)
+}
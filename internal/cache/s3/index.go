@@ -0,0 +1,169 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// artifactIndex mirrors the artifact listing in memory so Has does not need a
+// HEAD request per key. It is loaded from a single index object, rebuilt with
+// ListObjects when that object is missing, and written back on SaveStore.
+type artifactIndex struct {
+	mu      sync.Mutex
+	loaded  bool
+	dirty   bool
+	entries map[string]artifactIndexEntry
+}
+
+// artifactIndexFile is the persisted form of the artifact index.
+type artifactIndexFile struct {
+	SchemaVersion int                           `json:"schema_version"`
+	UpdatedAt     time.Time                     `json:"updated_at"`
+	Artifacts     map[string]artifactIndexEntry `json:"artifacts"`
+}
+
+// artifactIndexEntry describes one cached artifact.
+type artifactIndexEntry struct {
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256,omitempty"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// lookup reports whether key is indexed, loading the index on first use.
+func (s *Artifacts) lookup(ctx context.Context, key string) (bool, error) {
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	if err := s.loadIndexLocked(ctx); err != nil {
+		return false, err
+	}
+	_, ok := s.index.entries[key]
+	return ok, nil
+}
+
+// loadIndexLocked reads the index object or rebuilds it from a listing.
+func (s *Artifacts) loadIndexLocked(ctx context.Context) error {
+	if s.index.loaded {
+		return nil
+	}
+	entries, err := s.readIndex(ctx)
+	if errors.Is(err, errS3NotFound) || errors.Is(err, errS3IndexUnsupported) {
+		entries, err = s.rebuildIndex(ctx)
+		s.index.dirty = err == nil
+	}
+	if err != nil {
+		return err
+	}
+	s.index.entries = entries
+	s.index.loaded = true
+	return nil
+}
+
+// readIndex downloads and decodes the index object.
+func (s *Artifacts) readIndex(ctx context.Context) (map[string]artifactIndexEntry, error) {
+	resp, err := s.client.getObject(ctx, s.indexKey)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var file artifactIndexFile
+	if err := json.Unmarshal(data, &file); err != nil || file.SchemaVersion != artifactIndexSchemaVersion {
+		return nil, errS3IndexUnsupported
+	}
+	if file.Artifacts == nil {
+		file.Artifacts = make(map[string]artifactIndexEntry)
+	}
+	return file.Artifacts, nil
+}
+
+// rebuildIndex lists the artifacts prefix once to recover the index.
+func (s *Artifacts) rebuildIndex(ctx context.Context) (map[string]artifactIndexEntry, error) {
+	objects, err := s.client.listObjectEntries(ctx, s.prefix+"/")
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]artifactIndexEntry, len(objects))
+	for _, obj := range objects {
+		key := strings.TrimPrefix(obj.Key, s.prefix+"/")
+		if key == "" || key == obj.Key {
+			continue
+		}
+		entries[key] = artifactIndexEntry{Size: obj.Size, LastUsed: obj.LastModified}
+	}
+	return entries, nil
+}
+
+// recordIndex adds or refreshes an entry when the index is loaded.
+func (s *Artifacts) recordIndex(key string, size int64, sha string) {
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	if !s.index.loaded {
+		return
+	}
+	entry := s.index.entries[key]
+	if size > 0 {
+		entry.Size = size
+	}
+	if sha != "" {
+		entry.SHA256 = sha
+	}
+	entry.LastUsed = time.Now().UTC()
+	s.index.entries[key] = entry
+	s.index.dirty = true
+}
+
+// forgetIndex drops an entry when the index is loaded.
+func (s *Artifacts) forgetIndex(key string) {
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	if !s.index.loaded {
+		return
+	}
+	if _, ok := s.index.entries[key]; ok {
+		delete(s.index.entries, key)
+		s.index.dirty = true
+	}
+}
+
+// resetIndex replaces the index with an empty one, e.g. after clearing artifacts.
+func (s *Artifacts) resetIndex() {
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	s.index.entries = make(map[string]artifactIndexEntry)
+	s.index.loaded = true
+	s.index.dirty = true
+}
+
+// flushIndex writes the index object when it changed since the last write.
+func (s *Artifacts) flushIndex(ctx context.Context) error {
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	if !s.index.loaded || !s.index.dirty {
+		return nil
+	}
+	payload, err := json.Marshal(artifactIndexFile{
+		SchemaVersion: artifactIndexSchemaVersion,
+		UpdatedAt:     time.Now().UTC(),
+		Artifacts:     s.index.entries,
+	})
+	if err != nil {
+		return err
+	}
+	reader := bytes.NewReader(payload)
+	if err := s.client.putObject(ctx, s.indexKey, reader, int64(len(payload)), "application/json", "", nil, false, ""); err != nil {
+		return err
+	}
+	s.index.dirty = false
+	return nil
+}
@@ -0,0 +1,68 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+func TestArtifactIndexAvoidsHeadRequests(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fake, srv := newFakeS3(t)
+	fake.objects["artifacts/a-b-1.0.0.tar.gz"] = []byte("existing")
+
+	backend, err := New(fake.config(srv.URL), srv.Client(), t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := backend.Open(ctx); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	artifacts := backend.Artifacts()
+	for key, want := range map[string]bool{"a-b-1.0.0.tar.gz": true, "c-d-1.0.0.tar.gz": false} {
+		ok, err := artifacts.Has(ctx, key)
+		if err != nil || ok != want {
+			t.Fatalf("Has(%s) = %v, %v; want %v", key, ok, err, want)
+		}
+	}
+
+	tmp := filepath.Join(t.TempDir(), "upload")
+	if err := os.WriteFile(tmp, []byte("new"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := artifacts.Commit(ctx, "c-d-1.0.0.tar.gz", tmp, nil); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if ok, _ := artifacts.Has(ctx, "c-d-1.0.0.tar.gz"); !ok {
+		t.Fatalf("expected committed artifact in index")
+	}
+	if err := backend.SaveStore(ctx, store.New()); err != nil {
+		t.Fatalf("SaveStore: %v", err)
+	}
+	if got := fake.count(http.MethodHead); got != 0 {
+		t.Fatalf("expected no HEAD requests, got %d", got)
+	}
+	if got := fake.count("LIST"); got != 1 {
+		t.Fatalf("expected one listing to build the index, got %d", got)
+	}
+
+	// A second backend reads the persisted index instead of listing again.
+	reopened, err := New(fake.config(srv.URL), srv.Client(), t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := reopened.Open(ctx); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if ok, err := reopened.Artifacts().Has(ctx, "c-d-1.0.0.tar.gz"); err != nil || !ok {
+		t.Fatalf("Has after reopen = %v, %v", ok, err)
+	}
+	if got := fake.count("LIST"); got != 1 {
+		t.Fatalf("expected persisted index to be reused, got %d listings", got)
+	}
+}
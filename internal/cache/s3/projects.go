@@ -0,0 +1,130 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// Each project is its own object under state/projects/, keyed by its
+// registry slot, so parallel pipelines never overwrite each other's records.
+// The registry is merged from these objects on read, together with the
+// single projects.json written by older versions.
+
+// projectObject is the stored form of one registry entry.
+type projectObject struct {
+	Path   string              `json:"path"`
+	Record store.ProjectRecord `json:"record"`
+}
+
+// RecordProject records the project metadata in S3.
+func (b *Backend) RecordProject(ctx context.Context, run store.ProjectRun) error {
+	if err := b.Open(ctx); err != nil {
+		return err
+	}
+	projectPath, record := store.NewProjectRecord(run)
+	payload, err := json.MarshalIndent(projectObject{Path: projectPath, Record: record}, "", "  ")
+	if err != nil {
+		return err
+	}
+	reader := bytes.NewReader(payload)
+	key := b.projectKey(store.ProjectSlot(projectPath, record))
+	return b.client.putObject(ctx, key, reader, int64(len(payload)), "application/json", "", nil, false, "")
+}
+
+// LoadProjectRegistry loads the project registry from S3.
+func (b *Backend) LoadProjectRegistry(ctx context.Context) (*store.ProjectRegistry, error) {
+	if err := b.Open(ctx); err != nil {
+		return nil, err
+	}
+	registry, err := b.loadLegacyRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := b.client.listObjects(ctx, b.key(statePrefix, projectsDir)+"/")
+	if err != nil {
+		return nil, err
+	}
+	objects, err := b.readProjects(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	// Oldest first, so a newer run wins a slot shared with an older one.
+	slices.SortFunc(objects, func(x, y projectObject) int { return x.Record.LastRun.Compare(y.Record.LastRun) })
+	for _, obj := range objects {
+		registry.Put(obj.Path, obj.Record)
+	}
+	return registry, nil
+}
+
+// readProjects downloads project objects a few at a time. Objects that vanish
+// or do not parse are skipped.
+func (b *Backend) readProjects(ctx context.Context, keys []string) ([]projectObject, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		objects  = make([]projectObject, 0, len(keys))
+		firstErr error
+	)
+	slots := make(chan struct{}, projectReadWorkers)
+	for _, key := range keys {
+		if !strings.HasSuffix(key, ".json") {
+			continue
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			data, err := b.readObject(ctx, key)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if !errors.Is(err, errS3NotFound) && firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			var obj projectObject
+			if json.Unmarshal(data, &obj) == nil && obj.Path != "" {
+				objects = append(objects, obj)
+			}
+		}()
+	}
+	wg.Wait()
+	return objects, firstErr
+}
+
+// loadLegacyRegistry reads the single registry object of older versions.
+func (b *Backend) loadLegacyRegistry(ctx context.Context) (*store.ProjectRegistry, error) {
+	empty := &store.ProjectRegistry{Projects: make(map[string]store.ProjectRecord)}
+	data, err := b.readObject(ctx, b.key(statePrefix, projectsObject))
+	if err != nil {
+		if errors.Is(err, errS3NotFound) {
+			return empty, nil
+		}
+		return nil, err
+	}
+	var registry store.ProjectRegistry
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return empty, nil
+	}
+	if registry.Projects == nil {
+		registry.Projects = make(map[string]store.ProjectRecord)
+	}
+	return &registry, nil
+}
+
+// projectKey returns the object key of the project filed under slot.
+func (b *Backend) projectKey(slot string) string {
+	sum := sha256.Sum256([]byte(slot))
+	return b.key(statePrefix, projectsDir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,59 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+func TestRecordProjectConcurrentRunsKeepEveryProject(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fake, srv := newFakeS3(t)
+	fake.objects["state/projects.json"] = []byte(`{"projects":{"/srv/legacy":{"requirements_file":"/srv/legacy/requirements.yml"}}}`)
+
+	const runs = 12
+	root := t.TempDir()
+	var wg sync.WaitGroup
+	errs := make(chan error, runs)
+	for i := range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			backend, err := New(fake.config(srv.URL), srv.Client(), t.TempDir())
+			if err != nil {
+				errs <- err
+				return
+			}
+			errs <- backend.RecordProject(ctx, store.ProjectRun{
+				RequirementsFile: filepath.Join(root, fmt.Sprintf("project-%d", i), "requirements.yml"),
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("RecordProject: %v", err)
+		}
+	}
+
+	backend, err := New(fake.config(srv.URL), srv.Client(), t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	registry, err := backend.LoadProjectRegistry(ctx)
+	if err != nil {
+		t.Fatalf("LoadProjectRegistry: %v", err)
+	}
+	if len(registry.Projects) != runs+1 {
+		t.Fatalf("expected %d projects, got %d: %v", runs+1, len(registry.Projects), registry.Projects)
+	}
+	if _, ok := registry.Projects["/srv/legacy"]; !ok {
+		t.Fatal("records of the legacy registry object must be kept")
+	}
+}
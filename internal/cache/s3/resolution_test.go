@@ -0,0 +1,42 @@
+package s3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+func TestResolutionRoundTrip(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fake, srv := newFakeS3(t)
+
+	backend, err := New(fake.config(srv.URL), srv.Client(), t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if res, err := backend.LoadResolution(ctx, "abc"); err != nil || res != nil {
+		t.Fatalf("missing resolution = %v, %v; want nil, nil", res, err)
+	}
+
+	want := &store.Resolution{
+		RequirementsHash: "abc",
+		Server:           "https://galaxy.example",
+		Resolved:         map[string]store.ResolvedEntry{"a.b": {Version: "1.0.0"}},
+		Graph:            map[string][]string{"a.b@1.0.0": {}},
+	}
+	if err := backend.SaveResolution(ctx, want); err != nil {
+		t.Fatalf("SaveResolution: %v", err)
+	}
+	if _, ok := fake.objects["state/resolutions/abc.json"]; !ok {
+		t.Fatalf("resolution not stored under its hash: %v", fake.objects)
+	}
+	got, err := backend.LoadResolution(ctx, "abc")
+	if err != nil {
+		t.Fatalf("LoadResolution: %v", err)
+	}
+	if got.Resolved["a.b"].Version != "1.0.0" || got.Server != want.Server {
+		t.Fatalf("unexpected resolution: %+v", got)
+	}
+}
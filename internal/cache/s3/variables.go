@@ -27,6 +27,7 @@ var (
 	errS3DeleteFailed           = errors.New("s3 delete object failed")
 	errS3ClientNil              = errors.New("s3 client is nil")
 	errArtifactSHA256Mismatch   = errors.New("s3 artifact sha256 mismatch")
+	errS3IndexUnsupported       = errors.New("s3 artifact index is unreadable")
 )
 
 const (
@@ -36,8 +37,15 @@ const (
 	locksPrefix     = "locks"
 	storeObject     = "store.json.gz"
 	projectsObject  = "projects.json"
+	projectsDir     = "projects"
+	resolutionsDir  = "resolutions"
+	indexObject     = "artifacts-index.json"
 	lockObject      = "cache.lock"
 	lockTTL         = 10 * time.Minute
 	peekBytes       = 2
 	headerLength    = 2
+
+	projectReadWorkers = 8
+
+	artifactIndexSchemaVersion = 1
 )
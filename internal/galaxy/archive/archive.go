@@ -2,8 +2,6 @@ package archive
 
 import (
 	"archive/tar"
-	"crypto/sha256"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -15,8 +13,47 @@ import (
 	"github.com/klauspost/pgzip"
 )
 
+// Decompression tunes the gzip reader; zero values keep the pgzip defaults.
+type Decompression struct {
+	// Blocks is the number of blocks decoded ahead of the tar reader.
+	Blocks int
+	// BlockSize is the size of each read-ahead block in bytes.
+	BlockSize int
+}
+
+// Limits caps what one archive may unpack beyond the size caps; zero values
+// keep helpers.ArchiveMaxEntries and helpers.ArchiveMaxPathDepth.
+type Limits struct {
+	// MaxEntries is the number of tar entries read, of any type.
+	MaxEntries int
+	// MaxDepth is the number of path components of one entry.
+	MaxDepth int
+}
+
+// withDefaults fills unset limits with the built-in caps.
+func (l Limits) withDefaults() Limits {
+	if l.MaxEntries <= 0 {
+		l.MaxEntries = helpers.ArchiveMaxEntries
+	}
+	if l.MaxDepth <= 0 {
+		l.MaxDepth = helpers.ArchiveMaxPathDepth
+	}
+	return l
+}
+
+// Options tunes ExtractTarGz.
+type Options struct {
+	Decompression Decompression
+	Limits        Limits
+	// Progress, when set, is called after each regular file is written with
+	// its archive name and the number of regular files written so far.
+	Progress func(name string, files int)
+}
+
 // ExtractTarGz extracts a tar.gz archive into dstDir with safety checks.
-func ExtractTarGz(tarGzFile, dstDir string) error {
+// Errors about an entry name it and its byte offset in the uncompressed tar
+// stream.
+func ExtractTarGz(tarGzFile, dstDir string, opts Options) error {
 	info, err := os.Stat(tarGzFile)
 	if err != nil {
 		return fmt.Errorf("failed to stat file %s: %w", tarGzFile, err)
@@ -34,7 +71,7 @@ func ExtractTarGz(tarGzFile, dstDir string) error {
 		_ = file.Close()
 	}()
 
-	uncompressedStream, err := pgzip.NewReader(file)
+	uncompressedStream, err := pgzip.NewReaderN(file, opts.Decompression.BlockSize, opts.Decompression.Blocks)
 	if err != nil {
 		return fmt.Errorf("failed to create gzip reader: %w", err)
 	}
@@ -42,27 +79,56 @@ func ExtractTarGz(tarGzFile, dstDir string) error {
 		_ = uncompressedStream.Close()
 	}()
 
-	tarReader := tar.NewReader(uncompressedStream)
-	return extractTarEntries(tarReader, dstDir)
+	opts.Limits = opts.Limits.withDefaults()
+	return extractTarEntries(&countingReader{r: uncompressedStream}, dstDir, opts)
+}
+
+// countingReader counts the bytes read through it, which gives the offset of
+// each entry in the uncompressed tar stream.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements io.Reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
 }
 
-func extractTarEntries(tarReader *tar.Reader, dstDir string) error {
+func extractTarEntries(stream *countingReader, dstDir string, opts Options) error {
+	tarReader := tar.NewReader(stream)
 	var extracted int64
-	for {
+	files := 0
+	last := "start of archive"
+	for entries := 0; ; entries++ {
 		header, err := tarReader.Next()
 		if errors.Is(err, io.EOF) {
 			return nil
 		}
 		if err != nil {
-			return fmt.Errorf("error reading tar archive: %w", err)
+			return fmt.Errorf("error reading tar archive after %s at offset %d: %w", last, stream.n, err)
+		}
+		// the header has been read, so the count is where the entry's data starts
+		offset := stream.n
+		last = header.Name
+		if entries >= opts.Limits.MaxEntries {
+			return fmt.Errorf("%w: more than %d", helpers.ErrArchiveTooManyEntries, opts.Limits.MaxEntries)
 		}
-		if err := handleTarEntry(tarReader, header, dstDir, &extracted); err != nil {
-			return err
+		if err := handleTarEntry(tarReader, header, dstDir, opts.Limits, &extracted); err != nil {
+			return fmt.Errorf("entry %s at offset %d: %w", header.Name, offset, err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			files++
+			if opts.Progress != nil {
+				opts.Progress(header.Name, files)
+			}
 		}
 	}
 }
 
-func handleTarEntry(tarReader *tar.Reader, header *tar.Header, dstDir string, extracted *int64) error {
+func handleTarEntry(tarReader *tar.Reader, header *tar.Header, dstDir string, limits Limits, extracted *int64) error {
 	relPath, err := sanitizeArchivePath(header.Name)
 	if err != nil {
 		return err
@@ -70,6 +136,9 @@ func handleTarEntry(tarReader *tar.Reader, header *tar.Header, dstDir string, ex
 	if relPath == "" {
 		return nil
 	}
+	if depth := strings.Count(relPath, string(os.PathSeparator)) + 1; depth > limits.MaxDepth {
+		return fmt.Errorf("%w: %d levels, at most %d", helpers.ErrArchiveEntryTooDeep, depth, limits.MaxDepth)
+	}
 	targetPath := filepath.Join(dstDir, relPath)
 	if err := ensureNoSymlinkParents(dstDir, relPath); err != nil {
 		return err
@@ -98,10 +167,10 @@ func extractDir(targetPath string) error {
 
 func extractRegularFile(tarReader *tar.Reader, header *tar.Header, targetPath string, extracted *int64) error {
 	if header.Size < 0 {
-		return fmt.Errorf("%w: %s ", helpers.ErrArchiveEntryHasNegativeSize, header.Name)
+		return fmt.Errorf("%w: %d", helpers.ErrArchiveEntryHasNegativeSize, header.Size)
 	}
 	if header.Size > helpers.ArchiveMaxEntrySize {
-		return fmt.Errorf("%w %s: %d bytes", helpers.ErrArchiveEntryIsTooLarge, header.Name, header.Size)
+		return fmt.Errorf("%w: %d bytes", helpers.ErrArchiveEntryIsTooLarge, header.Size)
 	}
 	if *extracted+header.Size > helpers.ArchiveMaxTotalSize {
 		return fmt.Errorf("%w: %d bytes", helpers.ErrArchiveExceedsMaxSize, helpers.ArchiveMaxTotalSize)
@@ -164,20 +233,7 @@ func extractHardlink(dstDir, targetPath string, header *tar.Header) error {
 
 // FileHashSHA256 calculates the SHA256 hash of a file on disk.
 func FileHashSHA256(path string) (string, error) {
-	//nolint:gosec // path is caller-provided and expected for hashing.
-	f, err := os.Open(path)
-	if err != nil {
-		return "", err
-	}
-	defer func() {
-		_ = f.Close()
-	}()
-
-	h := sha256.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(h.Sum(nil)), nil
+	return FileHash(path, HashSHA256)
 }
 
 // sanitizeArchivePath validates and normalizes a tar entry path.
@@ -2,7 +2,9 @@ package archive
 
 import (
 	"errors"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
@@ -45,3 +47,71 @@ func TestSanitizeArchivePath(t *testing.T) {
 		})
 	}
 }
+
+// newCollectionTarball packs a collection with three files.
+func newCollectionTarball(t *testing.T) string {
+	t.Helper()
+	src := t.TempDir()
+	dir := filepath.Join(src, "ansible_collections", "a", "b")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, name := range []string{"MANIFEST.json", "FILES.json", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	tarball := filepath.Join(t.TempDir(), "a-b.tar.gz")
+	if err := CreateTarGz(src, tarball, "ansible_collections"); err != nil {
+		t.Fatalf("CreateTarGz: %v", err)
+	}
+	return tarball
+}
+
+func TestExtractTarGzEnforcesLimits(t *testing.T) {
+	t.Parallel()
+	tarball := newCollectionTarball(t)
+	tests := []struct {
+		name   string
+		limits Limits
+		want   error
+	}{
+		{name: "defaults", limits: Limits{}},
+		{name: "entries", limits: Limits{MaxEntries: 3}, want: helpers.ErrArchiveTooManyEntries},
+		{name: "depth", limits: Limits{MaxDepth: 3}, want: helpers.ErrArchiveEntryTooDeep},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ExtractTarGz(tarball, t.TempDir(), Options{Limits: tt.limits})
+			if tt.want == nil && err != nil {
+				t.Fatalf("ExtractTarGz: %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestExtractTarGzReportsProgressAndEntryContext(t *testing.T) {
+	t.Parallel()
+	tarball := newCollectionTarball(t)
+	var names []string
+	last := 0
+	opts := Options{Progress: func(name string, files int) {
+		names = append(names, name)
+		last = files
+	}}
+	if err := ExtractTarGz(tarball, t.TempDir(), opts); err != nil {
+		t.Fatalf("ExtractTarGz: %v", err)
+	}
+	if last != 3 || len(names) != 3 {
+		t.Fatalf("expected progress for 3 files, got %d after %v", last, names)
+	}
+
+	err := ExtractTarGz(tarball, t.TempDir(), Options{Limits: Limits{MaxDepth: 3}})
+	if err == nil || !strings.Contains(err.Error(), "entry ansible_collections/a/b/") || !strings.Contains(err.Error(), " at offset ") {
+		t.Fatalf("expected the entry name and offset in the error, got %v", err)
+	}
+}
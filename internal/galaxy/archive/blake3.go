@@ -0,0 +1,228 @@
+package archive
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// BLAKE3 parameters from the specification: 64-byte blocks, 1 KiB chunks
+// and a 32-byte default output.
+const (
+	blake3BlockLen = 64
+	blake3ChunkLen = 1024
+	blake3OutLen   = 32
+	// blake3MaxDepth is the deepest a tree over 2^64 bytes of chunks gets.
+	blake3MaxDepth = 54
+
+	blake3ChunkStart = 1 << 0
+	blake3ChunkEnd   = 1 << 1
+	blake3Parent     = 1 << 2
+	blake3Root       = 1 << 3
+)
+
+// blake3IV returns the BLAKE3 initialization vector, the same words as SHA-256's.
+func blake3IV() [8]uint32 {
+	return [8]uint32{0x6A09E667, 0xBB67AE85, 0x3C6EF372, 0xA54FF53A, 0x510E527F, 0x9B05688C, 0x1F83D9AB, 0x5BE0CD19}
+}
+
+// blake3G is the quarter-round mixing function.
+func blake3G(s *[16]uint32, a, b, c, d int, mx, my uint32) {
+	s[a] += s[b] + mx
+	s[d] = bits.RotateLeft32(s[d]^s[a], -16)
+	s[c] += s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], -12)
+	s[a] += s[b] + my
+	s[d] = bits.RotateLeft32(s[d]^s[a], -8)
+	s[c] += s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], -7)
+}
+
+// blake3Round mixes the columns and then the diagonals of the state.
+func blake3Round(s *[16]uint32, m *[16]uint32) {
+	blake3G(s, 0, 4, 8, 12, m[0], m[1])
+	blake3G(s, 1, 5, 9, 13, m[2], m[3])
+	blake3G(s, 2, 6, 10, 14, m[4], m[5])
+	blake3G(s, 3, 7, 11, 15, m[6], m[7])
+	blake3G(s, 0, 5, 10, 15, m[8], m[9])
+	blake3G(s, 1, 6, 11, 12, m[10], m[11])
+	blake3G(s, 2, 7, 8, 13, m[12], m[13])
+	blake3G(s, 3, 4, 9, 14, m[14], m[15])
+}
+
+// blake3Permute reorders the message words between rounds.
+func blake3Permute(m *[16]uint32) {
+	*m = [16]uint32{m[2], m[6], m[3], m[10], m[7], m[0], m[4], m[13], m[1], m[11], m[12], m[5], m[9], m[14], m[15], m[8]}
+}
+
+// blake3Compress runs the compression function over one block and returns
+// the full 16-word output; its first 8 words are the new chaining value.
+func blake3Compress(cv [8]uint32, block [16]uint32, counter uint64, blockLen, flags uint32) [16]uint32 {
+	iv := blake3IV()
+	s := [16]uint32{
+		cv[0], cv[1], cv[2], cv[3], cv[4], cv[5], cv[6], cv[7],
+		iv[0], iv[1], iv[2], iv[3], uint32(counter), uint32(counter >> 32), blockLen, flags,
+	}
+	for round := range 7 {
+		if round > 0 {
+			blake3Permute(&block)
+		}
+		blake3Round(&s, &block)
+	}
+	for i := range 8 {
+		s[i] ^= s[i+8]
+		s[i+8] ^= cv[i]
+	}
+	return s
+}
+
+// blake3Words reads a block, zero padded to 64 bytes, as little-endian words.
+func blake3Words(p []byte) [16]uint32 {
+	var buf [blake3BlockLen]byte
+	copy(buf[:], p)
+	var words [16]uint32
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(buf[i*4:])
+	}
+	return words
+}
+
+// blake3Output is a node whose compression is deferred until it is known
+// whether it is the root.
+type blake3Output struct {
+	cv       [8]uint32
+	block    [16]uint32
+	counter  uint64
+	blockLen uint32
+	flags    uint32
+}
+
+func (o blake3Output) chainingValue() [8]uint32 {
+	out := blake3Compress(o.cv, o.block, o.counter, o.blockLen, o.flags)
+	return [8]uint32(out[:8])
+}
+
+func (o blake3Output) rootBytes(b []byte) []byte {
+	out := blake3Compress(o.cv, o.block, 0, o.blockLen, o.flags|blake3Root)
+	for _, word := range out[:blake3OutLen/4] {
+		b = binary.LittleEndian.AppendUint32(b, word)
+	}
+	return b
+}
+
+func blake3ParentOutput(left, right [8]uint32) blake3Output {
+	var block [16]uint32
+	copy(block[:8], left[:])
+	copy(block[8:], right[:])
+	return blake3Output{cv: blake3IV(), block: block, blockLen: blake3BlockLen, flags: blake3Parent}
+}
+
+// blake3Chunk hashes the blocks of one 1 KiB chunk.
+type blake3Chunk struct {
+	cv         [8]uint32
+	counter    uint64
+	block      [blake3BlockLen]byte
+	blockLen   int
+	compressed int
+}
+
+func newBLAKE3Chunk(counter uint64) blake3Chunk {
+	return blake3Chunk{cv: blake3IV(), counter: counter}
+}
+
+func (c *blake3Chunk) len() int {
+	return c.compressed*blake3BlockLen + c.blockLen
+}
+
+func (c *blake3Chunk) startFlag() uint32 {
+	if c.compressed == 0 {
+		return blake3ChunkStart
+	}
+	return 0
+}
+
+// update adds p, which must fit in the chunk. The last block is kept back,
+// since it is compressed with the chunk end flag.
+func (c *blake3Chunk) update(p []byte) {
+	for len(p) > 0 {
+		if c.blockLen == blake3BlockLen {
+			out := blake3Compress(c.cv, blake3Words(c.block[:]), c.counter, blake3BlockLen, c.startFlag())
+			c.cv = [8]uint32(out[:8])
+			c.compressed++
+			c.blockLen = 0
+		}
+		n := copy(c.block[c.blockLen:], p)
+		c.blockLen += n
+		p = p[n:]
+	}
+}
+
+func (c *blake3Chunk) output() blake3Output {
+	return blake3Output{
+		cv:       c.cv,
+		block:    blake3Words(c.block[:c.blockLen]),
+		counter:  c.counter,
+		blockLen: uint32(c.blockLen), //nolint:gosec // blockLen is at most 64.
+		flags:    c.startFlag() | blake3ChunkEnd,
+	}
+}
+
+// blake3Digest is an unkeyed BLAKE3 hash with the default 32-byte output.
+// Chunks are hashed one after another; the chaining values of complete
+// subtrees are kept on a stack and merged as the tree grows.
+type blake3Digest struct {
+	chunk  blake3Chunk
+	stack  [blake3MaxDepth][8]uint32
+	stackN int
+}
+
+func newBLAKE3() hash.Hash {
+	return &blake3Digest{chunk: newBLAKE3Chunk(0)}
+}
+
+func (d *blake3Digest) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if d.chunk.len() == blake3ChunkLen {
+			d.pushChunk(d.chunk.output().chainingValue(), d.chunk.counter+1)
+			d.chunk = newBLAKE3Chunk(d.chunk.counter + 1)
+		}
+		take := min(blake3ChunkLen-d.chunk.len(), len(p))
+		d.chunk.update(p[:take])
+		p = p[take:]
+	}
+	return n, nil
+}
+
+// pushChunk adds the chaining value of a finished chunk, merging every
+// subtree it completes; total is the number of chunks hashed so far.
+func (d *blake3Digest) pushChunk(cv [8]uint32, total uint64) {
+	for total&1 == 0 {
+		d.stackN--
+		cv = blake3ParentOutput(d.stack[d.stackN], cv).chainingValue()
+		total >>= 1
+	}
+	d.stack[d.stackN] = cv
+	d.stackN++
+}
+
+// Sum appends the hash to b without changing the state.
+func (d *blake3Digest) Sum(b []byte) []byte {
+	out := d.chunk.output()
+	for i := d.stackN - 1; i >= 0; i-- {
+		out = blake3ParentOutput(d.stack[i], out.chainingValue())
+	}
+	return out.rootBytes(b)
+}
+
+func (d *blake3Digest) Reset() {
+	*d = blake3Digest{chunk: newBLAKE3Chunk(0)}
+}
+
+func (d *blake3Digest) Size() int {
+	return blake3OutLen
+}
+
+func (d *blake3Digest) BlockSize() int {
+	return blake3BlockLen
+}
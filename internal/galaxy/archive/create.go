@@ -0,0 +1,126 @@
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/klauspost/pgzip"
+)
+
+// CreateTarGz writes the named entries of srcDir, recursively, into a tar.gz at dstFile.
+// The archive is written next to dstFile and renamed into place.
+func CreateTarGz(srcDir, dstFile string, names ...string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dstFile), "."+filepath.Base(dstFile)+".tmp-")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		_ = os.Remove(tmpPath)
+	}()
+
+	gz := pgzip.NewWriter(tmp)
+	if err := WriteTar(gz, srcDir, "", names...); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(helpers.FileMod); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, dstFile)
+}
+
+// WriteTar writes the named entries of srcDir, recursively, as an uncompressed tar stream.
+// When prefix is set, entries are placed below it and its parent directories are emitted first.
+func WriteTar(w io.Writer, srcDir, prefix string, names ...string) error {
+	tw := tar.NewWriter(w)
+	prefix = strings.Trim(filepath.ToSlash(prefix), "/")
+	if prefix != "" {
+		parts := strings.Split(prefix, "/")
+		for i := range parts {
+			header := &tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     strings.Join(parts[:i+1], "/") + "/",
+				Mode:     int64(helpers.DirMod),
+			}
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range names {
+		if err := addTree(tw, srcDir, prefix, name); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// addTree appends srcDir/name and everything below it to tw, placed under prefix.
+func addTree(tw *tar.Writer, srcDir, prefix, name string) error {
+	root := filepath.Join(srcDir, name)
+	return filepath.WalkDir(root, func(current string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, current)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if d.Type()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(current); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", rel, err)
+		}
+		header.Name = path.Join(prefix, filepath.ToSlash(rel))
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFileInto(tw, current)
+	})
+}
+
+// copyFileInto streams a regular file into the current tar entry.
+func copyFileInto(w io.Writer, path string) error {
+	//nolint:gosec // path comes from walking the directory being archived.
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	_, err = io.Copy(w, f)
+	return err
+}
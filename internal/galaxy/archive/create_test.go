@@ -0,0 +1,47 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateTarGzRoundTrip(t *testing.T) {
+	t.Parallel()
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "ansible_collections", "a", "b"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "ansible_collections", "a", "b", "MANIFEST.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Symlink("MANIFEST.json", filepath.Join(src, "ansible_collections", "a", "b", "link.json")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "install-manifest.json"), []byte("manifest"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "ignored.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := CreateTarGz(src, bundle, "ansible_collections", "install-manifest.json"); err != nil {
+		t.Fatalf("CreateTarGz: %v", err)
+	}
+	dst := t.TempDir()
+	if err := ExtractTarGz(bundle, dst, Options{}); err != nil {
+		t.Fatalf("ExtractTarGz: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dst, "ansible_collections", "a", "b", "link.json"))
+	if err != nil || string(data) != "{}" {
+		t.Fatalf("unexpected symlinked content %q: %v", data, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "install-manifest.json")); err != nil || string(data) != "manifest" {
+		t.Fatalf("unexpected manifest %q: %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "ignored.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected ignored.txt to be left out, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+// Supported artifact hash algorithms.
+const (
+	HashSHA256 = "sha256"
+	HashSHA512 = "sha512"
+	HashBLAKE3 = "blake3"
+)
+
+// NewHash returns a hasher for algo.
+func NewHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case HashSHA256:
+		return sha256.New(), nil
+	case HashSHA512:
+		return sha512.New(), nil
+	case HashBLAKE3:
+		return newBLAKE3(), nil
+	default:
+		return nil, fmt.Errorf("%w: %q (supported: %s, %s, %s)",
+			helpers.ErrUnsupportedArtifactHash, algo, HashSHA256, HashSHA512, HashBLAKE3)
+	}
+}
+
+// FileHash calculates the algo hash of a file on disk as lowercase hex.
+func FileHash(path, algo string) (string, error) {
+	h, err := NewHash(algo)
+	if err != nil {
+		return "", err
+	}
+	//nolint:gosec // path is caller-provided and expected for hashing.
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
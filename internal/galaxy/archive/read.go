@@ -0,0 +1,49 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// ReadTarGzFile returns the content of the regular file name inside the
+// tar.gz archive at tarGzFile, reading at most limit bytes of it. A missing
+// entry is reported as os.ErrNotExist.
+func ReadTarGzFile(tarGzFile, name string, limit int64) ([]byte, error) {
+	//nolint:gosec // tarGzFile is an artifact path chosen by the caller.
+	f, err := os.Open(tarGzFile)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+	tr := tar.NewReader(zr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: %s", os.ErrNotExist, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg || path.Clean(header.Name) != name {
+			continue
+		}
+		if header.Size > limit {
+			return nil, fmt.Errorf("%s: %d bytes exceeds %d", name, header.Size, limit)
+		}
+		return io.ReadAll(io.LimitReader(tr, limit))
+	}
+}
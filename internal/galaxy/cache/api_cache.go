@@ -1,15 +1,22 @@
 package cache
 
 import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
 	"github.com/greeddj/go-galaxy/internal/galaxy/store"
 )
 
@@ -22,7 +29,7 @@ func apiCacheKey(url string) string {
 // FetchJSONWithCachePolicy fetches JSON with cache policy and unmarshals into out.
 func FetchJSONWithCachePolicy(ctx context.Context, client *http.Client, url string, st *store.Store, out any, policy Policy) error {
 	if st == nil || (!policy.Read && !policy.Write) {
-		body, _, _, _, err := fetchJSONBody(ctx, client, url, nil)
+		body, _, _, _, err := fetchJSONBody(ctx, client, url, nil, policy)
 		if err != nil {
 			return err
 		}
@@ -53,6 +60,7 @@ func tryServeFromCache(
 		return false, nil
 	}
 	if ok := serveFreshCache(entry, out, policy); ok {
+		st.AddStats(store.CacheCounters{APIHits: 1})
 		return true, nil
 	}
 	return revalidateCache(ctx, client, url, st, key, entry, out, policy)
@@ -85,16 +93,18 @@ func revalidateCache(
 	out any,
 	policy Policy,
 ) (bool, error) {
-	body, etag, lastModified, notModified, err := fetchJSONBody(ctx, client, url, &entry)
+	body, etag, lastModified, notModified, err := fetchJSONBody(ctx, client, url, &entry, policy)
 	if err != nil {
 		return false, err
 	}
 	if notModified {
+		st.AddStats(store.CacheCounters{APIHits: 1})
 		if policy.Write {
 			st.SetAPICache(key, refreshAPICacheEntry(entry, etag, lastModified))
 		}
 		return true, json.Unmarshal(entry.Body, out)
 	}
+	st.AddStats(store.CacheCounters{APIMisses: 1})
 	if policy.Write {
 		st.SetAPICache(key, newAPICacheEntry(url, body, etag, lastModified, policy.TTL))
 	}
@@ -103,10 +113,11 @@ func revalidateCache(
 
 // fetchAndStore downloads JSON and optionally stores it in the cache.
 func fetchAndStore(ctx context.Context, client *http.Client, url string, st *store.Store, key string, out any, policy Policy) error {
-	body, etag, lastModified, _, err := fetchJSONBody(ctx, client, url, nil)
+	body, etag, lastModified, _, err := fetchJSONBody(ctx, client, url, nil, policy)
 	if err != nil {
 		return err
 	}
+	st.AddStats(store.CacheCounters{APIMisses: 1})
 	if policy.Write {
 		st.SetAPICache(key, newAPICacheEntry(url, body, etag, lastModified, policy.TTL))
 	}
@@ -138,11 +149,34 @@ func refreshAPICacheEntry(entry store.APICacheEntry, etag, lastModified string)
 }
 
 // fetchJSONBody fetches JSON bytes and validation headers for a URL.
-func fetchJSONBody(ctx context.Context, client *http.Client, url string, entry *store.APICacheEntry) ([]byte, string, string, bool, error) {
+// The body is capped at the policy's size limit and reading it is aborted
+// once it stops making progress for the policy's read timeout, so a
+// misbehaving server can neither hang the resolver nor balloon its memory.
+// Waiting for the response headers is bounded by the client timeout.
+func fetchJSONBody(
+	ctx context.Context,
+	client *http.Client,
+	url string,
+	entry *store.APICacheEntry,
+	policy Policy,
+) ([]byte, string, string, bool, error) {
+	maxBody := policy.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = helpers.FetchMaxMetadataBytes
+	}
+	readTimeout := policy.ReadTimeout
+	if readTimeout <= 0 {
+		readTimeout = helpers.FetchMetadataReadTimeout
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
 	if err != nil {
 		return nil, "", "", false, err
 	}
+	// set explicitly, the transport leaves decoding to decodeContent
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
 	if entry != nil {
 		if entry.ETag != "" {
 			req.Header.Set("If-None-Match", entry.ETag)
@@ -158,6 +192,10 @@ func fetchJSONBody(ctx context.Context, client *http.Client, url string, entry *
 	defer func() {
 		_ = resp.Body.Close()
 	}()
+	stall := time.AfterFunc(readTimeout, func() {
+		cancel(fmt.Errorf("%w: no data for %s from %s", helpers.ErrMetadataStalled, readTimeout, url))
+	})
+	defer stall.Stop()
 
 	if resp.StatusCode == http.StatusNotModified {
 		return nil, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), true, nil
@@ -165,9 +203,72 @@ func fetchJSONBody(ctx context.Context, client *http.Client, url string, entry *
 	if resp.StatusCode != http.StatusOK {
 		return nil, "", "", false, &HTTPStatusError{URL: url, Status: resp.Status, Code: resp.StatusCode}
 	}
+	if resp.ContentLength > maxBody {
+		return nil, "", "", false, fmt.Errorf("%w: %d bytes from %s", helpers.ErrMetadataTooLarge, resp.ContentLength, url)
+	}
 
-	body, err := io.ReadAll(resp.Body)
-	return body, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), false, err
+	decoded, err := decodeContent(resp.Header.Get("Content-Encoding"), &progressReader{r: resp.Body, stall: stall, timeout: readTimeout})
+	if err != nil {
+		return nil, "", "", false, fmt.Errorf("%s: %w", url, stallCause(ctx, err))
+	}
+	// the limit applies to the decoded body, so a small gzip bomb is cut off too
+	body, err := io.ReadAll(io.LimitReader(decoded, maxBody+1))
+	if err != nil {
+		return nil, "", "", false, stallCause(ctx, err)
+	}
+	if int64(len(body)) > maxBody {
+		return nil, "", "", false, fmt.Errorf("%w: over %d bytes from %s", helpers.ErrMetadataTooLarge, maxBody, url)
+	}
+	return body, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), false, nil
+}
+
+// decodeContent returns r decoded according to a Content-Encoding header.
+// deflate is zlib-wrapped per RFC 9110, but raw deflate streams, which some
+// servers send instead, are accepted as well.
+func decodeContent(encoding string, r io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return r, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		br := bufio.NewReader(r)
+		header, err := br.Peek(2)
+		if err != nil {
+			return nil, err
+		}
+		// a zlib header is CMF FLG with CM 8 and a check value divisible by 31
+		if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", helpers.ErrUnsupportedContentEncoding, encoding)
+	}
+}
+
+// progressReader pushes the stall timer back whenever data arrives.
+type progressReader struct {
+	r       io.Reader
+	stall   *time.Timer
+	timeout time.Duration
+}
+
+// Read implements io.Reader.
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.stall.Reset(p.timeout)
+	}
+	return n, err
+}
+
+// stallCause returns the stall error when the stall timer cancelled ctx, else err.
+func stallCause(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); errors.Is(cause, helpers.ErrMetadataStalled) {
+		return cause
+	}
+	return err
 }
 
 // HTTPStatusError describes a non-200 HTTP response.
@@ -184,3 +285,24 @@ func (e *HTTPStatusError) Error() string {
 	}
 	return "failed to fetch metadata: " + e.Status
 }
+
+// HTTPStatus returns the response status code.
+func (e *HTTPStatusError) HTTPStatus() int {
+	return e.Code
+}
+
+// SeedAPICache stores body as a fresh cached response for url, as if it had
+// just been fetched.
+func SeedAPICache(st *store.Store, url string, body []byte) {
+	st.SetAPICache(apiCacheKey(url), newAPICacheEntry(url, body, "", "", 0))
+}
+
+// CachedAPIBody returns the cached response body for url, if there is one
+// that FetchJSONWithCachePolicy would serve.
+func CachedAPIBody(st *store.Store, url string) ([]byte, bool) {
+	entry, ok := st.GetAPICache(apiCacheKey(url))
+	if !isValidCacheEntry(ok, entry, url) {
+		return nil, false
+	}
+	return entry.Body, true
+}
@@ -2,13 +2,19 @@ package cache
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
+	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
 	"github.com/greeddj/go-galaxy/internal/galaxy/store"
 )
 
@@ -112,3 +118,94 @@ func TestFetchJSONWithCachePolicyRevalidate(t *testing.T) {
 		t.Fatalf("expected If-None-Match on revalidate")
 	}
 }
+
+func TestFetchJSONBodyLimits(t *testing.T) {
+	t.Parallel()
+	large := &http.Client{
+		Transport: roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				Status:        http.StatusText(http.StatusOK),
+				Header:        make(http.Header),
+				ContentLength: -1,
+				Body:          io.NopCloser(bytes.NewReader(bytes.Repeat([]byte(" "), 64))),
+			}, nil
+		}),
+	}
+	var out map[string]any
+	err := FetchJSONWithCachePolicy(context.Background(), large, "https://example.com/big", nil, &out, Policy{MaxBodyBytes: 16})
+	if !errors.Is(err, helpers.ErrMetadataTooLarge) {
+		t.Fatalf("expected ErrMetadataTooLarge, got %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":`))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	err = FetchJSONWithCachePolicy(context.Background(), srv.Client(), srv.URL, nil, &out, Policy{ReadTimeout: 50 * time.Millisecond})
+	if !errors.Is(err, helpers.ErrMetadataStalled) {
+		t.Fatalf("expected ErrMetadataStalled, got %v", err)
+	}
+}
+
+func TestFetchJSONBodyDecodesContentEncoding(t *testing.T) {
+	t.Parallel()
+	payload := []byte(`{"data":[{"version":"1.0.0"}]}`)
+	var gz, zl, raw bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write(payload)
+	_ = gw.Close()
+	zw := zlib.NewWriter(&zl)
+	_, _ = zw.Write(payload)
+	_ = zw.Close()
+	fw, _ := flate.NewWriter(&raw, flate.DefaultCompression)
+	_, _ = fw.Write(payload)
+	_ = fw.Close()
+
+	for _, tc := range []struct {
+		encoding string
+		body     []byte
+	}{{"", payload}, {"gzip", gz.Bytes()}, {"deflate", zl.Bytes()}, {"deflate", raw.Bytes()}} {
+		client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if got := req.Header.Get("Accept-Encoding"); got != "gzip, deflate" {
+				t.Errorf("expected gzip and deflate to be accepted, got %q", got)
+			}
+			header := make(http.Header)
+			if tc.encoding != "" {
+				header.Set("Content-Encoding", tc.encoding)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(bytes.NewReader(tc.body))}, nil
+		})}
+		body, _, _, _, err := fetchJSONBody(context.Background(), client, "https://example.com/api", nil, Policy{})
+		if err != nil || !bytes.Equal(body, payload) {
+			t.Fatalf("%q: expected the decoded payload, got %q, %v", tc.encoding, body, err)
+		}
+	}
+
+	client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		header := http.Header{"Content-Encoding": {"br"}}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(bytes.NewReader(payload))}, nil
+	})}
+	_, _, _, _, err := fetchJSONBody(context.Background(), client, "https://example.com/api", nil, Policy{})
+	if !errors.Is(err, helpers.ErrUnsupportedContentEncoding) {
+		t.Fatalf("expected ErrUnsupportedContentEncoding, got %v", err)
+	}
+}
+
+func TestFetchJSONBodyLimitsDecodedSize(t *testing.T) {
+	t.Parallel()
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write(bytes.Repeat([]byte(" "), 1<<20))
+	_ = gw.Close()
+	client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		header := http.Header{"Content-Encoding": {"gzip"}}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(bytes.NewReader(gz.Bytes()))}, nil
+	})}
+	_, _, _, _, err := fetchJSONBody(context.Background(), client, "https://example.com/api", nil, Policy{MaxBodyBytes: 1 << 16})
+	if !errors.Is(err, helpers.ErrMetadataTooLarge) {
+		t.Fatalf("expected ErrMetadataTooLarge for a %d byte gzip body, got %v", gz.Len(), err)
+	}
+}
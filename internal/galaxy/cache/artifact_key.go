@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// artifactKeySourceSeparator separates the version from the source digest
+	// in format 2 keys. It cannot appear in a semantic version.
+	artifactKeySourceSeparator = "~"
+	// artifactKeySourceDigestLen is how many hex digits of the source digest a key carries.
+	artifactKeySourceDigestLen = 12
+)
+
+// ArtifactKey builds the format 2 cache key of a collection tarball,
+// <namespace>-<name>-<version>~<source digest>.tar.gz. The digest of the
+// server URL keeps servers that serve different bytes under the same
+// version apart in a shared cache.
+func ArtifactKey(namespace, name, version, source string) string {
+	filename := fmt.Sprintf("%s-%s-%s%s%s.tar.gz", namespace, name, version, artifactKeySourceSeparator, sourceDigest(source))
+	return url.QueryEscape(filename)
+}
+
+// LegacyArtifactKey builds the format 1 cache key,
+// <namespace>-<name>-<version>.tar.gz, which artifacts cached before
+// format 2 are stored under.
+func LegacyArtifactKey(namespace, name, version string) string {
+	filename := fmt.Sprintf("%s-%s-%s.tar.gz", namespace, name, version)
+	return url.QueryEscape(filename)
+}
+
+// sourceDigest returns the short digest of a server URL, ignoring
+// surrounding whitespace and trailing slashes.
+func sourceDigest(source string) string {
+	sum := sha256.Sum256([]byte(strings.TrimRight(strings.TrimSpace(source), "/")))
+	return hex.EncodeToString(sum[:])[:artifactKeySourceDigestLen]
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"time"
+)
+
+// Audit operations recorded for mutating cache actions.
+const (
+	AuditArtifactCommit = "artifact.commit"
+	AuditArtifactDelete = "artifact.delete"
+	AuditStoreSave      = "store.save"
+	AuditCacheClear     = "cache.clear"
+	AuditLockSteal      = "lock.steal"
+)
+
+// AuditEvent is one entry of the cache audit journal.
+type AuditEvent struct {
+	Time   time.Time `json:"time"`
+	User   string    `json:"user"`
+	Host   string    `json:"host"`
+	PID    int       `json:"pid"`
+	Op     string    `json:"op"`
+	Key    string    `json:"key,omitempty"`
+	SHA256 string    `json:"sha256,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// AuditSink is implemented by backends that can persist audit events beside their state.
+type AuditSink interface {
+	AppendAudit(ctx context.Context, event AuditEvent) error
+}
+
+// AppendAudit records event when b keeps an audit journal and does nothing otherwise.
+func AppendAudit(ctx context.Context, b Backend, event AuditEvent) error {
+	sink, ok := b.(AuditSink)
+	if !ok {
+		return nil
+	}
+	return sink.AppendAudit(ctx, event)
+}
+
+// NewAuditEvent returns an event stamped with the current user, host, pid and time.
+func NewAuditEvent(op, key, sha256 string) AuditEvent {
+	host, _ := os.Hostname()
+	name := ""
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	return AuditEvent{
+		Time:   time.Now().UTC(),
+		User:   name,
+		Host:   host,
+		PID:    os.Getpid(),
+		Op:     op,
+		Key:    key,
+		SHA256: sha256,
+	}
+}
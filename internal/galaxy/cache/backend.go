@@ -23,6 +23,25 @@ type ArtifactStore interface {
 	Delete(ctx context.Context, key string) error
 }
 
+// ArtifactLister is implemented by artifact stores that can enumerate their keys.
+type ArtifactLister interface {
+	List(ctx context.Context) ([]string, error)
+}
+
+// PartialLoader is implemented by backends that can load part of the store.
+type PartialLoader interface {
+	LoadStoreWith(ctx context.Context, opts store.LoadOptions) (*store.Store, error)
+}
+
+// LoadStoreWith loads the parts of the store selected by opts when the
+// backend supports it, and the whole store otherwise.
+func LoadStoreWith(ctx context.Context, b Backend, opts store.LoadOptions) (*store.Store, error) {
+	if loader, ok := b.(PartialLoader); ok {
+		return loader.LoadStoreWith(ctx, opts)
+	}
+	return b.LoadStore(ctx)
+}
+
 // Backend defines a cache backend for state and artifacts.
 type Backend interface {
 	Open(ctx context.Context) error
@@ -31,7 +50,7 @@ type Backend interface {
 	LoadStore(ctx context.Context) (*store.Store, error)
 	SaveStore(ctx context.Context, st *store.Store) error
 	ClearFiles(ctx context.Context) error
-	RecordProject(ctx context.Context, requirementsFile, downloadPath string) error
+	RecordProject(ctx context.Context, run store.ProjectRun) error
 	LoadProjectRegistry(ctx context.Context) (*store.ProjectRegistry, error)
 	Artifacts() ArtifactStore
 }
@@ -0,0 +1,23 @@
+package cache
+
+import "context"
+
+// lockGuardKey keys the cancel func WithLockGuard stores in its context.
+type lockGuardKey struct{}
+
+// WithLockGuard returns a context for work done under a cache lock. A lock
+// taken with it that is lost while held, such as a lease that can no longer
+// be renewed, cancels the context through LockLost, so the work stops
+// instead of writing to a cache another process may now own.
+func WithLockGuard(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return context.WithValue(ctx, lockGuardKey{}, cancel), func() { cancel(nil) }
+}
+
+// LockLost cancels the work guarded by ctx with cause. Without a guard it
+// does nothing.
+func LockLost(ctx context.Context, cause error) {
+	if cancel, ok := ctx.Value(lockGuardKey{}).(context.CancelCauseFunc); ok {
+		cancel(cause)
+	}
+}
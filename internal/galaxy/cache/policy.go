@@ -11,6 +11,11 @@ type Policy struct {
 	Read  bool
 	Write bool
 	TTL   time.Duration
+	// MaxBodyBytes caps a response body; zero means helpers.FetchMaxMetadataBytes.
+	MaxBodyBytes int64
+	// ReadTimeout aborts a request without progress for that long;
+	// zero means helpers.FetchMetadataReadTimeout.
+	ReadTimeout time.Duration
 }
 
 // Options exposes cache-related flags used to derive a Policy.
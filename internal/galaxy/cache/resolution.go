@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// ResolutionStore is implemented by backends that keep each resolution as a
+// small standalone object keyed by requirements hash, next to the store.
+type ResolutionStore interface {
+	// LoadResolution returns nil without an error when no resolution exists for reqHash.
+	LoadResolution(ctx context.Context, reqHash string) (*store.Resolution, error)
+	SaveResolution(ctx context.Context, res *store.Resolution) error
+}
+
+// LoadResolution loads the resolution for reqHash when b keeps resolutions.
+func LoadResolution(ctx context.Context, b Backend, reqHash string) (*store.Resolution, error) {
+	rs, ok := b.(ResolutionStore)
+	if !ok {
+		return nil, nil
+	}
+	return rs.LoadResolution(ctx, reqHash)
+}
+
+// SaveResolution saves res when b keeps resolutions and does nothing otherwise.
+func SaveResolution(ctx context.Context, b Backend, res *store.Resolution) error {
+	rs, ok := b.(ResolutionStore)
+	if !ok {
+		return nil
+	}
+	return rs.SaveResolution(ctx, res)
+}
@@ -4,10 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver"
 	cacheBackend "github.com/greeddj/go-galaxy/internal/cache"
@@ -26,6 +26,8 @@ type installedCollection struct {
 	Version        string
 	InstallPath    string
 	CollectionsDir string
+	// Source is the server the collection was installed from, when the store knows it.
+	Source string
 }
 
 type cleanupState struct {
@@ -63,14 +65,34 @@ func Start(ctx context.Context, cfg *config.Config, runtime *infra.Infra) error
 		}
 	}()
 
-	reachable, installedByKey, err := buildReachable(runtime, state.registry)
+	scoped := state.registry
+	if cfg.CleanupProject != "" {
+		if scoped, err = scopeRegistry(state.registry, cfg.CleanupProject); err != nil {
+			return err
+		}
+		runtime.Output.Printf("🎯 cleanup scoped to %d of %d projects", len(scoped.Projects), len(state.registry.Projects))
+	}
+	if cfg.ProjectTTL > 0 {
+		scoped = dropStaleProjects(runtime, scoped, cfg.ProjectTTL, time.Now())
+	}
+	reachable, installedByKey, err := buildReachable(runtime, state.store, scoped)
 	if err != nil {
 		return err
 	}
-	removed, err := removeUnused(ctx, cfg, runtime, state.backend, state.store, reachable, installedByKey)
+	keepCached := func(installedCollection) bool { return false }
+	if cfg.CleanupProject != "" {
+		keepCached = func(inst installedCollection) bool { return installedOutside(state.registry, scoped, inst) }
+	}
+	removed, err := removeUnused(ctx, cfg, runtime, state.backend, state.store, reachable, installedByKey, keepCached)
 	if err != nil {
 		return err
 	}
+	if !cfg.DryRun {
+		pruneEmptyDirs(cfg, runtime, scoped)
+		if cfg.QuarantineDir != "" {
+			purgeQuarantine(runtime, cfg.QuarantineDir, cfg.QuarantineRetention, time.Now())
+		}
+	}
 	return finalizeCleanup(ctx, cfg, runtime, state.backend, state.store, removed)
 }
 
@@ -89,7 +111,7 @@ func initCleanup(ctx context.Context, cfg *config.Config, runtime *infra.Infra)
 		return nil, err
 	}
 	runtime.Output.Printf("🚀 load storage")
-	st, err := backend.LoadStore(ctx)
+	st, err := cacheManager.LoadStoreWith(ctx, backend, cleanupLoadOptions())
 	if err != nil {
 		_ = releaseLock()
 		_ = backend.Close(ctx)
@@ -110,7 +132,22 @@ func initCleanup(ctx context.Context, cfg *config.Config, runtime *infra.Infra)
 	}, nil
 }
 
-func buildReachable(runtime *infra.Infra, registry *store.ProjectRegistry) (map[string]bool, map[string]installedCollection, error) {
+// cleanupLoadOptions limits the store load to the buckets cleanup reads or
+// prunes; the API cache, usually the largest bucket, is left on disk.
+func cleanupLoadOptions() store.LoadOptions {
+	return store.LoadOptions{Buckets: []string{
+		helpers.StoreBucketInstalled,
+		helpers.StoreBucketGraph,
+		helpers.StoreBucketRoots,
+		helpers.StoreBucketDepsCache,
+	}}
+}
+
+func buildReachable(
+	runtime *infra.Infra,
+	st *store.Store,
+	registry *store.ProjectRegistry,
+) (map[string]bool, map[string]installedCollection, error) {
 	reachable := make(map[string]bool)
 	installedIndex := make(map[string][]installedCollection)
 	depsByKey := make(map[string]map[string]string)
@@ -124,12 +161,20 @@ func buildReachable(runtime *infra.Infra, registry *store.ProjectRegistry) (map[
 		if err := scanInstalledCollections(collectionsPath, installedIndex, installedByKey, depsByKey); err != nil {
 			return nil, nil, err
 		}
-		roots, err := loadRequirements(project.RequirementsFile, "")
+		if err := markIgnored(runtime, collectionsPath, reachable, depsByKey, installedIndex); err != nil {
+			return nil, nil, err
+		}
+		requirementsFile := project.RequirementsPath(projectPath)
+		roots, err := loadRequirements(requirementsFile, "")
 		if err != nil {
-			runtime.Output.Printf("⚠️ Failed to load requirements %s: %v", project.RequirementsFile, err)
+			runtime.Output.Printf("⚠️ Failed to load requirements %s: %v", requirementsFile, err)
 			continue
 		}
 		for _, root := range roots {
+			if strings.EqualFold(strings.TrimSpace(root.Type), gitType) {
+				markGitRoot(runtime, st, root, reachable, depsByKey, installedIndex)
+				continue
+			}
 			fqdn := fmt.Sprintf("%s.%s", root.Namespace, root.Name)
 			for _, inst := range selectInstalled(installedIndex, fqdn, root.Version) {
 				markReachable(inst.Key, reachable, depsByKey, installedIndex)
@@ -147,7 +192,13 @@ func removeUnused(
 	st *store.Store,
 	reachable map[string]bool,
 	installedByKey map[string]installedCollection,
+	keepCached func(installedCollection) bool,
 ) (int, error) {
+	verb := "🧹 remove"
+	quarantined := newQuarantine(cfg.QuarantineDir, time.Now())
+	if quarantined != nil {
+		verb = "📦 quarantine"
+	}
 	var removed int
 	for key, inst := range installedByKey {
 		if reachable[key] {
@@ -155,16 +206,28 @@ func removeUnused(
 		}
 		removed++
 		if cfg.DryRun {
-			runtime.Output.Printf("🧹 remove %s", key)
+			runtime.Output.Printf("%s %s", verb, key)
 			continue
 		}
-		if err := removeInstalled(ctx, inst, backend.Artifacts()); err != nil {
+		if entry, ok := st.GetInstalled(key); ok {
+			inst.Source = entry.Source
+		}
+		shared := keepCached(inst)
+		artifacts := backend.Artifacts()
+		if shared {
+			artifacts = nil
+		}
+		if err := removeInstalled(ctx, inst, artifacts, quarantined); err != nil {
 			return removed, err
 		}
-		runtime.Output.Printf("🧹 remove %s", key)
+		runtime.Output.Printf("%s %s", verb, key)
+		if shared {
+			runtime.Output.Debugf("keep cached %s: installed by another project", key)
+			continue
+		}
 		if st != nil {
 			st.DeleteInstalled(key)
-			st.DeleteGraph(key)
+			st.PruneGraph(key)
 			st.DeleteDepsCache(key)
 		}
 	}
@@ -192,11 +255,36 @@ func finalizeCleanup(
 	return nil
 }
 
+// markIgnored keeps collections listed in the collections path's .galaxyignore, and their
+// dependencies, by marking them reachable. An unreadable ignore file aborts cleanup.
+func markIgnored(
+	runtime *infra.Infra,
+	collectionsPath string,
+	reachable map[string]bool,
+	deps map[string]map[string]string,
+	index map[string][]installedCollection,
+) error {
+	ignore, err := loadIgnoreFile(collectionsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s in %s: %w", helpers.GalaxyIgnoreFile, collectionsPath, err)
+	}
+	for _, items := range index {
+		for _, inst := range items {
+			if inst.CollectionsDir != collectionsPath || reachable[inst.Key] || !ignore.matches(inst) {
+				continue
+			}
+			runtime.Output.Debugf("keep %s (%s)", inst.Key, helpers.GalaxyIgnoreFile)
+			markReachable(inst.Key, reachable, deps, index)
+		}
+	}
+	return nil
+}
+
 // pickCollectionsPath chooses the collections path for a project.
 func pickCollectionsPath(projectPath string, project store.ProjectRecord) string {
 	candidates := []string{}
 	if project.CollectionsPath != "" {
-		candidates = append(candidates, project.CollectionsPath)
+		candidates = append(candidates, project.CollectionsDir(projectPath))
 	}
 	if projectPath != "" {
 		candidates = append(candidates, filepath.Join(projectPath, ".collections"), filepath.Join(projectPath, "collections"))
@@ -299,7 +387,7 @@ func extractDeps(manifest types.GalaxyCollectionVersionInfoManifest) map[string]
 
 // normalizeConstraint normalizes semver constraints for matching.
 func normalizeConstraint(value string) string {
-	trimmed := strings.TrimSpace(value)
+	trimmed := helpers.ExpandConstraint(value)
 	if trimmed == "" || trimmed == "*" {
 		return ""
 	}
@@ -353,31 +441,38 @@ func markReachable(key string, reachable map[string]bool, deps map[string]map[st
 	}
 }
 
-// removeInstalled deletes collection files and cached artifacts.
-func removeInstalled(ctx context.Context, inst installedCollection, artifacts cacheManager.ArtifactStore) error {
+// removeInstalled deletes collection files, or moves them into q when set,
+// and cached artifacts, and the collection's namespace directory once it is empty.
+func removeInstalled(ctx context.Context, inst installedCollection, artifacts cacheManager.ArtifactStore, q *quarantine) error {
 	parts := strings.Split(inst.FQDN, ".")
 	if len(parts) != helpers.CollectionNameParts {
 		return nil
 	}
 	namespace := parts[0]
 	name := parts[1]
-	if inst.InstallPath != "" {
-		if err := os.RemoveAll(inst.InstallPath); err != nil {
+	root := filepath.Join(inst.CollectionsDir, "ansible_collections")
+	if q != nil {
+		if err := q.move(inst, namespace, name); err != nil {
 			return err
 		}
+	} else {
+		if inst.InstallPath != "" {
+			if err := os.RemoveAll(inst.InstallPath); err != nil {
+				return err
+			}
+		}
+		infoDir := filepath.Join(root, fmt.Sprintf("%s.%s-%s.info", namespace, name, inst.Version))
+		_ = os.RemoveAll(infoDir)
+	}
+	if inst.InstallPath != "" {
+		pruneEmptyParents(filepath.Dir(inst.InstallPath), root)
 	}
-	infoDir := filepath.Join(inst.CollectionsDir, "ansible_collections", fmt.Sprintf("%s.%s-%s.info", namespace, name, inst.Version))
-	_ = os.RemoveAll(infoDir)
 
 	if artifacts != nil {
-		key := artifactKey(namespace, name, inst.Version)
-		_ = artifacts.Delete(ctx, key)
+		_ = artifacts.Delete(ctx, cacheManager.LegacyArtifactKey(namespace, name, inst.Version))
+		if inst.Source != "" {
+			_ = artifacts.Delete(ctx, cacheManager.ArtifactKey(namespace, name, inst.Version, inst.Source))
+		}
 	}
 	return nil
 }
-
-// artifactKey builds the cache key for an artifact.
-func artifactKey(namespace, name, version string) string {
-	filename := fmt.Sprintf("%s-%s-%s.tar.gz", namespace, name, version)
-	return url.QueryEscape(filename)
-}
@@ -0,0 +1,57 @@
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestBuildReachableKeepsGitRoots(t *testing.T) {
+	t.Parallel()
+	project := t.TempDir()
+	collectionsPath := filepath.Join(project, "collections")
+	writeManifest(t, collectionsPath, "acme", "tools", "1.0.0")
+	writeManifest(t, collectionsPath, "community", "general", "8.0.0")
+	writeManifest(t, collectionsPath, "unused", "thing", "1.0.0")
+	manifest := `{"collection_info":{"namespace":"acme","name":"tools","version":"1.0.0",` +
+		`"dependencies":{"community.general":">=7.0.0"}}}`
+	if err := os.WriteFile(filepath.Join(collectionsPath, "ansible_collections", "acme", "tools", "MANIFEST.json"),
+		[]byte(manifest), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	const repo = "git+https://git.example.com/acme/tools.git"
+	reqs := filepath.Join(project, "requirements.yml")
+	data := "collections:\n  - name: " + repo + "\n    type: git\n    version: v1.0.0\n"
+	if err := os.WriteFile(reqs, []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	registry := &store.ProjectRegistry{Projects: map[string]store.ProjectRecord{
+		project: {RequirementsFile: reqs, CollectionsPath: collectionsPath},
+	}}
+	runtime := infra.New(progress.New(false, true), nil)
+
+	st := store.New()
+	reachable, _, err := buildReachable(runtime, st, registry)
+	if err != nil {
+		t.Fatalf("buildReachable: %v", err)
+	}
+	if reachable["acme.tools@1.0.0"] {
+		t.Fatal("a git root without a recorded install cannot be resolved")
+	}
+
+	st.AddRoot(store.GitRootLabel(repo, "v1.0.0"), "acme.tools@1.0.0")
+	reachable, _, err = buildReachable(runtime, st, registry)
+	if err != nil {
+		t.Fatalf("buildReachable: %v", err)
+	}
+	if !reachable["acme.tools@1.0.0"] || !reachable["community.general@8.0.0"] {
+		t.Fatalf("expected the git-built collection and its dependency to be reachable, got %v", reachable)
+	}
+	if reachable["unused.thing@1.0.0"] {
+		t.Fatalf("expected unused.thing to stay unreachable, got %v", reachable)
+	}
+}
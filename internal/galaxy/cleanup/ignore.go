@@ -0,0 +1,59 @@
+package cleanup
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+// ignoreList holds .galaxyignore patterns for one collections path.
+// A pattern is a glob matched against namespace.name or namespace.name@version.
+type ignoreList []string
+
+// loadIgnoreFile reads the ignore file in collectionsPath; a missing file yields an empty list.
+func loadIgnoreFile(collectionsPath string) (ignoreList, error) {
+	//nolint:gosec // the ignore file lives in a collections path recorded by install.
+	f, err := os.Open(filepath.Join(collectionsPath, helpers.GalaxyIgnoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var patterns ignoreList
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := path.Match(line, ""); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// matches reports whether the installed collection is protected from cleanup.
+func (l ignoreList) matches(inst installedCollection) bool {
+	for _, pattern := range l {
+		if ok, _ := path.Match(pattern, inst.FQDN); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, inst.Key); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestMarkIgnoredKeepsCollectionsAndDependencies(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	ignore := "# vendored\nmycorp.*\n\ncommunity.general@7.0.0\n"
+	if err := os.WriteFile(filepath.Join(dir, helpers.GalaxyIgnoreFile), []byte(ignore), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	record := func(fqdn, version string) installedCollection {
+		return installedCollection{Key: fqdn + "@" + version, FQDN: fqdn, Version: version, CollectionsDir: dir}
+	}
+	index := map[string][]installedCollection{
+		"mycorp.tools":      {record("mycorp.tools", "1.0.0")},
+		"ansible.utils":     {record("ansible.utils", "2.0.0")},
+		"community.general": {record("community.general", "7.0.0"), record("community.general", "6.0.0")},
+		"ansible.netcommon": {record("ansible.netcommon", "5.0.0")},
+	}
+	deps := map[string]map[string]string{
+		"mycorp.tools@1.0.0": {"ansible.utils": ">=2.0.0"},
+	}
+	reachable := make(map[string]bool)
+	runtime := infra.New(progress.New(false, true), nil)
+	if err := markIgnored(runtime, dir, reachable, deps, index); err != nil {
+		t.Fatalf("markIgnored: %v", err)
+	}
+	for _, key := range []string{"mycorp.tools@1.0.0", "ansible.utils@2.0.0", "community.general@7.0.0"} {
+		if !reachable[key] {
+			t.Fatalf("expected %s to be kept, reachable=%v", key, reachable)
+		}
+	}
+	for _, key := range []string{"community.general@6.0.0", "ansible.netcommon@5.0.0"} {
+		if reachable[key] {
+			t.Fatalf("expected %s to stay a cleanup candidate", key)
+		}
+	}
+}
+
+func TestMarkIgnoredRejectsBadPattern(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, helpers.GalaxyIgnoreFile), []byte("[\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	runtime := infra.New(progress.New(false, true), nil)
+	if err := markIgnored(runtime, dir, map[string]bool{}, nil, nil); err == nil {
+		t.Fatalf("expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,93 @@
+package cleanup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// scopeRegistry narrows registry to the project named by target, a project
+// directory, its requirements file or its repository (as recorded in the
+// project owner, e.g. group/repo). Other projects installing into the same
+// collections path stay in scope, so their roots keep what they use there.
+func scopeRegistry(registry *store.ProjectRegistry, target string) (*store.ProjectRegistry, error) {
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		abs = filepath.Clean(target)
+	}
+	projectPath := ""
+	for path, project := range registry.Projects {
+		if path == abs || project.RequirementsPath(path) == abs || project.Owner.Repository == target {
+			projectPath = path
+			break
+		}
+	}
+	if projectPath == "" {
+		return nil, fmt.Errorf("%w: %s", helpers.ErrProjectNotRecorded, target)
+	}
+
+	scoped := &store.ProjectRegistry{Projects: map[string]store.ProjectRecord{projectPath: registry.Projects[projectPath]}}
+	collectionsPath := pickCollectionsPath(projectPath, registry.Projects[projectPath])
+	if collectionsPath == "" {
+		return scoped, nil
+	}
+	for path, project := range registry.Projects {
+		if path != projectPath && pickCollectionsPath(path, project) == collectionsPath {
+			scoped.Projects[path] = project
+		}
+	}
+	return scoped, nil
+}
+
+// installedOutside reports whether inst's version is also installed in a
+// collections path of a registry project outside scoped. Its cached artifact
+// and store entries must then survive a project-scoped cleanup.
+func installedOutside(registry, scoped *store.ProjectRegistry, inst installedCollection) bool {
+	ns, name, ok := strings.Cut(inst.FQDN, ".")
+	if !ok {
+		return false
+	}
+	for path, project := range registry.Projects {
+		if _, in := scoped.Projects[path]; in {
+			continue
+		}
+		collectionsPath := pickCollectionsPath(path, project)
+		if collectionsPath == "" || collectionsPath == inst.CollectionsDir {
+			continue
+		}
+		manifest, ok, err := readManifest(filepath.Join(collectionsPath, "ansible_collections", ns, name, "MANIFEST.json"))
+		if err != nil && !os.IsNotExist(err) {
+			// unreadable: assume it is in use
+			return true
+		}
+		if ok && manifest.CollectionInfo.Version == inst.Version {
+			return true
+		}
+	}
+	return false
+}
+
+// dropStaleProjects returns registry without projects that have not run
+// within ttl. Their collections paths are not cleaned and their roots no
+// longer keep shared collections or cached artifacts.
+func dropStaleProjects(runtime *infra.Infra, registry *store.ProjectRegistry, ttl time.Duration, now time.Time) *store.ProjectRegistry {
+	fresh := &store.ProjectRegistry{Projects: make(map[string]store.ProjectRecord, len(registry.Projects))}
+	for path, project := range registry.Projects {
+		if !project.Stale(ttl, now) {
+			fresh.Projects[path] = project
+			continue
+		}
+		owner := project.Owner.String()
+		if owner == "" {
+			owner = "unknown owner"
+		}
+		runtime.Output.Printf("⏳ skip stale project %s (%s, last run %s)", path, owner, project.LastRun.Format(time.RFC3339))
+	}
+	return fresh
+}
@@ -0,0 +1,86 @@
+package cleanup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func writeManifest(t *testing.T, collectionsPath, ns, name, version string) {
+	t.Helper()
+	dir := filepath.Join(collectionsPath, "ansible_collections", ns, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	manifest := `{"collection_info":{"namespace":"` + ns + `","name":"` + name + `","version":"` + version + `"}}`
+	if err := os.WriteFile(filepath.Join(dir, "MANIFEST.json"), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestScopeRegistry(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	shared := filepath.Join(root, "shared")
+	own := filepath.Join(root, "team-b", ".collections")
+	writeManifest(t, shared, "a", "b", "1.0.0")
+	writeManifest(t, own, "a", "b", "2.0.0")
+
+	teamA := filepath.Join(root, "team-a")
+	teamC := filepath.Join(root, "team-c")
+	teamB := filepath.Join(root, "team-b")
+	registry := &store.ProjectRegistry{Projects: map[string]store.ProjectRecord{
+		teamA: {RequirementsFile: filepath.Join(teamA, "requirements.yml"), CollectionsPath: shared},
+		teamC: {RequirementsFile: filepath.Join(teamC, "requirements.yml"), CollectionsPath: shared},
+		teamB: {RequirementsFile: filepath.Join(teamB, "requirements.yml"), CollectionsPath: own},
+	}}
+
+	scoped, err := scopeRegistry(registry, filepath.Join(teamA, "requirements.yml"))
+	if err != nil {
+		t.Fatalf("scopeRegistry: %v", err)
+	}
+	if len(scoped.Projects) != 2 {
+		t.Fatalf("projects sharing the collections path must stay in scope: %v", scoped.Projects)
+	}
+	if _, ok := scoped.Projects[teamB]; ok {
+		t.Fatal("a project with its own collections path must be out of scope")
+	}
+
+	scoped, err = scopeRegistry(registry, teamB)
+	if err != nil || len(scoped.Projects) != 1 {
+		t.Fatalf("scope by directory: %v %v", scoped, err)
+	}
+	inst := installedCollection{FQDN: "a.b", Version: "1.0.0", CollectionsDir: own}
+	if !installedOutside(registry, scoped, inst) {
+		t.Fatal("a version installed by another project must keep its cache")
+	}
+	inst.Version = "3.0.0"
+	if installedOutside(registry, scoped, inst) {
+		t.Fatal("a version nobody else installed may be dropped from the cache")
+	}
+
+	if _, err := scopeRegistry(registry, filepath.Join(root, "unknown")); !errors.Is(err, helpers.ErrProjectNotRecorded) {
+		t.Fatalf("expected ErrProjectNotRecorded, got %v", err)
+	}
+}
+
+func TestDropStaleProjects(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	registry := &store.ProjectRegistry{Projects: map[string]store.ProjectRecord{
+		"/fresh": {LastRun: now.Add(-time.Hour)},
+		"/stale": {LastRun: now.Add(-60 * 24 * time.Hour), Owner: store.ProjectOwner{Repository: "old/repo"}},
+	}}
+	runtime := infra.New(progress.New(false, true), nil)
+	fresh := dropStaleProjects(runtime, registry, 30*24*time.Hour, now)
+	if _, ok := fresh.Projects["/fresh"]; !ok || len(fresh.Projects) != 1 {
+		t.Fatalf("only the fresh project must remain: %v", fresh.Projects)
+	}
+}
@@ -0,0 +1,64 @@
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// pruneEmptyParents removes dir and then its parents while they are empty,
+// stopping below stop. os.Remove refuses non-empty directories, so a
+// concurrent install into the same namespace keeps its directory.
+func pruneEmptyParents(dir, stop string) {
+	stop = filepath.Clean(stop)
+	for dir = filepath.Clean(dir); dir != stop && filepath.Dir(dir) != dir; dir = filepath.Dir(dir) {
+		if rel, err := filepath.Rel(stop, dir); err != nil || rel == "." || !filepath.IsLocal(rel) {
+			return
+		}
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
+// pruneEmptySubdirs removes the empty immediate subdirectories of dir and
+// returns how many were removed. A missing dir prunes nothing.
+func pruneEmptySubdirs(dir string) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	var pruned int
+	for _, entry := range entries {
+		if entry.IsDir() && os.Remove(filepath.Join(dir, entry.Name())) == nil {
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// pruneEmptyDirs sweeps the empty namespace directories left under each
+// project's ansible_collections and the empty namespaced cache directories,
+// which otherwise pile up on long-lived runners.
+func pruneEmptyDirs(cfg *config.Config, runtime *infra.Infra, registry *store.ProjectRegistry) {
+	seen := make(map[string]bool)
+	var pruned int
+	for projectPath, project := range registry.Projects {
+		collectionsPath := pickCollectionsPath(projectPath, project)
+		if collectionsPath == "" || seen[collectionsPath] {
+			continue
+		}
+		seen[collectionsPath] = true
+		pruned += pruneEmptySubdirs(filepath.Join(collectionsPath, "ansible_collections"))
+	}
+	if cfg.CacheDir != "" {
+		pruned += pruneEmptySubdirs(filepath.Join(cfg.CacheDir, helpers.StoreNamespacesDir))
+	}
+	if pruned > 0 {
+		runtime.Output.Debugf("pruned %d empty directories", pruned)
+	}
+}
@@ -0,0 +1,63 @@
+package cleanup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveInstalledPrunesNamespace(t *testing.T) {
+	t.Parallel()
+	collectionsPath := t.TempDir()
+	writeManifest(t, collectionsPath, "a", "b", "1.0.0")
+	writeManifest(t, collectionsPath, "c", "d", "1.0.0")
+	writeManifest(t, collectionsPath, "c", "e", "1.0.0")
+	root := filepath.Join(collectionsPath, "ansible_collections")
+
+	remove := func(ns, name string) {
+		inst := installedCollection{
+			FQDN:           ns + "." + name,
+			Version:        "1.0.0",
+			InstallPath:    filepath.Join(root, ns, name),
+			CollectionsDir: collectionsPath,
+		}
+		if err := removeInstalled(context.Background(), inst, nil, nil); err != nil {
+			t.Fatalf("removeInstalled: %v", err)
+		}
+	}
+	remove("a", "b")
+	remove("c", "d")
+
+	if dirExists(filepath.Join(root, "a")) {
+		t.Fatal("an emptied namespace directory must be removed")
+	}
+	if !dirExists(filepath.Join(root, "c", "e")) {
+		t.Fatal("a namespace that still holds collections must be kept")
+	}
+	if !dirExists(root) {
+		t.Fatal("ansible_collections itself must be kept")
+	}
+}
+
+func TestPruneEmptySubdirs(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{"empty-1", "empty-2", "full"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "full", "store.db"), nil, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if pruned := pruneEmptySubdirs(dir); pruned != 2 {
+		t.Fatalf("pruned %d directories, want 2", pruned)
+	}
+	if !dirExists(filepath.Join(dir, "full")) {
+		t.Fatal("a non-empty directory must be kept")
+	}
+	if pruned := pruneEmptySubdirs(filepath.Join(dir, "missing")); pruned != 0 {
+		t.Fatalf("a missing directory must prune nothing, got %d", pruned)
+	}
+}
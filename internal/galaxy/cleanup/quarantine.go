@@ -0,0 +1,93 @@
+package cleanup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+const (
+	// quarantineDayLayout names the dated folders under --quarantine-dir.
+	quarantineDayLayout = "2006-01-02"
+	// quarantineOriginFile records the collections path a quarantined collection came from.
+	quarantineOriginFile = "ORIGIN"
+)
+
+// quarantine moves removed collections into a dated folder instead of
+// deleting them. A nil quarantine deletes.
+type quarantine struct {
+	dir string
+}
+
+// newQuarantine returns the quarantine for today under dir, or nil when dir is empty.
+func newQuarantine(dir string, now time.Time) *quarantine {
+	if dir == "" {
+		return nil
+	}
+	return &quarantine{dir: filepath.Join(dir, now.UTC().Format(quarantineDayLayout))}
+}
+
+// move relocates inst and its .info directory into the quarantine as
+// <day>/<namespace>.<name>-<version>-*/ansible_collections/..., next to an
+// ORIGIN file naming the collections path to move them back into.
+func (q *quarantine) move(inst installedCollection, namespace, name string) error {
+	if err := os.MkdirAll(q.dir, helpers.DirMod); err != nil {
+		return fmt.Errorf("%w: %w", helpers.ErrQuarantineFailed, err)
+	}
+	target, err := os.MkdirTemp(q.dir, fmt.Sprintf("%s.%s-%s-", namespace, name, inst.Version))
+	if err != nil {
+		return fmt.Errorf("%w: %w", helpers.ErrQuarantineFailed, err)
+	}
+	origin := filepath.Join(target, quarantineOriginFile)
+	if err := os.WriteFile(origin, []byte(inst.CollectionsDir+"\n"), helpers.FileMod); err != nil {
+		return fmt.Errorf("%w: %w", helpers.ErrQuarantineFailed, err)
+	}
+
+	root := filepath.Join(inst.CollectionsDir, "ansible_collections")
+	infoName := fmt.Sprintf("%s.%s-%s.info", namespace, name, inst.Version)
+	moves := [][2]string{
+		{inst.InstallPath, filepath.Join(target, "ansible_collections", namespace, name)},
+		{filepath.Join(root, infoName), filepath.Join(target, "ansible_collections", infoName)},
+	}
+	for _, m := range moves {
+		if _, err := os.Stat(m[0]); err != nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(m[1]), helpers.DirMod); err != nil {
+			return fmt.Errorf("%w: %w", helpers.ErrQuarantineFailed, err)
+		}
+		if err := os.Rename(m[0], m[1]); err != nil {
+			return fmt.Errorf("%w: %w", helpers.ErrQuarantineFailed, err)
+		}
+	}
+	return nil
+}
+
+// purgeQuarantine deletes the dated folders under dir whose whole day lies
+// more than retention in the past.
+// Folders that are not named by a date are left alone.
+func purgeQuarantine(runtime *infra.Infra, dir string, retention time.Duration, now time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	cutoff := now.UTC().Add(-retention)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		day, err := time.Parse(quarantineDayLayout, entry.Name())
+		if err != nil || !day.AddDate(0, 0, 1).Before(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			runtime.Output.Printf("⚠️ Failed to purge quarantine %s: %v", entry.Name(), err)
+			continue
+		}
+		runtime.Output.Printf("🗑️ purge quarantine %s", entry.Name())
+	}
+}
@@ -0,0 +1,72 @@
+package cleanup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestRemoveInstalledQuarantine(t *testing.T) {
+	t.Parallel()
+	collectionsPath := t.TempDir()
+	writeManifest(t, collectionsPath, "a", "b", "1.0.0")
+	root := filepath.Join(collectionsPath, "ansible_collections")
+	if err := os.Mkdir(filepath.Join(root, "a.b-1.0.0.info"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	inst := installedCollection{
+		FQDN:           "a.b",
+		Version:        "1.0.0",
+		InstallPath:    filepath.Join(root, "a", "b"),
+		CollectionsDir: collectionsPath,
+	}
+	if err := removeInstalled(context.Background(), inst, nil, newQuarantine(dir, now)); err != nil {
+		t.Fatalf("removeInstalled: %v", err)
+	}
+	if dirExists(inst.InstallPath) || dirExists(filepath.Join(root, "a")) {
+		t.Fatal("a quarantined collection must leave the collections path")
+	}
+
+	moved, err := filepath.Glob(filepath.Join(dir, "2026-03-10", "a.b-1.0.0-*"))
+	if err != nil || len(moved) != 1 {
+		t.Fatalf("expected one quarantine folder, got %v %v", moved, err)
+	}
+	if _, err := os.Stat(filepath.Join(moved[0], "ansible_collections", "a", "b", "MANIFEST.json")); err != nil {
+		t.Fatalf("collection files must be kept: %v", err)
+	}
+	if !dirExists(filepath.Join(moved[0], "ansible_collections", "a.b-1.0.0.info")) {
+		t.Fatal("the .info directory must be quarantined with the collection")
+	}
+	origin, err := os.ReadFile(filepath.Join(moved[0], quarantineOriginFile))
+	if err != nil || strings.TrimSpace(string(origin)) != collectionsPath {
+		t.Fatalf("ORIGIN must name the collections path: %q %v", origin, err)
+	}
+}
+
+func TestPurgeQuarantine(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{"2026-03-01", "2026-03-05", "keep-me"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	runtime := infra.New(progress.New(false, true), nil)
+	purgeQuarantine(runtime, dir, 7*24*time.Hour, time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC))
+
+	if dirExists(filepath.Join(dir, "2026-03-01")) {
+		t.Fatal("a folder past retention must be purged")
+	}
+	if !dirExists(filepath.Join(dir, "2026-03-05")) || !dirExists(filepath.Join(dir, "keep-me")) {
+		t.Fatal("recent and undated folders must be kept")
+	}
+}
@@ -1,9 +1,37 @@
 package cleanup
 
-import "github.com/greeddj/go-galaxy/internal/galaxy/requirements"
+import (
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/requirements"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// gitType is the requirement type of collections built from a git repository.
+const gitType = "git"
 
 // loadRequirements reads requirements for cleanup scope.
 func loadRequirements(path, defaultSource string) ([]requirements.CollectionRequirement, error) {
-	reqs, _, err := requirements.LoadCollections(path, defaultSource)
+	reqs, _, err := requirements.LoadCollections(path, defaultSource, false)
 	return reqs, err
 }
+
+// markGitRoot marks what a git requirement was built as on its last
+// installs. Its namespace.name lives in the repository's galaxy.yml, so it
+// is taken from the keys the install recorded under store.GitRootLabel.
+func markGitRoot(
+	runtime *infra.Infra,
+	st *store.Store,
+	root requirements.CollectionRequirement,
+	reachable map[string]bool,
+	deps map[string]map[string]string,
+	index map[string][]installedCollection,
+) {
+	keys := st.GetRoots(store.GitRootLabel(root.Name, root.Version))
+	if len(keys) == 0 {
+		runtime.Output.Printf("⚠️ no install recorded for git requirement %s; install the project again to keep it", root.Name)
+		return
+	}
+	for _, key := range keys {
+		markReachable(key, reachable, deps, index)
+	}
+}
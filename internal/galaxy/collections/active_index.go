@@ -0,0 +1,56 @@
+package collections
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// ActiveIndex maps each collection to the versioned directory that is active.
+// Tooling switches versions by rewriting the file and renaming it into place.
+type ActiveIndex struct {
+	SchemaVersion int                    `json:"schema_version"`
+	Collections   map[string]ActiveEntry `json:"collections"`
+}
+
+// ActiveEntry points at one installed collection version.
+type ActiveEntry struct {
+	Version string `json:"version"`
+	// Path is relative to the ansible_collections directory of the collections path.
+	Path string `json:"path"`
+	// InstallPath is set when the collection was routed away from the default collections path.
+	InstallPath string `json:"install_path,omitempty"`
+}
+
+// writeActiveIndex writes active-collections.json next to the install
+// manifest, pointing every planned collection at the directory the install
+// template rendered for it. The write is atomic so readers never see a
+// partial index.
+func writeActiveIndex(cfg *config.Config, runtime *infra.Infra, plan *installPlan) error {
+	runtime.Output.Debugf("write %s", helpers.ActiveIndexFile)
+	index := ActiveIndex{
+		SchemaVersion: helpers.ActiveIndexSchemaVersion,
+		Collections:   make(map[string]ActiveEntry, len(plan.collections)),
+	}
+	for _, col := range plan.collections {
+		index.Collections[col.Namespace+"."+col.Name] = ActiveEntry{
+			Version:     col.Version,
+			Path:        filepath.ToSlash(col.installRelDir(cfg)),
+			InstallPath: col.InstallPath,
+		}
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(cfg.DownloadPath, helpers.ActiveIndexFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), helpers.FileMod); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
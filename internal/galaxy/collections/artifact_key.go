@@ -0,0 +1,49 @@
+package collections
+
+import (
+	"context"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+// artifactKey builds the cache key for a collection tarball from its source.
+func artifactKey(col collection) string {
+	return cacheManager.ArtifactKey(col.Namespace, col.Name, col.Version, col.Source)
+}
+
+// legacyArtifactKey builds the source-less key older releases cached under.
+func legacyArtifactKey(col collection) string {
+	return cacheManager.LegacyArtifactKey(col.Namespace, col.Name, col.Version)
+}
+
+// findCachedArtifact returns the key col is cached under, trying the current
+// key before the legacy one, or "" when it is not cached.
+func findCachedArtifact(ctx context.Context, artifacts cacheManager.ArtifactStore, col collection) string {
+	for _, key := range []string{artifactKey(col), legacyArtifactKey(col)} {
+		if ok, err := artifacts.Has(ctx, key); err == nil && ok {
+			return key
+		}
+	}
+	return ""
+}
+
+// legacyArtifactUsable reports whether an artifact found under the legacy
+// key can stand in for col. That key does not say which server the bytes
+// came from, so it is only trusted when they match the checksum the server
+// publishes, or when there is no checksum to compare against.
+func legacyArtifactUsable(artifact artifactData, meta *types.GalaxyCollectionVersionInfo) bool {
+	if meta == nil || strings.TrimSpace(meta.Artifact.Sha256) == "" {
+		return true
+	}
+	sha := strings.TrimSpace(artifact.Meta[archive.HashSHA256])
+	if sha == "" {
+		var err error
+		if sha, err = archive.FileHashSHA256(artifact.Path); err != nil {
+			return false
+		}
+	}
+	return sha == strings.TrimSpace(meta.Artifact.Sha256)
+}
@@ -0,0 +1,68 @@
+package collections
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/cache/local"
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+func TestArtifactKeySeparatesSources(t *testing.T) {
+	t.Parallel()
+	a := collection{Namespace: "a", Name: "b", Version: "1.0.0", Source: "https://one.example"}
+	b := a
+	b.Source = "https://two.example/"
+	if artifactKey(a) == artifactKey(b) {
+		t.Fatalf("sources share key %s", artifactKey(a))
+	}
+	b.Source = "https://one.example/"
+	if artifactKey(a) != artifactKey(b) {
+		t.Fatal("a trailing slash must not change the key")
+	}
+	if !strings.HasPrefix(artifactKey(a), "a-b-1.0.0~") || legacyArtifactKey(a) != "a-b-1.0.0.tar.gz" {
+		t.Fatalf("unexpected keys %s, %s", artifactKey(a), legacyArtifactKey(a))
+	}
+}
+
+func TestFindCachedArtifactFallsBackToLegacyKey(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	artifacts := local.NewArtifacts(t.TempDir(), nil)
+	col := collection{Namespace: "a", Name: "b", Version: "1.0.0", Source: "https://one.example"}
+	if key := findCachedArtifact(ctx, artifacts, col); key != "" {
+		t.Fatalf("unexpected hit %s", key)
+	}
+
+	tmp := filepath.Join(t.TempDir(), "artifact")
+	if err := os.WriteFile(tmp, []byte("payload"), 0o600); err != nil {
+		t.Fatalf("write artifact: %v", err)
+	}
+	if _, err := artifacts.Commit(ctx, legacyArtifactKey(col), tmp, nil); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if key := findCachedArtifact(ctx, artifacts, col); key != legacyArtifactKey(col) {
+		t.Fatalf("key = %q, want the legacy key", key)
+	}
+}
+
+func TestLegacyArtifactUsable(t *testing.T) {
+	t.Parallel()
+	artifact := artifactData{Meta: map[string]string{archive.HashSHA256: "abc"}}
+	meta := &types.GalaxyCollectionVersionInfo{}
+	if !legacyArtifactUsable(artifact, nil) || !legacyArtifactUsable(artifact, meta) {
+		t.Fatal("an artifact without a published checksum must be usable")
+	}
+	meta.Artifact.Sha256 = "abc"
+	if !legacyArtifactUsable(artifact, meta) {
+		t.Fatal("a matching artifact must be usable")
+	}
+	meta.Artifact.Sha256 = "def"
+	if legacyArtifactUsable(artifact, meta) {
+		t.Fatal("an artifact from another source must not be usable")
+	}
+}
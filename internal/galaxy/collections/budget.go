@@ -0,0 +1,49 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// checkDownloadBudget fails once the artifact bytes downloaded in this run
+// exceed --max-total-download under the abort policy. It is checked before
+// and after every download, so the run stops fetching as soon as it is over.
+func checkDownloadBudget(cfg *config.Config, st *store.Store) error {
+	if cfg == nil || cfg.MaxTotalDownload <= 0 || cfg.BudgetPolicy == config.BudgetWarn {
+		return nil
+	}
+	if downloaded := st.MetaSnapshot().Stats.LastRun.BytesDownloaded; downloaded > cfg.MaxTotalDownload {
+		return fmt.Errorf("%w: downloaded %d bytes, --max-total-download is %d", helpers.ErrBudgetExceeded, downloaded, cfg.MaxTotalDownload)
+	}
+	return nil
+}
+
+// withInstallBudget bounds ctx by --max-install-time under the abort policy,
+// with ErrBudgetExceeded as the cancellation cause.
+func withInstallBudget(ctx context.Context, cfg *config.Config) (context.Context, context.CancelFunc) {
+	if cfg.MaxInstallTime <= 0 || cfg.BudgetPolicy == config.BudgetWarn {
+		return ctx, func() {}
+	}
+	cause := fmt.Errorf("%w: install ran longer than --max-install-time %s", helpers.ErrBudgetExceeded, cfg.MaxInstallTime)
+	return context.WithTimeoutCause(ctx, cfg.MaxInstallTime, cause)
+}
+
+// reportBudget prints the budgets a completed run went over under the warn policy.
+func reportBudget(cfg *config.Config, runtime *infra.Infra, st *store.Store, elapsed time.Duration) {
+	if cfg.BudgetPolicy != config.BudgetWarn {
+		return
+	}
+	if downloaded := st.MetaSnapshot().Stats.LastRun.BytesDownloaded; cfg.MaxTotalDownload > 0 && downloaded > cfg.MaxTotalDownload {
+		runtime.Output.PersistentPrintf("⚠️ Budget exceeded: downloaded %d bytes, --max-total-download is %d", downloaded, cfg.MaxTotalDownload)
+	}
+	if cfg.MaxInstallTime > 0 && elapsed > cfg.MaxInstallTime {
+		runtime.Output.PersistentPrintf("⚠️ Budget exceeded: install took %s, --max-install-time is %s",
+			elapsed.Round(time.Second), cfg.MaxInstallTime)
+	}
+}
@@ -0,0 +1,43 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+func TestCheckDownloadBudget(t *testing.T) {
+	t.Parallel()
+	st := store.New()
+	st.BeginStatsRun()
+	st.AddStats(store.CacheCounters{BytesDownloaded: 100})
+
+	cfg := &config.Config{MaxTotalDownload: 100, BudgetPolicy: config.BudgetAbort}
+	if err := checkDownloadBudget(cfg, st); err != nil {
+		t.Fatalf("expected budget to hold at the limit, got %v", err)
+	}
+	st.AddStats(store.CacheCounters{BytesDownloaded: 1})
+	if err := checkDownloadBudget(cfg, st); !errors.Is(err, helpers.ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	cfg.BudgetPolicy = config.BudgetWarn
+	if err := checkDownloadBudget(cfg, st); err != nil {
+		t.Fatalf("warn policy must not fail, got %v", err)
+	}
+}
+
+func TestWithInstallBudget(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{MaxInstallTime: time.Millisecond, BudgetPolicy: config.BudgetAbort}
+	ctx, cancel := withInstallBudget(context.Background(), cfg)
+	defer cancel()
+	<-ctx.Done()
+	if !errors.Is(context.Cause(ctx), helpers.ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded cause, got %v", context.Cause(ctx))
+	}
+}
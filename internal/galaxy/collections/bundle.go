@@ -0,0 +1,52 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// runBundle installs into a scratch collections path and packs the result into cfg.Bundle.
+func runBundle(ctx context.Context, cfg *config.Config, runtime *infra.Infra) error {
+	stage, err := os.MkdirTemp(cfg.TempDir, "go-galaxy-bundle-")
+	if err != nil {
+		return fmt.Errorf("failed to create bundle staging dir: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(stage)
+	}()
+
+	staged := *cfg
+	staged.DownloadPath = stage
+	if err := runInstall(ctx, &staged, runtime); err != nil {
+		return err
+	}
+	if cfg.DryRun {
+		runtime.Output.Printf("🧪 Dry run: bundle %s not written", cfg.Bundle)
+		return nil
+	}
+
+	if dir := filepath.Dir(cfg.Bundle); dir != "" {
+		if err := os.MkdirAll(dir, helpers.DirMod); err != nil {
+			return err
+		}
+	}
+	names := []string{"ansible_collections", helpers.InstallManifestFile}
+	present := names[:0]
+	for _, name := range names {
+		if _, err := os.Lstat(filepath.Join(stage, name)); err == nil {
+			present = append(present, name)
+		}
+	}
+	if err := archive.CreateTarGz(stage, cfg.Bundle, present...); err != nil {
+		return fmt.Errorf("failed to write bundle %s: %w", cfg.Bundle, err)
+	}
+	runtime.Output.Printf("📦 Bundle written to %s", cfg.Bundle)
+	return nil
+}
@@ -0,0 +1,65 @@
+package collections
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestBundleKeepsInstalledEntries(t *testing.T) {
+	t.Parallel()
+	reqs := filepath.Join(t.TempDir(), "requirements.yml")
+	cfg := &config.Config{
+		Server:       "https://galaxy.invalid",
+		CacheDir:     t.TempDir(),
+		DownloadPath: t.TempDir(),
+		TempDir:      t.TempDir(),
+		Workers:      2,
+		HealthCheck:  config.HealthCheckOff,
+	}
+	runtime := infra.New(progress.New(false, true), &http.Client{Transport: offlineTransport{}})
+	if err := GenerateCache(context.Background(), cfg, runtime, GenCacheOptions{Collections: 2, Versions: 1, Requirements: reqs}); err != nil {
+		t.Fatalf("GenerateCache: %v", err)
+	}
+	data, err := os.ReadFile(reqs)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	cfg.RequirementsData = data
+	if err := Start(context.Background(), cfg, runtime); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	bundled := *cfg
+	bundled.Bundle = filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := Start(context.Background(), &bundled, runtime); err != nil {
+		t.Fatalf("bundle: %v", err)
+	}
+	if _, err := os.Stat(bundled.Bundle); err != nil {
+		t.Fatalf("bundle not written: %v", err)
+	}
+
+	state, err := openInstallState(context.Background(), cfg, runtime)
+	if err != nil {
+		t.Fatalf("openInstallState: %v", err)
+	}
+	defer func() {
+		_ = state.release()
+		_ = state.backend.Close(context.Background())
+	}()
+	installed := state.store.InstalledSnapshot()
+	if len(installed) != 2 {
+		t.Fatalf("expected 2 installed entries, got %v", installed)
+	}
+	for key, entry := range installed {
+		if filepath.Dir(filepath.Dir(filepath.Dir(entry.InstallPath))) != cfg.DownloadPath {
+			t.Fatalf("%s points into %s after a bundle run, not %s", key, entry.InstallPath, cfg.DownloadPath)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package collections
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// bustCollections clears the cached metadata and artifacts of the --bust
+// collections, leaving the rest of the cache alone.
+func bustCollections(ctx context.Context, cfg *config.Config, runtime *infra.Infra, state *installState) {
+	artifacts := state.backend.Artifacts()
+	var listed []string
+	if lister, ok := artifacts.(cacheManager.ArtifactLister); ok {
+		keys, err := lister.List(ctx)
+		if err != nil {
+			runtime.Output.Debugf("bust: list artifacts: %v", err)
+		}
+		listed = keys
+	}
+	sources := append([]string{cfg.Server}, mirrorSources(cfg, state.store, cfg.Server)...)
+	for _, fqdn := range cfg.Bust {
+		namespace, name, ok := helpers.SplitFQDN(fqdn)
+		if !ok {
+			continue
+		}
+		runtime.Output.Printf("💥 bust cache of %s", fqdn)
+		keys := bustArtifactKeys(namespace, name, state.store.BustCollection(namespace, name), sources, listed)
+		for _, key := range keys {
+			if err := artifacts.Delete(ctx, key); err != nil {
+				runtime.Output.Printf("⚠️ Failed to delete cached artifact %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// bustArtifactKeys returns the artifact keys of namespace.name: those of the
+// known versions from every source, their legacy keys, and any listed key
+// carrying the collection's prefix.
+func bustArtifactKeys(namespace, name string, versions, sources, listed []string) []string {
+	keys := make([]string, 0, len(versions)*(len(sources)+1))
+	for _, version := range versions {
+		col := collection{Namespace: namespace, Name: name, Version: version}
+		keys = append(keys, legacyArtifactKey(col))
+		for _, source := range sources {
+			col.Source = source
+			keys = append(keys, artifactKey(col))
+		}
+	}
+	prefix := url.QueryEscape(namespace + "-" + name + "-")
+	for _, key := range listed {
+		if strings.HasPrefix(key, prefix) && strings.HasSuffix(key, ".tar.gz") {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
@@ -0,0 +1,24 @@
+package collections
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBustArtifactKeys(t *testing.T) {
+	t.Parallel()
+	listed := []string{"a-b-1.0.0.tar.gz", "a-b-4.0.0.tar.gz", "a-bc-1.0.0.tar.gz", "c-d-1.0.0.tar.gz"}
+	sources := []string{"https://galaxy.example"}
+	got := bustArtifactKeys("a", "b", []string{"1.0.0", "2.0.0"}, sources, listed)
+	want := []string{
+		"a-b-1.0.0.tar.gz",
+		artifactKey(collection{Namespace: "a", Name: "b", Version: "1.0.0", Source: sources[0]}),
+		"a-b-2.0.0.tar.gz",
+		artifactKey(collection{Namespace: "a", Name: "b", Version: "2.0.0", Source: sources[0]}),
+		"a-b-4.0.0.tar.gz",
+	}
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("bustArtifactKeys = %v, want %v", got, want)
+	}
+}
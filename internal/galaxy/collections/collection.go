@@ -1,6 +1,11 @@
 package collections
 
-import "fmt"
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+)
 
 // collection represents a resolved collection with metadata.
 type collection struct {
@@ -11,9 +16,35 @@ type collection struct {
 	Signatures []string `yaml:"signatures"`
 	Constraint string   `yaml:"-"`
 	Type       string   `yaml:"-"`
+	// DepsSource names where the dependency edges of a resolved collection came from.
+	DepsSource string `yaml:"-"`
+	// InstallPath overrides the collections path for this collection when set.
+	InstallPath string `yaml:"-"`
 }
 
 // key returns the unique key for the collection.
 func (c collection) key() string {
 	return fmt.Sprintf("%s.%s@%s", c.Namespace, c.Name, c.Version)
 }
+
+// collectionsPath returns the collections path the collection is installed into.
+func (c collection) collectionsPath(cfg *config.Config) string {
+	if c.InstallPath != "" {
+		return c.InstallPath
+	}
+	return cfg.DownloadPath
+}
+
+// installDir returns the directory the collection is extracted into.
+func (c collection) installDir(cfg *config.Config) string {
+	return filepath.Join(c.collectionsPath(cfg), "ansible_collections", c.installRelDir(cfg))
+}
+
+// installRelDir returns the collection's directory relative to
+// ansible_collections, following --install-template when set.
+func (c collection) installRelDir(cfg *config.Config) string {
+	if cfg.InstallTemplate == "" {
+		return filepath.Join(c.Namespace, c.Name)
+	}
+	return filepath.FromSlash(config.RenderInstallTemplate(cfg.InstallTemplate, c.Namespace, c.Name, c.Version))
+}
@@ -2,10 +2,11 @@ package collections
 
 import (
 	"context"
-	"net/http"
+	"encoding/json"
 
 	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
 	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
 	"github.com/greeddj/go-galaxy/internal/galaxy/store"
 )
 
@@ -22,21 +23,26 @@ func setResolvedAll(st *store.Store, resolved map[string]collection) {
 	}
 	entries := make(map[string]store.ResolvedEntry, len(resolved))
 	for fqdn, col := range resolved {
-		entries[fqdn] = store.ResolvedEntry{Version: col.Version, Source: col.Source}
+		entries[fqdn] = store.ResolvedEntry{Version: col.Version, Source: col.Source, DepsSource: col.DepsSource}
 	}
 	st.SetResolvedAll(entries)
 }
 
 // fetchJSONWithCachePolicy fetches JSON using cache policy and context.
+// Collection and version payloads are decoded tolerantly; see decodeGalaxyJSON.
 func fetchJSONWithCachePolicy(
 	ctx context.Context,
-	client *http.Client,
+	runtime *infra.Infra,
 	url string,
 	st *store.Store,
 	out any,
 	policy cacheManager.Policy,
 ) error {
-	return cacheManager.FetchJSONWithCachePolicy(ctx, client, url, st, out, policy)
+	var raw json.RawMessage
+	if err := cacheManager.FetchJSONWithCachePolicy(ctx, runtime.ClientFor(url), url, st, &raw, policy); err != nil {
+		return err
+	}
+	return decodeGalaxyJSON(runtime, url, raw, out)
 }
 
 // cachePolicyForConstraint builds a cache policy from config options.
@@ -0,0 +1,83 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// Datasource is a collection's release list in Renovate's custom datasource shape.
+type Datasource struct {
+	Releases  []Release `json:"releases"`
+	SourceURL string    `json:"sourceUrl,omitempty"`
+	Homepage  string    `json:"homepage,omitempty"`
+}
+
+// Release is one version entry of a Datasource.
+type Release struct {
+	Version          string `json:"version"`
+	ReleaseTimestamp string `json:"releaseTimestamp,omitempty"`
+	IsDeprecated     bool   `json:"isDeprecated,omitempty"`
+}
+
+// BuildDatasource lists all versions of a collection with release timestamps.
+// Responses go through the API cache in st, so repeated bot queries stay cheap.
+func BuildDatasource(ctx context.Context, cfg *config.Config, runtime *infra.Infra, st *store.Store, fqdn string) (*Datasource, error) {
+	namespace, name, ok := helpers.SplitFQDN(fqdn)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", helpers.ErrInvalidCollectionName, fqdn)
+	}
+	col := collection{Namespace: namespace, Name: name, Source: cfg.Server}
+	deps := collectionDeps{cfg: cfg, runtime: runtime, st: st}
+	policy := cachePolicyForConstraint(cfg, false)
+
+	rootMeta, versionsURL, err := resolveRootMetadata(ctx, deps, col, policy, fqdn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load root metadata: %w", err)
+	}
+	versions, timestamps, err := loadVersionTimestamps(ctx, deps, versionsURL, versionLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load versions list: %w", err)
+	}
+
+	out := &Datasource{Releases: make([]Release, 0, len(versions))}
+	for _, version := range sortVersionsDesc(versions) {
+		out.Releases = append(out.Releases, Release{
+			Version:          version,
+			ReleaseTimestamp: timestamps[version],
+			IsDeprecated:     rootMeta.Deprecated,
+		})
+	}
+	if highest := rootMeta.HighestVersion.Version; highest != "" {
+		info, err := fetchVersionMetadataCached(ctx, deps, col.Source, versionsURL, highest, cachePolicyForConstraint(cfg, true))
+		if err != nil {
+			runtime.Output.Debugf("datasource metadata for %s@%s: %v", fqdn, highest, err)
+		} else {
+			out.SourceURL = info.Metadata.Repository
+			out.Homepage = info.Metadata.Homepage
+		}
+	}
+	return out, nil
+}
+
+// loadVersionTimestamps fetches the full versions list with created_at values.
+// It requests the same URLs as loadVersionsListCached so both share API cache entries.
+func loadVersionTimestamps(ctx context.Context, deps collectionDeps, versionsURL string, limit int) ([]string, map[string]string, error) {
+	url := fmt.Sprintf("%s?limit=%d&offset=0", versionsURL, limit)
+	var payload map[string]any
+	if err := fetchJSONWithCachePolicy(ctx, deps.runtime, url, deps.st, &payload, cachePolicyForConstraint(deps.cfg, false)); err != nil {
+		return nil, nil, err
+	}
+	versions, total, err := parseVersionsPayload(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if total > limit {
+		return loadVersionTimestamps(ctx, deps, versionsURL, total)
+	}
+	return versions, parseVersionTimestamps(payload), nil
+}
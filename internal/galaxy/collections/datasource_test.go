@@ -0,0 +1,43 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestBuildDatasourceReleases(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/collections/a/b/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"versions_url":"/api/v3/collections/a/b/versions/","highest_version":{"version":"1.1.0"}}`)
+	})
+	mux.HandleFunc("/api/v3/collections/a/b/versions/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"meta":{"count":2},"data":[`+
+			`{"version":"1.0.0","created_at":"2024-01-01T00:00:00Z"},{"version":"1.1.0","created_at":"2024-02-01T00:00:00Z"}]}`)
+	})
+	mux.HandleFunc("/api/v3/collections/a/b/versions/1.1.0/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"version":"1.1.0","metadata":{"repository":"https://git.example.com/a/b"}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	ds, err := BuildDatasource(context.Background(), &config.Config{Server: srv.URL}, runtime, store.New(), "a.b")
+	if err != nil {
+		t.Fatalf("BuildDatasource: %v", err)
+	}
+	if len(ds.Releases) != 2 || ds.Releases[0].Version != "1.1.0" || ds.Releases[0].ReleaseTimestamp != "2024-02-01T00:00:00Z" {
+		t.Fatalf("unexpected releases: %+v", ds.Releases)
+	}
+	if ds.SourceURL != "https://git.example.com/a/b" {
+		t.Fatalf("unexpected source url: %q", ds.SourceURL)
+	}
+}
@@ -12,6 +12,13 @@ type collectionDeps struct {
 	cfg     *config.Config
 	runtime *infra.Infra
 	st      *store.Store
+	semver  *semverCache
+	// backend shares resolutions between runners when it keeps them; nil skips sharing.
+	backend cacheManager.Backend
+	// trace records resolver decisions for --trace; nil records nothing.
+	trace *resolveTrace
+	// replacements renames replaced collections; nil renames nothing.
+	replacements *replacements
 }
 
 type installDeps struct {
@@ -19,16 +26,35 @@ type installDeps struct {
 
 	artifacts cacheManager.ArtifactStore
 	db        *bolt.DB
+	// extractSlots caps concurrent extractions so CPU-bound decompression
+	// stays within GOMAXPROCS even when many download workers are configured.
+	extractSlots chan struct{}
+	// hashes runs artifact hashing, shared with the prefetcher.
+	hashes *hashPool
+	// downloads shares artifact downloads with the prefetcher; nil shares nothing.
+	downloads *artifactDownloads
+	// fingerprints maps collections paths to their fingerprint at the start
+	// of the run; a missing path is checked on disk.
+	fingerprints map[string]string
 }
 
 type prefetchDeps struct {
 	collectionDeps
 
-	artifacts cacheManager.ArtifactStore
+	artifacts    cacheManager.ArtifactStore
+	downloads    *artifactDownloads
+	hashes       *hashPool
+	fingerprints map[string]string
 }
 
 func newCollectionDeps(cfg *config.Config, runtime *infra.Infra, st *store.Store) collectionDeps {
-	return collectionDeps{cfg: cfg, runtime: runtime, st: st}
+	return collectionDeps{
+		cfg:          cfg,
+		runtime:      runtime,
+		st:           st,
+		semver:       newSemverCache(),
+		replacements: newReplacements(cfg, runtime),
+	}
 }
 
 func newInstallDeps(
@@ -42,6 +68,8 @@ func newInstallDeps(
 		collectionDeps: newCollectionDeps(cfg, runtime, st),
 		artifacts:      artifacts,
 		db:             db,
+		extractSlots:   make(chan struct{}, max(cfg.ExtractWorkers, 1)),
+		hashes:         newHashPool(cfg.ExtractWorkers),
 	}
 }
 
@@ -50,9 +78,12 @@ func newPrefetchDeps(
 	runtime *infra.Infra,
 	st *store.Store,
 	artifacts cacheManager.ArtifactStore,
+	downloads *artifactDownloads,
 ) prefetchDeps {
 	return prefetchDeps{
 		collectionDeps: newCollectionDeps(cfg, runtime, st),
 		artifacts:      artifacts,
+		downloads:      downloads,
+		hashes:         newHashPool(cfg.ExtractWorkers),
 	}
 }
@@ -0,0 +1,105 @@
+package collections
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+// Version metadata and MANIFEST.json both list a collection's dependencies
+// and occasionally disagree. --deps-source picks the one that wins; the other
+// is only used when the preferred one lists nothing, and a disagreement is
+// reported where the choice is made.
+
+// pickDependencies returns the dependencies cfg prefers and where they came from.
+func pickDependencies(cfg *config.Config, metadata, manifest map[string]string) (map[string]string, string) {
+	if cfg.DepsSource == config.DepsSourceManifest {
+		if len(manifest) > 0 || len(metadata) == 0 {
+			return manifest, config.DepsSourceManifest
+		}
+		return metadata, config.DepsSourceMetadata
+	}
+	if len(metadata) > 0 || len(manifest) == 0 {
+		return metadata, config.DepsSourceMetadata
+	}
+	return manifest, config.DepsSourceManifest
+}
+
+// versionDependencies picks the dependencies of the version key from its
+// metadata, which embeds the collection's MANIFEST.json on most servers.
+func versionDependencies(deps collectionDeps, key string, info *types.GalaxyCollectionVersionInfo) (map[string]string, string) {
+	metadata, manifest := info.Metadata.Dependencies, info.Manifest.CollectionInfo.Dependencies
+	chosen, source := pickDependencies(deps.cfg, metadata, manifest)
+	warnDepsDivergence(deps.runtime, key, metadata, manifest, source)
+	return chosen, source
+}
+
+// warnDepsDivergence reports when metadata and manifest both list dependencies
+// for key and the lists differ.
+func warnDepsDivergence(runtime *infra.Infra, key string, metadata, manifest map[string]string, used string) {
+	if len(metadata) == 0 || len(manifest) == 0 || maps.Equal(metadata, manifest) {
+		return
+	}
+	runtime.Output.Printf("⚠️ %s: dependencies differ between version metadata (%s) and MANIFEST.json (%s), using %s",
+		key, formatDeps(metadata), formatDeps(manifest), used)
+}
+
+// formatDeps renders dependencies as sorted name:constraint pairs.
+func formatDeps(deps map[string]string) string {
+	parts := make([]string, 0, len(deps))
+	for _, name := range slices.Sorted(maps.Keys(deps)) {
+		parts = append(parts, name+":"+deps[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// depsCachePolicy returns the policy for the dependency cache, which holds
+// what version metadata lists. Under --deps-source manifest it is bypassed;
+// the version metadata itself is still served from the API cache.
+func depsCachePolicy(cfg *config.Config, policy cacheManager.Policy) cacheManager.Policy {
+	if cfg.DepsSource == config.DepsSourceManifest {
+		return cacheManager.Policy{}
+	}
+	return policy
+}
+
+// withDepsSource folds a non-default --deps-source into the requirements
+// hash, so a resolution made under the other policy is not reused.
+func withDepsSource(cfg *config.Config, reqHash string) string {
+	if cfg.DepsSource != config.DepsSourceManifest {
+		return reqHash
+	}
+	sum := sha256.Sum256([]byte(reqHash + "|deps-source=" + cfg.DepsSource))
+	return hex.EncodeToString(sum[:])
+}
+
+// manifestDependencies reads the dependencies from an installed collection's
+// MANIFEST.json; a missing manifest lists none.
+func manifestDependencies(installPath string) (map[string]string, error) {
+	manifestPath := filepath.Join(installPath, "MANIFEST.json")
+	//nolint:gosec // manifestPath is derived from install path and is trusted.
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var parsed types.GalaxyCollectionVersionInfoManifest
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid MANIFEST.json: %w", err)
+	}
+	return parsed.CollectionInfo.Dependencies, nil
+}
@@ -0,0 +1,68 @@
+package collections
+
+import (
+	"maps"
+	"testing"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+func TestPickDependencies(t *testing.T) {
+	t.Parallel()
+	metadata := map[string]string{"a.b": ">=1.0.0"}
+	manifest := map[string]string{"a.b": ">=1.0.0", "c.d": "*"}
+	tests := []struct {
+		name       string
+		policy     string
+		metadata   map[string]string
+		manifest   map[string]string
+		want       map[string]string
+		wantSource string
+	}{
+		{"default prefers metadata", "", metadata, manifest, metadata, config.DepsSourceMetadata},
+		{"metadata", config.DepsSourceMetadata, metadata, manifest, metadata, config.DepsSourceMetadata},
+		{"manifest", config.DepsSourceManifest, metadata, manifest, manifest, config.DepsSourceManifest},
+		{"metadata falls back to manifest", config.DepsSourceMetadata, nil, manifest, manifest, config.DepsSourceManifest},
+		{"manifest falls back to metadata", config.DepsSourceManifest, metadata, nil, metadata, config.DepsSourceMetadata},
+		{"neither lists any", config.DepsSourceManifest, nil, nil, nil, config.DepsSourceManifest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, source := pickDependencies(&config.Config{DepsSource: tt.policy}, tt.metadata, tt.manifest)
+			if !maps.Equal(got, tt.want) || source != tt.wantSource {
+				t.Fatalf("got %v from %s, want %v from %s", got, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestWithDepsSourceSeparatesResolutions(t *testing.T) {
+	t.Parallel()
+	const hash = "abc"
+	if got := withDepsSource(&config.Config{DepsSource: config.DepsSourceMetadata}, hash); got != hash {
+		t.Fatalf("metadata policy must keep the requirements hash, got %s", got)
+	}
+	if got := withDepsSource(&config.Config{DepsSource: config.DepsSourceManifest}, hash); got == hash {
+		t.Fatal("manifest policy must change the requirements hash")
+	}
+}
+
+func TestCacheDepsKeepsOnlyMetadataDependencies(t *testing.T) {
+	t.Parallel()
+	st := store.New()
+	policy := cacheManager.Policy{Read: true, Write: true}
+	deps := map[string]string{"c.d": "*"}
+	cacheDeps(st, policy, "a.b@1.0.0", deps, config.DepsSourceManifest)
+	task := resolveTask{FQDN: "a.b", Namespace: "a", Name: "b"}
+	if res, ok := cachedResult(task, "1.0.0", st, policy); ok {
+		t.Fatalf("manifest fallback dependencies must not be cached, got %+v", res)
+	}
+	cacheDeps(st, policy, "a.b@1.0.0", deps, config.DepsSourceMetadata)
+	res, ok := cachedResult(task, "1.0.0", st, policy)
+	if !ok || !maps.Equal(res.Deps, deps) || res.DepsSource != config.DepsSourceMetadata {
+		t.Fatalf("expected cached metadata dependencies, got %+v, %v", res, ok)
+	}
+}
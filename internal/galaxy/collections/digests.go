@@ -0,0 +1,51 @@
+package collections
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// installDigests are the keys install reports for pipelines to cache
+// downstream steps on.
+type installDigests struct {
+	// RequirementsHash is the hash stored resolutions are keyed on.
+	RequirementsHash string `json:"requirements_hash"`
+	// ResolutionDigest changes whenever a resolved version or edge does.
+	ResolutionDigest string `json:"resolution_digest"`
+}
+
+// newInstallDigests computes the digests of roots and the graph resolved for them.
+func newInstallDigests(cfg *config.Config, roots []collection, graph map[string][]string) installDigests {
+	return installDigests{
+		RequirementsHash: withReplacements(cfg, withDepsSource(cfg, requirementsSignatureFromSpec(buildRequirementsSpec(cfg, roots)))),
+		ResolutionDigest: resolutionDigest(graph),
+	}
+}
+
+// resolutionDigest hashes graph with its nodes and their edges sorted, so it
+// does not depend on the order resolution visited them in.
+func resolutionDigest(graph map[string][]string) string {
+	lines := make([]string, 0, len(graph))
+	for _, key := range slices.Sorted(maps.Keys(graph)) {
+		lines = append(lines, key+"|"+strings.Join(slices.Sorted(slices.Values(graph[key])), ","))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// reportDigests prints digests as a progress line, or with --output json
+// writes them to stdout, where --quiet leaves nothing else.
+func reportDigests(cfg *config.Config, runtime *infra.Infra, digests installDigests) error {
+	if cfg.Output == config.OutputJSON {
+		return json.NewEncoder(runtime.Stdout).Encode(digests)
+	}
+	runtime.Output.PersistentPrintf("🔑 requirements hash %s, resolution digest %s", digests.RequirementsHash, digests.ResolutionDigest)
+	return nil
+}
@@ -0,0 +1,45 @@
+package collections
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestResolutionDigestIgnoresOrder(t *testing.T) {
+	t.Parallel()
+	a := map[string][]string{"a.b@1.0.0": {"c.d@2.0.0", "e.f@1.0.0"}, "c.d@2.0.0": nil, "e.f@1.0.0": {}}
+	b := map[string][]string{"e.f@1.0.0": nil, "c.d@2.0.0": {}, "a.b@1.0.0": {"e.f@1.0.0", "c.d@2.0.0"}}
+	if resolutionDigest(a) != resolutionDigest(b) {
+		t.Fatal("expected the same digest for the same graph")
+	}
+	b["a.b@1.0.0"] = []string{"c.d@2.0.1"}
+	if resolutionDigest(a) == resolutionDigest(b) {
+		t.Fatal("expected a changed edge to change the digest")
+	}
+}
+
+func TestReportDigestsJSON(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	runtime := infra.New(progress.New(false, true), http.DefaultClient)
+	runtime.Stdout = &out
+	cfg := &config.Config{Server: "https://galaxy.invalid", Output: config.OutputJSON}
+	roots := []collection{{Namespace: "a", Name: "b", Version: ">=1.0.0", Constraint: ">=1.0.0"}}
+	want := newInstallDigests(cfg, roots, map[string][]string{"a.b@1.0.0": nil})
+	if err := reportDigests(cfg, runtime, want); err != nil {
+		t.Fatalf("reportDigests: %v", err)
+	}
+	var got installDigests
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal %q: %v", out.String(), err)
+	}
+	if got != want || got.RequirementsHash == "" || got.ResolutionDigest == "" {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,76 @@
+package collections
+
+import (
+	"context"
+	"sync"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+)
+
+// artifactDownloads lets the prefetcher and the installers share artifact
+// downloads, so each artifact key is fetched at most once per install state:
+// a caller asking for an artifact that is already being downloaded waits for
+// that download and then reads the artifact from the cache.
+type artifactDownloads struct {
+	mu   sync.Mutex
+	byID map[string]*artifactDownload
+}
+
+// artifactDownload is one download of an artifact key.
+type artifactDownload struct {
+	done chan struct{}
+	err  error
+}
+
+// newArtifactDownloads returns an empty download registry.
+func newArtifactDownloads() *artifactDownloads {
+	return &artifactDownloads{byID: make(map[string]*artifactDownload)}
+}
+
+// do runs fetch for key unless another caller is fetching key or already
+// has, in which case it waits for that fetch and reports shared. A failed
+// fetch is shared with the callers that waited for it but not remembered,
+// so a later retry downloads again. A nil registry always runs fetch.
+func (d *artifactDownloads) do(ctx context.Context, key string, fetch func() error) (bool, error) {
+	if d == nil {
+		return false, fetch()
+	}
+	d.mu.Lock()
+	if running, ok := d.byID[key]; ok {
+		d.mu.Unlock()
+		select {
+		case <-running.done:
+			return true, running.err
+		case <-ctx.Done():
+			return true, context.Cause(ctx)
+		}
+	}
+	running := &artifactDownload{done: make(chan struct{})}
+	d.byID[key] = running
+	d.mu.Unlock()
+
+	running.err = fetch()
+	if running.err != nil {
+		d.mu.Lock()
+		delete(d.byID, key)
+		d.mu.Unlock()
+	}
+	close(running.done)
+	return false, running.err
+}
+
+// sharedDownload returns the artifact another caller downloaded into the
+// cache. As for other cache hits, SHA and Digest are only set when the store
+// keeps them with the artifact.
+func sharedDownload(ctx context.Context, artifacts cacheManager.ArtifactStore, key, algo string) (downloadResult, error) {
+	file, err := artifacts.Fetch(ctx, key)
+	if err != nil {
+		return downloadResult{}, err
+	}
+	result := downloadResult{Path: file.Path, SHA: file.Meta[archive.HashSHA256], Cleanup: file.Cleanup}
+	if algo != archive.HashSHA256 {
+		result.Digest = file.Meta[algo]
+	}
+	return result, nil
+}
@@ -0,0 +1,102 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/cache/local"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+func TestDownloadRefreshesRejectedSignedURL(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cdn/expired", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "Request has expired", http.StatusForbidden)
+	})
+	mux.HandleFunc("/download/a-b-1.0.0.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/cdn/fresh", http.StatusFound)
+	})
+	mux.HandleFunc("/cdn/fresh", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "tarball")
+	})
+	var srv *httptest.Server
+	mux.HandleFunc("/api/v3/collections/a/b/versions/1.0.0/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"version":"1.0.0","download_url":"%s/download/a-b-1.0.0.tar.gz"}`, srv.URL)
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	col := collection{Namespace: "a", Name: "b", Version: "1.0.0", Source: srv.URL}
+	stale := &types.GalaxyCollectionVersionInfo{
+		Version:     "1.0.0",
+		Href:        "/api/v3/collections/a/b/versions/1.0.0/",
+		DownloadURL: srv.URL + "/cdn/expired",
+	}
+	st := store.New()
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	deps := newInstallDeps(&config.Config{Server: srv.URL}, runtime, st, local.NewArtifacts(t.TempDir(), nil), nil)
+
+	result, err := downloadCollectionToCache(context.Background(), deps, col, stale, true)
+	if err != nil {
+		t.Fatalf("downloadCollectionToCache: %v", err)
+	}
+	defer cleanupIfNeeded(result.Cleanup)
+	if result.SHA == "" {
+		t.Fatalf("expected artifact sha")
+	}
+	dump, err := st.Dump([]string{"api_cache"}, srv.URL+stale.Href)
+	if err != nil {
+		t.Fatalf("Dump: %v", err)
+	}
+	if entries, ok := dump["api_cache"].(map[string]store.APICacheSummary); !ok || len(entries) != 1 {
+		t.Fatalf("expected refreshed metadata in API cache, got %#v", dump["api_cache"])
+	}
+}
+
+func TestDownloadSharesArtifactAcrossWorkers(t *testing.T) {
+	t.Parallel()
+	var downloads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		downloads.Add(1)
+		_, _ = fmt.Fprint(w, "tarball")
+	}))
+	defer srv.Close()
+
+	col := collection{Namespace: "a", Name: "b", Version: "1.0.0", Source: srv.URL}
+	meta := &types.GalaxyCollectionVersionInfo{Version: "1.0.0", DownloadURL: srv.URL + "/download/a-b-1.0.0.tar.gz"}
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	deps := newInstallDeps(&config.Config{Server: srv.URL}, runtime, store.New(), local.NewArtifacts(t.TempDir(), nil), nil)
+	deps.downloads = newArtifactDownloads()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Go(func() {
+			result, err := downloadCollectionToCache(context.Background(), deps, col, meta, true)
+			cleanupIfNeeded(result.Cleanup)
+			errs[i] = err
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("downloadCollectionToCache: %v", err)
+		}
+	}
+	if got := downloads.Load(); got != 1 {
+		t.Fatalf("expected one download, got %d", got)
+	}
+	if findCachedArtifact(context.Background(), deps.artifacts, col) == "" {
+		t.Fatalf("expected %s to be cached", col.key())
+	}
+}
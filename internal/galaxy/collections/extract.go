@@ -1,15 +1,24 @@
 package collections
 
 import (
+	"context"
+	"encoding/json"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
 	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
 )
 
+// stagingDirSuffix marks sibling directories used while extracting a collection.
+const stagingDirSuffix = ".staging-"
+
 // extractCollection unpacks a collection tarball into the install path.
-func extractCollection(col collection, tarPath, installPath string, runtime *infra.Infra, artifactSHA string) error {
+func extractCollection(ctx context.Context, col collection, tarPath, installPath string, deps installDeps, artifactSHA string) error {
+	runtime := deps.runtime
 	if artifactSHA == "" {
 		hash, err := archive.FileHashSHA256(tarPath)
 		if err != nil {
@@ -24,18 +33,163 @@ func extractCollection(col collection, tarPath, installPath string, runtime *inf
 		return nil
 	}
 
-	_ = os.RemoveAll(installPath)
-	if err := os.MkdirAll(installPath, dirMod); err != nil {
+	parent := filepath.Dir(installPath)
+	if err := os.MkdirAll(parent, dirMod); err != nil {
+		return err
+	}
+	// Stage next to the install path so the final rename never crosses filesystems.
+	staging, err := os.MkdirTemp(parent, "."+filepath.Base(installPath)+stagingDirSuffix)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(staging)
+	}()
+	if err := os.Chmod(staging, dirMod); err != nil {
 		return err
 	}
 
-	if err := archive.ExtractTarGz(tarPath, installPath); err != nil {
+	select {
+	case deps.extractSlots <- struct{}{}:
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+	opts := archive.Options{
+		Decompression: archive.Decompression{
+			Blocks:    deps.cfg.DecompressBlocks,
+			BlockSize: deps.cfg.DecompressBlockSize,
+		},
+		Limits: archive.Limits{
+			MaxEntries: deps.cfg.ArchiveMaxEntries,
+			MaxDepth:   deps.cfg.ArchiveMaxDepth,
+		},
+	}
+	// Progress lines are debug output; without --verbose skip the callback
+	// and the FILES.json read it does.
+	if deps.cfg.Verbose {
+		opts.Progress = newExtractProgress(runtime, col, staging).report
+	}
+	err = archive.ExtractTarGz(tarPath, staging, opts)
+	<-deps.extractSlots
+	if err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(cacheTag, []byte("ok"), fileMod); err != nil {
+	if err := os.WriteFile(filepath.Join(staging, filepath.Base(cacheTag)), []byte("ok"), fileMod); err != nil {
+		return err
+	}
+	if err := applyModes(staging, deps.cfg.ChmodFiles, deps.cfg.ChmodDirs); err != nil {
 		return err
 	}
 
-	return nil
+	_ = os.RemoveAll(installPath)
+	return os.Rename(staging, installPath)
+}
+
+// applyModes sets the --chmod-files and --chmod-dirs modes on the extracted
+// tree with explicit chmod calls, so neither the archive nor the umask decides
+// them. A zero mode keeps what extraction produced. Executable files also get
+// execute permission wherever the file mode grants read, so scripts keep
+// working. Symlinks are left alone.
+func applyModes(root string, files, dirs os.FileMode) error {
+	if files == 0 && dirs == 0 {
+		return nil
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			return nil
+		case d.IsDir():
+			if dirs == 0 {
+				return nil
+			}
+			return os.Chmod(path, dirs)
+		case d.Type().IsRegular():
+			if files == 0 {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			mode := files
+			if info.Mode().Perm()&0o100 != 0 {
+				mode |= (files & 0o444) >> 2
+			}
+			return os.Chmod(path, mode)
+		default:
+			return nil
+		}
+	})
+}
+
+// extractProgressStep is how many files are extracted between progress lines
+// when FILES.json gives no total.
+const extractProgressStep = 1000
+
+// extractProgress logs how far the extraction of one collection got: every
+// tenth of the files FILES.json lists, or every extractProgressStep files
+// before FILES.json is read or when it is missing.
+type extractProgress struct {
+	runtime *infra.Infra
+	col     collection
+	staging string
+	// total counts the files FILES.json lists plus MANIFEST.json and
+	// FILES.json themselves; 0 while unknown.
+	total  int
+	logged int
+}
+
+// newExtractProgress starts the progress of extracting col into staging.
+func newExtractProgress(runtime *infra.Infra, col collection, staging string) *extractProgress {
+	return &extractProgress{runtime: runtime, col: col, staging: staging}
+}
+
+// report implements archive.Options.Progress.
+func (p *extractProgress) report(name string, files int) {
+	if p.total == 0 && path.Clean(name) == "FILES.json" {
+		p.total = p.filesTotal()
+	}
+	step := extractProgressStep
+	if p.total > 0 {
+		step = max(p.total/10, 1)
+	}
+	if files-p.logged < step && files != p.total {
+		return
+	}
+	p.logged = files
+	if p.total > 0 {
+		p.runtime.Output.Debugf("extract %s: %d/%d files", p.col.key(), files, p.total)
+		return
+	}
+	p.runtime.Output.Debugf("extract %s: %d files", p.col.key(), files)
+}
+
+// filesTotal counts the regular files of the extracted FILES.json, or
+// returns 0 when it cannot be read.
+func (p *extractProgress) filesTotal() int {
+	//nolint:gosec // staging is the extraction directory created for this install.
+	data, err := os.ReadFile(filepath.Join(p.staging, "FILES.json"))
+	if err != nil {
+		return 0
+	}
+	var manifest filesManifest
+	if json.Unmarshal(data, &manifest) != nil {
+		return 0
+	}
+	total := 2
+	for _, entry := range manifest.Files {
+		if entry.Ftype == "file" {
+			total++
+		}
+	}
+	return total
+}
+
+// isStagingDir reports whether name is an in-progress extraction directory.
+func isStagingDir(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, stagingDirSuffix)
 }
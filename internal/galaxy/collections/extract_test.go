@@ -0,0 +1,108 @@
+package collections
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestExtractCollectionStagesBesideInstallPath(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	tarPath := filepath.Join(dir, "a-b-1.0.0.tar.gz")
+	writeTestTarball(t, tarPath, map[string]string{"MANIFEST.json": "{}"})
+
+	installPath := filepath.Join(dir, "ansible_collections", "a", "b")
+	if err := os.MkdirAll(installPath, dirMod); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(installPath, "stale.txt"), []byte("old"), fileMod); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	col := collection{Namespace: "a", Name: "b", Version: "1.0.0"}
+	runtime := infra.New(progress.New(false, true), nil)
+	deps := newInstallDeps(&config.Config{ExtractWorkers: 1, DecompressBlocks: 2}, runtime, nil, nil, nil)
+	if err := extractCollection(context.Background(), col, tarPath, installPath, deps, "abc"); err != nil {
+		t.Fatalf("extractCollection: %v", err)
+	}
+
+	for _, name := range []string{"MANIFEST.json", ".extract-done.abc"} {
+		if _, err := os.Stat(filepath.Join(installPath, name)); err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(installPath, "stale.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected stale file to be replaced, got %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(installPath))
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "b" {
+		t.Fatalf("expected only the install dir, got %v", entries)
+	}
+}
+
+func TestApplyModesIgnoresArchiveModes(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "plugins"), 0o700); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	for name, mode := range map[string]os.FileMode{"README.md": 0o600, "plugins/run.sh": 0o700} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x"), mode); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	if err := os.Symlink("README.md", filepath.Join(root, "link.md")); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+	if err := applyModes(root, 0o644, 0o755); err != nil {
+		t.Fatalf("applyModes: %v", err)
+	}
+	want := map[string]os.FileMode{".": 0o755, "plugins": 0o755, "README.md": 0o644, "plugins/run.sh": 0o755}
+	for name, mode := range want {
+		info, err := os.Stat(filepath.Join(root, name))
+		if err != nil {
+			t.Fatalf("Stat: %v", err)
+		}
+		if got := info.Mode().Perm(); got != mode {
+			t.Fatalf("%s: expected %04o, got %04o", name, mode, got)
+		}
+	}
+}
+
+func writeTestTarball(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: fileMod, Size: int64(len(body))}); err != nil {
+			t.Fatalf("WriteHeader: %v", err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar Close: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip Close: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package collections
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// treeFingerprint rolls up the modification time and size of the
+// ansible_collections directory under collectionsPath, of every namespace
+// directory below it and of every collection directory in those. Installing,
+// removing or renaming a collection or its .info directory changes it, and
+// so does adding or removing a file at the top of a collection, such as its
+// extraction marker; edits deeper inside a collection do not. It is empty
+// when the tree cannot be read.
+func treeFingerprint(cfg *config.Config, collectionsPath string) string {
+	root := filepath.Join(collectionsPath, "ansible_collections")
+	info, err := os.Stat(root)
+	if err != nil {
+		return ""
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	// GALAXY.yml is only written in some modes, so a new mode must not
+	// trust a tree written under another.
+	fmt.Fprintf(h, "galaxy-info=%s\n%d %d\n", cfg.GalaxyInfo, info.ModTime().UnixNano(), info.Size())
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if !fingerprintDir(h, root, entry.Name()) {
+			return ""
+		}
+		if strings.HasSuffix(entry.Name(), ".info") {
+			continue
+		}
+		children, err := os.ReadDir(filepath.Join(root, entry.Name()))
+		if err != nil {
+			return ""
+		}
+		for _, child := range children {
+			if child.IsDir() && !fingerprintDir(h, root, entry.Name()+"/"+child.Name()) {
+				return ""
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fingerprintDir writes the modification time and size of the directory rel
+// under root to w, reporting whether it could be read.
+func fingerprintDir(w io.Writer, root, rel string) bool {
+	info, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return false
+	}
+	fmt.Fprintf(w, "%s %d %d\n", rel, info.ModTime().UnixNano(), info.Size())
+	return true
+}
+
+// collectionsPathFingerprints fingerprints every collections path the
+// collections install into, before anything is installed.
+func collectionsPathFingerprints(cfg *config.Config, collections map[string]collection) map[string]string {
+	fingerprints := make(map[string]string)
+	for _, col := range collections {
+		path := col.collectionsPath(cfg)
+		if _, ok := fingerprints[path]; !ok {
+			fingerprints[path] = treeFingerprint(cfg, path)
+		}
+	}
+	return fingerprints
+}
+
+// stampFingerprints records the fingerprint of their collections path, as
+// left by this run, in the installed entries of collections, so the next
+// run can trust them without checking each one on disk.
+func stampFingerprints(cfg *config.Config, st *store.Store, collections map[string]collection) {
+	if cfg.DryRun || st == nil {
+		return
+	}
+	fingerprints := make(map[string]string)
+	for _, col := range collections {
+		entry, ok := st.GetInstalled(col.key())
+		if !ok || entry.InstallPath != col.installDir(cfg) {
+			continue
+		}
+		path := col.collectionsPath(cfg)
+		fingerprint, ok := fingerprints[path]
+		if !ok {
+			fingerprint = treeFingerprint(cfg, path)
+			fingerprints[path] = fingerprint
+		}
+		if entry.PathFingerprint == fingerprint {
+			continue
+		}
+		entry.PathFingerprint = fingerprint
+		st.SetInstalled(col.key(), entry)
+	}
+}
@@ -0,0 +1,68 @@
+package collections
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+func TestCanSkipInstallTrustsUnchangedFingerprint(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{DownloadPath: t.TempDir(), GalaxyInfo: config.GalaxyInfoNone}
+	col := collection{Namespace: "a", Name: "b", Version: "1.0.0"}
+	other := collection{Namespace: "c", Name: "d", Version: "1.0.0"}
+	for _, dir := range []string{col.installDir(cfg), other.installDir(cfg)} {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+	}
+	st := store.New()
+	st.SetInstalled(col.key(), store.InstalledEntry{InstallPath: col.installDir(cfg), ArtifactID: "id"})
+	stampFingerprints(cfg, st, map[string]collection{col.key(): col})
+
+	// no extraction marker: only the fingerprint can let the skip through
+	fingerprint := treeFingerprint(cfg, cfg.DownloadPath)
+	if fingerprint == "" {
+		t.Fatalf("expected a fingerprint")
+	}
+	if !canSkipInstall(cfg, col, col.installDir(cfg), st, fingerprint) {
+		t.Fatalf("expected skip with an unchanged fingerprint")
+	}
+	if canSkipInstall(cfg, col, col.installDir(cfg), st, "") {
+		t.Fatalf("expected the marker to be checked without a fingerprint")
+	}
+	verify := *cfg
+	verify.VerifySkip = config.VerifySkipSample
+	if canSkipInstall(&verify, col, col.installDir(cfg), st, fingerprint) {
+		t.Fatalf("expected --verify-skip to bypass the fingerprint")
+	}
+
+	// a marker removed from the collection touches its directory
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(col.installDir(cfg), later, later); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	touched := treeFingerprint(cfg, cfg.DownloadPath)
+	if touched == fingerprint {
+		t.Fatalf("expected a change inside a collection directory to change the fingerprint")
+	}
+	if canSkipInstall(cfg, col, col.installDir(cfg), st, touched) {
+		t.Fatalf("expected a changed collection directory to fall back to the marker")
+	}
+	fingerprint = touched
+
+	if err := os.RemoveAll(filepath.Join(cfg.DownloadPath, "ansible_collections", "c")); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	changed := treeFingerprint(cfg, cfg.DownloadPath)
+	if changed == fingerprint {
+		t.Fatalf("expected removing a namespace to change the fingerprint")
+	}
+	if canSkipInstall(cfg, col, col.installDir(cfg), st, changed) {
+		t.Fatalf("expected a changed fingerprint to fall back to the marker")
+	}
+}
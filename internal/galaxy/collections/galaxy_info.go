@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/greeddj/go-galaxy/internal/galaxy/config"
 	"github.com/psvmcc/hub/pkg/types"
@@ -17,23 +18,31 @@ const (
 
 // GalaxyYAML represents the GALAXY.yml metadata file.
 type GalaxyYAML struct {
-	DownloadURL string `yaml:"download_url"`
-	FormatVer   string `yaml:"format_version"`
-	Name        string `yaml:"name"`
-	Namespace   string `yaml:"namespace"`
-	Server      string `yaml:"server"`
-	Signatures  any    `yaml:"signatures"`
-	Version     string `yaml:"version"`
-	VersionURL  string `yaml:"version_url"`
+	ArtifactSHA256 string   `yaml:"artifact_sha256,omitempty"`
+	Dependencies   []string `yaml:"dependencies,omitempty"`
+	DownloadURL    string   `yaml:"download_url"`
+	FormatVer      string   `yaml:"format_version"`
+	Name           string   `yaml:"name"`
+	Namespace      string   `yaml:"namespace"`
+	Server         string   `yaml:"server"`
+	Signatures     any      `yaml:"signatures"`
+	Version        string   `yaml:"version"`
+	VersionURL     string   `yaml:"version_url"`
 }
 
-// writeGalaxyInfo writes GALAXY.yml for the installed collection.
-func writeGalaxyInfo(cfg *config.Config, meta *types.GalaxyCollectionVersionInfo) error {
-	if meta == nil {
+// galaxyInfoExtra carries install results added to GALAXY.yml in extended mode.
+type galaxyInfoExtra struct {
+	artifactSHA string
+	deps        []string
+}
+
+// writeGalaxyInfo writes GALAXY.yml for the installed collection under the collections path base.
+func writeGalaxyInfo(cfg *config.Config, base string, meta *types.GalaxyCollectionVersionInfo, extra galaxyInfoExtra) error {
+	if meta == nil || cfg.GalaxyInfo == config.GalaxyInfoNone {
 		return nil
 	}
 	infoDir := filepath.Join(
-		cfg.DownloadPath,
+		base,
 		"ansible_collections",
 		fmt.Sprintf("%s.%s-%s.info", meta.Namespace.Name, meta.Name, meta.Version),
 	)
@@ -51,6 +60,16 @@ func writeGalaxyInfo(cfg *config.Config, meta *types.GalaxyCollectionVersionInfo
 		Version:     meta.Version,
 		VersionURL:  meta.Href,
 	}
+	switch cfg.GalaxyInfo {
+	case config.GalaxyInfoExtended:
+		g.ArtifactSHA256 = extra.artifactSHA
+		g.Dependencies = slices.Sorted(slices.Values(extra.deps))
+	case config.GalaxyInfoAnsible:
+		// ansible-galaxy always writes a list, never null.
+		if g.Signatures == nil {
+			g.Signatures = []any{}
+		}
+	}
 
 	data, err := yaml.Marshal(&g)
 	if err != nil {
@@ -0,0 +1,51 @@
+package collections
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+func TestWriteGalaxyInfoModes(t *testing.T) {
+	t.Parallel()
+	meta := &types.GalaxyCollectionVersionInfo{Name: "b", Version: "1.0.0"}
+	meta.Namespace.Name = "a"
+	extra := galaxyInfoExtra{artifactSHA: "abc123", deps: []string{"c.d@2.0.0", "b.a@1.0.0"}}
+
+	read := func(t *testing.T, mode string) (string, bool) {
+		t.Helper()
+		base := t.TempDir()
+		cfg := &config.Config{Server: "https://galaxy.example.com", GalaxyInfo: mode}
+		if err := writeGalaxyInfo(cfg, base, meta, extra); err != nil {
+			t.Fatalf("writeGalaxyInfo(%s): %v", mode, err)
+		}
+		data, err := os.ReadFile(filepath.Join(base, "ansible_collections", "a.b-1.0.0.info", "GALAXY.yml"))
+		if os.IsNotExist(err) {
+			return "", false
+		}
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		return string(data), true
+	}
+
+	if _, ok := read(t, config.GalaxyInfoNone); ok {
+		t.Fatal("none mode must not write GALAXY.yml")
+	}
+	def, _ := read(t, config.GalaxyInfoDefault)
+	if strings.Contains(def, "artifact_sha256") || strings.Contains(def, "dependencies") {
+		t.Fatalf("default mode should keep the standard fields only:\n%s", def)
+	}
+	ext, _ := read(t, config.GalaxyInfoExtended)
+	if !strings.Contains(ext, "artifact_sha256: abc123") || !strings.Contains(ext, "- b.a@1.0.0\n    - c.d@2.0.0") {
+		t.Fatalf("extended mode should add sha and sorted deps:\n%s", ext)
+	}
+	ansible, _ := read(t, config.GalaxyInfoAnsible)
+	if !strings.Contains(ansible, "signatures: []") || strings.Contains(ansible, "artifact_sha256") {
+		t.Fatalf("ansible mode should match ansible-galaxy output:\n%s", ansible)
+	}
+}
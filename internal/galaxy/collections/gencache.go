@@ -0,0 +1,298 @@
+package collections
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/klauspost/pgzip"
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+// genCacheNamespace is the namespace of every fabricated collection.
+const genCacheNamespace = "bench"
+
+// GenCacheOptions sizes the synthetic cache built by GenerateCache.
+type GenCacheOptions struct {
+	// Collections is how many collections to fabricate.
+	Collections int
+	// Versions is how many versions each collection gets.
+	Versions int
+	// Deps is how many earlier collections each version depends on.
+	Deps int
+	// Seed picks the dependency edges; the same seed builds the same cache.
+	Seed uint64
+	// Requirements, when set, is written as a requirements file naming
+	// every fabricated collection.
+	Requirements string
+}
+
+// genCollection is one fabricated collection and the collections its
+// versions depend on.
+type genCollection struct {
+	name string
+	deps map[string]string
+}
+
+// GenerateCache fills the configured cache with fabricated collections:
+// root, versions and version metadata for the configured server, dependency
+// cache entries and one small artifact per version. It gives snapshot
+// load/save and resolution benchmarks a cache of known size without a
+// Galaxy server.
+func GenerateCache(ctx context.Context, cfg *config.Config, runtime *infra.Infra, opts GenCacheOptions) error {
+	if opts.Collections <= 0 || opts.Versions <= 0 || opts.Deps < 0 {
+		return fmt.Errorf("%w: %d collections, %d versions, %d deps",
+			helpers.ErrInvalidFixture, opts.Collections, opts.Versions, opts.Deps)
+	}
+	apiRoots := apiRootCandidates(cfg.Server)
+	if len(apiRoots) == 0 {
+		return fmt.Errorf("%w: no server configured", helpers.ErrInvalidFixture)
+	}
+	cols := planGenCollections(opts)
+	if cfg.DryRun {
+		runtime.Output.Printf("🧪 Dry run: %d collections with %d versions each not generated", len(cols), opts.Versions)
+		return nil
+	}
+	runtime.Output.Printf("🧪 Generating %d collections with %d versions each", opts.Collections, opts.Versions)
+	start := time.Now()
+	state, err := openInstallState(ctx, cfg, runtime)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = state.release()
+	}()
+	defer func() {
+		_ = state.backend.Close(context.WithoutCancel(ctx))
+	}()
+
+	errs := make([]error, len(cols))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(cfg.Workers, 1))
+	for i, col := range cols {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			errs[i] = generateCollection(ctx, cfg, state, apiRoots[0], col, opts.Versions)
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	if err := state.backend.SaveStore(ctx, state.store); err != nil {
+		return err
+	}
+	if opts.Requirements != "" {
+		if err := writeGenRequirements(opts.Requirements, cols); err != nil {
+			return err
+		}
+	}
+	runtime.Output.PersistentPrintf("🤩 Generated %d collections, %d artifacts. Took %s",
+		len(cols), len(cols)*opts.Versions, time.Since(start).Round(time.Second))
+	return nil
+}
+
+// planGenCollections names the collections and draws their dependencies
+// from the collections before them, so the graph has no cycles. Each draw
+// takes Deps distinct indices with Floyd's algorithm, so planning stays
+// linear in the number of edges rather than the square of the collections.
+func planGenCollections(opts GenCacheOptions) []genCollection {
+	//nolint:gosec // fixture shape only, reproducible by design.
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed))
+	cols := make([]genCollection, opts.Collections)
+	for i := range cols {
+		cols[i] = genCollection{name: fmt.Sprintf("c%06d", i), deps: make(map[string]string)}
+		if i == 0 {
+			continue
+		}
+		for j := i - min(opts.Deps, i); j < i; j++ {
+			dep := genCacheNamespace + "." + cols[rng.IntN(j+1)].name
+			if _, taken := cols[i].deps[dep]; taken {
+				dep = genCacheNamespace + "." + cols[j].name
+			}
+			cols[i].deps[dep] = ">=1.0.0"
+		}
+	}
+	return cols
+}
+
+// genVersion returns the n-th fabricated version; 1.0.0 comes first.
+func genVersion(n int) string {
+	return fmt.Sprintf("1.%d.0", n)
+}
+
+// generateCollection stores the metadata and artifacts of every version of col.
+func generateCollection(ctx context.Context, cfg *config.Config, state *installState, apiRoot string, col genCollection, count int) error {
+	rootURL := fmt.Sprintf("%s/collections/%s/%s/", apiRoot, genCacheNamespace, col.name)
+	versionsURL := rootURL + "versions/"
+	versions := make([]string, count)
+	for n := range versions {
+		versions[n] = genVersion(n)
+	}
+	highest := versions[count-1]
+
+	var root types.GalaxyCollection
+	root.Href = rootURL
+	root.Namespace = genCacheNamespace
+	root.Name = col.name
+	root.VersionsURL = versionsURL
+	root.HighestVersion.Version = highest
+	root.HighestVersion.Href = versionsURL + highest + "/"
+	if err := seedJSON(state.store, rootURL, root); err != nil {
+		return err
+	}
+	if err := seedVersionsList(state.store, versionsURL, versions); err != nil {
+		return err
+	}
+
+	for _, version := range versions {
+		tarball, err := genArtifact(col, version)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(tarball)
+		sha := hex.EncodeToString(sum[:])
+		target := collection{Namespace: genCacheNamespace, Name: col.name, Version: version, Source: cfg.Server}
+		var info types.GalaxyCollectionVersionInfo
+		info.Href = versionsURL + version + "/"
+		info.Namespace.Name = genCacheNamespace
+		info.Name = col.name
+		info.Version = version
+		info.Artifact.Filename = fmt.Sprintf("%s-%s-%s.tar.gz", genCacheNamespace, col.name, version)
+		info.Artifact.Sha256 = sha
+		info.Artifact.Size = int64(len(tarball))
+		info.DownloadURL = strings.TrimRight(cfg.Server, "/") + "/download/" + info.Artifact.Filename
+		info.Metadata.Dependencies = col.deps
+		if err := seedJSON(state.store, info.Href, info); err != nil {
+			return err
+		}
+		state.store.SetDepsCache(target.key(), col.deps)
+		if err := putGenArtifact(ctx, state.backend.Artifacts(), artifactKey(target), tarball, sha); err != nil {
+			return fmt.Errorf("%s: %w", target.key(), err)
+		}
+	}
+	return nil
+}
+
+// seedVersionsList stores the versions list, oldest first, both as the API
+// pages the resolver requests and, newest first, in the versions cache.
+func seedVersionsList(st *store.Store, versionsURL string, versions []string) error {
+	newest := slices.Clone(versions)
+	slices.Reverse(newest)
+	data := make([]map[string]string, 0, len(versions))
+	for _, version := range newest {
+		data = append(data, map[string]string{"version": version})
+	}
+	page := map[string]any{"data": data, "meta": map[string]int{"count": len(versions)}, "links": map[string]any{}}
+	for _, limit := range []int{versionLimit, len(versions)} {
+		if err := seedJSON(st, fmt.Sprintf("%s?limit=%d&offset=0", versionsURL, limit), page); err != nil {
+			return err
+		}
+	}
+	st.SetVersionsCache(versionsURL, newest)
+	return nil
+}
+
+// seedJSON stores v as the cached API response for url.
+func seedJSON(st *store.Store, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	cacheManager.SeedAPICache(st, url, body)
+	return nil
+}
+
+// genArtifact builds a collection tarball holding only MANIFEST.json and
+// FILES.json, which is enough to install it.
+func genArtifact(col genCollection, version string) ([]byte, error) {
+	var manifest artifactManifest
+	manifest.CollectionInfo.Namespace = genCacheNamespace
+	manifest.CollectionInfo.Name = col.name
+	manifest.CollectionInfo.Version = version
+	manifest.CollectionInfo.Dependencies = col.deps
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	filesJSON, err := json.Marshal(filesManifest{Files: []filesEntry{{Name: ".", Ftype: "dir"}}})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := pgzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{{"MANIFEST.json", manifestJSON}, {"FILES.json", filesJSON}} {
+		header := &tar.Header{Name: file.name, Mode: int64(helpers.FileMod), Size: int64(len(file.data))}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// putGenArtifact stages tarball in the artifact store and commits it under key.
+func putGenArtifact(ctx context.Context, artifacts cacheManager.ArtifactStore, key string, tarball []byte, sha string) error {
+	tmpFile, cleanup, err := artifacts.TempFile(ctx, ".gen-")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	_, err = tmpFile.Write(tarball)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	committed, err := artifacts.Commit(ctx, key, tmpFile.Name(), map[string]string{archive.HashSHA256: sha})
+	if err != nil {
+		return err
+	}
+	if committed.Cleanup != nil {
+		committed.Cleanup()
+	}
+	return nil
+}
+
+// writeGenRequirements writes a requirements file naming every collection in cols.
+func writeGenRequirements(path string, cols []genCollection) error {
+	var b strings.Builder
+	b.WriteString("collections:\n")
+	for _, col := range cols {
+		fmt.Fprintf(&b, "  - name: %s.%s\n", genCacheNamespace, col.name)
+	}
+	return os.WriteFile(path, []byte(b.String()), helpers.FileMod)
+}
@@ -0,0 +1,111 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+// offlineTransport fails every request, so only cached data can be used.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("offline")
+}
+
+func TestGenerateCacheResolvesOffline(t *testing.T) {
+	t.Parallel()
+	reqs := filepath.Join(t.TempDir(), "requirements.yml")
+	cfg := &config.Config{
+		Server:      "https://galaxy.invalid",
+		CacheDir:    t.TempDir(),
+		Workers:     2,
+		HealthCheck: config.HealthCheckOff,
+	}
+	runtime := infra.New(progress.New(false, true), &http.Client{Transport: offlineTransport{}})
+	opts := GenCacheOptions{Collections: 12, Versions: 3, Deps: 2, Seed: 7, Requirements: reqs}
+	if err := GenerateCache(context.Background(), cfg, runtime, opts); err != nil {
+		t.Fatalf("GenerateCache: %v", err)
+	}
+	data, err := os.ReadFile(reqs)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	cfg.RequirementsData = data
+
+	state, err := openInstallState(context.Background(), cfg, runtime)
+	if err != nil {
+		t.Fatalf("openInstallState: %v", err)
+	}
+	defer func() {
+		_ = state.release()
+		_ = state.backend.Close(context.Background())
+	}()
+	got, err := ResolveRequirements(context.Background(), cfg, runtime, state.store)
+	if err != nil {
+		t.Fatalf("ResolveRequirements: %v", err)
+	}
+	if len(got) != opts.Collections {
+		t.Fatalf("expected %d collections, got %v", opts.Collections, got)
+	}
+	for fqdn, version := range got {
+		if version != genVersion(opts.Versions-1) {
+			t.Fatalf("expected %s at the highest version, got %s", fqdn, version)
+		}
+	}
+	col := collection{Namespace: genCacheNamespace, Name: "c000000", Version: "1.0.0", Source: cfg.Server}
+	if findCachedArtifact(context.Background(), state.backend.Artifacts(), col) == "" {
+		t.Fatalf("expected %s to be cached", col.key())
+	}
+}
+
+func TestPlanGenCollectionsIsReproducible(t *testing.T) {
+	t.Parallel()
+	opts := GenCacheOptions{Collections: 50, Versions: 1, Deps: 4, Seed: 42}
+	a, b := planGenCollections(opts), planGenCollections(opts)
+	for i := range a {
+		if len(a[i].deps) != min(opts.Deps, i) {
+			t.Fatalf("%s: expected %d deps, got %v", a[i].name, min(opts.Deps, i), a[i].deps)
+		}
+		for dep := range a[i].deps {
+			if _, ok := b[i].deps[dep]; !ok {
+				t.Fatalf("%s: dependencies differ between runs: %v vs %v", a[i].name, a[i].deps, b[i].deps)
+			}
+		}
+	}
+}
+
+func TestGenerateCacheRejectsEmptySizes(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{Server: "https://galaxy.invalid", CacheDir: t.TempDir()}
+	runtime := infra.New(progress.New(false, true), http.DefaultClient)
+	err := GenerateCache(context.Background(), cfg, runtime, GenCacheOptions{Collections: 0, Versions: 1})
+	if !errors.Is(err, helpers.ErrInvalidFixture) {
+		t.Fatalf("expected ErrInvalidFixture, got %v", err)
+	}
+}
+
+func TestGenerateCacheDryRunWritesNothing(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	reqs := filepath.Join(dir, "requirements.yml")
+	cfg := &config.Config{Server: "https://galaxy.invalid", CacheDir: filepath.Join(dir, "cache"), DryRun: true}
+	runtime := infra.New(progress.New(false, true), &http.Client{Transport: offlineTransport{}})
+	opts := GenCacheOptions{Collections: 5, Versions: 2, Deps: 1, Requirements: reqs}
+	if err := GenerateCache(context.Background(), cfg, runtime, opts); err != nil {
+		t.Fatalf("GenerateCache: %v", err)
+	}
+	for _, path := range []string{cfg.CacheDir, reqs} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected %s not to be written, got %v", path, err)
+		}
+	}
+}
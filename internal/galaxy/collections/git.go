@@ -0,0 +1,292 @@
+package collections
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	cacheBackend "github.com/greeddj/go-galaxy/internal/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// gitType is the requirement type of collections built from a git repository.
+	gitType = "git"
+	// gitScheme prefixes a git requirement name, as in git+https://host/repo.git.
+	gitScheme = "git+"
+	// gitCommitPrefix is how much of the commit names the directory a tarball is built in.
+	gitCommitPrefix = 12
+	// gitSHA1Len and gitSHA256Len are the lengths of full hex commit ids.
+	gitSHA1Len   = 40
+	gitSHA256Len = 64
+)
+
+// gitRequirement is the repository a type: git requirement names, the
+// branch, tag or commit to check out and the collection's directory in it.
+type gitRequirement struct {
+	repo   string
+	ref    string
+	subdir string
+}
+
+// galaxyYML is the part of a collection's galaxy.yml a build reads.
+type galaxyYML struct {
+	Namespace    string            `yaml:"namespace"`
+	Name         string            `yaml:"name"`
+	Version      string            `yaml:"version"`
+	Dependencies map[string]string `yaml:"dependencies"`
+	BuildIgnore  []string          `yaml:"build_ignore"`
+}
+
+// parseGitRequirement splits a git requirement name as ansible-galaxy
+// writes it, [git+]repo[#/subdir][,ref]. A version other than "*" names the
+// ref and wins over one in the name.
+func parseGitRequirement(name, version string) gitRequirement {
+	repo := strings.TrimSpace(name)
+	if strings.HasPrefix(strings.ToLower(repo), gitScheme) {
+		repo = repo[len(gitScheme):]
+	}
+	var req gitRequirement
+	if i := strings.LastIndex(repo, ","); i >= 0 {
+		req.ref = strings.TrimSpace(repo[i+1:])
+		repo = repo[:i]
+	}
+	if i := strings.Index(repo, "#"); i >= 0 {
+		req.subdir = strings.Trim(repo[i+1:], "/")
+		repo = repo[:i]
+	}
+	req.repo = repo
+	if version != "" && version != "*" {
+		req.ref = version
+	}
+	return req
+}
+
+// String returns the requirement as repo[#/subdir][,ref].
+func (r gitRequirement) String() string {
+	out := r.repo
+	if r.subdir != "" {
+		out += "#/" + r.subdir
+	}
+	if r.ref != "" {
+		out += "," + r.ref
+	}
+	return out
+}
+
+// addGitRoots builds each type: git requirement into a collection tarball
+// under the cache directory and replaces it with a root installed from that
+// tarball, the way an --artifact collection is. Dependencies in its
+// galaxy.yml are resolved from the server. It also returns the key each
+// requirement was built as, by store.GitRootLabel, for cleanup to find.
+func addGitRoots(
+	ctx context.Context, cfg *config.Config, runtime *infra.Infra, roots []collection,
+) ([]collection, map[string]string, error) {
+	built := make(map[string]string)
+	for i, root := range roots {
+		if normalizeType(root.Type) != gitType {
+			continue
+		}
+		req := parseGitRequirement(root.Name, root.Version)
+		runtime.Output.Printf("🌿 build collection from git %s", req)
+		path, err := buildGitArtifact(ctx, cfg, req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", req, err)
+		}
+		artifact, err := readLocalArtifact(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		col := artifact.col
+		col.InstallPath = root.InstallPath
+		built[store.GitRootLabel(root.Name, root.Version)] = col.key()
+		roots[i] = col
+	}
+	return roots, built, nil
+}
+
+// buildGitArtifact resolves req's ref to a commit, clones it and builds the
+// collection found there into a tarball, returning its path. A commit is
+// built once; later runs that resolve to it reuse its tarball without
+// cloning again.
+func buildGitArtifact(ctx context.Context, cfg *config.Config, req gitRequirement) (string, error) {
+	if strings.HasPrefix(req.ref, "-") {
+		return "", fmt.Errorf("%w: version %q", helpers.ErrInvalidCollectionEntry, req.ref)
+	}
+	if req.subdir != "" && !filepath.IsLocal(req.subdir) {
+		return "", fmt.Errorf("%w: collection path %q leaves the repository", helpers.ErrInvalidCollectionEntry, req.subdir)
+	}
+	gitDir := filepath.Join(cacheBackend.LocalDir(cfg), helpers.StoreGitDir)
+	commit, branch, err := resolveGitRef(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if commit != "" {
+		if path, ok := cachedGitArtifact(gitDir, commit, req.subdir); ok {
+			return path, nil
+		}
+	}
+
+	checkout, err := os.MkdirTemp(cfg.TempDir, "go-galaxy-git-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = os.RemoveAll(checkout)
+	}()
+
+	if err := cloneGitRef(ctx, req, branch, checkout); err != nil {
+		return "", err
+	}
+	if commit, err = runGit(ctx, checkout, "rev-parse", "HEAD"); err != nil {
+		return "", err
+	}
+	if path, ok := cachedGitArtifact(gitDir, commit, req.subdir); ok {
+		return path, nil
+	}
+
+	srcDir := filepath.Join(checkout, filepath.FromSlash(req.subdir))
+	info, err := readGalaxyYML(srcDir)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%s-%s.tar.gz", info.Namespace, info.Name, info.Version)
+	dst := filepath.Join(gitArtifactDir(gitDir, commit, req.subdir), name)
+	if err := os.MkdirAll(filepath.Dir(dst), helpers.DirMod); err != nil {
+		return "", err
+	}
+	if err := buildCollectionTarball(srcDir, dst, info); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// resolveGitRef asks the remote which commit req's ref names, without
+// cloning. branch is the branch or tag to clone it from, empty for the
+// default branch. A full commit id is its own commit; any other ref the
+// remote does not advertise resolves to no commit and is checked out after a
+// full clone instead.
+func resolveGitRef(ctx context.Context, req gitRequirement) (string, string, error) {
+	if isCommitID(req.ref) {
+		return strings.ToLower(req.ref), "", nil
+	}
+	pattern := req.ref
+	if pattern == "" {
+		pattern = "HEAD"
+	}
+	out, err := runGit(ctx, "", "ls-remote", "--", req.repo, pattern)
+	if err != nil {
+		return "", "", err
+	}
+	refs := make(map[string]string)
+	for line := range strings.Lines(out) {
+		commit, ref, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if ok {
+			refs[ref] = commit
+		}
+	}
+	if req.ref == "" {
+		return refs["HEAD"], "", nil
+	}
+	// Branches win over tags, the way git clone --branch picks; an annotated
+	// tag names the commit it peels to.
+	for _, ref := range []string{"refs/heads/" + req.ref, "refs/tags/" + req.ref + "^{}", "refs/tags/" + req.ref} {
+		if commit := refs[ref]; commit != "" {
+			return commit, req.ref, nil
+		}
+	}
+	return "", "", nil
+}
+
+// isCommitID reports whether ref is a full SHA-1 or SHA-256 commit id.
+func isCommitID(ref string) bool {
+	if len(ref) != gitSHA1Len && len(ref) != gitSHA256Len {
+		return false
+	}
+	_, err := hex.DecodeString(ref)
+	return err == nil
+}
+
+// cloneGitRef clones req into dir: only the tip of branch when the ref was
+// resolved to one, otherwise the whole history, checking out the ref.
+func cloneGitRef(ctx context.Context, req gitRequirement, branch, dir string) error {
+	if branch != "" || req.ref == "" {
+		args := []string{"clone", "--quiet", "--depth", "1"}
+		if branch != "" {
+			args = append(args, "--branch", branch)
+		}
+		_, err := runGit(ctx, "", append(args, "--", req.repo, dir)...)
+		return err
+	}
+	if _, err := runGit(ctx, "", "clone", "--quiet", "--", req.repo, dir); err != nil {
+		return err
+	}
+	_, err := runGit(ctx, dir, "checkout", "--quiet", req.ref)
+	return err
+}
+
+// gitArtifactDir is where the tarball built from subdir at commit is kept.
+func gitArtifactDir(gitDir, commit, subdir string) string {
+	return filepath.Join(gitDir, commit[:min(len(commit), gitCommitPrefix)], filepath.FromSlash(subdir))
+}
+
+// cachedGitArtifact returns the tarball an earlier run built from subdir at
+// commit, if there is one.
+func cachedGitArtifact(gitDir, commit, subdir string) (string, bool) {
+	matches, err := filepath.Glob(filepath.Join(gitArtifactDir(gitDir, commit, subdir), "*.tar.gz"))
+	if err != nil || len(matches) != 1 {
+		return "", false
+	}
+	return matches[0], true
+}
+
+// runGit runs git with args in dir and returns its trimmed stdout. Prompts
+// for credentials are disabled, so a private repository without them fails
+// instead of hanging; the error carries what git printed.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	//nolint:gosec // git runs fixed subcommands; repository and ref are passed after them as arguments.
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		detail := strings.TrimSpace(stderr.String())
+		if detail == "" {
+			detail = err.Error()
+		}
+		return "", fmt.Errorf("%w: git %s: %s", helpers.ErrGitCommandFailed, args[0], detail)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// readGalaxyYML reads the galaxy.yml in dir, which must name the
+// collection's namespace, name and version.
+func readGalaxyYML(dir string) (galaxyYML, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "galaxy.yml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return galaxyYML{}, fmt.Errorf("%w: no galaxy.yml in the repository", helpers.ErrInvalidGalaxyYML)
+	}
+	if err != nil {
+		return galaxyYML{}, err
+	}
+	var info galaxyYML
+	if err := yaml.Unmarshal(data, &info); err != nil {
+		return galaxyYML{}, fmt.Errorf("%w: %w", helpers.ErrInvalidGalaxyYML, err)
+	}
+	if info.Namespace == "" || info.Name == "" || info.Version == "" {
+		return galaxyYML{}, fmt.Errorf("%w: namespace, name or version missing", helpers.ErrInvalidGalaxyYML)
+	}
+	return info, nil
+}
@@ -0,0 +1,228 @@
+package collections
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/klauspost/pgzip"
+)
+
+// buildManifest is the MANIFEST.json written into a tarball built from source.
+type buildManifest struct {
+	CollectionInfo struct {
+		Namespace    string            `json:"namespace"`
+		Name         string            `json:"name"`
+		Version      string            `json:"version"`
+		Dependencies map[string]string `json:"dependencies"`
+	} `json:"collection_info"`
+	FileManifestFile filesEntry `json:"file_manifest_file"`
+	Format           int        `json:"format"`
+}
+
+// buildEntry is one file, directory or symlink of a collection being built.
+type buildEntry struct {
+	rel  string
+	info fs.FileInfo
+	link string
+}
+
+// defaultBuildIgnore are the paths ansible-galaxy leaves out of every build.
+func defaultBuildIgnore(info galaxyYML) []string {
+	return []string{
+		".git", "galaxy.yml", "galaxy.yaml", "MANIFEST.json", "FILES.json",
+		"*.pyc", "*.retry", "tests/output",
+		info.Namespace + "-" + info.Name + "-*.tar.gz",
+	}
+}
+
+// buildCollectionTarball writes the collection in srcDir to dst the way
+// 'ansible-galaxy collection build' lays it out: MANIFEST.json and
+// FILES.json generated from galaxy.yml and the tree, then the tree itself
+// without build_ignore matches. Owners and times are fixed, so the same
+// tree always gives the same tarball.
+func buildCollectionTarball(srcDir, dst string, info galaxyYML) error {
+	entries, err := collectBuildEntries(srcDir, append(defaultBuildIgnore(info), info.BuildIgnore...))
+	if err != nil {
+		return err
+	}
+	filesJSON, err := buildFilesJSON(srcDir, entries)
+	if err != nil {
+		return err
+	}
+	var manifest buildManifest
+	manifest.CollectionInfo.Namespace = info.Namespace
+	manifest.CollectionInfo.Name = info.Name
+	manifest.CollectionInfo.Version = info.Version
+	manifest.CollectionInfo.Dependencies = info.Dependencies
+	if manifest.CollectionInfo.Dependencies == nil {
+		manifest.CollectionInfo.Dependencies = map[string]string{}
+	}
+	sum := sha256.Sum256(filesJSON)
+	manifest.FileManifestFile = filesEntry{
+		Name: "FILES.json", Ftype: "file", ChksumType: archive.HashSHA256, ChksumSHA256: hex.EncodeToString(sum[:]),
+	}
+	manifest.Format = 1
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp-")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		_ = os.Remove(tmpPath)
+	}()
+	gz := pgzip.NewWriter(tmp)
+	err = writeBuildTar(gz, srcDir, entries, manifestJSON, filesJSON)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, dst)
+}
+
+// collectBuildEntries walks srcDir in lexical order and returns what is not
+// ignored. A pattern without a slash also matches the base name at any depth.
+func collectBuildEntries(srcDir string, ignore []string) ([]buildEntry, error) {
+	var entries []buildEntry
+	err := filepath.WalkDir(srcDir, func(current string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, current)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if buildIgnored(ignore, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := buildEntry{rel: rel, info: info}
+		if d.Type()&fs.ModeSymlink != 0 {
+			if entry.link, err = os.Readlink(current); err != nil {
+				return err
+			}
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// buildIgnored reports whether rel matches one of the ignore patterns.
+func buildIgnored(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.Trim(pattern, "/")
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buildFilesJSON lists the directories and regular files of entries with the
+// sha256 of each file. Symlinks are archived but not listed.
+func buildFilesJSON(srcDir string, entries []buildEntry) ([]byte, error) {
+	files := filesManifest{Files: []filesEntry{{Name: ".", Ftype: "dir"}}}
+	for _, entry := range entries {
+		switch {
+		case entry.info.IsDir():
+			files.Files = append(files.Files, filesEntry{Name: entry.rel, Ftype: "dir"})
+		case entry.info.Mode().IsRegular():
+			sha, err := archive.FileHashSHA256(filepath.Join(srcDir, filepath.FromSlash(entry.rel)))
+			if err != nil {
+				return nil, err
+			}
+			files.Files = append(files.Files, filesEntry{Name: entry.rel, Ftype: "file", ChksumType: archive.HashSHA256, ChksumSHA256: sha})
+		}
+	}
+	return json.MarshalIndent(files, "", "  ")
+}
+
+// writeBuildTar writes MANIFEST.json, FILES.json and then entries to w.
+func writeBuildTar(w io.Writer, srcDir string, entries []buildEntry, manifestJSON, filesJSON []byte) error {
+	tw := tar.NewWriter(w)
+	epoch := time.Unix(0, 0)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{{"MANIFEST.json", manifestJSON}, {"FILES.json", filesJSON}} {
+		header := &tar.Header{Name: file.name, Mode: int64(helpers.FileMod), Size: int64(len(file.data)), ModTime: epoch}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return err
+		}
+	}
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.rel, ModTime: epoch}
+		switch {
+		case entry.info.IsDir():
+			header.Typeflag, header.Name, header.Mode = tar.TypeDir, entry.rel+"/", int64(helpers.DirMod)
+		case entry.link != "":
+			header.Typeflag, header.Linkname, header.Mode = tar.TypeSymlink, entry.link, int64(helpers.FileMod)
+		case entry.info.Mode().IsRegular():
+			header.Typeflag, header.Size, header.Mode = tar.TypeReg, entry.info.Size(), int64(helpers.FileMod)
+			if entry.info.Mode()&0o111 != 0 {
+				header.Mode = int64(helpers.DirMod)
+			}
+		default:
+			continue
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeReg {
+			if err := copyBuildFile(tw, filepath.Join(srcDir, filepath.FromSlash(entry.rel))); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Close()
+}
+
+// copyBuildFile streams a file of the tree being built into the current tar entry.
+func copyBuildFile(w io.Writer, name string) error {
+	//nolint:gosec // name comes from walking the checked out collection.
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	_, err = io.Copy(w, f)
+	return err
+}
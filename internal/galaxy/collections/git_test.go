@@ -0,0 +1,150 @@
+package collections
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestParseGitRequirement(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name, version string
+		want          gitRequirement
+	}{
+		{"https://github.com/org/repo.git", "*", gitRequirement{repo: "https://github.com/org/repo.git"}},
+		{"git+https://github.com/org/repo.git,devel", "", gitRequirement{repo: "https://github.com/org/repo.git", ref: "devel"}},
+		{"git@github.com:org/repo.git#/collections/tools/", "v1.2.0",
+			gitRequirement{repo: "git@github.com:org/repo.git", ref: "v1.2.0", subdir: "collections/tools"}},
+		{"git+file:///srv/repo#/tools,main", "*", gitRequirement{repo: "file:///srv/repo", ref: "main", subdir: "tools"}},
+	}
+	for _, tt := range tests {
+		if got := parseGitRequirement(tt.name, tt.version); got != tt.want {
+			t.Fatalf("parseGitRequirement(%q, %q) = %+v, want %+v", tt.name, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestAddGitRootsBuildsCollection(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(name, data string) {
+		t.Helper()
+		path := filepath.Join(repo, "tools", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	galaxy := "namespace: acme\nname: tools\nversion: %s\ndependencies:\n  community.general: \">=7.0.0\"\nbuild_ignore:\n  - '*.md'\n"
+	git("init", "--quiet")
+	write("galaxy.yml", fmt.Sprintf(galaxy, "1.0.0"))
+	write("plugins/modules/ping.py", "print('pong')\n")
+	write("README.md", "ignored\n")
+	write("tests/output/junk.txt", "ignored\n")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "first")
+	git("tag", "v1.0.0")
+	write("galaxy.yml", fmt.Sprintf(galaxy, "1.1.0"))
+	git("commit", "--quiet", "-am", "second")
+
+	cfg := &config.Config{CacheDir: t.TempDir(), CacheNamespace: "team", TempDir: t.TempDir()}
+	runtime := infra.New(progress.New(false, true), nil)
+	roots := []collection{
+		{Name: "git+file://" + repo + "#/tools", Version: "v1.0.0", Type: gitType, InstallPath: "/opt/collections"},
+		{Name: "community.general", Version: "8.0.0"},
+	}
+	got, built, err := addGitRoots(context.Background(), cfg, runtime, roots)
+	if err != nil {
+		t.Fatalf("addGitRoots: %v", err)
+	}
+	if label := store.GitRootLabel("git+file://"+repo+"#/tools", "v1.0.0"); built[label] != "acme.tools@1.0.0" {
+		t.Fatalf("expected %s to be recorded as acme.tools@1.0.0, got %v", label, built)
+	}
+	root := got[0]
+	if root.key() != "acme.tools@1.0.0" || !root.isLocalArtifact() || root.InstallPath != "/opt/collections" ||
+		got[1].Name != "community.general" {
+		t.Fatalf("unexpected roots %+v", got)
+	}
+	artifact, err := readLocalArtifact(localArtifactPath(root.Source))
+	if err != nil {
+		t.Fatalf("readLocalArtifact: %v", err)
+	}
+	gitDir := filepath.Join(cfg.CacheDir, helpers.StoreNamespacesDir, "team", helpers.StoreGitDir)
+	if rel, err := filepath.Rel(gitDir, artifact.path); err != nil || !filepath.IsLocal(rel) {
+		t.Fatalf("expected the tarball under %s, got %s", gitDir, artifact.path)
+	}
+	if artifact.deps["community.general"] != ">=7.0.0" {
+		t.Fatalf("unexpected dependencies %+v", artifact.deps)
+	}
+	raw, err := archive.ReadTarGzFile(artifact.path, "FILES.json", manifestMaxSize)
+	if err != nil {
+		t.Fatalf("ReadTarGzFile: %v", err)
+	}
+	var files filesManifest
+	if err := json.Unmarshal(raw, &files); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	names := make([]string, 0, len(files.Files))
+	for _, entry := range files.Files {
+		names = append(names, entry.Name)
+	}
+	want := []string{".", "plugins", "plugins/modules", "plugins/modules/ping.py", "tests"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Fatalf("expected FILES.json entries %v, got %v", want, names)
+	}
+
+	// A resolved commit that was built before is reused without cloning;
+	// a clone would need the missing temp directory.
+	commit, err := exec.Command("git", "-C", repo, "rev-parse", "v1.0.0").Output()
+	if err != nil {
+		t.Fatalf("rev-parse: %v", err)
+	}
+	cached := &config.Config{CacheDir: cfg.CacheDir, CacheNamespace: "team", TempDir: filepath.Join(t.TempDir(), "missing")}
+	for _, version := range []string{"v1.0.0", string(bytes.TrimSpace(commit))} {
+		roots = []collection{{Name: "git+file://" + repo + "#/tools", Version: version, Type: gitType}}
+		if got, _, err = addGitRoots(context.Background(), cached, runtime, roots); err != nil || got[0].Source != root.Source {
+			t.Fatalf("expected %s to reuse %s, got %+v, %v", version, root.Source, got, err)
+		}
+	}
+
+	roots = []collection{{Name: "git+file://" + repo + "#/tools", Version: "*", Type: gitType}}
+	if got, _, err = addGitRoots(context.Background(), cfg, runtime, roots); err != nil || got[0].Version != "1.1.0" {
+		t.Fatalf("expected acme.tools 1.1.0 from the default branch, got %+v, %v", got, err)
+	}
+
+	roots = []collection{{Name: "git+file://" + repo, Version: "*", Type: gitType}}
+	if _, _, err := addGitRoots(context.Background(), cfg, runtime, roots); !errors.Is(err, helpers.ErrInvalidGalaxyYML) {
+		t.Fatalf("expected ErrInvalidGalaxyYML, got %v", err)
+	}
+	roots = []collection{{Name: "git+file://" + repo + "#/tools", Version: "no-such-ref", Type: gitType}}
+	if _, _, err := addGitRoots(context.Background(), cfg, runtime, roots); !errors.Is(err, helpers.ErrGitCommandFailed) {
+		t.Fatalf("expected ErrGitCommandFailed, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package collections
+
+import (
+	"context"
+	"encoding/hex"
+	"hash"
+	"sync"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+)
+
+// hashQueueDepth bounds how many copied chunks of one download may wait
+// for the hash pool before Write blocks.
+const hashQueueDepth = 16
+
+// hashPool runs the hashing of every download in a run on at most limit
+// goroutines. Workers start when jobs are queued and exit once the queue is
+// empty, so the pool needs no shutdown. A nil pool runs each job on its own
+// goroutine.
+type hashPool struct {
+	mu      sync.Mutex
+	queue   []func()
+	workers int
+	limit   int
+}
+
+func newHashPool(limit int) *hashPool {
+	return &hashPool{limit: max(limit, 1)}
+}
+
+// submit queues job to run on the next free worker.
+func (p *hashPool) submit(job func()) {
+	if p == nil {
+		go job()
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queue = append(p.queue, job)
+	if p.workers < p.limit {
+		p.workers++
+		go p.work()
+	}
+}
+
+func (p *hashPool) work() {
+	for {
+		p.mu.Lock()
+		if len(p.queue) == 0 {
+			p.workers--
+			p.mu.Unlock()
+			return
+		}
+		job := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.mu.Unlock()
+		job()
+	}
+}
+
+// fileHash hashes the file at path with algo on the pool and waits for it.
+func (p *hashPool) fileHash(ctx context.Context, path, algo string) (string, error) {
+	type result struct {
+		digest string
+		err    error
+	}
+	done := make(chan result, 1)
+	p.submit(func() {
+		if ctx.Err() != nil {
+			done <- result{err: context.Cause(ctx)}
+			return
+		}
+		digest, err := archive.FileHash(path, algo)
+		done <- result{digest: digest, err: err}
+	})
+	select {
+	case res := <-done:
+		return res.digest, res.err
+	case <-ctx.Done():
+		return "", context.Cause(ctx)
+	}
+}
+
+// asyncHasher feeds written bytes to hashes on the hash pool, so a slow hash
+// does not stall the download loop that writes to disk. At most one batch of
+// a hasher is on the pool at a time, which keeps its chunks in order.
+type asyncHasher struct {
+	pool   *hashPool
+	hashes []hash.Hash
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending [][]byte
+	// queued is set while a drain of this hasher is queued or running.
+	queued bool
+}
+
+func newAsyncHasher(pool *hashPool, hashes ...hash.Hash) *asyncHasher {
+	h := &asyncHasher{pool: pool, hashes: hashes}
+	h.cond = sync.NewCond(&h.mu)
+	return h
+}
+
+// Write queues a copy of p; the caller may reuse p once Write returns.
+func (h *asyncHasher) Write(p []byte) (int, error) {
+	chunk := append([]byte(nil), p...)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for len(h.pending) >= hashQueueDepth {
+		h.cond.Wait()
+	}
+	h.pending = append(h.pending, chunk)
+	if !h.queued {
+		h.queued = true
+		h.pool.submit(h.drain)
+	}
+	return len(p), nil
+}
+
+// drain hashes the chunks queued so far and hands the worker back, queueing
+// itself again if more arrived, so one large download cannot hold a worker
+// while others wait.
+func (h *asyncHasher) drain() {
+	h.mu.Lock()
+	batch := h.pending
+	h.pending = nil
+	h.cond.Broadcast()
+	h.mu.Unlock()
+
+	for _, chunk := range batch {
+		for _, sum := range h.hashes {
+			_, _ = sum.Write(chunk)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.pending) > 0 {
+		h.pool.submit(h.drain)
+		return
+	}
+	h.queued = false
+	h.cond.Broadcast()
+}
+
+// Sums waits for queued chunks and returns each hash as lowercase hex.
+func (h *asyncHasher) Sums() []string {
+	h.mu.Lock()
+	for h.queued {
+		h.cond.Wait()
+	}
+	h.mu.Unlock()
+	sums := make([]string, len(h.hashes))
+	for i, sum := range h.hashes {
+		sums[i] = hex.EncodeToString(sum.Sum(nil))
+	}
+	return sums
+}
+
+// artifactID returns the identity used for extraction markers and the
+// installed snapshot: the sha256 itself by default, or "<algo>-<hex>" when
+// --artifact-hash selects another algorithm.
+func artifactID(algo, sha256, digest string) string {
+	if algo == "" || algo == archive.HashSHA256 {
+		return sha256
+	}
+	return algo + "-" + digest
+}
+
+// resolveArtifactID reuses the digest computed during download or stored with
+// the cached artifact, and otherwise hashes the file on the hash pool.
+func resolveArtifactID(ctx context.Context, deps installDeps, artifact artifactData, sha256 string) (string, error) {
+	algo := deps.cfg.ArtifactHash
+	if algo == "" || algo == archive.HashSHA256 {
+		return sha256, nil
+	}
+	digest := artifact.Digest
+	if digest == "" && artifact.Meta != nil {
+		digest = artifact.Meta[algo]
+	}
+	if digest == "" {
+		var err error
+		if digest, err = deps.hashes.fileHash(ctx, artifact.Path, algo); err != nil {
+			return "", err
+		}
+	}
+	return artifactID(algo, sha256, digest), nil
+}
@@ -0,0 +1,94 @@
+package collections
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+func TestAsyncHasher(t *testing.T) {
+	t.Parallel()
+	pool := newHashPool(2)
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			payload := strings.Repeat("collection-bytes", 10000+i)
+			hasher := newAsyncHasher(pool, sha256.New(), sha512.New())
+			if _, err := io.Copy(hasher, strings.NewReader(payload)); err != nil {
+				t.Errorf("copy: %v", err)
+				return
+			}
+			sums := hasher.Sums()
+
+			want256 := sha256.Sum256([]byte(payload))
+			want512 := sha512.Sum512([]byte(payload))
+			if sums[0] != hex.EncodeToString(want256[:]) || sums[1] != hex.EncodeToString(want512[:]) {
+				t.Errorf("unexpected sums: %v", sums)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestHashPoolBoundsWorkers(t *testing.T) {
+	t.Parallel()
+	pool := newHashPool(3)
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		pool.submit(func() {
+			defer wg.Done()
+			n := running.Add(1)
+			for {
+				old := peak.Load()
+				if n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			_ = sha512.Sum512(make([]byte, 1<<16))
+			running.Add(-1)
+		})
+	}
+	wg.Wait()
+	if got := peak.Load(); got > 3 {
+		t.Fatalf("expected at most 3 concurrent hash jobs, got %d", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "artifact.tar.gz")
+	if err := os.WriteFile(path, []byte("artifact"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	digest, err := pool.fileHash(context.Background(), path, archive.HashBLAKE3)
+	if err != nil || len(digest) != 64 {
+		t.Fatalf("unexpected blake3 digest %q, %v", digest, err)
+	}
+}
+
+func TestArtifactID(t *testing.T) {
+	t.Parallel()
+	if got := artifactID(archive.HashSHA256, "abc", ""); got != "abc" {
+		t.Fatalf("sha256 identity should stay the plain sha: %q", got)
+	}
+	if got := artifactID(archive.HashSHA512, "abc", "def"); got != "sha512-def" {
+		t.Fatalf("unexpected sha512 identity: %q", got)
+	}
+	if got := artifactID(archive.HashBLAKE3, "abc", "def"); got != "blake3-def" {
+		t.Fatalf("unexpected blake3 identity: %q", got)
+	}
+	if _, err := archive.NewHash("md5"); !errors.Is(err, helpers.ErrUnsupportedArtifactHash) {
+		t.Fatalf("expected ErrUnsupportedArtifactHash for md5, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// healthCheckTimeout caps the root API ping, so an unresponsive server fails
+// the gate quickly even when --timeout is generous.
+const healthCheckTimeout = 10 * time.Second
+
+// checkServerHealth pings the root API of every source the roots resolve
+// against. Transport errors, throttling and 5xx answers count as unhealthy;
+// any other status means the server is up, even if it wants credentials.
+func checkServerHealth(ctx context.Context, deps collectionDeps, roots []collection) error {
+	for _, source := range rootSources(deps.cfg, roots) {
+		url := strings.TrimRight(source, "/") + "/api/"
+		deps.runtime.Output.Debugf("health check %s", url)
+		if err := pingAPI(ctx, deps.runtime.ClientFor(url), url, min(deps.cfg.Timeout, healthCheckTimeout)); err != nil {
+			return fmt.Errorf("%w: %s: %w", helpers.ErrServerUnhealthy, url, err)
+		}
+	}
+	return nil
+}
+
+func pingAPI(ctx context.Context, client *http.Client, url string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return &cacheManager.HTTPStatusError{Status: resp.Status, Code: resp.StatusCode}
+	}
+	return nil
+}
+
+// rootSources returns the distinct galaxy sources of roots, sorted.
+func rootSources(cfg *config.Config, roots []collection) []string {
+	seen := make(map[string]bool)
+	sources := make([]string, 0, 1)
+	for _, root := range roots {
+		if !isGalaxyType(root.Type) || root.isLocalArtifact() {
+			continue
+		}
+		source := root.Source
+		if source == "" {
+			source = cfg.Server
+		}
+		source = normalizeServerURL(source)
+		if !seen[source] {
+			seen[source] = true
+			sources = append(sources, source)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// loadOfflineSnapshot returns the stored resolution restricted to what roots
+// reach, without requiring the requirements hash to match. It is the
+// fallback for --health-check snapshot, so every root must still be
+// satisfied by its stored version.
+func loadOfflineSnapshot(cfg *config.Config, st *store.Store, roots []collection) (map[string]collection, map[string][]string, bool) {
+	if st == nil || !snapshotMatchesServer(st.MetaSnapshot(), snapshotServer(cfg)) {
+		return nil, nil, false
+	}
+	resolvedSnapshot, graphSnapshot, ok := loadSnapshotData(st)
+	if !ok {
+		return nil, nil, false
+	}
+	all, ok := buildResolvedSnapshot(cfg, resolvedSnapshot)
+	if !ok || !rootsMatchSnapshot(roots, all, graphSnapshot) {
+		return nil, nil, false
+	}
+
+	queue := make([]string, 0, len(roots))
+	for _, root := range roots {
+		queue = append(queue, all[root.Namespace+"."+root.Name].key())
+	}
+	reachable := make(map[string]bool)
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		if reachable[key] {
+			continue
+		}
+		reachable[key] = true
+		queue = append(queue, graphSnapshot[key]...)
+	}
+
+	resolved := make(map[string]collection, len(reachable))
+	for fqdn, col := range all {
+		if reachable[col.key()] {
+			resolved[fqdn] = col
+		}
+	}
+	if len(resolved) != len(reachable) {
+		// the graph points at versions the resolved snapshot does not hold
+		return nil, nil, false
+	}
+	return resolved, filterGraphSnapshot(graphSnapshot, resolved), true
+}
+
+// healthGate runs the --health-check policy before a network resolution. It
+// returns a stored resolution when the server is down and the policy allows
+// it, an error when the run must stop, and nil maps to resolve as usual.
+func healthGate(ctx context.Context, deps collectionDeps, roots []collection) (map[string]collection, map[string][]string, error) {
+	cfg := deps.cfg
+	if cfg.HealthCheck == config.HealthCheckOff {
+		return nil, nil, nil
+	}
+	err := checkServerHealth(ctx, deps, roots)
+	if err == nil {
+		return nil, nil, nil
+	}
+	if cfg.HealthCheck != config.HealthCheckSnapshot {
+		return nil, nil, err
+	}
+	resolved, graph, ok := loadOfflineSnapshot(cfg, deps.st, roots)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w; no stored resolution satisfies the requirements", err)
+	}
+	deps.runtime.Output.Printf("⚠️ %s; using the stored resolution of %d collections", err, len(resolved))
+	return resolved, graph, nil
+}
@@ -0,0 +1,66 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestHealthGate(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	st := store.New()
+	stored := map[string]collection{
+		"a.b": {Namespace: "a", Name: "b", Version: "1.0.0", Source: srv.URL},
+		"c.d": {Namespace: "c", Name: "d", Version: "2.0.0", Source: srv.URL},
+		"e.f": {Namespace: "e", Name: "f", Version: "3.0.0", Source: srv.URL},
+	}
+	setResolvedAll(st, stored)
+	st.SetGraphSnapshot(map[string][]string{
+		"a.b@1.0.0": {"c.d@2.0.0"},
+		"c.d@2.0.0": {},
+		"e.f@3.0.0": {},
+	})
+	st.SetMetaRequirements("old-hash", srv.URL)
+
+	newDeps := func(policy string) collectionDeps {
+		return collectionDeps{
+			cfg:     &config.Config{Server: srv.URL, Workers: 1, Timeout: helpers.FetchDefaultTimeout, HealthCheck: policy},
+			runtime: infra.New(progress.New(false, true), srv.Client()),
+			st:      st,
+		}
+	}
+	roots := []collection{{Namespace: "a", Name: "b", Constraint: ">=1.0.0"}}
+
+	if _, _, err := healthGate(context.Background(), newDeps(config.HealthCheckFail), roots); !errors.Is(err, helpers.ErrServerUnhealthy) {
+		t.Fatalf("expected ErrServerUnhealthy, got %v", err)
+	}
+	if resolved, _, err := healthGate(context.Background(), newDeps(config.HealthCheckOff), roots); resolved != nil || err != nil {
+		t.Fatalf("off must not gate: %v %v", resolved, err)
+	}
+
+	resolved, graph, err := healthGate(context.Background(), newDeps(config.HealthCheckSnapshot), roots)
+	if err != nil {
+		t.Fatalf("snapshot fallback: %v", err)
+	}
+	if len(resolved) != 2 || resolved["c.d"].Version != "2.0.0" || len(graph["a.b@1.0.0"]) != 1 {
+		t.Fatalf("fallback must keep only what the roots reach: %+v %+v", resolved, graph)
+	}
+
+	unsatisfied := []collection{{Namespace: "a", Name: "b", Constraint: ">=2.0.0"}}
+	if _, _, err := healthGate(context.Background(), newDeps(config.HealthCheckSnapshot), unsatisfied); !errors.Is(err, helpers.ErrServerUnhealthy) {
+		t.Fatalf("unsatisfiable snapshot must fail the gate, got %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// Info describes a collection's remote versions and local install state.
+type Info struct {
+	Name          string             `json:"name"`
+	Source        string             `json:"source"`
+	Deprecated    bool               `json:"deprecated"`
+	ReplacedBy    string             `json:"replaced_by,omitempty"`
+	VersionsTotal int                `json:"versions_total"`
+	Versions      []string           `json:"versions"`
+	VersionsStale bool               `json:"versions_stale,omitempty"`
+	Selected      string             `json:"selected"`
+	Dependencies  map[string]string  `json:"dependencies"`
+	Installed     []InstalledVersion `json:"installed"`
+}
+
+// InstalledVersion is one locally installed version of a collection.
+type InstalledVersion struct {
+	Version     string `json:"version"`
+	InstallPath string `json:"install_path"`
+	Source      string `json:"source"`
+	SHA256      string `json:"artifact_sha256"`
+	Present     bool   `json:"present"`
+}
+
+// Describe looks up a collection as "ns.name" or "ns.name:constraint".
+// Up to maxVersions versions are listed, newest first; 0 lists all.
+func Describe(ctx context.Context, cfg *config.Config, runtime *infra.Infra, st *store.Store, ref string, maxVersions int) (*Info, error) {
+	fqdn, constraint, _ := strings.Cut(strings.TrimSpace(ref), ":")
+	namespace, name, ok := helpers.SplitFQDN(fqdn)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", helpers.ErrInvalidCollectionName, ref)
+	}
+	col := collection{Namespace: namespace, Name: name, Source: cfg.Server}
+	deps := collectionDeps{cfg: cfg, runtime: runtime, st: st}
+
+	listing, err := listCollectionVersions(ctx, deps, col)
+	if err != nil {
+		return nil, err
+	}
+	rootMeta, versionsURL, versions := listing.root, listing.versionsURL, listing.versions
+	info := &Info{
+		Name:          fqdn,
+		Source:        col.Source,
+		Deprecated:    rootMeta.Deprecated,
+		ReplacedBy:    rootMeta.ReplacedBy,
+		VersionsTotal: len(versions),
+		Versions:      versions,
+		VersionsStale: listing.stale,
+		Installed:     installedVersions(st, fqdn),
+	}
+	if maxVersions > 0 && len(versions) > maxVersions {
+		info.Versions = versions[:maxVersions]
+	}
+
+	if info.Selected, err = pickVersion(&rootMeta.GalaxyCollection, versions, []string{constraint}); err != nil {
+		return nil, err
+	}
+	if info.Selected == "" {
+		return info, nil
+	}
+	versionInfo, err := fetchVersionMetadataCached(ctx, deps, col.Source, versionsURL, info.Selected, cachePolicyForConstraint(cfg, true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s %s metadata: %w", fqdn, info.Selected, err)
+	}
+	info.Dependencies, _ = pickDependencies(cfg, versionInfo.Metadata.Dependencies, versionInfo.Manifest.CollectionInfo.Dependencies)
+	return info, nil
+}
+
+// versionListing holds a collection's root metadata and sorted versions.
+type versionListing struct {
+	root        *rootMetadata
+	versionsURL string
+	versions    []string
+	stale       bool
+}
+
+// listCollectionVersions loads root metadata and all versions, newest first.
+// When the server is unreachable the versions cache from the last resolve is used.
+func listCollectionVersions(ctx context.Context, deps collectionDeps, col collection) (*versionListing, error) {
+	fqdn := col.Namespace + "." + col.Name
+	policy := cachePolicyForConstraint(deps.cfg, false)
+	rootMeta, versionsURL, err := resolveRootMetadata(ctx, deps, col, policy, fqdn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load root metadata: %w", err)
+	}
+	listing := &versionListing{root: rootMeta, versionsURL: versionsURL}
+	versions, err := loadVersionsListCached(ctx, deps, versionsURL, versionLimit, policy)
+	if err != nil {
+		cached, ok := deps.st.GetVersionsCache(versionsURL)
+		if !ok {
+			return nil, fmt.Errorf("failed to load versions list: %w", err)
+		}
+		deps.runtime.Output.Debugf("versions list for %s from cache: %v", fqdn, err)
+		versions = cached
+		listing.stale = true
+	}
+	listing.versions = sortVersionsDesc(versions)
+	return listing, nil
+}
+
+// installedVersions lists store install entries for a collection.
+func installedVersions(st *store.Store, fqdn string) []InstalledVersion {
+	out := make([]InstalledVersion, 0)
+	for key, entry := range st.InstalledSnapshot() {
+		name, version, ok := strings.Cut(key, "@")
+		if !ok || name != fqdn {
+			continue
+		}
+		_, statErr := os.Stat(entry.InstallPath)
+		out = append(out, InstalledVersion{
+			Version:     version,
+			InstallPath: entry.InstallPath,
+			Source:      entry.Source,
+			SHA256:      entry.ArtifactSHA256,
+			Present:     statErr == nil,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].Version, out[j].Version
+		switch {
+		case versionNewer(a, b):
+			return true
+		case versionNewer(b, a):
+			return false
+		case a != b:
+			return a < b
+		default:
+			return out[i].InstallPath < out[j].InstallPath
+		}
+	})
+	return out
+}
+
+// sortVersionsDesc orders versions newest first like selectVersion, leaving
+// non-semver entries last in their original order.
+func sortVersionsDesc(versions []string) []string {
+	out := append([]string(nil), versions...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return versionNewer(out[i], out[j])
+	})
+	return out
+}
+
+// versionNewer reports whether a sorts before b newest first. Semver
+// versions sort before the others; two non-semver versions are unordered.
+func versionNewer(a, b string) bool {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	if errA != nil || errB != nil {
+		return errA == nil && errB != nil
+	}
+	return compareVersionsDesc(va, vb, a, b) < 0
+}
@@ -0,0 +1,67 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestDescribeCombinesRemoteAndInstalled(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/collections/a/b/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"namespace":"a","name":"b","deprecated":true,`+
+			`"versions_url":"/api/v3/collections/a/b/versions/","highest_version":{"version":"1.2.0"}}`)
+	})
+	mux.HandleFunc("/api/v3/collections/a/b/versions/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"meta":{"count":3},"data":[{"version":"1.0.0"},{"version":"1.2.0"},{"version":"1.1.0"}]}`)
+	})
+	mux.HandleFunc("/api/v3/collections/a/b/versions/1.1.0/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"version":"1.1.0","metadata":{"dependencies":{"c.d":">=1.0.0"}}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	st := store.New()
+	st.SetInstalled("a.b@1.0.0", store.InstalledEntry{InstallPath: t.TempDir()})
+	st.SetInstalled("a.bc@1.0.0", store.InstalledEntry{InstallPath: "/nowhere"})
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	cfg := &config.Config{Server: srv.URL}
+
+	info, err := Describe(context.Background(), cfg, runtime, st, "a.b:<1.2.0", 2)
+	if err != nil {
+		t.Fatalf("Describe: %v", err)
+	}
+	if !info.Deprecated || info.VersionsTotal != 3 || !slices.Equal(info.Versions, []string{"1.2.0", "1.1.0"}) {
+		t.Fatalf("unexpected versions: %+v", info)
+	}
+	if info.Selected != "1.1.0" || info.Dependencies["c.d"] != ">=1.0.0" {
+		t.Fatalf("unexpected selection: %+v", info)
+	}
+	if len(info.Installed) != 1 || info.Installed[0].Version != "1.0.0" || !info.Installed[0].Present {
+		t.Fatalf("unexpected installed: %+v", info.Installed)
+	}
+}
+
+func TestInstalledVersionsNewestFirst(t *testing.T) {
+	t.Parallel()
+	st := store.New()
+	for _, version := range []string{"1.9.0", "1.10.0", "2.0.0-rc1", "main", "1.2.0"} {
+		st.SetInstalled("a.b@"+version, store.InstalledEntry{InstallPath: "/nowhere"})
+	}
+	got := make([]string, 0, 5)
+	for _, item := range installedVersions(st, "a.b") {
+		got = append(got, item.Version)
+	}
+	if want := []string{"2.0.0-rc1", "1.10.0", "1.9.0", "1.2.0", "main"}; !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -3,13 +3,11 @@ package collections
 import (
 	"context"
 	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -44,9 +42,9 @@ func installCollection(
 	}()
 
 	filename := fmt.Sprintf("%s-%s-%s.tar.gz", col.Namespace, col.Name, col.Version)
-	installPath := filepath.Join(cfg.DownloadPath, "ansible_collections", col.Namespace, col.Name)
+	installPath := col.installDir(cfg)
 
-	if canSkipInstall(cfg, col, installPath, st) {
+	if canSkipInstall(cfg, col, installPath, st, deps.fingerprints[col.collectionsPath(cfg)]) {
 		runtime.Output.Printf("⏭️ Skipping install, already installed: %s/%s/%s", col.Namespace, col.Name, col.Version)
 		return nil
 	}
@@ -60,17 +58,21 @@ func installCollection(
 	}
 
 	extractStart := time.Now()
-	err = extractCollection(col, payload.artifact.Path, installPath, runtime, payload.artifactSHA)
+	err = extractCollection(ctx, col, payload.artifact.Path, installPath, deps, payload.artifactID)
 	if err != nil {
 		return fmt.Errorf("failed to extract %s: %w", filename, err)
 	}
 	runtime.Output.DebugSincef(extractStart, "%s", "extract "+col.key())
-	depsList, err := resolveDependencies(ctx, installPath, deps, resolvedDeps, col, filename)
+	depsList, depsSource, err := resolveDependencies(ctx, installPath, deps, resolvedDeps, col, payload.meta, filename)
 	if err != nil {
 		return err
 	}
-	writeGalaxyInfoIfPresent(runtime, cfg, payload.meta)
-	recordInstall(st, col, installPath, payload.artifactSHA, depsList)
+	col.DepsSource = depsSource
+	writeGalaxyInfoIfPresent(runtime, cfg, col.collectionsPath(cfg), payload.meta, galaxyInfoExtra{
+		artifactSHA: payload.artifactSHA,
+		deps:        depsList,
+	})
+	recordInstall(cfg, st, col, payload.artifact.Source, installPath, payload.artifactSHA, payload.artifactID, depsList)
 	return nil
 }
 
@@ -78,11 +80,14 @@ type installPayload struct {
 	meta        *types.GalaxyCollectionVersionInfo
 	artifact    artifactData
 	artifactSHA string
+	artifactID  string
 }
 
 type artifactData struct {
 	Path    string
 	SHA     string
+	Digest  string
+	Source  string
 	Meta    map[string]string
 	Cleanup func()
 }
@@ -98,9 +103,18 @@ func prepareInstall(
 	runtime := deps.runtime
 	artifacts := deps.artifacts
 
+	if col.isLocalArtifact() {
+		runtime.Output.Printf("📦 Using artifact %s", localArtifactPath(col.Source))
+		return prepareLocalInstall(ctx, deps, col)
+	}
+
 	meta := metaOverride
 	useCache := !cfg.NoCache
-	cacheHit := useCache && artifacts != nil && artifactExists(ctx, artifacts, col)
+	var cachedKey string
+	if useCache && artifacts != nil {
+		cachedKey = findCachedArtifact(ctx, artifacts, col)
+	}
+	cacheHit := cachedKey != ""
 
 	if cacheHit && meta == nil {
 		runtime.Output.Printf("📦 Using cached %s", filename)
@@ -111,10 +125,22 @@ func prepareInstall(
 		return installPayload{}, err
 	}
 
-	artifact, err := fetchArtifact(ctx, deps, col, meta, cacheHit, useCache)
+	artifact, err := fetchArtifact(ctx, deps, col, meta, cachedKey, useCache)
+	if err == nil && cachedKey == legacyArtifactKey(col) && !legacyArtifactUsable(artifact, meta) {
+		runtime.Output.Debugf("cached %s does not match %s, downloading", cachedKey, col.Source)
+		cleanupIfNeeded(artifact.Cleanup)
+		cacheHit = false
+		artifact, err = fetchArtifact(ctx, deps, col, meta, "", useCache)
+	}
+	if errors.Is(err, helpers.ErrArtifactNotFound) {
+		artifact, meta, err = fetchArtifactFromMirrors(ctx, deps, col, useCache, err)
+	}
 	if err != nil {
 		return installPayload{}, err
 	}
+	if useCache {
+		recordArtifactStats(deps.st, cacheHit, artifact.Path)
+	}
 	artifactSHA, err := resolveArtifactSHA(artifact.Path, meta, artifact.Meta, artifact.SHA)
 	if err != nil {
 		if artifact.Cleanup != nil {
@@ -122,57 +148,91 @@ func prepareInstall(
 		}
 		return installPayload{}, err
 	}
-	return installPayload{meta: meta, artifact: artifact, artifactSHA: artifactSHA}, nil
+	artifactID, err := resolveArtifactID(ctx, deps, artifact, artifactSHA)
+	if err != nil {
+		if artifact.Cleanup != nil {
+			artifact.Cleanup()
+		}
+		return installPayload{}, err
+	}
+	return installPayload{meta: meta, artifact: artifact, artifactSHA: artifactSHA, artifactID: artifactID}, nil
 }
 
+// resolveDependencies returns the dependency keys of an installed collection
+// and where they came from, installing them first when the graph did not
+// resolve them.
 func resolveDependencies(
 	ctx context.Context,
 	installPath string,
 	deps installDeps,
 	resolvedDeps []string,
 	col collection,
+	meta *types.GalaxyCollectionVersionInfo,
 	filename string,
-) ([]string, error) {
+) ([]string, string, error) {
 	cfg := deps.cfg
 	runtime := deps.runtime
 
 	if resolvedDeps != nil || cfg.NoDeps {
-		return resolvedDeps, nil
+		return resolvedDeps, col.DepsSource, nil
 	}
 	depsStart := time.Now()
-	depsList, err := installDependencies(ctx, installPath, deps)
+	depsList, depsSource, err := installDependencies(ctx, installPath, deps, col, meta)
 	if err != nil {
-		return nil, fmt.Errorf("failed to install dependencies for %s: %w", filename, err)
+		return nil, "", fmt.Errorf("failed to install dependencies for %s: %w", filename, err)
 	}
 	runtime.Output.DebugSincef(depsStart, "%s", "deps "+col.key())
-	return depsList, nil
+	return depsList, depsSource, nil
 }
 
-func writeGalaxyInfoIfPresent(runtime *infra.Infra, cfg *config.Config, meta *types.GalaxyCollectionVersionInfo) {
-	if err := writeGalaxyInfo(cfg, meta); err != nil {
+func writeGalaxyInfoIfPresent(
+	runtime *infra.Infra,
+	cfg *config.Config,
+	base string,
+	meta *types.GalaxyCollectionVersionInfo,
+	extra galaxyInfoExtra,
+) {
+	if err := writeGalaxyInfo(cfg, base, meta, extra); err != nil {
 		runtime.Output.Printf("⚠️ Failed to write GALAXY.yml: %v", err)
 	}
 }
 
-func recordInstall(st *store.Store, col collection, installPath, artifactSHA string, deps []string) {
+func recordInstall(cfg *config.Config, st *store.Store, col collection, source, installPath, artifactSHA, artifactID string, deps []string) {
 	if st == nil {
 		return
 	}
-	st.SetInstalled(col.key(), installedEntry{
+	if source == "" {
+		source = col.Source
+	}
+	entry := installedEntry{
 		InstallPath:    installPath,
-		Source:         col.Source,
+		Source:         source,
 		ArtifactSHA256: artifactSHA,
 		InstalledAt:    time.Now().UTC(),
 		Deps:           deps,
-	})
+		DepsSource:     col.DepsSource,
+		Modes:          cfg.PermissionModes(),
+	}
+	if artifactID != artifactSHA {
+		entry.ArtifactID = artifactID
+	}
+	st.SetInstalled(col.key(), entry)
 	if deps != nil {
 		st.SetGraph(col.key(), deps)
 	}
 }
 
-func artifactExists(ctx context.Context, artifacts cacheManager.ArtifactStore, col collection) bool {
-	ok, err := artifacts.Has(ctx, artifactKey(col))
-	return err == nil && ok
+// recordArtifactStats counts an artifact cache hit (with bytes saved) or miss.
+func recordArtifactStats(st *store.Store, cacheHit bool, path string) {
+	if !cacheHit {
+		st.AddStats(store.CacheCounters{ArtifactMisses: 1})
+		return
+	}
+	delta := store.CacheCounters{ArtifactHits: 1}
+	if info, err := os.Stat(path); err == nil {
+		delta.BytesSaved = info.Size()
+	}
+	st.AddStats(delta)
 }
 
 func fetchArtifact(
@@ -180,25 +240,25 @@ func fetchArtifact(
 	deps installDeps,
 	col collection,
 	meta *types.GalaxyCollectionVersionInfo,
-	cacheHit bool,
+	cachedKey string,
 	useCache bool,
 ) (artifactData, error) {
 	runtime := deps.runtime
 	artifacts := deps.artifacts
 
-	if !cacheHit {
+	if cachedKey == "" {
 		downloadStart := time.Now()
-		result, err := downloadCollectionToCache(ctx, deps, artifactKey(col), meta, useCache)
+		result, err := downloadCollectionToCache(ctx, deps, col, meta, useCache)
 		if err != nil {
 			return artifactData{}, err
 		}
 		runtime.Output.DebugSincef(downloadStart, "%s", "download "+col.key())
-		return artifactData{Path: result.Path, Cleanup: result.Cleanup, SHA: result.SHA}, nil
+		return artifactData{Path: result.Path, Cleanup: result.Cleanup, SHA: result.SHA, Digest: result.Digest}, nil
 	}
 	if artifacts == nil {
 		return artifactData{}, helpers.ErrArtifactCacheNotConfigured
 	}
-	cached, err := artifacts.Fetch(ctx, artifactKey(col))
+	cached, err := artifacts.Fetch(ctx, cachedKey)
 	if err != nil {
 		return artifactData{}, err
 	}
@@ -224,41 +284,79 @@ func resolveArtifactSHA(
 	return archive.FileHashSHA256(path)
 }
 
-// artifactKey builds the cache key for a collection tarball.
-func artifactKey(col collection) string {
-	filename := fmt.Sprintf("%s-%s-%s.tar.gz", col.Namespace, col.Name, col.Version)
-	return url.QueryEscape(filename)
-}
-
 // canSkipInstall reports whether a collection is already installed.
-func canSkipInstall(cfg *config.Config, col collection, installPath string, st *store.Store) bool {
+// fingerprint is the current fingerprint of the collection's collections
+// path; when it matches the one recorded with the entry, nothing was added
+// or removed there since, and the checks on disk are skipped. Under
+// --info-check manifest a tree the store does not vouch for, because its
+// entry, extraction marker or .info/GALAXY.yml is missing, is skipped when
+// its MANIFEST.json and FILES.json match; see manifestSkip.
+func canSkipInstall(cfg *config.Config, col collection, installPath string, st *store.Store, fingerprint string) bool {
 	if cfg == nil || st == nil {
 		return false
 	}
 	entry, ok := st.GetInstalled(col.key())
-	if !ok {
-		return false
+	if !ok || entry.InstallPath == "" || entry.InstallPath != installPath {
+		return manifestSkip(cfg, col, installPath, "")
 	}
-	if entry.InstallPath == "" || entry.InstallPath != installPath {
+	if entry.Modes != cfg.PermissionModes() {
 		return false
 	}
-	if entry.ArtifactSHA256 == "" {
-		return false
+	id := entry.ArtifactID
+	if id == "" {
+		id = entry.ArtifactSHA256
+	}
+	if id == "" {
+		return manifestSkip(cfg, col, installPath, entry.Modes)
+	}
+	if col.isLocalArtifact() {
+		// the file behind an --artifact path may have been rebuilt in place
+		sha, err := archive.FileHashSHA256(localArtifactPath(col.Source))
+		if err != nil || sha != entry.ArtifactSHA256 {
+			return false
+		}
+	}
+	verify := cfg.VerifySkip == config.VerifySkipSample || cfg.VerifySkip == config.VerifySkipAll
+	if !verify && fingerprint != "" && fingerprint == entry.PathFingerprint {
+		return true
 	}
 
-	marker := filepath.Join(installPath, ".extract-done."+entry.ArtifactSHA256)
+	marker := filepath.Join(installPath, ".extract-done."+id)
 	if _, err := os.Stat(marker); err != nil {
+		return manifestSkip(cfg, col, installPath, entry.Modes)
+	}
+	if verify && !verifyInstalledFiles(installPath, cfg.VerifySkip == config.VerifySkipAll) {
 		return false
 	}
 
-	infoDir := filepath.Join(cfg.DownloadPath, "ansible_collections", fmt.Sprintf("%s.%s-%s.info", col.Namespace, col.Name, col.Version))
+	if cfg.GalaxyInfo == config.GalaxyInfoNone {
+		return true
+	}
+	infoDir := filepath.Join(col.collectionsPath(cfg), "ansible_collections", fmt.Sprintf("%s.%s-%s.info", col.Namespace, col.Name, col.Version))
 	if _, err := os.Stat(filepath.Join(infoDir, "GALAXY.yml")); err != nil {
-		return false
+		return cfg.InfoCheck == config.InfoCheckManifest && installedManifestMatches(col, installPath)
 	}
 
 	return true
 }
 
+// manifestSkip is the --info-check manifest fallback for a tree the store
+// has no usable record of: it may be skipped when its MANIFEST.json names
+// col and records the checksum of its FILES.json. modes are the chmod modes
+// the tree is known to have, "" when unknown; a tree that may not carry the
+// configured modes is reinstalled, as is one from an --artifact file, which
+// may have been rebuilt under the same version.
+func manifestSkip(cfg *config.Config, col collection, installPath, modes string) bool {
+	if cfg.InfoCheck != config.InfoCheckManifest || col.isLocalArtifact() || modes != cfg.PermissionModes() {
+		return false
+	}
+	if !installedManifestMatches(col, installPath) {
+		return false
+	}
+	verify := cfg.VerifySkip == config.VerifySkipSample || cfg.VerifySkip == config.VerifySkipAll
+	return !verify || verifyInstalledFiles(installPath, cfg.VerifySkip == config.VerifySkipAll)
+}
+
 // downloadCollection fetches an artifact and returns the HTTP response.
 func downloadCollection(ctx context.Context, runtime *infra.Infra, collectionURL string) (*http.Response, error) {
 	runtime.Output.Printf("🌐 Downloading %s", collectionURL)
@@ -266,10 +364,19 @@ func downloadCollection(ctx context.Context, runtime *infra.Infra, collectionURL
 	if err != nil {
 		return nil, err
 	}
-	resp, err := runtime.HTTP.Do(req)
+	resp, err := runtime.ClientFor(collectionURL).Do(req)
 	if err != nil {
 		return nil, err
 	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%w: %w: %s (%s)", helpers.ErrDownloadFailed, helpers.ErrArtifactNotFound, collectionURL, resp.Status)
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusGone:
+		// Signed CDN URLs answer like this once their signature has expired.
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%w: %w: %s (%s)", helpers.ErrDownloadFailed, helpers.ErrDownloadURLRejected, collectionURL, resp.Status)
+	}
 	if resp.StatusCode != http.StatusOK {
 		_ = resp.Body.Close()
 		return nil, fmt.Errorf("%w: %s (%s)", helpers.ErrDownloadFailed, collectionURL, resp.Status)
@@ -281,21 +388,70 @@ func downloadCollection(ctx context.Context, runtime *infra.Infra, collectionURL
 type downloadResult struct {
 	Path    string
 	SHA     string
+	Digest  string
+	Size    int64
 	Cleanup func()
 }
 
 // downloadCollectionToCache downloads an artifact and optionally stores it.
+// Cached downloads of one artifact are shared through deps.downloads.
 func downloadCollectionToCache(
 	ctx context.Context,
 	deps installDeps,
-	key string,
+	col collection,
+	meta *types.GalaxyCollectionVersionInfo,
+	useCache bool,
+) (downloadResult, error) {
+	if !useCache || deps.downloads == nil {
+		return downloadArtifact(ctx, deps, col, meta, useCache)
+	}
+	key := artifactKey(col)
+	var result downloadResult
+	shared, err := deps.downloads.do(ctx, key, func() error {
+		var err error
+		result, err = downloadArtifact(ctx, deps, col, meta, true)
+		return err
+	})
+	if err != nil || !shared {
+		return result, err
+	}
+	deps.runtime.Output.Debugf("%s was downloaded by another worker, using the cached artifact", col.key())
+	if result, err = sharedDownload(ctx, deps.artifacts, key, deps.cfg.ArtifactHash); err == nil {
+		return result, nil
+	}
+	// the shared artifact is gone from the cache again, so fetch it anew
+	return downloadArtifact(ctx, deps, col, meta, true)
+}
+
+// downloadArtifact downloads an artifact, verifies it and, with useCache,
+// commits it to the artifact cache.
+func downloadArtifact(
+	ctx context.Context,
+	deps installDeps,
+	col collection,
 	meta *types.GalaxyCollectionVersionInfo,
 	useCache bool,
 ) (downloadResult, error) {
 	if err := validateDownloadInputs(deps.cfg, deps.artifacts, meta); err != nil {
 		return downloadResult{}, err
 	}
+	if err := checkDownloadBudget(deps.cfg, deps.st); err != nil {
+		return downloadResult{}, err
+	}
 	resp, err := downloadCollection(ctx, deps.runtime, meta.DownloadURL)
+	if errors.Is(err, helpers.ErrDownloadURLRejected) {
+		// Cached metadata may carry a signed download_url that has since expired.
+		deps.runtime.Output.Printf("🔄 Download URL for %s rejected, refreshing metadata", col.key())
+		fresh, refreshErr := refreshVersionMetadata(ctx, deps.collectionDeps, col, meta)
+		if refreshErr != nil {
+			return downloadResult{}, fmt.Errorf("%w (refresh failed: %w)", err, refreshErr)
+		}
+		if err := validateDownloadInputs(deps.cfg, deps.artifacts, fresh); err != nil {
+			return downloadResult{}, err
+		}
+		meta = fresh
+		resp, err = downloadCollection(ctx, deps.runtime, meta.DownloadURL)
+	}
 	if err != nil {
 		return downloadResult{}, err
 	}
@@ -303,19 +459,44 @@ func downloadCollectionToCache(
 		_ = resp.Body.Close()
 	}()
 
-	tmpPath, cleanup, sha, err := writeDownloadToTemp(ctx, deps.artifacts, resp.Body)
+	downloaded, err := writeDownloadToTemp(ctx, deps.artifacts, resp.Body, deps.cfg.ArtifactHash, deps.hashes)
 	if err != nil {
-		cleanupIfNeeded(cleanup)
+		cleanupIfNeeded(downloaded.Cleanup)
+		return downloadResult{}, err
+	}
+	deps.st.AddStats(store.CacheCounters{BytesDownloaded: downloaded.Size})
+	if err := checkDownloadBudget(deps.cfg, deps.st); err != nil {
+		cleanupIfNeeded(downloaded.Cleanup)
 		return downloadResult{}, err
 	}
-	if err := verifyDownloadSHA(meta, sha); err != nil {
-		cleanupIfNeeded(cleanup)
+	if err := verifyDownloadSHA(meta, downloaded.SHA); err != nil {
+		cleanupIfNeeded(downloaded.Cleanup)
 		return downloadResult{}, err
 	}
 	if useCache {
-		return commitDownload(ctx, deps.artifacts, key, tmpPath, sha, cleanup)
+		return commitDownload(ctx, deps.artifacts, artifactKey(col), deps.cfg.ArtifactHash, downloaded)
+	}
+	return downloaded, nil
+}
+
+// refreshVersionMetadata re-fetches version metadata past the API cache and stores the result.
+func refreshVersionMetadata(
+	ctx context.Context,
+	deps collectionDeps,
+	col collection,
+	meta *types.GalaxyCollectionVersionInfo,
+) (*types.GalaxyCollectionVersionInfo, error) {
+	versionURL := collectionVersionsURL(col) + col.Version + "/"
+	if meta != nil && strings.TrimSpace(meta.Href) != "" {
+		versionURL = normalizeVersionsURL(col.Source, meta.Href)
+	}
+	policy := cachePolicyForConstraint(deps.cfg, true)
+	policy.Read = false
+	var fresh types.GalaxyCollectionVersionInfo
+	if err := fetchJSONWithCachePolicy(ctx, deps.runtime, versionURL, deps.st, &fresh, policy); err != nil {
+		return nil, err
 	}
-	return downloadResult{Path: tmpPath, SHA: sha, Cleanup: cleanup}, nil
+	return &fresh, nil
 }
 
 func validateDownloadInputs(cfg *config.Config, artifacts cacheManager.ArtifactStore, meta *types.GalaxyCollectionVersionInfo) error {
@@ -334,21 +515,43 @@ func validateDownloadInputs(cfg *config.Config, artifacts cacheManager.ArtifactS
 	return nil
 }
 
-func writeDownloadToTemp(ctx context.Context, artifacts cacheManager.ArtifactStore, body io.Reader) (string, func(), string, error) {
+// writeDownloadToTemp streams body to a temp file while sha256 and, if
+// configured, the --artifact-hash digest are computed on the hash pool.
+func writeDownloadToTemp(
+	ctx context.Context,
+	artifacts cacheManager.ArtifactStore,
+	body io.Reader,
+	algo string,
+	pool *hashPool,
+) (downloadResult, error) {
 	tmpFile, cleanup, err := artifacts.TempFile(ctx, ".download-")
 	if err != nil {
-		return "", cleanup, "", err
+		return downloadResult{Cleanup: cleanup}, err
 	}
-	hasher := sha256.New()
-	writer := io.MultiWriter(tmpFile, hasher)
-	if _, err := io.Copy(writer, body); err != nil {
+	hashes := []hash.Hash{sha256.New()}
+	if algo != "" && algo != archive.HashSHA256 {
+		extra, err := archive.NewHash(algo)
+		if err != nil {
+			_ = tmpFile.Close()
+			return downloadResult{Cleanup: cleanup}, err
+		}
+		hashes = append(hashes, extra)
+	}
+	hasher := newAsyncHasher(pool, hashes...)
+	size, copyErr := io.Copy(io.MultiWriter(tmpFile, hasher), body)
+	sums := hasher.Sums()
+	if copyErr != nil {
 		_ = tmpFile.Close()
-		return "", cleanup, "", err
+		return downloadResult{Cleanup: cleanup}, copyErr
 	}
 	if err := tmpFile.Close(); err != nil {
-		return "", cleanup, "", err
+		return downloadResult{Cleanup: cleanup}, err
+	}
+	result := downloadResult{Path: tmpFile.Name(), SHA: sums[0], Size: size, Cleanup: cleanup}
+	if len(sums) > 1 {
+		result.Digest = sums[1]
 	}
-	return tmpFile.Name(), cleanup, hex.EncodeToString(hasher.Sum(nil)), nil
+	return result, nil
 }
 
 func verifyDownloadSHA(meta *types.GalaxyCollectionVersionInfo, sha string) error {
@@ -363,16 +566,19 @@ func commitDownload(
 	ctx context.Context,
 	artifacts cacheManager.ArtifactStore,
 	key string,
-	tmpPath string,
-	sha string,
-	cleanup func(),
+	algo string,
+	downloaded downloadResult,
 ) (downloadResult, error) {
-	stored, err := artifacts.Commit(ctx, key, tmpPath, map[string]string{"sha256": sha})
+	meta := map[string]string{archive.HashSHA256: downloaded.SHA}
+	if downloaded.Digest != "" {
+		meta[algo] = downloaded.Digest
+	}
+	stored, err := artifacts.Commit(ctx, key, downloaded.Path, meta)
 	if err != nil {
-		cleanupIfNeeded(cleanup)
+		cleanupIfNeeded(downloaded.Cleanup)
 		return downloadResult{}, err
 	}
-	return downloadResult{Path: stored.Path, SHA: sha, Cleanup: stored.Cleanup}, nil
+	return downloadResult{Path: stored.Path, SHA: downloaded.SHA, Digest: downloaded.Digest, Cleanup: stored.Cleanup}, nil
 }
 
 func cleanupIfNeeded(cleanup func()) {
@@ -406,28 +612,32 @@ func resolveMetadata(
 	return meta, nil
 }
 
-// installDependencies installs dependent collections from MANIFEST.json.
-func installDependencies(ctx context.Context, installPath string, depsCtx installDeps) ([]string, error) {
+// installDependencies installs the dependencies of a collection installed
+// without a resolved graph, taken from its MANIFEST.json or version metadata
+// as --deps-source prefers.
+func installDependencies(
+	ctx context.Context,
+	installPath string,
+	depsCtx installDeps,
+	col collection,
+	meta *types.GalaxyCollectionVersionInfo,
+) ([]string, string, error) {
 	cfg := depsCtx.cfg
 	runtime := depsCtx.runtime
 
-	manifestPath := filepath.Join(installPath, "MANIFEST.json")
-	//nolint:gosec // manifestPath is derived from install path and is trusted.
-	data, err := os.ReadFile(manifestPath)
+	manifest, err := manifestDependencies(installPath)
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil
-		}
-		return nil, err
+		return nil, "", err
 	}
-
-	var parsed types.GalaxyCollectionVersionInfoManifest
-	if err := json.Unmarshal(data, &parsed); err != nil {
-		return nil, fmt.Errorf("invalid MANIFEST.json: %w", err)
+	var metadata map[string]string
+	if meta != nil {
+		metadata = meta.Metadata.Dependencies
 	}
+	chosen, depsSource := pickDependencies(cfg, metadata, manifest)
+	warnDepsDivergence(runtime, col.key(), metadata, manifest, depsSource)
 
-	deps := make([]string, 0, len(parsed.CollectionInfo.Dependencies))
-	for fqdn, version := range parsed.CollectionInfo.Dependencies {
+	deps := make([]string, 0, len(chosen))
+	for fqdn, version := range chosen {
 		parts := strings.Split(fqdn, ".")
 		if len(parts) != helpers.CollectionNameParts {
 			runtime.Output.Printf("⚠️ Skipping invalid dependency: %s", fqdn)
@@ -440,5 +650,5 @@ func installDependencies(ctx context.Context, installPath string, depsCtx instal
 			runtime.Output.Printf("⚠️ Failed to install dependency: %s: %v", fqdn, err)
 		}
 	}
-	return deps, nil
+	return deps, depsSource, nil
 }
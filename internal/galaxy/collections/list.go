@@ -0,0 +1,125 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// Remote states reported by ListInstalled when the server is checked.
+const (
+	// RemotePublished means the installed version is still served upstream.
+	RemotePublished = "published"
+	// RemoteMissing means the server answered 404: the version was unpublished or yanked.
+	RemoteMissing = "missing"
+	// RemoteUnknown means the server could not be asked; see RemoteError.
+	RemoteUnknown = "unknown"
+)
+
+// ListedCollection is one collection found under the collections path.
+type ListedCollection struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Path        string `json:"path"`
+	Source      string `json:"source"`
+	Remote      string `json:"remote,omitempty"`
+	RemoteError string `json:"remote_error,omitempty"`
+	// Licenses are the SPDX identifiers the collection's MANIFEST.json declares.
+	Licenses []string `json:"licenses,omitempty"`
+	// LicenseFile is the license file MANIFEST.json points to instead.
+	LicenseFile string `json:"license_file,omitempty"`
+}
+
+// ListInstalled lists collections under cfg.DownloadPath by their MANIFEST.json.
+// With remote set, each version is looked up on its source past the API cache.
+func ListInstalled(ctx context.Context, cfg *config.Config, runtime *infra.Infra, st *store.Store, remote bool) ([]ListedCollection, error) {
+	root := filepath.Join(cfg.DownloadPath, "ansible_collections")
+	namespaces, err := os.ReadDir(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return []ListedCollection{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	installed := st.InstalledSnapshot()
+	listed := make([]ListedCollection, 0)
+	for _, ns := range namespaces {
+		if !ns.IsDir() {
+			continue
+		}
+		names, err := os.ReadDir(filepath.Join(root, ns.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if !name.IsDir() {
+				continue
+			}
+			dir := filepath.Join(root, ns.Name(), name.Name())
+			manifest, ok := readInstalledManifest(dir)
+			if !ok {
+				continue
+			}
+			version := manifest.CollectionInfo.Version
+			col := collection{Namespace: ns.Name(), Name: name.Name(), Version: version, Source: cfg.Server}
+			if entry, ok := installed[col.key()]; ok && entry.Source != "" {
+				col.Source = entry.Source
+			}
+			listed = append(listed, ListedCollection{
+				Name:        ns.Name() + "." + name.Name(),
+				Version:     version,
+				Path:        dir,
+				Source:      col.Source,
+				Licenses:    manifest.CollectionInfo.License,
+				LicenseFile: manifest.CollectionInfo.LicenseFile,
+			})
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool { return listed[i].Name < listed[j].Name })
+
+	if remote {
+		checkRemoteVersions(ctx, newCollectionDeps(cfg, runtime, st), listed)
+	}
+	return listed, nil
+}
+
+// checkRemoteVersions fills Remote for every entry using up to cfg.ResolveWorkerCount() requests.
+func checkRemoteVersions(ctx context.Context, deps collectionDeps, listed []ListedCollection) {
+	policy := cachePolicyForConstraint(deps.cfg, true)
+	policy.Read = false
+	sem := make(chan struct{}, deps.cfg.ResolveWorkerCount())
+	var wg sync.WaitGroup
+	for i := range listed {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			item := &listed[i]
+			deps.runtime.Output.Printf("🔍 check %s %s", item.Name, item.Version)
+			ns, name, _ := helpers.SplitFQDN(item.Name)
+			col := collection{Namespace: ns, Name: name, Version: item.Version, Source: item.Source}
+			_, err := fetchVersionMetadataCached(ctx, deps, col.Source, collectionVersionsURL(col), col.Version, policy)
+			var statusErr *cacheManager.HTTPStatusError
+			switch {
+			case err == nil:
+				item.Remote = RemotePublished
+			case errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound:
+				item.Remote = RemoteMissing
+			default:
+				item.Remote = RemoteUnknown
+				item.RemoteError = err.Error()
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,41 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestListInstalledRemote(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/collections/a/b/versions/1.0.0/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"version":"1.0.0"}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeInstalledManifest(t, dir, "a", "b", "1.0.0")
+	writeInstalledManifest(t, dir, "c", "d", "0.9.0")
+
+	cfg := &config.Config{Server: srv.URL, DownloadPath: dir, Workers: 2}
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	listed, err := ListInstalled(context.Background(), cfg, runtime, store.New(), true)
+	if err != nil {
+		t.Fatalf("ListInstalled: %v", err)
+	}
+	if len(listed) != 2 || listed[0].Name != "a.b" || listed[1].Name != "c.d" {
+		t.Fatalf("unexpected listing: %+v", listed)
+	}
+	if listed[0].Remote != RemotePublished || listed[1].Remote != RemoteMissing {
+		t.Fatalf("unexpected remote states: %q %q", listed[0].Remote, listed[1].Remote)
+	}
+}
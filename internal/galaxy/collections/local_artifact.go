@@ -0,0 +1,188 @@
+package collections
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+const (
+	// localArtifactScheme prefixes the source of collections installed from --artifact files.
+	localArtifactScheme = "file://"
+	// manifestMaxSize bounds how much of an artifact's MANIFEST.json is read.
+	manifestMaxSize = 4 << 20
+)
+
+// localArtifact is a collection tarball given with --artifact.
+type localArtifact struct {
+	col  collection
+	path string
+	sha  string
+	deps map[string]string
+}
+
+// artifactManifest is the part of MANIFEST.json that identifies a collection.
+type artifactManifest struct {
+	CollectionInfo struct {
+		Namespace    string            `json:"namespace"`
+		Name         string            `json:"name"`
+		Version      string            `json:"version"`
+		Dependencies map[string]string `json:"dependencies"`
+	} `json:"collection_info"`
+}
+
+// readLocalArtifact identifies the collection in the tarball at path from
+// its MANIFEST.json.
+func readLocalArtifact(path string) (localArtifact, error) {
+	raw, err := archive.ReadTarGzFile(path, "MANIFEST.json", manifestMaxSize)
+	if err != nil {
+		return localArtifact{}, fmt.Errorf("%w: %s: %w", helpers.ErrInvalidArtifactFile, path, err)
+	}
+	var manifest artifactManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return localArtifact{}, fmt.Errorf("%w: %s: MANIFEST.json: %w", helpers.ErrInvalidArtifactFile, path, err)
+	}
+	info := manifest.CollectionInfo
+	if info.Namespace == "" || info.Name == "" || info.Version == "" {
+		return localArtifact{}, fmt.Errorf("%w: %s: MANIFEST.json lacks namespace, name or version", helpers.ErrInvalidArtifactFile, path)
+	}
+	deps, err := parseDependencies(info.Dependencies, helpers.ErrInvalidArtifactFile)
+	if err != nil {
+		return localArtifact{}, fmt.Errorf("%s: %w", path, err)
+	}
+	sha, err := archive.FileHashSHA256(path)
+	if err != nil {
+		return localArtifact{}, err
+	}
+	return localArtifact{
+		col: collection{
+			Namespace: info.Namespace,
+			Name:      info.Name,
+			Version:   info.Version,
+			Source:    localArtifactScheme + path,
+			Type:      "galaxy",
+		},
+		path: path,
+		sha:  sha,
+		deps: deps,
+	}, nil
+}
+
+// isLocalArtifact reports whether col is installed from an --artifact file.
+func (c collection) isLocalArtifact() bool {
+	return isLocalArtifactSource(c.Source)
+}
+
+// isLocalArtifactSource reports whether source names an --artifact file.
+func isLocalArtifactSource(source string) bool {
+	return strings.HasPrefix(source, localArtifactScheme)
+}
+
+// localArtifactPath returns the file behind an --artifact source.
+func localArtifactPath(source string) string {
+	return strings.TrimPrefix(source, localArtifactScheme)
+}
+
+// addArtifactRoots adds the --artifact collections to the requirement roots.
+// A requirement for the same collection must accept the artifact's version;
+// its install_path and signatures carry over to the artifact.
+func addArtifactRoots(cfg *config.Config, roots []collection) ([]collection, error) {
+	if len(cfg.Artifacts) == 0 {
+		return roots, nil
+	}
+	sc := newSemverCache()
+	for _, path := range cfg.Artifacts {
+		artifact, err := readLocalArtifact(path)
+		if err != nil {
+			return nil, err
+		}
+		root := artifact.col
+		fqdn := root.Namespace + "." + root.Name
+		kept := roots[:0]
+		for _, req := range roots {
+			if requirementFQDN(req) != fqdn {
+				kept = append(kept, req)
+				continue
+			}
+			if isLocalArtifactSource(req.Source) {
+				return nil, fmt.Errorf("%w for %s (artifact %s vs %s)",
+					helpers.ErrDuplicateCollectionRequirement, fqdn, localArtifactPath(req.Source), path)
+			}
+			ok, err := constraintsSatisfiedByVersion(sc, root.Version, []string{normalizeConstraint(req.Version)})
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("%w: %s %s from %s, requirements ask for %q",
+					helpers.ErrArtifactConstraint, fqdn, root.Version, path, req.Version)
+			}
+			root.InstallPath = req.InstallPath
+			root.Signatures = req.Signatures
+		}
+		roots = append(kept, root)
+	}
+	return roots, nil
+}
+
+// requirementFQDN returns namespace.name of a requirement whether or not
+// its name has been split yet.
+func requirementFQDN(req collection) string {
+	if req.Namespace == "" {
+		return req.Name
+	}
+	return req.Namespace + "." + req.Name
+}
+
+// resolveLocalArtifact resolves a task whose source is an --artifact file:
+// the version and dependencies come from its MANIFEST.json and must satisfy
+// the constraints other collections put on it.
+func resolveLocalArtifact(deps collectionDeps, task resolveTask) resolveResult {
+	failed := resolveResult{FQDN: task.FQDN, Namespace: task.Namespace, Name: task.Name}
+	artifact, err := readLocalArtifact(localArtifactPath(task.Source))
+	if err != nil {
+		failed.Err = err
+		return failed
+	}
+	ok, err := constraintsSatisfiedByVersion(deps.semver, artifact.col.Version, task.Constraints)
+	if err != nil {
+		failed.Err = err
+		return failed
+	}
+	if !ok {
+		failed.Err = fmt.Errorf("%w: %s %s from %s, constraints %s",
+			helpers.ErrArtifactConstraint, task.FQDN, artifact.col.Version, artifact.path, strings.Join(task.Constraints, ", "))
+		return failed
+	}
+	return buildResolveResult(task, artifact.col.Version, artifact.deps, config.DepsSourceManifest)
+}
+
+// prepareLocalInstall installs an --artifact collection straight from its
+// file, describing it with metadata built from its MANIFEST.json.
+func prepareLocalInstall(ctx context.Context, deps installDeps, col collection) (installPayload, error) {
+	artifact, err := readLocalArtifact(localArtifactPath(col.Source))
+	if err != nil {
+		return installPayload{}, err
+	}
+	if artifact.col.Namespace != col.Namespace || artifact.col.Name != col.Name || artifact.col.Version != col.Version {
+		return installPayload{}, fmt.Errorf("%w: %s now holds %s.%s %s, not %s",
+			helpers.ErrInvalidArtifactFile, artifact.path, artifact.col.Namespace, artifact.col.Name, artifact.col.Version, col.key())
+	}
+	meta := &types.GalaxyCollectionVersionInfo{}
+	meta.Namespace.Name = col.Namespace
+	meta.Name = col.Name
+	meta.Version = col.Version
+	meta.Artifact.Sha256 = artifact.sha
+	meta.Metadata.Dependencies = artifact.deps
+	data := artifactData{Path: artifact.path, SHA: artifact.sha, Source: col.Source}
+	artifactID, err := resolveArtifactID(ctx, deps, data, artifact.sha)
+	if err != nil {
+		return installPayload{}, err
+	}
+	return installPayload{meta: meta, artifact: data, artifactSHA: artifact.sha, artifactID: artifactID}, nil
+}
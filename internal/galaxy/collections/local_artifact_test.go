@@ -0,0 +1,82 @@
+package collections
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+const testArtifactManifest = `{"collection_info": {"namespace": "acme", "name": "tools", "version": "1.4.0",
+	"dependencies": {"community.general": ">=7.0.0"}}}`
+
+func TestReadLocalArtifact(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "acme-tools-1.4.0.tar.gz")
+	writeTestTarball(t, path, map[string]string{"./MANIFEST.json": testArtifactManifest, "FILES.json": "{}"})
+
+	artifact, err := readLocalArtifact(path)
+	if err != nil {
+		t.Fatalf("readLocalArtifact: %v", err)
+	}
+	if artifact.col.key() != "acme.tools@1.4.0" || !artifact.col.isLocalArtifact() || localArtifactPath(artifact.col.Source) != path {
+		t.Fatalf("unexpected collection %+v", artifact.col)
+	}
+	if artifact.deps["community.general"] != ">=7.0.0" || artifact.sha == "" {
+		t.Fatalf("unexpected artifact %+v", artifact)
+	}
+
+	broken := filepath.Join(t.TempDir(), "broken.tar.gz")
+	writeTestTarball(t, broken, map[string]string{"FILES.json": "{}"})
+	if _, err := readLocalArtifact(broken); !errors.Is(err, helpers.ErrInvalidArtifactFile) {
+		t.Fatalf("expected ErrInvalidArtifactFile, got %v", err)
+	}
+}
+
+func TestAddArtifactRootsReplacesRequirement(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "acme-tools-1.4.0.tar.gz")
+	writeTestTarball(t, path, map[string]string{"MANIFEST.json": testArtifactManifest})
+	cfg := &config.Config{Artifacts: []string{path}}
+
+	roots := []collection{
+		{Name: "acme.tools", Version: ">=1.0.0", InstallPath: "/opt/collections"},
+		{Name: "community.general", Version: "8.0.0"},
+	}
+	got, err := addArtifactRoots(cfg, roots)
+	if err != nil {
+		t.Fatalf("addArtifactRoots: %v", err)
+	}
+	if len(got) != 2 || got[0].Name != "community.general" {
+		t.Fatalf("unexpected roots %+v", got)
+	}
+	if root := got[1]; root.Version != "1.4.0" || !root.isLocalArtifact() || root.InstallPath != "/opt/collections" {
+		t.Fatalf("unexpected artifact root %+v", root)
+	}
+
+	roots = []collection{{Name: "acme.tools", Version: "<1.0.0"}}
+	if _, err := addArtifactRoots(cfg, roots); !errors.Is(err, helpers.ErrArtifactConstraint) {
+		t.Fatalf("expected ErrArtifactConstraint, got %v", err)
+	}
+}
+
+func TestResolveLocalArtifactChecksConstraints(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "acme-tools-1.4.0.tar.gz")
+	writeTestTarball(t, path, map[string]string{"MANIFEST.json": testArtifactManifest})
+	deps := collectionDeps{cfg: &config.Config{}, semver: newSemverCache()}
+	task := resolveTask{FQDN: "acme.tools", Namespace: "acme", Name: "tools", Source: localArtifactScheme + path}
+
+	task.Constraints = []string{"1.4.0", ">=1.2.0"}
+	res := resolveLocalArtifact(deps, task)
+	if res.Err != nil || res.Version != "1.4.0" || res.Deps["community.general"] != ">=7.0.0" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	task.Constraints = []string{"1.4.0", ">=2.0.0"}
+	if res := resolveLocalArtifact(deps, task); !errors.Is(res.Err, helpers.ErrArtifactConstraint) {
+		t.Fatalf("expected ErrArtifactConstraint, got %v", res.Err)
+	}
+}
@@ -0,0 +1,119 @@
+package collections
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// resolveLocked resolves roots from the lockfile under --no-deps and, like
+// resolveWithoutDeps, records the resolution when asked to.
+func resolveLocked(
+	cfg *config.Config,
+	st *store.Store,
+	roots []collection,
+	frozen map[string]store.ResolvedEntry,
+	record bool,
+) (map[string]collection, map[string][]string, error) {
+	resolved, graph, err := resolveFromLockfile(cfg, roots)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkFrozen(cfg, frozen, slices.Collect(maps.Values(resolved))...); err != nil {
+		return nil, nil, err
+	}
+	if record && st != nil {
+		spec := buildRequirementsSpec(cfg, roots)
+		recordResolution(st, resolved, graph, requirementsSignatureFromSpec(spec), snapshotServer(cfg), spec)
+	}
+	return resolved, graph, nil
+}
+
+// resolveFromLockfile returns the closure of roots recorded in cfg.Lockfile:
+// each root at its locked version plus, transitively, the collections the
+// lockfile graph says it depends on. Nothing is asked from the server and no
+// MANIFEST.json is read, so a hermetic build installs exactly what was locked.
+func resolveFromLockfile(cfg *config.Config, roots []collection) (map[string]collection, map[string][]string, error) {
+	manifest, err := readInstallManifest(cfg.Lockfile)
+	if err != nil {
+		return nil, nil, err
+	}
+	locked := func(fqdn string) (collection, error) {
+		entry, ok := manifest.Collections[fqdn]
+		if !ok {
+			return collection{}, fmt.Errorf("%w: %s is not in %s", helpers.ErrLockfileIncomplete, fqdn, cfg.Lockfile)
+		}
+		col, err := manifestCollection(cfg, fqdn, entry)
+		if err != nil {
+			return collection{}, fmt.Errorf("%s: %w", cfg.Lockfile, err)
+		}
+		return col, nil
+	}
+
+	resolved := make(map[string]collection)
+	graph := make(map[string][]string)
+	var queue []string
+	for _, root := range roots {
+		fqdn := root.Namespace + "." + root.Name
+		if root.isLocalArtifact() {
+			resolved[fqdn] = root
+			graph[root.key()] = nil
+			continue
+		}
+		col, err := locked(fqdn)
+		if err != nil {
+			return nil, nil, err
+		}
+		constraint := root.Constraint
+		if constraint == "" {
+			constraint = root.Version
+		}
+		ok, err := constraintSatisfied(col.Version, constraint)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", fqdn, err)
+		}
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s is locked at %s, the requirements ask for %q",
+				helpers.ErrLockfileIncomplete, fqdn, col.Version, constraint)
+		}
+		col.Signatures = root.Signatures
+		if root.InstallPath != "" {
+			col.InstallPath = root.InstallPath
+		}
+		resolved[fqdn] = col
+		queue = append(queue, col.key())
+	}
+
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		if _, done := graph[key]; done {
+			continue
+		}
+		deps, ok := manifest.Graph[key]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: no dependencies recorded for %s", helpers.ErrLockfileIncomplete, key)
+		}
+		graph[key] = slices.Clone(deps)
+		for _, dep := range deps {
+			fqdn, _, _ := strings.Cut(dep, "@")
+			col, err := locked(fqdn)
+			if err != nil {
+				return nil, nil, err
+			}
+			if col.key() != dep {
+				return nil, nil, fmt.Errorf("%w: %s depends on %s, but %s is locked", helpers.ErrLockfileIncomplete, key, dep, col.key())
+			}
+			if _, ok := resolved[fqdn]; !ok {
+				resolved[fqdn] = col
+			}
+			queue = append(queue, dep)
+		}
+	}
+	return resolved, graph, nil
+}
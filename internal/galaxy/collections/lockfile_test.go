@@ -0,0 +1,83 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+// writeLockfile writes an install manifest in which a.b depends on c.d,
+// which depends on e.f; g.h is locked but not needed by a.b.
+func writeLockfile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), helpers.InstallManifestFile)
+	data := fmt.Sprintf(`{"schema_version":%d,
+"graph":{"a.b@1.0.0":["c.d@2.0.0"],"c.d@2.0.0":["e.f@1.1.0"],"e.f@1.1.0":[],"g.h@3.0.0":null},
+"collections":{"a.b":{"version":"1.0.0"},"c.d":{"version":"2.0.0"},"e.f":{"version":"1.1.0"},"g.h":{"version":"3.0.0"}}}`,
+		helpers.InstallManifestSchemaVersion)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestResolveNoDepsFromLockfile(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{
+		Server:           "https://galaxy.invalid",
+		NoDeps:           true,
+		Lockfile:         writeLockfile(t),
+		HealthCheck:      config.HealthCheckOff,
+		RequirementsData: []byte("collections:\n  - name: a.b\n    version: '>=1.0.0'\n"),
+	}
+	runtime := infra.New(progress.New(false, true), http.DefaultClient)
+	got, err := ResolveRequirements(context.Background(), cfg, runtime, store.New())
+	if err != nil {
+		t.Fatalf("ResolveRequirements: %v", err)
+	}
+	want := map[string]string{"a.b": "1.0.0", "c.d": "2.0.0", "e.f": "1.1.0"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for fqdn, version := range want {
+		if got[fqdn] != version {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestResolveFromLockfileGraph(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{Server: "https://galaxy.invalid", Lockfile: writeLockfile(t)}
+	_, graph, err := resolveFromLockfile(cfg, []collection{{Namespace: "c", Name: "d", Version: "*"}})
+	if err != nil {
+		t.Fatalf("resolveFromLockfile: %v", err)
+	}
+	if _, ok := graph["e.f@1.1.0"]; !ok || len(graph) != 2 || !slices.Equal(graph["c.d@2.0.0"], []string{"e.f@1.1.0"}) {
+		t.Fatalf("unexpected graph %v", graph)
+	}
+}
+
+func TestResolveFromLockfileRejectsUncoveredRoots(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{Server: "https://galaxy.invalid", Lockfile: writeLockfile(t)}
+	for _, root := range []collection{
+		{Namespace: "x", Name: "y", Version: "*"},
+		{Namespace: "a", Name: "b", Version: ">=2.0.0"},
+	} {
+		if _, _, err := resolveFromLockfile(cfg, []collection{root}); !errors.Is(err, helpers.ErrLockfileIncomplete) {
+			t.Fatalf("%s: expected ErrLockfileIncomplete, got %v", root.key(), err)
+		}
+	}
+}
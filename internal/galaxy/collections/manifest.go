@@ -0,0 +1,289 @@
+package collections
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+// InstallManifest records what an install produced so a checkout can be verified later.
+type InstallManifest struct {
+	SchemaVersion    int                      `json:"schema_version"`
+	ToolVersion      string                   `json:"tool_version"`
+	GeneratedAt      time.Time                `json:"generated_at"`
+	Servers          []string                 `json:"servers"`
+	RequirementsHash string                   `json:"requirements_hash"`
+	Roots            []string                 `json:"roots"`
+	Graph            map[string][]string      `json:"graph"`
+	Collections      map[string]ManifestEntry `json:"collections"`
+	// Permissions are the --chmod-files and --chmod-dirs modes applied to the
+	// installed trees; empty when they kept the archive's modes.
+	Permissions string `json:"permissions,omitempty"`
+	// Targets are the --target-ansible-core and --target-python versions
+	// the install was made for.
+	Targets *ManifestTargets `json:"targets,omitempty"`
+}
+
+// ManifestEntry pins one installed collection.
+type ManifestEntry struct {
+	Version        string `json:"version"`
+	Source         string `json:"source"`
+	ArtifactSHA256 string `json:"artifact_sha256"`
+	TreeSHA256     string `json:"tree_sha256"`
+	// InstallPath is set when the collection was routed away from the default collections path.
+	InstallPath string `json:"install_path,omitempty"`
+	// Dir is the directory under ansible_collections when --install-template moved it from namespace/name.
+	Dir string `json:"dir,omitempty"`
+}
+
+// ManifestMismatch describes one difference found by VerifyManifest.
+type ManifestMismatch struct {
+	Name   string
+	Reason string
+}
+
+// writeInstallManifest writes install-manifest.json into the collections path.
+func writeInstallManifest(cfg *config.Config, runtime *infra.Infra, st *store.Store, plan *installPlan) error {
+	if cfg.DryRun {
+		return nil
+	}
+	runtime.Output.Printf("🧾 write install manifest")
+	manifest := InstallManifest{
+		SchemaVersion:    helpers.InstallManifestSchemaVersion,
+		ToolVersion:      cfg.ToolVersion,
+		GeneratedAt:      runtime.Now().UTC(),
+		RequirementsHash: st.MetaSnapshot().RequirementsHash,
+		Roots:            slices.Sorted(slices.Values(plan.roots)),
+		Graph:            plan.graph,
+		Collections:      make(map[string]ManifestEntry, len(plan.collections)),
+		Permissions:      cfg.PermissionModes(),
+		Targets:          manifestTargets(cfg),
+	}
+	servers := map[string]bool{strings.TrimRight(progress.Redact(cfg.Server), "/"): true}
+	for _, col := range plan.collections {
+		fqdn := col.Namespace + "." + col.Name
+		tree, err := treeDigest(col.installDir(cfg))
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %w", fqdn, err)
+		}
+		entry, _ := st.GetInstalled(col.key())
+		manifestEntry := ManifestEntry{
+			Version:        col.Version,
+			Source:         progress.Redact(col.Source),
+			ArtifactSHA256: entry.ArtifactSHA256,
+			TreeSHA256:     tree,
+			InstallPath:    col.InstallPath,
+		}
+		if cfg.InstallTemplate != "" {
+			manifestEntry.Dir = filepath.ToSlash(col.installRelDir(cfg))
+		}
+		manifest.Collections[fqdn] = manifestEntry
+		servers[strings.TrimRight(progress.Redact(col.Source), "/")] = true
+	}
+	for server := range servers {
+		if server != "" {
+			manifest.Servers = append(manifest.Servers, server)
+		}
+	}
+	sort.Strings(manifest.Servers)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(cfg.DownloadPath, helpers.InstallManifestFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), helpers.FileMod); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	if cfg.InstallTemplate != "" {
+		return writeActiveIndex(cfg, runtime, plan)
+	}
+	return nil
+}
+
+// Verify checks the installed collections against an install manifest and reports differences.
+// An empty manifestPath selects the manifest in cfg.DownloadPath.
+func Verify(cfg *config.Config, runtime *infra.Infra, manifestPath string) error {
+	if manifestPath == "" {
+		manifestPath = filepath.Join(cfg.DownloadPath, helpers.InstallManifestFile)
+	}
+	runtime.Output.Printf("🔎 verify %s", manifestPath)
+	manifest, mismatches, err := VerifyManifest(manifestPath)
+	if err != nil {
+		runtime.Output.Errorf("Error: %s", err.Error())
+		return err
+	}
+	for _, m := range mismatches {
+		runtime.Output.Errorf("%s: %s", m.Name, m.Reason)
+	}
+	if len(mismatches) > 0 {
+		err := fmt.Errorf("%w: %d differences", helpers.ErrManifestMismatch, len(mismatches))
+		runtime.Output.Errorf("Error: %s", err.Error())
+		return err
+	}
+	runtime.Output.PersistentPrintf("🤩 %d collections match the manifest", len(manifest.Collections))
+	return nil
+}
+
+// VerifyManifest compares the collections next to a manifest with what it recorded.
+func VerifyManifest(manifestPath string) (*InstallManifest, []ManifestMismatch, error) {
+	manifest, err := readInstallManifest(manifestPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	base := filepath.Join(filepath.Dir(manifestPath), "ansible_collections")
+
+	var mismatches []ManifestMismatch
+	for _, fqdn := range slices.Sorted(maps.Keys(manifest.Collections)) {
+		entry := manifest.Collections[fqdn]
+		namespace, name, ok := helpers.SplitFQDN(fqdn)
+		if !ok {
+			mismatches = append(mismatches, ManifestMismatch{Name: fqdn, Reason: "invalid collection name"})
+			continue
+		}
+		collectionsDir := base
+		if entry.InstallPath != "" {
+			collectionsDir = filepath.Join(entry.InstallPath, "ansible_collections")
+		}
+		dir := filepath.Join(namespace, name)
+		if entry.Dir != "" {
+			dir = filepath.FromSlash(entry.Dir)
+		}
+		tree, err := treeDigest(filepath.Join(collectionsDir, dir))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			mismatches = append(mismatches, ManifestMismatch{Name: fqdn, Reason: "not installed"})
+		case err != nil:
+			return nil, nil, err
+		case tree != entry.TreeSHA256:
+			mismatches = append(mismatches, ManifestMismatch{Name: fqdn, Reason: "content differs from " + entry.Version})
+		}
+	}
+
+	extra, err := unexpectedCollections(base, manifest.Collections)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, fqdn := range extra {
+		mismatches = append(mismatches, ManifestMismatch{Name: fqdn, Reason: "not in manifest"})
+	}
+	return manifest, mismatches, nil
+}
+
+// readInstallManifest reads and checks the schema of an install manifest.
+func readInstallManifest(path string) (*InstallManifest, error) {
+	//nolint:gosec // path is a user-provided install manifest.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest InstallManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid install manifest %s: %w", path, err)
+	}
+	if manifest.SchemaVersion != helpers.InstallManifestSchemaVersion {
+		return nil, fmt.Errorf("%w: install manifest %d", helpers.ErrUnsupportedSchemaVersion, manifest.SchemaVersion)
+	}
+	return &manifest, nil
+}
+
+// unexpectedCollections lists installed collections missing from the manifest.
+func unexpectedCollections(base string, expected map[string]ManifestEntry) ([]string, error) {
+	namespaces, err := os.ReadDir(base)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// with --install-template the expected directories are not namespace/name
+	templated := make(map[string]bool)
+	for _, entry := range expected {
+		if entry.Dir != "" && entry.InstallPath == "" {
+			templated[entry.Dir] = true
+		}
+	}
+	var out []string
+	for _, ns := range namespaces {
+		if !ns.IsDir() {
+			continue
+		}
+		names, err := os.ReadDir(filepath.Join(base, ns.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if isStagingDir(name.Name()) {
+				continue
+			}
+			if templated[ns.Name()+"/"+name.Name()] {
+				continue
+			}
+			fqdn := ns.Name() + "." + name.Name()
+			if entry, ok := expected[fqdn]; name.IsDir() && (!ok || entry.InstallPath != "") {
+				out = append(out, fqdn)
+			}
+		}
+	}
+	return out, nil
+}
+
+// treeDigest hashes relative paths, file contents and symlink targets under dir.
+func treeDigest(dir string) (string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(h, "l %s %s\n", rel, target)
+		case d.IsDir():
+			_, _ = fmt.Fprintf(h, "d %s\n", rel)
+		default:
+			sum, err := archive.FileHashSHA256(path)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(h, "f %s %s\n", rel, sum)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
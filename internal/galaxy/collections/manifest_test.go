@@ -0,0 +1,98 @@
+package collections
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestInstallManifestVerify(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	colDir := filepath.Join(dir, "ansible_collections", "a", "b")
+	if err := os.MkdirAll(filepath.Join(colDir, "plugins"), helpers.DirMod); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	file := filepath.Join(colDir, "plugins", "x.py")
+	if err := os.WriteFile(file, []byte("print(1)\n"), helpers.FileMod); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	col := collection{Namespace: "a", Name: "b", Version: "1.0.0", Source: "https://galaxy.example.com"}
+	st := store.New()
+	st.SetInstalled(col.key(), store.InstalledEntry{InstallPath: colDir, ArtifactSHA256: "abc"})
+	plan := &installPlan{roots: []string{col.key()}, collections: map[string]collection{col.key(): col}}
+	cfg := &config.Config{DownloadPath: dir, Server: "https://galaxy.example.com/", ToolVersion: "test"}
+	runtime := infra.New(progress.New(false, true), nil)
+	if err := writeInstallManifest(cfg, runtime, st, plan); err != nil {
+		t.Fatalf("writeInstallManifest: %v", err)
+	}
+	manifestPath := filepath.Join(dir, helpers.InstallManifestFile)
+
+	manifest, mismatches, err := VerifyManifest(manifestPath)
+	if err != nil || len(mismatches) != 0 {
+		t.Fatalf("expected clean verify, got %v %v", mismatches, err)
+	}
+	if manifest.Collections["a.b"].ArtifactSHA256 != "abc" || len(manifest.Servers) != 1 {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+
+	if err := os.WriteFile(file, []byte("print(2)\n"), helpers.FileMod); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "ansible_collections", "c", "d"), helpers.DirMod); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	_, mismatches, err = VerifyManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("VerifyManifest: %v", err)
+	}
+	if len(mismatches) != 2 || mismatches[0].Name != "a.b" || mismatches[1].Name != "c.d" {
+		t.Fatalf("unexpected mismatches: %+v", mismatches)
+	}
+}
+
+func TestInstallManifestTemplate(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	cfg := &config.Config{DownloadPath: dir, ToolVersion: "test", InstallTemplate: "{namespace}/{name}-{version}"}
+	col := collection{Namespace: "a", Name: "b", Version: "1.2.3"}
+	if got := col.installDir(cfg); got != filepath.Join(dir, "ansible_collections", "a", "b-1.2.3") {
+		t.Fatalf("installDir = %s", got)
+	}
+	if err := os.MkdirAll(col.installDir(cfg), helpers.DirMod); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(col.installDir(cfg), "MANIFEST.json"), []byte("{}"), helpers.FileMod); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	plan := &installPlan{roots: []string{col.key()}, collections: map[string]collection{col.key(): col}}
+	runtime := infra.New(progress.New(false, true), nil)
+	if err := writeInstallManifest(cfg, runtime, store.New(), plan); err != nil {
+		t.Fatalf("writeInstallManifest: %v", err)
+	}
+	_, mismatches, err := VerifyManifest(filepath.Join(dir, helpers.InstallManifestFile))
+	if err != nil || len(mismatches) != 0 {
+		t.Fatalf("expected clean verify, got %v %v", mismatches, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, helpers.ActiveIndexFile))
+	if err != nil {
+		t.Fatalf("read active index: %v", err)
+	}
+	var index ActiveIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("decode active index: %v", err)
+	}
+	if entry := index.Collections["a.b"]; entry.Version != "1.2.3" || entry.Path != "a/b-1.2.3" {
+		t.Fatalf("unexpected active entry: %+v", index.Collections)
+	}
+}
@@ -49,7 +49,7 @@ func loadCollectionMetadata(
 		if err != nil {
 			return nil, fmt.Errorf("failed to load versions list: %w", err)
 		}
-		selected, err := selectVersion(versions, []string{col.Version})
+		selected, err := selectNormalizedVersion(deps.semver, versions, []string{col.Version})
 		if err != nil {
 			return nil, err
 		}
@@ -58,7 +58,7 @@ func loadCollectionMetadata(
 
 	versionURL = normalizeVersionsURL(col.Source, versionURL)
 	var versionMetadataInfo types.GalaxyCollectionVersionInfo
-	if err := fetchJSONWithCachePolicy(ctx, runtime.HTTP, versionURL, st, &versionMetadataInfo, policy); err != nil {
+	if err := fetchJSONWithCachePolicy(ctx, runtime, versionURL, st, &versionMetadataInfo, policy); err != nil {
 		return nil, err
 	}
 
@@ -71,7 +71,7 @@ func loadRootMetadataCached(
 	deps collectionDeps,
 	col collection,
 	policy cacheManager.Policy,
-) (*types.GalaxyCollection, error) {
+) (*rootMetadata, error) {
 	cfg := deps.cfg
 	runtime := deps.runtime
 	st := deps.st
@@ -83,8 +83,8 @@ func loadRootMetadataCached(
 
 	for _, url := range candidates {
 		runtime.Output.Debugf("root metadata GET %s", url)
-		var root types.GalaxyCollection
-		if err := fetchJSONWithCachePolicy(ctx, runtime.HTTP, url, st, &root, policy); err != nil {
+		var root rootMetadata
+		if err := fetchJSONWithCachePolicy(ctx, runtime, url, st, &root, policy); err != nil {
 			var statusErr *cacheManager.HTTPStatusError
 			if hasExplicitSource {
 				return nil, err
@@ -124,7 +124,7 @@ func fetchVersionMetadataCached(
 	}
 	url := fmt.Sprintf("%s%s/", base, version)
 	var info types.GalaxyCollectionVersionInfo
-	if err := fetchJSONWithCachePolicy(ctx, runtime.HTTP, url, st, &info, policy); err != nil {
+	if err := fetchJSONWithCachePolicy(ctx, runtime, url, st, &info, policy); err != nil {
 		return nil, err
 	}
 	return &info, nil
@@ -0,0 +1,76 @@
+package collections
+
+import (
+	"context"
+	"slices"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+// fetchArtifactFromMirrors retries a missing artifact download on other configured servers.
+// It returns notFoundErr when no alternate server hosts the same collection version.
+func fetchArtifactFromMirrors(
+	ctx context.Context,
+	deps installDeps,
+	col collection,
+	useCache bool,
+	notFoundErr error,
+) (artifactData, *types.GalaxyCollectionVersionInfo, error) {
+	runtime := deps.runtime
+	for _, source := range mirrorSources(deps.cfg, deps.st, col.Source) {
+		mirror := col
+		mirror.Source = source
+		meta, err := loadCollectionMetadata(ctx, deps.collectionDeps, mirror)
+		if err != nil {
+			runtime.Output.Debugf("mirror %s has no %s: %v", source, col.key(), err)
+			continue
+		}
+		result, err := downloadCollectionToCache(ctx, deps, mirror, meta, useCache)
+		if err != nil {
+			runtime.Output.Debugf("mirror %s download failed for %s: %v", source, col.key(), err)
+			continue
+		}
+		runtime.Output.Printf("🔁 Downloaded %s from mirror %s", col.key(), source)
+		return artifactData{Path: result.Path, Cleanup: result.Cleanup, SHA: result.SHA, Source: source}, meta, nil
+	}
+	return artifactData{}, nil, notFoundErr
+}
+
+// mirrorSources lists configured servers other than primary, each once: the
+// default server, the galaxy_server sections by name, the --server-mirror
+// servers in the order given, then sources recorded by requirements, sorted.
+func mirrorSources(cfg *config.Config, st *store.Store, primary string) []string {
+	seen := map[string]struct{}{normalizeServerURL(primary): {}}
+	var sources []string
+	add := func(source string) {
+		normalized := normalizeServerURL(source)
+		if normalized == "" || isLocalArtifactSource(source) {
+			return
+		}
+		if _, ok := seen[normalized]; ok {
+			return
+		}
+		seen[normalized] = struct{}{}
+		sources = append(sources, source)
+	}
+	if cfg != nil {
+		add(cfg.Server)
+		for _, src := range cfg.Sources {
+			add(src.URL)
+		}
+		for _, mirror := range cfg.Mirrors {
+			add(mirror)
+		}
+	}
+	var extra []string
+	for _, spec := range st.RequirementsSnapshot() {
+		extra = append(extra, spec.Source)
+	}
+	slices.Sort(extra)
+	for _, source := range extra {
+		add(source)
+	}
+	return sources
+}
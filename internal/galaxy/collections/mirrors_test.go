@@ -0,0 +1,62 @@
+package collections
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+func TestMirrorSourcesExcludesPrimary(t *testing.T) {
+	t.Parallel()
+	st := store.New()
+	st.SetRequirements(map[string]store.RequirementSpec{
+		"a.b": {Source: "https://galaxy.ansible.com/"},
+		"c.d": {Source: "https://hub.example.com"},
+		"e.f": {Source: "https://hub.example.com/"},
+	})
+	cfg := &config.Config{Server: "https://galaxy.ansible.com"}
+
+	got := mirrorSources(cfg, st, "https://galaxy.ansible.com")
+	if !slices.Equal(got, []string{"https://hub.example.com"}) {
+		t.Fatalf("unexpected mirrors: %v", got)
+	}
+	got = mirrorSources(cfg, st, "https://hub.example.com")
+	if !slices.Equal(got, []string{"https://galaxy.ansible.com"}) {
+		t.Fatalf("unexpected mirrors: %v", got)
+	}
+}
+
+func TestMirrorSourcesIncludesConfiguredServers(t *testing.T) {
+	t.Parallel()
+	st := store.New()
+	st.SetRequirements(map[string]store.RequirementSpec{
+		"a.b": {Source: "https://zeta.example.com"},
+		"c.d": {Source: "https://mirror.example.com/"},
+	})
+	cfg := &config.Config{
+		Server: "https://galaxy.ansible.com",
+		Sources: []config.SourceConfig{
+			{Name: "automation_hub", URL: "https://hub.example.com/api/"},
+			{Name: "release_galaxy", URL: "https://galaxy.ansible.com/"},
+		},
+		Mirrors: []string{"https://mirror.example.com", "https://alpha.example.com", "https://hub.example.com/api"},
+	}
+
+	want := []string{
+		"https://galaxy.ansible.com",
+		"https://hub.example.com/api/",
+		"https://mirror.example.com",
+		"https://alpha.example.com",
+		"https://zeta.example.com",
+	}
+	for range 3 {
+		if got := mirrorSources(cfg, st, "https://primary.example.com"); !slices.Equal(got, want) {
+			t.Fatalf("mirrorSources = %v, want %v", got, want)
+		}
+	}
+	if got := mirrorSources(cfg, st, "https://hub.example.com/api"); slices.Contains(got, "https://hub.example.com/api/") {
+		t.Fatalf("primary listed as its own mirror: %v", got)
+	}
+}
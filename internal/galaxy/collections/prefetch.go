@@ -2,9 +2,9 @@ package collections
 
 import (
 	"context"
-	"path/filepath"
 	"sync"
 
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
 	"github.com/psvmcc/hub/pkg/types"
 )
 
@@ -14,6 +14,14 @@ type prefetcher struct {
 	meta map[string]*types.GalaxyCollectionVersionInfo
 	errs map[string]error
 	done map[string]chan struct{}
+	// downloads is shared with the installers, so an artifact the prefetcher
+	// is still downloading is not fetched a second time.
+	downloads *artifactDownloads
+	// hashes is the hash pool shared with the installers.
+	hashes *hashPool
+	// fingerprints are the collections path fingerprints taken before the
+	// run installed anything, shared with the installers.
+	fingerprints map[string]string
 }
 
 // startPrefetcher schedules prefetch tasks for collections.
@@ -24,6 +32,10 @@ func startPrefetcher(ctx context.Context, deps prefetchDeps, collections map[str
 		meta: make(map[string]*types.GalaxyCollectionVersionInfo),
 		errs: make(map[string]error),
 		done: make(map[string]chan struct{}),
+
+		downloads:    deps.downloads,
+		hashes:       deps.hashes,
+		fingerprints: deps.fingerprints,
 	}
 	if cfg == nil || cfg.NoCache || artifacts == nil {
 		return p
@@ -47,17 +59,20 @@ func buildPrefetchTasks(
 ) []collection {
 	cfg := deps.cfg
 	st := deps.st
-	artifacts := deps.artifacts
-	tasks := make([]collection, 0, len(collections))
+	candidates := make([]collection, 0, len(collections))
 	for _, col := range collections {
-		if !isGalaxyType(col.Type) {
+		if !isGalaxyType(col.Type) || col.isLocalArtifact() {
 			continue
 		}
-		installPath := filepath.Join(cfg.DownloadPath, "ansible_collections", col.Namespace, col.Name)
-		if canSkipInstall(cfg, col, installPath, st) {
+		if canSkipInstall(cfg, col, col.installDir(cfg), st, deps.fingerprints[col.collectionsPath(cfg)]) {
 			continue
 		}
-		if ok, err := artifacts.Has(ctx, artifactKey(col)); err == nil && ok {
+		candidates = append(candidates, col)
+	}
+	cached := cachedArtifacts(ctx, deps.artifacts, candidates, cfg.DownloadWorkerCount())
+	tasks := make([]collection, 0, len(candidates))
+	for i, col := range candidates {
+		if cached[i] {
 			continue
 		}
 		p.register(col.key())
@@ -66,6 +81,23 @@ func buildPrefetchTasks(
 	return tasks
 }
 
+// cachedArtifacts checks artifact existence for cols concurrently, bounded by workers,
+// so remote stores do not pay one sequential round trip per collection.
+func cachedArtifacts(ctx context.Context, artifacts cacheManager.ArtifactStore, cols []collection, workers int) []bool {
+	cached := make([]bool, len(cols))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(workers, 1))
+	for i, col := range cols {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			cached[i] = findCachedArtifact(ctx, artifacts, col) != ""
+		})
+	}
+	wg.Wait()
+	return cached
+}
+
 func makeTaskChannel(tasks []collection) chan collection {
 	taskCh := make(chan collection, len(tasks))
 	for _, col := range tasks {
@@ -82,7 +114,7 @@ func startPrefetchWorkers(
 	taskCh chan collection,
 ) {
 	cfg := deps.cfg
-	for range max(cfg.Workers, 1) {
+	for range cfg.DownloadWorkerCount() {
 		go func() {
 			for col := range taskCh {
 				meta, err := prefetchOne(ctx, deps, col)
@@ -101,15 +133,13 @@ func prefetchOne(
 	if err != nil {
 		return nil, err
 	}
-	key := artifactKey(col)
-	ok, statErr := deps.artifacts.Has(ctx, key)
-	if statErr != nil {
-		return meta, statErr
-	}
-	if ok {
+	if findCachedArtifact(ctx, deps.artifacts, col) != "" {
 		return meta, nil
 	}
-	_, err = downloadCollectionToCache(ctx, newInstallDeps(deps.cfg, deps.runtime, deps.st, deps.artifacts, nil), key, meta, true)
+	download := newInstallDeps(deps.cfg, deps.runtime, deps.st, deps.artifacts, nil)
+	download.downloads = deps.downloads
+	download.hashes = deps.hashes
+	_, err = downloadCollectionToCache(ctx, download, col, meta, true)
 	return meta, err
 }
 
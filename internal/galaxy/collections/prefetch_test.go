@@ -0,0 +1,52 @@
+package collections
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+)
+
+type slowHasStore struct {
+	cacheManager.ArtifactStore
+
+	mu      sync.Mutex
+	active  int
+	peak    int
+	present map[string]bool
+}
+
+func (s *slowHasStore) Has(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	s.active++
+	s.peak = max(s.peak, s.active)
+	s.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+	return s.present[key], nil
+}
+
+func TestCachedArtifactsChecksConcurrently(t *testing.T) {
+	t.Parallel()
+	cols := make([]collection, 0, 12)
+	for i := range 12 {
+		cols = append(cols, collection{Namespace: "ns", Name: "c" + strconv.Itoa(i), Version: "1.0.0"})
+	}
+	store := &slowHasStore{present: map[string]bool{artifactKey(cols[3]): true}}
+
+	cached := cachedArtifacts(context.Background(), store, cols, 4)
+
+	for i, ok := range cached {
+		if ok != (i == 3) {
+			t.Fatalf("cached[%d] = %v", i, ok)
+		}
+	}
+	if store.peak < 2 || store.peak > 4 {
+		t.Fatalf("expected bounded concurrent checks, peak %d", store.peak)
+	}
+}
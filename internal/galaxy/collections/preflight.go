@@ -0,0 +1,131 @@
+package collections
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// OfflineGap is one cached object an offline install would need but not find.
+type OfflineGap struct {
+	// Name is the collection as namespace.name@version.
+	Name string
+	// Kind is "metadata" for a cached API response or "artifact" for a tarball.
+	Kind string
+	// Object is the API URL or the artifact cache key.
+	Object string
+}
+
+// Preflight reports what an offline 'install --no-deps --lockfile lockPath'
+// of the configured requirements would miss in the cache backend, and fails
+// with helpers.ErrOfflineGaps when anything is missing.
+func Preflight(ctx context.Context, cfg *config.Config, runtime *infra.Infra, lockPath string) error {
+	runtime.Output.Printf("🔎 preflight %s against %s", cfg.RequirementsName(), lockPath)
+	checked, gaps, err := OfflineGaps(ctx, cfg, runtime, lockPath)
+	if err != nil {
+		return err
+	}
+	for _, gap := range gaps {
+		runtime.Output.Errorf("%s: %s %s missing", gap.Name, gap.Kind, gap.Object)
+	}
+	if len(gaps) > 0 {
+		return fmt.Errorf("%w: %d objects missing", helpers.ErrOfflineGaps, len(gaps))
+	}
+	runtime.Output.PersistentPrintf("🤩 %d collections can be installed offline", checked)
+	return nil
+}
+
+// OfflineGaps returns how many collections of the locked closure of the
+// configured requirements were checked and the cached objects an offline
+// install of them lacks: the root and version metadata loadCollectionMetadata
+// reads and the artifact tarball. Exact versions are served from the cache
+// without revalidation, so a present object is enough.
+func OfflineGaps(ctx context.Context, cfg *config.Config, runtime *infra.Infra, lockPath string) (int, []OfflineGap, error) {
+	requested, _, err := loadConfiguredRequirements(cfg)
+	if err != nil {
+		return 0, nil, err
+	}
+	roots := make([]collection, 0, len(requested))
+	for _, root := range requested {
+		if normalizeType(root.Type) == gitType {
+			runtime.Output.Printf("⚠️ skipping %s, a git collection is built from its repository", root.Name)
+			continue
+		}
+		roots = append(roots, root)
+	}
+	locked := *cfg
+	locked.Lockfile = lockPath
+	resolved, _, err := resolveFromLockfile(&locked, roots)
+	if err != nil {
+		return 0, nil, err
+	}
+	cols := make([]collection, 0, len(resolved))
+	for _, fqdn := range slices.Sorted(maps.Keys(resolved)) {
+		col := resolved[fqdn]
+		if col.isLocalArtifact() {
+			continue
+		}
+		if !isGalaxyType(col.Type) {
+			runtime.Output.Printf("⚠️ skipping %s, a %s collection is fetched from its source", fqdn, col.Type)
+			continue
+		}
+		cols = append(cols, col)
+	}
+
+	state, err := openInstallState(ctx, cfg, runtime)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		_ = state.release()
+	}()
+	defer func() {
+		_ = state.backend.Close(context.WithoutCancel(ctx))
+	}()
+
+	var gaps []OfflineGap
+	cached := cachedArtifacts(ctx, state.backend.Artifacts(), cols, cfg.DownloadWorkerCount())
+	for i, col := range cols {
+		gaps = append(gaps, metadataGaps(cfg, state.store, col)...)
+		if !cached[i] {
+			gaps = append(gaps, OfflineGap{Name: col.key(), Kind: "artifact", Object: artifactKey(col)})
+		}
+	}
+	return len(cols), gaps, nil
+}
+
+// metadataGaps returns the metadata of col missing from st. Offline, the
+// first root metadata candidate decides: a miss there is a failed request,
+// not a 404 that moves on to the next candidate.
+func metadataGaps(cfg *config.Config, st *store.Store, col collection) []OfflineGap {
+	candidates := rootMetadataURLCandidates(cfg, col)
+	if len(candidates) == 0 {
+		return []OfflineGap{{Name: col.key(), Kind: "metadata", Object: "no server configured"}}
+	}
+	body, ok := cacheManager.CachedAPIBody(st, candidates[0])
+	if !ok {
+		return []OfflineGap{{Name: col.key(), Kind: "metadata", Object: candidates[0]}}
+	}
+	var root rootMetadata
+	if err := json.Unmarshal(body, &root); err != nil {
+		return []OfflineGap{{Name: col.key(), Kind: "metadata", Object: candidates[0]}}
+	}
+	versionsURL := normalizeVersionsURL(col.Source, root.VersionsURL)
+	if !strings.HasSuffix(versionsURL, "/") {
+		versionsURL += "/"
+	}
+	versionURL := normalizeVersionsURL(col.Source, versionsURL+col.Version+"/")
+	if _, ok := cacheManager.CachedAPIBody(st, versionURL); !ok {
+		return []OfflineGap{{Name: col.key(), Kind: "metadata", Object: versionURL}}
+	}
+	return nil
+}
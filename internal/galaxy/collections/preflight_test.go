@@ -0,0 +1,60 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestOfflineGapsReportsMissingObjects(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{
+		Server:      "https://galaxy.invalid",
+		CacheDir:    t.TempDir(),
+		Workers:     2,
+		HealthCheck: config.HealthCheckOff,
+	}
+	runtime := infra.New(progress.New(false, true), &http.Client{Transport: offlineTransport{}})
+	if err := GenerateCache(context.Background(), cfg, runtime, GenCacheOptions{Collections: 2, Versions: 1}); err != nil {
+		t.Fatalf("GenerateCache: %v", err)
+	}
+	lockPath := filepath.Join(t.TempDir(), helpers.InstallManifestFile)
+	data := fmt.Sprintf(`{"schema_version":%d,
+"graph":{"bench.c000000@1.0.0":[],"bench.c000001@1.0.0":["bench.gone@1.0.0"],"bench.gone@1.0.0":[]},
+"collections":{"bench.c000000":{"version":"1.0.0"},"bench.c000001":{"version":"1.0.0"},"bench.gone":{"version":"1.0.0"}}}`,
+		helpers.InstallManifestSchemaVersion)
+	if err := os.WriteFile(lockPath, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cfg.RequirementsData = []byte("collections:\n  - name: bench.c000000\n")
+	if err := Preflight(context.Background(), cfg, runtime, lockPath); err != nil {
+		t.Fatalf("Preflight: %v", err)
+	}
+
+	cfg.RequirementsData = []byte("collections:\n  - name: bench.c000001\n")
+	checked, gaps, err := OfflineGaps(context.Background(), cfg, runtime, lockPath)
+	if err != nil {
+		t.Fatalf("OfflineGaps: %v", err)
+	}
+	gone := collection{Namespace: genCacheNamespace, Name: "gone", Version: "1.0.0", Source: cfg.Server}
+	want := []OfflineGap{
+		{Name: gone.key(), Kind: "metadata", Object: rootMetadataURLCandidates(cfg, gone)[0]},
+		{Name: gone.key(), Kind: "artifact", Object: artifactKey(gone)},
+	}
+	if checked != 2 || len(gaps) != len(want) || gaps[0] != want[0] || gaps[1] != want[1] {
+		t.Fatalf("expected 2 collections checked with gaps %+v, got %d and %+v", want, checked, gaps)
+	}
+	if err := Preflight(context.Background(), cfg, runtime, lockPath); !errors.Is(err, helpers.ErrOfflineGaps) {
+		t.Fatalf("expected ErrOfflineGaps, got %v", err)
+	}
+}
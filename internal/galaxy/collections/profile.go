@@ -0,0 +1,69 @@
+package collections
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// Install phases profiled with --profile; each gets <phase>.cpu.pprof and
+// <phase>.heap.pprof in the profile directory.
+const (
+	profileSnapshotLoad = "snapshot-load"
+	profileResolve      = "resolve"
+	profileExtract      = "extract"
+	profileSnapshotSave = "snapshot-save"
+)
+
+// profilePhase starts a CPU profile of one install phase when --profile is
+// set and returns the function that stops it and writes a heap profile.
+// Profiling failures are reported, never returned: a profile must not fail
+// the install it measures.
+func profilePhase(cfg *config.Config, runtime *infra.Infra, phase string) func() {
+	if cfg.Profile == "" {
+		return func() {}
+	}
+	if err := os.MkdirAll(cfg.Profile, helpers.DirMod); err != nil {
+		runtime.Output.Printf("⚠️ Failed to profile %s: %v", phase, err)
+		return func() {}
+	}
+	cpuPath := filepath.Join(cfg.Profile, phase+".cpu.pprof")
+	//nolint:gosec // the profile directory is chosen by the user.
+	cpu, err := os.Create(cpuPath)
+	if err != nil {
+		runtime.Output.Printf("⚠️ Failed to profile %s: %v", phase, err)
+		return func() {}
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		_ = cpu.Close()
+		_ = os.Remove(cpuPath)
+		runtime.Output.Printf("⚠️ Failed to profile %s: %v", phase, err)
+		return func() {}
+	}
+	return func() {
+		pprof.StopCPUProfile()
+		_ = cpu.Close()
+		if err := writeHeapProfile(filepath.Join(cfg.Profile, phase+".heap.pprof")); err != nil {
+			runtime.Output.Printf("⚠️ Failed to write %s heap profile: %v", phase, err)
+		}
+		runtime.Output.Debugf("profiled %s into %s", phase, cfg.Profile)
+	}
+}
+
+// writeHeapProfile writes the heap profile as of the last garbage collection to path.
+func writeHeapProfile(path string) error {
+	//nolint:gosec // the profile directory is chosen by the user.
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
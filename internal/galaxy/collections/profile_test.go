@@ -0,0 +1,29 @@
+package collections
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestProfilePhaseWritesProfiles(t *testing.T) {
+	t.Parallel()
+	dir := filepath.Join(t.TempDir(), "profiles")
+	cfg := &config.Config{Profile: dir}
+	stop := profilePhase(cfg, infra.New(progress.New(false, true), http.DefaultClient), profileResolve)
+	stop()
+	for _, name := range []string{"resolve.cpu.pprof", "resolve.heap.pprof"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+		if info.Size() == 0 {
+			t.Fatalf("expected %s to hold a profile", name)
+		}
+	}
+}
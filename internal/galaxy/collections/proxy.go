@@ -0,0 +1,35 @@
+package collections
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// Metadata returns the Galaxy API response for rawURL through the API cache
+// of the session, under the same key and policy the resolver uses, so other
+// tools asking for it share and fill the cache.
+func (s *Session) Metadata(ctx context.Context, cfg *config.Config, runtime *infra.Infra, rawURL string) ([]byte, error) {
+	var raw json.RawMessage
+	policy := cachePolicyForConstraint(cfg, isVersionURL(rawURL))
+	if err := cacheManager.FetchJSONWithCachePolicy(ctx, runtime.ClientFor(rawURL), rawURL, s.state.store, &raw, policy); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// isVersionURL reports whether rawURL is the metadata of one collection
+// version, which does not change once published.
+func isVersionURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.RawQuery != "" {
+		return false
+	}
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	return len(parts) >= 2 && parts[len(parts)-2] == "versions"
+}
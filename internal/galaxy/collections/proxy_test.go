@@ -0,0 +1,60 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestSessionMetadataSharesAPICache(t *testing.T) {
+	t.Parallel()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = fmt.Fprintf(w, `{"version":"1.0.0","href":%q}`, r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+	cfg := &config.Config{Server: srv.URL, CacheDir: t.TempDir(), Workers: 1}
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	session, err := OpenSession(context.Background(), cfg, runtime)
+	if err != nil {
+		t.Fatalf("OpenSession: %v", err)
+	}
+	t.Cleanup(func() { _ = session.Close(context.Background()) })
+
+	target := srv.URL + "/api/v3/collections/a/b/versions/1.0.0/"
+	for range 2 {
+		body, err := session.Metadata(context.Background(), cfg, runtime, target)
+		if err != nil {
+			t.Fatalf("Metadata: %v", err)
+		}
+		if want := `{"version":"1.0.0","href":"/api/v3/collections/a/b/versions/1.0.0/"}`; string(body) != want {
+			t.Fatalf("unexpected body %s", body)
+		}
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected the second lookup from the cache, got %d upstream hits", got)
+	}
+}
+
+func TestIsVersionURL(t *testing.T) {
+	t.Parallel()
+	cases := map[string]bool{
+		"https://g/api/v3/collections/a/b/versions/1.0.0/":     true,
+		"https://g/api/v3/collections/a/b/versions/":           false,
+		"https://g/api/v3/collections/a/b/versions/?limit=100": false,
+		"https://g/api/v3/collections/a/b/":                    false,
+	}
+	for rawURL, want := range cases {
+		if got := isVersionURL(rawURL); got != want {
+			t.Fatalf("isVersionURL(%q) = %v, want %v", rawURL, got, want)
+		}
+	}
+}
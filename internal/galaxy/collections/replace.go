@@ -0,0 +1,185 @@
+package collections
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+// rootMetadata is the root collection metadata together with the
+// replaced_by hint some hubs publish for renamed collections.
+type rootMetadata struct {
+	types.GalaxyCollection
+
+	// ReplacedBy is the namespace.name of the successor, if any.
+	ReplacedBy string
+}
+
+// replacedByHint reads the replaced_by field of a root metadata payload.
+func replacedByHint(raw []byte) string {
+	var hint struct {
+		ReplacedBy string `json:"replaced_by"`
+	}
+	if json.Unmarshal(raw, &hint) != nil {
+		return ""
+	}
+	return strings.TrimSpace(hint.ReplacedBy)
+}
+
+// replacedError reports a collection the server names a successor for
+// during resolution.
+type replacedError struct {
+	from string
+	to   string
+}
+
+// Error implements the error interface.
+func (e *replacedError) Error() string {
+	return fmt.Sprintf("%s: %s by %s", helpers.ErrCollectionReplaced, e.from, e.to)
+}
+
+// Unwrap returns ErrCollectionReplaced.
+func (e *replacedError) Unwrap() error {
+	return helpers.ErrCollectionReplaced
+}
+
+// replacements maps renamed collections to their successors: the
+// --replacements-file entries plus the hints servers gave during this run.
+type replacements struct {
+	runtime *infra.Infra
+
+	mu     sync.Mutex
+	byFQDN map[string]config.Replacement
+	warned map[string]bool
+}
+
+// newReplacements starts from the configured replacements.
+func newReplacements(cfg *config.Config, runtime *infra.Infra) *replacements {
+	r := &replacements{
+		runtime: runtime,
+		byFQDN:  make(map[string]config.Replacement, len(cfg.Replacements)),
+		warned:  make(map[string]bool),
+	}
+	maps.Copy(r.byFQDN, cfg.Replacements)
+	return r
+}
+
+// learn records a successor hinted by a server. It reports false when fqdn
+// already has a successor, so a hint can never restart resolution twice.
+func (r *replacements) learn(fqdn, successor string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byFQDN[fqdn]; ok {
+		return false
+	}
+	r.byFQDN[fqdn] = config.Replacement{Name: successor}
+	return true
+}
+
+// successor follows the replacements of fqdn to the collection that is
+// still published. It reports false when fqdn is not replaced.
+func (r *replacements) successor(fqdn string) (config.Replacement, bool, error) {
+	if r == nil {
+		return config.Replacement{}, false, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	to, ok := r.byFQDN[fqdn]
+	if !ok {
+		return config.Replacement{}, false, nil
+	}
+	seen := map[string]bool{fqdn: true}
+	for {
+		if seen[to.Name] {
+			return config.Replacement{}, false, fmt.Errorf("%w: %s -> %s", helpers.ErrReplacementCycle, fqdn, to.Name)
+		}
+		seen[to.Name] = true
+		next, ok := r.byFQDN[to.Name]
+		if !ok {
+			break
+		}
+		to = next
+	}
+	if !r.warned[fqdn] {
+		r.warned[fqdn] = true
+		r.runtime.Output.Printf("⚠️ %s is replaced by %s, resolving the successor instead", fqdn, to.Name)
+	}
+	return to, true, nil
+}
+
+// replaceRoots renames replaced roots to their successors in place, so the
+// caller finds them under the new name in the resolution.
+func replaceRoots(deps collectionDeps, roots []collection) error {
+	for i, root := range roots {
+		if root.isLocalArtifact() {
+			continue
+		}
+		to, ok, err := deps.replacements.successor(root.Namespace + "." + root.Name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		namespace, name, _ := helpers.SplitFQDN(to.Name)
+		roots[i].Namespace, roots[i].Name = namespace, name
+		roots[i].Version, roots[i].Constraint = to.Version, to.Version
+	}
+	return nil
+}
+
+// replaceDependencies returns depMap with replaced collections swapped for
+// their successors. A successor the parent already depends on keeps its own
+// constraint.
+func replaceDependencies(deps collectionDeps, depMap map[string]string) (map[string]string, error) {
+	var out map[string]string
+	for dep := range depMap {
+		to, ok, err := deps.replacements.successor(dep)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = maps.Clone(depMap)
+		}
+		delete(out, dep)
+		if _, exists := depMap[to.Name]; !exists {
+			out[to.Name] = to.Version
+		}
+	}
+	if out == nil {
+		return depMap, nil
+	}
+	return out, nil
+}
+
+// withReplacements folds the configured replacements into a requirements
+// hash, so stored resolutions made under a different mapping are not reused.
+func withReplacements(cfg *config.Config, reqHash string) string {
+	if len(cfg.Replacements) == 0 {
+		return reqHash
+	}
+	var b strings.Builder
+	b.WriteString(reqHash)
+	for _, from := range slices.Sorted(maps.Keys(cfg.Replacements)) {
+		to := cfg.Replacements[from]
+		fmt.Fprintf(&b, "|replace=%s>%s:%s", from, to.Name, to.Version)
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,127 @@
+package collections
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+// replaceIndex is a collection on the test server: its single 1.0.0 release
+// with dependencies, and an optional replaced_by hint.
+type replaceIndex struct {
+	deps       map[string]string
+	replacedBy string
+}
+
+// newReplaceServer serves root and version metadata for index.
+func newReplaceServer(t *testing.T, index map[string]replaceIndex) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v3/collections/"), "/"), "/")
+		if len(parts) < 2 {
+			http.NotFound(w, r)
+			return
+		}
+		col, ok := index[parts[0]+"."+parts[1]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		base := "/api/v3/collections/" + parts[0] + "/" + parts[1] + "/versions/"
+		var body any
+		switch len(parts) {
+		case 2:
+			body = map[string]any{
+				"versions_url":    base,
+				"highest_version": map[string]string{"version": "1.0.0", "href": base + "1.0.0/"},
+				"replaced_by":     col.replacedBy,
+			}
+		case 3:
+			body = map[string]any{"data": []map[string]string{{"version": "1.0.0"}}, "links": map[string]any{}}
+		default:
+			body = map[string]any{"version": "1.0.0", "metadata": map[string]any{"dependencies": col.deps}}
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestResolveFollowsConfiguredReplacements(t *testing.T) {
+	t.Parallel()
+	srv := newReplaceServer(t, map[string]replaceIndex{
+		"a.b":      {deps: map[string]string{"old.dep": ">=1.0.0"}},
+		"new.root": {},
+		"new.dep":  {},
+	})
+	cfg := &config.Config{
+		Server:           srv.URL,
+		Workers:          2,
+		HealthCheck:      config.HealthCheckOff,
+		RequirementsData: []byte("collections:\n  - name: a.b\n  - name: old.root\n    version: 0.1.0\n"),
+		Replacements: map[string]config.Replacement{
+			"old.root": {Name: "new.root"},
+			"old.dep":  {Name: "new.dep", Version: ">=1.0.0"},
+		},
+	}
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	got, err := ResolveRequirements(context.Background(), cfg, runtime, store.New())
+	if err != nil {
+		t.Fatalf("ResolveRequirements: %v", err)
+	}
+	want := map[string]string{"a.b": "1.0.0", "new.root": "1.0.0", "new.dep": "1.0.0"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for fqdn, version := range want {
+		if got[fqdn] != version {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestResolveFollowsServerReplacedBy(t *testing.T) {
+	t.Parallel()
+	srv := newReplaceServer(t, map[string]replaceIndex{
+		"a.b":                  {deps: map[string]string{"community.kubernetes": "*"}},
+		"community.kubernetes": {replacedBy: "kubernetes.core"},
+		"kubernetes.core":      {},
+	})
+	cfg := &config.Config{
+		Server:           srv.URL,
+		Workers:          2,
+		HealthCheck:      config.HealthCheckOff,
+		RequirementsData: []byte("collections:\n  - name: community.kubernetes\n  - name: a.b\n"),
+	}
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	got, err := ResolveRequirements(context.Background(), cfg, runtime, store.New())
+	if err != nil {
+		t.Fatalf("ResolveRequirements: %v", err)
+	}
+	if _, ok := got["community.kubernetes"]; ok || got["kubernetes.core"] != "1.0.0" || len(got) != 2 {
+		t.Fatalf("expected kubernetes.core in place of community.kubernetes, got %v", got)
+	}
+}
+
+func TestReplacementCycle(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{Replacements: map[string]config.Replacement{
+		"a.b": {Name: "c.d"},
+		"c.d": {Name: "a.b"},
+	}}
+	deps := newCollectionDeps(cfg, infra.New(progress.New(false, true), http.DefaultClient), store.New())
+	roots := []collection{{Namespace: "a", Name: "b"}}
+	if err := replaceRoots(deps, roots); !errors.Is(err, helpers.ErrReplacementCycle) {
+		t.Fatalf("expected ErrReplacementCycle, got %v", err)
+	}
+}
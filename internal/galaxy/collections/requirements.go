@@ -1,24 +1,63 @@
 package collections
 
-import "github.com/greeddj/go-galaxy/internal/galaxy/requirements"
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/requirements"
+)
+
+// loadConfiguredRequirements loads the requirements given inline or on stdin,
+// or else from cfg.RequirementsFile. Relative install_path values of inline
+// requirements are resolved against the working directory.
+func loadConfiguredRequirements(cfg *config.Config) ([]collection, bool, error) {
+	if cfg.RequirementsData == nil {
+		return loadRequirements(cfg.RequirementsFile, cfg.Server, cfg.Strict)
+	}
+	reqs, rolesFound, err := requirements.ParseCollections(cfg.RequirementsData, cfg.Server, cfg.Strict)
+	if err == nil {
+		reqs, err = requirements.Select(reqs, requirements.CurrentEnvironment())
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", cfg.RequirementsName(), err)
+	}
+	return requirementsToCollections(reqs, "."), rolesFound, nil
+}
 
 // loadRequirements parses collection requirements into internal structs.
-func loadRequirements(path, defaultSource string) ([]collection, bool, error) {
-	reqs, rolesFound, err := requirements.LoadCollections(path, defaultSource)
+// Relative install_path values are resolved against the requirements file directory.
+func loadRequirements(path, defaultSource string, strict bool) ([]collection, bool, error) {
+	reqs, rolesFound, err := requirements.LoadCollections(path, defaultSource, strict)
 	if err != nil {
 		return nil, false, err
 	}
+	// entries whose when: condition fails on this runner are not requested
+	if reqs, err = requirements.Select(reqs, requirements.CurrentEnvironment()); err != nil {
+		return nil, false, fmt.Errorf("%s: %w", path, err)
+	}
+	return requirementsToCollections(reqs, filepath.Dir(path)), rolesFound, nil
+}
+
+// requirementsToCollections converts parsed requirements, resolving relative
+// install_path values against baseDir.
+func requirementsToCollections(reqs requirements.Collections, baseDir string) []collection {
 	collections := make([]collection, 0, len(reqs))
 	for _, req := range reqs {
+		installPath := req.InstallPath
+		if installPath != "" && !filepath.IsAbs(installPath) {
+			installPath = filepath.Join(baseDir, installPath)
+		}
 		collections = append(collections, collection{
-			Namespace:  req.Namespace,
-			Name:       req.Name,
-			Version:    req.Version,
-			Source:     req.Source,
-			Signatures: req.Signatures,
-			Constraint: req.Version,
-			Type:       req.Type,
+			Namespace:   req.Namespace,
+			Name:        req.Name,
+			Version:     req.Version,
+			Source:      req.Source,
+			Signatures:  req.Signatures,
+			Constraint:  req.Version,
+			Type:        req.Type,
+			InstallPath: installPath,
 		})
 	}
-	return collections, rolesFound, nil
+	return collections
 }
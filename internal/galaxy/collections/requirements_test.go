@@ -0,0 +1,29 @@
+package collections
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+)
+
+func TestLoadConfiguredRequirementsInline(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{
+		RequirementsFile: config.RequirementsStdin,
+		RequirementsData: []byte("collections:\n  - name: a.b\n    version: '>=1.0.0'\n    install_path: vendor\n"),
+		Server:           "https://galaxy.example",
+	}
+	cols, _, err := loadConfiguredRequirements(cfg)
+	if err != nil {
+		t.Fatalf("loadConfiguredRequirements: %v", err)
+	}
+	if len(cols) != 1 || cols[0].Namespace != "a" || cols[0].Constraint != ">=1.0.0" || cols[0].InstallPath != filepath.Join(".", "vendor") {
+		t.Fatalf("unexpected collections: %+v", cols)
+	}
+
+	cfg.RequirementsData = []byte("collections: [")
+	if _, _, err := loadConfiguredRequirements(cfg); err == nil || cfg.RequirementsName() != "stdin" {
+		t.Fatalf("invalid stdin requirements must fail and be named stdin: %v", err)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"maps"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -25,6 +27,8 @@ type resolveTask struct {
 	Name        string
 	Constraints []string
 	Source      string
+	// ConstraintsBy keys the constraints by who set them, for --trace.
+	ConstraintsBy map[string]string
 }
 
 // resolveResult captures the outcome of resolving one collection.
@@ -35,35 +39,89 @@ type resolveResult struct {
 	Source    string
 	Version   string
 	Deps      map[string]string
-	Err       error
+	// DepsSource names where Deps came from: metadata or manifest.
+	DepsSource string
+	Err        error
 }
 
 // resolveCollectionsInternal resolves versions and dependencies for roots.
+// Replaced roots are renamed to their successors in place. A server hint
+// that a collection was replaced restarts resolution with the successor.
 func resolveCollectionsInternal(
 	ctx context.Context,
 	deps collectionDeps,
 	roots []collection,
 	allowSnapshot bool,
 	record bool,
+) (map[string]collection, map[string][]string, error) {
+	for {
+		if err := replaceRoots(deps, roots); err != nil {
+			return nil, nil, err
+		}
+		resolved, graph, err := resolveRoots(ctx, deps, roots, allowSnapshot, record)
+		var replaced *replacedError
+		if !errors.As(err, &replaced) || !deps.replacements.learn(replaced.from, replaced.to) {
+			return resolved, graph, err
+		}
+	}
+}
+
+// resolveRoots resolves roots once, from a stored resolution when allowed.
+func resolveRoots(
+	ctx context.Context,
+	deps collectionDeps,
+	roots []collection,
+	allowSnapshot bool,
+	record bool,
 ) (map[string]collection, map[string][]string, error) {
 	cfg := deps.cfg
 	st := deps.st
+	var frozen map[string]store.ResolvedEntry
+	if cfg.Frozen {
+		frozen = st.ResolvedSnapshot()
+	}
+	if cfg.NoDeps && cfg.Lockfile != "" {
+		return resolveLocked(cfg, st, roots, frozen, record)
+	}
 	if cfg.NoDeps {
+		if err := checkFrozen(cfg, frozen, roots...); err != nil {
+			return nil, nil, err
+		}
 		resolved, graph := resolveWithoutDeps(cfg, st, roots, record)
 		return resolved, graph, nil
 	}
 
 	reqSpec := buildRequirementsSpec(cfg, roots)
-	reqHash := requirementsSignatureFromSpec(reqSpec)
+	reqHash := withReplacements(cfg, withDepsSource(cfg, requirementsSignatureFromSpec(reqSpec)))
+	migrateRequirementsHash(st, reqSpec, reqHash)
 
 	snapshotAllowed := allowSnapshot && st != nil
+	push := false
 	if snapshotAllowed {
+		push = record && pullSharedResolution(ctx, deps, reqHash)
 		resolvedSnap, graphSnap, ok, err := resolveFromSnapshots(ctx, deps, roots, reqSpec, reqHash)
+		if ok && err == nil {
+			err = checkFrozen(cfg, frozen, slices.Collect(maps.Values(resolvedSnap))...)
+		}
+		if ok && err == nil && push {
+			pushSharedResolution(ctx, deps, reqHash)
+		}
 		if shouldReturnSnapshot(ok, err) {
+			if err == nil {
+				deps.trace.recordResolved(resolvedSnap, "snapshot")
+			}
 			return resolvedSnap, graphSnap, err
 		}
 	}
 
+	if resolved, graph, err := healthGate(ctx, deps, roots); resolved != nil || err != nil {
+		if err == nil {
+			deps.trace.recordResolved(resolved, "health_snapshot")
+			err = checkFrozen(cfg, frozen, slices.Collect(maps.Values(resolved))...)
+		}
+		return resolved, graph, err
+	}
+
 	state, err := newResolverState(cfg, roots)
 	if err != nil {
 		return nil, nil, err
@@ -75,10 +133,42 @@ func resolveCollectionsInternal(
 	if err != nil {
 		return nil, nil, err
 	}
-	recordResolutionIfNeeded(st, record, resolved, graph, reqHash, cfg.Server, reqSpec)
+	if err := checkFrozen(cfg, frozen, slices.Collect(maps.Values(resolved))...); err != nil {
+		return nil, nil, err
+	}
+	recordResolutionIfNeeded(st, record, resolved, graph, reqHash, snapshotServer(cfg), reqSpec)
+	if push {
+		pushSharedResolution(ctx, deps, reqHash)
+	}
 	return resolved, graph, nil
 }
 
+// checkFrozen fails under --frozen when any collection is missing from the stored
+// resolved snapshot or resolved to a different version or source.
+func checkFrozen(cfg *config.Config, frozen map[string]store.ResolvedEntry, resolved ...collection) error {
+	if !cfg.Frozen {
+		return nil
+	}
+	var changes []string
+	for _, col := range resolved {
+		fqdn := col.Namespace + "." + col.Name
+		entry, ok := frozen[fqdn]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("%s: new at %s", fqdn, col.Version))
+		case entry.Version != col.Version:
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", fqdn, entry.Version, col.Version))
+		case strings.TrimRight(entry.Source, "/") != strings.TrimRight(col.Source, "/"):
+			changes = append(changes, fmt.Sprintf("%s: source %s -> %s", fqdn, entry.Source, col.Source))
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	sort.Strings(changes)
+	return fmt.Errorf("%w: %s", helpers.ErrFrozenResolutionChanged, strings.Join(changes, "; "))
+}
+
 func shouldReturnSnapshot(ok bool, err error) bool {
 	return ok || err != nil
 }
@@ -118,7 +208,7 @@ func resolveWithoutDeps(cfg *config.Config, st *store.Store, roots []collection,
 	}
 	if record && st != nil {
 		spec := buildRequirementsSpec(cfg, roots)
-		recordResolution(st, resolved, graph, requirementsSignatureFromSpec(spec), cfg.Server, spec)
+		recordResolution(st, resolved, graph, requirementsSignatureFromSpec(spec), snapshotServer(cfg), spec)
 	}
 	return resolved, graph
 }
@@ -133,6 +223,10 @@ func resolveFromSnapshots(
 	cfg := deps.cfg
 	st := deps.st
 
+	if meta := st.MetaSnapshot(); !snapshotMatchesServer(meta, snapshotServer(cfg)) {
+		deps.runtime.Output.Debugf("snapshot servers changed: %q -> %q, resolving from scratch", meta.Server, snapshotServer(cfg))
+		return nil, nil, false, nil
+	}
 	if resolved, graph, ok := loadResolvedFromSnapshot(cfg, st, roots, reqHash); ok {
 		return resolved, graph, true, nil
 	}
@@ -224,11 +318,12 @@ func (r *resolverState) buildTasks() ([]resolveTask, error) {
 			source = r.cfg.Server
 		}
 		tasks = append(tasks, resolveTask{
-			FQDN:        fqdn,
-			Namespace:   namespace,
-			Name:        name,
-			Constraints: constraints,
-			Source:      source,
+			FQDN:          fqdn,
+			Namespace:     namespace,
+			Name:          name,
+			Constraints:   constraints,
+			Source:        source,
+			ConstraintsBy: constraintOrigins(r.depConstraints, fqdn),
 		})
 	}
 	return tasks, nil
@@ -254,10 +349,11 @@ func (r *resolverState) applyResult(res resolveResult) {
 	previous, ok := r.resolved[parentFQDN]
 	if !ok || previous.Version != res.Version {
 		r.resolved[parentFQDN] = collection{
-			Namespace: res.Namespace,
-			Name:      res.Name,
-			Version:   res.Version,
-			Source:    res.Source,
+			Namespace:  res.Namespace,
+			Name:       res.Name,
+			Version:    res.Version,
+			Source:     res.Source,
+			DepsSource: res.DepsSource,
 		}
 	}
 
@@ -348,13 +444,18 @@ func resolveBatch(ctx context.Context, deps collectionDeps, tasks []resolveTask)
 
 	var mu sync.Mutex
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, deps.cfg.Workers)
+	sem := make(chan struct{}, deps.cfg.ResolveWorkerCount())
 
 	for _, task := range tasks {
 		sem <- struct{}{}
 		wg.Go(func() {
 			defer func() { <-sem }()
+			deps.trace.record(traceEvent{Event: traceTask, Collection: task.FQDN, Server: task.Source, Constraints: task.ConstraintsBy})
 			res := resolveOne(ctx, deps, task)
+			if res.Err == nil {
+				res.Deps, res.Err = replaceDependencies(deps, res.Deps)
+			}
+			deps.trace.recordResult(res)
 			mu.Lock()
 			results = append(results, res)
 			mu.Unlock()
@@ -370,6 +471,10 @@ func resolveOne(ctx context.Context, deps collectionDeps, task resolveTask) reso
 	cfg := deps.cfg
 	st := deps.st
 
+	if isLocalArtifactSource(task.Source) {
+		return resolveLocalArtifact(deps, task)
+	}
+
 	col := collection{
 		Namespace: task.Namespace,
 		Name:      task.Name,
@@ -381,8 +486,11 @@ func resolveOne(ctx context.Context, deps collectionDeps, task resolveTask) reso
 		return resolveResult{FQDN: task.FQDN, Namespace: task.Namespace, Name: task.Name, Err: err}
 	}
 	policy := cachePolicyForConstraint(cfg, exact)
+	depsPolicy := depsCachePolicy(cfg, policy)
 	if exact {
-		if res, ok := cachedResult(task, version, st, policy); ok {
+		deps.trace.record(traceEvent{Event: traceCandidates, Collection: task.FQDN, Candidates: []string{version}, Detail: "exact"})
+		if res, ok := cachedResult(task, version, st, depsPolicy); ok {
+			deps.trace.record(traceEvent{Event: traceCacheHit, Collection: task.FQDN, Version: version, Cache: "deps_cache"})
 			return res
 		}
 	}
@@ -391,13 +499,20 @@ func resolveOne(ctx context.Context, deps collectionDeps, task resolveTask) reso
 	if err != nil {
 		return resolveResult{FQDN: task.FQDN, Namespace: task.Namespace, Name: task.Name, Err: err}
 	}
+	if successor := rootMeta.ReplacedBy; successor != "" && successor != task.FQDN {
+		if _, _, ok := helpers.SplitFQDN(successor); ok {
+			err := &replacedError{from: task.FQDN, to: successor}
+			return resolveResult{FQDN: task.FQDN, Namespace: task.Namespace, Name: task.Name, Err: err}
+		}
+	}
 
-	version, err = resolveFinalVersion(ctx, deps, task, policy, version, exact, rootMeta, versionsURL)
+	version, err = resolveFinalVersion(ctx, deps, task, policy, version, exact, &rootMeta.GalaxyCollection, versionsURL)
 	if err != nil {
 		return resolveResult{FQDN: task.FQDN, Namespace: task.Namespace, Name: task.Name, Err: err}
 	}
 
-	if res, ok := cachedResult(task, version, st, policy); ok {
+	if res, ok := cachedResult(task, version, st, depsPolicy); ok {
+		deps.trace.record(traceEvent{Event: traceCacheHit, Collection: task.FQDN, Version: version, Cache: "deps_cache"})
 		return res
 	}
 
@@ -406,7 +521,9 @@ func resolveOne(ctx context.Context, deps collectionDeps, task resolveTask) reso
 		return resolveResult{FQDN: task.FQDN, Namespace: task.Namespace, Name: task.Name, Err: err}
 	}
 
-	depMap, err := parseDependencies(extractDependencies(versionInfo), err)
+	cacheKey := fmt.Sprintf("%s.%s@%s", task.Namespace, task.Name, version)
+	chosen, depsSource := versionDependencies(deps, cacheKey, versionInfo)
+	depMap, err := parseDependencies(chosen, err)
 	if err != nil {
 		return resolveResult{
 			FQDN:      task.FQDN,
@@ -416,9 +533,8 @@ func resolveOne(ctx context.Context, deps collectionDeps, task resolveTask) reso
 		}
 	}
 
-	cacheKey := fmt.Sprintf("%s.%s@%s", task.Namespace, task.Name, version)
-	cacheDeps(st, policy, cacheKey, depMap)
-	return buildResolveResult(task, version, depMap)
+	cacheDeps(st, depsPolicy, cacheKey, depMap, depsSource)
+	return buildResolveResult(task, version, depMap, depsSource)
 }
 
 func resolveFinalVersion(
@@ -443,24 +559,28 @@ func cachedResult(task resolveTask, version string, st *store.Store, policy cach
 	if !ok {
 		return resolveResult{}, false
 	}
-	return buildResolveResult(task, version, deps), true
+	return buildResolveResult(task, version, deps, config.DepsSourceMetadata), true
 }
 
-func cacheDeps(st *store.Store, policy cacheManager.Policy, cacheKey string, deps map[string]string) {
-	if st == nil || !policy.Write {
+// cacheDeps records deps in the dependency cache when they came from version
+// metadata. A hit is reported as metadata-sourced, so dependencies that fell
+// back to MANIFEST.json are left out and read from the version metadata again.
+func cacheDeps(st *store.Store, policy cacheManager.Policy, cacheKey string, deps map[string]string, source string) {
+	if st == nil || !policy.Write || source != config.DepsSourceMetadata {
 		return
 	}
 	st.SetDepsCache(cacheKey, deps)
 }
 
-func buildResolveResult(task resolveTask, version string, deps map[string]string) resolveResult {
+func buildResolveResult(task resolveTask, version string, deps map[string]string, depsSource string) resolveResult {
 	return resolveResult{
-		FQDN:      task.FQDN,
-		Namespace: task.Namespace,
-		Name:      task.Name,
-		Source:    task.Source,
-		Version:   version,
-		Deps:      deps,
+		FQDN:       task.FQDN,
+		Namespace:  task.Namespace,
+		Name:       task.Name,
+		Source:     task.Source,
+		Version:    version,
+		Deps:       deps,
+		DepsSource: depsSource,
 	}
 }
 
@@ -478,7 +598,7 @@ func resolveRootMetadata(
 	col collection,
 	policy cacheManager.Policy,
 	label string,
-) (*types.GalaxyCollection, string, error) {
+) (*rootMetadata, string, error) {
 	runtime := deps.runtime
 	versionsURL := collectionVersionsURL(col)
 	rootMeta, err := loadRootMetadataCached(ctx, deps, col, policy)
@@ -492,13 +612,6 @@ func resolveRootMetadata(
 	return rootMeta, versionsURL, nil
 }
 
-func extractDependencies(info *types.GalaxyCollectionVersionInfo) map[string]string {
-	if len(info.Metadata.Dependencies) > 0 {
-		return info.Metadata.Dependencies
-	}
-	return info.Manifest.CollectionInfo.Dependencies
-}
-
 func parseDependencies(deps map[string]string, baseErr error) (map[string]string, error) {
 	parsedDeps := make(map[string]string)
 	for dep, constraint := range deps {
@@ -511,14 +624,17 @@ func parseDependencies(deps map[string]string, baseErr error) (map[string]string
 }
 
 // selectVersion picks the highest version that satisfies constraints.
-func selectVersion(versions, constraints []string) (string, error) {
+// Versions of equal semver precedence, such as build metadata variants of
+// one release, are ordered lexically and the smallest string wins, so the
+// pick never depends on the order the server listed them in.
+func selectVersion(sc *semverCache, versions, constraints []string) (string, error) {
 	type candidate struct {
 		version string
 		semver  *semver.Version
 	}
 	candidates := make([]candidate, 0, len(versions))
 	for _, v := range versions {
-		parsed, err := semver.NewVersion(v)
+		parsed, err := sc.version(v)
 		if err != nil {
 			continue
 		}
@@ -531,44 +647,132 @@ func selectVersion(versions, constraints []string) (string, error) {
 		return "", helpers.ErrNoSemverCandidates
 	}
 
-	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].semver.GreaterThan(candidates[j].semver)
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return compareVersionsDesc(a.semver, b.semver, a.version, b.version)
+	})
+
+	next := 0
+	return firstSatisfying(sc, constraints, func() (string, *semver.Version, bool) {
+		if next == len(candidates) {
+			return "", nil, false
+		}
+		c := candidates[next]
+		next++
+		return c.version, c.semver, true
+	})
+}
+
+// selectNormalizedVersion is selectVersion for a list in the form
+// normalizeVersions returns, as loadVersionsListCached does. The list is
+// already newest first, so versions are parsed only up to the one picked
+// rather than all of them.
+func selectNormalizedVersion(sc *semverCache, versions, constraints []string) (string, error) {
+	if len(versions) == 0 {
+		return "", helpers.ErrNoSemverCandidates
+	}
+	next := 0
+	return firstSatisfying(sc, constraints, func() (string, *semver.Version, bool) {
+		for next < len(versions) {
+			raw := versions[next]
+			next++
+			if parsed, err := sc.version(raw); err == nil {
+				return raw, parsed, true
+			}
+		}
+		return "", nil, false
 	})
+}
 
-	parsedConstraints, err := parseConstraints(constraints)
+// firstSatisfying returns the first version from next, which yields them
+// newest first, that satisfies constraints, honoring latest-N.
+func firstSatisfying(sc *semverCache, constraints []string, next func() (string, *semver.Version, bool)) (string, error) {
+	parsedConstraints, err := parseConstraints(sc, constraints)
 	if err != nil {
 		return "", err
 	}
 
-	for _, c := range candidates {
-		ok := true
+	skip := latestOffset(constraints)
+	for raw, parsed, ok := next(); ok; raw, parsed, ok = next() {
+		if skip > 0 && parsed.Prerelease() != "" {
+			continue
+		}
+		satisfied := true
 		for _, constraint := range parsedConstraints {
-			if !constraint.Check(c.semver) {
-				ok = false
+			if !constraint.Check(parsed) {
+				satisfied = false
 				break
 			}
 		}
-		if ok {
-			return c.version, nil
+		if !satisfied {
+			continue
 		}
+		if skip == 0 {
+			return raw, nil
+		}
+		skip--
 	}
 
 	return "", fmt.Errorf("%w: %v", helpers.ErrNoVersionSatisfiesConstraints, constraints)
 }
 
+// compareVersionsDesc orders versions newest first; equal precedence falls
+// back to the lexical order of the version strings.
+func compareVersionsDesc(a, b *semver.Version, rawA, rawB string) int {
+	if c := b.Compare(a); c != 0 {
+		return c
+	}
+	return strings.Compare(rawA, rawB)
+}
+
+// normalizeVersions returns the valid semantic versions among versions,
+// newest first as selectVersion orders them and without duplicates, which
+// is the form the versions cache keeps and selectNormalizedVersion reads.
+func normalizeVersions(sc *semverCache, versions []string) []string {
+	type parsed struct {
+		raw    string
+		semver *semver.Version
+	}
+	valid := make([]parsed, 0, len(versions))
+	for _, raw := range versions {
+		if v, err := sc.version(raw); err == nil {
+			valid = append(valid, parsed{raw: raw, semver: v})
+		}
+	}
+	slices.SortFunc(valid, func(a, b parsed) int {
+		return compareVersionsDesc(a.semver, b.semver, a.raw, b.raw)
+	})
+	out := make([]string, 0, len(valid))
+	for _, v := range valid {
+		out = append(out, v.raw)
+	}
+	return slices.Compact(out)
+}
+
+// latestOffset returns the largest N of any latest-N constraint, or 0 when there is none.
+// latest-N selects the Nth newest stable release that satisfies the other constraints.
+func latestOffset(constraints []string) int {
+	offset := 0
+	for _, raw := range constraints {
+		if n, ok := helpers.LatestOffset(normalizeConstraint(raw)); ok && n > offset {
+			offset = n
+		}
+	}
+	return offset
+}
+
 // constraintsSatisfiedByVersion reports whether version matches constraints.
-func constraintsSatisfiedByVersion(version string, constraints []string) (bool, error) {
+func constraintsSatisfiedByVersion(sc *semverCache, version string, constraints []string) (bool, error) {
 	if len(constraints) == 0 {
 		return true, nil
 	}
-	parsed, err := parseConstraints(constraints)
+	parsed, err := parseConstraints(sc, constraints)
 	if err != nil {
 		return false, err
 	}
 	if len(parsed) == 0 {
 		return true, nil
 	}
-	parsedVersion, err := semver.NewVersion(version)
+	parsedVersion, err := sc.version(version)
 	if err != nil {
 		return false, fmt.Errorf("invalid version %q: %w", version, err)
 	}
@@ -598,6 +802,7 @@ func loadVersionsListCached(
 	if total > limit {
 		return loadVersionsListCached(ctx, deps, versionsURL, total, policy)
 	}
+	versions = normalizeVersions(deps.semver, versions)
 	cacheVersionsList(deps.st, policy, versionsURL, versions)
 	return versions, nil
 }
@@ -622,7 +827,7 @@ func fetchVersionsPage(
 ) ([]string, int, error) {
 	url := fmt.Sprintf("%s?limit=%d&offset=0", versionsURL, limit)
 	var payload map[string]any
-	if err := fetchJSONWithCachePolicy(ctx, deps.runtime.HTTP, url, deps.st, &payload, policy); err != nil {
+	if err := fetchJSONWithCachePolicy(ctx, deps.runtime, url, deps.st, &payload, policy); err != nil {
 		return nil, 0, err
 	}
 	return parseVersionsPayload(payload)
@@ -647,13 +852,19 @@ func resolveNonExactVersion(
 ) (string, error) {
 	runtime := deps.runtime
 
-	if rootMeta != nil && rootMeta.HighestVersion.Version != "" {
-		ok, err := constraintsSatisfiedByVersion(rootMeta.HighestVersion.Version, task.Constraints)
+	if rootMeta != nil && rootMeta.HighestVersion.Version != "" && latestOffset(task.Constraints) == 0 {
+		ok, err := constraintsSatisfiedByVersion(deps.semver, rootMeta.HighestVersion.Version, task.Constraints)
 		if err != nil {
 			return "", err
 		}
 		if ok {
 			runtime.Output.Debugf("highest_version selected for %s: %s", task.FQDN, rootMeta.HighestVersion.Version)
+			deps.trace.record(traceEvent{
+				Event:      traceCandidates,
+				Collection: task.FQDN,
+				Candidates: []string{rootMeta.HighestVersion.Version},
+				Detail:     "highest_version satisfies every constraint",
+			})
 			return rootMeta.HighestVersion.Version, nil
 		}
 	}
@@ -665,18 +876,19 @@ func resolveNonExactVersion(
 	if err != nil {
 		return "", err
 	}
-	return selectVersion(versionsMeta, task.Constraints)
+	deps.trace.record(traceEvent{Event: traceCandidates, Collection: task.FQDN, Candidates: versionsMeta, Detail: "versions list"})
+	return selectNormalizedVersion(deps.semver, versionsMeta, task.Constraints)
 }
 
 // parseConstraints parses version constraints into semver constraints.
-func parseConstraints(list []string) ([]*semver.Constraints, error) {
+func parseConstraints(sc *semverCache, list []string) ([]*semver.Constraints, error) {
 	result := make([]*semver.Constraints, 0, len(list))
 	for _, raw := range list {
 		normalized := normalizeConstraint(raw)
-		if normalized == "" {
+		if _, latest := helpers.LatestOffset(normalized); normalized == "" || latest {
 			continue
 		}
-		c, err := semver.NewConstraint(normalized)
+		c, err := sc.constraint(normalized)
 		if err != nil {
 			return nil, fmt.Errorf("invalid constraint %q: %w", normalized, err)
 		}
@@ -784,9 +996,9 @@ func collectionVersionsURL(col collection) string {
 	return fmt.Sprintf("%s/api/v3/collections/%s/%s/versions/", base, col.Namespace, col.Name)
 }
 
-// normalizeConstraint trims and normalizes a version constraint.
+// normalizeConstraint trims a version constraint and expands shorthands such as 1.2.x.
 func normalizeConstraint(value string) string {
-	trimmed := strings.TrimSpace(value)
+	trimmed := helpers.ExpandConstraint(value)
 	if trimmed == "" || trimmed == "*" {
 		return ""
 	}
@@ -824,7 +1036,8 @@ func normalizeRequirementConstraint(value string) string {
 
 // requirementSpecEqual reports whether two requirement specs are equal.
 func requirementSpecEqual(a, b requirementSpec) bool {
-	if a.Constraint != b.Constraint || a.Source != b.Source || a.Type != b.Type {
+	if canonicalConstraint(a.Constraint) != canonicalConstraint(b.Constraint) ||
+		canonicalSource(a.Source) != canonicalSource(b.Source) || a.Type != b.Type {
 		return false
 	}
 	left := normalizeSignatures(a.Signatures)
@@ -845,7 +1058,7 @@ func exactVersionFromConstraints(constraints []string) (string, bool, error) {
 	exact := ""
 	for _, raw := range constraints {
 		normalized := normalizeConstraint(raw)
-		if normalized == "" {
+		if _, latest := helpers.LatestOffset(normalized); normalized == "" || latest {
 			continue
 		}
 		if after, contains := strings.CutPrefix(normalized, "="); contains {
@@ -966,7 +1179,7 @@ func tryIncrementalResolve(
 	}
 
 	unchangedRoots, changedRoots := splitRootsByChange(roots, currentSpec, prevSpec)
-	if len(unchangedRoots) == 0 || len(changedRoots) == 0 {
+	if len(unchangedRoots) == 0 {
 		return nil, nil, false, nil
 	}
 
@@ -991,10 +1204,11 @@ func tryIncrementalResolveWithSnapshot(
 		return nil, nil, false, nil
 	}
 
-	resolvedNew, graphNew, err := resolveCollectionsInternal(ctx, deps, changedRoots, false, false)
-	if err != nil {
-		return nil, nil, false, err
+	resolvedNew, graphNew, ok := resolvePinned(ctx, deps, changedRoots, preservedResolved)
+	if !ok {
+		return nil, nil, false, nil
 	}
+	deps.runtime.Output.Debugf("incremental resolve: reused %d collections, re-resolved %d", len(preservedResolved), len(resolvedNew))
 
 	mergedResolved, mergedGraph, ok := mergeResolvedGraphs(preservedResolved, preservedGraph, resolvedNew, graphNew)
 	if !ok {
@@ -1009,12 +1223,63 @@ func tryIncrementalResolveWithSnapshot(
 	}
 
 	if deps.st != nil {
-		recordResolution(deps.st, mergedResolved, mergedGraph, reqHash, deps.cfg.Server, currentSpec)
+		recordResolution(deps.st, mergedResolved, mergedGraph, reqHash, snapshotServer(deps.cfg), currentSpec)
 	}
 
 	return mergedResolved, mergedGraph, true, nil
 }
 
+// snapshotPinSource names the constraint source that pins a collection to its snapshot version.
+const snapshotPinSource = "snapshot"
+
+// resolvePinned resolves only the dirty subgraph: the changed roots and everything below them.
+// Collections shared with the preserved subgraph are pinned to their snapshot versions, and the
+// result is rejected when a pin violates a constraint from the dirty side, so the caller can
+// fall back to a full resolve instead of merging inconsistent versions.
+func resolvePinned(
+	ctx context.Context,
+	deps collectionDeps,
+	roots []collection,
+	pinned map[string]collection,
+) (map[string]collection, map[string][]string, bool) {
+	if len(roots) == 0 {
+		return map[string]collection{}, map[string][]string{}, true
+	}
+	state, err := newResolverState(deps.cfg, roots)
+	if err != nil {
+		return nil, nil, false
+	}
+	for fqdn, col := range pinned {
+		setConstraint(state.depConstraints, fqdn, snapshotPinSource, "="+col.Version)
+		if _, ok := state.sourceByFQDN[fqdn]; !ok {
+			state.sourceByFQDN[fqdn] = col.Source
+		}
+	}
+	if err := state.resolveQueue(ctx, deps); err != nil {
+		deps.runtime.Output.Debugf("incremental resolve failed, resolving from scratch: %v", err)
+		return nil, nil, false
+	}
+	for fqdn, col := range state.resolved {
+		if _, ok := pinned[fqdn]; !ok {
+			continue
+		}
+		constraints := make([]string, 0, len(state.depConstraints[fqdn]))
+		for source, constraint := range state.depConstraints[fqdn] {
+			if source != snapshotPinSource {
+				constraints = append(constraints, constraint)
+			}
+		}
+		if ok, err := constraintsSatisfiedByVersion(deps.semver, col.Version, constraints); err != nil || !ok {
+			return nil, nil, false
+		}
+	}
+	resolved, graph, err := state.buildGraph(roots)
+	if err != nil {
+		return nil, nil, false
+	}
+	return resolved, graph, true
+}
+
 func splitRootsByChange(roots []collection, currentSpec, prevSpec map[string]requirementSpec) ([]collection, []collection) {
 	unchangedRoots := make([]collection, 0, len(roots))
 	changedRoots := make([]collection, 0, len(roots))
@@ -1103,10 +1368,11 @@ func addPreservedEntry(
 		source = cfg.Server
 	}
 	preservedResolved[fqdn] = collection{
-		Namespace: namespace,
-		Name:      name,
-		Version:   version,
-		Source:    source,
+		Namespace:  namespace,
+		Name:       name,
+		Version:    version,
+		Source:     source,
+		DepsSource: entry.DepsSource,
 	}
 	return true
 }
@@ -1199,10 +1465,11 @@ func ensureResolvedFromSnapshot(
 		source = cfg.Server
 	}
 	mergedResolved[fqdn] = collection{
-		Namespace: namespace,
-		Name:      name,
-		Version:   version,
-		Source:    source,
+		Namespace:  namespace,
+		Name:       name,
+		Version:    version,
+		Source:     source,
+		DepsSource: entry.DepsSource,
 	}
 	return true
 }
@@ -1246,15 +1513,14 @@ func buildRequirementsSpec(cfg *config.Config, roots []collection) map[string]re
 }
 
 // requirementsSignatureFromSpec returns a stable signature of requirements.
+// Sources and constraints are canonicalized first, so reformatting the
+// requirements file does not invalidate the stored resolution.
 func requirementsSignatureFromSpec(spec map[string]requirementSpec) string {
 	parts := make([]string, 0, len(spec))
 	for fqdn, entry := range spec {
-		constraint := entry.Constraint
-		if constraint == "" {
-			constraint = "*"
-		}
 		signatureKey := strings.Join(normalizeSignatures(entry.Signatures), ",")
-		parts = append(parts, fmt.Sprintf("%s|%s|%s|%s|%s", fqdn, constraint, entry.Source, entry.Type, signatureKey))
+		parts = append(parts, fmt.Sprintf("%s|%s|%s|%s|%s",
+			fqdn, canonicalConstraint(entry.Constraint), canonicalSource(entry.Source), entry.Type, signatureKey))
 	}
 	sort.Strings(parts)
 	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
@@ -1268,7 +1534,7 @@ func loadResolvedFromSnapshot(
 	roots []collection,
 	reqHash string,
 ) (map[string]collection, map[string][]string, bool) {
-	if !snapshotMatchesRequirements(st, reqHash) {
+	if !snapshotMatchesRequirements(st, reqHash, snapshotServer(cfg)) {
 		return nil, nil, false
 	}
 	resolvedSnapshot, graphSnapshot, ok := loadSnapshotData(st)
@@ -1286,9 +1552,55 @@ func loadResolvedFromSnapshot(
 	return resolved, filtered, true
 }
 
-func snapshotMatchesRequirements(st *store.Store, reqHash string) bool {
+func snapshotMatchesRequirements(st *store.Store, reqHash, server string) bool {
 	meta := st.MetaSnapshot()
-	return meta.RequirementsHash != "" && meta.RequirementsHash == reqHash
+	if meta.RequirementsHash == "" || meta.RequirementsHash != reqHash {
+		return false
+	}
+	return snapshotMatchesServer(meta, server)
+}
+
+// snapshotMatchesServer reports whether the snapshot was recorded against
+// server, as snapshotServer describes it. Dependencies are resolved against
+// the default server, so a different server makes every non-root source in
+// the snapshot stale.
+func snapshotMatchesServer(meta store.SnapshotMeta, server string) bool {
+	return normalizeServerURL(meta.Server) == normalizeServerURL(server)
+}
+
+// snapshotServer describes the servers a resolution is made against: the
+// default server, followed by the galaxy_server sources in priority order
+// and the server mirrors when any are configured. Changing either makes the
+// stored resolution stale. Without them it is the server URL alone, so
+// snapshots recorded before they were tracked keep matching.
+func snapshotServer(cfg *config.Config) string {
+	id := normalizeServerURL(cfg.Server)
+	var sources []string
+	for _, src := range cfg.Sources {
+		if source := normalizeServerURL(src.URL); source != "" {
+			sources = append(sources, source)
+		}
+	}
+	if len(sources) > 0 {
+		id += " sources=" + strings.Join(sources, ",")
+	}
+	var mirrors []string
+	for _, mirror := range cfg.Mirrors {
+		if mirror = normalizeServerURL(mirror); mirror != "" {
+			mirrors = append(mirrors, mirror)
+		}
+	}
+	// Mirrors serve the same content in any order; only the set counts.
+	slices.Sort(mirrors)
+	if mirrors = slices.Compact(mirrors); len(mirrors) > 0 {
+		id += " mirrors=" + strings.Join(mirrors, ",")
+	}
+	return id
+}
+
+// normalizeServerURL trims whitespace, quotes and trailing slashes from a server URL.
+func normalizeServerURL(value string) string {
+	return strings.TrimRight(strings.TrimSpace(strings.Trim(value, "\"")), "/")
 }
 
 func buildResolvedSnapshot(cfg *config.Config, resolvedSnapshot map[string]store.ResolvedEntry) (map[string]collection, bool) {
@@ -1306,10 +1618,11 @@ func buildResolvedSnapshot(cfg *config.Config, resolvedSnapshot map[string]store
 			source = cfg.Server
 		}
 		resolved[fqdn] = collection{
-			Namespace: namespace,
-			Name:      name,
-			Version:   entry.Version,
-			Source:    source,
+			Namespace:  namespace,
+			Name:       name,
+			Version:    entry.Version,
+			Source:     source,
+			DepsSource: entry.DepsSource,
 		}
 	}
 	return resolved, true
@@ -1367,6 +1680,10 @@ func constraintSatisfied(version, constraint string) (bool, error) {
 	if normalized == "" {
 		return true, nil
 	}
+	if _, latest := helpers.LatestOffset(normalized); latest {
+		// Depends on the current version list; re-resolve.
+		return false, nil
+	}
 	v, err := semver.NewVersion(version)
 	if err != nil {
 		return false, fmt.Errorf("invalid version %q: %w", version, err)
@@ -1,11 +1,20 @@
 package collections
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"sort"
+	"strings"
 	"testing"
 
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
 	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
 )
 
 func TestBuildInstallLevels(t *testing.T) {
@@ -42,6 +51,27 @@ func TestBuildInstallLevelsCycle(t *testing.T) {
 	}
 }
 
+func BenchmarkBuildInstallLevels(b *testing.B) {
+	for _, size := range []int{100, 1000, 5000} {
+		cols := planGenCollections(GenCacheOptions{Collections: size, Versions: 1, Deps: 4, Seed: 1})
+		graph := make(map[string][]string, len(cols))
+		for _, col := range cols {
+			key := genCacheNamespace + "." + col.name + "@1.0.0"
+			graph[key] = make([]string, 0, len(col.deps))
+			for dep := range col.deps {
+				graph[key] = append(graph[key], dep+"@1.0.0")
+			}
+		}
+		b.Run(fmt.Sprintf("collections=%d", size), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := buildInstallLevels(graph); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func assertLevel(t *testing.T, got []string, want []string) {
 	t.Helper()
 	if len(got) != len(want) {
@@ -57,3 +87,116 @@ func assertLevel(t *testing.T, got []string, want []string) {
 		}
 	}
 }
+
+func TestSnapshotMatchesRequirementsServer(t *testing.T) {
+	t.Parallel()
+	st := store.New()
+	st.SetMetaRequirements("hash", "https://galaxy.example.com/")
+	if !snapshotMatchesRequirements(st, "hash", "https://galaxy.example.com") {
+		t.Fatalf("expected snapshot to match the same server")
+	}
+	if snapshotMatchesRequirements(st, "hash", "https://hub.example.com") {
+		t.Fatalf("expected snapshot mismatch on server change")
+	}
+	if snapshotMatchesRequirements(st, "other", "https://galaxy.example.com") {
+		t.Fatalf("expected snapshot mismatch on requirements change")
+	}
+}
+
+func TestSnapshotServerTracksSourcesAndMirrors(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{Server: "https://galaxy.example.com/"}
+	st := store.New()
+	st.SetMetaRequirements("hash", "https://galaxy.example.com")
+	if !snapshotMatchesRequirements(st, "hash", snapshotServer(cfg)) {
+		t.Fatal("expected a snapshot recorded against the server URL alone to keep matching")
+	}
+	withSources := *cfg
+	withSources.Sources = []config.SourceConfig{{Name: "private", URL: "https://hub.example.com/"}}
+	if snapshotMatchesRequirements(st, "hash", snapshotServer(&withSources)) {
+		t.Fatal("expected a snapshot mismatch when a source is added")
+	}
+	withMirrors := *cfg
+	withMirrors.Mirrors = []string{"https://b.example.com", "https://a.example.com/"}
+	st.SetMetaRequirements("hash", snapshotServer(&withMirrors))
+	if snapshotMatchesRequirements(st, "hash", snapshotServer(cfg)) {
+		t.Fatal("expected a snapshot mismatch when mirrors are removed")
+	}
+	reordered := withMirrors
+	reordered.Mirrors = []string{"https://a.example.com", "https://b.example.com"}
+	if !snapshotMatchesRequirements(st, "hash", snapshotServer(&reordered)) {
+		t.Fatal("expected the mirror order not to matter")
+	}
+}
+
+func TestCheckFrozen(t *testing.T) {
+	t.Parallel()
+	frozen := map[string]store.ResolvedEntry{
+		"community.general": {Version: "7.0.0", Source: "https://galaxy.ansible.com/"},
+		"ansible.utils":     {Version: "2.0.0", Source: "https://galaxy.ansible.com"},
+	}
+	same := []collection{
+		{Namespace: "community", Name: "general", Version: "7.0.0", Source: "https://galaxy.ansible.com"},
+		{Namespace: "ansible", Name: "utils", Version: "2.0.0", Source: "https://galaxy.ansible.com"},
+	}
+	cfg := &config.Config{Frozen: true}
+	if err := checkFrozen(cfg, frozen, same...); err != nil {
+		t.Fatalf("unchanged resolution should pass: %v", err)
+	}
+
+	changed := []collection{
+		{Namespace: "community", Name: "general", Version: "7.1.0", Source: "https://galaxy.ansible.com"},
+		{Namespace: "ansible", Name: "posix", Version: "1.0.0", Source: "https://galaxy.ansible.com"},
+	}
+	err := checkFrozen(cfg, frozen, changed...)
+	if !errors.Is(err, helpers.ErrFrozenResolutionChanged) {
+		t.Fatalf("expected ErrFrozenResolutionChanged, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "7.0.0 -> 7.1.0") || !strings.Contains(err.Error(), "ansible.posix: new") {
+		t.Fatalf("error should list the changes: %v", err)
+	}
+	if err := checkFrozen(&config.Config{}, nil, changed...); err != nil {
+		t.Fatalf("check must be a no-op without --frozen: %v", err)
+	}
+}
+
+func TestResolvePinned(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/collections/a/b/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"versions_url":"/api/v3/collections/a/b/versions/","highest_version":{"version":"1.0.0"}}`)
+	})
+	mux.HandleFunc("/api/v3/collections/a/b/versions/1.0.0/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"version":"1.0.0","metadata":{"dependencies":{"c.d":">=2.0.0"}}}`)
+	})
+	mux.HandleFunc("/api/v3/collections/c/d/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"versions_url":"/api/v3/collections/c/d/versions/","highest_version":{"version":"2.1.0"}}`)
+	})
+	mux.HandleFunc("/api/v3/collections/c/d/versions/", func(w http.ResponseWriter, r *http.Request) {
+		version := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v3/collections/c/d/versions/"), "/")
+		_, _ = fmt.Fprintf(w, `{"version":%q,"metadata":{"dependencies":{}}}`, version)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	deps := collectionDeps{
+		cfg:     &config.Config{Server: srv.URL, Workers: 2},
+		runtime: infra.New(progress.New(false, true), srv.Client()),
+		st:      store.New(),
+	}
+	roots := []collection{{Namespace: "a", Name: "b", Version: "1.0.0", Source: srv.URL}}
+
+	compatible := map[string]collection{"c.d": {Namespace: "c", Name: "d", Version: "2.1.0", Source: srv.URL}}
+	resolved, graph, ok := resolvePinned(context.Background(), deps, roots, compatible)
+	if !ok {
+		t.Fatal("compatible pin should resolve incrementally")
+	}
+	if resolved["c.d"].Version != "2.1.0" || len(graph["a.b@1.0.0"]) != 1 {
+		t.Fatalf("unexpected result: %+v %+v", resolved, graph)
+	}
+
+	conflicting := map[string]collection{"c.d": {Namespace: "c", Name: "d", Version: "1.0.0", Source: srv.URL}}
+	if _, _, ok := resolvePinned(context.Background(), deps, roots, conflicting); ok {
+		t.Fatal("pin violating a dirty-side constraint must fall back to a full resolve")
+	}
+}
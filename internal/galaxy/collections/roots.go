@@ -2,30 +2,29 @@ package collections
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/greeddj/go-galaxy/internal/galaxy/config"
 	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
 )
 
 // rootPreparation groups normalized root collections.
 type rootPreparation struct {
 	AllRoots    []collection
 	GalaxyRoots []collection
+	// GitRoots maps the store.GitRootLabel of each git requirement to the
+	// key it was built as.
+	GitRoots map[string]string
 }
 
 // prepareRoots normalizes and validates root requirements.
+// Duplicate requirements for the same collection are merged (see mergeRoot).
 func prepareRoots(cfg *config.Config, roots []collection) (*rootPreparation, error) {
 	prep := &rootPreparation{}
-	seen := make(map[string]collection)
-	addRoot := func(col collection) error {
-		fqdn := fmt.Sprintf("%s.%s", col.Namespace, col.Name)
-		if existing, ok := seen[fqdn]; ok {
-			return fmt.Errorf("%w for %s (type %s vs %s)", helpers.ErrDuplicateCollectionRequirement, fqdn, existing.Type, col.Type)
-		}
-		seen[fqdn] = col
-		return nil
-	}
+	merged := make([]collection, 0, len(roots))
+	seen := make(map[string]int)
 
 	for _, root := range roots {
 		root.Type = normalizeType(root.Type)
@@ -46,16 +45,143 @@ func prepareRoots(cfg *config.Config, roots []collection) (*rootPreparation, err
 		if root.Source == "" {
 			root.Source = cfg.Server
 		}
-		if err := addRoot(root); err != nil {
-			return nil, err
+		fqdn := fmt.Sprintf("%s.%s", root.Namespace, root.Name)
+		if i, ok := seen[fqdn]; ok {
+			combined, err := mergeRoot(merged[i], root)
+			if err != nil {
+				return nil, err
+			}
+			merged[i] = combined
+			continue
+		}
+		seen[fqdn] = len(merged)
+		merged = append(merged, root)
+	}
+
+	for _, root := range merged {
+		if !rootSelected(cfg, root) {
+			continue
 		}
 		prep.GalaxyRoots = append(prep.GalaxyRoots, root)
 		prep.AllRoots = append(prep.AllRoots, root)
 	}
+	if len(prep.AllRoots) == 0 && len(roots) > 0 && (len(cfg.Only) > 0 || len(cfg.Skip) > 0) {
+		return nil, helpers.ErrNoRootsSelected
+	}
 
 	return prep, nil
 }
 
+// mergeRoot combines two requirements for the same collection by intersecting their
+// constraints. It fails when the sources or install paths differ or the
+// constraints cannot both hold.
+func mergeRoot(existing, col collection) (collection, error) {
+	fqdn := existing.Namespace + "." + existing.Name
+	if strings.TrimRight(existing.Source, "/") != strings.TrimRight(col.Source, "/") {
+		return collection{}, fmt.Errorf("%w for %s (source %s vs %s)",
+			helpers.ErrDuplicateCollectionRequirement, fqdn, existing.Source, col.Source)
+	}
+	if existing.InstallPath != col.InstallPath {
+		return collection{}, fmt.Errorf("%w for %s (install_path %q vs %q)",
+			helpers.ErrDuplicateCollectionRequirement, fqdn, existing.InstallPath, col.InstallPath)
+	}
+	constraint, err := intersectConstraints(existing.Version, col.Version)
+	if err != nil {
+		return collection{}, fmt.Errorf("%w for %s: %w", helpers.ErrDuplicateCollectionRequirement, fqdn, err)
+	}
+	existing.Version = constraint
+	existing.Constraint = constraint
+	existing.Signatures = normalizeSignatures(append(append([]string(nil), existing.Signatures...), col.Signatures...))
+	return existing, nil
+}
+
+// intersectConstraints returns a constraint satisfied only by versions matching both a and b.
+func intersectConstraints(a, b string) (string, error) {
+	left, right := normalizeConstraint(a), normalizeConstraint(b)
+	switch {
+	case left == right || right == "":
+		return requirementConstraint(a, left), nil
+	case left == "":
+		return requirementConstraint(b, right), nil
+	}
+	_, leftLatest := helpers.LatestOffset(left)
+	_, rightLatest := helpers.LatestOffset(right)
+	if leftLatest || rightLatest {
+		return "", fmt.Errorf("%w: %q vs %q", helpers.ErrConflictingRootConstraints, a, b)
+	}
+	for _, pair := range [][2]string{{left, right}, {right, left}} {
+		pinned, exact, err := exactVersionFromConstraints([]string{pair[0]})
+		if err != nil {
+			return "", err
+		}
+		if !exact {
+			continue
+		}
+		ok, err := constraintSatisfied(pinned, pair[1])
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", fmt.Errorf("%w: %q vs %q", helpers.ErrConflictingRootConstraints, a, b)
+		}
+		return pair[0], nil
+	}
+	return left + ", " + right, nil
+}
+
+// requirementConstraint keeps the original spelling of a constraint unless it was normalized away.
+func requirementConstraint(raw, normalized string) string {
+	if normalized == "" {
+		return strings.TrimSpace(raw)
+	}
+	return normalized
+}
+
+// applyInstallPaths routes resolved root collections to their per-requirement install_path.
+// Dependencies stay in the default collections path. Bundles ignore overrides so the
+// archive holds the whole tree.
+func applyInstallPaths(cfg *config.Config, runtime *infra.Infra, collections map[string]collection, roots []collection) {
+	paths := make(map[string]string)
+	for _, root := range roots {
+		if root.InstallPath != "" {
+			paths[root.Namespace+"."+root.Name] = root.InstallPath
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	if cfg.Bundle != "" {
+		runtime.Output.Printf("⚠️ install_path is ignored when writing a bundle")
+		return
+	}
+	for key, col := range collections {
+		if path, ok := paths[col.Namespace+"."+col.Name]; ok {
+			col.InstallPath = path
+			collections[key] = col
+		}
+	}
+}
+
+// rootSelected applies --only and --skip glob patterns to a root's FQDN.
+// Dependencies are not filtered; they follow from the selected roots.
+func rootSelected(cfg *config.Config, root collection) bool {
+	fqdn := root.Namespace + "." + root.Name
+	if len(cfg.Only) > 0 && !matchesAny(cfg.Only, fqdn) {
+		return false
+	}
+	return !matchesAny(cfg.Skip, fqdn)
+}
+
+// matchesAny reports whether name matches any glob pattern.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // normalizeType normalizes a collection type string.
 func normalizeType(value string) string {
 	return strings.ToLower(strings.TrimSpace(value))
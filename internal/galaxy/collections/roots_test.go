@@ -0,0 +1,122 @@
+package collections
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestPrepareRootsOnlySkip(t *testing.T) {
+	t.Parallel()
+	roots := []collection{
+		{Name: "mycorp.base"},
+		{Name: "mycorp.legacy"},
+		{Name: "community.general"},
+	}
+	cfg := &config.Config{Server: "https://galaxy.ansible.com", Only: []string{"mycorp.*"}, Skip: []string{"*.legacy"}}
+
+	prep, err := prepareRoots(cfg, roots)
+	if err != nil {
+		t.Fatalf("prepareRoots: %v", err)
+	}
+	if len(prep.AllRoots) != 1 || prep.AllRoots[0].Name != "base" {
+		t.Fatalf("unexpected roots: %+v", prep.AllRoots)
+	}
+
+	cfg.Only = []string{"nothing.*"}
+	if _, err := prepareRoots(cfg, roots); !errors.Is(err, helpers.ErrNoRootsSelected) {
+		t.Fatalf("expected ErrNoRootsSelected, got %v", err)
+	}
+}
+
+func TestPrepareRootsMergesDuplicates(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{Server: "https://galaxy.ansible.com"}
+	roots := []collection{
+		{Name: "community.general", Version: ">=7.0.0"},
+		{Name: "ansible.posix"},
+		{Name: "community.general", Version: "<8.0.0", Signatures: []string{"sig"}},
+		{Name: "ansible.posix", Version: "1.5.4"},
+	}
+	prep, err := prepareRoots(cfg, roots)
+	if err != nil {
+		t.Fatalf("prepareRoots: %v", err)
+	}
+	if len(prep.AllRoots) != 2 {
+		t.Fatalf("expected 2 merged roots, got %+v", prep.AllRoots)
+	}
+	general, posix := prep.AllRoots[0], prep.AllRoots[1]
+	if general.Name != "general" || general.Version != ">=7.0.0, <8.0.0" || len(general.Signatures) != 1 {
+		t.Fatalf("unexpected merged general: %+v", general)
+	}
+	if posix.Version != "1.5.4" {
+		t.Fatalf("unexpected merged posix: %+v", posix)
+	}
+}
+
+func TestPrepareRootsRejectsConflictingDuplicates(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{Server: "https://galaxy.ansible.com"}
+	cases := map[string][]collection{
+		"pins": {
+			{Name: "community.general", Version: "7.0.0"},
+			{Name: "community.general", Version: "7.1.0"},
+		},
+		"pin outside range": {
+			{Name: "community.general", Version: ">=8.0.0"},
+			{Name: "community.general", Version: "7.1.0"},
+		},
+		"sources": {
+			{Name: "community.general"},
+			{Name: "community.general", Source: "https://mirror.example.com"},
+		},
+	}
+	for name, roots := range cases {
+		if _, err := prepareRoots(cfg, roots); !errors.Is(err, helpers.ErrDuplicateCollectionRequirement) {
+			t.Fatalf("%s: expected ErrDuplicateCollectionRequirement, got %v", name, err)
+		}
+	}
+}
+
+func TestApplyInstallPathsRoutesRootsOnly(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	reqPath := filepath.Join(dir, "requirements.yml")
+	data := "collections:\n  - name: mycorp.plugins\n    install_path: plugins\n  - name: community.general\n"
+	if err := os.WriteFile(reqPath, []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	roots, _, err := loadRequirements(reqPath, "https://galaxy.ansible.com", true)
+	if err != nil {
+		t.Fatalf("loadRequirements: %v", err)
+	}
+	if roots[0].InstallPath != filepath.Join(dir, "plugins") {
+		t.Fatalf("expected install_path relative to the requirements file, got %q", roots[0].InstallPath)
+	}
+
+	cfg := &config.Config{Server: "https://galaxy.ansible.com", DownloadPath: "/collections"}
+	prep, err := prepareRoots(cfg, roots)
+	if err != nil {
+		t.Fatalf("prepareRoots: %v", err)
+	}
+	collections := map[string]collection{
+		"mycorp.plugins@1.0.0":    {Namespace: "mycorp", Name: "plugins", Version: "1.0.0"},
+		"community.general@7.0.0": {Namespace: "community", Name: "general", Version: "7.0.0"},
+		"ansible.utils@2.0.0":     {Namespace: "ansible", Name: "utils", Version: "2.0.0"},
+	}
+	applyInstallPaths(cfg, infra.New(progress.New(false, true), nil), collections, prep.AllRoots)
+
+	want := filepath.Join(dir, "plugins", "ansible_collections", "mycorp", "plugins")
+	if got := collections["mycorp.plugins@1.0.0"].installDir(cfg); got != want {
+		t.Fatalf("plugins installDir = %s, want %s", got, want)
+	}
+	if got := collections["ansible.utils@2.0.0"].installDir(cfg); got != "/collections/ansible_collections/ansible/utils" {
+		t.Fatalf("dependency installDir = %s", got)
+	}
+}
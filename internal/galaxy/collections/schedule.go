@@ -0,0 +1,89 @@
+package collections
+
+import "context"
+
+// installScheduler dispatches graph nodes as soon as all of their
+// dependencies have finished, instead of waiting for a whole level.
+type installScheduler struct {
+	workers    int
+	pending    map[string]int
+	dependents map[string][]string
+	ready      []string
+}
+
+// newInstallScheduler indexes the nodes listed in levels. Levels only seed
+// the initial ready queue order; they do not act as barriers.
+func newInstallScheduler(graph map[string][]string, levels [][]string, workers int) *installScheduler {
+	s := &installScheduler{
+		workers:    max(workers, 1),
+		pending:    make(map[string]int),
+		dependents: make(map[string][]string),
+	}
+	for _, level := range levels {
+		for _, key := range level {
+			s.pending[key] = 0
+		}
+	}
+	for key := range s.pending {
+		for _, dep := range graph[key] {
+			if _, ok := s.pending[dep]; !ok {
+				continue
+			}
+			s.pending[key]++
+			s.dependents[dep] = append(s.dependents[dep], key)
+		}
+	}
+	for _, level := range levels {
+		for _, key := range level {
+			if s.pending[key] == 0 {
+				s.ready = append(s.ready, key)
+			}
+		}
+	}
+	return s
+}
+
+// run calls install for every node, keeping up to workers calls in flight.
+// A node is released once each dependency's install returned, whether it
+// failed or not, matching the level-based behavior. When ctx is done no new
+// nodes are started, interrupted is called once, and run returns false after
+// in-flight installs finish.
+func (s *installScheduler) run(ctx context.Context, install func(key string), interrupted func()) bool {
+	done := make(chan string)
+	ctxDone := ctx.Done()
+	running := 0
+	for {
+		for running < s.workers && len(s.ready) > 0 && ctx.Err() == nil {
+			key := s.ready[0]
+			s.ready = s.ready[1:]
+			running++
+			go func() {
+				install(key)
+				done <- key
+			}()
+		}
+		if running == 0 {
+			if ctx.Err() != nil && ctxDone != nil {
+				interrupted()
+			}
+			return ctx.Err() == nil
+		}
+		select {
+		case key := <-done:
+			running--
+			s.release(key)
+		case <-ctxDone:
+			ctxDone = nil
+			interrupted()
+		}
+	}
+}
+
+func (s *installScheduler) release(key string) {
+	for _, child := range s.dependents[key] {
+		s.pending[child]--
+		if s.pending[child] == 0 {
+			s.ready = append(s.ready, child)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package collections
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInstallSchedulerDoesNotWaitForLevel(t *testing.T) {
+	t.Parallel()
+	// slow and fast share the first level; child only depends on fast and
+	// must start while slow is still running.
+	graph := map[string][]string{
+		"slow":  {},
+		"fast":  {},
+		"child": {"fast"},
+		"top":   {"slow", "child"},
+	}
+	levels, err := buildInstallLevels(graph)
+	if err != nil {
+		t.Fatalf("buildInstallLevels: %v", err)
+	}
+
+	childStarted := make(chan struct{})
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	sched := newInstallScheduler(graph, levels, 2)
+	ok := sched.run(context.Background(), func(key string) {
+		switch key {
+		case "slow":
+			select {
+			case <-childStarted:
+			case <-time.After(5 * time.Second):
+				t.Error("child was blocked behind an unrelated slow install")
+			}
+		case "child":
+			close(childStarted)
+		}
+		mu.Lock()
+		order = append(order, key)
+		mu.Unlock()
+	}, func() {})
+	if !ok {
+		t.Fatal("run should complete")
+	}
+	if len(order) != 4 || order[3] != "top" {
+		t.Fatalf("unexpected install order: %v", order)
+	}
+}
+
+func TestInstallSchedulerStopsOnCancel(t *testing.T) {
+	t.Parallel()
+	graph := map[string][]string{"a": {}, "b": {"a"}}
+	levels, err := buildInstallLevels(graph)
+	if err != nil {
+		t.Fatalf("buildInstallLevels: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var installed []string
+	interrupted := false
+	ok := newInstallScheduler(graph, levels, 1).run(ctx, func(key string) {
+		installed = append(installed, key)
+		cancel()
+	}, func() { interrupted = true })
+	if ok || !interrupted {
+		t.Fatalf("expected interrupted run, ok=%v interrupted=%v", ok, interrupted)
+	}
+	if len(installed) != 1 || installed[0] != "a" {
+		t.Fatalf("no new installs should start after cancel: %v", installed)
+	}
+}
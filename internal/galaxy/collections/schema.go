@@ -0,0 +1,179 @@
+package collections
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+// schemaRetries bounds how many mistyped fields one payload may have dropped.
+const schemaRetries = 8
+
+// fieldAlias maps a field name used by some server variant to the name the
+// hub types expect.
+type fieldAlias struct {
+	from string
+	to   string
+}
+
+// collectionAliases covers older Galaxy v2 and AWX collection payloads.
+func collectionAliases() []fieldAlias {
+	return []fieldAlias{
+		{from: "latest_version", to: "highest_version"},
+		{from: "created", to: "created_at"},
+		{from: "modified", to: "updated_at"},
+	}
+}
+
+// versionInfoAliases covers older Galaxy v2, Pulp and AWX version payloads.
+func versionInfoAliases() []fieldAlias {
+	return []fieldAlias{
+		{from: "created", to: "created_at"},
+		{from: "modified", to: "updated_at"},
+		{from: "download", to: "download_url"},
+	}
+}
+
+// decodeGalaxyJSON decodes collection and version payloads tolerantly, so a
+// server whose schema differs from the hub types in a field we can live
+// without still resolves. Other payloads are decoded as is.
+func decodeGalaxyJSON(runtime *infra.Infra, url string, raw []byte, out any) error {
+	switch v := out.(type) {
+	case *types.GalaxyCollection:
+		return decodeTolerant(runtime, url, raw, v, collectionAliases())
+	case *rootMetadata:
+		if err := decodeTolerant(runtime, url, raw, &v.GalaxyCollection, collectionAliases()); err != nil {
+			return err
+		}
+		v.ReplacedBy = replacedByHint(raw)
+		return nil
+	case *types.GalaxyCollectionVersionInfo:
+		if err := decodeTolerant(runtime, url, raw, v, versionInfoAliases()); err != nil {
+			return err
+		}
+		if len(v.Metadata.Dependencies) == 0 && len(v.Manifest.CollectionInfo.Dependencies) > 0 {
+			// some servers only carry dependencies in the embedded MANIFEST.json
+			v.Metadata.Dependencies = v.Manifest.CollectionInfo.Dependencies
+		}
+		return nil
+	default:
+		return json.Unmarshal(raw, out)
+	}
+}
+
+// decodeTolerant decodes the JSON object raw into out after renaming aliased
+// fields, dropping unparsable timestamps and then, one by one, fields whose
+// type does not match out. Every adjustment and unknown field is reported
+// in debug output. Payloads that decode cleanly and use no aliased field
+// skip the rework, which keeps cached metadata cheap to read.
+func decodeTolerant(runtime *infra.Infra, url string, raw []byte, out any, aliases []fieldAlias) error {
+	if !mentionsAlias(raw, aliases) && json.Unmarshal(raw, out) == nil {
+		return nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+	var warnings []string
+	for _, alias := range aliases {
+		value, ok := fields[alias.from]
+		if !ok {
+			continue
+		}
+		delete(fields, alias.from)
+		if _, exists := fields[alias.to]; !exists {
+			fields[alias.to] = value
+			warnings = append(warnings, "renamed field "+alias.from+" to "+alias.to)
+		}
+	}
+	for _, name := range []string{"created_at", "updated_at"} {
+		if value, ok := fields[name].(string); ok {
+			if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+				delete(fields, name)
+				warnings = append(warnings, "dropped unparsable "+name+" "+value)
+			}
+		}
+	}
+	known := jsonFieldNames(reflect.TypeOf(out).Elem())
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if !known[name] {
+			warnings = append(warnings, "unknown field "+name)
+		}
+	}
+
+	target := reflect.ValueOf(out).Elem()
+	var err error
+	for range schemaRetries {
+		var data []byte
+		if data, err = json.Marshal(fields); err != nil {
+			return err
+		}
+		target.SetZero()
+		if err = json.Unmarshal(data, out); err == nil {
+			break
+		}
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) || !deleteFieldPath(fields, typeErr.Field) {
+			return err
+		}
+		warnings = append(warnings, "dropped field "+typeErr.Field+" of type "+typeErr.Value)
+	}
+	for _, warning := range warnings {
+		runtime.Output.Debugf("schema %s: %s", url, warning)
+	}
+	return err
+}
+
+// mentionsAlias reports whether raw may use a field name that must be renamed.
+func mentionsAlias(raw []byte, aliases []fieldAlias) bool {
+	for _, alias := range aliases {
+		if bytes.Contains(raw, []byte(`"`+alias.from+`"`)) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFieldNames returns the top-level JSON names of struct type t.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// deleteFieldPath removes the dotted path from nested JSON objects and
+// reports whether it was there.
+func deleteFieldPath(fields map[string]any, path string) bool {
+	if path == "" {
+		return false
+	}
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := fields[part].(map[string]any)
+		if !ok {
+			return false
+		}
+		fields = next
+	}
+	last := parts[len(parts)-1]
+	if _, ok := fields[last]; !ok {
+		return false
+	}
+	delete(fields, last)
+	return true
+}
@@ -0,0 +1,44 @@
+package collections
+
+import (
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+func TestDecodeGalaxyJSONTolerates(t *testing.T) {
+	t.Parallel()
+	runtime := infra.New(progress.New(false, true), nil)
+
+	var root types.GalaxyCollection
+	rootPayload := `{"namespace":"a","name":"b","versions_url":"/api/v2/collections/a/b/versions/",
+		"latest_version":{"version":"1.2.0","href":"/api/v2/collections/a/b/versions/1.2.0/"},
+		"created":"2020-01-02 03:04:05","download_count":"many","community_score":4.5}`
+	if err := decodeGalaxyJSON(runtime, "test", []byte(rootPayload), &root); err != nil {
+		t.Fatalf("decode collection: %v", err)
+	}
+	if root.HighestVersion.Version != "1.2.0" || root.VersionsURL == "" || root.Name != "b" {
+		t.Fatalf("unexpected collection: %+v", root)
+	}
+
+	var info types.GalaxyCollectionVersionInfo
+	infoPayload := `{"version":"1.2.0","download":"https://example/a-b-1.2.0.tar.gz",
+		"artifact":{"filename":"a-b-1.2.0.tar.gz","sha256":"abc","size":"1024"},
+		"namespace":"a",
+		"manifest":{"collection_info":{"dependencies":{"c.d":">=1.0.0"}}}}`
+	if err := decodeGalaxyJSON(runtime, "test", []byte(infoPayload), &info); err != nil {
+		t.Fatalf("decode version: %v", err)
+	}
+	if info.DownloadURL != "https://example/a-b-1.2.0.tar.gz" || info.Artifact.Sha256 != "abc" {
+		t.Fatalf("unexpected version info: %+v", info)
+	}
+	if info.Metadata.Dependencies["c.d"] != ">=1.0.0" {
+		t.Fatalf("dependencies must fall back to the manifest: %v", info.Metadata.Dependencies)
+	}
+
+	if err := decodeGalaxyJSON(runtime, "test", []byte(`["not","an","object"]`), &info); err == nil {
+		t.Fatal("a payload that is not an object must still fail")
+	}
+}
@@ -0,0 +1,84 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+// VersionSelection is the version the resolver picks for a constraint.
+type VersionSelection struct {
+	Name       string   `json:"name"`
+	Constraint string   `json:"constraint"`
+	Selected   string   `json:"selected"`
+	Candidates []string `json:"candidates,omitempty"`
+}
+
+// ResolveVersion applies the resolver's selection rules to one collection.
+// With all set, every version satisfying the constraint is listed, newest first.
+func ResolveVersion(
+	ctx context.Context,
+	cfg *config.Config,
+	runtime *infra.Infra,
+	st *store.Store,
+	fqdn, constraint string,
+	all bool,
+) (*VersionSelection, error) {
+	namespace, name, ok := helpers.SplitFQDN(fqdn)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", helpers.ErrInvalidCollectionName, fqdn)
+	}
+	col := collection{Namespace: namespace, Name: name, Source: cfg.Server}
+	deps := newCollectionDeps(cfg, runtime, st)
+
+	listing, err := listCollectionVersions(ctx, deps, col)
+	if err != nil {
+		return nil, err
+	}
+	constraints := []string{constraint}
+	selected, err := pickVersion(&listing.root.GalaxyCollection, listing.versions, constraints)
+	if err != nil {
+		return nil, err
+	}
+	out := &VersionSelection{
+		Name:       namespace + "." + name,
+		Constraint: normalizeRequirementConstraint(constraint),
+		Selected:   selected,
+	}
+	if all {
+		out.Candidates = make([]string, 0, len(listing.versions))
+		for _, version := range listing.versions {
+			if ok, err := constraintsSatisfiedByVersion(deps.semver, version, constraints); err == nil && ok {
+				out.Candidates = append(out.Candidates, version)
+			}
+		}
+	}
+	return out, nil
+}
+
+// pickVersion mirrors resolveFinalVersion: an exact pin wins, then the
+// server's highest_version if it satisfies, then the newest matching version.
+func pickVersion(rootMeta *types.GalaxyCollection, versions, constraints []string) (string, error) {
+	version, exact, err := exactVersionFromConstraints(constraints)
+	if err != nil {
+		return "", err
+	}
+	if exact {
+		return version, nil
+	}
+	if rootMeta != nil && rootMeta.HighestVersion.Version != "" && latestOffset(constraints) == 0 {
+		ok, err := constraintsSatisfiedByVersion(nil, rootMeta.HighestVersion.Version, constraints)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return rootMeta.HighestVersion.Version, nil
+		}
+	}
+	return selectVersion(nil, versions, constraints)
+}
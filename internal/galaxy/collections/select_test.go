@@ -0,0 +1,109 @@
+package collections
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/psvmcc/hub/pkg/types"
+)
+
+func TestPickVersionMatchesResolverRules(t *testing.T) {
+	t.Parallel()
+	root := &types.GalaxyCollection{}
+	root.HighestVersion.Version = "7.1.0"
+	versions := []string{"7.1.0", "6.5.0", "6.4.0", "5.0.0", "4.9.0"}
+
+	cases := map[string]string{
+		"":               "7.1.0",
+		"*":              "7.1.0",
+		">=5.0.0,<7.0.0": "6.5.0",
+		"=4.9.0":         "4.9.0",
+		"6.4.0":          "6.4.0",
+		"~5.0":           "5.0.0",
+		">=6.0.0":        "7.1.0",
+	}
+	for constraint, want := range cases {
+		got, err := pickVersion(root, versions, []string{constraint})
+		if err != nil {
+			t.Fatalf("pickVersion(%q): %v", constraint, err)
+		}
+		if got != want {
+			t.Fatalf("pickVersion(%q) = %s, want %s", constraint, got, want)
+		}
+	}
+}
+
+func TestPickVersionShorthands(t *testing.T) {
+	t.Parallel()
+	root := &types.GalaxyCollection{}
+	root.HighestVersion.Version = "7.1.0"
+	versions := []string{"7.1.0", "7.0.0", "6.5.0", "6.4.2", "6.4.0", "8.0.0-rc1"}
+
+	cases := map[string]string{
+		"6.4.x":    "6.4.2",
+		"6.*":      "6.5.0",
+		"~=6.4":    "6.5.0",
+		"~=6.4.0":  "6.4.2",
+		"==7.0.0":  "7.0.0",
+		"latest":   "7.1.0",
+		"latest-1": "7.0.0",
+		"latest-2": "6.5.0",
+	}
+	for constraint, want := range cases {
+		got, err := pickVersion(root, versions, []string{constraint})
+		if err != nil {
+			t.Fatalf("pickVersion(%q): %v", constraint, err)
+		}
+		if got != want {
+			t.Fatalf("pickVersion(%q) = %s, want %s", constraint, got, want)
+		}
+	}
+	got, err := pickVersion(root, versions, []string{"6.x", "latest-1"})
+	if err != nil || got != "6.4.2" {
+		t.Fatalf("latest-1 within 6.x = %s, %v; want 6.4.2", got, err)
+	}
+}
+
+func TestSelectVersionBreaksTiesLexically(t *testing.T) {
+	t.Parallel()
+	orders := [][]string{
+		{"1.0.0+build.2", "1.0.0+build.10", "1.0.0", "0.9.0"},
+		{"0.9.0", "1.0.0", "1.0.0+build.10", "1.0.0+build.2"},
+		{"1.0.0+build.10", "0.9.0", "1.0.0+build.2", "1.0.0"},
+	}
+	for _, versions := range orders {
+		got, err := selectVersion(nil, versions, []string{"*"})
+		if err != nil {
+			t.Fatalf("selectVersion(%v): %v", versions, err)
+		}
+		if got != "1.0.0" {
+			t.Fatalf("selectVersion(%v) = %s, want 1.0.0", versions, got)
+		}
+		sorted := sortVersionsDesc(versions)
+		want := []string{"1.0.0", "1.0.0+build.10", "1.0.0+build.2", "0.9.0"}
+		for i := range want {
+			if sorted[i] != want[i] {
+				t.Fatalf("sortVersionsDesc(%v) = %v, want %v", versions, sorted, want)
+			}
+		}
+	}
+}
+
+func TestNormalizeVersions(t *testing.T) {
+	t.Parallel()
+	got := normalizeVersions(newSemverCache(), []string{"1.0.0", "not-a-version", "2.0.0-rc1", "1.10.0", "1.0.0", "2.0.0", ""})
+	want := []string{"2.0.0", "2.0.0-rc1", "1.10.0", "1.0.0"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("normalizeVersions = %v, want %v", got, want)
+	}
+	for _, constraints := range [][]string{{"<2.0.0"}, {"latest-1"}, {"<2.0.0-0"}} {
+		want, err := selectVersion(nil, got, constraints)
+		if err != nil {
+			t.Fatalf("selectVersion(%v): %v", constraints, err)
+		}
+		selected, err := selectNormalizedVersion(nil, got, constraints)
+		if err != nil || selected != want {
+			t.Fatalf("selectNormalizedVersion(%v) = %s, %v; want %s", constraints, selected, err, want)
+		}
+	}
+}
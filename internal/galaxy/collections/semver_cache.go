@@ -0,0 +1,69 @@
+package collections
+
+import (
+	"sync"
+
+	"github.com/Masterminds/semver"
+)
+
+// semverCache memoizes parsed versions and constraints by their raw string.
+// A resolve run selects from the same version lists and dependency
+// constraints many times over, and parsing dominates on large graphs.
+// Parsed values are never mutated, so they are shared between workers.
+// A nil cache parses every time.
+type semverCache struct {
+	mu          sync.RWMutex
+	versions    map[string]parsedVersion
+	constraints map[string]parsedConstraint
+}
+
+type parsedVersion struct {
+	v   *semver.Version
+	err error
+}
+
+type parsedConstraint struct {
+	c   *semver.Constraints
+	err error
+}
+
+func newSemverCache() *semverCache {
+	return &semverCache{
+		versions:    make(map[string]parsedVersion),
+		constraints: make(map[string]parsedConstraint),
+	}
+}
+
+// version parses raw as a semantic version.
+func (s *semverCache) version(raw string) (*semver.Version, error) {
+	if s == nil {
+		return semver.NewVersion(raw)
+	}
+	s.mu.RLock()
+	parsed, ok := s.versions[raw]
+	s.mu.RUnlock()
+	if !ok {
+		parsed.v, parsed.err = semver.NewVersion(raw)
+		s.mu.Lock()
+		s.versions[raw] = parsed
+		s.mu.Unlock()
+	}
+	return parsed.v, parsed.err
+}
+
+// constraint parses raw as a semver constraint.
+func (s *semverCache) constraint(raw string) (*semver.Constraints, error) {
+	if s == nil {
+		return semver.NewConstraint(raw)
+	}
+	s.mu.RLock()
+	parsed, ok := s.constraints[raw]
+	s.mu.RUnlock()
+	if !ok {
+		parsed.c, parsed.err = semver.NewConstraint(raw)
+		s.mu.Lock()
+		s.constraints[raw] = parsed
+		s.mu.Unlock()
+	}
+	return parsed.c, parsed.err
+}
@@ -0,0 +1,62 @@
+package collections
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSemverCache(t *testing.T) {
+	t.Parallel()
+	sc := newSemverCache()
+	first, err := sc.version("1.2.3")
+	if err != nil {
+		t.Fatalf("version: %v", err)
+	}
+	if second, _ := sc.version("1.2.3"); second != first {
+		t.Fatal("repeated version lookups must reuse the parsed value")
+	}
+	if _, err := sc.version("not-a-version"); err == nil {
+		t.Fatal("invalid version must fail")
+	}
+	if _, err := sc.version("not-a-version"); err == nil {
+		t.Fatal("cached parse errors must be returned again")
+	}
+	c, err := sc.constraint(">=1.0.0")
+	if err != nil {
+		t.Fatalf("constraint: %v", err)
+	}
+	if again, _ := sc.constraint(">=1.0.0"); again != c {
+		t.Fatal("repeated constraint lookups must reuse the parsed value")
+	}
+
+	var none *semverCache
+	if v, err := none.version("1.2.3"); err != nil || v.String() != "1.2.3" {
+		t.Fatalf("nil cache must still parse: %v %v", v, err)
+	}
+}
+
+func BenchmarkSelectVersion(b *testing.B) {
+	versions := make([]string, 0, 3000)
+	for major := range 30 {
+		for minor := range 100 {
+			versions = append(versions, fmt.Sprintf("%d.%d.0", major, minor))
+		}
+	}
+	constraints := []string{">=10.0.0", "<20.0.0"}
+
+	b.Run("uncached", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := selectVersion(nil, versions, constraints); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		sc := newSemverCache()
+		for b.Loop() {
+			if _, err := selectVersion(sc, versions, constraints); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// Session keeps the cache backend, lock and store open across runs.
+type Session struct {
+	state *installState
+}
+
+// OpenSession opens the cache backend, takes the lock and loads the store once.
+func OpenSession(ctx context.Context, cfg *config.Config, runtime *infra.Infra) (*Session, error) {
+	state, err := initInstall(ctx, cfg, runtime)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{state: state}, nil
+}
+
+// Resolve resolves requirements against the in-memory store and returns key -> version.
+func (s *Session) Resolve(ctx context.Context, cfg *config.Config, runtime *infra.Infra) (map[string]string, error) {
+	return resolveVersions(ctx, s.state.resolveDeps(cfg, runtime), true)
+}
+
+// ResolveRequirements resolves requirements against the server alone, without
+// reusing or recording stored resolutions, and returns key -> version.
+func ResolveRequirements(ctx context.Context, cfg *config.Config, runtime *infra.Infra, st *store.Store) (map[string]string, error) {
+	return resolveVersions(ctx, newCollectionDeps(cfg, runtime, st), false)
+}
+
+// resolveVersions resolves the configured requirements and returns key -> version.
+func resolveVersions(ctx context.Context, deps collectionDeps, useSnapshot bool) (map[string]string, error) {
+	prep, err := loadRoots(ctx, deps.cfg, deps.runtime)
+	if err != nil {
+		return nil, err
+	}
+	resolved, _, err := resolveCollectionsInternal(ctx, deps, prep.AllRoots, useSnapshot, useSnapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve dependencies: %w", err)
+	}
+	versions := make(map[string]string, len(resolved))
+	for fqdn, col := range resolved {
+		versions[fqdn] = col.Version
+	}
+	return versions, nil
+}
+
+// Datasource builds a release list for a collection from the in-memory store.
+func (s *Session) Datasource(ctx context.Context, cfg *config.Config, runtime *infra.Infra, fqdn string) (*Datasource, error) {
+	return BuildDatasource(ctx, cfg, runtime, s.state.store, fqdn)
+}
+
+// Install installs requirements using the in-memory store without persisting it.
+func (s *Session) Install(ctx context.Context, cfg *config.Config, runtime *infra.Infra) error {
+	start := time.Now()
+	ctx, cancelBudget := withInstallBudget(ctx, cfg)
+	defer cancelBudget()
+	if cfg.RequirementsData == nil {
+		if err := s.state.backend.RecordProject(ctx, projectRun(cfg)); err != nil {
+			runtime.Output.Printf("⚠️ Failed to record project: %v", err)
+		}
+	}
+	plan, err := prepareInstallPlan(ctx, cfg, runtime, s.state)
+	if err != nil {
+		return err
+	}
+	failures, err := installLevels(
+		ctx,
+		cfg,
+		runtime,
+		s.state.store,
+		s.state.backend.Artifacts(),
+		plan.collections,
+		plan.graph,
+		plan.levels,
+		plan.prefetch,
+	)
+	if err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		return newInstallFailure(failures)
+	}
+	if err := writeInstallManifest(cfg, runtime, s.state.store, plan); err != nil {
+		return err
+	}
+	stampFingerprints(cfg, s.state.store, plan.collections)
+	reportBudget(cfg, runtime, s.state.store, time.Since(start))
+	runtime.Output.DebugSincef(start, "%s", "session install")
+	return nil
+}
+
+// Save persists the in-memory store.
+func (s *Session) Save(ctx context.Context) error {
+	return s.state.backend.SaveStore(ctx, s.state.store)
+}
+
+// Close persists the store, releases the lock and closes the backend.
+func (s *Session) Close(ctx context.Context) error {
+	err := s.Save(ctx)
+	if s.state.release != nil {
+		_ = s.state.release()
+	}
+	_ = s.state.backend.Close(ctx)
+	return err
+}
@@ -0,0 +1,126 @@
+package collections
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// shadowedCollection is a resolved collection that also exists, at another
+// version, in a different element of Ansible's collections search path.
+type shadowedCollection struct {
+	col          collection
+	otherPath    string
+	otherVersion string
+	// otherWins reports whether Ansible picks the other copy, because its path
+	// element comes first or the install path is not searched at all.
+	otherWins bool
+}
+
+// findShadowedCollections looks up every resolved collection in the other
+// search path elements and reports copies with a different version.
+func findShadowedCollections(cfg *config.Config, collections map[string]collection) []shadowedCollection {
+	search := make([]string, 0, len(cfg.CollectionsSearchPath))
+	for _, elem := range cfg.CollectionsSearchPath {
+		search = append(search, absPath(elem))
+	}
+
+	keys := make([]string, 0, len(collections))
+	for key := range collections {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var found []shadowedCollection
+	for _, key := range keys {
+		col := collections[key]
+		target := absPath(col.collectionsPath(cfg))
+		targetIdx := slices.Index(search, target)
+		for idx, elem := range search {
+			if elem == target {
+				continue
+			}
+			version, ok := installedVersion(filepath.Join(elem, "ansible_collections", col.Namespace, col.Name))
+			if !ok || version == col.Version {
+				continue
+			}
+			found = append(found, shadowedCollection{
+				col:          col,
+				otherPath:    elem,
+				otherVersion: version,
+				otherWins:    targetIdx < 0 || idx < targetIdx,
+			})
+		}
+	}
+	return found
+}
+
+// warnShadowedCollections prints one warning per conflicting copy.
+func warnShadowedCollections(cfg *config.Config, runtime *infra.Infra, collections map[string]collection) {
+	shadowed := findShadowedCollections(cfg, collections)
+	runtime.Output.Debugf("checked %d collections against %d search path elements", len(collections), len(cfg.CollectionsSearchPath))
+	for _, s := range shadowed {
+		fqdn := s.col.Namespace + "." + s.col.Name
+		if s.otherWins {
+			runtime.Output.Printf("⚠️ %s %s in %s shadows the installed %s; Ansible will load %s",
+				fqdn, s.otherVersion, s.otherPath, s.col.Version, s.otherVersion)
+		} else {
+			runtime.Output.Printf("⚠️ %s %s is also present in %s; the installed %s takes precedence",
+				fqdn, s.otherVersion, s.otherPath, s.col.Version)
+		}
+	}
+	if len(shadowed) > 0 {
+		runtime.Output.Printf("⚠️ %d collections also exist at other versions in the collections search path", len(shadowed))
+	}
+}
+
+// installedVersion reads the version from an installed collection's MANIFEST.json.
+func installedVersion(dir string) (string, bool) {
+	manifest, ok := readInstalledManifest(dir)
+	if !ok {
+		return "", false
+	}
+	return manifest.CollectionInfo.Version, true
+}
+
+// installedManifest is the part of an installed MANIFEST.json that list,
+// the shadow check and skip decisions read.
+type installedManifest struct {
+	CollectionInfo struct {
+		Namespace   string   `json:"namespace"`
+		Name        string   `json:"name"`
+		Version     string   `json:"version"`
+		License     []string `json:"license"`
+		LicenseFile string   `json:"license_file"`
+	} `json:"collection_info"`
+	FileManifestFile struct {
+		ChksumSHA256 string `json:"chksum_sha256"`
+	} `json:"file_manifest_file"`
+}
+
+// readInstalledManifest reads the MANIFEST.json of the collection installed
+// in dir. It reports false when there is none or it names no version.
+func readInstalledManifest(dir string) (installedManifest, bool) {
+	//nolint:gosec // dir is built from the configured collections search path.
+	data, err := os.ReadFile(filepath.Join(dir, "MANIFEST.json"))
+	if err != nil {
+		return installedManifest{}, false
+	}
+	var manifest installedManifest
+	if err := json.Unmarshal(data, &manifest); err != nil || manifest.CollectionInfo.Version == "" {
+		return installedManifest{}, false
+	}
+	return manifest, true
+}
+
+func absPath(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return filepath.Clean(p)
+}
@@ -0,0 +1,47 @@
+package collections
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+)
+
+func TestFindShadowedCollections(t *testing.T) {
+	t.Parallel()
+	user := t.TempDir()
+	project := t.TempDir()
+	system := t.TempDir()
+	writeInstalledManifest(t, user, "a", "b", "1.0.0")
+	writeInstalledManifest(t, system, "a", "b", "3.0.0")
+	writeInstalledManifest(t, system, "c", "d", "2.0.0")
+
+	cfg := &config.Config{DownloadPath: project, CollectionsSearchPath: []string{user, project, system}}
+	collections := map[string]collection{
+		"a.b@2.0.0": {Namespace: "a", Name: "b", Version: "2.0.0"},
+		"c.d@2.0.0": {Namespace: "c", Name: "d", Version: "2.0.0"},
+	}
+	found := findShadowedCollections(cfg, collections)
+	if len(found) != 2 {
+		t.Fatalf("expected two conflicting copies, got %+v", found)
+	}
+	if found[0].otherPath != user || found[0].otherVersion != "1.0.0" || !found[0].otherWins {
+		t.Fatalf("user-level copy should shadow the install: %+v", found[0])
+	}
+	if found[1].otherPath != system || found[1].otherWins {
+		t.Fatalf("system copy comes after the install path: %+v", found[1])
+	}
+}
+
+func writeInstalledManifest(t *testing.T, base, namespace, name, version string) {
+	t.Helper()
+	dir := filepath.Join(base, "ansible_collections", namespace, name)
+	if err := os.MkdirAll(dir, dirMod); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	data := []byte(`{"collection_info":{"namespace":"` + namespace + `","name":"` + name + `","version":"` + version + `"}}`)
+	if err := os.WriteFile(filepath.Join(dir, "MANIFEST.json"), data, fileMod); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
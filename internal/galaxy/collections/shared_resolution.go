@@ -0,0 +1,58 @@
+package collections
+
+import (
+	"context"
+	"time"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// pullSharedResolution seeds the store with the backend's resolution for
+// reqHash when the store holds a different one, so the snapshot path can skip
+// resolution on a runner that never saw these requirements. It reports
+// whether the backend lacked a usable resolution and one should be pushed
+// once resolution finishes.
+func pullSharedResolution(ctx context.Context, deps collectionDeps, reqHash string) bool {
+	if deps.backend == nil || deps.st == nil {
+		return false
+	}
+	if meta := deps.st.MetaSnapshot(); meta.RequirementsHash == reqHash && snapshotMatchesServer(meta, snapshotServer(deps.cfg)) {
+		return false
+	}
+	res, err := cacheManager.LoadResolution(ctx, deps.backend, reqHash)
+	if err != nil {
+		deps.runtime.Output.Debugf("shared resolution %s: %v", reqHash, err)
+		return false
+	}
+	if res == nil || res.RequirementsHash != reqHash || len(res.Resolved) == 0 || len(res.Graph) == 0 ||
+		normalizeServerURL(res.Server) != snapshotServer(deps.cfg) {
+		return true
+	}
+	deps.runtime.Output.Debugf("using shared resolution %s from %s", reqHash, res.CreatedAt.Format(time.RFC3339))
+	deps.st.SetResolvedAll(res.Resolved)
+	deps.st.SetGraphSnapshot(res.Graph)
+	deps.st.SetRequirements(res.Requirements)
+	deps.st.SetMetaRequirements(res.RequirementsHash, res.Server)
+	return false
+}
+
+// pushSharedResolution stores the resolution recorded for reqHash as its own
+// backend object. Failures only cost other runners a resolve.
+func pushSharedResolution(ctx context.Context, deps collectionDeps, reqHash string) {
+	meta := deps.st.MetaSnapshot()
+	if meta.RequirementsHash != reqHash {
+		return
+	}
+	res := &store.Resolution{
+		RequirementsHash: reqHash,
+		Server:           meta.Server,
+		CreatedAt:        time.Now().UTC(),
+		Resolved:         deps.st.ResolvedSnapshot(),
+		Graph:            deps.st.GraphSnapshot(),
+		Requirements:     deps.st.RequirementsSnapshot(),
+	}
+	if err := cacheManager.SaveResolution(ctx, deps.backend, res); err != nil {
+		deps.runtime.Output.Debugf("save shared resolution %s: %v", reqHash, err)
+	}
+}
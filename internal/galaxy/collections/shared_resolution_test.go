@@ -0,0 +1,67 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+// resolutionBackend keeps resolutions in memory; other backend methods are not used.
+type resolutionBackend struct {
+	cacheManager.Backend
+
+	saved map[string]*store.Resolution
+}
+
+func (b *resolutionBackend) LoadResolution(_ context.Context, reqHash string) (*store.Resolution, error) {
+	return b.saved[reqHash], nil
+}
+
+func (b *resolutionBackend) SaveResolution(_ context.Context, res *store.Resolution) error {
+	b.saved[res.RequirementsHash] = res
+	return nil
+}
+
+func TestSharedResolution(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/collections/a/b/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"versions_url":"/api/v3/collections/a/b/versions/","highest_version":{"version":"1.0.0"}}`)
+	})
+	mux.HandleFunc("/api/v3/collections/a/b/versions/1.0.0/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"version":"1.0.0","metadata":{"dependencies":{}}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cfg := &config.Config{Server: srv.URL, Workers: 1, HealthCheck: config.HealthCheckOff}
+	roots := []collection{{Namespace: "a", Name: "b", Version: "1.0.0", Source: srv.URL}}
+	backend := &resolutionBackend{saved: make(map[string]*store.Resolution)}
+
+	first := collectionDeps{cfg: cfg, runtime: infra.New(progress.New(false, true), srv.Client()), st: store.New(), backend: backend}
+	if _, _, err := resolveCollectionsInternal(context.Background(), first, roots, true, true); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if len(backend.saved) != 1 {
+		t.Fatalf("a fresh resolution must be shared, got %d", len(backend.saved))
+	}
+
+	// A cold store on another runner resolves without touching the server.
+	srv.Close()
+	cold := collectionDeps{cfg: cfg, runtime: infra.New(progress.New(false, true), srv.Client()), st: store.New(), backend: backend}
+	resolved, _, err := resolveCollectionsInternal(context.Background(), cold, roots, true, true)
+	if err != nil {
+		t.Fatalf("resolve from shared resolution: %v", err)
+	}
+	if resolved["a.b"].Version != "1.0.0" {
+		t.Fatalf("unexpected resolution: %+v", resolved)
+	}
+}
@@ -0,0 +1,67 @@
+package collections
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// canonicalSource lowercases the scheme and host of a source URL and drops
+// surrounding quotes and trailing slashes.
+func canonicalSource(source string) string {
+	source = normalizeServerURL(source)
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return source
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	return strings.TrimRight(u.String(), "/")
+}
+
+// canonicalConstraint drops all whitespace from a normalized constraint, so
+// ">= 1.0.0, <2.0.0" and ">=1.0.0,<2.0.0" hash the same. Empty means any.
+func canonicalConstraint(constraint string) string {
+	if constraint == "" {
+		return "*"
+	}
+	return strings.Join(strings.Fields(constraint), "")
+}
+
+// legacyRequirementsSignature is the signature written before sources and
+// constraints were canonicalized. It is only used to migrate stored hashes.
+func legacyRequirementsSignature(spec map[string]requirementSpec) string {
+	parts := make([]string, 0, len(spec))
+	for fqdn, entry := range spec {
+		constraint := entry.Constraint
+		if constraint == "" {
+			constraint = "*"
+		}
+		signatureKey := strings.Join(normalizeSignatures(entry.Signatures), ",")
+		parts = append(parts, fmt.Sprintf("%s|%s|%s|%s|%s", fqdn, constraint, entry.Source, entry.Type, signatureKey))
+	}
+	sort.Strings(parts)
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// migrateRequirementsHash rewrites a stored requirements hash computed with
+// the legacy signature for the same requirements to reqHash, so upgrading
+// does not invalidate the stored resolution. Once rewritten it never matches again.
+func migrateRequirementsHash(st *store.Store, spec map[string]requirementSpec, reqHash string) {
+	if st == nil {
+		return
+	}
+	meta := st.MetaSnapshot()
+	if meta.RequirementsHash == "" || meta.RequirementsHash == reqHash {
+		return
+	}
+	if meta.RequirementsHash == legacyRequirementsSignature(spec) {
+		st.SetMetaRequirements(reqHash, meta.Server)
+	}
+}
@@ -0,0 +1,46 @@
+package collections
+
+import (
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+func TestRequirementsSignatureIgnoresFormatting(t *testing.T) {
+	t.Parallel()
+	a := map[string]requirementSpec{
+		"a.b": {Constraint: ">=1.0.0, <2.0.0", Source: "https://Galaxy.Example.com/api/"},
+	}
+	b := map[string]requirementSpec{
+		"a.b": {Constraint: ">= 1.0.0,<2.0.0", Source: "HTTPS://galaxy.example.com/api"},
+	}
+	if requirementsSignatureFromSpec(a) != requirementsSignatureFromSpec(b) {
+		t.Fatal("source case, trailing slashes and constraint whitespace must not change the hash")
+	}
+	c := map[string]requirementSpec{
+		"a.b": {Constraint: ">=1.0.0, <2.0.0", Source: "https://galaxy.example.com/API"},
+	}
+	if requirementsSignatureFromSpec(a) == requirementsSignatureFromSpec(c) {
+		t.Fatal("the source path must stay case sensitive")
+	}
+}
+
+func TestMigrateRequirementsHash(t *testing.T) {
+	t.Parallel()
+	spec := map[string]requirementSpec{"a.b": {Constraint: ">=1.0.0", Source: "https://galaxy.example.com/"}}
+	reqHash := requirementsSignatureFromSpec(spec)
+
+	st := store.New()
+	st.SetMetaRequirements(legacyRequirementsSignature(spec), "https://galaxy.example.com")
+	migrateRequirementsHash(st, spec, reqHash)
+	if got := st.MetaSnapshot().RequirementsHash; got != reqHash {
+		t.Fatalf("a legacy hash of the same requirements must be migrated, got %s", got)
+	}
+
+	other := store.New()
+	other.SetMetaRequirements("unrelated", "https://galaxy.example.com")
+	migrateRequirementsHash(other, spec, reqHash)
+	if got := other.MetaSnapshot().RequirementsHash; got != "unrelated" {
+		t.Fatalf("a hash of other requirements must be kept, got %s", got)
+	}
+}
@@ -2,9 +2,10 @@ package collections
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	cacheBackend "github.com/greeddj/go-galaxy/internal/cache"
@@ -19,18 +20,62 @@ type installState struct {
 	backend cacheManager.Backend
 	store   *store.Store
 	release func() error
+	// downloads dedups artifact downloads for as long as the state is open.
+	downloads *artifactDownloads
+	// bundleInstalled holds the installed entries a --bundle run started
+	// with; see keepInstalled.
+	bundleInstalled map[string]store.InstalledEntry
+}
+
+// keepInstalled makes the installed entries of this run temporary: a
+// --bundle run installs into a staging dir that is deleted afterwards, and
+// entries pointing there would make the next install of the real path
+// redo everything. The journal is detached so they never reach disk, and
+// restoreInstalled puts the starting entries back before the store is saved.
+func (s *installState) keepInstalled() {
+	s.store.AttachJournal(nil)
+	s.bundleInstalled = s.store.InstalledSnapshot()
+}
+
+// restoreInstalled undoes the installed entries recorded since keepInstalled.
+func (s *installState) restoreInstalled() {
+	if s.bundleInstalled == nil {
+		return
+	}
+	for key := range s.store.InstalledSnapshot() {
+		if _, ok := s.bundleInstalled[key]; !ok {
+			s.store.DeleteInstalled(key)
+		}
+	}
+	for key, entry := range s.bundleInstalled {
+		s.store.SetInstalled(key, entry)
+	}
+}
+
+// resolveDeps returns resolver dependencies that share resolutions through the backend.
+func (s *installState) resolveDeps(cfg *config.Config, runtime *infra.Infra) collectionDeps {
+	deps := newCollectionDeps(cfg, runtime, s.store)
+	deps.backend = s.backend
+	return deps
 }
 
 type installPlan struct {
+	roots       []string
 	collections map[string]collection
 	graph       map[string][]string
 	levels      [][]string
 	prefetch    *prefetcher
+	digests     installDigests
 }
 
 // Start installs collections according to the provided configuration.
 func Start(ctx context.Context, cfg *config.Config, runtime *infra.Infra) error {
-	err := runInstall(ctx, cfg, runtime)
+	var err error
+	if cfg.Bundle != "" {
+		err = runBundle(ctx, cfg, runtime)
+	} else {
+		err = runInstall(ctx, cfg, runtime)
+	}
 	if err != nil {
 		runtime.Output.Errorf("Error: %s", err.Error())
 	}
@@ -40,7 +85,11 @@ func Start(ctx context.Context, cfg *config.Config, runtime *infra.Infra) error
 func runInstall(ctx context.Context, cfg *config.Config, runtime *infra.Infra) error {
 	runtime.Output.Printf("🚀 Starting installation process")
 	start := time.Now()
+	ctx, cancelBudget := withInstallBudget(ctx, cfg)
+	defer cancelBudget()
+	stopProfile := profilePhase(cfg, runtime, profileSnapshotLoad)
 	state, err := initInstall(ctx, cfg, runtime)
+	stopProfile()
 	if err != nil {
 		return err
 	}
@@ -50,13 +99,23 @@ func runInstall(ctx context.Context, cfg *config.Config, runtime *infra.Infra) e
 		}
 	}()
 	defer func() {
-		_ = state.backend.Close(ctx)
+		_ = state.backend.Close(context.WithoutCancel(ctx))
 	}()
+	if cfg.Bundle != "" {
+		state.keepInstalled()
+	}
 
+	stopProfile = profilePhase(cfg, runtime, profileResolve)
 	plan, err := prepareInstallPlan(ctx, cfg, runtime, state)
+	stopProfile()
 	if err != nil {
+		flushOnShutdown(ctx, runtime, state)
 		return err
 	}
+	if cfg.Bundle == "" {
+		warnShadowedCollections(cfg, runtime, plan.collections)
+	}
+	stopProfile = profilePhase(cfg, runtime, profileExtract)
 	failures, err := installLevels(
 		ctx,
 		cfg,
@@ -68,27 +127,58 @@ func runInstall(ctx context.Context, cfg *config.Config, runtime *infra.Infra) e
 		plan.levels,
 		plan.prefetch,
 	)
+	stopProfile()
 	if err != nil {
+		flushOnShutdown(ctx, runtime, state)
 		return err
 	}
+	failures = append(failures, checkRequiresAnsible(cfg, plan.collections)...)
+	if len(failures) == 0 {
+		if err := writeInstallManifest(cfg, runtime, state.store, plan); err != nil {
+			return err
+		}
+		stampFingerprints(cfg, state.store, plan.collections)
+	}
+	reportBudget(cfg, runtime, state.store, time.Since(start))
+	state.restoreInstalled()
 
-	return finalizeInstall(ctx, runtime, state.backend, state.store, failures, start)
+	stopProfile = profilePhase(cfg, runtime, profileSnapshotSave)
+	err = finalizeInstall(ctx, runtime, state.backend, state.store, failures, start)
+	stopProfile()
+	if err != nil {
+		return err
+	}
+	return reportDigests(cfg, runtime, plan.digests)
 }
 
 func prepareInstallPlan(ctx context.Context, cfg *config.Config, runtime *infra.Infra, state *installState) (*installPlan, error) {
-	prep, err := loadRoots(cfg, runtime)
+	state.store.BeginStatsRun()
+	prep, err := loadRoots(ctx, cfg, runtime)
 	if err != nil {
 		return nil, err
 	}
 
+	trace, err := openResolveTrace(runtime, cfg.Trace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	defer func() {
+		_ = trace.Close()
+	}()
+	resolveDeps := state.resolveDeps(cfg, runtime)
+	resolveDeps.trace = trace
+
 	resolveStart := time.Now()
 	runtime.Output.Printf("🧩 resolve dependencies")
+	// a stored resolution cannot tell whether an --artifact file changed, and
+	// one naming local files is no use to later runs
+	fromServer := len(cfg.Artifacts) == 0
 	resolved, graph, err := resolveCollectionsInternal(
 		ctx,
-		newCollectionDeps(cfg, runtime, state.store),
+		resolveDeps,
 		prep.AllRoots,
-		true,
-		true,
+		fromServer,
+		fromServer,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve dependencies: %w", err)
@@ -99,19 +189,21 @@ func prepareInstallPlan(ctx context.Context, cfg *config.Config, runtime *infra.
 	if err != nil {
 		return nil, err
 	}
+	applyInstallPaths(cfg, runtime, collections, prep.AllRoots)
 
 	roots, err := buildRootKeys(prep, resolved)
 	if err != nil {
 		return nil, err
 	}
 	state.store.SetRoots("last_run", roots)
+	for label, key := range prep.GitRoots {
+		state.store.AddRoot(label, key)
+	}
 
 	prefetchStart := time.Now()
-	prefetch := startPrefetcher(
-		ctx,
-		newPrefetchDeps(cfg, runtime, state.store, state.backend.Artifacts()),
-		collections,
-	)
+	prefetchDeps := newPrefetchDeps(cfg, runtime, state.store, state.backend.Artifacts(), state.downloads)
+	prefetchDeps.fingerprints = collectionsPathFingerprints(cfg, collections)
+	prefetch := startPrefetcher(ctx, prefetchDeps, collections)
 	runtime.Output.DebugSincef(prefetchStart, "%s", "prefetch schedule")
 
 	levelStart := time.Now()
@@ -122,14 +214,56 @@ func prepareInstallPlan(ctx context.Context, cfg *config.Config, runtime *infra.
 	runtime.Output.DebugSincef(levelStart, "%s", "build install levels")
 
 	return &installPlan{
+		roots:       roots,
 		collections: collections,
 		graph:       graph,
 		levels:      levels,
 		prefetch:    prefetch,
+		digests:     newInstallDigests(cfg, prep.AllRoots, graph),
 	}, nil
 }
 
 func initInstall(ctx context.Context, cfg *config.Config, runtime *infra.Infra) (*installState, error) {
+	state, err := openInstallState(ctx, cfg, runtime)
+	if err != nil {
+		if !cfg.CacheSoftFail || ctx.Err() != nil || !backendUnavailable(err) {
+			return nil, err
+		}
+		state, err = openFallbackState(ctx, cfg, runtime, err)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ClearCache {
+		state.store.ClearCaches()
+		if err := state.backend.ClearFiles(ctx); err != nil {
+			_ = state.release()
+			_ = state.backend.Close(ctx)
+			return nil, err
+		}
+	}
+	if len(cfg.Bust) > 0 {
+		bustCollections(ctx, cfg, runtime, state)
+	}
+	if cfg.Bundle == "" && cfg.RequirementsData == nil {
+		if err := state.backend.RecordProject(ctx, projectRun(cfg)); err != nil {
+			runtime.Output.Printf("⚠️ Failed to record project: %v", err)
+		}
+	}
+	return state, nil
+}
+
+// projectRun describes the current install for the project registry.
+func projectRun(cfg *config.Config) store.ProjectRun {
+	return store.ProjectRun{
+		RequirementsFile: cfg.RequirementsFile,
+		DownloadPath:     cfg.DownloadPath,
+		RelativePaths:    cfg.CollectionsPathRelative,
+	}
+}
+
+// openInstallState opens the configured backend, takes its lock and loads the store.
+func openInstallState(ctx context.Context, cfg *config.Config, runtime *infra.Infra) (*installState, error) {
 	runtime.Output.Printf("🚀 init cache backend")
 	backend, err := cacheBackend.New(cfg, runtime)
 	if err != nil {
@@ -146,38 +280,88 @@ func initInstall(ctx context.Context, cfg *config.Config, runtime *infra.Infra)
 
 	snapshotStart := time.Now()
 	runtime.Output.Printf("🚀 load storage")
-	st, err := backend.LoadStore(ctx)
+	// API cache entries are read on first use: a run that resolves from its
+	// lockfile or resolution cache never touches most of them.
+	st, err := cacheManager.LoadStoreWith(ctx, backend, store.LoadOptions{LazyAPICache: true})
 	if err != nil {
 		_ = releaseLock()
 		_ = backend.Close(ctx)
 		return nil, err
 	}
 	runtime.Output.DebugSincef(snapshotStart, "%s", "load snapshot")
-	if cfg.ClearCache {
-		st.ClearCaches()
-		if err := backend.ClearFiles(ctx); err != nil {
-			_ = releaseLock()
-			_ = backend.Close(ctx)
-			return nil, err
-		}
-	}
-	if err := backend.RecordProject(ctx, cfg.RequirementsFile, cfg.DownloadPath); err != nil {
-		runtime.Output.Printf("⚠️ Failed to record project: %v", err)
-	}
+	runtime.Mirrors.Bind(st)
 
 	return &installState{
-		backend: backend,
-		store:   st,
-		release: releaseLock,
+		backend:   backend,
+		store:     st,
+		release:   releaseLock,
+		downloads: newArtifactDownloads(),
 	}, nil
 }
 
-func loadRoots(cfg *config.Config, runtime *infra.Infra) (*rootPreparation, error) {
+// backendUnavailable reports whether err means the cache backend could not
+// be reached, which --cache-soft-fail may run past. Lock contention, invalid
+// cache settings and unreadable or corrupt cache contents are returned as-is:
+// continuing would skip the lock or hide a broken cache.
+func backendUnavailable(err error) bool {
+	var annotated *helpers.Error
+	if !errors.As(helpers.Classify(err, ""), &annotated) {
+		return true
+	}
+	switch annotated.Category {
+	case helpers.CategoryLock, helpers.CategoryCache, helpers.CategoryIntegrity, helpers.CategoryConfig:
+		return false
+	default:
+		return true
+	}
+}
+
+// openFallbackState continues with a throwaway local cache after the configured
+// backend failed, so installs go straight to Galaxy during a cache outage.
+func openFallbackState(ctx context.Context, cfg *config.Config, runtime *infra.Infra, cause error) (*installState, error) {
+	runtime.Output.PersistentPrintf("⚠️ Cache backend unavailable, continuing with a temporary local cache: %v", cause)
+	dir, err := os.MkdirTemp(cfg.TempDir, "go-galaxy-cache-")
+	if err != nil {
+		return nil, fmt.Errorf("%w (fallback cache: %w)", cause, err)
+	}
+	fallback := *cfg
+	fallback.CacheBackend = cacheBackend.BackendLocal
+	fallback.CacheDir = dir
+	fallback.CacheNamespace = ""
+	fallback.CacheEncryptionKey = nil
+	fallback.S3Cache.Enabled = false
+	fallback.LeaseLock.Enabled = false
+	state, err := openInstallState(ctx, &fallback, runtime)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("%w (fallback cache: %w)", cause, err)
+	}
+	release := state.release
+	state.release = func() error {
+		err := release()
+		_ = os.RemoveAll(dir)
+		return err
+	}
+	return state, nil
+}
+
+func loadRoots(ctx context.Context, cfg *config.Config, runtime *infra.Infra) (*rootPreparation, error) {
 	runtime.Output.Printf("🗂️ load collections from requirements file")
-	collectionsDirect, rolesFound, err := loadRequirements(cfg.RequirementsFile, cfg.Server)
+	collectionsDirect, rolesFound, err := loadConfiguredRequirements(cfg)
+	if err != nil && len(cfg.Artifacts) > 0 && cfg.RequirementsData == nil && errors.Is(err, os.ErrNotExist) {
+		// --artifact alone is a complete request
+		collectionsDirect, rolesFound, err = nil, false, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to load requirements file: %w", err)
 	}
+	if collectionsDirect, err = addArtifactRoots(cfg, collectionsDirect); err != nil {
+		return nil, err
+	}
+	collectionsDirect, gitRoots, err := addGitRoots(ctx, cfg, runtime, collectionsDirect)
+	if err != nil {
+		return nil, err
+	}
 	if rolesFound {
 		runtime.Output.Printf("⚠️ requirements.yml contains roles, but roles are not supported.")
 	}
@@ -186,6 +370,7 @@ func loadRoots(cfg *config.Config, runtime *infra.Infra) (*rootPreparation, erro
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare requirements: %w", err)
 	}
+	prep.GitRoots = gitRoots
 	return prep, nil
 }
 
@@ -214,6 +399,8 @@ func buildRootKeys(prep *rootPreparation, resolved map[string]collection) ([]str
 	return roots, nil
 }
 
+// installLevels installs every node of the graph, starting each collection as
+// soon as its dependencies are done rather than waiting for a full level.
 func installLevels(
 	ctx context.Context,
 	cfg *config.Config,
@@ -224,44 +411,165 @@ func installLevels(
 	graph map[string][]string,
 	levels [][]string,
 	prefetch *prefetcher,
-) (int32, error) {
+) ([]error, error) {
 	depsCtx := newInstallDeps(cfg, runtime, st, artifacts, nil)
-	var failures int32
+	depsCtx.downloads = prefetch.downloads
+	depsCtx.hashes = prefetch.hashes
+	depsCtx.fingerprints = prefetch.fingerprints
+	// Installs already running get a grace period after a shutdown signal.
+	workCtx, cancel := graceContext(ctx, helpers.ShutdownGracePeriod)
+	defer cancel()
+	var (
+		mu     sync.Mutex
+		failed = make(map[string]error)
+	)
 	for _, level := range levels {
-		var wg sync.WaitGroup
-		sem := make(chan struct{}, cfg.Workers)
+		for _, key := range level {
+			if _, ok := collections[key]; !ok {
+				return nil, fmt.Errorf("%w for: %s", helpers.ErrMissingCollection, key)
+			}
+		}
+	}
+
+	sched := newInstallScheduler(graph, levels, cfg.InstallWorkerCount())
+	sched.run(ctx, func(key string) {
+		col := collections[key]
+		meta, ok, prefetchErr := prefetch.Wait(col.key())
+		if ok && prefetchErr != nil {
+			runtime.Output.Printf("⚠️ Prefetch failed for %s: %v", col.key(), prefetchErr)
+		}
+		if err := installCollection(workCtx, col, depsCtx, dependencyKeys(graph, key), meta); err != nil {
+			runtime.Output.Errorf("Failed: %s.%s error: %s", col.Namespace, col.Name, err)
+			mu.Lock()
+			failed[key] = helpers.Classify(err, key)
+			mu.Unlock()
+		} else {
+			runtime.Output.Okf("Installed: %s.%s", col.Namespace, col.Name)
+		}
+	}, func() {
+		runtime.Output.PersistentPrintf("🛑 Shutdown requested, waiting for running installs")
+	})
+	if ctx.Err() != nil {
+		return failedErrors(levels, failed), fmt.Errorf("%w: %w", helpers.ErrInterrupted, context.Cause(ctx))
+	}
+	if len(failed) == 0 || cfg.NoRetry {
+		return failedErrors(levels, failed), nil
+	}
+	return retryFailed(ctx, depsCtx, collections, graph, levels, failed), nil
+}
 
+// graceContext returns a context that stays alive for grace after ctx is done,
+// so work already in flight can finish instead of aborting mid-download.
+func graceContext(ctx context.Context, grace time.Duration) (context.Context, context.CancelFunc) {
+	workCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(grace, cancel)
+	})
+	return workCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// flushOnShutdown saves the store when the run was interrupted, so a nearly
+// complete cache warm survives the signal. The lock is released by the caller.
+func flushOnShutdown(ctx context.Context, runtime *infra.Infra, state *installState) {
+	if ctx.Err() == nil {
+		return
+	}
+	flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), helpers.ShutdownFlushTimeout)
+	defer cancel()
+	state.restoreInstalled()
+	if err := state.backend.SaveStore(flushCtx, state.store); err != nil {
+		runtime.Output.PersistentPrintf("⚠️ Failed to save storage on shutdown: %v", err)
+		return
+	}
+	runtime.Output.PersistentPrintf("💾 Storage saved on shutdown")
+}
+
+// retryFailed reinstalls failed collections once, sequentially and in level order,
+// with version metadata fetched past the API cache. It returns the remaining failures.
+func retryFailed(
+	ctx context.Context,
+	deps installDeps,
+	collections map[string]collection,
+	graph map[string][]string,
+	levels [][]string,
+	failed map[string]error,
+) []error {
+	runtime := deps.runtime
+	runtime.Output.PersistentPrintf("🔁 Retrying %d failed collections", len(failed))
+	// Metadata for the retry bypasses the API cache; artifacts still use it.
+	uncached := *deps.cfg
+	uncached.NoCache = true
+	metaDeps := newCollectionDeps(&uncached, runtime, deps.st)
+
+	var failures []error
+	for _, level := range levels {
 		for _, key := range level {
-			col, ok := collections[key]
-			if !ok {
-				return failures, fmt.Errorf("%w for: %s", helpers.ErrMissingCollection, key)
+			if _, ok := failed[key]; !ok {
+				continue
 			}
-			depKeys := graph[key]
-			if depKeys == nil {
-				depKeys = []string{}
+			col := collections[key]
+			meta, err := loadCollectionMetadata(ctx, metaDeps, col)
+			if err == nil {
+				err = installCollection(ctx, col, deps, dependencyKeys(graph, key), meta)
 			}
-			sem <- struct{}{}
-			wg.Go(func() {
-				defer func() { <-sem }()
-				meta, ok, prefetchErr := prefetch.Wait(col.key())
-				if ok && prefetchErr != nil {
-					runtime.Output.Printf("⚠️ Prefetch failed for %s: %v", col.key(), prefetchErr)
-				}
-				if err := installCollection(ctx, col, depsCtx, depKeys, meta); err != nil {
-					runtime.Output.Errorf("Failed: %s.%s error: %s", col.Namespace, col.Name, err)
-					atomic.AddInt32(&failures, 1)
-				} else {
-					runtime.Output.Okf("Installed: %s.%s", col.Namespace, col.Name)
-				}
-			})
+			if err != nil {
+				runtime.Output.Errorf("Failed again: %s.%s error: %s", col.Namespace, col.Name, err)
+				failures = append(failures, helpers.Classify(err, key))
+				continue
+			}
+			runtime.Output.Okf("Installed on retry: %s.%s", col.Namespace, col.Name)
 		}
+	}
+	return failures
+}
 
-		wg.Wait()
-		if atomic.LoadInt32(&failures) > 0 {
-			break
+// failedErrors returns the recorded failures in install level order.
+func failedErrors(levels [][]string, failed map[string]error) []error {
+	var errs []error
+	for _, level := range levels {
+		for _, key := range level {
+			if err, ok := failed[key]; ok {
+				errs = append(errs, err)
+			}
 		}
 	}
-	return atomic.LoadInt32(&failures), nil
+	return errs
+}
+
+// installFailure summarizes failed collections in one line while keeping each
+// cause reachable for errors.Is and remediation hints.
+type installFailure struct {
+	summary error
+	causes  []error
+}
+
+// newInstallFailure wraps per-collection failures behind ErrInstallationFailed.
+func newInstallFailure(causes []error) error {
+	return &installFailure{
+		summary: fmt.Errorf("%w for %d collections", helpers.ErrInstallationFailed, len(causes)),
+		causes:  causes,
+	}
+}
+
+// Error implements the error interface.
+func (e *installFailure) Error() string {
+	return e.summary.Error()
+}
+
+// Unwrap returns the summary followed by every per-collection cause.
+func (e *installFailure) Unwrap() []error {
+	return append([]error{e.summary}, e.causes...)
+}
+
+// dependencyKeys returns the resolved dependency keys of a collection, never nil.
+func dependencyKeys(graph map[string][]string, key string) []string {
+	if depKeys := graph[key]; depKeys != nil {
+		return depKeys
+	}
+	return []string{}
 }
 
 func finalizeInstall(
@@ -269,7 +577,7 @@ func finalizeInstall(
 	runtime *infra.Infra,
 	backend cacheManager.Backend,
 	st *store.Store,
-	failures int32,
+	failures []error,
 	start time.Time,
 ) error {
 	saveStart := time.Now()
@@ -277,9 +585,9 @@ func finalizeInstall(
 		return err
 	}
 	runtime.Output.DebugSincef(saveStart, "%s", "save snapshot")
-	if failures > 0 {
-		runtime.Output.PersistentPrintf("⚠️ Completed with errors: %d failed. Took %s", failures, time.Since(start).Round(time.Second))
-		return fmt.Errorf("%w for %d collections", helpers.ErrInstallationFailed, failures)
+	if len(failures) > 0 {
+		runtime.Output.PersistentPrintf("⚠️ Completed with errors: %d failed. Took %s", len(failures), time.Since(start).Round(time.Second))
+		return newInstallFailure(failures)
 	}
 	runtime.Output.PersistentPrintf("🤩 All done. Took %s", time.Since(start).Round(time.Second))
 	return nil
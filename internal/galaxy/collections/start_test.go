@@ -0,0 +1,132 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestGraceContextOutlivesParent(t *testing.T) {
+	t.Parallel()
+	parent, stop := context.WithCancel(context.Background())
+	workCtx, cancel := graceContext(parent, 50*time.Millisecond)
+	defer cancel()
+
+	stop()
+	select {
+	case <-workCtx.Done():
+		t.Fatalf("work context canceled together with parent")
+	case <-time.After(10 * time.Millisecond):
+	}
+	select {
+	case <-workCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("work context not canceled after grace period")
+	}
+}
+
+func TestInstallFailureCarriesHints(t *testing.T) {
+	t.Parallel()
+	causes := []error{
+		helpers.Classify(fmt.Errorf("failed to extract a-b-1.0.0.tar.gz: %w", helpers.ErrSHA256Mismatch), "a.b@1.0.0"),
+		helpers.Classify(fmt.Errorf("c.d: %w", helpers.ErrSHA256Mismatch), "c.d@2.0.0"),
+		helpers.Classify(errors.New("boom"), "e.f@1.0.0"),
+	}
+	err := newInstallFailure(causes)
+
+	if !errors.Is(err, helpers.ErrInstallationFailed) || !errors.Is(err, helpers.ErrSHA256Mismatch) {
+		t.Fatalf("expected summary and cause to match, got %v", err)
+	}
+	if strings.Contains(err.Error(), "a.b") {
+		t.Fatalf("expected one-line summary, got %q", err.Error())
+	}
+	var annotated *helpers.Error
+	if !errors.As(err, &annotated) || annotated.Collection != "a.b@1.0.0" || annotated.Category != helpers.CategoryIntegrity {
+		t.Fatalf("expected annotated integrity error for a.b, got %#v", annotated)
+	}
+	hints := helpers.Hints(err)
+	if len(hints) != 1 || !strings.Contains(hints[0], "--clear-cache") {
+		t.Fatalf("expected one deduplicated --clear-cache hint, got %v", hints)
+	}
+}
+
+func TestInitInstallSoftFailFallsBackToTempCache(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+	tmp := t.TempDir()
+	cfg := &config.Config{
+		CacheSoftFail:    true,
+		TempDir:          tmp,
+		RequirementsFile: "requirements.yml",
+		S3Cache: config.S3CacheConfig{
+			Enabled:   true,
+			Endpoint:  srv.URL,
+			Bucket:    "cache",
+			AccessKey: "key",
+			SecretKey: "secret",
+			PathStyle: true,
+		},
+	}
+	runtime := infra.New(progress.New(false, true), srv.Client())
+
+	cfg.CacheSoftFail = false
+	if _, err := initInstall(context.Background(), cfg, runtime); err == nil {
+		t.Fatalf("expected backend error without soft-fail")
+	}
+	cfg.CacheSoftFail = true
+	state, err := initInstall(context.Background(), cfg, runtime)
+	if err != nil {
+		t.Fatalf("initInstall: %v", err)
+	}
+	if err := state.backend.SaveStore(context.Background(), state.store); err != nil {
+		t.Fatalf("SaveStore: %v", err)
+	}
+	_ = state.backend.Close(context.Background())
+	if err := state.release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected temporary cache to be removed, got %v", entries)
+	}
+}
+
+func TestInitInstallSoftFailKeepsLockContention(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{CacheSoftFail: true, CacheDir: t.TempDir(), TempDir: t.TempDir(), RequirementsFile: "requirements.yml"}
+	release, err := store.AcquireLock(cfg.CacheDir, nil)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	defer func() {
+		_ = release()
+	}()
+	runtime := infra.New(progress.New(false, true), http.DefaultClient)
+	if _, err := initInstall(context.Background(), cfg, runtime); !errors.Is(err, helpers.ErrAnotherInstanceIsRunning) {
+		t.Fatalf("expected ErrAnotherInstanceIsRunning despite --cache-soft-fail, got %v", err)
+	}
+	if backendUnavailable(fmt.Errorf("load: %w", helpers.ErrCacheValueCorrupted)) {
+		t.Fatalf("a corrupt cache must not fall back")
+	}
+	if !backendUnavailable(errors.New("dial tcp: connection refused")) {
+		t.Fatalf("an unreachable backend must fall back")
+	}
+}
@@ -0,0 +1,87 @@
+package collections
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestTargets are the runtimes an install was checked against.
+type ManifestTargets struct {
+	AnsibleCore []string `json:"ansible_core,omitempty"`
+	Python      []string `json:"python,omitempty"`
+}
+
+// manifestTargets returns the configured targets, or nil without any.
+func manifestTargets(cfg *config.Config) *ManifestTargets {
+	if len(cfg.TargetAnsibleCore) == 0 && len(cfg.TargetPython) == 0 {
+		return nil
+	}
+	return &ManifestTargets{AnsibleCore: cfg.TargetAnsibleCore, Python: cfg.TargetPython}
+}
+
+// runtimeMeta is the part of a collection's meta/runtime.yml the target
+// checks read.
+type runtimeMeta struct {
+	RequiresAnsible string `yaml:"requires_ansible"`
+}
+
+// checkRequiresAnsible returns a failure for each installed collection whose
+// requires_ansible excludes one of the --target-ansible-core versions.
+// Skipped collections are checked too, as a target may change between runs;
+// a collection without meta/runtime.yml or requires_ansible allows any.
+func checkRequiresAnsible(cfg *config.Config, cols map[string]collection) []error {
+	if len(cfg.TargetAnsibleCore) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, fqdn := range slices.Sorted(maps.Keys(cols)) {
+		col := cols[fqdn]
+		required, err := requiresAnsible(col.installDir(cfg))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", col.key(), err))
+			continue
+		}
+		if required == "" {
+			continue
+		}
+		for _, target := range cfg.TargetAnsibleCore {
+			ok, err := constraintSatisfied(target, required)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: requires_ansible: %w", col.key(), err))
+				break
+			}
+			if !ok {
+				errs = append(errs, fmt.Errorf("%w: %s requires ansible-core %s, target is %s",
+					helpers.ErrRequiresAnsible, col.key(), required, target))
+			}
+		}
+	}
+	return errs
+}
+
+// requiresAnsible reads requires_ansible from meta/runtime.yml under
+// installDir; "" when either is missing.
+func requiresAnsible(installDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(installDir, "meta", "runtime.yml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var meta runtimeMeta
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		return "", fmt.Errorf("meta/runtime.yml: %w", err)
+	}
+	return strings.TrimSpace(meta.RequiresAnsible), nil
+}
@@ -0,0 +1,36 @@
+package collections
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+func TestCheckRequiresAnsible(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{DownloadPath: t.TempDir()}
+	strict := collection{Namespace: "acme", Name: "strict", Version: "1.0.0"}
+	loose := collection{Namespace: "acme", Name: "loose", Version: "1.0.0"}
+	meta := filepath.Join(strict.installDir(cfg), "meta")
+	if err := os.MkdirAll(meta, 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(meta, "runtime.yml"), []byte("requires_ansible: \">=2.15.0\"\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cols := map[string]collection{"acme.strict": strict, "acme.loose": loose}
+
+	cfg.TargetAnsibleCore = []string{"2.16.0"}
+	if errs := checkRequiresAnsible(cfg, cols); len(errs) != 0 {
+		t.Fatalf("expected no violations, got %v", errs)
+	}
+	cfg.TargetAnsibleCore = []string{"2.14.0", "2.16.0"}
+	errs := checkRequiresAnsible(cfg, cols)
+	if len(errs) != 1 || !errors.Is(errs[0], helpers.ErrRequiresAnsible) {
+		t.Fatalf("expected one ErrRequiresAnsible, got %v", errs)
+	}
+}
@@ -0,0 +1,140 @@
+package collections
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/output"
+)
+
+// Resolution trace events written with --trace, one JSON object per line.
+const (
+	// traceTask is a collection queued for resolution with the constraints
+	// on it, keyed by who set them: root for the requirements file, else the
+	// dependent collection.
+	traceTask = "task"
+	// traceCandidates lists the versions considered for a collection.
+	traceCandidates = "candidates"
+	// traceCacheHit is a decision served from a cache instead of the server.
+	traceCacheHit = "cache_hit"
+	// tracePick is the version chosen for a collection and its dependencies.
+	tracePick = "pick"
+	// traceError is a collection that failed to resolve.
+	traceError = "error"
+)
+
+// traceEvent is one resolver decision.
+type traceEvent struct {
+	Time        time.Time         `json:"time"`
+	Event       string            `json:"event"`
+	Collection  string            `json:"collection,omitempty"`
+	Server      string            `json:"server,omitempty"`
+	Version     string            `json:"version,omitempty"`
+	Constraints map[string]string `json:"constraints,omitempty"`
+	Candidates  []string          `json:"candidates,omitempty"`
+	Cache       string            `json:"cache,omitempty"`
+	Deps        map[string]string `json:"deps,omitempty"`
+	DepsSource  string            `json:"deps_source,omitempty"`
+	Detail      string            `json:"detail,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// resolveTrace appends resolver decisions to the --trace file. A nil trace
+// records nothing, so callers need not check whether tracing is on.
+type resolveTrace struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// openResolveTrace creates the trace file at path; an empty path disables tracing.
+// Credentials in server URLs and errors are masked as in progress output.
+func openResolveTrace(runtime *infra.Infra, path string) (*resolveTrace, error) {
+	if path == "" {
+		return nil, nil
+	}
+	//nolint:gosec // the trace path is chosen by the user.
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	var w io.Writer = file
+	if r, ok := runtime.Output.(output.Redactor); ok {
+		w = output.RedactWriter(w, r)
+	}
+	return &resolveTrace{file: file, enc: json.NewEncoder(w)}, nil
+}
+
+// record appends ev, stamping it with the current time. Write errors are
+// ignored: a trace must never fail the resolution it describes.
+func (t *resolveTrace) record(ev traceEvent) {
+	if t == nil {
+		return
+	}
+	ev.Time = time.Now().UTC()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_ = t.enc.Encode(ev)
+}
+
+// recordResult records the outcome of resolving one collection.
+func (t *resolveTrace) recordResult(res resolveResult) {
+	if t == nil {
+		return
+	}
+	if res.Err != nil {
+		t.record(traceEvent{Event: traceError, Collection: res.FQDN, Server: res.Source, Error: res.Err.Error()})
+		return
+	}
+	t.record(traceEvent{
+		Event:      tracePick,
+		Collection: res.FQDN,
+		Server:     res.Source,
+		Version:    res.Version,
+		Deps:       res.Deps,
+		DepsSource: res.DepsSource,
+	})
+}
+
+// recordResolved records every collection of a resolution reused as a whole,
+// e.g. from the stored snapshot, as picks served by cache.
+func (t *resolveTrace) recordResolved(resolved map[string]collection, cache string) {
+	if t == nil {
+		return
+	}
+	for _, fqdn := range slices.Sorted(maps.Keys(resolved)) {
+		col := resolved[fqdn]
+		t.record(traceEvent{
+			Event:      tracePick,
+			Collection: fqdn,
+			Server:     col.Source,
+			Version:    col.Version,
+			Cache:      cache,
+			DepsSource: col.DepsSource,
+		})
+	}
+}
+
+// Close closes the trace file.
+func (t *resolveTrace) Close() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.file.Close()
+}
+
+// constraintOrigins copies the constraints on a collection keyed by who set them.
+func constraintOrigins(depConstraints map[string]map[string]string, fqdn string) map[string]string {
+	if len(depConstraints[fqdn]) == 0 {
+		return nil
+	}
+	return maps.Clone(depConstraints[fqdn])
+}
@@ -0,0 +1,84 @@
+package collections
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestResolveTraceRecordsDecisions(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/collections/a/b/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"versions_url":"/api/v3/collections/a/b/versions/","highest_version":{"version":"1.2.0"}}`)
+	})
+	mux.HandleFunc("/api/v3/collections/a/b/versions/1.2.0/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"version":"1.2.0","metadata":{"dependencies":{"c.d":">=2.0.0"}}}`)
+	})
+	mux.HandleFunc("/api/v3/collections/c/d/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"versions_url":"/api/v3/collections/c/d/versions/","highest_version":{"version":"2.1.0"}}`)
+	})
+	mux.HandleFunc("/api/v3/collections/c/d/versions/2.1.0/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"version":"2.1.0","metadata":{"dependencies":{}}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	trace, err := openResolveTrace(runtime, path)
+	if err != nil {
+		t.Fatalf("openResolveTrace: %v", err)
+	}
+	cfg := &config.Config{Server: srv.URL, Workers: 1, HealthCheck: config.HealthCheckOff}
+	deps := newCollectionDeps(cfg, runtime, store.New())
+	deps.trace = trace
+	roots := []collection{{Namespace: "a", Name: "b", Version: ">=1.0.0", Source: srv.URL}}
+	if _, _, err := resolveCollectionsInternal(context.Background(), deps, roots, false, false); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if err := trace.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+	events := make(map[string]traceEvent)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev traceEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("invalid trace line %q: %v", scanner.Text(), err)
+		}
+		events[ev.Event+" "+ev.Collection] = ev
+	}
+	if got := events["task c.d"].Constraints["a.b"]; got != ">=2.0.0" {
+		t.Fatalf("c.d task must carry the constraint set by a.b, got %+v", events["task c.d"])
+	}
+	if got := events["task a.b"].Constraints["root"]; got != ">=1.0.0" {
+		t.Fatalf("a.b task must carry the root constraint, got %+v", events["task a.b"])
+	}
+	if got := events["candidates c.d"].Candidates; len(got) != 1 || got[0] != "2.1.0" {
+		t.Fatalf("unexpected candidates %+v", events["candidates c.d"])
+	}
+	if pick := events["pick a.b"]; pick.Version != "1.2.0" || pick.Deps["c.d"] != ">=2.0.0" || pick.DepsSource != config.DepsSourceMetadata {
+		t.Fatalf("unexpected pick %+v", pick)
+	}
+	if pick := events["pick c.d"]; pick.Version != "2.1.0" {
+		t.Fatalf("unexpected pick %+v", pick)
+	}
+}
@@ -0,0 +1,97 @@
+package collections
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+)
+
+// verifySampleSize is how many FILES.json entries --verify-skip sample checks.
+const verifySampleSize = 16
+
+// filesManifest is the FILES.json shipped in every collection artifact.
+type filesManifest struct {
+	Files []filesEntry `json:"files"`
+}
+
+type filesEntry struct {
+	Name         string `json:"name"`
+	Ftype        string `json:"ftype"`
+	ChksumType   string `json:"chksum_type"`
+	ChksumSHA256 string `json:"chksum_sha256"`
+}
+
+// verifyInstalledFiles checks the installed tree against its FILES.json:
+// every entry with all set, otherwise a random sample, so repeated runs
+// cover the tree over time. A missing or unreadable FILES.json fails the check.
+func verifyInstalledFiles(installPath string, all bool) bool {
+	data, err := os.ReadFile(filepath.Join(installPath, "FILES.json"))
+	if err != nil {
+		return false
+	}
+	var manifest filesManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return false
+	}
+	entries := manifest.Files
+	if !all && len(entries) > verifySampleSize {
+		sample := make([]filesEntry, 0, verifySampleSize)
+		//nolint:gosec // sampling which files to check, not security sensitive.
+		for _, i := range rand.Perm(len(entries))[:verifySampleSize] {
+			sample = append(sample, entries[i])
+		}
+		entries = sample
+	}
+	for _, entry := range entries {
+		if !verifyFileEntry(installPath, entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyFileEntry reports whether one FILES.json entry matches the tree.
+func verifyFileEntry(installPath string, entry filesEntry) bool {
+	if entry.Name == "." || entry.Name == "" {
+		return true
+	}
+	if !filepath.IsLocal(entry.Name) {
+		return false
+	}
+	path := filepath.Join(installPath, filepath.FromSlash(entry.Name))
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+	if entry.Ftype == "dir" {
+		return info.IsDir()
+	}
+	if entry.ChksumType != "sha256" || entry.ChksumSHA256 == "" {
+		return true
+	}
+	sum, err := archive.FileHashSHA256(path)
+	return err == nil && sum == entry.ChksumSHA256
+}
+
+// installedManifestMatches reports whether the MANIFEST.json in installPath
+// names col and the FILES.json next to it has the checksum MANIFEST.json
+// records, which stands in for GALAXY.yml under --info-check manifest.
+func installedManifestMatches(col collection, installPath string) bool {
+	manifest, ok := readInstalledManifest(installPath)
+	if !ok {
+		return false
+	}
+	info := manifest.CollectionInfo
+	if info.Namespace != col.Namespace || info.Name != col.Name || info.Version != col.Version {
+		return false
+	}
+	want := manifest.FileManifestFile.ChksumSHA256
+	if want == "" {
+		return false
+	}
+	sha, err := archive.FileHashSHA256(filepath.Join(installPath, "FILES.json"))
+	return err == nil && sha == want
+}
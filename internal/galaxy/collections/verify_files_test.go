@@ -0,0 +1,113 @@
+package collections
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+func TestVerifyInstalledFiles(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "plugins"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	entries := []string{`{"name":".","ftype":"dir"}`, `{"name":"plugins","ftype":"dir"}`}
+	for i := range 20 {
+		name := fmt.Sprintf("plugins/mod_%d.py", i)
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.WriteFile(path, fmt.Appendf(nil, "# module %d\n", i), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		sum, err := archive.FileHashSHA256(path)
+		if err != nil {
+			t.Fatalf("hash: %v", err)
+		}
+		entries = append(entries, fmt.Sprintf(`{"name":%q,"ftype":"file","chksum_type":"sha256","chksum_sha256":%q}`, name, sum))
+	}
+	files := `{"files":[` + strings.Join(entries, ",") + `]}`
+	if err := os.WriteFile(filepath.Join(dir, "FILES.json"), []byte(files), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if !verifyInstalledFiles(dir, true) || !verifyInstalledFiles(dir, false) {
+		t.Fatal("an untouched tree must verify")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "plugins", "mod_3.py"), []byte("tampered\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if verifyInstalledFiles(dir, true) {
+		t.Fatal("a modified file must fail the full check")
+	}
+	if err := os.Remove(filepath.Join(dir, "FILES.json")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if verifyInstalledFiles(dir, false) {
+		t.Fatal("a tree without FILES.json cannot be verified")
+	}
+}
+
+func TestCanSkipInstallWithoutGalaxyInfo(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{DownloadPath: t.TempDir(), GalaxyInfo: config.GalaxyInfoDefault, InfoCheck: config.InfoCheckRequire}
+	col := collection{Namespace: "a", Name: "b", Version: "1.0.0"}
+	dir := col.installDir(cfg)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	files := filepath.Join(dir, "FILES.json")
+	if err := os.WriteFile(files, []byte(`{"files":[{"name":".","ftype":"dir"}]}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	sum, err := archive.FileHashSHA256(files)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	manifest := fmt.Sprintf(`{"collection_info":{"namespace":"a","name":"b","version":"1.0.0"},"file_manifest_file":{"chksum_sha256":%q}}`, sum)
+	if err := os.WriteFile(filepath.Join(dir, "MANIFEST.json"), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".extract-done.id"), nil, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	st := store.New()
+	st.SetInstalled(col.key(), store.InstalledEntry{InstallPath: dir, ArtifactID: "id"})
+
+	if canSkipInstall(cfg, col, dir, st, "") {
+		t.Fatal("a collection without GALAXY.yml must be reinstalled by default")
+	}
+	manifestCheck := *cfg
+	manifestCheck.InfoCheck = config.InfoCheckManifest
+	if !canSkipInstall(&manifestCheck, col, dir, st, "") {
+		t.Fatal("a matching MANIFEST.json must allow the skip")
+	}
+	if err := os.Remove(filepath.Join(dir, ".extract-done.id")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if !canSkipInstall(&manifestCheck, col, dir, st, "") {
+		t.Fatal("a matching MANIFEST.json must allow the skip without the extraction marker")
+	}
+	if !canSkipInstall(&manifestCheck, col, dir, store.New(), "") {
+		t.Fatal("a matching MANIFEST.json must allow the skip without a store entry")
+	}
+	if canSkipInstall(cfg, col, dir, store.New(), "") {
+		t.Fatal("a collection without a store entry must be reinstalled by default")
+	}
+	chmod := manifestCheck
+	chmod.ChmodFiles = 0o644
+	if canSkipInstall(&chmod, col, dir, store.New(), "") {
+		t.Fatal("a tree of unknown modes must be reinstalled under --chmod-files")
+	}
+	if err := os.WriteFile(files, []byte(`{"files":[]}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if canSkipInstall(&manifestCheck, col, dir, st, "") {
+		t.Fatal("a FILES.json that no longer matches MANIFEST.json must fail the skip")
+	}
+}
@@ -64,3 +64,24 @@ func parseCount(value any) int {
 		return 0
 	}
 }
+
+// parseVersionTimestamps maps versions to their created_at field, when present.
+func parseVersionTimestamps(payload map[string]any) map[string]string {
+	items, ok := payload["data"].([]any)
+	if !ok {
+		items, _ = payload["results"].([]any)
+	}
+	out := make(map[string]string, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		version := extractVersionField(item)
+		created, _ := m["created_at"].(string)
+		if version != "" && created != "" {
+			out[version] = created
+		}
+	}
+	return out
+}
@@ -0,0 +1,192 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+// WarmFromLock downloads every collection pinned by the given lockfiles into
+// the artifact cache, without resolving dependencies or installing anything.
+// A lockfile is an install-manifest.json or a requirements file that pins
+// exact versions; a collection pinned by several files or install paths is
+// fetched once.
+func WarmFromLock(ctx context.Context, cfg *config.Config, runtime *infra.Infra, lockPaths []string) error {
+	runtime.Output.Printf("🔥 Warming cache from %d lockfiles", len(lockPaths))
+	start := time.Now()
+	cols, err := loadLockedCollections(cfg, runtime, lockPaths)
+	if err != nil {
+		return err
+	}
+	state, err := openInstallState(ctx, cfg, runtime)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = state.release()
+	}()
+	defer func() {
+		_ = state.backend.Close(context.WithoutCancel(ctx))
+	}()
+
+	cached := cachedArtifacts(ctx, state.backend.Artifacts(), cols, cfg.DownloadWorkerCount())
+	missing := make([]collection, 0, len(cols))
+	for i, col := range cols {
+		if !cached[i] {
+			missing = append(missing, col)
+		}
+	}
+	runtime.Output.Printf("📦 %d pinned collections, %d already cached", len(cols), len(cols)-len(missing))
+
+	failures := warmArtifacts(ctx, newInstallDeps(cfg, runtime, state.store, state.backend.Artifacts(), nil), missing)
+	if ctx.Err() != nil {
+		flushOnShutdown(ctx, runtime, state)
+		return fmt.Errorf("%w: %w", helpers.ErrInterrupted, context.Cause(ctx))
+	}
+	if err := state.backend.SaveStore(ctx, state.store); err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		runtime.Output.PersistentPrintf("⚠️ Cache warm-up completed with errors: %d failed. Took %s",
+			len(failures), time.Since(start).Round(time.Second))
+		return &installFailure{
+			summary: fmt.Errorf("%w for %d collections", helpers.ErrCacheWarmFailed, len(failures)),
+			causes:  failures,
+		}
+	}
+	runtime.Output.PersistentPrintf("🤩 Cached %d collections. Took %s", len(missing), time.Since(start).Round(time.Second))
+	return nil
+}
+
+// warmArtifacts downloads cols into the artifact cache, bounded by the
+// download workers, and returns the failures in the order of cols.
+func warmArtifacts(ctx context.Context, deps installDeps, cols []collection) []error {
+	errs := make([]error, len(cols))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, deps.cfg.DownloadWorkerCount())
+	for i, col := range cols {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			if err := warmArtifact(ctx, deps, col); err != nil {
+				deps.runtime.Output.Errorf("Failed: %s error: %s", col.key(), err)
+				errs[i] = helpers.Classify(err, col.key())
+				return
+			}
+			deps.runtime.Output.Okf("Cached: %s", col.key())
+		})
+	}
+	wg.Wait()
+	return slices.DeleteFunc(errs, func(err error) bool { return err == nil })
+}
+
+// warmArtifact fetches the version metadata of col and caches its tarball.
+func warmArtifact(ctx context.Context, deps installDeps, col collection) error {
+	meta, err := loadCollectionMetadata(ctx, deps.collectionDeps, col)
+	if err != nil {
+		return err
+	}
+	result, err := downloadCollectionToCache(ctx, deps, col, meta, true)
+	cleanupIfNeeded(result.Cleanup)
+	return err
+}
+
+// loadLockedCollections reads the pinned collections of every lockfile,
+// keeping one entry per artifact.
+func loadLockedCollections(cfg *config.Config, runtime *infra.Infra, lockPaths []string) ([]collection, error) {
+	if len(lockPaths) == 0 {
+		return nil, helpers.ErrNoLockfiles
+	}
+	unique := make(map[string]collection)
+	for _, path := range lockPaths {
+		var (
+			cols []collection
+			err  error
+		)
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			cols, err = loadManifestLock(cfg, path)
+		} else {
+			cols, err = loadRequirementsLock(cfg, path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, col := range cols {
+			if !isGalaxyType(col.Type) || col.isLocalArtifact() {
+				runtime.Output.Printf("⚠️ %s: skipping %s.%s, only Galaxy collections are cached", path, col.Namespace, col.Name)
+				continue
+			}
+			unique[artifactKey(col)] = col
+		}
+	}
+	cols := make([]collection, 0, len(unique))
+	for _, key := range slices.Sorted(maps.Keys(unique)) {
+		cols = append(cols, unique[key])
+	}
+	return cols, nil
+}
+
+// loadManifestLock reads the collections recorded in an install manifest.
+func loadManifestLock(cfg *config.Config, path string) ([]collection, error) {
+	manifest, err := readInstallManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	cols := make([]collection, 0, len(manifest.Collections))
+	for fqdn, entry := range manifest.Collections {
+		col, err := manifestCollection(cfg, fqdn, entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// manifestCollection returns the collection an install manifest pins
+// under fqdn. Sources are stored redacted, so one matching the redacted
+// server is taken to be the configured server, credentials included.
+func manifestCollection(cfg *config.Config, fqdn string, entry ManifestEntry) (collection, error) {
+	namespace, name, ok := helpers.SplitFQDN(fqdn)
+	if !ok {
+		return collection{}, fmt.Errorf("%w: %s", helpers.ErrInvalidCollectionName, fqdn)
+	}
+	source := entry.Source
+	if source == "" || strings.TrimRight(source, "/") == strings.TrimRight(progress.Redact(cfg.Server), "/") {
+		source = cfg.Server
+	}
+	return collection{Namespace: namespace, Name: name, Version: entry.Version, Source: source, InstallPath: entry.InstallPath}, nil
+}
+
+// loadRequirementsLock reads a requirements file in which every collection
+// is pinned to one exact version.
+func loadRequirementsLock(cfg *config.Config, path string) ([]collection, error) {
+	cols, _, err := loadRequirements(path, cfg.Server, cfg.Strict)
+	if err != nil {
+		return nil, err
+	}
+	for i, col := range cols {
+		if !isGalaxyType(col.Type) {
+			continue
+		}
+		version, exact, err := exactVersionFromConstraints([]string{col.Version})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s.%s: %w", path, col.Namespace, col.Name, err)
+		}
+		if !exact {
+			return nil, fmt.Errorf("%w: %s: %s.%s %q", helpers.ErrLockEntryNotPinned, path, col.Namespace, col.Name, col.Version)
+		}
+		cols[i].Version = version
+	}
+	return cols, nil
+}
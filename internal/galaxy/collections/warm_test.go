@@ -0,0 +1,93 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+// newWarmServer serves metadata and a tarball for every requested version,
+// counting the tarball downloads.
+func newWarmServer(t *testing.T, downloads *atomic.Int32) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/download/") {
+			downloads.Add(1)
+			_, _ = fmt.Fprint(w, "tarball")
+			return
+		}
+		// /api/v3/collections/<ns>/<name>/[versions/<version>/]
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v3/collections/"), "/"), "/")
+		switch len(parts) {
+		case 2:
+			_, _ = fmt.Fprintf(w, `{"namespace":%q,"name":%q,"versions_url":"/api/v3/collections/%s/%s/versions/"}`,
+				parts[0], parts[1], parts[0], parts[1])
+		case 4:
+			_, _ = fmt.Fprintf(w, `{"version":%q,"download_url":"%s/download/%s-%s-%s.tar.gz"}`,
+				parts[3], srv.URL, parts[0], parts[1], parts[3])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWarmFromLockCachesEachPinOnce(t *testing.T) {
+	t.Parallel()
+	var downloads atomic.Int32
+	srv := newWarmServer(t, &downloads)
+	dir := t.TempDir()
+	reqs := filepath.Join(dir, "requirements.lock.yml")
+	data := "collections:\n  - name: a.b\n    version: 1.0.0\n  - name: c.d\n    version: '==2.0.0'\n    install_path: other\n"
+	if err := os.WriteFile(reqs, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	manifest := filepath.Join(dir, "install-manifest.json")
+	data = fmt.Sprintf(`{"schema_version":%d,"collections":{"a.b":{"version":"1.0.0"},"e.f":{"version":"3.0.0","source":%q}}}`,
+		helpers.InstallManifestSchemaVersion, srv.URL)
+	if err := os.WriteFile(manifest, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg := &config.Config{Server: srv.URL, CacheDir: t.TempDir(), Workers: 2}
+	runtime := infra.New(progress.New(false, true), srv.Client())
+
+	if err := WarmFromLock(context.Background(), cfg, runtime, []string{reqs, manifest}); err != nil {
+		t.Fatalf("WarmFromLock: %v", err)
+	}
+	if got := downloads.Load(); got != 3 {
+		t.Fatalf("expected 3 downloads, got %d", got)
+	}
+	if err := WarmFromLock(context.Background(), cfg, runtime, []string{manifest}); err != nil {
+		t.Fatalf("WarmFromLock again: %v", err)
+	}
+	if got := downloads.Load(); got != 3 {
+		t.Fatalf("expected cached artifacts to be reused, got %d downloads", got)
+	}
+}
+
+func TestWarmFromLockRejectsRanges(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "requirements.yml")
+	if err := os.WriteFile(path, []byte("collections:\n  - name: a.b\n    version: '>=1.0.0'\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg := &config.Config{Server: "https://galaxy.invalid", CacheDir: t.TempDir()}
+	err := WarmFromLock(context.Background(), cfg, infra.New(progress.New(false, true), http.DefaultClient), []string{path})
+	if !errors.Is(err, helpers.ErrLockEntryNotPinned) {
+		t.Fatalf("expected ErrLockEntryNotPinned, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/urfave/cli/v2"
+)
+
+// ChaosConfig injects failures into an HTTP client or the cache backend so
+// retry and soft-fail settings can be exercised on purpose. Zero values
+// inject nothing.
+type ChaosConfig struct {
+	// ErrorRate is the fraction of requests answered with a synthetic 503, or
+	// of cache backend calls failed with helpers.ErrChaosInjected.
+	ErrorRate float64
+	// SlowDownload delays every artifact download by this long.
+	SlowDownload time.Duration
+	// Seed makes the sequence of injected errors repeatable; 0 picks a random one.
+	Seed uint64
+}
+
+// Enabled reports whether any failure is injected.
+func (c ChaosConfig) Enabled() bool {
+	return c.ErrorRate > 0 || c.SlowDownload > 0
+}
+
+// loadChaos applies the hidden --chaos-* flags: S3 errors go to the S3
+// client, cache errors to the cache backend whatever it is, slow downloads
+// to the Galaxy client.
+func loadChaos(c *cli.Context, cfg *Config) error {
+	s3Rate, err := chaosRate(c, "chaos-s3-error-rate")
+	if err != nil {
+		return err
+	}
+	cacheRate, err := chaosRate(c, "chaos-cache-error-rate")
+	if err != nil {
+		return err
+	}
+	seed := c.Uint64("chaos-seed")
+	cfg.S3Cache.HTTP.Chaos = ChaosConfig{ErrorRate: s3Rate, Seed: seed}
+	cfg.CacheChaos = ChaosConfig{ErrorRate: cacheRate, Seed: seed}
+	cfg.HTTP.Chaos = ChaosConfig{SlowDownload: max(c.Duration("chaos-slow-download"), 0), Seed: seed}
+	return nil
+}
+
+// chaosRate reads an error rate flag, which must lie in 0..1.
+func chaosRate(c *cli.Context, name string) (float64, error) {
+	rate := c.Float64(name)
+	if rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("%w: --%s %v", helpers.ErrInvalidChaosRate, name, rate)
+	}
+	return rate, nil
+}
@@ -3,11 +3,21 @@ package config
 import (
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"path"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/Masterminds/semver"
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	"github.com/greeddj/go-galaxy/internal/galaxy/crypt"
 	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
 	"github.com/urfave/cli/v2"
 )
@@ -17,18 +27,72 @@ type Config struct {
 	Verbose                    bool
 	Quiet                      bool
 	RequirementsFile           string
+	RequirementsData           []byte
+	Strict                     bool
 	CacheDir                   string
+	CacheNamespace             string
+	CacheBackend               string
+	CacheEncryptionKey         []byte
+	CacheSoftFail              bool
+	CacheMigrateFrom           string
+	CacheChaos                 ChaosConfig
+	TempDir                    string
 	DownloadPath               string
+	Bundle                     string
+	Artifacts                  []string
+	Trace                      string
+	Profile                    string
 	Server                     string
+	Mirrors                    []string
 	S3Cache                    S3CacheConfig
+	HTTP                       HTTPConfig
+	Sources                    []SourceConfig
+	LeaseLock                  LeaseLockConfig
 	ClearCache                 bool
+	Bust                       []string
 	NoCache                    bool
 	Refresh                    bool
 	NoDeps                     bool
+	Lockfile                   string
+	NoRetry                    bool
+	Frozen                     bool
+	Only                       []string
+	ToolVersion                string
+	Skip                       []string
 	DryRun                     bool
+	CleanupProject             string
+	CollectionsPathRelative    bool
+	QuarantineDir              string
+	QuarantineRetention        time.Duration
+	ProjectTTL                 time.Duration
 	Timeout                    time.Duration
 	Workers                    int
+	ResolveWorkers             int
+	DownloadWorkers            int
+	InstallWorkers             int
+	ExtractWorkers             int
+	DecompressBlocks           int
+	DecompressBlockSize        int
+	ArchiveMaxEntries          int
+	ArchiveMaxDepth            int
+	ArtifactHash               string
+	GalaxyInfo                 string
+	InstallTemplate            string
+	ChmodFiles                 os.FileMode
+	ChmodDirs                  os.FileMode
+	TargetAnsibleCore          []string
+	TargetPython               []string
+	HealthCheck                string
+	DepsSource                 string
+	Replacements               map[string]Replacement
+	VerifySkip                 string
+	InfoCheck                  string
+	MaxTotalDownload           int64
+	MaxInstallTime             time.Duration
+	BudgetPolicy               string
+	Output                     string
 	AnsibleConfigPath          string
+	CollectionsSearchPath      []string
 	AnsibleCollectionsPathUsed bool
 	AnsibleCacheDirUsed        bool
 	AnsibleServerUsed          bool
@@ -42,6 +106,18 @@ func (c *Config) IsNoCache() bool {
 	return c.NoCache
 }
 
+// Secrets returns credential values that must never appear in output.
+func (c *Config) Secrets() []string {
+	if c == nil {
+		return nil
+	}
+	secrets := []string{c.S3Cache.AccessKey, c.S3Cache.SecretKey, c.S3Cache.SessionToken}
+	for _, src := range c.Sources {
+		secrets = append(secrets, src.Token, src.Password)
+	}
+	return secrets
+}
+
 // IsRefresh reports whether cache refresh is requested.
 func (c *Config) IsRefresh() bool {
 	if c == nil {
@@ -71,42 +147,583 @@ type CollectionOptions struct {
 func BuildCollectionConfig(c *cli.Context) (*Config, error) {
 	cfg := newConfigFromCLI(c)
 	applyTimeout(cfg, c)
+	cfg.HTTP = loadHTTPConfig(c)
+	if err := loadRequirementsData(cfg, c); err != nil {
+		return nil, err
+	}
 
 	ansibleConfig, ansiblePath, err := loadAnsibleConfigFromCLI(c)
 	if err != nil {
 		return nil, err
 	}
 	applyAnsibleConfig(cfg, c, ansibleConfig, ansiblePath)
+	if cfg.Sources, err = loadSources(ansibleConfig.GalaxyServers); err != nil {
+		return nil, err
+	}
+	if cfg.Mirrors, err = loadMirrors(c); err != nil {
+		return nil, err
+	}
+
+	namespace, err := loadCacheNamespace(c)
+	if err != nil {
+		return nil, err
+	}
+	cfg.CacheNamespace = namespace
+	cfg.CacheBackend = strings.TrimSpace(c.String("cache-backend"))
+
+	if cfg.Only, err = loadRootFilter(c, "only"); err != nil {
+		return nil, err
+	}
+	if cfg.Skip, err = loadRootFilter(c, "skip"); err != nil {
+		return nil, err
+	}
+	if cfg.Bust, err = loadBust(c); err != nil {
+		return nil, err
+	}
+	if cfg.Artifacts, err = loadArtifacts(c); err != nil {
+		return nil, err
+	}
 
 	s3Cfg, err := loadS3CacheConfig(c)
 	if err != nil {
 		return nil, err
 	}
 	cfg.S3Cache = s3Cfg
+	cfg.LeaseLock = loadLeaseLockConfig(c)
+	if err := loadChaos(c, cfg); err != nil {
+		return nil, err
+	}
+
+	if _, err := archive.NewHash(cfg.ArtifactHash); err != nil {
+		return nil, err
+	}
+	if cfg.GalaxyInfo, err = parseGalaxyInfo(c.String("galaxy-info")); err != nil {
+		return nil, err
+	}
+	if cfg.HealthCheck, err = parseHealthCheck(c.String("health-check")); err != nil {
+		return nil, err
+	}
+	if cfg.DepsSource, err = parseDepsSource(c.String("deps-source")); err != nil {
+		return nil, err
+	}
+	if cfg.Replacements, err = loadReplacements(c); err != nil {
+		return nil, err
+	}
+	if cfg.VerifySkip, err = parseVerifySkip(c.String("verify-skip")); err != nil {
+		return nil, err
+	}
+	if cfg.InfoCheck, err = parseInfoCheck(c.String("info-check")); err != nil {
+		return nil, err
+	}
+	if cfg.Output, err = parseOutput(c.String("output")); err != nil {
+		return nil, err
+	}
+	if cfg.Lockfile != "" && !cfg.NoDeps {
+		return nil, fmt.Errorf("%w: %s", helpers.ErrLockfileNeedsNoDeps, cfg.Lockfile)
+	}
+	if cfg.InstallTemplate, err = parseInstallTemplate(c.String("install-template")); err != nil {
+		return nil, err
+	}
+	if cfg.ChmodFiles, err = parseChmod("chmod-files", c.String("chmod-files")); err != nil {
+		return nil, err
+	}
+	if cfg.ChmodDirs, err = parseDirChmod("chmod-dirs", c.String("chmod-dirs")); err != nil {
+		return nil, err
+	}
+	if cfg.TargetAnsibleCore, err = parseTargetVersions("target-ansible-core", c.StringSlice("target-ansible-core")); err != nil {
+		return nil, err
+	}
+	if cfg.TargetPython, err = parseTargetVersions("target-python", c.StringSlice("target-python")); err != nil {
+		return nil, err
+	}
+	if cfg.MaxTotalDownload, err = parseByteSize(c.String("max-total-download")); err != nil {
+		return nil, err
+	}
+	cfg.MaxInstallTime = max(c.Duration("max-install-time"), 0)
+	if cfg.BudgetPolicy, err = parseBudgetPolicy(c.String("budget-policy")); err != nil {
+		return nil, err
+	}
+
+	key, err := crypt.ParseKey(c.String("cache-encryption-key"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.CacheEncryptionKey = key
 
 	return cfg, nil
 }
 
+// RequirementsStdin is the --requirements-file value that reads requirements from stdin.
+const RequirementsStdin = "-"
+
+// loadRequirementsData reads requirements given with --requirements-inline or
+// on stdin (-r -) into cfg.RequirementsData. Inline requirements win.
+func loadRequirementsData(cfg *Config, c *cli.Context) error {
+	if inline := c.String("requirements-inline"); strings.TrimSpace(inline) != "" {
+		cfg.RequirementsData = []byte(inline)
+		return nil
+	}
+	if cfg.RequirementsFile != RequirementsStdin {
+		return nil
+	}
+	reader := io.Reader(os.Stdin)
+	if c.App != nil && c.App.Reader != nil {
+		reader = c.App.Reader
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("read requirements from stdin: %w", err)
+	}
+	cfg.RequirementsData = data
+	return nil
+}
+
+// RequirementsName names where requirements come from in messages.
+func (c *Config) RequirementsName() string {
+	switch {
+	case c.RequirementsData == nil:
+		return c.RequirementsFile
+	case c.RequirementsFile == RequirementsStdin:
+		return "stdin"
+	default:
+		return "--requirements-inline"
+	}
+}
+
 func newConfigFromCLI(c *cli.Context) *Config {
 	cfg := &Config{
 		Workers:          c.Int("workers"),
+		ResolveWorkers:   max(c.Int("resolve-workers"), 0),
+		DownloadWorkers:  max(c.Int("download-workers"), 0),
+		InstallWorkers:   max(c.Int("install-workers"), 0),
 		RequirementsFile: c.String("requirements-file"),
+		Strict:           c.Bool("strict"),
 		ClearCache:       c.Bool("clear-cache"),
 		NoCache:          c.Bool("no-cache"),
 		Refresh:          c.Bool("refresh"),
 		NoDeps:           c.Bool("no-deps"),
+		Lockfile:         strings.TrimSpace(c.String("lockfile")),
+		NoRetry:          c.Bool("no-retry"),
+		Frozen:           c.Bool("frozen"),
 		DryRun:           c.Bool("dry-run"),
 		DownloadPath:     c.String("download-path"),
+		TempDir:          strings.TrimSpace(c.String("tmp-dir")),
+		CacheSoftFail:    c.Bool("cache-soft-fail"),
+		CacheMigrateFrom: strings.TrimSpace(c.String("cache-migrate-from")),
+		Bundle:           strings.TrimSpace(c.String("bundle")),
+		Trace:            strings.TrimSpace(c.String("trace")),
+		Profile:          strings.TrimSpace(c.String("profile")),
+		CleanupProject:   strings.TrimSpace(c.String("project")),
+		ProjectTTL:       max(c.Duration("project-ttl"), 0),
+
+		CollectionsPathRelative: c.Bool("collections-path-relative"),
+		QuarantineDir:           strings.TrimSpace(c.String("quarantine-dir")),
+		QuarantineRetention:     time.Duration(max(c.Int("quarantine-days"), 0)) * 24 * time.Hour,
 	}
 
 	if cfg.Workers < 1 {
 		cfg.Workers = runtime.NumCPU()
 	}
+	cfg.ExtractWorkers = min(cfg.InstallWorkerCount(), runtime.GOMAXPROCS(0))
+	cfg.DecompressBlocks = decompressBlocks(c.Int("decompress-blocks"), cfg.ExtractWorkers)
+	cfg.DecompressBlockSize = max(c.Int("decompress-block-size"), 0) * 1024
+	cfg.ArchiveMaxEntries = max(c.Int("archive-max-entries"), 0)
+	cfg.ArchiveMaxDepth = max(c.Int("archive-max-depth"), 0)
+	cfg.ArtifactHash = strings.ToLower(strings.TrimSpace(c.String("artifact-hash")))
+	if cfg.ArtifactHash == "" {
+		cfg.ArtifactHash = archive.HashSHA256
+	}
+	if c.App != nil {
+		cfg.ToolVersion = c.App.Version
+	}
 	cfg.Verbose = c.Bool("verbose")
 	cfg.Quiet = !cfg.Verbose && c.Bool("quiet")
 	return cfg
 }
 
+// GALAXY.yml modes selected with --galaxy-info.
+const (
+	// GalaxyInfoDefault writes the standard GALAXY.yml fields.
+	GalaxyInfoDefault = "default"
+	// GalaxyInfoExtended adds the resolved dependency list and artifact sha256.
+	GalaxyInfoExtended = "extended"
+	// GalaxyInfoAnsible writes exactly what ansible-galaxy writes.
+	GalaxyInfoAnsible = "ansible"
+	// GalaxyInfoNone skips the .info directory entirely.
+	GalaxyInfoNone = "none"
+)
+
+// parseGalaxyInfo validates the --galaxy-info mode; empty means default.
+func parseGalaxyInfo(value string) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(value))
+	switch mode {
+	case "":
+		return GalaxyInfoDefault, nil
+	case GalaxyInfoDefault, GalaxyInfoExtended, GalaxyInfoAnsible, GalaxyInfoNone:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %q (use %s, %s, %s or %s)", helpers.ErrInvalidGalaxyInfoMode, value,
+			GalaxyInfoDefault, GalaxyInfoExtended, GalaxyInfoAnsible, GalaxyInfoNone)
+	}
+}
+
+// parseChmod reads an octal permission such as 0644; empty means the
+// archive's modes are kept.
+func parseChmod(flag, value string) (os.FileMode, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(strings.TrimPrefix(value, "0o"), 8, 32)
+	if err != nil || mode == 0 || mode > 0o777 {
+		return 0, fmt.Errorf("%w: --%s %q", helpers.ErrInvalidChmodMode, flag, value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// parseDirChmod reads a directory mode like parseChmod. The mode must keep
+// owner read, write and search (0o700): the modes are set while the tree is
+// walked, and a directory without them could not be descended into.
+func parseDirChmod(flag, value string) (os.FileMode, error) {
+	mode, err := parseChmod(flag, value)
+	if err != nil {
+		return 0, err
+	}
+	if mode != 0 && mode&0o700 != 0o700 {
+		return 0, fmt.Errorf("%w: --%s %q must keep owner rwx (0700)", helpers.ErrInvalidChmodMode, flag, value)
+	}
+	return mode, nil
+}
+
+// PermissionModes describes the --chmod-files and --chmod-dirs modes applied
+// to installed collections, or returns "" when the archive's modes are kept.
+func (c *Config) PermissionModes() string {
+	if c == nil || (c.ChmodFiles == 0 && c.ChmodDirs == 0) {
+		return ""
+	}
+	return fmt.Sprintf("files=%s dirs=%s", formatMode(c.ChmodFiles), formatMode(c.ChmodDirs))
+}
+
+// parseTargetVersions reads the versions of a runtime the install targets,
+// such as 2.16.3 or 3.11, dropping repeats in order.
+func parseTargetVersions(flag string, values []string) ([]string, error) {
+	var out []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || slices.Contains(out, value) {
+			continue
+		}
+		if _, err := semver.NewVersion(value); err != nil {
+			return nil, fmt.Errorf("%w: --%s %q", helpers.ErrInvalidTargetVersion, flag, value)
+		}
+		out = append(out, value)
+	}
+	return out, nil
+}
+
+// formatMode prints mode in octal, or "archive" when it is not set.
+func formatMode(mode os.FileMode) string {
+	if mode == 0 {
+		return "archive"
+	}
+	return fmt.Sprintf("%04o", uint32(mode))
+}
+
+// Server health gate policies selected with --health-check.
+const (
+	// HealthCheckFail aborts before resolving when the server is unhealthy.
+	HealthCheckFail = "fail"
+	// HealthCheckSnapshot falls back to the stored resolution when the server is unhealthy.
+	HealthCheckSnapshot = "snapshot"
+	// HealthCheckOff skips the health gate.
+	HealthCheckOff = "off"
+)
+
+// parseHealthCheck validates the --health-check policy; empty means fail.
+func parseHealthCheck(value string) (string, error) {
+	policy := strings.ToLower(strings.TrimSpace(value))
+	switch policy {
+	case "":
+		return HealthCheckFail, nil
+	case HealthCheckFail, HealthCheckSnapshot, HealthCheckOff:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: %q (use %s, %s or %s)", helpers.ErrInvalidHealthCheckPolicy, value,
+			HealthCheckFail, HealthCheckSnapshot, HealthCheckOff)
+	}
+}
+
+// Dependency sources selected with --deps-source.
+const (
+	// DepsSourceMetadata prefers the dependencies in the server's version metadata.
+	DepsSourceMetadata = "metadata"
+	// DepsSourceManifest prefers the dependencies in the collection's MANIFEST.json.
+	DepsSourceManifest = "manifest"
+)
+
+// parseDepsSource validates the --deps-source policy; empty means metadata.
+func parseDepsSource(value string) (string, error) {
+	source := strings.ToLower(strings.TrimSpace(value))
+	switch source {
+	case "":
+		return DepsSourceMetadata, nil
+	case DepsSourceMetadata, DepsSourceManifest:
+		return source, nil
+	default:
+		return "", fmt.Errorf("%w: %q (use %s or %s)", helpers.ErrInvalidDepsSource, value, DepsSourceMetadata, DepsSourceManifest)
+	}
+}
+
+// Budget policies selected with --budget-policy.
+const (
+	// BudgetAbort fails the install once a budget is exceeded.
+	BudgetAbort = "abort"
+	// BudgetWarn completes the install and reports exceeded budgets.
+	BudgetWarn = "warn"
+)
+
+// parseBudgetPolicy validates the --budget-policy value; empty means abort.
+func parseBudgetPolicy(value string) (string, error) {
+	policy := strings.ToLower(strings.TrimSpace(value))
+	switch policy {
+	case "":
+		return BudgetAbort, nil
+	case BudgetAbort, BudgetWarn:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: %q (use %s or %s)", helpers.ErrInvalidBudgetPolicy, value, BudgetAbort, BudgetWarn)
+	}
+}
+
+// byteUnit is a size suffix and the number of bytes it stands for.
+type byteUnit struct {
+	suffix string
+	factor int64
+}
+
+// byteUnits returns the size suffixes accepted by parseByteSize, longest first.
+func byteUnits() []byteUnit {
+	return []byteUnit{
+		{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30}, {"tib", 1 << 40},
+		{"kb", 1e3}, {"mb", 1e6}, {"gb", 1e9}, {"tb", 1e12},
+		{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30}, {"t", 1 << 40},
+		{"b", 1},
+	}
+}
+
+// parseByteSize parses sizes like 500MiB, 2GB or 1048576; empty means no limit.
+// Binary units (KiB, MiB, ...) and bare K, M, G, T are powers of 1024,
+// KB, MB, GB and TB powers of 1000.
+func parseByteSize(value string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	if s == "" {
+		return 0, nil
+	}
+	factor := int64(1)
+	for _, unit := range byteUnits() {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, factor = strings.TrimSpace(number), unit.factor
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 || n*float64(factor) > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %q", helpers.ErrInvalidSize, value)
+	}
+	return int64(n * float64(factor)), nil
+}
+
+// DefaultInstallTemplate is the ansible-galaxy layout under ansible_collections.
+const DefaultInstallTemplate = "{namespace}/{name}"
+
+// RenderInstallTemplate expands {namespace}, {name} and {version} in template.
+func RenderInstallTemplate(template, namespace, name, version string) string {
+	return strings.NewReplacer("{namespace}", namespace, "{name}", name, "{version}", version).Replace(template)
+}
+
+// parseInstallTemplate validates --install-template; empty and the default
+// layout both mean the default. The template must name the collection and
+// render to a path inside ansible_collections.
+func parseInstallTemplate(value string) (string, error) {
+	template := strings.Trim(strings.TrimSpace(value), "/")
+	if template == "" || template == DefaultInstallTemplate {
+		return "", nil
+	}
+	rendered := filepath.FromSlash(RenderInstallTemplate(template, "ns", "name", "1.0.0"))
+	if !strings.Contains(template, "{namespace}") || !strings.Contains(template, "{name}") || !filepath.IsLocal(rendered) ||
+		rendered != filepath.Clean(rendered) {
+		return "", fmt.Errorf("%w: %q", helpers.ErrInvalidInstallTemplate, value)
+	}
+	return template, nil
+}
+
+// Installed-tree checks selected with --verify-skip before skipping an install.
+const (
+	// VerifySkipOff trusts the extraction marker.
+	VerifySkipOff = "off"
+	// VerifySkipSample checks a random sample of files against FILES.json.
+	VerifySkipSample = "sample"
+	// VerifySkipAll checks every file against FILES.json.
+	VerifySkipAll = "all"
+)
+
+// How --info-check treats an installed collection whose .info/GALAXY.yml is missing.
+const (
+	// InfoCheckRequire reinstalls it.
+	InfoCheckRequire = "require"
+	// InfoCheckManifest skips it when its MANIFEST.json names it and matches its FILES.json.
+	InfoCheckManifest = "manifest"
+)
+
+// parseInfoCheck validates the --info-check mode; empty means require.
+func parseInfoCheck(value string) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(value))
+	switch mode {
+	case "":
+		return InfoCheckRequire, nil
+	case InfoCheckRequire, InfoCheckManifest:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %q (use %s or %s)", helpers.ErrInvalidInfoCheckMode, value, InfoCheckRequire, InfoCheckManifest)
+	}
+}
+
+// How install reports the requirements hash and resolution digest.
+const (
+	// OutputText prints them as a progress line.
+	OutputText = "text"
+	// OutputJSON writes them to stdout as one JSON object.
+	OutputJSON = "json"
+)
+
+// parseOutput validates the --output format; empty means text.
+func parseOutput(value string) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(value))
+	switch format {
+	case "":
+		return OutputText, nil
+	case OutputText, OutputJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %q (use %s or %s)", helpers.ErrUnsupportedOutputFormat, value, OutputText, OutputJSON)
+	}
+}
+
+// parseVerifySkip validates the --verify-skip mode; empty means off.
+func parseVerifySkip(value string) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(value))
+	switch mode {
+	case "":
+		return VerifySkipOff, nil
+	case VerifySkipOff, VerifySkipSample, VerifySkipAll:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %q (use %s, %s or %s)", helpers.ErrInvalidVerifySkipMode, value,
+			VerifySkipOff, VerifySkipSample, VerifySkipAll)
+	}
+}
+
+// maxDecompressBlocks matches the pgzip default read-ahead.
+const maxDecompressBlocks = 16
+
+// decompressBlocks returns the requested block count, or splits GOMAXPROCS
+// across concurrent extractions so small containers are not oversubscribed.
+func decompressBlocks(requested, extractWorkers int) int {
+	if requested > 0 {
+		return requested
+	}
+	return min(max(runtime.GOMAXPROCS(0)/max(extractWorkers, 1), 2), maxDecompressBlocks)
+}
+
+// loadRootFilter reads and validates collection name glob patterns.
+func loadRootFilter(c *cli.Context, name string) ([]string, error) {
+	var patterns []string
+	for _, raw := range c.StringSlice(name) {
+		for part := range strings.SplitSeq(raw, ",") {
+			pattern := strings.TrimSpace(part)
+			if pattern == "" {
+				continue
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%w: --%s %q", helpers.ErrInvalidRootFilter, name, pattern)
+			}
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns, nil
+}
+
+// loadBust validates the --bust collection names.
+func loadBust(c *cli.Context) ([]string, error) {
+	var names []string
+	for _, raw := range c.StringSlice("bust") {
+		for part := range strings.SplitSeq(raw, ",") {
+			name := strings.TrimSpace(part)
+			if name == "" {
+				continue
+			}
+			if _, _, ok := helpers.SplitFQDN(name); !ok {
+				return nil, fmt.Errorf("%w: --bust %q (use namespace.name)", helpers.ErrInvalidCollectionName, name)
+			}
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// loadArtifacts resolves the --artifact paths and checks each names a regular file.
+func loadArtifacts(c *cli.Context) ([]string, error) {
+	var paths []string
+	for _, raw := range c.StringSlice("artifact") {
+		path := strings.TrimSpace(raw)
+		if path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: --artifact %q: %w", helpers.ErrInvalidArtifactFile, path, err)
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, fmt.Errorf("%w: --artifact %q: %w", helpers.ErrInvalidArtifactFile, path, err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("%w: --artifact %q is not a file", helpers.ErrInvalidArtifactFile, path)
+		}
+		paths = append(paths, abs)
+	}
+	return paths, nil
+}
+
+// loadCacheNamespace validates the cache namespace flag.
+func loadCacheNamespace(c *cli.Context) (string, error) {
+	namespace := strings.TrimSpace(c.String("cache-namespace"))
+	if namespace == "" {
+		return "", nil
+	}
+	if namespace == "." || namespace == ".." {
+		return "", fmt.Errorf("%w: %q", helpers.ErrInvalidCacheNamespace, namespace)
+	}
+	for _, r := range namespace {
+		if !isNamespaceRune(r) {
+			return "", fmt.Errorf("%w: %q", helpers.ErrInvalidCacheNamespace, namespace)
+		}
+	}
+	return namespace, nil
+}
+
+// isNamespaceRune reports whether r is allowed in a cache namespace.
+func isNamespaceRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r == '-' || r == '_' || r == '.':
+		return true
+	default:
+		return false
+	}
+}
+
 func applyTimeout(cfg *Config, c *cli.Context) {
 	cfg.Timeout = c.Duration("timeout")
 	cfg.Timeout = max(cfg.Timeout, helpers.FetchDefaultTimeout)
@@ -130,12 +747,19 @@ func applyAnsibleConfig(cfg *Config, c *cli.Context, ansibleConfig ansibleConfig
 	} else {
 		cfg.DownloadPath = c.String("download-path")
 	}
+	if cfg.DownloadPath == "" {
+		cfg.DownloadPath = defaultDownloadPath()
+	}
+	cfg.CollectionsSearchPath = collectionsSearchPath(ansibleConfig.Defaults.CollectionsPath)
 	if ansibleConfig.Galaxy.CacheDir != "" {
 		cfg.CacheDir = ansibleConfig.Galaxy.CacheDir
 		cfg.AnsibleCacheDirUsed = true
 	} else {
 		cfg.CacheDir = c.String("cache-dir")
 	}
+	if cfg.CacheDir == "" {
+		cfg.CacheDir = defaultCacheDir()
+	}
 	if ansibleConfig.Galaxy.Server != "" {
 		cfg.Server = ansibleConfig.Galaxy.Server
 		cfg.AnsibleServerUsed = true
@@ -144,6 +768,32 @@ func applyAnsibleConfig(cfg *Config, c *cli.Context, ansibleConfig ansibleConfig
 	}
 }
 
+// ansibleSystemCollectionsPath is the last element of Ansible's default collections search path.
+const ansibleSystemCollectionsPath = "/usr/share/ansible/collections"
+
+// collectionsSearchPath returns the path elements Ansible searches for collections, in
+// precedence order: ANSIBLE_COLLECTIONS_PATH, then ansible.cfg, then Ansible's defaults.
+func collectionsSearchPath(fromConfig string) []string {
+	raw := os.Getenv("ANSIBLE_COLLECTIONS_PATH")
+	if raw == "" {
+		raw = fromConfig
+	}
+	if raw == "" {
+		home, err := os.UserHomeDir()
+		if err != nil || home == "" {
+			return []string{ansibleSystemCollectionsPath}
+		}
+		return []string{filepath.Join(home, ".ansible", "collections"), ansibleSystemCollectionsPath}
+	}
+	var paths []string
+	for _, elem := range filepath.SplitList(raw) {
+		if elem = strings.TrimSpace(elem); elem != "" {
+			paths = append(paths, expandHome(elem))
+		}
+	}
+	return paths
+}
+
 /*
 env: ANSIBLE_CONFIG (environment variable if set)
 ansible.cfg (in the current directory)
@@ -169,8 +819,9 @@ type ansibleDefaultsConfig struct {
 
 // ansibleConfig represents the parsed ansible.cfg structure.
 type ansibleConfig struct {
-	Defaults ansibleDefaultsConfig `toml:"defaults"`
-	Galaxy   ansibleGalaxyConfig   `toml:"galaxy"`
+	Defaults      ansibleDefaultsConfig          `toml:"defaults"`
+	Galaxy        ansibleGalaxyConfig            `toml:"galaxy"`
+	GalaxyServers map[string]ansibleGalaxyServer `toml:"galaxy_server"`
 }
 
 // loadAnsibleConfig loads ansible.cfg if it exists.
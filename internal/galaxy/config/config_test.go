@@ -0,0 +1,203 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/urfave/cli/v2"
+)
+
+func TestLoadRequirementsData(t *testing.T) {
+	t.Parallel()
+	newContext := func(args ...string) *cli.Context {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.String("requirements-inline", "", "")
+		if err := set.Parse(args); err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		app := &cli.App{Reader: strings.NewReader("collections:\n  - a.b\n")}
+		return cli.NewContext(app, set, nil)
+	}
+
+	cfg := &Config{RequirementsFile: RequirementsStdin}
+	if err := loadRequirementsData(cfg, newContext()); err != nil {
+		t.Fatalf("stdin: %v", err)
+	}
+	if string(cfg.RequirementsData) != "collections:\n  - a.b\n" || cfg.RequirementsName() != "stdin" {
+		t.Fatalf("requirements must be read from stdin: %q", cfg.RequirementsData)
+	}
+
+	cfg = &Config{RequirementsFile: "requirements.yml"}
+	if err := loadRequirementsData(cfg, newContext("-requirements-inline", "collections: [c.d]")); err != nil {
+		t.Fatalf("inline: %v", err)
+	}
+	if string(cfg.RequirementsData) != "collections: [c.d]" || cfg.RequirementsName() != "--requirements-inline" {
+		t.Fatalf("inline requirements must win: %q", cfg.RequirementsData)
+	}
+
+	cfg = &Config{RequirementsFile: "requirements.yml"}
+	if err := loadRequirementsData(cfg, newContext()); err != nil || cfg.RequirementsData != nil {
+		t.Fatalf("a requirements file must be left to the loader: %v", err)
+	}
+}
+
+func TestParseInstallTemplate(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{value: "", want: "", ok: true},
+		{value: "{namespace}/{name}", want: "", ok: true},
+		{value: "{namespace}/{name}-{version}/", want: "{namespace}/{name}-{version}", ok: true},
+		{value: "{namespace}/{version}", ok: false},
+		{value: "{namespace}/../{name}", ok: false},
+	}
+	for _, tc := range cases {
+		got, err := parseInstallTemplate(tc.value)
+		if tc.ok && (err != nil || got != tc.want) {
+			t.Fatalf("parseInstallTemplate(%q) = %q, %v; want %q", tc.value, got, err, tc.want)
+		}
+		if !tc.ok && !errors.Is(err, helpers.ErrInvalidInstallTemplate) {
+			t.Fatalf("parseInstallTemplate(%q) error = %v", tc.value, err)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	t.Parallel()
+	cases := map[string]int64{
+		"":        0,
+		"1048576": 1 << 20,
+		"500MiB":  500 << 20,
+		"500 mib": 500 << 20,
+		"2GB":     2e9,
+		"1.5K":    1536,
+		"10b":     10,
+	}
+	for value, want := range cases {
+		got, err := parseByteSize(value)
+		if err != nil || got != want {
+			t.Fatalf("parseByteSize(%q) = %d, %v; want %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"lots", "-1MiB", "MiB"} {
+		if _, err := parseByteSize(value); !errors.Is(err, helpers.ErrInvalidSize) {
+			t.Fatalf("parseByteSize(%q) error = %v", value, err)
+		}
+	}
+}
+
+func TestParseDirChmod(t *testing.T) {
+	t.Parallel()
+	cases := map[string]os.FileMode{
+		"":      0,
+		"0755":  0o755,
+		"0o700": 0o700,
+		"775":   0o775,
+	}
+	for value, want := range cases {
+		got, err := parseDirChmod("chmod-dirs", value)
+		if err != nil || got != want {
+			t.Fatalf("parseDirChmod(%q) = %o, %v; want %o", value, got, err, want)
+		}
+	}
+	// Modes without owner rwx would stop the walk that applies them partway.
+	for _, value := range []string{"0644", "0500", "0300", "0077", "0888", "01777"} {
+		if _, err := parseDirChmod("chmod-dirs", value); !errors.Is(err, helpers.ErrInvalidChmodMode) {
+			t.Fatalf("parseDirChmod(%q) error = %v", value, err)
+		}
+	}
+	if mode, err := parseChmod("chmod-files", "0644"); err != nil || mode != 0o644 {
+		t.Fatalf("parseChmod(0644) = %o, %v; file modes need no owner rwx", mode, err)
+	}
+}
+
+func TestLoadCacheNamespace(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{value: "", want: "", ok: true},
+		{value: "   ", want: "", ok: true},
+		{value: "team-a", want: "team-a", ok: true},
+		{value: " CI_2.x ", want: "CI_2.x", ok: true},
+		{value: "..", ok: false},
+		{value: ".", ok: false},
+		{value: "team/a", ok: false},
+		{value: "../escape", ok: false},
+		{value: `team\a`, ok: false},
+		{value: "team a", ok: false},
+		{value: "équipe", ok: false},
+	}
+	for _, tc := range cases {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.String("cache-namespace", "", "")
+		if err := set.Parse([]string{"-cache-namespace", tc.value}); err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		got, err := loadCacheNamespace(cli.NewContext(&cli.App{}, set, nil))
+		if tc.ok {
+			if err != nil || got != tc.want {
+				t.Fatalf("loadCacheNamespace(%q) = %q, %v; want %q", tc.value, got, err, tc.want)
+			}
+			continue
+		}
+		if !errors.Is(err, helpers.ErrInvalidCacheNamespace) {
+			t.Fatalf("loadCacheNamespace(%q) error = %v", tc.value, err)
+		}
+	}
+}
+
+func TestLoadSourcesFromAnsibleConfig(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "ansible.cfg")
+	data := `[galaxy]
+server = "https://galaxy.ansible.com"
+
+[galaxy_server.hub]
+url = "https://hub.example.com/api/galaxy/"
+token = "secret"
+validate_certs = false
+timeout = 90
+proxy = "http://proxy.example.com:3128"
+
+[galaxy_server.public]
+url = "https://galaxy.ansible.com"
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	parsed, _, err := loadAnsibleConfig(path)
+	if err != nil {
+		t.Fatalf("loadAnsibleConfig: %v", err)
+	}
+	sources, err := loadSources(parsed.GalaxyServers)
+	if err != nil {
+		t.Fatalf("loadSources: %v", err)
+	}
+	if len(sources) != 2 || sources[0].Name != "hub" || sources[1].Name != "public" {
+		t.Fatalf("unexpected sources %+v", sources)
+	}
+	hub := sources[0]
+	if hub.Token != "secret" || hub.Timeout != 90*time.Second || hub.Proxy == nil || hub.TLS == nil || !hub.TLS.InsecureSkipVerify {
+		t.Fatalf("unexpected hub settings %+v", hub)
+	}
+	if sources[1].TLS != nil || sources[1].Proxy != nil {
+		t.Fatalf("expected defaults for public, got %+v", sources[1])
+	}
+
+	_, err = loadSources(map[string]ansibleGalaxyServer{"hub": {URL: "https://hub.example.com", CACert: "/nonexistent.pem"}})
+	if !errors.Is(err, helpers.ErrInvalidSourceConfig) {
+		t.Fatalf("expected ErrInvalidSourceConfig, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const (
+	// toolDirName is the per-tool directory under the cache base directories.
+	toolDirName = "go-galaxy"
+	// projectCollectionsPath is the download path used when ANSIBLE_HOME is unset.
+	projectCollectionsPath = ".collections"
+	// fallbackHomeDir is used when the user's home directory cannot be determined.
+	fallbackHomeDir = "/root"
+)
+
+// defaultCacheDir returns the cache directory used when neither the flag,
+// its environment variables nor ansible.cfg set one. Fallback order:
+// $ANSIBLE_HOME/galaxy_cache/go-galaxy, $XDG_CACHE_HOME/go-galaxy,
+// ~/.cache/go-galaxy. The bolt store stays next to the artifacts rather than
+// under XDG_STATE_HOME: it only holds data that can be rebuilt from the servers.
+func defaultCacheDir() string {
+	if home := os.Getenv("ANSIBLE_HOME"); home != "" {
+		return filepath.Join(expandHome(home), "galaxy_cache", toolDirName)
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, toolDirName)
+	}
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		return filepath.Join(home, ".cache", toolDirName)
+	}
+	return filepath.Join(fallbackHomeDir, ".cache", toolDirName)
+}
+
+// defaultDownloadPath returns $ANSIBLE_HOME/collections, matching Ansible's
+// own default, or the project-local .collections directory.
+func defaultDownloadPath() string {
+	if home := os.Getenv("ANSIBLE_HOME"); home != "" {
+		return filepath.Join(expandHome(home), "collections")
+	}
+	return projectCollectionsPath
+}
+
+// expandHome replaces a leading ~ with the user's home directory.
+func expandHome(p string) string {
+	rest, ok := cutHomePrefix(p)
+	if !ok {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return p
+	}
+	return filepath.Join(home, rest)
+}
+
+func cutHomePrefix(p string) (string, bool) {
+	if p == "~" {
+		return "", true
+	}
+	if len(p) > 1 && p[0] == '~' && os.IsPathSeparator(p[1]) {
+		return p[2:], true
+	}
+	return "", false
+}
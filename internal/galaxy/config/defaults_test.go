@@ -0,0 +1,37 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultDirsHonorAnsibleHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("ANSIBLE_HOME", home)
+	t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+
+	if got := defaultCacheDir(); got != filepath.Join(home, "galaxy_cache", "go-galaxy") {
+		t.Fatalf("unexpected cache dir: %q", got)
+	}
+	if got := defaultDownloadPath(); got != filepath.Join(home, "collections") {
+		t.Fatalf("unexpected download path: %q", got)
+	}
+}
+
+func TestDefaultDirsFallBackToXDG(t *testing.T) {
+	t.Setenv("ANSIBLE_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+
+	if got := defaultCacheDir(); got != filepath.Join("/xdg/cache", "go-galaxy") {
+		t.Fatalf("unexpected cache dir: %q", got)
+	}
+	if got := defaultDownloadPath(); got != ".collections" {
+		t.Fatalf("unexpected download path: %q", got)
+	}
+
+	t.Setenv("XDG_CACHE_HOME", "relative/ignored")
+	t.Setenv("HOME", "/home/runner")
+	if got := defaultCacheDir(); got != filepath.Join("/home/runner", ".cache", "go-galaxy") {
+		t.Fatalf("relative XDG_CACHE_HOME must be ignored: %q", got)
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import "github.com/urfave/cli/v2"
+
+// HTTPConfig tunes the connection pool of one HTTP client.
+// Zero values keep the client defaults.
+type HTTPConfig struct {
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	DisableHTTP2        bool
+	// HTTP3 tries GET and HEAD requests over HTTP/3 first, falling back to TCP.
+	HTTP3 bool
+	// Chaos injects failures for testing; see --chaos-* flags.
+	Chaos ChaosConfig
+}
+
+// loadHTTPConfig builds the Galaxy client tuning from CLI flags.
+func loadHTTPConfig(c *cli.Context) HTTPConfig {
+	return HTTPConfig{
+		MaxIdleConnsPerHost: max(c.Int("http-max-idle-conns-per-host"), 0),
+		MaxConnsPerHost:     max(c.Int("http-max-conns-per-host"), 0),
+		DisableHTTP2:        c.Bool("http2-disabled"),
+		HTTP3:               c.Bool("http3"),
+	}
+}
+
+// loadS3HTTPConfig builds the S3 client tuning from CLI flags. The S3 client
+// has its own pool so prefetch traffic to the bucket is not throttled by the
+// per-host limits meant for Galaxy.
+func loadS3HTTPConfig(c *cli.Context) HTTPConfig {
+	return HTTPConfig{
+		MaxIdleConnsPerHost: max(c.Int("s3-max-idle-conns-per-host"), 0),
+		MaxConnsPerHost:     max(c.Int("s3-max-conns-per-host"), 0),
+		DisableHTTP2:        c.Bool("http2-disabled"),
+		HTTP3:               c.Bool("http3"),
+	}
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// LeaseLockConfig defines the Kubernetes Lease coordination lock.
+type LeaseLockConfig struct {
+	Enabled   bool
+	Name      string
+	Namespace string
+	Duration  time.Duration
+}
+
+// loadLeaseLockConfig builds the lease lock config from CLI flags.
+func loadLeaseLockConfig(c *cli.Context) LeaseLockConfig {
+	cfg := LeaseLockConfig{
+		Name:      strings.TrimSpace(c.String("lock-lease")),
+		Namespace: strings.TrimSpace(c.String("lock-lease-namespace")),
+		Duration:  c.Duration("lock-lease-duration"),
+	}
+	cfg.Enabled = cfg.Name != ""
+	return cfg
+}
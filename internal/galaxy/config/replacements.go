@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Replacement names the successor of a renamed collection.
+type Replacement struct {
+	// Name is the namespace.name of the successor.
+	Name string `yaml:"name"`
+	// Version constrains the successor; empty accepts any version.
+	Version string `yaml:"version"`
+}
+
+// UnmarshalYAML accepts a bare successor name as well as a name/version mapping.
+func (r *Replacement) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		r.Name = node.Value
+		return nil
+	}
+	type plain Replacement
+	return node.Decode((*plain)(r))
+}
+
+// loadReplacements reads the --replacements-file mapping of renamed
+// collections to their successors:
+//
+//	community.kubernetes: kubernetes.core
+//	old.name: {name: new.name, version: ">=2.0.0"}
+func loadReplacements(c *cli.Context) (map[string]Replacement, error) {
+	path := strings.TrimSpace(c.String("replacements-file"))
+	if path == "" {
+		return nil, nil
+	}
+	//nolint:gosec // path is a user-provided replacements file.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replacements file: %w", err)
+	}
+	var replacements map[string]Replacement
+	if err := yaml.Unmarshal(data, &replacements); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", helpers.ErrInvalidReplacement, path, err)
+	}
+	for from, to := range replacements {
+		_, _, fromOK := helpers.SplitFQDN(from)
+		_, _, toOK := helpers.SplitFQDN(to.Name)
+		if !fromOK || !toOK || from == to.Name {
+			return nil, fmt.Errorf("%w: %s: %s -> %s", helpers.ErrInvalidReplacement, path, from, to.Name)
+		}
+	}
+	return replacements, nil
+}
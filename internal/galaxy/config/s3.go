@@ -16,6 +16,7 @@ type S3CacheConfig struct {
 	SecretKey    string
 	SessionToken string
 	PathStyle    bool
+	HTTP         HTTPConfig
 }
 
 // loadS3CacheConfig builds S3 cache config from CLI flags.
@@ -28,6 +29,7 @@ func loadS3CacheConfig(c *cli.Context) (S3CacheConfig, error) {
 		AccessKey:    c.String("s3-access-key"),
 		SecretKey:    c.String("s3-secret-key"),
 		SessionToken: c.String("s3-session-token"),
+		HTTP:         loadS3HTTPConfig(c),
 	}
 
 	if cfg.Bucket == "" {
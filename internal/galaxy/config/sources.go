@@ -0,0 +1,146 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/urfave/cli/v2"
+)
+
+// SourceConfig holds the connection settings of one Galaxy server, so a
+// private hub with its own CA, client certificate or credentials can be used
+// next to public Galaxy in the same run.
+type SourceConfig struct {
+	// Name is the galaxy_server section the settings come from.
+	Name string
+	// URL is the server URL; requests to its host use these settings.
+	URL      string
+	Token    string
+	Username string
+	Password string
+	// TLS is nil when the server needs no TLS settings of its own.
+	TLS *tls.Config
+	// Proxy is nil when the proxy comes from the environment.
+	Proxy *url.URL
+	// Timeout is 0 when the global --timeout applies.
+	Timeout time.Duration
+}
+
+// ansibleGalaxyServer maps a [galaxy_server.<name>] section from ansible.cfg.
+// ca_cert, client_cert, client_key and proxy are go-galaxy extensions.
+type ansibleGalaxyServer struct {
+	URL           string `toml:"url"`
+	Token         string `toml:"token"`
+	Username      string `toml:"username"`
+	Password      string `toml:"password"`
+	ValidateCerts *bool  `toml:"validate_certs"`
+	Timeout       int    `toml:"timeout"`
+	CACert        string `toml:"ca_cert"`
+	ClientCert    string `toml:"client_cert"`
+	ClientKey     string `toml:"client_key"`
+	Proxy         string `toml:"proxy"`
+}
+
+// loadSources builds the per-server settings of the galaxy_server sections,
+// ordered by name.
+func loadSources(servers map[string]ansibleGalaxyServer) ([]SourceConfig, error) {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	sources := make([]SourceConfig, 0, len(names))
+	for _, name := range names {
+		src, err := loadSource(name, servers[name])
+		if err != nil {
+			return nil, fmt.Errorf("%w: galaxy_server.%s: %w", helpers.ErrInvalidSourceConfig, name, err)
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// loadSource builds the settings of one galaxy_server section.
+func loadSource(name string, server ansibleGalaxyServer) (SourceConfig, error) {
+	src := SourceConfig{
+		Name:     name,
+		URL:      strings.TrimSpace(server.URL),
+		Token:    strings.TrimSpace(server.Token),
+		Username: server.Username,
+		Password: server.Password,
+		Timeout:  time.Duration(max(server.Timeout, 0)) * time.Second,
+	}
+	parsed, err := url.Parse(src.URL)
+	if err != nil || parsed.Host == "" {
+		return SourceConfig{}, fmt.Errorf("url %q is not absolute", src.URL)
+	}
+	if proxy := strings.TrimSpace(server.Proxy); proxy != "" {
+		if src.Proxy, err = url.Parse(proxy); err != nil {
+			return SourceConfig{}, fmt.Errorf("proxy: %w", err)
+		}
+	}
+	src.TLS, err = sourceTLS(server)
+	if err != nil {
+		return SourceConfig{}, err
+	}
+	return src, nil
+}
+
+// loadMirrors reads the --server-mirror URLs, which serve the same content
+// as the configured server.
+func loadMirrors(c *cli.Context) ([]string, error) {
+	var mirrors []string
+	for _, raw := range c.StringSlice("server-mirror") {
+		mirror := strings.TrimRight(strings.TrimSpace(raw), "/")
+		if mirror == "" {
+			continue
+		}
+		parsed, err := url.Parse(mirror)
+		if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return nil, fmt.Errorf("%w: %q is not an absolute http(s) url", helpers.ErrInvalidServerMirror, raw)
+		}
+		mirrors = append(mirrors, mirror)
+	}
+	return mirrors, nil
+}
+
+// sourceTLS builds the TLS settings of a galaxy_server section, or nil when
+// it keeps the defaults.
+func sourceTLS(server ansibleGalaxyServer) (*tls.Config, error) {
+	insecure := server.ValidateCerts != nil && !*server.ValidateCerts
+	if !insecure && server.CACert == "" && server.ClientCert == "" && server.ClientKey == "" {
+		return nil, nil
+	}
+	//nolint:gosec // validate_certs = false is an explicit opt-out, as in ansible-galaxy.
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: insecure}
+	if server.CACert != "" {
+		//nolint:gosec // path is a user-provided CA bundle.
+		pem, err := os.ReadFile(expandHome(server.CACert))
+		if err != nil {
+			return nil, fmt.Errorf("ca_cert: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca_cert %s: no certificates found", server.CACert)
+		}
+		cfg.RootCAs = pool
+	}
+	if server.ClientCert != "" || server.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(expandHome(server.ClientCert), expandHome(server.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("client_cert: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+// resolveWorkersFactor is how many metadata requests run per --workers slot
+// when --resolve-workers is unset: they mostly wait on the server.
+const resolveWorkersFactor = 2
+
+// ResolveWorkerCount returns how many collections are resolved concurrently:
+// --resolve-workers, or a multiple of Workers when it is unset.
+func (c *Config) ResolveWorkerCount() int {
+	if c.ResolveWorkers > 0 {
+		return c.ResolveWorkers
+	}
+	return max(c.Workers, 1) * resolveWorkersFactor
+}
+
+// DownloadWorkerCount returns how many artifacts are prefetched concurrently:
+// --download-workers, or Workers when it is unset.
+func (c *Config) DownloadWorkerCount() int {
+	if c.DownloadWorkers > 0 {
+		return c.DownloadWorkers
+	}
+	return max(c.Workers, 1)
+}
+
+// InstallWorkerCount returns how many collections are installed concurrently:
+// --install-workers, or Workers when it is unset. Extraction within them is
+// further bounded by ExtractWorkers.
+func (c *Config) InstallWorkerCount() int {
+	if c.InstallWorkers > 0 {
+		return c.InstallWorkers
+	}
+	return max(c.Workers, 1)
+}
@@ -0,0 +1,34 @@
+package config
+
+import "testing"
+
+func TestWorkerCounts(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name                       string
+		cfg                        Config
+		resolve, download, install int
+	}{
+		{name: "derived", cfg: Config{Workers: 4}, resolve: 8, download: 4, install: 4},
+		{name: "unset workers", cfg: Config{}, resolve: 2, download: 1, install: 1},
+		{
+			name:    "explicit",
+			cfg:     Config{Workers: 4, ResolveWorkers: 32, DownloadWorkers: 6, InstallWorkers: 2},
+			resolve: 32, download: 6, install: 2,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tc.cfg.ResolveWorkerCount(); got != tc.resolve {
+				t.Fatalf("ResolveWorkerCount = %d, want %d", got, tc.resolve)
+			}
+			if got := tc.cfg.DownloadWorkerCount(); got != tc.download {
+				t.Fatalf("DownloadWorkerCount = %d, want %d", got, tc.download)
+			}
+			if got := tc.cfg.InstallWorkerCount(); got != tc.install {
+				t.Fatalf("InstallWorkerCount = %d, want %d", got, tc.install)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package conformance
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/output"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// Case outcomes.
+const (
+	// StatusMatch is a case resolved exactly as ansible-galaxy resolved it.
+	StatusMatch = "match"
+	// StatusDiverged is a case resolved differently.
+	StatusDiverged = "diverged"
+	// StatusKnown is a case resolved differently for a documented reason.
+	StatusKnown = "known"
+)
+
+// Outcomes of a whole resolution in a Divergence on collection "*".
+const (
+	resolved = "resolved"
+	failed   = "failed"
+)
+
+// Result is the outcome of one case.
+type Result struct {
+	Case        string       `json:"case"`
+	Description string       `json:"description,omitempty"`
+	Status      string       `json:"status"`
+	Divergences []Divergence `json:"divergences,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	Note        string       `json:"note,omitempty"`
+}
+
+// Divergence is a collection the two tools resolved differently; an empty
+// version means the tool did not install the collection.
+type Divergence struct {
+	Collection    string `json:"collection"`
+	AnsibleGalaxy string `json:"ansible_galaxy"`
+	GoGalaxy      string `json:"go_galaxy"`
+}
+
+// Run resolves every case against its recorded index and compares the result
+// with ansible-galaxy's. Workers and the dependency source follow base; the
+// rest of the configuration is ignored so that cases stay reproducible.
+func Run(ctx context.Context, base *config.Config, out output.Printer, cases []Case) []Result {
+	results := make([]Result, 0, len(cases))
+	for _, c := range cases {
+		results = append(results, runCase(ctx, base, out, c))
+	}
+	return results
+}
+
+// runCase resolves one case.
+func runCase(ctx context.Context, base *config.Config, out output.Printer, c Case) Result {
+	cfg := &config.Config{
+		Server:           corpusServer,
+		RequirementsData: []byte(c.Requirements),
+		Workers:          max(base.Workers, 1),
+		ResolveWorkers:   base.ResolveWorkers,
+		DepsSource:       base.DepsSource,
+		HealthCheck:      config.HealthCheckOff,
+		Timeout:          base.Timeout,
+		Verbose:          base.Verbose,
+	}
+	runtime := infra.New(out, &http.Client{Transport: corpusTransport{index: c.Index}})
+	out.Debugf("conformance case %s", c.Name)
+	got, err := collections.ResolveRequirements(ctx, cfg, runtime, store.New())
+
+	res := Result{Case: c.Name, Description: c.Description, Status: StatusMatch}
+	switch {
+	case err != nil && c.AnsibleGalaxyFails:
+		// both tools refuse the case
+	case err != nil:
+		res.Error = err.Error()
+		res.Divergences = []Divergence{{Collection: "*", AnsibleGalaxy: resolved, GoGalaxy: failed}}
+	case c.AnsibleGalaxyFails:
+		res.Divergences = []Divergence{{Collection: "*", AnsibleGalaxy: failed, GoGalaxy: resolved}}
+	default:
+		res.Divergences = compare(c.AnsibleGalaxy, got)
+	}
+	if len(res.Divergences) > 0 {
+		res.Status = StatusDiverged
+		if c.KnownDivergence != "" {
+			res.Status, res.Note = StatusKnown, c.KnownDivergence
+		}
+	}
+	return res
+}
+
+// compare lists the collections whose versions differ between want and got.
+func compare(want, got map[string]string) []Divergence {
+	names := slices.Sorted(maps.Keys(want))
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	var out []Divergence
+	for _, name := range names {
+		if want[name] != got[name] {
+			out = append(out, Divergence{Collection: name, AnsibleGalaxy: want[name], GoGalaxy: got[name]})
+		}
+	}
+	return out
+}
+
+// Diverged reports whether any result diverged without a documented reason.
+func Diverged(results []Result) bool {
+	return slices.ContainsFunc(results, func(r Result) bool { return r.Status == StatusDiverged })
+}
@@ -0,0 +1,67 @@
+package conformance
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestBuiltinCorpus(t *testing.T) {
+	t.Parallel()
+	cases, err := LoadCorpus("")
+	if err != nil {
+		t.Fatalf("LoadCorpus: %v", err)
+	}
+	results := Run(context.Background(), &config.Config{Workers: 2}, progress.New(false, true), cases)
+	for _, res := range results {
+		if res.Status == StatusDiverged {
+			t.Errorf("case %s diverged from ansible-galaxy: %+v %s", res.Case, res.Divergences, res.Error)
+		}
+	}
+}
+
+func TestRunReportsDivergence(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	data := `name: wrong
+requirements: |
+  collections:
+    - name: a.b
+index:
+  a.b:
+    1.0.0: {}
+    1.1.0: {}
+ansible_galaxy:
+  a.b: 1.0.0
+`
+	if err := os.WriteFile(filepath.Join(dir, "wrong.yml"), []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cases, err := LoadCorpus(dir)
+	if err != nil {
+		t.Fatalf("LoadCorpus: %v", err)
+	}
+	results := Run(context.Background(), &config.Config{}, progress.New(false, true), cases)
+	if !Diverged(results) {
+		t.Fatalf("expected a divergence, got %+v", results)
+	}
+	want := Divergence{Collection: "a.b", AnsibleGalaxy: "1.0.0", GoGalaxy: "1.1.0"}
+	if got := results[0].Divergences; len(got) != 1 || got[0] != want {
+		t.Fatalf("unexpected divergences %+v", got)
+	}
+}
+
+func TestLoadCorpusRejectsIncompleteCase(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "empty.yml"), []byte("name: empty\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := LoadCorpus(dir); err == nil {
+		t.Fatal("expected an incomplete case to be rejected")
+	}
+}
@@ -0,0 +1,77 @@
+package conformance
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"gopkg.in/yaml.v3"
+)
+
+// corpusFiles is the built-in corpus.
+//
+//go:embed corpus/*.yml
+var corpusFiles embed.FS //nolint:gochecknoglobals // embedded files must be a package variable.
+
+// Case is one recorded ansible-galaxy resolution: a requirements file, the
+// server index it was resolved against and what ansible-galaxy installed.
+type Case struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Requirements is the requirements.yml given to both tools.
+	Requirements string `yaml:"requirements"`
+	// Index lists the versions the server offered, each with its dependencies.
+	Index map[string]map[string]map[string]string `yaml:"index"`
+	// AnsibleGalaxy maps every collection ansible-galaxy installed to its version.
+	AnsibleGalaxy map[string]string `yaml:"ansible_galaxy"`
+	// AnsibleGalaxyFails records that ansible-galaxy refused to resolve the case.
+	AnsibleGalaxyFails bool `yaml:"ansible_galaxy_fails"`
+	// KnownDivergence explains a difference that is deliberate; it is reported
+	// but does not fail the run.
+	KnownDivergence string `yaml:"known_divergence"`
+}
+
+// LoadCorpus reads the cases in dir, or the built-in corpus when dir is empty.
+func LoadCorpus(dir string) ([]Case, error) {
+	var fsys fs.FS = corpusFiles
+	root := "corpus"
+	if dir != "" {
+		fsys, root = os.DirFS(dir), "."
+	}
+	entries, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+	cases := make([]Case, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (!strings.HasSuffix(name, ".yml") && !strings.HasSuffix(name, ".yaml")) {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(root, name))
+		if err != nil {
+			return nil, err
+		}
+		var c Case
+		if err := yaml.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", helpers.ErrInvalidConformanceCase, name, err)
+		}
+		if c.Name == "" {
+			c.Name = strings.TrimSuffix(strings.TrimSuffix(name, ".yml"), ".yaml")
+		}
+		if c.Requirements == "" || len(c.Index) == 0 || (len(c.AnsibleGalaxy) == 0 && !c.AnsibleGalaxyFails) {
+			return nil, fmt.Errorf("%w: %s: requirements, index and ansible_galaxy are required", helpers.ErrInvalidConformanceCase, name)
+		}
+		cases = append(cases, c)
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("%w: no cases in %s", helpers.ErrInvalidConformanceCase, dir)
+	}
+	slices.SortFunc(cases, func(a, b Case) int { return strings.Compare(a.Name, b.Name) })
+	return cases, nil
+}
@@ -0,0 +1,117 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// corpusServer is the server URL cases resolve against; requests never leave
+// the process.
+const corpusServer = "https://conformance.invalid"
+
+// corpusTransport answers the Galaxy v3 metadata requests the resolver makes
+// from a case's index.
+type corpusTransport struct {
+	index map[string]map[string]map[string]string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t corpusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// /api/v3/collections/<ns>/<name>/[versions/[<version>/]]
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/api/v3/collections/"), "/"), "/")
+	if len(parts) < 2 || len(parts) > 4 || (len(parts) > 2 && parts[2] != "versions") {
+		return respond(req, http.StatusNotFound, nil), nil
+	}
+	fqdn := parts[0] + "." + parts[1]
+	versions, ok := t.index[fqdn]
+	if !ok {
+		return respond(req, http.StatusNotFound, nil), nil
+	}
+	base := "/api/v3/collections/" + parts[0] + "/" + parts[1] + "/versions/"
+	switch len(parts) {
+	case 2:
+		return respond(req, http.StatusOK, map[string]any{
+			"namespace":       parts[0],
+			"name":            parts[1],
+			"versions_url":    base,
+			"highest_version": map[string]string{"version": highestVersion(versions)},
+		}), nil
+	case 3:
+		data := make([]map[string]string, 0, len(versions))
+		for _, version := range slices.Sorted(maps.Keys(versions)) {
+			data = append(data, map[string]string{"version": version, "href": base + version + "/"})
+		}
+		return respond(req, http.StatusOK, map[string]any{
+			"meta":  map[string]int{"count": len(data)},
+			"links": map[string]any{"next": nil},
+			"data":  data,
+		}), nil
+	default:
+		deps, ok := versions[parts[3]]
+		if !ok {
+			return respond(req, http.StatusNotFound, nil), nil
+		}
+		if deps == nil {
+			deps = map[string]string{}
+		}
+		return respond(req, http.StatusOK, map[string]any{
+			"version":  parts[3],
+			"metadata": map[string]any{"dependencies": deps},
+		}), nil
+	}
+}
+
+// respond builds a JSON response to req.
+func respond(req *http.Request, status int, body any) *http.Response {
+	payload := []byte(`{"errors":[{"status":"404","code":"not_found"}]}`)
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	return &http.Response{
+		Status:        http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(payload)),
+		ContentLength: int64(len(payload)),
+		Request:       req,
+	}
+}
+
+// highestVersion mirrors the server's highest_version: the newest release,
+// or the newest pre-release when there is no release.
+func highestVersion(versions map[string]map[string]string) string {
+	var highest, highestPre *semver.Version
+	for raw := range versions {
+		v, err := semver.NewVersion(raw)
+		if err != nil {
+			continue
+		}
+		if v.Prerelease() != "" {
+			if highestPre == nil || v.GreaterThan(highestPre) {
+				highestPre = v
+			}
+			continue
+		}
+		if highest == nil || v.GreaterThan(highest) {
+			highest = v
+		}
+	}
+	switch {
+	case highest != nil:
+		return highest.Original()
+	case highestPre != nil:
+		return highestPre.Original()
+	default:
+		return ""
+	}
+}
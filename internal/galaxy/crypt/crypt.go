@@ -0,0 +1,261 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+const (
+	// KeySize is the required key length in bytes (AES-256).
+	KeySize = 32
+
+	valueMagic  = "GGE1"
+	streamMagic = "GGS1"
+
+	chunkSize    = 64 << 10
+	prefixSize   = 7
+	counterSize  = 4
+	lengthSize   = 4
+	finalChunk   = 1
+	regularChunk = 0
+)
+
+// Cipher seals cache values and artifact files with AES-256-GCM.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// ParseKey decodes a hex or base64 encoded 32-byte key.
+func ParseKey(value string) ([]byte, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return nil, nil
+	}
+	if key, err := hex.DecodeString(trimmed); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := enc.DecodeString(trimmed); err == nil && len(key) == KeySize {
+			return key, nil
+		}
+	}
+	return nil, helpers.ErrInvalidCacheEncryptionKey
+}
+
+// New returns a Cipher for key, or nil when key is empty.
+func New(key []byte) (*Cipher, error) {
+	if len(key) == 0 {
+		return nil, nil
+	}
+	if len(key) != KeySize {
+		return nil, helpers.ErrInvalidCacheEncryptionKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// IsSealed reports whether data looks like a value produced by Seal.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(valueMagic))
+}
+
+// Seal encrypts a single value.
+func (c *Cipher) Seal(plain []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(valueMagic)+len(nonce)+len(plain)+c.aead.Overhead())
+	out = append(out, valueMagic...)
+	out = append(out, nonce...)
+	return c.aead.Seal(out, nonce, plain, []byte(valueMagic)), nil
+}
+
+// Open decrypts a value produced by Seal.
+func (c *Cipher) Open(data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return nil, helpers.ErrCacheValueNotEncrypted
+	}
+	rest := data[len(valueMagic):]
+	if len(rest) < c.aead.NonceSize() {
+		return nil, helpers.ErrCacheValueCorrupted
+	}
+	nonce, sealed := rest[:c.aead.NonceSize()], rest[c.aead.NonceSize():]
+	return c.aead.Open(nil, nonce, sealed, []byte(valueMagic))
+}
+
+// EncryptFile encrypts src into dst using chunked AES-GCM.
+func (c *Cipher) EncryptFile(src, dst string) error {
+	//nolint:gosec // src is a staged artifact created by this process.
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	//nolint:gosec // dst is derived from the cache directory.
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, helpers.FileMod)
+	if err != nil {
+		return err
+	}
+	if err := c.encryptStream(in, out); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// DecryptFile decrypts src produced by EncryptFile into dst.
+func (c *Cipher) DecryptFile(src, dst string) error {
+	//nolint:gosec // src is a cached artifact under the cache directory.
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	//nolint:gosec // dst is a temporary file created by this process.
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, helpers.FileMod)
+	if err != nil {
+		return err
+	}
+	if err := c.decryptStream(in, out); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// encryptStream writes the stream header followed by length-prefixed sealed chunks.
+// Each chunk nonce is prefix || counter || final flag, so reordering, truncation
+// and chunk reuse across files are all detected on decryption.
+func (c *Cipher) encryptStream(r io.Reader, w io.Writer) error {
+	prefix := make([]byte, prefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return err
+	}
+	if _, err := w.Write(append([]byte(streamMagic), prefix...)); err != nil {
+		return err
+	}
+	buf := make([]byte, chunkSize)
+	next := make([]byte, chunkSize)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	for counter := uint32(0); ; counter++ {
+		m, nextErr := io.ReadFull(r, next)
+		if nextErr != nil && !errors.Is(nextErr, io.EOF) && !errors.Is(nextErr, io.ErrUnexpectedEOF) {
+			return nextErr
+		}
+		final := m == 0
+		sealed := c.aead.Seal(nil, chunkNonce(prefix, counter, final), buf[:n], nil)
+		var length [lengthSize]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(sealed))) //nolint:gosec // chunk size is bounded.
+		if _, err := w.Write(length[:]); err != nil {
+			return err
+		}
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+		buf, next = next, buf
+		n = m
+	}
+}
+
+// decryptStream reverses encryptStream.
+func (c *Cipher) decryptStream(r io.Reader, w io.Writer) error {
+	header := make([]byte, len(streamMagic)+prefixSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("%w: %w", helpers.ErrCacheValueCorrupted, err)
+	}
+	if string(header[:len(streamMagic)]) != streamMagic {
+		return helpers.ErrCacheValueNotEncrypted
+	}
+	prefix := header[len(streamMagic):]
+	maxSealed := chunkSize + c.aead.Overhead()
+	sealed := make([]byte, maxSealed)
+	for counter := uint32(0); ; counter++ {
+		var length [lengthSize]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return fmt.Errorf("%w: %w", helpers.ErrCacheValueCorrupted, err)
+		}
+		size := int(binary.BigEndian.Uint32(length[:]))
+		if size > maxSealed {
+			return helpers.ErrCacheValueCorrupted
+		}
+		if _, err := io.ReadFull(r, sealed[:size]); err != nil {
+			return fmt.Errorf("%w: %w", helpers.ErrCacheValueCorrupted, err)
+		}
+		plain, err := c.aead.Open(nil, chunkNonce(prefix, counter, false), sealed[:size], nil)
+		final := false
+		if err != nil {
+			plain, err = c.aead.Open(nil, chunkNonce(prefix, counter, true), sealed[:size], nil)
+			if err != nil {
+				return err
+			}
+			final = true
+		}
+		if _, err := w.Write(plain); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// chunkNonce builds the nonce for a stream chunk.
+func chunkNonce(prefix []byte, counter uint32, final bool) []byte {
+	nonce := make([]byte, 0, prefixSize+counterSize+1)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if final {
+		return append(nonce, finalChunk)
+	}
+	return append(nonce, regularChunk)
+}
+
+// IsSealedFile reports whether the file at path was produced by EncryptFile.
+func IsSealedFile(path string) (bool, error) {
+	//nolint:gosec // path is a cached artifact under the cache directory.
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	header := make([]byte, len(streamMagic))
+	if _, err := io.ReadFull(f, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		return false, err
+	}
+	return string(header) == streamMagic, nil
+}
@@ -0,0 +1,107 @@
+package crypt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	t.Parallel()
+	c := mustCipher(t)
+	sealed, err := c.Seal([]byte("payload"))
+	if err != nil {
+		t.Fatalf("Seal error: %v", err)
+	}
+	if !IsSealed(sealed) {
+		t.Fatalf("expected sealed value")
+	}
+	plain, err := c.Open(sealed)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	if string(plain) != "payload" {
+		t.Fatalf("unexpected plaintext %q", plain)
+	}
+	sealed[len(sealed)-1] ^= 0xff
+	if _, err := c.Open(sealed); err == nil {
+		t.Fatalf("expected tampered value to fail")
+	}
+}
+
+func TestEncryptDecryptFile(t *testing.T) {
+	t.Parallel()
+	c := mustCipher(t)
+	for _, size := range []int{0, 10, chunkSize, chunkSize*2 + 7} {
+		dir := t.TempDir()
+		data := bytes.Repeat([]byte{'x'}, size)
+		src := filepath.Join(dir, "src")
+		enc := filepath.Join(dir, "enc")
+		dst := filepath.Join(dir, "dst")
+		if err := os.WriteFile(src, data, 0o600); err != nil {
+			t.Fatalf("write error: %v", err)
+		}
+		if err := c.EncryptFile(src, enc); err != nil {
+			t.Fatalf("EncryptFile error: %v", err)
+		}
+		if sealed, err := IsSealedFile(enc); err != nil || !sealed {
+			t.Fatalf("expected sealed file, got %v %v", sealed, err)
+		}
+		if err := c.DecryptFile(enc, dst); err != nil {
+			t.Fatalf("DecryptFile error: %v", err)
+		}
+		got, err := os.ReadFile(dst)
+		if err != nil {
+			t.Fatalf("read error: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("size %d: roundtrip mismatch", size)
+		}
+	}
+}
+
+func TestDecryptFileDetectsTruncation(t *testing.T) {
+	t.Parallel()
+	c := mustCipher(t)
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	enc := filepath.Join(dir, "enc")
+	if err := os.WriteFile(src, bytes.Repeat([]byte{'y'}, chunkSize+1), 0o600); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if err := c.EncryptFile(src, enc); err != nil {
+		t.Fatalf("EncryptFile error: %v", err)
+	}
+	data, err := os.ReadFile(enc)
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	first := len(streamMagic) + prefixSize + lengthSize + chunkSize + 16
+	if err := os.WriteFile(enc, data[:first], 0o600); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if err := c.DecryptFile(enc, filepath.Join(dir, "dst")); err == nil {
+		t.Fatalf("expected truncated stream to fail")
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+	hexKey := "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	if key, err := ParseKey(hexKey); err != nil || len(key) != KeySize {
+		t.Fatalf("unexpected hex parse: %v %v", key, err)
+	}
+	if _, err := ParseKey("short"); err == nil {
+		t.Fatalf("expected invalid key error")
+	}
+}
+
+func mustCipher(t *testing.T) *Cipher {
+	t.Helper()
+	c, err := New(make([]byte, KeySize))
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	return c
+}
@@ -0,0 +1,102 @@
+package daemon
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+// checkListen refuses to serve the API on a non-loopback address without a
+// token: /install writes wherever the daemon may, so only local clients are
+// trusted unauthenticated. An empty host listens on every interface.
+func checkListen(listen, token string) error {
+	if token != "" {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(host, "localhost") {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", helpers.ErrDaemonTokenRequired, listen)
+}
+
+// requireToken rejects requests that do not carry the daemon token as a
+// bearer token. Without a configured token, which checkListen only allows on
+// a loopback address, every request passes.
+func (d *daemon) requireToken(next http.Handler) http.Handler {
+	if d.token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(d.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, runResponse{Error: helpers.ErrDaemonUnauthorized.Error()})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedRoots resolves the directories request overrides may point into.
+func allowedRoots(paths []string) ([]string, error) {
+	roots := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if strings.TrimSpace(path) == "" {
+			continue
+		}
+		root, err := realPath(path)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+	return roots, nil
+}
+
+// checkOverride fails with ErrPathNotAllowed unless path, once symlinks are
+// resolved, lies inside one of the allowed roots.
+func (d *daemon) checkOverride(path string) error {
+	target, err := realPath(path)
+	if err != nil {
+		return err
+	}
+	for _, root := range d.allowPaths {
+		if rel, err := filepath.Rel(root, target); err == nil && (rel == "." || filepath.IsLocal(rel)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", helpers.ErrPathNotAllowed, path)
+}
+
+// realPath makes path absolute and resolves symlinks in the part of it that
+// exists, so a link inside an allowed root cannot lead out of it.
+func realPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rest := ""
+	for dir := abs; ; {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return abs, nil
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
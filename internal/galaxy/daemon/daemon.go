@@ -0,0 +1,350 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 30 * time.Second
+	maxRequestBody    = 64 << 10
+)
+
+// Options configures the daemon server.
+type Options struct {
+	Listen        string
+	WatchInterval time.Duration
+	// ProxyListen, when set, is the address of the Galaxy metadata proxy.
+	ProxyListen string
+	// Token, when set, must be sent as a bearer token with every API request.
+	// It is required unless Listen is a loopback address.
+	Token string
+	// AllowPaths are the directories requirements_file and download_path
+	// overrides may point into; without any, overrides are refused.
+	AllowPaths []string
+}
+
+// Status describes the daemon state returned by /status.
+type Status struct {
+	StartedAt        time.Time `json:"started_at"`
+	RequirementsFile string    `json:"requirements_file"`
+	DownloadPath     string    `json:"download_path"`
+	Runs             int       `json:"runs"`
+	Busy             bool      `json:"busy"`
+	Dirty            bool      `json:"dirty"`
+	LastRun          time.Time `json:"last_run,omitzero"`
+	LastError        string    `json:"last_error,omitempty"`
+}
+
+// runRequest optionally overrides paths for a single /resolve or /install call.
+type runRequest struct {
+	RequirementsFile string `json:"requirements_file"`
+	DownloadPath     string `json:"download_path"`
+}
+
+// runResponse is returned by /resolve and /install.
+type runResponse struct {
+	OK       bool              `json:"ok"`
+	Error    string            `json:"error,omitempty"`
+	Resolved map[string]string `json:"resolved,omitempty"`
+	Took     string            `json:"took"`
+}
+
+// daemon serves API requests against a long-lived session.
+type daemon struct {
+	cfg     *config.Config
+	runtime *infra.Infra
+	session *collections.Session
+
+	token      string
+	allowPaths []string
+
+	// runMu serializes resolve/install runs against the shared store.
+	runMu sync.Mutex
+
+	mu     sync.Mutex
+	status Status
+}
+
+// Run keeps the store in memory and serves /resolve, /install and /status until ctx is done.
+// With opts.ProxyListen set it also serves the metadata proxy there.
+func Run(ctx context.Context, cfg *config.Config, runtime *infra.Infra, opts Options) error {
+	if err := checkListen(opts.Listen, opts.Token); err != nil {
+		return err
+	}
+	var upstream string
+	if opts.ProxyListen != "" {
+		var err error
+		if upstream, err = proxyUpstream(cfg.Server); err != nil {
+			return err
+		}
+	}
+	allowPaths, err := allowedRoots(opts.AllowPaths)
+	if err != nil {
+		return err
+	}
+	session, err := collections.OpenSession(ctx, cfg, runtime)
+	if err != nil {
+		return err
+	}
+	d := &daemon{
+		cfg:        cfg,
+		runtime:    runtime,
+		session:    session,
+		token:      opts.Token,
+		allowPaths: allowPaths,
+		status: Status{
+			StartedAt:        time.Now().UTC(),
+			RequirementsFile: cfg.RequirementsFile,
+			DownloadPath:     cfg.DownloadPath,
+		},
+	}
+	defer func() {
+		// The signal context is already canceled here; persist with a fresh one.
+		if err := session.Close(context.WithoutCancel(ctx)); err != nil {
+			runtime.Output.Errorf("Failed to persist store: %s", err)
+		}
+	}()
+
+	serveErr := make(chan error, 2)
+	server, addr, err := serve(opts.Listen, d.routes(ctx), serveErr)
+	if err != nil {
+		return err
+	}
+	servers := []*http.Server{server}
+	runtime.Output.PersistentPrintf("🛰️ Daemon listening on %s", addr)
+	if opts.ProxyListen != "" {
+		proxy, proxyAddr, err := serve(opts.ProxyListen, d.proxyHandler(upstream), serveErr)
+		if err != nil {
+			_ = server.Close()
+			return err
+		}
+		servers = append(servers, proxy)
+		runtime.Output.PersistentPrintf("🛰️ Metadata proxy for %s listening on %s", upstream, proxyAddr)
+	}
+
+	go d.watch(ctx, opts.WatchInterval)
+
+	var runErr error
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			runErr = err
+		}
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	runtime.Output.PersistentPrintf("🛑 Daemon shutting down")
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil && runErr == nil {
+			runErr = err
+		}
+	}
+	return runErr
+}
+
+// serve starts an HTTP server for handler on addr and reports its exit on serveErr.
+func serve(addr string, handler http.Handler, serveErr chan<- error) (*http.Server, net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	return server, listener.Addr(), nil
+}
+
+// routes registers the API handlers.
+func (d *daemon) routes(ctx context.Context) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, d.snapshotStatus())
+	})
+	mux.HandleFunc("POST /resolve", func(w http.ResponseWriter, r *http.Request) {
+		d.handleRun(ctx, w, r, false)
+	})
+	mux.HandleFunc("POST /install", func(w http.ResponseWriter, r *http.Request) {
+		d.handleRun(ctx, w, r, true)
+	})
+	mux.HandleFunc("GET /datasource/{name}", d.handleDatasource)
+	return d.requireToken(mux)
+}
+
+// handleRun decodes overrides and runs a resolve or install.
+func (d *daemon) handleRun(ctx context.Context, w http.ResponseWriter, r *http.Request, install bool) {
+	var req runRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, runResponse{Error: err.Error()})
+			return
+		}
+	}
+	for _, path := range []string{req.RequirementsFile, req.DownloadPath} {
+		if path == "" {
+			continue
+		}
+		if err := d.checkOverride(path); err != nil {
+			writeJSON(w, http.StatusForbidden, runResponse{Error: err.Error()})
+			return
+		}
+	}
+	cfg := *d.cfg
+	if req.RequirementsFile != "" {
+		cfg.RequirementsFile = req.RequirementsFile
+		cfg.RequirementsData = nil
+	}
+	if req.DownloadPath != "" {
+		cfg.DownloadPath = req.DownloadPath
+	}
+
+	// Runs outlive the request so a disconnected client cannot leave a half-written install.
+	start := time.Now()
+	resolved, err := d.run(ctx, &cfg, install)
+	resp := runResponse{OK: err == nil, Resolved: resolved, Took: time.Since(start).Round(time.Millisecond).String()}
+	if err != nil {
+		resp.Error = err.Error()
+		writeJSON(w, http.StatusInternalServerError, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleDatasource serves a collection's releases in Renovate's custom datasource shape.
+func (d *daemon) handleDatasource(w http.ResponseWriter, r *http.Request) {
+	ds, err := d.session.Datasource(r.Context(), d.cfg, d.runtime, r.PathValue("name"))
+	if err != nil {
+		code := http.StatusBadGateway
+		if errors.Is(err, helpers.ErrInvalidCollectionName) {
+			code = http.StatusBadRequest
+		}
+		writeJSON(w, code, runResponse{Error: err.Error()})
+		return
+	}
+	// Lookups fill the API cache; let the watch loop persist it.
+	d.mu.Lock()
+	d.status.Dirty = true
+	d.mu.Unlock()
+	writeJSON(w, http.StatusOK, ds)
+}
+
+// run executes one resolve or install under runMu and records status.
+func (d *daemon) run(ctx context.Context, cfg *config.Config, install bool) (map[string]string, error) {
+	d.runMu.Lock()
+	defer d.runMu.Unlock()
+	d.setBusy(true)
+
+	var (
+		resolved map[string]string
+		err      error
+	)
+	if install {
+		err = d.session.Install(ctx, cfg, d.runtime)
+	} else {
+		resolved, err = d.session.Resolve(ctx, cfg, d.runtime)
+	}
+	d.finishRun(err)
+	return resolved, err
+}
+
+// watch re-resolves when the requirements file changes and persists the store when dirty.
+func (d *daemon) watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastMod := modTime(d.cfg.RequirementsFile)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if mod := modTime(d.cfg.RequirementsFile); !mod.Equal(lastMod) {
+			lastMod = mod
+			d.runtime.Output.PersistentPrintf("👀 %s changed, re-resolving", d.cfg.RequirementsFile)
+			if _, err := d.run(ctx, d.cfg, false); err != nil {
+				d.runtime.Output.Errorf("Resolve failed: %s", err)
+			}
+		}
+		d.persistIfDirty(ctx)
+	}
+}
+
+// persistIfDirty saves the store when a run changed it since the last save.
+func (d *daemon) persistIfDirty(ctx context.Context) {
+	d.runMu.Lock()
+	defer d.runMu.Unlock()
+	d.mu.Lock()
+	dirty := d.status.Dirty
+	d.mu.Unlock()
+	if !dirty {
+		return
+	}
+	if err := d.session.Save(ctx); err != nil {
+		d.runtime.Output.Errorf("Failed to persist store: %s", err)
+		return
+	}
+	d.mu.Lock()
+	d.status.Dirty = false
+	d.mu.Unlock()
+}
+
+func (d *daemon) setBusy(busy bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status.Busy = busy
+}
+
+func (d *daemon) finishRun(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status.Busy = false
+	d.status.Dirty = true
+	d.status.Runs++
+	d.status.LastRun = time.Now().UTC()
+	d.status.LastError = ""
+	if err != nil {
+		d.status.LastError = err.Error()
+	}
+}
+
+func (d *daemon) snapshotStatus() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status
+}
+
+// modTime returns the file modification time or zero if unavailable.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
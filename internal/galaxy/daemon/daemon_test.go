@@ -0,0 +1,247 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+// offlineTransport fails every request, so only cached data can be used.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("offline")
+}
+
+// openTestSession opens a session over cfg and closes it with the test.
+func openTestSession(t *testing.T, cfg *config.Config, runtime *infra.Infra) *collections.Session {
+	t.Helper()
+	session, err := collections.OpenSession(context.Background(), cfg, runtime)
+	if err != nil {
+		t.Fatalf("OpenSession: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = session.Close(context.Background())
+	})
+	return session
+}
+
+func post(t *testing.T, handler http.Handler, path, body, token string) (*httptest.ResponseRecorder, runResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var resp runResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s: decode %q: %v", path, rec.Body.String(), err)
+	}
+	return rec, resp
+}
+
+func TestRoutesRequireToken(t *testing.T) {
+	t.Parallel()
+	d := &daemon{cfg: &config.Config{}, token: "s3cret-token"}
+	handler := d.routes(context.Background())
+	for _, header := range []string{"", "Bearer wrong-token", "s3cret-token", "Basic s3cret-token"} {
+		req := httptest.NewRequest(http.MethodGet, "/status", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Fatalf("Authorization %q: expected 401, got %d", header, rec.Code)
+		}
+	}
+	if rec, resp := post(t, handler, "/install", "", ""); rec.Code != http.StatusUnauthorized || resp.OK {
+		t.Fatalf("expected an unauthenticated install to be refused, got %d %+v", rec.Code, resp)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.Header.Set("Authorization", "Bearer s3cret-token")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with the token, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestHandleRunRefusesOverrides(t *testing.T) {
+	t.Parallel()
+	allowed, outside := t.TempDir(), t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(allowed, "escape")); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+	roots, err := allowedRoots([]string{allowed})
+	if err != nil {
+		t.Fatalf("allowedRoots: %v", err)
+	}
+	// No session: every request below must be refused before a run starts.
+	d := &daemon{cfg: &config.Config{}, allowPaths: roots}
+	handler := d.routes(context.Background())
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"requirements_file": "` + filepath.Join(outside, "requirements.yml") + `"}`, http.StatusForbidden},
+		{`{"download_path": "` + filepath.Join(allowed, "..", filepath.Base(outside)) + `"}`, http.StatusForbidden},
+		{`{"requirements_file": "` + filepath.Join(allowed, "escape", "requirements.yml") + `"}`, http.StatusForbidden},
+		{`{"download_path": "` + filepath.Join(allowed, "escape", "new", "collections") + `"}`, http.StatusForbidden},
+		{`{"requirements_file": `, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec, resp := post(t, handler, "/resolve", tt.body, "")
+		if rec.Code != tt.code || resp.OK || resp.Error == "" {
+			t.Fatalf("%s: expected %d, got %d %+v", tt.body, tt.code, rec.Code, resp)
+		}
+	}
+
+	d.allowPaths = nil
+	body := `{"requirements_file": "` + filepath.Join(allowed, "requirements.yml") + `"}`
+	if rec, resp := post(t, handler, "/install", body, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected overrides to be refused without --allow-path, got %d %+v", rec.Code, resp)
+	}
+}
+
+func TestHandleRunResolvesAllowedOverride(t *testing.T) {
+	t.Parallel()
+	project := t.TempDir()
+	reqs := filepath.Join(project, "requirements.yml")
+	cfg := &config.Config{
+		Server:      "https://galaxy.invalid",
+		CacheDir:    t.TempDir(),
+		Workers:     2,
+		HealthCheck: config.HealthCheckOff,
+	}
+	runtime := infra.New(progress.New(false, true), &http.Client{Transport: offlineTransport{}})
+	opts := collections.GenCacheOptions{Collections: 3, Versions: 2, Deps: 1, Seed: 3, Requirements: reqs}
+	if err := collections.GenerateCache(context.Background(), cfg, runtime, opts); err != nil {
+		t.Fatalf("GenerateCache: %v", err)
+	}
+	roots, err := allowedRoots([]string{project})
+	if err != nil {
+		t.Fatalf("allowedRoots: %v", err)
+	}
+	d := &daemon{cfg: cfg, runtime: runtime, session: openTestSession(t, cfg, runtime), token: "s3cret-token", allowPaths: roots}
+	rec, resp := post(t, d.routes(context.Background()), "/resolve", `{"requirements_file": "`+reqs+`"}`, "s3cret-token")
+	if rec.Code != http.StatusOK || !resp.OK || len(resp.Resolved) != opts.Collections {
+		t.Fatalf("expected %d resolved collections, got %d %+v", opts.Collections, rec.Code, resp)
+	}
+	if status := d.snapshotStatus(); status.Runs != 1 || !status.Dirty || status.LastError != "" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
+
+func TestProxyHandler(t *testing.T) {
+	t.Parallel()
+	var hits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/api/v3/collections/acme/missing/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"namespace": "acme", "name": "tools"}`))
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Config{Server: upstream.URL, CacheDir: t.TempDir(), Workers: 1, HealthCheck: config.HealthCheckOff}
+	runtime := infra.New(progress.New(false, true), upstream.Client())
+	base, err := proxyUpstream(cfg.Server)
+	if err != nil {
+		t.Fatalf("proxyUpstream: %v", err)
+	}
+	d := &daemon{cfg: cfg, runtime: runtime, session: openTestSession(t, cfg, runtime)}
+	handler := d.proxyHandler(base)
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "/api/v3/collections/acme/tools/"); rec.Code != http.StatusMethodNotAllowed ||
+		rec.Header().Get("Allow") != "GET, HEAD" {
+		t.Fatalf("expected 405 for POST, got %d", rec.Code)
+	}
+	rec := serve(http.MethodGet, "/download/acme-tools-1.0.0.tar.gz")
+	if rec.Code != http.StatusTemporaryRedirect || rec.Header().Get("Location") != upstream.URL+"/download/acme-tools-1.0.0.tar.gz" {
+		t.Fatalf("expected a redirect upstream, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	for range 2 {
+		rec = serve(http.MethodGet, "/api/v3/collections/acme/tools/")
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"tools"`) {
+			t.Fatalf("expected the collection from the proxy, got %d %s", rec.Code, rec.Body)
+		}
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected the second request to be served from the cache, upstream saw %d", got)
+	}
+	if !d.snapshotStatus().Dirty {
+		t.Fatal("expected a proxied lookup to mark the store dirty")
+	}
+	if rec = serve(http.MethodGet, "/api/v3/collections/acme/missing/"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected the upstream 404 to pass through, got %d", rec.Code)
+	}
+	if hits.Load() != 2 {
+		t.Fatalf("expected one upstream request for the missing collection, got %d in total", hits.Load())
+	}
+}
+
+func TestCheckListenRequiresTokenOffLoopback(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		listen, token string
+		ok            bool
+	}{
+		{"127.0.0.1:8787", "", true},
+		{"[::1]:8787", "", true},
+		{"localhost:8787", "", true},
+		{"0.0.0.0:8787", "", false},
+		{":8787", "", false},
+		{"10.0.0.5:8787", "", false},
+		{"ci-runner.internal:8787", "", false},
+		{"0.0.0.0:8787", "s3cret-token", true},
+	}
+	for _, tt := range tests {
+		err := checkListen(tt.listen, tt.token)
+		if tt.ok && err != nil {
+			t.Fatalf("checkListen(%q, %q): %v", tt.listen, tt.token, err)
+		}
+		if !tt.ok && !errors.Is(err, helpers.ErrDaemonTokenRequired) {
+			t.Fatalf("checkListen(%q, %q): expected ErrDaemonTokenRequired, got %v", tt.listen, tt.token, err)
+		}
+	}
+}
+
+func TestRunRefusesPublicListenWithoutToken(t *testing.T) {
+	t.Parallel()
+	cfg := &config.Config{Server: "https://galaxy.invalid", CacheDir: t.TempDir(), HealthCheck: config.HealthCheckOff}
+	runtime := infra.New(progress.New(false, true), &http.Client{Transport: offlineTransport{}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := Run(ctx, cfg, runtime, Options{Listen: "0.0.0.0:0", WatchInterval: time.Second})
+	if !errors.Is(err, helpers.ErrDaemonTokenRequired) {
+		t.Fatalf("expected ErrDaemonTokenRequired, got %v", err)
+	}
+	if err := Run(ctx, cfg, runtime, Options{Listen: "127.0.0.1:0", WatchInterval: time.Second}); err != nil {
+		t.Fatalf("expected a loopback daemon to start without a token, got %v", err)
+	}
+}
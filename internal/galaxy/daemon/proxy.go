@@ -0,0 +1,57 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+// proxyUpstream returns the scheme and host of the configured server, which
+// proxied request paths are appended to.
+func proxyUpstream(server string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(server))
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return "", fmt.Errorf("%w: %q", helpers.ErrInvalidProxyUpstream, server)
+	}
+	return parsed.Scheme + "://" + parsed.Host, nil
+}
+
+// proxyHandler serves Galaxy API requests from the session's API cache,
+// fetching misses from upstream. The proxy keeps the server's paths, so the
+// relative links in responses lead back to it. Anything outside the API,
+// such as artifact downloads, is redirected upstream.
+func (d *daemon) proxyHandler(upstream string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := upstream + r.URL.RequestURI()
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if !strings.Contains(r.URL.Path, "/api/") {
+			http.Redirect(w, r, target, http.StatusTemporaryRedirect)
+			return
+		}
+		body, err := d.session.Metadata(r.Context(), d.cfg, d.runtime, target)
+		if err != nil {
+			var statusErr *cacheManager.HTTPStatusError
+			code := http.StatusBadGateway
+			if errors.As(err, &statusErr) {
+				code = statusErr.Code
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		// Lookups fill the API cache; let the watch loop persist it.
+		d.mu.Lock()
+		d.status.Dirty = true
+		d.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
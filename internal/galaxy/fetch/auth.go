@@ -0,0 +1,44 @@
+package fetch
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+)
+
+// authTransport adds a server's credentials to requests for its host.
+type authTransport struct {
+	next     http.RoundTripper
+	host     string
+	token    string
+	username string
+	password string
+}
+
+// withAuth wraps next when src carries credentials.
+func withAuth(next http.RoundTripper, src config.SourceConfig) http.RoundTripper {
+	if src.Token == "" && src.Username == "" {
+		return next
+	}
+	parsed, err := url.Parse(src.URL)
+	if err != nil {
+		return next
+	}
+	return &authTransport{next: next, host: parsed.Host, token: src.Token, username: src.Username, password: src.Password}
+}
+
+// RoundTrip sets the Authorization header unless the request left the
+// server's host, e.g. for a signed CDN URL, or already carries one.
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host || req.Header.Get("Authorization") != "" {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	if t.token != "" {
+		req.Header.Set("Authorization", "Token "+t.token)
+	} else {
+		req.SetBasicAuth(t.username, t.password)
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,46 @@
+package fetch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+)
+
+func TestNewForSourceSendsCredentialsToItsHostOnly(t *testing.T) {
+	t.Parallel()
+	var cdnAuth string
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cdnAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer cdn.Close()
+	var hubAuth string
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".tar.gz") {
+			http.Redirect(w, r, cdn.URL+"/signed", http.StatusFound)
+			return
+		}
+		hubAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer hub.Close()
+
+	client := NewForSource(time.Second, config.HTTPConfig{}, config.SourceConfig{URL: hub.URL + "/api/", Token: "secret"})
+	for _, target := range []string{hub.URL + "/api/v3/collections/a/b/", hub.URL + "/download/a-b-1.0.0.tar.gz"} {
+		resp, err := client.Get(target)
+		if err != nil {
+			t.Fatalf("Get %s: %v", target, err)
+		}
+		_ = resp.Body.Close()
+	}
+	if hubAuth != "Token secret" {
+		t.Fatalf("expected the hub to get its token, got %q", hubAuth)
+	}
+	if cdnAuth != "" {
+		t.Fatalf("expected no credentials after the redirect, got %q", cdnAuth)
+	}
+}
@@ -0,0 +1,87 @@
+package fetch
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+)
+
+// chaosErrorBody mimics the error S3 returns when it sheds load.
+const chaosErrorBody = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>SlowDown</Code><Message>injected by --chaos-s3-error-rate</Message></Error>`
+
+// chaosTransport injects the failures of a config.ChaosConfig in front of
+// the real transport.
+type chaosTransport struct {
+	next http.RoundTripper
+	cfg  config.ChaosConfig
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// withChaos wraps next when cfg injects anything.
+func withChaos(next http.RoundTripper, cfg config.ChaosConfig) http.RoundTripper {
+	if !cfg.Enabled() {
+		return next
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		//nolint:gosec // picks which requests fail, not security sensitive.
+		seed = rand.Uint64()
+	}
+	//nolint:gosec // a seeded generator keeps injected failures repeatable.
+	return &chaosTransport{next: next, cfg: cfg, rng: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// RoundTrip delays artifact downloads and fails a share of requests before
+// they reach the network.
+func (t *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.cfg.SlowDownload > 0 && strings.HasSuffix(req.URL.Path, ".tar.gz") {
+		timer := time.NewTimer(t.cfg.SlowDownload)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			closeBody(req)
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	if t.fail() {
+		closeBody(req)
+		return &http.Response{
+			Status:        http.StatusText(http.StatusServiceUnavailable),
+			StatusCode:    http.StatusServiceUnavailable,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/xml"}},
+			Body:          io.NopCloser(strings.NewReader(chaosErrorBody)),
+			ContentLength: int64(len(chaosErrorBody)),
+			Request:       req,
+		}, nil
+	}
+	return t.next.RoundTrip(req)
+}
+
+// fail draws whether the next request fails.
+func (t *chaosTransport) fail() bool {
+	if t.cfg.ErrorRate <= 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rng.Float64() < t.cfg.ErrorRate
+}
+
+// closeBody closes a request body the transport will not send.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
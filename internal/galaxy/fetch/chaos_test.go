@@ -0,0 +1,78 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+)
+
+func TestChaosErrorRateIsRepeatable(t *testing.T) {
+	t.Parallel()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	statuses := func() []int {
+		client := New(time.Second, config.HTTPConfig{Chaos: config.ChaosConfig{ErrorRate: 0.5, Seed: 42}})
+		out := make([]int, 0, 20)
+		for range 20 {
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			_ = resp.Body.Close()
+			out = append(out, resp.StatusCode)
+		}
+		return out
+	}
+	first, second := statuses(), statuses()
+	failed := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("request %d: %d then %d with the same seed", i, first[i], second[i])
+		}
+		if first[i] == http.StatusServiceUnavailable {
+			failed++
+		}
+	}
+	if failed == 0 || failed == len(first) {
+		t.Fatalf("expected some of %d requests to fail, %d did", len(first), failed)
+	}
+	if got := int(hits.Load()); got != 2*(len(first)-failed) {
+		t.Fatalf("injected failures must not reach the server: %d hits for %d failures", got, failed)
+	}
+}
+
+func TestChaosSlowDownloadHonorsContext(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := New(time.Minute, config.HTTPConfig{Chaos: config.ChaosConfig{SlowDownload: time.Minute}})
+	resp, err := client.Get(srv.URL + "/api/v3/collections/a/b/")
+	if err != nil {
+		t.Fatalf("metadata requests must not be delayed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/download/a-b-1.0.0.tar.gz", http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	if resp, err := client.Do(req); err == nil {
+		_ = resp.Body.Close()
+		t.Fatal("expected the delayed download to be cancelled")
+	}
+}
@@ -1,29 +1,80 @@
 package fetch
 
 import (
+	"fmt"
 	"net"
 	"net/http"
 	"time"
 
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
 	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
 )
 
-// New creates a configured HTTP client with reasonable defaults.
-func New(timeout time.Duration) *http.Client {
+// New creates a configured HTTP client with reasonable defaults,
+// adjusted by tuning where it sets a value.
+func New(timeout time.Duration, tuning config.HTTPConfig) *http.Client {
 	return &http.Client{
-		Timeout: timeout,
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
-				Timeout:   helpers.FetchDialContextTimeout,
-				KeepAlive: helpers.FetchDialContextKeepAlive,
-			}).DialContext,
-			ForceAttemptHTTP2:     helpers.FetchForceAttemptHTTP2,
-			MaxIdleConns:          helpers.FetchMaxIdleConns,
-			MaxIdleConnsPerHost:   helpers.FetchMaxIdleConnsPerHost,
-			IdleConnTimeout:       helpers.FetchIdleConnTimeout,
-			TLSHandshakeTimeout:   helpers.FetchTLSHandshakeTimeout,
-			ExpectContinueTimeout: helpers.FetchExpectContinueTimeout,
-		},
+		Timeout:       timeout,
+		CheckRedirect: checkRedirect,
+		Transport:     withChaos(withHTTP3(newTransport(tuning), tuning), tuning.Chaos),
 	}
 }
+
+// NewForSource creates the client for one configured server: its TLS
+// settings, proxy and timeout replace the defaults, and its credentials are
+// sent to its host only.
+func NewForSource(timeout time.Duration, tuning config.HTTPConfig, src config.SourceConfig) *http.Client {
+	transport := newTransport(tuning)
+	if src.TLS != nil {
+		transport.TLSClientConfig = src.TLS.Clone()
+	}
+	if src.Proxy != nil {
+		transport.Proxy = http.ProxyURL(src.Proxy)
+	}
+	if src.Timeout > 0 {
+		timeout = src.Timeout
+	}
+	return &http.Client{
+		Timeout:       timeout,
+		CheckRedirect: checkRedirect,
+		Transport:     withAuth(withChaos(withHTTP3(transport, tuning), tuning.Chaos), src),
+	}
+}
+
+// newTransport builds the transport shared by all clients.
+func newTransport(tuning config.HTTPConfig) *http.Transport {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   helpers.FetchDialContextTimeout,
+			KeepAlive: helpers.FetchDialContextKeepAlive,
+		}).DialContext,
+		// With a custom DialContext, HTTP/2 is only negotiated when forced.
+		ForceAttemptHTTP2:     helpers.FetchForceAttemptHTTP2 && !tuning.DisableHTTP2,
+		MaxIdleConns:          helpers.FetchMaxIdleConns,
+		MaxIdleConnsPerHost:   helpers.FetchMaxIdleConnsPerHost,
+		MaxConnsPerHost:       tuning.MaxConnsPerHost,
+		IdleConnTimeout:       helpers.FetchIdleConnTimeout,
+		TLSHandshakeTimeout:   helpers.FetchTLSHandshakeTimeout,
+		ExpectContinueTimeout: helpers.FetchExpectContinueTimeout,
+	}
+	if tuning.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = tuning.MaxIdleConnsPerHost
+		transport.MaxIdleConns = max(transport.MaxIdleConns, tuning.MaxIdleConnsPerHost)
+	}
+	return transport
+}
+
+// checkRedirect follows redirects, e.g. to signed CDN URLs, but drops
+// credentials whenever the redirect leaves the original host or scheme.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= helpers.FetchMaxRedirects {
+		return fmt.Errorf("%w: %d redirects", helpers.ErrTooManyRedirects, len(via))
+	}
+	origin := via[0].URL
+	if req.URL.Host != origin.Host || req.URL.Scheme != origin.Scheme {
+		req.Header.Del("Authorization")
+		req.Header.Del("Cookie")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package fetch
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+func TestCheckRedirectDropsCredentialsAcrossHosts(t *testing.T) {
+	t.Parallel()
+	origin, err := http.NewRequest(http.MethodGet, "https://hub.example.com/api/v3/download/a-b-1.0.0.tar.gz", http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	for target, keep := range map[string]bool{
+		"https://hub.example.com/other":     true,
+		"https://cdn.hub.example.com/file":  false,
+		"http://hub.example.com/downgraded": false,
+	} {
+		req, err := http.NewRequest(http.MethodGet, target, http.NoBody)
+		if err != nil {
+			t.Fatalf("NewRequest: %v", err)
+		}
+		req.Header.Set("Authorization", "Token secret")
+		if err := checkRedirect(req, []*http.Request{origin}); err != nil {
+			t.Fatalf("checkRedirect(%s): %v", target, err)
+		}
+		if got := req.Header.Get("Authorization") != ""; got != keep {
+			t.Fatalf("checkRedirect(%s): authorization kept=%t, want %t", target, got, keep)
+		}
+	}
+}
+
+func TestNewAppliesTuning(t *testing.T) {
+	t.Parallel()
+	transport, ok := New(time.Second, config.HTTPConfig{}).Transport.(*http.Transport)
+	if !ok || transport.MaxIdleConnsPerHost != helpers.FetchMaxIdleConnsPerHost || !transport.ForceAttemptHTTP2 {
+		t.Fatalf("unexpected default transport: %+v", transport)
+	}
+	tuned := config.HTTPConfig{MaxIdleConnsPerHost: 200, MaxConnsPerHost: 8, DisableHTTP2: true}
+	transport, ok = New(time.Second, tuned).Transport.(*http.Transport)
+	if !ok || transport.MaxIdleConnsPerHost != 200 || transport.MaxIdleConns < 200 || transport.MaxConnsPerHost != 8 || transport.ForceAttemptHTTP2 {
+		t.Fatalf("tuning not applied: %+v", transport)
+	}
+}
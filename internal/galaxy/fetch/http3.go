@@ -0,0 +1,86 @@
+package fetch
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/http3"
+)
+
+// http3Transport sends GET and HEAD requests over HTTP/3 and falls back to
+// the TCP transport when a host does not speak it.
+type http3Transport struct {
+	h3   *http3.Transport
+	next *http.Transport
+	now  func() time.Time
+
+	mu sync.Mutex
+	// broken holds when each host last failed over HTTP/3.
+	broken map[string]time.Time
+}
+
+// withHTTP3 wraps transport when tuning opts into HTTP/3.
+func withHTTP3(transport *http.Transport, tuning config.HTTPConfig) http.RoundTripper {
+	if !tuning.HTTP3 {
+		return transport
+	}
+	h3 := &http3.Transport{HandshakeTimeout: helpers.FetchHTTP3HandshakeTimeout}
+	if transport.TLSClientConfig != nil {
+		h3.TLSClientConfig = transport.TLSClientConfig.Clone()
+	}
+	return &http3Transport{h3: h3, next: transport, now: time.Now, broken: make(map[string]time.Time)}
+}
+
+// RoundTrip tries HTTP/3 first where it can apply. A request that fails
+// before a response arrives is sent again over TCP, and the host stays on
+// TCP for helpers.FetchHTTP3BrokenCooldown.
+func (t *http3Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.eligible(req) {
+		return t.next.RoundTrip(req)
+	}
+	resp, err := t.h3.RoundTrip(req)
+	if err == nil {
+		return resp, nil
+	}
+	if req.Context().Err() != nil {
+		// The caller gave up; HTTP/3 is not to blame.
+		return nil, err
+	}
+	t.mu.Lock()
+	t.broken[req.URL.Host] = t.now()
+	t.mu.Unlock()
+	return t.next.RoundTrip(req)
+}
+
+// eligible reports whether req may go over HTTP/3: a bodiless https request
+// that is not proxied, to a host that has not failed recently.
+func (t *http3Transport) eligible(req *http.Request) bool {
+	if req.URL.Scheme != "https" || req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+	if t.next.Proxy != nil {
+		if proxy, err := t.next.Proxy(req); err != nil || proxy != nil {
+			return false
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	failed, ok := t.broken[req.URL.Host]
+	if ok && t.now().Sub(failed) >= helpers.FetchHTTP3BrokenCooldown {
+		delete(t.broken, req.URL.Host)
+		ok = false
+	}
+	return !ok
+}
+
+// CloseIdleConnections closes the idle connections of both transports.
+func (t *http3Transport) CloseIdleConnections() {
+	t.h3.CloseIdleConnections()
+	t.next.CloseIdleConnections()
+}
@@ -0,0 +1,97 @@
+package fetch
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+func TestHTTP3FallsBackToTCP(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}))
+	t.Cleanup(srv.Close)
+	transport := newTransport(config.HTTPConfig{})
+	transport.Proxy = nil
+	transport.TLSClientConfig = srv.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	rt, ok := withHTTP3(transport, config.HTTPConfig{HTTP3: true}).(*http3Transport)
+	if !ok {
+		t.Fatalf("withHTTP3 did not wrap the transport")
+	}
+	client := &http.Client{Transport: rt}
+	t.Cleanup(client.CloseIdleConnections)
+
+	// Nothing answers QUIC on the server's port, so the request goes over TCP.
+	resp, err := client.Get(srv.URL + "/api/")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(string(body), "HTTP/1") {
+		t.Fatalf("unexpected response %d %q", resp.StatusCode, body)
+	}
+	host := strings.TrimPrefix(srv.URL, "https://")
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/", http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	if rt.eligible(req) {
+		t.Fatalf("host %s not kept on TCP after a failed HTTP/3 attempt", host)
+	}
+	rt.now = func() time.Time { return time.Now().Add(helpers.FetchHTTP3BrokenCooldown) }
+	if !rt.eligible(req) {
+		t.Fatalf("host %s still on TCP after the cooldown", host)
+	}
+}
+
+func TestHTTP3Eligible(t *testing.T) {
+	t.Parallel()
+	transport := newTransport(config.HTTPConfig{})
+	transport.Proxy = nil
+	rt, ok := withHTTP3(transport, config.HTTPConfig{HTTP3: true}).(*http3Transport)
+	if !ok {
+		t.Fatalf("withHTTP3 did not wrap the transport")
+	}
+	for _, tc := range []struct {
+		method, url string
+		body        io.Reader
+		want        bool
+	}{
+		{http.MethodGet, "https://galaxy.example.com/api/", nil, true},
+		{http.MethodHead, "https://galaxy.example.com/download/a-1.0.0.tar.gz", nil, true},
+		{http.MethodGet, "http://galaxy.example.com/api/", nil, false},
+		{http.MethodPut, "https://bucket.example.com/key", strings.NewReader("data"), false},
+	} {
+		req, err := http.NewRequest(tc.method, tc.url, tc.body)
+		if err != nil {
+			t.Fatalf("NewRequest: %v", err)
+		}
+		if got := rt.eligible(req); got != tc.want {
+			t.Fatalf("eligible(%s %s) = %t, want %t", tc.method, tc.url, got, tc.want)
+		}
+	}
+	proxy, err := url.Parse("http://proxy.example.com:3128")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	transport.Proxy = http.ProxyURL(proxy)
+	req, err := http.NewRequest(http.MethodGet, "https://galaxy.example.com/api/", http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	if rt.eligible(req) {
+		t.Fatalf("proxied request sent over HTTP/3")
+	}
+	if _, ok := withHTTP3(transport, config.HTTPConfig{}).(*http.Transport); !ok {
+		t.Fatalf("HTTP/3 enabled without the opt-in")
+	}
+}
@@ -0,0 +1,220 @@
+package fetch
+
+import (
+	"cmp"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// MirrorSet serves the requests for one Galaxy server from the server and
+// its mirrors, healthiest first, and keeps the health of each in the store.
+type MirrorSet struct {
+	// bases are the server and mirror URLs without a trailing slash,
+	// the server first.
+	bases  []string
+	debugf func(format string, args ...any)
+	now    func() time.Time
+
+	mu    sync.Mutex
+	stats map[string]store.MirrorStats
+	st    *store.Store
+}
+
+// NewMirrorSet returns the mirror set of server, or nil without mirrors.
+// debugf reports failovers and may be nil.
+func NewMirrorSet(server string, mirrors []string, debugf func(format string, args ...any)) *MirrorSet {
+	primary := strings.TrimRight(server, "/")
+	if primary == "" || len(mirrors) == 0 {
+		return nil
+	}
+	bases := []string{primary}
+	for _, mirror := range mirrors {
+		mirror = strings.TrimRight(mirror, "/")
+		if mirror != "" && !slices.Contains(bases, mirror) {
+			bases = append(bases, mirror)
+		}
+	}
+	if len(bases) == 1 {
+		return nil
+	}
+	if debugf == nil {
+		debugf = func(string, ...any) {}
+	}
+	return &MirrorSet{bases: bases, debugf: debugf, now: time.Now, stats: make(map[string]store.MirrorStats)}
+}
+
+// Bind starts from the health st recorded in earlier runs and records the
+// health of this run in st. Samples taken before Bind are kept.
+func (s *MirrorSet) Bind(st *store.Store) {
+	if s == nil || st == nil {
+		return
+	}
+	recorded := st.MirrorStatsSnapshot()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.st = st
+	for _, base := range s.bases {
+		stats, ok := s.stats[base]
+		if !ok {
+			if stats, ok = recorded[base]; !ok {
+				continue
+			}
+			s.stats[base] = stats
+		}
+		st.SetMirrorStats(base, stats)
+	}
+}
+
+// Order returns the server and its mirrors, healthiest first: mirrors that
+// failed within helpers.FetchMirrorCooldown go last, the rest are ordered by
+// average latency. A mirror without samples counts as fastest, so each is
+// tried at least once; ties keep the configured order.
+func (s *MirrorSet) Order() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	cooling := func(base string) bool {
+		stats := s.stats[base]
+		return stats.ConsecutiveFailures > 0 && now.Sub(stats.LastFailure) < helpers.FetchMirrorCooldown
+	}
+	order := slices.Clone(s.bases)
+	slices.SortStableFunc(order, func(a, b string) int {
+		if ca, cb := cooling(a), cooling(b); ca != cb {
+			if ca {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(s.stats[a].Latency, s.stats[b].Latency)
+	})
+	return order
+}
+
+// record adds the outcome of one request to the health of base.
+func (s *MirrorSet) record(base string, latency time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats[base]
+	if failed {
+		stats.Failures++
+		stats.ConsecutiveFailures++
+		stats.LastFailure = s.now()
+	} else {
+		stats.Successes++
+		stats.ConsecutiveFailures = 0
+		if stats.Latency == 0 {
+			stats.Latency = latency
+		} else {
+			stats.Latency += time.Duration(helpers.FetchMirrorLatencyWeight * float64(latency-stats.Latency))
+		}
+	}
+	s.stats[base] = stats
+	s.st.SetMirrorStats(base, stats)
+}
+
+// Wrap returns next with requests for the server spread over its mirrors.
+func (s *MirrorSet) Wrap(next http.RoundTripper) http.RoundTripper {
+	if s == nil {
+		return next
+	}
+	return &mirrorTransport{next: next, set: s}
+}
+
+// mirrorTransport sends a request for the server to the healthiest mirror
+// and fails over to the next one on network errors and the replies failed
+// rejects.
+type mirrorTransport struct {
+	next http.RoundTripper
+	set  *MirrorSet
+}
+
+// RoundTrip implements http.RoundTripper. Requests for other hosts and
+// requests with a body pass through unchanged. The server's credentials are
+// not sent to mirrors on other hosts.
+func (t *mirrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rest, ok := t.set.relative(req.URL)
+	if !ok || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return t.next.RoundTrip(req)
+	}
+	order := t.set.Order()
+	var lastErr error
+	for i, base := range order {
+		target, err := url.Parse(base + rest)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		attempt := req.Clone(req.Context())
+		attempt.URL = target
+		attempt.Host = ""
+		if target.Host != req.URL.Host {
+			attempt.Header.Del("Authorization")
+			attempt.Header.Del("Cookie")
+		}
+		start := t.set.now()
+		resp, err := t.next.RoundTrip(attempt)
+		if err != nil {
+			if req.Context().Err() != nil {
+				return nil, err
+			}
+			t.set.record(base, 0, true)
+			t.set.debugf("mirror %s failed for %s: %v", base, rest, err)
+			lastErr = err
+			continue
+		}
+		if t.set.failed(base, resp.StatusCode) {
+			t.set.record(base, 0, true)
+			if i < len(order)-1 {
+				t.set.debugf("mirror %s answered %d for %s, trying the next", base, resp.StatusCode, rest)
+				_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+				_ = resp.Body.Close()
+				continue
+			}
+			return resp, nil
+		}
+		t.set.record(base, t.set.now().Sub(start), false)
+		return resp, nil
+	}
+	return nil, lastErr
+}
+
+// failed reports whether a reply with code from base counts as a failed
+// attempt: 5xx and 429 from any base, and 401, 403 and 404 from a mirror,
+// which may lag the server or lack its private content. The server's own
+// 4xx replies are its answer.
+func (s *MirrorSet) failed(base string, code int) bool {
+	if code >= http.StatusInternalServerError || code == http.StatusTooManyRequests {
+		return true
+	}
+	if base == s.bases[0] {
+		return false
+	}
+	switch code {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// relative returns the part of u after the server URL, and false when u
+// is not a URL of the server.
+func (s *MirrorSet) relative(u *url.URL) (string, bool) {
+	raw := u.String()
+	primary := s.bases[0]
+	if !strings.HasPrefix(raw, primary) {
+		return "", false
+	}
+	rest := raw[len(primary):]
+	if rest != "" && rest[0] != '/' && rest[0] != '?' {
+		return "", false
+	}
+	return rest, true
+}
@@ -0,0 +1,147 @@
+package fetch
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+func TestMirrorSetFailsOverAndPrefersHealthyMirror(t *testing.T) {
+	t.Parallel()
+	var primaryHits, mirrorHits atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		primaryHits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	var mirrorAuth string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorHits.Add(1)
+		mirrorAuth = r.Header.Get("Authorization")
+		_, _ = io.WriteString(w, r.URL.Path)
+	}))
+	defer mirror.Close()
+
+	st := store.New()
+	set := NewMirrorSet(primary.URL+"/", []string{mirror.URL + "/galaxy"}, nil)
+	set.Bind(st)
+	client := New(time.Second, config.HTTPConfig{})
+	client.Transport = set.Wrap(client.Transport)
+
+	for range 2 {
+		req, err := http.NewRequest(http.MethodGet, primary.URL+"/api/v3/collections/a/b/", nil)
+		if err != nil {
+			t.Fatalf("NewRequest: %v", err)
+		}
+		req.Header.Set("Authorization", "Token secret")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != "/galaxy/api/v3/collections/a/b/" {
+			t.Fatalf("expected the mirror to answer, got %d %q", resp.StatusCode, body)
+		}
+	}
+	if primaryHits.Load() != 1 || mirrorHits.Load() != 2 {
+		t.Fatalf("expected the failed server to be skipped after one try, got %d server and %d mirror hits",
+			primaryHits.Load(), mirrorHits.Load())
+	}
+	if mirrorAuth != "" {
+		t.Fatalf("expected no credentials on the mirror, got %q", mirrorAuth)
+	}
+
+	stats := st.MirrorStatsSnapshot()
+	if got := stats[primary.URL]; got.Failures != 1 || got.ConsecutiveFailures != 1 {
+		t.Fatalf("expected one recorded server failure, got %+v", got)
+	}
+	if got := stats[mirror.URL+"/galaxy"]; got.Successes != 2 || got.Latency <= 0 {
+		t.Fatalf("expected two recorded mirror successes, got %+v", got)
+	}
+	if order := set.Order(); order[0] != mirror.URL+"/galaxy" {
+		t.Fatalf("expected the mirror first, got %v", order)
+	}
+}
+
+func TestMirrorSetFailsOverOnMirrorClientErrors(t *testing.T) {
+	t.Parallel()
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/collections/a/missing/" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, "server")
+	}))
+	defer primary.Close()
+	for _, code := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound} {
+		var mirrorHits atomic.Int32
+		mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			mirrorHits.Add(1)
+			w.WriteHeader(code)
+		}))
+		st := store.New()
+		set := NewMirrorSet(primary.URL, []string{mirror.URL}, nil)
+		set.Bind(st)
+		// The mirror has no samples yet, so it is tried first.
+		set.record(primary.URL, time.Second, false)
+		client := New(time.Second, config.HTTPConfig{})
+		client.Transport = set.Wrap(client.Transport)
+
+		resp, err := client.Get(primary.URL + "/api/v3/collections/a/b/")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		mirror.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != "server" || mirrorHits.Load() != 1 {
+			t.Fatalf("mirror %d: expected the server to answer after the mirror, got %d %q", code, resp.StatusCode, body)
+		}
+		if got := st.MirrorStatsSnapshot()[mirror.URL]; got.Failures != 1 {
+			t.Fatalf("mirror %d: expected a recorded mirror failure, got %+v", code, got)
+		}
+		if order := set.Order(); order[0] != primary.URL {
+			t.Fatalf("mirror %d: expected the server first after the failure, got %v", code, order)
+		}
+		resp, err = client.Get(primary.URL + "/api/v3/collections/a/missing/")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound || mirrorHits.Load() != 1 {
+			t.Fatalf("mirror %d: expected the server's 404 to be final, got %d", code, resp.StatusCode)
+		}
+	}
+}
+
+func TestMirrorSetPassesOtherHostsThrough(t *testing.T) {
+	t.Parallel()
+	var hits atomic.Int32
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer other.Close()
+
+	set := NewMirrorSet("https://galaxy.invalid", []string{"https://mirror.invalid"}, nil)
+	client := New(time.Second, config.HTTPConfig{})
+	client.Transport = set.Wrap(client.Transport)
+	resp, err := client.Get(other.URL + "/download/a-b-1.0.0.tar.gz")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || hits.Load() != 1 {
+		t.Fatalf("expected one untouched request, got %d after %d hits", resp.StatusCode, hits.Load())
+	}
+	if NewMirrorSet("https://galaxy.invalid", nil, nil) != nil {
+		t.Fatal("expected no mirror set without mirrors")
+	}
+}
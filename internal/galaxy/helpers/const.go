@@ -18,6 +18,10 @@ const (
 	ArchiveMaxEntrySize = int64(512 << 20) // 512 MiB per file
 	// ArchiveMaxTotalSize caps total extracted bytes per archive.
 	ArchiveMaxTotalSize = int64(4 << 30) // 4 GiB per archive
+	// ArchiveMaxEntries caps the entries of one archive unless --archive-max-entries is set.
+	ArchiveMaxEntries = 100_000
+	// ArchiveMaxPathDepth caps the path components of one entry unless --archive-max-depth is set.
+	ArchiveMaxPathDepth = 64
 
 	// FetchDefaultTimeout is the overall HTTP client timeout.
 	FetchDefaultTimeout = 30 * time.Second
@@ -37,16 +41,58 @@ const (
 	FetchTLSHandshakeTimeout = 3 * time.Second
 	// FetchExpectContinueTimeout is the expect-continue timeout.
 	FetchExpectContinueTimeout = 1 * time.Second
+	// FetchMaxRedirects caps redirects followed per request.
+	FetchMaxRedirects = 10
+	// FetchMaxMetadataBytes caps the body of one metadata response.
+	FetchMaxMetadataBytes = int64(32 << 20) // 32 MiB
+	// FetchMetadataReadTimeout aborts a metadata request that makes no progress
+	// for this long, independent of the overall client timeout.
+	FetchMetadataReadTimeout = 15 * time.Second
+	// FetchMirrorCooldown keeps a mirror that just failed behind healthy ones for this long.
+	FetchMirrorCooldown = 5 * time.Minute
+	// FetchMirrorLatencyWeight is the weight of the newest sample in a mirror's latency average.
+	FetchMirrorLatencyWeight = 0.3
+	// FetchHTTP3HandshakeTimeout bounds the QUIC handshake before a request falls back to TCP.
+	FetchHTTP3HandshakeTimeout = 3 * time.Second
+	// FetchHTTP3BrokenCooldown keeps a host whose HTTP/3 attempt failed on TCP for this long.
+	FetchHTTP3BrokenCooldown = 5 * time.Minute
 
 	// StoreSnapshotSchemaVersion is the current snapshot schema version.
-	StoreSnapshotSchemaVersion = 2
+	StoreSnapshotSchemaVersion = 3
+	// StoreNormalizedVersionsSchemaVersion is the first schema whose versions
+	// cache holds only normalized lists, newest first.
+	StoreNormalizedVersionsSchemaVersion = 3
+
+	// StoreBlobsDir is the local cache subdirectory holding content-addressed artifacts.
+	StoreBlobsDir = "blobs"
+	// StoreNamespacesDir is the cache subdirectory (or S3 prefix) holding namespaced caches.
+	StoreNamespacesDir = "namespaces"
+	// StoreGitDir is the cache subdirectory holding collection tarballs built from git requirements.
+	StoreGitDir = "git"
 
 	// StoreDBLock is the cache lock file name.
 	StoreDBLock = ".go-galaxy.lock"
 
+	// StoreAuditLog is the local cache audit journal filename.
+	StoreAuditLog = "audit.jsonl"
+	// StoreJournal is the local cache install journal filename.
+	StoreJournal = "go-galaxy-journal.jsonl"
+	// StoreJSONFile is the single-file store of the local-json cache backend.
+	StoreJSONFile = "go-galaxy-store.json.gz"
+	// StoreCompletionFile caches collection names offered to shell completion.
+	StoreCompletionFile = "go-galaxy-completion.json"
+	// StoreJournalRetiredSuffix names the journal moved aside while a snapshot is saved.
+	StoreJournalRetiredSuffix = ".retired"
+	// StoreAuditPrefix is the S3 key prefix for audit journal entries.
+	StoreAuditPrefix = "audit"
 	// StoreDBProjects is the project registry filename.
 	StoreDBProjects = "projects.json"
 
+	// StoreHotShards is the number of partitions of the store's in-memory lookup cache.
+	StoreHotShards = 32
+	// StoreHotEntries caps the entries of the store's in-memory lookup cache.
+	StoreHotEntries = 8192
+
 	// StoreDBLocal is the local cache database filename.
 	StoreDBLocal = "go-galaxy.db"
 
@@ -96,4 +142,25 @@ const (
 	StoreMetaRequirementsHash = "requirements_hash"
 	// StoreMetaServer is the metadata key for the Galaxy server.
 	StoreMetaServer = "server"
+	// StoreMetaStats is the metadata key for cache hit/miss counters.
+	StoreMetaStats = "stats"
+	// StoreMetaMirrors is the metadata key for server mirror health.
+	StoreMetaMirrors = "mirrors"
+
+	// InstallManifestFile is the manifest filename written into the collections path.
+	InstallManifestFile = "install-manifest.json"
+	// GalaxyIgnoreFile lists collections in a collections path that cleanup must keep.
+	GalaxyIgnoreFile = ".galaxyignore"
+	// InstallManifestSchemaVersion is the current install manifest schema version.
+	InstallManifestSchemaVersion = 1
+	// ActiveIndexFile maps each collection to its active versioned directory
+	// when --install-template is set.
+	ActiveIndexFile = "active-collections.json"
+	// ActiveIndexSchemaVersion is the current active index schema version.
+	ActiveIndexSchemaVersion = 1
+
+	// ShutdownGracePeriod is how long in-flight installs may run after a shutdown signal.
+	ShutdownGracePeriod = 10 * time.Second
+	// ShutdownFlushTimeout bounds saving the store after a shutdown signal.
+	ShutdownFlushTimeout = 30 * time.Second
 )
@@ -0,0 +1,116 @@
+package helpers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// latestPrefix starts the latest-N shorthand ("latest-1" is one release behind the newest).
+const latestPrefix = "latest-"
+
+// ExpandConstraint rewrites pip and ansible style shorthands into semver constraints:
+// "1.2.x" and "1.2.*" become a range, "~=1.4" a compatible-release range and "==1.0.0" an
+// exact pin. "latest" and "latest-0" mean any version; "latest-N" is kept in canonical form,
+// since it can only be applied to a version list (see LatestOffset).
+func ExpandConstraint(value string) string {
+	trimmed := strings.TrimSpace(value)
+	lower := strings.ToLower(trimmed)
+	if lower == "latest" {
+		return ""
+	}
+	if offset, ok := LatestOffset(lower); ok {
+		if offset == 0 {
+			return ""
+		}
+		return latestPrefix + strconv.Itoa(offset)
+	}
+	if strings.Contains(trimmed, "||") {
+		return trimmed
+	}
+	parts := strings.Split(trimmed, ",")
+	for i, part := range parts {
+		parts[i] = expandConstraintPart(strings.TrimSpace(part))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// LatestOffset reports whether value is a latest-N constraint and returns N.
+func LatestOffset(value string) (int, bool) {
+	rest, ok := strings.CutPrefix(strings.ToLower(strings.TrimSpace(value)), latestPrefix)
+	if !ok {
+		return 0, false
+	}
+	offset, err := strconv.Atoi(rest)
+	if err != nil || offset < 0 {
+		return 0, false
+	}
+	return offset, true
+}
+
+func expandConstraintPart(part string) string {
+	switch {
+	case strings.HasPrefix(part, "~="):
+		return compatibleRelease(strings.TrimSpace(part[2:]), part)
+	case strings.HasPrefix(part, "==") && !strings.HasPrefix(part, "==="):
+		return "=" + strings.TrimSpace(part[2:])
+	case part != "" && strings.IndexAny(part[:1], "<>=!~^") < 0:
+		return wildcardRange(part)
+	}
+	return part
+}
+
+// compatibleRelease expands ~=X.Y to >=X.Y.0, <X+1.0.0 and ~=X.Y.Z to >=X.Y.Z, <X.Y+1.0.
+func compatibleRelease(version, original string) string {
+	nums, ok := numericParts(strings.Split(version, "."))
+	if !ok || len(nums) < 2 || len(nums) > 3 {
+		return original
+	}
+	if len(nums) == 2 {
+		return ">=" + joinVersion(nums[0], nums[1], 0) + ", <" + joinVersion(nums[0]+1, 0, 0)
+	}
+	return ">=" + version + ", <" + joinVersion(nums[0], nums[1]+1, 0)
+}
+
+// wildcardRange expands 1.x and 1.2.x (or * / X) into the matching range.
+func wildcardRange(part string) string {
+	fields := strings.Split(part, ".")
+	wild := -1
+	for i, field := range fields {
+		if field == "x" || field == "X" || field == "*" {
+			wild = i
+			break
+		}
+	}
+	if wild < 1 {
+		return part
+	}
+	for _, field := range fields[wild:] {
+		if field != "x" && field != "X" && field != "*" {
+			return part
+		}
+	}
+	nums, ok := numericParts(fields[:wild])
+	if !ok || len(nums) > 2 {
+		return part
+	}
+	if len(nums) == 1 {
+		return ">=" + joinVersion(nums[0], 0, 0) + ", <" + joinVersion(nums[0]+1, 0, 0)
+	}
+	return ">=" + joinVersion(nums[0], nums[1], 0) + ", <" + joinVersion(nums[0], nums[1]+1, 0)
+}
+
+func numericParts(fields []string) ([]int, bool) {
+	nums := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		nums = append(nums, n)
+	}
+	return nums, true
+}
+
+func joinVersion(major, minor, patch int) string {
+	return strconv.Itoa(major) + "." + strconv.Itoa(minor) + "." + strconv.Itoa(patch)
+}
@@ -39,6 +39,25 @@ var (
 	// ErrS3EmptyCreds indicates S3 cache credentials are required but missing.
 	ErrS3EmptyCreds = errors.New("s3 cache requires access/secret keys when GO_GALAXY_S3_BUCKET is set")
 
+	// ErrInvalidRootFilter indicates an --only/--skip pattern is malformed.
+	ErrInvalidRootFilter = errors.New("invalid collection filter pattern")
+	// ErrNoRootsSelected indicates --only/--skip filtered out every requirement.
+	ErrNoRootsSelected = errors.New("no requirements left after --only/--skip filters")
+
+	// ErrInvalidCacheNamespace indicates the cache namespace contains unsupported characters.
+	ErrInvalidCacheNamespace = errors.New("invalid cache namespace (allowed: letters, digits, '-', '_', '.')")
+
+	// ErrUnknownCacheBackend indicates the requested cache backend is not registered.
+	ErrUnknownCacheBackend = errors.New("unknown cache backend")
+	// ErrInvalidCacheEncryptionKey indicates the cache encryption key is not a 32-byte hex or base64 value.
+	ErrInvalidCacheEncryptionKey = errors.New("invalid cache encryption key (expected 32 bytes, hex or base64 encoded)")
+	// ErrCacheEncryptionUnsupported indicates encryption was requested for a backend that does not support it.
+	ErrCacheEncryptionUnsupported = errors.New("cache encryption is supported only for the local cache backend")
+	// ErrCacheValueNotEncrypted indicates a cached value was expected to be encrypted.
+	ErrCacheValueNotEncrypted = errors.New("cached value is not encrypted")
+	// ErrCacheValueCorrupted indicates an encrypted cached value is truncated or malformed.
+	ErrCacheValueCorrupted = errors.New("encrypted cached value is corrupted")
+
 	// ErrArtifactCacheNotConfigured indicates the artifact cache is unavailable.
 	ErrArtifactCacheNotConfigured = errors.New("artifact cache is not configured")
 	// ErrMetadataIsNil indicates metadata is nil when required.
@@ -47,6 +66,12 @@ var (
 	ErrMissingDownloadURL = errors.New("missing download url")
 	// ErrConfigIsNil indicates a nil config was provided.
 	ErrConfigIsNil = errors.New("config is nil")
+	// ErrVersionUnpublished indicates installed versions that the server no longer serves.
+	ErrVersionUnpublished = errors.New("installed versions are no longer published upstream")
+	// ErrInvalidGalaxyInfoMode indicates an unknown --galaxy-info mode.
+	ErrInvalidGalaxyInfoMode = errors.New("invalid galaxy-info mode")
+	// ErrUnsupportedArtifactHash indicates an unknown or unavailable --artifact-hash algorithm.
+	ErrUnsupportedArtifactHash = errors.New("unsupported artifact hash")
 	// ErrSHA256Mismatch indicates a checksum mismatch.
 	ErrSHA256Mismatch = errors.New("sha256 mismatch")
 	// ErrMetadataUnavailable indicates metadata could not be loaded.
@@ -58,6 +83,8 @@ var (
 	ErrCacheDirEmpty = errors.New("cache directory is empty")
 	// ErrAnotherInstanceIsRunning indicates another instance is already running.
 	ErrAnotherInstanceIsRunning = errors.New("another instance is running")
+	// ErrLockLost indicates the cache lock could not be kept while the run held it.
+	ErrLockLost = errors.New("cache lock was lost")
 	// ErrNoSemverCandidates indicates no semver candidates are available.
 	ErrNoSemverCandidates = errors.New("no semver candidates available")
 	// ErrMissingResolvedParent indicates a resolved parent is missing.
@@ -78,10 +105,22 @@ var (
 	ErrVersionsPayloadUnsupported = errors.New("unsupported versions payload")
 	// ErrDownloadFailed indicates a download failed.
 	ErrDownloadFailed = errors.New("download failed")
+	// ErrArtifactNotFound indicates an artifact download URL returned 404.
+	ErrArtifactNotFound = errors.New("artifact not found")
+	// ErrTooManyRedirects indicates a request exceeded the redirect limit.
+	ErrTooManyRedirects = errors.New("too many redirects")
+	// ErrMetadataTooLarge indicates a metadata response over the body size limit.
+	ErrMetadataTooLarge = errors.New("metadata response too large")
+	// ErrMetadataStalled indicates a metadata response that stopped making progress.
+	ErrMetadataStalled = errors.New("metadata response stalled")
+	// ErrDownloadURLRejected indicates the download URL answered 401/403/410, e.g. an expired signature.
+	ErrDownloadURLRejected = errors.New("download url rejected")
 	// ErrMissingResolvedRoot indicates a resolved root is missing.
 	ErrMissingResolvedRoot = errors.New("missing resolved root")
 	// ErrInstallationFailed indicates installation failed.
 	ErrInstallationFailed = errors.New("installation failed")
+	// ErrUnknownRequirementsKey indicates a requirements file uses a key go-galaxy does not know.
+	ErrUnknownRequirementsKey = errors.New("unknown requirements key")
 	// ErrInvalidCollectionsList indicates the collections list is invalid.
 	ErrInvalidCollectionsList = errors.New("invalid collections list")
 	// ErrMissingCollection indicates a collection is missing.
@@ -113,4 +152,122 @@ var (
 	ErrStoreNil = errors.New("store is nil")
 	// ErrUnsupportedSchemaVersion indicates the snapshot schema version is unsupported.
 	ErrUnsupportedSchemaVersion = errors.New("unsupported snapshot schema version")
+	// ErrUnknownStoreBucket indicates an unknown store bucket was requested.
+	ErrUnknownStoreBucket = errors.New("unknown store bucket")
+	// ErrUnsupportedOutputFormat indicates an unsupported output format was requested.
+	ErrUnsupportedOutputFormat = errors.New("unsupported output format")
+	// ErrManifestMismatch indicates installed collections differ from the install manifest.
+	ErrManifestMismatch = errors.New("collections do not match the install manifest")
+	// ErrSearchTermRequired indicates search was invoked without a term.
+	ErrSearchTermRequired = errors.New("search term is required")
+	// ErrInterrupted indicates the run was stopped by a shutdown signal.
+	ErrInterrupted = errors.New("interrupted")
+	// ErrMigrationSourceRequired indicates cache migrate was invoked without a source backend.
+	ErrMigrationSourceRequired = errors.New("--cache-migrate-from is required")
+	// ErrMigrationSameBackend indicates the migration source equals the configured backend.
+	ErrMigrationSameBackend = errors.New("migration source is the configured backend")
+	// ErrFrozenResolutionChanged indicates --frozen resolution differs from the stored snapshot.
+	ErrFrozenResolutionChanged = errors.New("resolution differs from the stored snapshot")
+	// ErrInvalidImageReference indicates an OCI image reference could not be parsed.
+	ErrInvalidImageReference = errors.New("invalid image reference")
+	// ErrRegistryAuth indicates authenticating to an OCI registry failed.
+	ErrRegistryAuth = errors.New("registry authentication failed")
+	// ErrRegistryProtocol indicates an OCI registry response did not follow the distribution API.
+	ErrRegistryProtocol = errors.New("unexpected registry response")
+	// ErrNothingToExport indicates the collections path has no installed collections.
+	ErrNothingToExport = errors.New("no installed collections to export")
+	// ErrProjectNotRecorded indicates cleanup --project names a project missing from the registry.
+	ErrProjectNotRecorded = errors.New("project is not recorded in the project registry")
+	// ErrInvalidHealthCheckPolicy indicates an unknown --health-check value.
+	ErrInvalidHealthCheckPolicy = errors.New("invalid health check policy")
+	// ErrServerUnhealthy indicates the server API failed the pre-resolve health check.
+	ErrServerUnhealthy = errors.New("server API is unhealthy")
+	// ErrInvalidVerifySkipMode indicates an unknown --verify-skip value.
+	ErrInvalidVerifySkipMode = errors.New("invalid verify-skip mode")
+	// ErrInvalidInstallTemplate indicates an --install-template that does not render to a local path.
+	ErrInvalidInstallTemplate = errors.New("invalid install template")
+	// ErrInvalidSize indicates a byte size flag that does not parse, e.g. --max-total-download.
+	ErrInvalidSize = errors.New("invalid size")
+	// ErrInvalidBudgetPolicy indicates an unknown --budget-policy value.
+	ErrInvalidBudgetPolicy = errors.New("invalid budget policy")
+	// ErrBudgetExceeded indicates an install went over --max-total-download or --max-install-time.
+	ErrBudgetExceeded = errors.New("install budget exceeded")
+	// ErrQuarantineFailed indicates cleanup could not move a collection into --quarantine-dir.
+	ErrQuarantineFailed = errors.New("failed to quarantine collection")
+	// ErrInvalidArtifactFile indicates an --artifact file that is not a collection tarball.
+	ErrInvalidArtifactFile = errors.New("invalid collection artifact")
+	// ErrArtifactConstraint indicates an --artifact version that a requirement or dependency rules out.
+	ErrArtifactConstraint = errors.New("collection artifact does not satisfy a requirement")
+	// ErrInvalidDepsSource indicates an unknown --deps-source value.
+	ErrInvalidDepsSource = errors.New("invalid dependency source")
+	// ErrInvalidOutputStyle indicates an unknown --output-style value.
+	ErrInvalidOutputStyle = errors.New("invalid output style")
+	// ErrUnsupportedShell indicates a completion script requested for an unknown shell.
+	ErrUnsupportedShell = errors.New("unsupported shell")
+	// ErrInvalidConformanceCase indicates a conformance corpus file that does not parse or misses fields.
+	ErrInvalidConformanceCase = errors.New("invalid conformance case")
+	// ErrConformanceDivergence indicates a resolution that differs from the one recorded from ansible-galaxy.
+	ErrConformanceDivergence = errors.New("resolution diverges from ansible-galaxy")
+	// ErrInvalidChaosRate indicates a --chaos-s3-error-rate or --chaos-cache-error-rate outside 0..1.
+	ErrInvalidChaosRate = errors.New("invalid chaos error rate")
+	// ErrNoLockfiles indicates download was run without --from-lock.
+	ErrNoLockfiles = errors.New("no lockfiles given")
+	// ErrLockEntryNotPinned indicates a lockfile entry without an exact version.
+	ErrLockEntryNotPinned = errors.New("lockfile entry is not pinned to an exact version")
+	// ErrCacheWarmFailed indicates some pinned collections could not be cached.
+	ErrCacheWarmFailed = errors.New("cache warm-up failed")
+	// ErrInvalidReplacement indicates a malformed --replacements-file entry.
+	ErrInvalidReplacement = errors.New("invalid collection replacement")
+	// ErrReplacementCycle indicates collections that replace each other.
+	ErrReplacementCycle = errors.New("collection replacements form a cycle")
+	// ErrCollectionReplaced indicates a collection the server reports as replaced by another.
+	ErrCollectionReplaced = errors.New("collection is replaced")
+	// ErrInvalidSourceConfig indicates a galaxy_server section of ansible.cfg that cannot be used.
+	ErrInvalidSourceConfig = errors.New("invalid galaxy server settings")
+	// ErrInvalidFixture indicates devtools gen-cache sizes that cannot build a cache.
+	ErrInvalidFixture = errors.New("invalid cache fixture settings")
+	// ErrInvalidProxyUpstream indicates the metadata proxy has no absolute server URL to forward to.
+	ErrInvalidProxyUpstream = errors.New("metadata proxy needs an absolute server url")
+	// ErrMigrateInputRequired indicates requirements migrate was run without exactly one input file.
+	ErrMigrateInputRequired = errors.New("requirements migrate needs exactly one input file")
+	// ErrLockfileNeedsNoDeps indicates --lockfile was given without --no-deps.
+	ErrLockfileNeedsNoDeps = errors.New("--lockfile requires --no-deps")
+	// ErrLockfileIncomplete indicates the lockfile lacks a requested collection or its dependency edges.
+	ErrLockfileIncomplete = errors.New("lockfile does not cover the requested collections")
+	// ErrInvalidInfoCheckMode indicates an unknown --info-check value.
+	ErrInvalidInfoCheckMode = errors.New("invalid info-check mode")
+	// ErrInvalidServerMirror indicates a --server-mirror value that is not an absolute URL.
+	ErrInvalidServerMirror = errors.New("invalid server mirror")
+	// ErrArchiveTooManyEntries indicates an archive holds more entries than allowed.
+	ErrArchiveTooManyEntries = errors.New("archive has too many entries")
+	// ErrArchiveEntryTooDeep indicates an archive entry path is nested deeper than allowed.
+	ErrArchiveEntryTooDeep = errors.New("archive entry path is too deep")
+	// ErrInvalidChmodMode indicates a --chmod-files or --chmod-dirs value that is not an octal permission.
+	ErrInvalidChmodMode = errors.New("invalid chmod mode")
+	// ErrInvalidWhenCondition indicates a requirements when: condition that cannot be parsed.
+	ErrInvalidWhenCondition = errors.New("invalid when condition")
+	// ErrOfflineGaps indicates the cache lacks objects an offline install of a lockfile needs.
+	ErrOfflineGaps = errors.New("cache is not ready for an offline install")
+	// ErrUnsupportedContentEncoding indicates an API response compressed with an encoding that was not asked for.
+	ErrUnsupportedContentEncoding = errors.New("unsupported content encoding")
+	// ErrVersionsEntryCorrupted indicates a stored versions list that cannot be decoded.
+	ErrVersionsEntryCorrupted = errors.New("versions cache entry is corrupted")
+	// ErrInvalidTargetVersion indicates a --target-ansible-core or --target-python value that is not a version.
+	ErrInvalidTargetVersion = errors.New("invalid target version")
+	// ErrRequiresAnsible indicates an installed collection whose requires_ansible excludes a target ansible-core.
+	ErrRequiresAnsible = errors.New("collection does not support the target ansible-core")
+	// ErrGitCommandFailed indicates a git command for a type: git requirement failed.
+	ErrGitCommandFailed = errors.New("git command failed")
+	// ErrInvalidGalaxyYML indicates a git requirement whose galaxy.yml is missing or incomplete.
+	ErrInvalidGalaxyYML = errors.New("invalid galaxy.yml")
+	// ErrDaemonUnauthorized indicates a daemon API request without the configured token.
+	ErrDaemonUnauthorized = errors.New("missing or invalid daemon token")
+	// ErrPathNotAllowed indicates a daemon request override outside the --allow-path directories.
+	ErrPathNotAllowed = errors.New("path is outside the daemon's allowed paths")
+	// ErrChaosInjected indicates a cache backend call failed on purpose by --chaos-cache-error-rate.
+	ErrChaosInjected = errors.New("cache failure injected by --chaos-cache-error-rate")
+	// ErrPreflightCheckRequired indicates preflight was run without a check to perform.
+	ErrPreflightCheckRequired = errors.New("preflight needs a check to run")
+	// ErrDaemonTokenRequired indicates a daemon asked to listen on a non-loopback address without --token.
+	ErrDaemonTokenRequired = errors.New("daemon needs a token to listen on a non-loopback address")
 )
@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// MoveFile renames src to dst, falling back to copy and rename when the
+// paths live on different filesystems.
+func MoveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyIntoPlace(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyIntoPlace copies src next to dst and renames the copy over dst so
+// readers never observe a partially written file.
+func copyIntoPlace(src, dst string) error {
+	//nolint:gosec // src is a staging file created by the caller.
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".move-")
+	if err != nil {
+		return err
+	}
+	tmpPath := out.Name()
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Chmod(info.Mode().Perm()); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, dst); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ErrorCategory groups failures by what the user has to look at.
+type ErrorCategory string
+
+const (
+	// CategoryNetwork covers unreachable or failing servers.
+	CategoryNetwork ErrorCategory = "network"
+	// CategoryAuth covers rejected requests and download URLs.
+	CategoryAuth ErrorCategory = "auth"
+	// CategoryIntegrity covers checksum and archive validation failures.
+	CategoryIntegrity ErrorCategory = "integrity"
+	// CategoryCache covers unreadable or incompatible cache state.
+	CategoryCache ErrorCategory = "cache"
+	// CategoryRequirements covers unsatisfiable or invalid requirements.
+	CategoryRequirements ErrorCategory = "requirements"
+	// CategoryLock covers cache lock contention.
+	CategoryLock ErrorCategory = "lock"
+	// CategoryConfig covers invalid flags and environment.
+	CategoryConfig ErrorCategory = "config"
+)
+
+// Error annotates a failure with a category, the collection it concerns and a remediation hint.
+type Error struct {
+	Category   ErrorCategory
+	Collection string
+	Hint       string
+	Err        error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Collection != "" {
+		return e.Collection + ": " + e.Err.Error()
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the annotated error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatusCoder is implemented by errors that carry an HTTP response status.
+type HTTPStatusCoder interface {
+	HTTPStatus() int
+}
+
+// Classify annotates err with the collection and, when recognized, a category and hint.
+func Classify(err error, collection string) error {
+	if err == nil {
+		return nil
+	}
+	var annotated *Error
+	if errors.As(err, &annotated) && annotated.Collection != "" {
+		return err
+	}
+	category, hint := classify(err)
+	if category == "" && collection == "" {
+		return err
+	}
+	return &Error{Category: category, Collection: collection, Hint: hint, Err: err}
+}
+
+// Hints returns the distinct remediation hints found anywhere in err's tree.
+func Hints(err error) []string {
+	var hints []string
+	seen := make(map[string]bool)
+	add := func(hint string) {
+		if hint != "" && !seen[hint] {
+			seen[hint] = true
+			hints = append(hints, hint)
+		}
+	}
+	var walk func(error)
+	walk = func(err error) {
+		if err == nil {
+			return
+		}
+		switch e := err.(type) { //nolint:errorlint // walking the tree by hand.
+		case *Error:
+			add(e.Hint)
+			walk(e.Err)
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				walk(inner)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		}
+	}
+	walk(err)
+	if len(hints) == 0 {
+		_, hint := classify(err)
+		add(hint)
+	}
+	return hints
+}
+
+type hintRule struct {
+	target   error
+	category ErrorCategory
+	hint     string
+}
+
+// hintRules maps known sentinel errors to remediation hints, most specific first.
+func hintRules() []hintRule {
+	const (
+		resolveHint = "check the version constraints in the requirements file; " +
+			"'go-galaxy resolve-version --all <namespace.name>' lists the candidates"
+		clearHint = "run with --clear-cache to rebuild the cache"
+	)
+	return []hintRule{
+		{ErrInterrupted, "", ""},
+		{ErrSHA256Mismatch, CategoryIntegrity, "the cached artifact may be corrupt; run with --clear-cache or --no-cache"},
+		{ErrCacheValueCorrupted, CategoryCache, clearHint},
+		{ErrCacheValueNotEncrypted, CategoryCache, clearHint},
+		{ErrUnsupportedSchemaVersion, CategoryCache, "the cache was written by a newer go-galaxy; upgrade or " + clearHint},
+		{ErrAnotherInstanceIsRunning, CategoryLock, "wait for the other run to finish, or isolate this one with --cache-namespace"},
+		{ErrLockLost, CategoryLock, "the lease could not be renewed in time; check access to the Kubernetes API and rerun"},
+		{ErrS3EmptyCreds, CategoryConfig, "set GO_GALAXY_S3_ACCESS_KEY and GO_GALAXY_S3_SECRET_KEY (or the AWS_* equivalents)"},
+		{ErrInvalidCacheEncryptionKey, CategoryConfig, "generate a key with 'openssl rand -hex 32'"},
+		{ErrUnsupportedArtifactHash, CategoryConfig, "set --artifact-hash to sha256, sha512 or blake3"},
+		{ErrVersionUnpublished, CategoryRequirements,
+			"a rebuild from scratch would fail; move the requirement to a published version or keep the artifact in the cache"},
+		{ErrInvalidGalaxyInfoMode, CategoryConfig, "set --galaxy-info to default, extended, ansible or none"},
+		{ErrProjectNotRecorded, CategoryConfig,
+			"pass the project directory or requirements file used by install; projects are recorded on each install"},
+		{ErrInvalidHealthCheckPolicy, CategoryConfig, "set --health-check to fail, snapshot or off"},
+		{ErrServerUnhealthy, CategoryNetwork,
+			"retry later, use --health-check snapshot to install the last stored resolution, or --health-check off to try anyway"},
+		{ErrInvalidVerifySkipMode, CategoryConfig, "set --verify-skip to off, sample or all"},
+		{ErrInvalidInstallTemplate, CategoryConfig,
+			"use a relative path with {namespace} and {name}, e.g. {namespace}/{name}-{version}"},
+		{ErrInvalidSize, CategoryConfig, "use a byte count with an optional unit, e.g. 500MiB, 2GB or 1048576"},
+		{ErrInvalidBudgetPolicy, CategoryConfig, "set --budget-policy to abort or warn"},
+		{ErrBudgetExceeded, CategoryRequirements,
+			"a requirement likely pulled in more dependencies than expected; review the resolved graph or raise the budget"},
+		{ErrQuarantineFailed, CategoryConfig,
+			"--quarantine-dir must be writable and on the same filesystem as the collections path"},
+		{ErrInvalidArtifactFile, CategoryConfig,
+			"pass a collection tarball built by ansible-galaxy collection build, with MANIFEST.json at its root"},
+		{ErrArtifactConstraint, CategoryRequirements,
+			"rebuild the artifact with a version the requirements allow, or relax the constraint that rules it out"},
+		{ErrInvalidDepsSource, CategoryConfig, "set --deps-source to metadata or manifest"},
+		{ErrInvalidOutputStyle, CategoryConfig, "set --output-style to emoji or plain"},
+		{ErrUnsupportedShell, CategoryConfig, "generate the completion script with: go-galaxy completion bash|zsh"},
+		{ErrInvalidConformanceCase, CategoryConfig, "each case needs requirements, index and ansible_galaxy (or ansible_galaxy_fails: true)"},
+		{ErrConformanceDivergence, CategoryRequirements, "pin the diverging collections in requirements.yml to get ansible-galaxy's result"},
+		{ErrInvalidChaosRate, CategoryConfig, "set --chaos-s3-error-rate and --chaos-cache-error-rate between 0 and 1"},
+		{ErrDaemonTokenRequired, CategoryConfig, "set --token (or $GO_GALAXY_DAEMON_TOKEN), or --listen on 127.0.0.1"},
+		{ErrPreflightCheckRequired, CategoryConfig, "pass --offline-target to check the cache for an offline install"},
+		{ErrChaosInjected, CategoryNetwork, "the failure was injected on purpose; add --cache-soft-fail to run on without the cache"},
+		{ErrNoLockfiles, CategoryConfig, "pass --from-lock with an install-manifest.json or a pinned requirements file"},
+		{ErrLockEntryNotPinned, CategoryRequirements, "pin every collection in the lockfile to one version, or warm from install-manifest.json"},
+		{ErrInvalidReplacement, CategoryConfig, "map each namespace.name to a different namespace.name in --replacements-file"},
+		{ErrReplacementCycle, CategoryConfig, "remove one direction of the rename from --replacements-file"},
+		{ErrInvalidSourceConfig, CategoryConfig, "check url, ca_cert, client_cert/client_key and proxy of the galaxy_server section in ansible.cfg"},
+		{ErrInvalidFixture, CategoryConfig, "pass --collections and --versions of at least 1 and --deps of at least 0"},
+		{ErrInvalidProxyUpstream, CategoryConfig, "set --server to the Galaxy URL, e.g. https://galaxy.ansible.com/"},
+		{ErrMigrateInputRequired, CategoryConfig,
+			"pass the text list or execution-environment.yml to convert, e.g. go-galaxy requirements migrate collections.txt"},
+		{ErrLockfileNeedsNoDeps, CategoryConfig, "add --no-deps to install the locked closure, or drop --lockfile to resolve"},
+		{ErrLockfileIncomplete, CategoryRequirements,
+			"regenerate the install-manifest.json with a full install of the same requirements, or drop --lockfile"},
+		{ErrInvalidInfoCheckMode, CategoryConfig, "set --info-check to require or manifest"},
+		{ErrInvalidServerMirror, CategoryConfig, "pass each --server-mirror as a full URL, e.g. https://mirror.example.com/"},
+		{ErrInvalidChmodMode, CategoryConfig,
+			"pass --chmod-files and --chmod-dirs as octal permissions, e.g. 0644 and 0755; --chmod-dirs must include 0700"},
+		{ErrArchiveTooManyEntries, CategoryIntegrity, "raise --archive-max-entries only if you trust the collection's source"},
+		{ErrArchiveEntryTooDeep, CategoryIntegrity, "raise --archive-max-depth only if you trust the collection's source"},
+		{ErrInvalidWhenCondition, CategoryRequirements,
+			"write when: as comparisons of os, arch or env.NAME joined by and/or, e.g. os == \"linux\" and arch in [\"amd64\", \"arm64\"]"},
+		{ErrOfflineGaps, CategoryCache,
+			"run 'go-galaxy download --from-lock <lockfile>' against the same cache backend while the server is reachable"},
+		{ErrUnsupportedContentEncoding, CategoryNetwork,
+			"go-galaxy asks for gzip or deflate; check proxies between this machine and the server that re-encode responses"},
+		{ErrVersionsEntryCorrupted, CategoryCache, clearHint},
+		{ErrInvalidTargetVersion, CategoryConfig, "pass --target-ansible-core and --target-python as versions, e.g. 2.16.3 and 3.11"},
+		{ErrRequiresAnsible, CategoryRequirements,
+			"pin a collection version whose meta/runtime.yml requires_ansible includes every --target-ansible-core, or drop that target"},
+		{ErrGitCommandFailed, CategoryNetwork, "check the repository URL, its credentials and that version names a branch, tag or commit"},
+		{ErrInvalidGalaxyYML, CategoryRequirements,
+			"point the git requirement at a collection directory whose galaxy.yml sets namespace, name and version, e.g. repo.git#/path"},
+		{ErrNoVersionSatisfiesConstraints, CategoryRequirements, resolveHint},
+		{ErrConflictingRootConstraints, CategoryRequirements, resolveHint},
+		{ErrConflictingExactVersions, CategoryRequirements, resolveHint},
+		{ErrFrozenResolutionChanged, CategoryRequirements,
+			"run once without --frozen to accept the new versions, or pin them in the requirements file"},
+		{ErrDependencyGraphHasACycle, CategoryRequirements, "break the dependency cycle or install with --no-deps"},
+		{ErrDownloadURLRejected, CategoryAuth, "the download host rejected the request; check access to it from this machine"},
+		{ErrArtifactNotFound, CategoryNetwork, "the version may have been removed upstream; run with --refresh to re-read version lists"},
+		{ErrTooManyRedirects, CategoryNetwork, "check --server; the server or a proxy is redirecting in a loop"},
+		{ErrMetadataTooLarge, CategoryNetwork, "check --server; the server or a proxy answered with something other than Galaxy metadata"},
+		{ErrMetadataStalled, CategoryNetwork, "the server stopped sending data; retry later or check proxies between you and --server"},
+		{ErrRegistryAuth, CategoryAuth, "check --registry-username and --registry-password and that they can push to the repository"},
+	}
+}
+
+// classify returns the category and hint for the first recognized cause of err.
+func classify(err error) (ErrorCategory, string) {
+	for _, rule := range hintRules() {
+		if errors.Is(err, rule.target) {
+			return rule.category, rule.hint
+		}
+	}
+	var status HTTPStatusCoder
+	if errors.As(err, &status) {
+		switch code := status.HTTPStatus(); {
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return CategoryAuth, "the server rejected the request; check --server and the token or username/password " +
+				"of its galaxy_server section in ansible.cfg"
+		case code == http.StatusTooManyRequests || code >= http.StatusInternalServerError:
+			return CategoryNetwork, "the server is throttling or failing; retry later or lower --workers"
+		}
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return CategoryNetwork, "check connectivity to --server or raise --timeout"
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return CategoryNetwork, "check that --server is reachable from this machine"
+	}
+	return "", ""
+}
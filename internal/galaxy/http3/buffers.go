@@ -0,0 +1,199 @@
+package http3
+
+// sendBuffer holds the data of one stream or crypto stream that was written
+// but not yet acknowledged, and what of it needs (re)sending.
+type sendBuffer struct {
+	// data starts at offset base; everything below base was acknowledged.
+	data []byte
+	base uint64
+	// next is the offset of the first byte never sent.
+	next uint64
+	// lost are ranges sent before that must be sent again.
+	lost rangeSet
+	// acked are acknowledged ranges above base.
+	acked rangeSet
+	// fin is set once the stream is closed for writing.
+	fin      bool
+	finSent  bool
+	finAcked bool
+}
+
+// write appends data to the stream.
+func (s *sendBuffer) write(data []byte) {
+	s.data = append(s.data, data...)
+}
+
+// end is the offset one past the last byte written.
+func (s *sendBuffer) end() uint64 {
+	return s.base + uint64(len(s.data))
+}
+
+// unsent is how many written bytes were never sent.
+func (s *sendBuffer) unsent() uint64 {
+	return s.end() - s.next
+}
+
+// hasPending reports whether anything is waiting to be sent, new data
+// beyond limit excepted.
+func (s *sendBuffer) hasPending(limit uint64) bool {
+	if len(s.lost) > 0 {
+		return true
+	}
+	if s.next < s.end() && s.next < limit {
+		return true
+	}
+	return s.fin && !s.finSent && s.next == s.end()
+}
+
+// nextChunk returns the next range to send: lost data first, then new data
+// up to limit. It returns at most size bytes and whether the chunk carries
+// the end of the stream; ok is false when there is nothing to send.
+func (s *sendBuffer) nextChunk(size int, limit uint64) (off uint64, data []byte, fin, ok bool) {
+	if size <= 0 {
+		return 0, nil, false, false
+	}
+	for len(s.lost) > 0 {
+		r := s.lost[0]
+		if r.end <= s.base {
+			s.lost = s.lost[1:]
+			continue
+		}
+		start := max(r.start, s.base)
+		end := min(r.end, start+uint64(size))
+		if end >= r.end {
+			s.lost = s.lost[1:]
+		} else {
+			s.lost[0].start = end
+		}
+		fin = s.fin && end == s.end()
+		if fin {
+			s.finSent = true
+		}
+		return start, s.data[start-s.base : end-s.base], fin, true
+	}
+	if s.next < s.end() && s.next < limit {
+		start := s.next
+		end := min(s.end(), start+uint64(size), limit)
+		s.next = end
+		fin = s.fin && end == s.end()
+		if fin {
+			s.finSent = true
+		}
+		return start, s.data[start-s.base : end-s.base], fin, true
+	}
+	if s.fin && !s.finSent && s.next == s.end() {
+		s.finSent = true
+		return s.next, nil, true, true
+	}
+	return 0, nil, false, false
+}
+
+// onAck records that [off, off+n) was acknowledged and drops data below
+// the first gap.
+func (s *sendBuffer) onAck(off, n uint64, fin bool) {
+	if fin {
+		s.finAcked = true
+	}
+	if off+n <= s.base {
+		return
+	}
+	s.acked.add(max(off, s.base), off+n)
+	if s.acked.min() > s.base {
+		return
+	}
+	newBase := s.acked[0].end
+	s.acked = s.acked[1:]
+	s.data = s.data[newBase-s.base:]
+	if len(s.data) == 0 {
+		s.data = nil
+	}
+	s.base = newBase
+}
+
+// onLost queues [off, off+n) for sending again.
+func (s *sendBuffer) onLost(off, n uint64, fin bool) {
+	if fin && !s.finAcked {
+		s.finSent = false
+	}
+	if off+n > s.base {
+		s.lost.add(max(off, s.base), off+n)
+	}
+}
+
+// done reports whether every byte and the end of the stream were acknowledged.
+func (s *sendBuffer) done() bool {
+	return s.fin && s.finAcked && len(s.data) == 0
+}
+
+// recvBuffer reassembles the data of one stream or crypto stream.
+type recvBuffer struct {
+	// data is the contiguous data not yet read, starting at offset readOff.
+	data    []byte
+	readOff uint64
+	// pending holds data received out of order, sorted by offset.
+	pending []segment
+	// highest is one past the largest offset received.
+	highest uint64
+	// finalSize is the size of the stream once its end was received.
+	finalSize uint64
+	hasFinal  bool
+}
+
+// segment is data received ahead of a gap.
+type segment struct {
+	off  uint64
+	data []byte
+}
+
+// contiguous is the offset one past the last byte that can be read.
+func (r *recvBuffer) contiguous() uint64 {
+	return r.readOff + uint64(len(r.data))
+}
+
+// push stores data received at off; it is copied.
+func (r *recvBuffer) push(off uint64, data []byte) {
+	end := off + uint64(len(data))
+	r.highest = max(r.highest, end)
+	have := r.contiguous()
+	if end <= have {
+		return
+	}
+	if off > have {
+		i := 0
+		for i < len(r.pending) && r.pending[i].off < off {
+			i++
+		}
+		r.pending = append(r.pending, segment{})
+		copy(r.pending[i+1:], r.pending[i:])
+		r.pending[i] = segment{off: off, data: append([]byte(nil), data...)}
+		return
+	}
+	r.data = append(r.data, data[have-off:]...)
+	for len(r.pending) > 0 {
+		seg := r.pending[0]
+		have = r.contiguous()
+		if seg.off > have {
+			break
+		}
+		if segEnd := seg.off + uint64(len(seg.data)); segEnd > have {
+			r.data = append(r.data, seg.data[have-seg.off:]...)
+		}
+		r.pending = r.pending[1:]
+	}
+}
+
+// read copies readable data into p.
+func (r *recvBuffer) read(p []byte) int {
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	r.readOff += uint64(n)
+	if len(r.data) == 0 {
+		r.data = nil
+	}
+	return n
+}
+
+// eof reports whether every byte up to the final size was read.
+func (r *recvBuffer) eof() bool {
+	return r.hasFinal && r.readOff == r.finalSize
+}
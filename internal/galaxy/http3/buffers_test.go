@@ -0,0 +1,75 @@
+package http3
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSendBufferResendsLostData(t *testing.T) {
+	t.Parallel()
+	var s sendBuffer
+	s.write([]byte("0123456789"))
+	s.fin = true
+	off, data, fin, ok := s.nextChunk(4, maxVarint)
+	if !ok || off != 0 || string(data) != "0123" || fin {
+		t.Fatalf("nextChunk = %d %q %v %v", off, data, fin, ok)
+	}
+	off, data, fin, ok = s.nextChunk(100, maxVarint)
+	if !ok || off != 4 || string(data) != "456789" || !fin {
+		t.Fatalf("nextChunk = %d %q %v %v", off, data, fin, ok)
+	}
+	s.onLost(0, 4, false)
+	s.onAck(4, 6, true)
+	if s.done() || !s.hasPending(maxVarint) {
+		t.Fatalf("lost data is not pending")
+	}
+	off, data, _, ok = s.nextChunk(100, maxVarint)
+	if !ok || off != 0 || string(data) != "0123" {
+		t.Fatalf("resend = %d %q %v", off, data, ok)
+	}
+	s.onAck(0, 4, false)
+	if !s.done() || s.base != 10 || s.data != nil {
+		t.Fatalf("buffer not drained: base %d, %d bytes", s.base, len(s.data))
+	}
+	// A late duplicate ack below base is ignored.
+	s.onAck(0, 4, false)
+	if s.base != 10 {
+		t.Fatalf("duplicate ack moved base to %d", s.base)
+	}
+}
+
+func TestSendBufferHonorsFlowControlLimit(t *testing.T) {
+	t.Parallel()
+	var s sendBuffer
+	s.write(make([]byte, 100))
+	if _, data, _, ok := s.nextChunk(1000, 30); !ok || len(data) != 30 {
+		t.Fatalf("nextChunk sent %d bytes past a limit of 30", len(data))
+	}
+	if s.hasPending(30) {
+		t.Fatalf("data beyond the limit is pending")
+	}
+}
+
+func TestRecvBufferReassembles(t *testing.T) {
+	t.Parallel()
+	var r recvBuffer
+	r.push(6, []byte("world"))
+	r.push(3, []byte("lo "))
+	if r.contiguous() != 0 {
+		t.Fatalf("contiguous = %d before the gap is filled", r.contiguous())
+	}
+	r.push(0, []byte("hel"))
+	r.push(2, []byte("llo"))
+	if r.contiguous() != 11 || r.highest != 11 {
+		t.Fatalf("contiguous = %d, highest = %d", r.contiguous(), r.highest)
+	}
+	buf := make([]byte, 20)
+	n := r.read(buf)
+	if !bytes.Equal(buf[:n], []byte("hello world")) {
+		t.Fatalf("read %q", buf[:n])
+	}
+	r.finalSize, r.hasFinal = 11, true
+	if !r.eof() {
+		t.Fatalf("eof not reported after the final byte")
+	}
+}
@@ -0,0 +1,247 @@
+package http3
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"math/bits"
+)
+
+// ChaCha20-Poly1305 parameters from RFC 8439.
+const (
+	chachaKeySize   = 32
+	chachaNonceSize = 12
+	chachaBlockSize = 64
+	poly1305TagSize = 16
+	poly1305Block   = 16
+)
+
+// chachaState returns the initial ChaCha20 state for key, counter and nonce.
+func chachaState(key []byte, counter uint32, nonce []byte) [16]uint32 {
+	var s [16]uint32
+	s[0], s[1], s[2], s[3] = 0x61707865, 0x3320646e, 0x79622d32, 0x6b206574
+	for i := range 8 {
+		s[4+i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	s[12] = counter
+	for i := range 3 {
+		s[13+i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+	return s
+}
+
+// chachaQuarterRound mixes four words of the state.
+func chachaQuarterRound(s *[16]uint32, a, b, c, d int) {
+	s[a] += s[b]
+	s[d] = bits.RotateLeft32(s[d]^s[a], 16)
+	s[c] += s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], 12)
+	s[a] += s[b]
+	s[d] = bits.RotateLeft32(s[d]^s[a], 8)
+	s[c] += s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], 7)
+}
+
+// chachaBlock writes the 64-byte keystream block of state to out.
+func chachaBlock(state [16]uint32, out *[chachaBlockSize]byte) {
+	w := state
+	for range 10 {
+		chachaQuarterRound(&w, 0, 4, 8, 12)
+		chachaQuarterRound(&w, 1, 5, 9, 13)
+		chachaQuarterRound(&w, 2, 6, 10, 14)
+		chachaQuarterRound(&w, 3, 7, 11, 15)
+		chachaQuarterRound(&w, 0, 5, 10, 15)
+		chachaQuarterRound(&w, 1, 6, 11, 12)
+		chachaQuarterRound(&w, 2, 7, 8, 13)
+		chachaQuarterRound(&w, 3, 4, 9, 14)
+	}
+	for i := range w {
+		binary.LittleEndian.PutUint32(out[4*i:], w[i]+state[i])
+	}
+}
+
+// chachaXOR XORs src with the ChaCha20 keystream starting at block counter
+// and writes the result to dst, which may alias src exactly.
+func chachaXOR(key []byte, counter uint32, nonce, dst, src []byte) {
+	state := chachaState(key, counter, nonce)
+	var block [chachaBlockSize]byte
+	for len(src) > 0 {
+		chachaBlock(state, &block)
+		state[12]++
+		n := min(len(src), chachaBlockSize)
+		subtle.XORBytes(dst[:n], src[:n], block[:n])
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// poly1305 is the Poly1305 one-time authenticator over 64-bit limbs.
+type poly1305 struct {
+	h0, h1, h2 uint64
+	r0, r1     uint64
+	s0, s1     uint64
+	buf        [poly1305Block]byte
+	n          int
+}
+
+// newPoly1305 clamps r and keeps s from the 32-byte one-time key.
+func newPoly1305(key []byte) *poly1305 {
+	return &poly1305{
+		r0: binary.LittleEndian.Uint64(key[0:8]) & 0x0FFFFFFC0FFFFFFF,
+		r1: binary.LittleEndian.Uint64(key[8:16]) & 0x0FFFFFFC0FFFFFFC,
+		s0: binary.LittleEndian.Uint64(key[16:24]),
+		s1: binary.LittleEndian.Uint64(key[24:32]),
+	}
+}
+
+// block adds one 16-byte block, with hibit set unless it is a padded tail,
+// and multiplies the accumulator by r modulo 2^130-5.
+func (p *poly1305) block(m []byte, hibit uint64) {
+	var c uint64
+	p.h0, c = bits.Add64(p.h0, binary.LittleEndian.Uint64(m[0:8]), 0)
+	p.h1, c = bits.Add64(p.h1, binary.LittleEndian.Uint64(m[8:16]), c)
+	p.h2 += c + hibit
+
+	h0r0hi, h0r0lo := bits.Mul64(p.h0, p.r0)
+	h1r0hi, h1r0lo := bits.Mul64(p.h1, p.r0)
+	h0r1hi, h0r1lo := bits.Mul64(p.h0, p.r1)
+	h1r1hi, h1r1lo := bits.Mul64(p.h1, p.r1)
+	h2r0 := p.h2 * p.r0
+	h2r1 := p.h2 * p.r1
+
+	m1lo, c := bits.Add64(h1r0lo, h0r1lo, 0)
+	m1hi, _ := bits.Add64(h1r0hi, h0r1hi, c)
+	m2lo, c := bits.Add64(h2r0, h1r1lo, 0)
+	m2hi, _ := bits.Add64(0, h1r1hi, c)
+
+	t0 := h0r0lo
+	t1, c := bits.Add64(h0r0hi, m1lo, 0)
+	t2, c := bits.Add64(m1hi, m2lo, c)
+	t3, _ := bits.Add64(m2hi, h2r1, c)
+
+	// h = t mod 2^130 + 5 * (t >> 130), adding (t >> 130) * 4 and then once more.
+	p.h0, p.h1, p.h2 = t0, t1, t2&3
+	cclo, cchi := t2&^3, t3
+	p.h0, c = bits.Add64(p.h0, cclo, 0)
+	p.h1, c = bits.Add64(p.h1, cchi, c)
+	p.h2 += c
+	cclo, cchi = cclo>>2|cchi<<62, cchi>>2
+	p.h0, c = bits.Add64(p.h0, cclo, 0)
+	p.h1, c = bits.Add64(p.h1, cchi, c)
+	p.h2 += c
+}
+
+// Write absorbs data.
+func (p *poly1305) Write(data []byte) {
+	if p.n > 0 {
+		k := copy(p.buf[p.n:], data)
+		p.n += k
+		data = data[k:]
+		if p.n < poly1305Block {
+			return
+		}
+		p.block(p.buf[:], 1)
+		p.n = 0
+	}
+	for len(data) >= poly1305Block {
+		p.block(data[:poly1305Block], 1)
+		data = data[poly1305Block:]
+	}
+	p.n = copy(p.buf[:], data)
+}
+
+// Sum returns the tag.
+func (p *poly1305) Sum() [poly1305TagSize]byte {
+	if p.n > 0 {
+		p.buf[p.n] = 1
+		clear(p.buf[p.n+1:])
+		p.block(p.buf[:], 0)
+	}
+	// Subtract p = 2^130-5 once if h >= p.
+	t0, b := bits.Sub64(p.h0, 0xFFFFFFFFFFFFFFFB, 0)
+	t1, b := bits.Sub64(p.h1, 0xFFFFFFFFFFFFFFFF, b)
+	_, b = bits.Sub64(p.h2, 3, b)
+	h0, h1 := p.h0, p.h1
+	if b == 0 {
+		h0, h1 = t0, t1
+	}
+	h0, c := bits.Add64(h0, p.s0, 0)
+	h1, _ = bits.Add64(h1, p.s1, c)
+	var tag [poly1305TagSize]byte
+	binary.LittleEndian.PutUint64(tag[0:8], h0)
+	binary.LittleEndian.PutUint64(tag[8:16], h1)
+	return tag
+}
+
+// chacha20Poly1305 is the AEAD_CHACHA20_POLY1305 construction of RFC 8439,
+// which TLS_CHACHA20_POLY1305_SHA256 protects QUIC packets with.
+type chacha20Poly1305 struct {
+	key [chachaKeySize]byte
+}
+
+// newChaCha20Poly1305 returns the AEAD for a 32-byte key.
+func newChaCha20Poly1305(key []byte) cipher.AEAD {
+	a := &chacha20Poly1305{}
+	copy(a.key[:], key)
+	return a
+}
+
+// NonceSize implements cipher.AEAD.
+func (a *chacha20Poly1305) NonceSize() int { return chachaNonceSize }
+
+// Overhead implements cipher.AEAD.
+func (a *chacha20Poly1305) Overhead() int { return poly1305TagSize }
+
+// tag authenticates additionalData and ciphertext with the one-time key of nonce.
+func (a *chacha20Poly1305) tag(nonce, ciphertext, additionalData []byte) [poly1305TagSize]byte {
+	var otk [chachaBlockSize]byte
+	chachaBlock(chachaState(a.key[:], 0, nonce), &otk)
+	mac := newPoly1305(otk[:32])
+	var pad [poly1305Block]byte
+	mac.Write(additionalData)
+	mac.Write(pad[:(poly1305Block-len(additionalData)%poly1305Block)%poly1305Block])
+	mac.Write(ciphertext)
+	mac.Write(pad[:(poly1305Block-len(ciphertext)%poly1305Block)%poly1305Block])
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[0:8], uint64(len(additionalData)))
+	binary.LittleEndian.PutUint64(lengths[8:16], uint64(len(ciphertext)))
+	mac.Write(lengths[:])
+	return mac.Sum()
+}
+
+// Seal implements cipher.AEAD.
+func (a *chacha20Poly1305) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	ret, out := sliceForAppend(dst, len(plaintext)+poly1305TagSize)
+	chachaXOR(a.key[:], 1, nonce, out[:len(plaintext)], plaintext)
+	tag := a.tag(nonce, out[:len(plaintext)], additionalData)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+// Open implements cipher.AEAD.
+func (a *chacha20Poly1305) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < poly1305TagSize {
+		return nil, errOpen
+	}
+	body, want := ciphertext[:len(ciphertext)-poly1305TagSize], ciphertext[len(ciphertext)-poly1305TagSize:]
+	tag := a.tag(nonce, body, additionalData)
+	if subtle.ConstantTimeCompare(tag[:], want) != 1 {
+		return nil, errOpen
+	}
+	ret, out := sliceForAppend(dst, len(body))
+	chachaXOR(a.key[:], 1, nonce, out, body)
+	return ret, nil
+}
+
+// sliceForAppend extends in by n bytes, returning the whole slice and the
+// new tail, reusing in's capacity when it suffices.
+func sliceForAppend(in []byte, n int) ([]byte, []byte) {
+	total := len(in) + n
+	var head []byte
+	if cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	return head, head[len(in):]
+}
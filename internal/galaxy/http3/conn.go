@@ -0,0 +1,538 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Stream directions, as indexes of per-direction state.
+const (
+	dirBidi = 0
+	dirUni  = 1
+)
+
+// connConfig sets up one side of a connection.
+type connConfig struct {
+	client bool
+	tls    *tls.Config
+	// send writes one datagram to the peer.
+	send func([]byte) error
+	// closed runs once the connection is gone.
+	closed func()
+	// Server side only: the destination connection ID of the Initial that
+	// created the connection, the client's first destination connection ID,
+	// the client's source connection ID and the source connection ID of the
+	// Retry sent to the client, if any.
+	initialDCID, origDCID, remoteCID, retrySCID []byte
+	// maxStreamsBidi and maxStreamsUni are how many streams the peer may open.
+	maxStreamsBidi, maxStreamsUni uint64
+}
+
+// pnSpace is the state of one packet number space.
+type pnSpace struct {
+	send, recv *packetKeys
+
+	// Sending.
+	nextPN           uint64
+	largestAcked     int64
+	sent             []*sentPacket
+	lastAckEliciting time.Time
+	lossTime         time.Time
+	probe            int
+	crypto           sendBuffer
+
+	// Receiving.
+	largestRecv     int64
+	largestRecvTime time.Time
+	recvd           rangeSet
+	ackNeeded       bool
+	ackEliciting    int
+	ackDeadline     time.Time
+	cryptoRecv      recvBuffer
+
+	discarded bool
+}
+
+// conn is a QUIC v1 connection. All of its state, and that of its streams,
+// is guarded by mu; a goroutine sends packets and runs the timers.
+type conn struct {
+	cfg connConfig
+	tls *tls.QUICConn
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	wakeCh chan struct{}
+	done   chan struct{}
+
+	localCID      []byte
+	remoteCID     []byte
+	remoteCIDSeq  uint64
+	peerCIDs      map[uint64][]byte
+	retirePriorTo uint64
+	pendingRetire []uint64
+	origDCID      []byte
+	retrySCID     []byte
+	token         []byte
+	retried       bool
+	gotPacket     bool
+	gotInitial    bool
+	pathResponses [][]byte
+
+	spaces   [numSpaces]pnSpace
+	keyPhase bool
+	nextRecv *packetKeys
+
+	handshakeComplete  bool
+	handshakeConfirmed bool
+	peerValidated      bool
+	needHandshakeDone  bool
+	peerParams         transportParams
+	havePeerParams     bool
+
+	rtt          rttStats
+	cc           congestion
+	ptoCount     int
+	pacingAt     time.Time
+	lastRecv     time.Time
+	idleDeadline time.Time
+	idleArmed    bool
+
+	streams         map[uint64]*stream
+	opened          [2]uint64
+	peerOpened      [2]uint64
+	peerMaxStreams  [2]uint64
+	localMaxStreams [2]uint64
+	needMaxStreams  [2]bool
+	accepted        [2][]*stream
+
+	peerMaxData  uint64
+	dataSent     uint64
+	localMaxData uint64
+	dataRecvd    uint64
+	dataConsumed uint64
+	needMaxData  bool
+
+	closing     bool
+	closeApp    bool
+	closeCode   uint64
+	closeReason string
+	closeErr    error
+	err         error
+}
+
+// newConn starts the handshake of one side of a connection.
+func newConn(cfg connConfig) (*conn, error) {
+	c := &conn{
+		cfg:             cfg,
+		wakeCh:          make(chan struct{}, 1),
+		done:            make(chan struct{}),
+		peerCIDs:        make(map[uint64][]byte),
+		streams:         make(map[uint64]*stream),
+		rtt:             newRTTStats(),
+		cc:              newCongestion(),
+		localMaxData:    connWindow,
+		localMaxStreams: [2]uint64{cfg.maxStreamsBidi, cfg.maxStreamsUni},
+	}
+	c.cond = sync.NewCond(&c.mu)
+	for i := range c.spaces {
+		c.spaces[i].largestAcked = -1
+		c.spaces[i].largestRecv = -1
+	}
+	c.localCID = make([]byte, connIDLen)
+	if _, err := rand.Read(c.localCID); err != nil {
+		return nil, err
+	}
+	initialDCID := cfg.initialDCID
+	if cfg.client {
+		c.remoteCID = make([]byte, connIDLen)
+		if _, err := rand.Read(c.remoteCID); err != nil {
+			return nil, err
+		}
+		c.origDCID = c.remoteCID
+		initialDCID = c.remoteCID
+		c.tls = tls.QUICClient(&tls.QUICConfig{TLSConfig: cfg.tls})
+	} else {
+		c.remoteCID = bytes.Clone(cfg.remoteCID)
+		c.origDCID = bytes.Clone(cfg.origDCID)
+		c.retrySCID = bytes.Clone(cfg.retrySCID)
+		c.tls = tls.QUICServer(&tls.QUICConfig{TLSConfig: cfg.tls})
+	}
+	send, recv, err := initialKeys(initialDCID, cfg.client)
+	if err != nil {
+		return nil, err
+	}
+	c.spaces[spaceInitial].send, c.spaces[spaceInitial].recv = send, recv
+	c.tls.SetTransportParameters(c.localParams().marshal())
+	if err := c.tls.Start(context.Background()); err != nil {
+		return nil, fmt.Errorf("%w: %w", errCrypto, err)
+	}
+	if err := c.handleTLSEvents(); err != nil {
+		_ = c.tls.Close()
+		return nil, err
+	}
+	now := time.Now()
+	c.lastRecv = now
+	c.idleDeadline = now.Add(c.idleDuration())
+	go c.run()
+	return c, nil
+}
+
+// localParams are the transport parameters this side sends.
+func (c *conn) localParams() *transportParams {
+	p := &transportParams{
+		initialSCID:    c.localCID,
+		maxIdleTimeout: idleTimeout,
+		maxData:        connWindow,
+		maxStreamBidiL: streamWindow,
+		maxStreamBidiR: streamWindow,
+		maxStreamUni:   streamWindow,
+		maxStreamsBidi: c.cfg.maxStreamsBidi,
+		maxStreamsUni:  c.cfg.maxStreamsUni,
+	}
+	if !c.cfg.client {
+		p.originalDCID, p.hasOriginalDCID = c.origDCID, true
+		p.retrySCID, p.hasRetrySCID = c.retrySCID, c.retrySCID != nil
+	}
+	return p
+}
+
+// run sends packets and fires timers until the connection is gone.
+func (c *conn) run() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		c.mu.Lock()
+		now := time.Now()
+		c.onTimers(now)
+		c.flush(now)
+		next := c.nextTimer(now)
+		err := c.err
+		if err != nil {
+			_ = c.tls.Close()
+		}
+		c.mu.Unlock()
+		if err != nil {
+			if c.cfg.closed != nil {
+				c.cfg.closed()
+			}
+			close(c.done)
+			return
+		}
+		wait := time.Hour
+		if !next.IsZero() {
+			wait = max(next.Sub(now), 0)
+		}
+		timer.Reset(wait)
+		select {
+		case <-c.wakeCh:
+		case <-timer.C:
+		}
+	}
+}
+
+// wake makes the run loop take another pass.
+func (c *conn) wake() {
+	select {
+	case c.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// broadcast wakes every goroutine waiting on the connection.
+func (c *conn) broadcast() {
+	c.mu.Lock()
+	c.cond.Broadcast()
+	c.mu.Unlock()
+}
+
+// receive processes one datagram from the peer.
+func (c *conn) receive(datagram []byte) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.handleDatagram(datagram, time.Now())
+	}
+	c.cond.Broadcast()
+	c.mu.Unlock()
+	c.wake()
+}
+
+// abort ends the connection without telling the peer, e.g. when the
+// socket fails.
+func (c *conn) abort(err error) {
+	c.mu.Lock()
+	c.terminate(err)
+	c.mu.Unlock()
+}
+
+// terminate ends the connection with err; the run loop then exits.
+func (c *conn) terminate(err error) {
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	c.cond.Broadcast()
+	c.wake()
+}
+
+// closeWithError closes the connection because of a local failure,
+// telling the peer the matching transport error code.
+func (c *conn) closeWithError(err error) {
+	if c.err != nil || c.closing {
+		return
+	}
+	c.closing = true
+	c.closeCode = transportCode(err)
+	c.closeErr = err
+	c.wake()
+}
+
+// close closes the connection with an application error code and waits
+// until the close was sent.
+func (c *conn) close(code uint64, reason string) {
+	c.mu.Lock()
+	if c.err == nil && !c.closing {
+		c.closing = true
+		c.closeApp = true
+		c.closeCode = code
+		c.closeReason = reason
+		c.closeErr = errConnClosed
+	}
+	c.mu.Unlock()
+	c.wake()
+	<-c.done
+}
+
+// isClosed reports whether the connection is gone or going.
+func (c *conn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err != nil || c.closing
+}
+
+// transportCode maps an error to the transport error code sent for it.
+func transportCode(err error) uint64 {
+	var alert tls.AlertError
+	switch {
+	case errors.As(err, &alert):
+		return errCodeCryptoBase + uint64(alert)
+	case errors.Is(err, errFrameEncoding):
+		return errCodeFrameEncoding
+	case errors.Is(err, errTransportParams):
+		return errCodeTransportParams
+	case errors.Is(err, errFlowControl):
+		return errCodeFlowControl
+	case errors.Is(err, errStreamState):
+		return errCodeStreamState
+	case errors.Is(err, errStreamLimit):
+		return errCodeStreamLimit
+	case errors.Is(err, errFinalSize):
+		return errCodeFinalSize
+	case errors.Is(err, errConnIDLimit):
+		return errCodeConnIDLimit
+	case errors.Is(err, errCryptoBuffer):
+		return errCodeCryptoBuffer
+	case errors.Is(err, errProtocol):
+		return errCodeProtocolViolation
+	default:
+		return errCodeInternal
+	}
+}
+
+// waitHandshake blocks until the handshake completes.
+func (c *conn) waitHandshake(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, c.broadcast)
+	defer stop()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		switch {
+		case c.err != nil:
+			return c.err
+		case c.handshakeComplete:
+			return nil
+		case ctx.Err() != nil:
+			return context.Cause(ctx)
+		}
+		c.cond.Wait()
+	}
+}
+
+// connectionState returns the TLS state of the connection.
+func (c *conn) connectionState() tls.ConnectionState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tls.ConnectionState()
+}
+
+// levelSpace maps a TLS encryption level to its packet number space.
+func levelSpace(level tls.QUICEncryptionLevel) (int, bool) {
+	switch level {
+	case tls.QUICEncryptionLevelInitial:
+		return spaceInitial, true
+	case tls.QUICEncryptionLevelHandshake:
+		return spaceHandshake, true
+	case tls.QUICEncryptionLevelApplication:
+		return spaceApp, true
+	default:
+		return 0, false
+	}
+}
+
+// spaceLevel maps a packet number space to its TLS encryption level.
+func spaceLevel(space int) tls.QUICEncryptionLevel {
+	switch space {
+	case spaceInitial:
+		return tls.QUICEncryptionLevelInitial
+	case spaceHandshake:
+		return tls.QUICEncryptionLevelHandshake
+	default:
+		return tls.QUICEncryptionLevelApplication
+	}
+}
+
+// handleTLSEvents applies what the TLS handshake produced.
+func (c *conn) handleTLSEvents() error {
+	for {
+		e := c.tls.NextEvent()
+		switch e.Kind {
+		case tls.QUICNoEvent:
+			return nil
+		case tls.QUICSetReadSecret, tls.QUICSetWriteSecret:
+			space, ok := levelSpace(e.Level)
+			if !ok {
+				continue
+			}
+			keys, err := newPacketKeys(e.Suite, bytes.Clone(e.Data))
+			if err != nil {
+				return err
+			}
+			if e.Kind == tls.QUICSetWriteSecret {
+				c.spaces[space].send = keys
+				continue
+			}
+			c.spaces[space].recv = keys
+			if space == spaceApp {
+				if c.nextRecv, err = keys.next(); err != nil {
+					return err
+				}
+			}
+		case tls.QUICWriteData:
+			if space, ok := levelSpace(e.Level); ok {
+				c.spaces[space].crypto.write(e.Data)
+			}
+		case tls.QUICTransportParameters:
+			if err := c.setPeerParams(e.Data); err != nil {
+				return err
+			}
+		case tls.QUICTransportParametersRequired:
+			c.tls.SetTransportParameters(c.localParams().marshal())
+		case tls.QUICHandshakeDone:
+			c.handshakeComplete = true
+			if !c.cfg.client {
+				c.handshakeConfirmed = true
+				c.needHandshakeDone = true
+				c.discardSpace(spaceHandshake)
+			}
+			c.cond.Broadcast()
+		default:
+		}
+	}
+}
+
+// setPeerParams checks and applies the peer's transport parameters.
+func (c *conn) setPeerParams(data []byte) error {
+	p, err := parseTransportParams(data)
+	if err != nil {
+		return err
+	}
+	if c.cfg.client {
+		if !p.hasOriginalDCID || !bytes.Equal(p.originalDCID, c.origDCID) {
+			return fmt.Errorf("%w: original_destination_connection_id mismatch", errTransportParams)
+		}
+		if p.hasRetrySCID != c.retried || c.retried && !bytes.Equal(p.retrySCID, c.retrySCID) {
+			return fmt.Errorf("%w: retry_source_connection_id mismatch", errTransportParams)
+		}
+	} else if p.hasOriginalDCID || p.hasRetrySCID {
+		return fmt.Errorf("%w: server-only parameter from client", errTransportParams)
+	}
+	if !bytes.Equal(p.initialSCID, c.remoteCID) {
+		return fmt.Errorf("%w: initial_source_connection_id mismatch", errTransportParams)
+	}
+	c.peerParams, c.havePeerParams = p, true
+	c.peerMaxData = p.maxData
+	c.peerMaxStreams = [2]uint64{p.maxStreamsBidi, p.maxStreamsUni}
+	return nil
+}
+
+// idleDuration is how long the connection may stay silent.
+func (c *conn) idleDuration() time.Duration {
+	d := idleTimeout
+	if c.havePeerParams && c.peerParams.maxIdleTimeout > 0 {
+		d = min(d, c.peerParams.maxIdleTimeout)
+	}
+	return max(d, 3*c.rtt.pto(0))
+}
+
+// discardSpace drops the keys and in-flight packets of a space.
+func (c *conn) discardSpace(space int) {
+	sp := &c.spaces[space]
+	if sp.discarded {
+		return
+	}
+	for _, p := range sp.sent {
+		c.cc.discard(p)
+	}
+	*sp = pnSpace{discarded: true, largestAcked: -1, largestRecv: -1}
+	c.ptoCount = 0
+}
+
+// onTimers fires the timers that are due.
+func (c *conn) onTimers(now time.Time) {
+	if c.err != nil {
+		return
+	}
+	if !now.Before(c.idleDeadline) {
+		c.terminate(errIdleTimeout)
+		return
+	}
+	for space := range numSpaces {
+		if t := c.spaces[space].lossTime; !t.IsZero() && !now.Before(t) {
+			c.detectLoss(space, now)
+		}
+	}
+	if t, space := c.ptoTime(); !t.IsZero() && !now.Before(t) {
+		c.onPTO(space)
+	}
+}
+
+// nextTimer returns when the run loop must next take a pass.
+func (c *conn) nextTimer(now time.Time) time.Time {
+	next := c.idleDeadline
+	consider := func(t time.Time) {
+		if !t.IsZero() && t.Before(next) {
+			next = t
+		}
+	}
+	for space := range numSpaces {
+		sp := &c.spaces[space]
+		if sp.send == nil {
+			continue
+		}
+		consider(sp.lossTime)
+		if sp.ackNeeded {
+			consider(sp.ackDeadline)
+		}
+	}
+	pto, _ := c.ptoTime()
+	consider(pto)
+	if c.pacingAt.After(now) {
+		consider(c.pacingAt)
+	}
+	return next
+}
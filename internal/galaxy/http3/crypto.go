@@ -0,0 +1,203 @@
+package http3
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"hash"
+)
+
+// QUIC v1 key schedule constants from RFC 9001.
+const (
+	aeadIVSize   = 12
+	aeadTagSize  = 16
+	hpSampleSize = 16
+)
+
+// initialSalt is the QUIC v1 salt the Initial secrets are extracted with.
+func initialSalt() []byte {
+	return []byte{
+		0x38, 0x76, 0x2c, 0xf7, 0xf5, 0x59, 0x34, 0xb3, 0x4d, 0x17,
+		0x9a, 0xe6, 0xa4, 0xc8, 0x0c, 0xad, 0xcc, 0xbb, 0x7f, 0x0a,
+	}
+}
+
+// headerMask derives the 5-byte header protection mask from a sample of
+// the packet's ciphertext.
+type headerMask func(sample []byte) [5]byte
+
+// packetKeys protect the packets of one direction at one encryption level.
+type packetKeys struct {
+	suite  uint16
+	secret []byte
+	aead   cipher.AEAD
+	iv     [aeadIVSize]byte
+	mask   headerMask
+}
+
+// suiteParams returns the hash and key length of a TLS 1.3 cipher suite.
+func suiteParams(suite uint16) (func() hash.Hash, int, error) {
+	switch suite {
+	case tls.TLS_AES_128_GCM_SHA256:
+		return sha256.New, 16, nil
+	case tls.TLS_AES_256_GCM_SHA384:
+		return sha512.New384, 32, nil
+	case tls.TLS_CHACHA20_POLY1305_SHA256:
+		return sha256.New, chachaKeySize, nil
+	default:
+		return nil, 0, fmt.Errorf("%w: cipher suite %#04x", errProtocol, suite)
+	}
+}
+
+// hkdfExpandLabel is HKDF-Expand-Label from TLS 1.3 with an empty context.
+func hkdfExpandLabel(h func() hash.Hash, secret []byte, label string, length int) ([]byte, error) {
+	info := make([]byte, 0, 4+len("tls13 ")+len(label))
+	info = binary.BigEndian.AppendUint16(info, uint16(length)) //nolint:gosec // lengths are key and secret sizes.
+	info = append(info, byte(len("tls13 ")+len(label)))
+	info = append(info, "tls13 "...)
+	info = append(info, label...)
+	info = append(info, 0)
+	return hkdf.Expand(h, secret, string(info), length)
+}
+
+// newPacketKeys derives the packet protection keys of a traffic secret.
+func newPacketKeys(suite uint16, secret []byte) (*packetKeys, error) {
+	h, keyLen, err := suiteParams(suite)
+	if err != nil {
+		return nil, err
+	}
+	hp, err := hkdfExpandLabel(h, secret, "quic hp", keyLen)
+	if err != nil {
+		return nil, err
+	}
+	mask, err := newHeaderMask(suite, hp)
+	if err != nil {
+		return nil, err
+	}
+	return newPacketKeysWithMask(suite, secret, mask)
+}
+
+// newPacketKeysWithMask derives the AEAD key and IV of secret and keeps
+// mask, which a key update does not change.
+func newPacketKeysWithMask(suite uint16, secret []byte, mask headerMask) (*packetKeys, error) {
+	h, keyLen, err := suiteParams(suite)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hkdfExpandLabel(h, secret, "quic key", keyLen)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := hkdfExpandLabel(h, secret, "quic iv", aeadIVSize)
+	if err != nil {
+		return nil, err
+	}
+	k := &packetKeys{suite: suite, secret: secret, mask: mask}
+	copy(k.iv[:], iv)
+	if suite == tls.TLS_CHACHA20_POLY1305_SHA256 {
+		k.aead = newChaCha20Poly1305(key)
+		return k, nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if k.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// next returns the keys of the following key phase.
+func (k *packetKeys) next() (*packetKeys, error) {
+	h, _, err := suiteParams(k.suite)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := hkdfExpandLabel(h, k.secret, "quic ku", h().Size())
+	if err != nil {
+		return nil, err
+	}
+	return newPacketKeysWithMask(k.suite, secret, k.mask)
+}
+
+// newHeaderMask returns the header protection of a suite: AES-ECB for the
+// GCM suites, a ChaCha20 block for ChaCha20-Poly1305.
+func newHeaderMask(suite uint16, key []byte) (headerMask, error) {
+	if suite == tls.TLS_CHACHA20_POLY1305_SHA256 {
+		return func(sample []byte) [5]byte {
+			var out [5]byte
+			chachaXOR(key, binary.LittleEndian.Uint32(sample[0:4]), sample[4:16], out[:], out[:])
+			return out
+		}, nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return func(sample []byte) [5]byte {
+		var full [aes.BlockSize]byte
+		block.Encrypt(full[:], sample)
+		var out [5]byte
+		copy(out[:], full[:5])
+		return out
+	}, nil
+}
+
+// initialKeys derives the Initial keys of a connection from the
+// destination connection ID of the client's first Initial packet.
+// The first keys returned are the ones this side sends with.
+func initialKeys(dcid []byte, client bool) (*packetKeys, *packetKeys, error) {
+	secret, err := hkdf.Extract(sha256.New, dcid, initialSalt())
+	if err != nil {
+		return nil, nil, err
+	}
+	var keys [2]*packetKeys
+	for i, label := range []string{"client in", "server in"} {
+		sideSecret, err := hkdfExpandLabel(sha256.New, secret, label, sha256.Size)
+		if err != nil {
+			return nil, nil, err
+		}
+		if keys[i], err = newPacketKeys(tls.TLS_AES_128_GCM_SHA256, sideSecret); err != nil {
+			return nil, nil, err
+		}
+	}
+	if client {
+		return keys[0], keys[1], nil
+	}
+	return keys[1], keys[0], nil
+}
+
+// nonce is the IV XORed with the packet number.
+func (k *packetKeys) nonce(pn uint64) []byte {
+	nonce := k.iv
+	for i := range 8 {
+		nonce[aeadIVSize-1-i] ^= byte(pn >> (8 * i))
+	}
+	return nonce[:]
+}
+
+// retryIntegrityTag computes the tag of a Retry packet for the client's
+// original destination connection ID (RFC 9001, section 5.8).
+func retryIntegrityTag(odcid, retry []byte) ([]byte, error) {
+	key := []byte{0xbe, 0x0c, 0x69, 0x0b, 0x9f, 0x66, 0x57, 0x5a, 0x1d, 0x76, 0x6b, 0x54, 0xe3, 0x68, 0xc8, 0x4e}
+	nonce := []byte{0x46, 0x15, 0x99, 0xd3, 0x5d, 0x63, 0x2b, 0xf2, 0x23, 0x98, 0x25, 0xbb}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	pseudo := make([]byte, 0, 1+len(odcid)+len(retry))
+	pseudo = append(pseudo, byte(len(odcid)))
+	pseudo = append(pseudo, odcid...)
+	pseudo = append(pseudo, retry...)
+	return aead.Seal(nil, nonce, nil, pseudo), nil
+}
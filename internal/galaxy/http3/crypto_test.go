@@ -0,0 +1,118 @@
+package http3
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("DecodeString: %v", err)
+	}
+	return b
+}
+
+func TestInitialKeysMatchRFC9001(t *testing.T) {
+	t.Parallel()
+	dcid := mustHex(t, "8394c8f03e515708")
+	client, server, err := initialKeys(dcid, true)
+	if err != nil {
+		t.Fatalf("initialKeys: %v", err)
+	}
+	if got := hex.EncodeToString(client.iv[:]); got != "fa044b2f42a3fd3b46fb255c" {
+		t.Fatalf("client iv = %s", got)
+	}
+	if got := hex.EncodeToString(server.iv[:]); got != "0ac1493ca1905853b0bba03e" {
+		t.Fatalf("server iv = %s", got)
+	}
+	// The header protection mask of the client Initial in RFC 9001, A.2.
+	mask := client.mask(mustHex(t, "d1b1c98dd7689fb8ec11d242b123dc9b"))
+	if got := hex.EncodeToString(mask[:]); got != "437b9aec36" {
+		t.Fatalf("client mask = %s", got)
+	}
+	serverSide, _, err := initialKeys(dcid, false)
+	if err != nil {
+		t.Fatalf("initialKeys: %v", err)
+	}
+	if serverSide.iv != server.iv {
+		t.Fatalf("server keys differ by role")
+	}
+}
+
+func TestChaChaPacketMatchesRFC9001(t *testing.T) {
+	t.Parallel()
+	secret := mustHex(t, "9ac312a7f877468ebe69422748ad00a15443f18203a07d6060f688f30f21632b")
+	keys, err := newPacketKeys(tls.TLS_CHACHA20_POLY1305_SHA256, secret)
+	if err != nil {
+		t.Fatalf("newPacketKeys: %v", err)
+	}
+	pkt := mustHex(t, "4cfe4189655e5cd55c41f69080575d7999c25a5bfb")
+	n, truncated := unmaskHeader(pkt, 1, keys.mask)
+	if n != 3 || !bytes.Equal(pkt[:4], mustHex(t, "4200bff4")) {
+		t.Fatalf("unmaskHeader = %d, header %x", n, pkt[:4])
+	}
+	pn := decodePacketNumber(654360563, truncated, n)
+	if pn != 654360564 {
+		t.Fatalf("packet number = %d", pn)
+	}
+	payload, err := keys.aead.Open(nil, keys.nonce(pn), pkt[4:], pkt[:4])
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !bytes.Equal(payload, []byte{0x01}) {
+		t.Fatalf("payload = %x", payload)
+	}
+}
+
+func TestChaChaPoly1305RejectsTampering(t *testing.T) {
+	t.Parallel()
+	aead := newChaCha20Poly1305(bytes.Repeat([]byte{7}, chachaKeySize))
+	nonce := make([]byte, chachaNonceSize)
+	plain := bytes.Repeat([]byte("galaxy"), 50)
+	sealed := aead.Seal(nil, nonce, plain, []byte("header"))
+	got, err := aead.Open(nil, nonce, sealed, []byte("header"))
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("Open: %v", err)
+	}
+	sealed[10] ^= 1
+	if _, err := aead.Open(nil, nonce, sealed, []byte("header")); err == nil {
+		t.Fatalf("Open accepted a corrupted ciphertext")
+	}
+}
+
+func TestRetryIntegrityTagMatchesRFC9001(t *testing.T) {
+	t.Parallel()
+	retry := mustHex(t, "ff000000010008f067a5502a4262b5746f6b656e04a265ba2eff4d829058fb3f0f2496ba")
+	tag, err := retryIntegrityTag(mustHex(t, "8394c8f03e515708"), retry[:len(retry)-aeadTagSize])
+	if err != nil {
+		t.Fatalf("retryIntegrityTag: %v", err)
+	}
+	if !bytes.Equal(tag, retry[len(retry)-aeadTagSize:]) {
+		t.Fatalf("tag = %x", tag)
+	}
+}
+
+func TestKeyUpdateKeepsHeaderMask(t *testing.T) {
+	t.Parallel()
+	secret := mustHex(t, "9ac312a7f877468ebe69422748ad00a15443f18203a07d6060f688f30f21632b")
+	keys, err := newPacketKeys(tls.TLS_CHACHA20_POLY1305_SHA256, secret)
+	if err != nil {
+		t.Fatalf("newPacketKeys: %v", err)
+	}
+	next, err := keys.next()
+	if err != nil {
+		t.Fatalf("next: %v", err)
+	}
+	// The updated secret of RFC 9001, A.5.
+	if got := hex.EncodeToString(next.secret); got != "1223504755036d556342ee9361d253421a826c9ecdf3c7148684b36b714881f9" {
+		t.Fatalf("next secret = %s", got)
+	}
+	sample := bytes.Repeat([]byte{1}, hpSampleSize)
+	if keys.mask(sample) != next.mask(sample) {
+		t.Fatalf("key update changed the header mask")
+	}
+}
@@ -0,0 +1,242 @@
+package http3
+
+import (
+	"fmt"
+	"time"
+)
+
+// QUIC frame types from RFC 9000, section 19.
+const (
+	framePadding            = 0x00
+	framePing               = 0x01
+	frameAck                = 0x02
+	frameAckECN             = 0x03
+	frameResetStream        = 0x04
+	frameStopSending        = 0x05
+	frameCrypto             = 0x06
+	frameNewToken           = 0x07
+	frameStream             = 0x08
+	frameStreamMax          = 0x0f
+	frameMaxData            = 0x10
+	frameMaxStreamData      = 0x11
+	frameMaxStreamsBidi     = 0x12
+	frameMaxStreamsUni      = 0x13
+	frameDataBlocked        = 0x14
+	frameStreamDataBlocked  = 0x15
+	frameStreamsBlockedBidi = 0x16
+	frameStreamsBlockedUni  = 0x17
+	frameNewConnectionID    = 0x18
+	frameRetireConnectionID = 0x19
+	framePathChallenge      = 0x1a
+	framePathResponse       = 0x1b
+	frameConnCloseTransport = 0x1c
+	frameConnCloseApp       = 0x1d
+	frameHandshakeDone      = 0x1e
+
+	// Bits of the STREAM frame type.
+	streamFrameOff = 0x04
+	streamFrameLen = 0x02
+	streamFrameFin = 0x01
+)
+
+// Transport error codes from RFC 9000, section 20.1.
+const (
+	errCodeInternal          = 0x1
+	errCodeFlowControl       = 0x3
+	errCodeStreamLimit       = 0x4
+	errCodeStreamState       = 0x5
+	errCodeFinalSize         = 0x6
+	errCodeFrameEncoding     = 0x7
+	errCodeTransportParams   = 0x8
+	errCodeConnIDLimit       = 0x9
+	errCodeProtocolViolation = 0xa
+	errCodeApplication       = 0xc
+	errCodeCryptoBuffer      = 0xd
+	errCodeCryptoBase        = 0x100
+)
+
+// span is a half-open range [start, end) of offsets or packet numbers.
+type span struct {
+	start, end uint64
+}
+
+// rangeSet is a sorted list of disjoint, non-adjacent spans.
+type rangeSet []span
+
+// add inserts [start, end), merging it with the spans it touches.
+func (s *rangeSet) add(start, end uint64) {
+	if start >= end {
+		return
+	}
+	set := *s
+	if n := len(set); n == 0 || start > set[n-1].end {
+		*s = append(set, span{start, end})
+		return
+	} else if start >= set[n-1].start {
+		set[n-1].end = max(set[n-1].end, end)
+		return
+	}
+	i := 0
+	for i < len(set) && set[i].end < start {
+		i++
+	}
+	j := i
+	for j < len(set) && set[j].start <= end {
+		start = min(start, set[j].start)
+		end = max(end, set[j].end)
+		j++
+	}
+	merged := append(append(append(rangeSet{}, set[:i]...), span{start, end}), set[j:]...)
+	*s = merged
+}
+
+// contains reports whether v is in the set.
+func (s rangeSet) contains(v uint64) bool {
+	for _, r := range s {
+		if v >= r.start && v < r.end {
+			return true
+		}
+	}
+	return false
+}
+
+// removeBelow drops everything below v.
+func (s *rangeSet) removeBelow(v uint64) {
+	set := *s
+	for len(set) > 0 && set[0].end <= v {
+		set = set[1:]
+	}
+	if len(set) > 0 && set[0].start < v {
+		set[0].start = v
+	}
+	*s = set
+}
+
+// min returns the smallest value in the set; the set must not be empty.
+func (s rangeSet) min() uint64 { return s[0].start }
+
+// max returns one past the largest value; the set must not be empty.
+func (s rangeSet) max() uint64 { return s[len(s)-1].end }
+
+// appendAckFrame appends an ACK frame for the packet numbers in recvd,
+// newest ranges first, stopping before the frame outgrows room bytes.
+func appendAckFrame(b []byte, recvd rangeSet, delay time.Duration, room int) []byte {
+	last := recvd[len(recvd)-1]
+	largest := last.end - 1
+	ackDelay := uint64(max(delay.Microseconds(), 0)) >> defaultAckDelayExponent
+	head := appendVarint(nil, frameAck)
+	head = appendVarint(head, largest)
+	head = appendVarint(head, ackDelay)
+	var ranges []byte
+	count := 0
+	smallest := last.start
+	ranges = appendVarint(ranges, last.end-1-last.start)
+	for i := len(recvd) - 2; i >= 0; i-- {
+		r := recvd[i]
+		var next []byte
+		next = appendVarint(next, smallest-r.end-1)
+		next = appendVarint(next, r.end-1-r.start)
+		if len(head)+varintLen(uint64(count+1))+len(ranges)+len(next) > room {
+			break
+		}
+		ranges = append(ranges, next...)
+		smallest = r.start
+		count++
+	}
+	b = append(b, head...)
+	b = appendVarint(b, uint64(count))
+	return append(b, ranges...)
+}
+
+// parseAckFrame reads an ACK frame after its type byte and returns the
+// acknowledged ranges, the ack delay field and its length.
+func parseAckFrame(b []byte, ecn bool) (rangeSet, uint64, int, error) {
+	pos := 0
+	next := func() (uint64, bool) {
+		v, n := consumeVarint(b[pos:])
+		if n < 0 {
+			return 0, false
+		}
+		pos += n
+		return v, true
+	}
+	largest, ok1 := next()
+	delay, ok2 := next()
+	count, ok3 := next()
+	first, ok4 := next()
+	if !ok1 || !ok2 || !ok3 || !ok4 || first > largest {
+		return nil, 0, 0, fmt.Errorf("%w: bad ACK frame", errFrameEncoding)
+	}
+	var acked rangeSet
+	acked.add(largest-first, largest+1)
+	smallest := largest - first
+	for range count {
+		gap, ok1 := next()
+		length, ok2 := next()
+		if !ok1 || !ok2 || smallest < gap+2 || smallest-gap-2 < length {
+			return nil, 0, 0, fmt.Errorf("%w: bad ACK range", errFrameEncoding)
+		}
+		high := smallest - gap - 2
+		acked.add(high-length, high+1)
+		smallest = high - length
+	}
+	if ecn {
+		for range 3 {
+			if _, ok := next(); !ok {
+				return nil, 0, 0, fmt.Errorf("%w: bad ACK ECN counts", errFrameEncoding)
+			}
+		}
+	}
+	return acked, delay, pos, nil
+}
+
+// appendStreamFrame appends a STREAM frame carrying data at off.
+func appendStreamFrame(b []byte, id, off uint64, data []byte, fin bool) []byte {
+	typ := uint64(frameStream | streamFrameLen)
+	if off > 0 {
+		typ |= streamFrameOff
+	}
+	if fin {
+		typ |= streamFrameFin
+	}
+	b = appendVarint(b, typ)
+	b = appendVarint(b, id)
+	if off > 0 {
+		b = appendVarint(b, off)
+	}
+	b = appendVarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+// streamFrameOverhead is the size of a STREAM frame without its data.
+func streamFrameOverhead(id, off uint64, length int) int {
+	n := 1 + varintLen(id) + varintLen(uint64(length))
+	if off > 0 {
+		n += varintLen(off)
+	}
+	return n
+}
+
+// appendCryptoFrame appends a CRYPTO frame carrying data at off.
+func appendCryptoFrame(b []byte, off uint64, data []byte) []byte {
+	b = appendVarint(b, frameCrypto)
+	b = appendVarint(b, off)
+	b = appendVarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+// appendCloseFrame appends a CONNECTION_CLOSE frame. Application closes
+// sent before the handshake completes must use the transport type instead
+// (RFC 9000, section 10.2.3).
+func appendCloseFrame(b []byte, app bool, code uint64, reason string) []byte {
+	if app {
+		b = appendVarint(b, frameConnCloseApp)
+		b = appendVarint(b, code)
+	} else {
+		b = appendVarint(b, frameConnCloseTransport)
+		b = appendVarint(b, code)
+		b = appendVarint(b, 0)
+	}
+	b = appendVarint(b, uint64(len(reason)))
+	return append(b, reason...)
+}
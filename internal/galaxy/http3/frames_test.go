@@ -0,0 +1,78 @@
+package http3
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRangeSetAddMerges(t *testing.T) {
+	t.Parallel()
+	var s rangeSet
+	s.add(10, 20)
+	s.add(30, 40)
+	s.add(0, 5)
+	s.add(5, 6)
+	s.add(50, 50)
+	if want := (rangeSet{{0, 6}, {10, 20}, {30, 40}}); !reflect.DeepEqual(s, want) {
+		t.Fatalf("add = %v, want %v", s, want)
+	}
+	s.add(15, 35)
+	if want := (rangeSet{{0, 6}, {10, 40}}); !reflect.DeepEqual(s, want) {
+		t.Fatalf("add bridging = %v, want %v", s, want)
+	}
+	if !s.contains(39) || s.contains(40) || s.contains(8) {
+		t.Fatalf("contains is wrong for %v", s)
+	}
+	s.removeBelow(12)
+	if want := (rangeSet{{12, 40}}); !reflect.DeepEqual(s, want) {
+		t.Fatalf("removeBelow = %v, want %v", s, want)
+	}
+}
+
+func TestAckFrameRoundTrip(t *testing.T) {
+	t.Parallel()
+	recvd := rangeSet{{0, 3}, {5, 6}, {10, 100}}
+	b := appendAckFrame(nil, recvd, 8*time.Millisecond, 1000)
+	if b[0] != frameAck {
+		t.Fatalf("frame type = %#x", b[0])
+	}
+	acked, delay, n, err := parseAckFrame(b[1:], false)
+	if err != nil {
+		t.Fatalf("parseAckFrame: %v", err)
+	}
+	if n != len(b)-1 || !reflect.DeepEqual(acked, recvd) {
+		t.Fatalf("parseAckFrame = %v (%d bytes), want %v", acked, n, recvd)
+	}
+	if want := uint64(8000 >> defaultAckDelayExponent); delay != want {
+		t.Fatalf("ack delay = %d, want %d", delay, want)
+	}
+}
+
+func TestAckFrameDropsOldRangesToFit(t *testing.T) {
+	t.Parallel()
+	var recvd rangeSet
+	for i := range uint64(100) {
+		recvd.add(i*3, i*3+1)
+	}
+	b := appendAckFrame(nil, recvd, 0, 40)
+	if len(b) > 40 {
+		t.Fatalf("ACK frame has %d bytes, room was 40", len(b))
+	}
+	acked, _, _, err := parseAckFrame(b[1:], false)
+	if err != nil {
+		t.Fatalf("parseAckFrame: %v", err)
+	}
+	if acked.max() != recvd.max() || len(acked) >= len(recvd) {
+		t.Fatalf("want the newest ranges, have %v", acked)
+	}
+}
+
+func TestParseAckFrameRejectsUnderflow(t *testing.T) {
+	t.Parallel()
+	// Largest 2, first range 1, then a gap reaching below zero.
+	b := appendVarint(appendVarint(appendVarint(appendVarint(appendVarint(appendVarint(nil, 2), 0), 1), 1), 5), 0)
+	if _, _, _, err := parseAckFrame(b, false); err == nil {
+		t.Fatalf("parseAckFrame accepted a range below zero")
+	}
+}
@@ -0,0 +1,271 @@
+package http3
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// QUIC v1 wire constants from RFC 9000.
+const (
+	quicVersion1 = 0x00000001
+	// connIDLen is the length of the connection IDs this side issues.
+	connIDLen = 8
+	// maxConnIDLen is the longest connection ID QUIC v1 allows.
+	maxConnIDLen = 20
+	// maxDatagramSize is the largest datagram sent; it is also the size
+	// every datagram carrying an Initial packet is padded to.
+	maxDatagramSize = 1200
+	// maxRecvDatagram bounds the datagrams read from the socket.
+	maxRecvDatagram = 1500
+	// pnLen is the encoded length of every packet number sent.
+	pnLen = 4
+	// lengthFieldLen is the encoded length of the Length field of long headers.
+	lengthFieldLen = 2
+	// maxVarint is the largest value a variable-length integer holds.
+	maxVarint = 1<<62 - 1
+)
+
+// packetType is the type of a packet, by the space its number belongs to.
+type packetType uint8
+
+// Long header packet types in wire order, then short header packets.
+const (
+	packetInitial packetType = iota
+	packetZeroRTT
+	packetHandshake
+	packetRetry
+	packetOneRTT
+)
+
+// Packet number spaces.
+const (
+	spaceInitial = iota
+	spaceHandshake
+	spaceApp
+	numSpaces
+)
+
+// space returns the packet number space of a packet type.
+func (t packetType) space() int {
+	switch t {
+	case packetInitial:
+		return spaceInitial
+	case packetHandshake:
+		return spaceHandshake
+	default:
+		return spaceApp
+	}
+}
+
+// appendVarint appends v as a QUIC variable-length integer.
+func appendVarint(b []byte, v uint64) []byte {
+	switch {
+	case v < 1<<6:
+		return append(b, byte(v))
+	case v < 1<<14:
+		return binary.BigEndian.AppendUint16(b, uint16(v)|0x4000)
+	case v < 1<<30:
+		return binary.BigEndian.AppendUint32(b, uint32(v)|0x80000000)
+	default:
+		return binary.BigEndian.AppendUint64(b, v|0xc000000000000000)
+	}
+}
+
+// varintLen returns the encoded length of v.
+func varintLen(v uint64) int {
+	switch {
+	case v < 1<<6:
+		return 1
+	case v < 1<<14:
+		return 2
+	case v < 1<<30:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// consumeVarint decodes a variable-length integer from the start of b and
+// returns it with its length, or a length of -1 when b is too short.
+func consumeVarint(b []byte) (uint64, int) {
+	if len(b) == 0 {
+		return 0, -1
+	}
+	n := 1 << (b[0] >> 6)
+	if len(b) < n {
+		return 0, -1
+	}
+	v := uint64(b[0] & 0x3f)
+	for _, c := range b[1:n] {
+		v = v<<8 | uint64(c)
+	}
+	return v, n
+}
+
+// packetHeader is the unprotected part of a received packet's header.
+type packetHeader struct {
+	typ     packetType
+	version uint32
+	dcid    []byte
+	scid    []byte
+	token   []byte
+	// pnOffset is where the protected packet number starts.
+	pnOffset int
+	// end is where the packet ends in the datagram.
+	end int
+}
+
+// parseHeader reads the header of the first packet in b. Short header
+// packets carry a destination connection ID of dcidLen bytes.
+func parseHeader(b []byte, dcidLen int) (packetHeader, error) {
+	if len(b) == 0 || b[0]&0x40 == 0 && b[0]&0x80 == 0 {
+		return packetHeader{}, fmt.Errorf("%w: bad packet header", errProtocol)
+	}
+	if b[0]&0x80 == 0 {
+		if len(b) < 1+dcidLen+pnLen+hpSampleSize {
+			return packetHeader{}, fmt.Errorf("%w: short packet too small", errProtocol)
+		}
+		return packetHeader{typ: packetOneRTT, dcid: b[1 : 1+dcidLen], pnOffset: 1 + dcidLen, end: len(b)}, nil
+	}
+	h := packetHeader{typ: packetType(b[0] >> 4 & 0x3)}
+	if len(b) < 6 {
+		return packetHeader{}, fmt.Errorf("%w: long header truncated", errProtocol)
+	}
+	h.version = binary.BigEndian.Uint32(b[1:5])
+	pos := 5
+	var ok bool
+	if h.dcid, pos, ok = consumeConnID(b, pos); !ok {
+		return packetHeader{}, fmt.Errorf("%w: bad destination connection id", errProtocol)
+	}
+	if h.scid, pos, ok = consumeConnID(b, pos); !ok {
+		return packetHeader{}, fmt.Errorf("%w: bad source connection id", errProtocol)
+	}
+	if h.version != quicVersion1 || h.typ == packetRetry {
+		// Version negotiation and Retry packets fill the datagram.
+		h.end = len(b)
+		h.pnOffset = pos
+		return h, nil
+	}
+	if h.typ == packetInitial {
+		tokenLen, n := consumeVarint(b[pos:])
+		if n < 0 || uint64(len(b)-pos-n) < tokenLen {
+			return packetHeader{}, fmt.Errorf("%w: bad token", errProtocol)
+		}
+		pos += n
+		h.token = b[pos : pos+int(tokenLen)]
+		pos += int(tokenLen)
+	}
+	length, n := consumeVarint(b[pos:])
+	if n < 0 || uint64(len(b)-pos-n) < length || length < pnLen+hpSampleSize {
+		return packetHeader{}, fmt.Errorf("%w: bad packet length", errProtocol)
+	}
+	h.pnOffset = pos + n
+	h.end = h.pnOffset + int(length)
+	return h, nil
+}
+
+// consumeConnID reads a length-prefixed connection ID at pos.
+func consumeConnID(b []byte, pos int) ([]byte, int, bool) {
+	if pos >= len(b) {
+		return nil, pos, false
+	}
+	n := int(b[pos])
+	pos++
+	if n > maxConnIDLen || len(b)-pos < n {
+		return nil, pos, false
+	}
+	return b[pos : pos+n], pos + n, true
+}
+
+// unmaskHeader removes header protection from pkt in place and returns the
+// length and truncated value of its packet number.
+func unmaskHeader(pkt []byte, pnOffset int, mask headerMask) (int, uint64) {
+	m := mask(pkt[pnOffset+pnLen : pnOffset+pnLen+hpSampleSize])
+	if pkt[0]&0x80 != 0 {
+		pkt[0] ^= m[0] & 0x0f
+	} else {
+		pkt[0] ^= m[0] & 0x1f
+	}
+	n := int(pkt[0]&0x3) + 1
+	var pn uint64
+	for i := range n {
+		pkt[pnOffset+i] ^= m[1+i]
+		pn = pn<<8 | uint64(pkt[pnOffset+i])
+	}
+	return n, pn
+}
+
+// decodePacketNumber expands a truncated packet number of n bytes to the
+// full number closest to the one after largest (RFC 9000, appendix A.3).
+func decodePacketNumber(largest int64, truncated uint64, n int) uint64 {
+	expected := uint64(largest + 1)
+	win := uint64(1) << (8 * n)
+	hwin := win / 2
+	candidate := expected&^(win-1) | truncated
+	switch {
+	case candidate+hwin <= expected && candidate < 1<<62-win:
+		return candidate + win
+	case candidate > expected+hwin && candidate >= win:
+		return candidate - win
+	default:
+		return candidate
+	}
+}
+
+// appendLongHeader appends a long header for a packet whose payload,
+// without the AEAD tag, is payloadLen bytes, and returns where the packet
+// number starts.
+func appendLongHeader(b []byte, typ packetType, dcid, scid, token []byte, pn uint64, payloadLen, tagLen int) ([]byte, int) {
+	b = append(b, 0xc0|byte(typ)<<4|(pnLen-1))
+	b = binary.BigEndian.AppendUint32(b, quicVersion1)
+	b = append(b, byte(len(dcid)))
+	b = append(b, dcid...)
+	b = append(b, byte(len(scid)))
+	b = append(b, scid...)
+	if typ == packetInitial {
+		b = appendVarint(b, uint64(len(token)))
+		b = append(b, token...)
+	}
+	b = binary.BigEndian.AppendUint16(b, uint16(pnLen+payloadLen+tagLen)|0x4000) //nolint:gosec // packets fit one datagram.
+	pnOffset := len(b)
+	return binary.BigEndian.AppendUint32(b, uint32(pn)), pnOffset //nolint:gosec // packet numbers are sent truncated.
+}
+
+// appendShortHeader appends a 1-RTT header and returns where the packet
+// number starts.
+func appendShortHeader(b []byte, dcid []byte, keyPhase bool, pn uint64) ([]byte, int) {
+	first := byte(0x40 | (pnLen - 1))
+	if keyPhase {
+		first |= 0x04
+	}
+	b = append(b, first)
+	b = append(b, dcid...)
+	pnOffset := len(b)
+	return binary.BigEndian.AppendUint32(b, uint32(pn)), pnOffset //nolint:gosec // packet numbers are sent truncated.
+}
+
+// longHeaderLen is the length of a long header with the given IDs and token.
+func longHeaderLen(typ packetType, dcid, scid, token []byte) int {
+	n := 1 + 4 + 1 + len(dcid) + 1 + len(scid) + lengthFieldLen + pnLen
+	if typ == packetInitial {
+		n += varintLen(uint64(len(token))) + len(token)
+	}
+	return n
+}
+
+// sealPacket encrypts the payload following the header in pkt in place,
+// then applies header protection. payloadStart is where the payload begins.
+func sealPacket(pkt []byte, pnOffset int, pn uint64, keys *packetKeys) []byte {
+	payloadStart := pnOffset + pnLen
+	pkt = keys.aead.Seal(pkt[:payloadStart], keys.nonce(pn), pkt[payloadStart:], pkt[:payloadStart])
+	m := keys.mask(pkt[pnOffset+pnLen : pnOffset+pnLen+hpSampleSize])
+	if pkt[0]&0x80 != 0 {
+		pkt[0] ^= m[0] & 0x0f
+	} else {
+		pkt[0] ^= m[0] & 0x1f
+	}
+	for i := range pnLen {
+		pkt[pnOffset+i] ^= m[1+i]
+	}
+	return pkt
+}
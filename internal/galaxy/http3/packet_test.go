@@ -0,0 +1,108 @@
+package http3
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestVarintRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, v := range []uint64{0, 37, 63, 64, 15293, 16383, 16384, 494878333, 1<<30 - 1, 1 << 30, 151288809941952652, maxVarint} {
+		b := appendVarint(nil, v)
+		if len(b) != varintLen(v) {
+			t.Fatalf("appendVarint(%d) has %d bytes, varintLen says %d", v, len(b), varintLen(v))
+		}
+		got, n := consumeVarint(b)
+		if got != v || n != len(b) {
+			t.Fatalf("consumeVarint(%x) = %d, %d", b, got, n)
+		}
+		if _, n := consumeVarint(b[:len(b)-1]); n != -1 {
+			t.Fatalf("consumeVarint accepted a truncated %d", v)
+		}
+	}
+	// The examples of RFC 9000, appendix A.1.
+	if v, _ := consumeVarint([]byte{0xc2, 0x19, 0x7c, 0x5e, 0xff, 0x14, 0xe8, 0x8c}); v != 151288809941952652 {
+		t.Fatalf("8-byte varint = %d", v)
+	}
+	if v, _ := consumeVarint([]byte{0x7b, 0xbd}); v != 15293 {
+		t.Fatalf("2-byte varint = %d", v)
+	}
+}
+
+func TestDecodePacketNumber(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		largest   int64
+		truncated uint64
+		n         int
+		want      uint64
+	}{
+		{-1, 0, 4, 0},
+		{0, 1, 4, 1},
+		{0, 0xff, 1, 0xff},
+		{0xff, 0x01, 1, 0x101},
+		{0x1000, 0xff, 1, 0xfff},
+		{0xa82f30ea, 0x9b32, 2, 0xa82f9b32},
+	} {
+		if got := decodePacketNumber(tc.largest, tc.truncated, tc.n); got != tc.want {
+			t.Fatalf("decodePacketNumber(%#x, %#x, %d) = %#x, want %#x", tc.largest, tc.truncated, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestSealedPacketsParseBack(t *testing.T) {
+	t.Parallel()
+	dcid := mustHex(t, "8394c8f03e515708")
+	scid := []byte{1, 2, 3, 4}
+	send, _, err := initialKeys(dcid, true)
+	if err != nil {
+		t.Fatalf("initialKeys: %v", err)
+	}
+	_, recv, err := initialKeys(dcid, false)
+	if err != nil {
+		t.Fatalf("initialKeys: %v", err)
+	}
+	payload := append([]byte{framePing}, make([]byte, 40)...)
+	for _, typ := range []packetType{packetInitial, packetHandshake, packetOneRTT} {
+		var pkt []byte
+		var pnOffset int
+		if typ == packetOneRTT {
+			pkt, pnOffset = appendShortHeader(nil, dcid, true, 7)
+		} else {
+			pkt, pnOffset = appendLongHeader(nil, typ, dcid, scid, []byte("token"), 7, len(payload), aeadTagSize)
+			if pnOffset+pnLen != longHeaderLen(typ, dcid, scid, []byte("token")) {
+				t.Fatalf("longHeaderLen(%d) disagrees with appendLongHeader", typ)
+			}
+		}
+		pkt = sealPacket(append(pkt, payload...), pnOffset, 7, send)
+		h, err := parseHeader(pkt, len(dcid))
+		if err != nil {
+			t.Fatalf("parseHeader(%d): %v", typ, err)
+		}
+		if h.typ != typ || !bytes.Equal(h.dcid, dcid) || h.pnOffset != pnOffset || h.end != len(pkt) {
+			t.Fatalf("parseHeader(%d) = %+v", typ, h)
+		}
+		if typ != packetOneRTT && !bytes.Equal(h.scid, scid) {
+			t.Fatalf("parseHeader(%d) scid = %x", typ, h.scid)
+		}
+		n, truncated := unmaskHeader(pkt, pnOffset, recv.mask)
+		if n != pnLen || decodePacketNumber(6, truncated, n) != 7 {
+			t.Fatalf("unmaskHeader(%d) = %d, %d", typ, n, truncated)
+		}
+		got, err := recv.aead.Open(nil, recv.nonce(7), pkt[pnOffset+n:], pkt[:pnOffset+n])
+		if err != nil || !bytes.Equal(got, payload) {
+			t.Fatalf("Open(%d): %v", typ, err)
+		}
+	}
+}
+
+func TestParseHeaderRejectsTruncatedPackets(t *testing.T) {
+	t.Parallel()
+	pkt, _ := appendLongHeader(nil, packetInitial, []byte{1, 2, 3, 4}, []byte{5, 6}, nil, 0, 30, aeadTagSize)
+	pkt = append(pkt, make([]byte, 30+aeadTagSize)...)
+	for i := range len(pkt) - 1 {
+		if _, err := parseHeader(pkt[:i], 4); err == nil {
+			t.Fatalf("parseHeader accepted %d of %d bytes", i, len(pkt))
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package http3
+
+import (
+	"fmt"
+	"time"
+)
+
+// Transport parameter IDs from RFC 9000, section 18.2.
+const (
+	paramOriginalDCID          = 0x00
+	paramMaxIdleTimeout        = 0x01
+	paramStatelessResetToken   = 0x02
+	paramMaxUDPPayloadSize     = 0x03
+	paramInitialMaxData        = 0x04
+	paramInitialMaxStreamBidiL = 0x05
+	paramInitialMaxStreamBidiR = 0x06
+	paramInitialMaxStreamUni   = 0x07
+	paramInitialMaxStreamsBidi = 0x08
+	paramInitialMaxStreamsUni  = 0x09
+	paramAckDelayExponent      = 0x0a
+	paramMaxAckDelay           = 0x0b
+	paramDisableMigration      = 0x0c
+	paramActiveConnIDLimit     = 0x0e
+	paramInitialSCID           = 0x0f
+	paramRetrySCID             = 0x10
+)
+
+// Defaults of parameters a peer leaves out.
+const (
+	defaultAckDelayExponent = 3
+	defaultMaxAckDelay      = 25 * time.Millisecond
+	// minActiveConnIDLimit is the smallest active_connection_id_limit.
+	minActiveConnIDLimit = 2
+)
+
+// transportParams are the QUIC transport parameters of one side.
+type transportParams struct {
+	originalDCID      []byte
+	retrySCID         []byte
+	initialSCID       []byte
+	hasOriginalDCID   bool
+	hasRetrySCID      bool
+	maxIdleTimeout    time.Duration
+	maxUDPPayloadSize uint64
+	maxData           uint64
+	maxStreamBidiL    uint64
+	maxStreamBidiR    uint64
+	maxStreamUni      uint64
+	maxStreamsBidi    uint64
+	maxStreamsUni     uint64
+	ackDelayExponent  uint64
+	maxAckDelay       time.Duration
+	activeConnIDLimit uint64
+}
+
+// appendParam appends one varint-valued parameter.
+func appendParam(b []byte, id, v uint64) []byte {
+	b = appendVarint(b, id)
+	b = appendVarint(b, uint64(varintLen(v)))
+	return appendVarint(b, v)
+}
+
+// appendBytesParam appends one parameter holding bytes.
+func appendBytesParam(b []byte, id uint64, v []byte) []byte {
+	b = appendVarint(b, id)
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// marshal encodes p for the TLS quic_transport_parameters extension.
+func (p *transportParams) marshal() []byte {
+	var b []byte
+	if p.hasOriginalDCID {
+		b = appendBytesParam(b, paramOriginalDCID, p.originalDCID)
+	}
+	if p.hasRetrySCID {
+		b = appendBytesParam(b, paramRetrySCID, p.retrySCID)
+	}
+	b = appendBytesParam(b, paramInitialSCID, p.initialSCID)
+	b = appendParam(b, paramMaxIdleTimeout, uint64(p.maxIdleTimeout.Milliseconds()))
+	b = appendParam(b, paramMaxUDPPayloadSize, maxRecvDatagram)
+	b = appendParam(b, paramInitialMaxData, p.maxData)
+	b = appendParam(b, paramInitialMaxStreamBidiL, p.maxStreamBidiL)
+	b = appendParam(b, paramInitialMaxStreamBidiR, p.maxStreamBidiR)
+	b = appendParam(b, paramInitialMaxStreamUni, p.maxStreamUni)
+	b = appendParam(b, paramInitialMaxStreamsBidi, p.maxStreamsBidi)
+	b = appendParam(b, paramInitialMaxStreamsUni, p.maxStreamsUni)
+	b = appendParam(b, paramActiveConnIDLimit, minActiveConnIDLimit)
+	b = appendVarint(b, paramDisableMigration)
+	return appendVarint(b, 0)
+}
+
+// parseTransportParams decodes the peer's transport parameters.
+func parseTransportParams(b []byte) (transportParams, error) {
+	p := transportParams{
+		maxUDPPayloadSize: 65527,
+		ackDelayExponent:  defaultAckDelayExponent,
+		maxAckDelay:       defaultMaxAckDelay,
+		activeConnIDLimit: minActiveConnIDLimit,
+	}
+	seen := make(map[uint64]bool)
+	for len(b) > 0 {
+		id, n := consumeVarint(b)
+		if n < 0 {
+			return p, fmt.Errorf("%w: truncated transport parameter", errTransportParams)
+		}
+		b = b[n:]
+		length, n := consumeVarint(b)
+		if n < 0 || uint64(len(b)-n) < length {
+			return p, fmt.Errorf("%w: truncated transport parameter %#x", errTransportParams, id)
+		}
+		value := b[n : n+int(length)]
+		b = b[n+int(length):]
+		if seen[id] {
+			return p, fmt.Errorf("%w: duplicate transport parameter %#x", errTransportParams, id)
+		}
+		seen[id] = true
+		if err := p.set(id, value); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+// set stores one received parameter.
+func (p *transportParams) set(id uint64, value []byte) error {
+	switch id {
+	case paramOriginalDCID:
+		p.originalDCID, p.hasOriginalDCID = append([]byte(nil), value...), true
+		return nil
+	case paramRetrySCID:
+		p.retrySCID, p.hasRetrySCID = append([]byte(nil), value...), true
+		return nil
+	case paramInitialSCID:
+		p.initialSCID = append([]byte(nil), value...)
+		return nil
+	case paramStatelessResetToken, paramDisableMigration:
+		return nil
+	}
+	targets := map[uint64]*uint64{
+		paramMaxUDPPayloadSize:     &p.maxUDPPayloadSize,
+		paramInitialMaxData:        &p.maxData,
+		paramInitialMaxStreamBidiL: &p.maxStreamBidiL,
+		paramInitialMaxStreamBidiR: &p.maxStreamBidiR,
+		paramInitialMaxStreamUni:   &p.maxStreamUni,
+		paramInitialMaxStreamsBidi: &p.maxStreamsBidi,
+		paramInitialMaxStreamsUni:  &p.maxStreamsUni,
+		paramAckDelayExponent:      &p.ackDelayExponent,
+		paramActiveConnIDLimit:     &p.activeConnIDLimit,
+	}
+	var v uint64
+	if id == paramMaxIdleTimeout || id == paramMaxAckDelay || targets[id] != nil {
+		var n int
+		if v, n = consumeVarint(value); n < 0 || n != len(value) {
+			return fmt.Errorf("%w: bad value of transport parameter %#x", errTransportParams, id)
+		}
+	}
+	switch {
+	case id == paramMaxIdleTimeout:
+		p.maxIdleTimeout = time.Duration(min(v, 1<<40)) * time.Millisecond //nolint:gosec // capped above.
+	case id == paramMaxAckDelay:
+		if v >= 1<<14 {
+			return fmt.Errorf("%w: max_ack_delay %d", errTransportParams, v)
+		}
+		p.maxAckDelay = time.Duration(v) * time.Millisecond //nolint:gosec // checked above.
+	case targets[id] != nil:
+		*targets[id] = v
+	}
+	if p.ackDelayExponent > 20 || p.maxUDPPayloadSize < maxDatagramSize || p.activeConnIDLimit < minActiveConnIDLimit {
+		return fmt.Errorf("%w: parameter %#x out of range", errTransportParams, id)
+	}
+	return nil
+}
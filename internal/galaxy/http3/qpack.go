@@ -0,0 +1,207 @@
+package http3
+
+import (
+	"fmt"
+	"sync"
+)
+
+// huffmanNode is a node of the Huffman decoding tree; leaves hold a symbol.
+type huffmanNode struct {
+	next [2]*huffmanNode
+	sym  int
+}
+
+// huffmanTree is the decoding tree of huffmanCodes, built on first use.
+//
+//nolint:gochecknoglobals // built once from the fixed code table.
+var huffmanTree = sync.OnceValue(func() *huffmanNode {
+	root := &huffmanNode{sym: -1}
+	for sym, code := range huffmanCodes {
+		n := root
+		for i := int(huffmanCodeLen[sym]) - 1; i >= 0; i-- {
+			bit := code >> i & 1
+			if n.next[bit] == nil {
+				n.next[bit] = &huffmanNode{sym: -1}
+			}
+			n = n.next[bit]
+		}
+		n.sym = sym
+	}
+	return root
+})
+
+// huffmanDecode decodes a Huffman-coded string (RFC 7541, section 5.2).
+func huffmanDecode(b []byte) (string, error) {
+	root := huffmanTree()
+	out := make([]byte, 0, len(b)*8/5)
+	n, depth, ones := root, 0, true
+	for _, c := range b {
+		for i := 7; i >= 0; i-- {
+			bit := c >> i & 1
+			if n = n.next[bit]; n == nil {
+				return "", errHuffman
+			}
+			depth++
+			ones = ones && bit == 1
+			if n.sym >= 0 {
+				out = append(out, byte(n.sym))
+				n, depth, ones = root, 0, true
+			}
+		}
+	}
+	// Only a prefix of the EOS code, at most 7 one bits, may pad the end.
+	if depth > 7 || !ones {
+		return "", errHuffman
+	}
+	return string(out), nil
+}
+
+// appendPrefixInt appends v as an integer with an n-bit prefix, the other
+// bits of the first byte taken from pattern (RFC 7541, section 5.1).
+func appendPrefixInt(b []byte, pattern byte, n uint, v uint64) []byte {
+	limit := uint64(1)<<n - 1
+	if v < limit {
+		return append(b, pattern|byte(v))
+	}
+	b = append(b, pattern|byte(limit))
+	for v -= limit; v >= 0x80; v >>= 7 {
+		b = append(b, byte(v)|0x80)
+	}
+	return append(b, byte(v))
+}
+
+// consumePrefixInt decodes an integer with an n-bit prefix and returns it
+// with its length, or false when b is short or the value overflows.
+func consumePrefixInt(b []byte, n uint) (uint64, int, bool) {
+	if len(b) == 0 {
+		return 0, 0, false
+	}
+	limit := uint64(1)<<n - 1
+	v := uint64(b[0]) & limit
+	if v < limit {
+		return v, 1, true
+	}
+	for i, shift := 1, uint(0); i < len(b) && shift <= 56; i, shift = i+1, shift+7 {
+		v += uint64(b[i]&0x7f) << shift
+		if b[i]&0x80 == 0 {
+			return v, i + 1, true
+		}
+	}
+	return 0, 0, false
+}
+
+// consumeString decodes a string literal whose length has an n-bit prefix
+// and whose Huffman flag is the bit above it.
+func consumeString(b []byte, n uint) (string, int, error) {
+	if len(b) == 0 {
+		return "", 0, errQPACK
+	}
+	huffman := b[0]&(1<<n) != 0
+	length, m, ok := consumePrefixInt(b, n)
+	if !ok || uint64(len(b)-m) < length {
+		return "", 0, errQPACK
+	}
+	raw := b[m : m+int(length)] //nolint:gosec // bounded by len(b).
+	if !huffman {
+		return string(raw), m + len(raw), nil
+	}
+	s, err := huffmanDecode(raw)
+	if err != nil {
+		return "", 0, err
+	}
+	return s, m + len(raw), nil
+}
+
+// qpackEncode encodes a field section that only refers to the static table,
+// so the peer needs no encoder stream state (RFC 9204, section 4.5).
+func qpackEncode(fields []qpackEntry) []byte {
+	// Required Insert Count and Delta Base are both zero.
+	b := []byte{0, 0}
+	for _, f := range fields {
+		nameIndex := -1
+		valueIndex := -1
+		for i, e := range qpackStaticTable {
+			if e.name != f.name {
+				continue
+			}
+			if nameIndex < 0 {
+				nameIndex = i
+			}
+			if e.value == f.value {
+				valueIndex = i
+				break
+			}
+		}
+		switch {
+		case valueIndex >= 0:
+			b = appendPrefixInt(b, 0xc0, 6, uint64(valueIndex))
+		case nameIndex >= 0:
+			b = appendPrefixInt(b, 0x50, 4, uint64(nameIndex))
+			b = appendPrefixInt(b, 0, 7, uint64(len(f.value)))
+			b = append(b, f.value...)
+		default:
+			b = appendPrefixInt(b, 0x20, 3, uint64(len(f.name)))
+			b = append(b, f.name...)
+			b = appendPrefixInt(b, 0, 7, uint64(len(f.value)))
+			b = append(b, f.value...)
+		}
+	}
+	return b
+}
+
+// qpackDecode decodes a field section. Only static table references are
+// accepted: this side allows the peer no dynamic table.
+func qpackDecode(b []byte) ([]qpackEntry, error) {
+	insertCount, n, ok := consumePrefixInt(b, 8)
+	if !ok || insertCount != 0 {
+		return nil, fmt.Errorf("%w: dynamic table reference", errQPACK)
+	}
+	b = b[n:]
+	if _, n, ok = consumePrefixInt(b, 7); !ok {
+		return nil, fmt.Errorf("%w: truncated prefix", errQPACK)
+	}
+	b = b[n:]
+	var fields []qpackEntry
+	for len(b) > 0 {
+		var f qpackEntry
+		var err error
+		switch c := b[0]; {
+		case c&0x80 != 0:
+			// Indexed field line.
+			f, n, err = staticEntry(b, c&0x40 != 0, 6)
+		case c&0x40 != 0:
+			// Literal field line with name reference.
+			f, n, err = staticEntry(b, c&0x10 != 0, 4)
+			if err == nil {
+				var m int
+				f.value, m, err = consumeString(b[n:], 7)
+				n += m
+			}
+		case c&0x20 != 0:
+			// Literal field line with literal name.
+			f.name, n, err = consumeString(b, 3)
+			if err == nil {
+				var m int
+				f.value, m, err = consumeString(b[n:], 7)
+				n += m
+			}
+		default:
+			err = fmt.Errorf("%w: post-base reference", errQPACK)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+		b = b[n:]
+	}
+	return fields, nil
+}
+
+// staticEntry reads a static table index with an n-bit prefix.
+func staticEntry(b []byte, static bool, n uint) (qpackEntry, int, error) {
+	index, m, ok := consumePrefixInt(b, n)
+	if !static || !ok || index >= uint64(len(qpackStaticTable)) {
+		return qpackEntry{}, 0, fmt.Errorf("%w: bad table reference", errQPACK)
+	}
+	return qpackStaticTable[index], m, nil
+}
@@ -0,0 +1,76 @@
+package http3
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHuffmanDecodeMatchesRFC7541(t *testing.T) {
+	t.Parallel()
+	for encoded, want := range map[string]string{
+		"f1e3c2e5f23a6ba0ab90f4ff": "www.example.com",
+		"a8eb10649cbf":             "no-cache",
+		"25a849e95ba97d7f":         "custom-key",
+		"25a849e95bb8e8b4bf":       "custom-value",
+	} {
+		got, err := huffmanDecode(mustHex(t, encoded))
+		if err != nil || got != want {
+			t.Fatalf("huffmanDecode(%s) = %q, %v", encoded, got, err)
+		}
+	}
+	// Padding must be a prefix of EOS, at most 7 bits long.
+	for _, bad := range []string{"f1e3c2e5f23a6ba0ab90f4ffff", "f1e3c2e5f23a6ba0ab90f400"} {
+		if _, err := huffmanDecode(mustHex(t, bad)); !errors.Is(err, errHuffman) {
+			t.Fatalf("huffmanDecode(%s): %v", bad, err)
+		}
+	}
+}
+
+func TestQPACKRoundTrip(t *testing.T) {
+	t.Parallel()
+	fields := []qpackEntry{
+		{":method", "GET"},
+		{":scheme", "https"},
+		{":authority", "galaxy.ansible.com"},
+		{":path", "/api/v3/collections/"},
+		{"accept", "application/json"},
+		{"authorization", "Token secret"},
+		{"x-custom", "value"},
+	}
+	got, err := qpackDecode(qpackEncode(fields))
+	if err != nil {
+		t.Fatalf("qpackDecode: %v", err)
+	}
+	if !reflect.DeepEqual(got, fields) {
+		t.Fatalf("qpackDecode = %v, want %v", got, fields)
+	}
+}
+
+func TestQPACKDecodeHuffmanLiteral(t *testing.T) {
+	t.Parallel()
+	// Literal with name reference to :authority (index 0), Huffman value.
+	block := append([]byte{0, 0, 0x50, 0x80 | 12}, mustHex(t, "f1e3c2e5f23a6ba0ab90f4ff")...)
+	got, err := qpackDecode(block)
+	if err != nil {
+		t.Fatalf("qpackDecode: %v", err)
+	}
+	if want := []qpackEntry{{":authority", "www.example.com"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("qpackDecode = %v", got)
+	}
+}
+
+func TestQPACKDecodeRejectsDynamicTable(t *testing.T) {
+	t.Parallel()
+	for _, block := range [][]byte{
+		{1, 0, 0xc0},       // Required Insert Count above zero.
+		{0, 0, 0x80},       // Indexed field line into the dynamic table.
+		{0, 0, 0x10},       // Post-base index.
+		{0, 0, 0xff, 0x7f}, // Static index past the table.
+		{0, 0, 0x5f, 0x00}, // Literal with a truncated value.
+	} {
+		if _, err := qpackDecode(block); !errors.Is(err, errQPACK) {
+			t.Fatalf("qpackDecode(%x): %v", block, err)
+		}
+	}
+}
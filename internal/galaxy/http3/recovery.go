@@ -0,0 +1,378 @@
+package http3
+
+import (
+	"fmt"
+	"time"
+)
+
+// Loss detection and congestion control constants from RFC 9002.
+const (
+	initialRTT        = 333 * time.Millisecond
+	timerGranularity  = time.Millisecond
+	packetThreshold   = 3
+	initialWindow     = 10 * maxDatagramSize
+	minimumWindow     = 2 * maxDatagramSize
+	maxPTOBackoff     = 6
+	maxBurstDatagrams = 10
+)
+
+// sentFrameKind says what a sent frame did, so its loss can be repaired.
+type sentFrameKind uint8
+
+// Kinds of frames whose loss or acknowledgment matters.
+const (
+	sentCrypto sentFrameKind = iota
+	sentStream
+	sentMaxData
+	sentMaxStreamData
+	sentMaxStreams
+	sentResetStream
+	sentStopSending
+	sentHandshakeDone
+	sentRetireConnID
+)
+
+// sentFrame records one frame of a sent packet.
+type sentFrame struct {
+	kind   sentFrameKind
+	stream *stream
+	offset uint64
+	length uint64
+	fin    bool
+	bidi   bool
+}
+
+// sentPacket is an ack-eliciting packet awaiting acknowledgment.
+type sentPacket struct {
+	pn     uint64
+	time   time.Time
+	size   int
+	frames []sentFrame
+}
+
+// rttStats estimates the round-trip time (RFC 9002, section 5).
+type rttStats struct {
+	latest   time.Duration
+	smoothed time.Duration
+	variance time.Duration
+	minRTT   time.Duration
+	sampled  bool
+}
+
+// newRTTStats returns the estimates before the first sample.
+func newRTTStats() rttStats {
+	return rttStats{smoothed: initialRTT, variance: initialRTT / 2}
+}
+
+// update adds a sample, less the peer's ack delay where that keeps it
+// above the minimum.
+func (r *rttStats) update(sample, ackDelay time.Duration) {
+	r.latest = sample
+	if !r.sampled {
+		r.sampled = true
+		r.minRTT, r.smoothed, r.variance = sample, sample, sample/2
+		return
+	}
+	r.minRTT = min(r.minRTT, sample)
+	adjusted := sample
+	if sample >= r.minRTT+ackDelay {
+		adjusted -= ackDelay
+	}
+	diff := r.smoothed - adjusted
+	if diff < 0 {
+		diff = -diff
+	}
+	r.variance = (3*r.variance + diff) / 4
+	r.smoothed = (7*r.smoothed + adjusted) / 8
+}
+
+// pto is the probe timeout, with maxAckDelay added for the application space.
+func (r *rttStats) pto(maxAckDelay time.Duration) time.Duration {
+	return r.smoothed + max(4*r.variance, timerGranularity) + maxAckDelay
+}
+
+// lossDelay is how long after a later packet was acknowledged an earlier
+// one is declared lost.
+func (r *rttStats) lossDelay() time.Duration {
+	return max(9*max(r.latest, r.smoothed)/8, timerGranularity)
+}
+
+// congestion is a NewReno controller with a token bucket pacer.
+type congestion struct {
+	window        int
+	ssthresh      int
+	inFlight      int
+	recoveryStart time.Time
+	tokens        float64
+	lastRefill    time.Time
+}
+
+// newCongestion returns the controller at its initial window.
+func newCongestion() congestion {
+	return congestion{window: initialWindow, ssthresh: 1 << 62, tokens: maxBurstDatagrams}
+}
+
+// canSend reports whether a packet of size fits the window.
+func (c *congestion) canSend(size int) bool {
+	return c.inFlight+size <= c.window
+}
+
+// onSent accounts for an ack-eliciting packet.
+func (c *congestion) onSent(size int) {
+	c.inFlight += size
+	c.tokens--
+}
+
+// onAcked grows the window for an acknowledged packet sent outside recovery.
+func (c *congestion) onAcked(p *sentPacket) {
+	c.inFlight -= p.size
+	if !p.time.After(c.recoveryStart) {
+		return
+	}
+	if c.window < c.ssthresh {
+		c.window += p.size
+		return
+	}
+	c.window += maxDatagramSize * p.size / c.window
+}
+
+// onLost halves the window once per round trip of losses.
+func (c *congestion) onLost(p *sentPacket, now time.Time) {
+	c.inFlight -= p.size
+	if !p.time.After(c.recoveryStart) {
+		return
+	}
+	c.recoveryStart = now
+	c.ssthresh = max(c.window/2, minimumWindow)
+	c.window = c.ssthresh
+}
+
+// discard forgets packets of a dropped packet number space.
+func (c *congestion) discard(p *sentPacket) {
+	c.inFlight -= p.size
+}
+
+// refill adds pacing tokens at 5/4 of the window per round trip and
+// returns when the next token arrives, or the zero time if one is there.
+func (c *congestion) refill(now time.Time, rtt time.Duration) time.Time {
+	rate := 1.25 * float64(c.window) / float64(maxDatagramSize) / max(rtt.Seconds(), 1e-3)
+	if !c.lastRefill.IsZero() {
+		c.tokens = min(c.tokens+now.Sub(c.lastRefill).Seconds()*rate, maxBurstDatagrams)
+	}
+	c.lastRefill = now
+	if c.tokens >= 1 {
+		return time.Time{}
+	}
+	return now.Add(time.Duration((1 - c.tokens) / rate * float64(time.Second)))
+}
+
+// onAck processes an ACK frame received in space.
+func (c *conn) onAck(space int, acked rangeSet, delay uint64, now time.Time) error {
+	sp := &c.spaces[space]
+	largest := acked.max() - 1
+	if largest >= sp.nextPN {
+		return fmt.Errorf("%w: ACK of unsent packet %d", errProtocol, largest)
+	}
+	var newly []*sentPacket
+	kept := sp.sent[:0]
+	for _, p := range sp.sent {
+		if acked.contains(p.pn) {
+			newly = append(newly, p)
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	clear(sp.sent[len(kept):])
+	sp.sent = kept
+	sp.largestAcked = max(sp.largestAcked, int64(largest)) //nolint:gosec // below nextPN.
+	if len(newly) == 0 {
+		return nil
+	}
+	if last := newly[len(newly)-1]; last.pn == largest {
+		var ackDelay time.Duration
+		if space == spaceApp {
+			exponent := uint64(defaultAckDelayExponent)
+			if c.havePeerParams {
+				exponent = c.peerParams.ackDelayExponent
+			}
+			ackDelay = time.Duration(min(delay, 1<<32)<<exponent) * time.Microsecond //nolint:gosec // capped above.
+			if c.handshakeConfirmed {
+				ackDelay = min(ackDelay, c.peerParams.maxAckDelay)
+			}
+		}
+		c.rtt.update(now.Sub(last.time), ackDelay)
+	}
+	for _, p := range newly {
+		c.cc.onAcked(p)
+		for _, f := range p.frames {
+			c.onFrameAcked(space, f)
+		}
+	}
+	if space == spaceHandshake {
+		c.peerValidated = true
+	}
+	c.detectLoss(space, now)
+	c.ptoCount = 0
+	return nil
+}
+
+// detectLoss declares lost the packets of space sent long enough before
+// the largest acknowledged one (RFC 9002, section 6.1).
+func (c *conn) detectLoss(space int, now time.Time) {
+	sp := &c.spaces[space]
+	sp.lossTime = time.Time{}
+	if sp.largestAcked < 0 {
+		return
+	}
+	delay := c.rtt.lossDelay()
+	lostBefore := now.Add(-delay)
+	var lost []*sentPacket
+	kept := sp.sent[:0]
+	for _, p := range sp.sent {
+		pn := int64(p.pn) //nolint:gosec // packet numbers stay below 2^62.
+		switch {
+		case pn > sp.largestAcked:
+			kept = append(kept, p)
+		case pn+packetThreshold <= sp.largestAcked || !p.time.After(lostBefore):
+			lost = append(lost, p)
+		default:
+			kept = append(kept, p)
+			if t := p.time.Add(delay); sp.lossTime.IsZero() || t.Before(sp.lossTime) {
+				sp.lossTime = t
+			}
+		}
+	}
+	clear(sp.sent[len(kept):])
+	sp.sent = kept
+	for _, p := range lost {
+		c.cc.onLost(p, now)
+		for _, f := range p.frames {
+			c.onFrameLost(space, f)
+		}
+	}
+}
+
+// ptoTime returns when the probe timeout fires and for which space, or
+// the zero time when it is not armed (RFC 9002, appendix A.8).
+func (c *conn) ptoTime() (time.Time, int) {
+	backoff := time.Duration(1) << min(c.ptoCount, maxPTOBackoff)
+	inFlight := false
+	for space := range numSpaces {
+		inFlight = inFlight || len(c.spaces[space].sent) > 0
+	}
+	if !inFlight {
+		// A client keeps probing until the server can send freely, so a
+		// lost server flight cannot deadlock the handshake.
+		if !c.cfg.client || c.peerValidated || c.handshakeConfirmed {
+			return time.Time{}, 0
+		}
+		space := spaceInitial
+		if c.spaces[spaceHandshake].send != nil {
+			space = spaceHandshake
+		}
+		if c.spaces[space].send == nil {
+			return time.Time{}, 0
+		}
+		base := c.lastRecv
+		if t := c.spaces[space].lastAckEliciting; t.After(base) {
+			base = t
+		}
+		return base.Add(c.rtt.pto(0) * backoff), space
+	}
+	var at time.Time
+	var atSpace int
+	for space := range numSpaces {
+		sp := &c.spaces[space]
+		if len(sp.sent) == 0 {
+			continue
+		}
+		d := c.rtt.pto(0)
+		if space == spaceApp {
+			if !c.handshakeConfirmed {
+				continue
+			}
+			d = c.rtt.pto(c.peerParams.maxAckDelay)
+		}
+		if t := sp.lastAckEliciting.Add(d * backoff); at.IsZero() || t.Before(at) {
+			at, atSpace = t, space
+		}
+	}
+	return at, atSpace
+}
+
+// onPTO sends probes in space, repeating the oldest data in flight.
+func (c *conn) onPTO(space int) {
+	c.ptoCount++
+	sp := &c.spaces[space]
+	sp.probe = 2
+	for _, p := range sp.sent[:min(len(sp.sent), 2)] {
+		for _, f := range p.frames {
+			c.onFrameLost(space, f)
+		}
+	}
+}
+
+// live reports whether s is still one of the connection's streams.
+func (c *conn) live(s *stream) bool {
+	return s != nil && c.streams[s.id] == s
+}
+
+// onFrameAcked applies the acknowledgment of a frame sent in space.
+func (c *conn) onFrameAcked(space int, f sentFrame) {
+	switch f.kind {
+	case sentCrypto:
+		c.spaces[space].crypto.onAck(f.offset, f.length, false)
+	case sentStream:
+		if s := f.stream; c.live(s) && !s.resetting() {
+			s.send.onAck(f.offset, f.length, f.fin)
+			c.maybeRemove(s)
+		}
+	case sentResetStream:
+		if s := f.stream; c.live(s) {
+			s.resetAcked = true
+			c.maybeRemove(s)
+		}
+	default:
+	}
+}
+
+// onFrameLost queues what a lost frame carried to be sent again.
+func (c *conn) onFrameLost(space int, f sentFrame) {
+	s := f.stream
+	switch f.kind {
+	case sentCrypto:
+		c.spaces[space].crypto.onLost(f.offset, f.length, false)
+	case sentStream:
+		if c.live(s) && !s.resetting() {
+			s.send.onLost(f.offset, f.length, f.fin)
+		}
+	case sentMaxData:
+		c.needMaxData = true
+	case sentMaxStreamData:
+		if c.live(s) && s.readErr == nil && !s.recv.hasFinal {
+			s.needMaxStreamData = true
+		}
+	case sentMaxStreams:
+		c.needMaxStreams[f.dir()] = true
+	case sentResetStream:
+		if c.live(s) && !s.resetAcked {
+			s.needReset, s.resetSent = true, false
+		}
+	case sentStopSending:
+		if c.live(s) && !s.recv.hasFinal {
+			s.needStopSending = true
+		}
+	case sentHandshakeDone:
+		c.needHandshakeDone = true
+	case sentRetireConnID:
+		c.pendingRetire = append(c.pendingRetire, f.offset)
+	}
+}
+
+// dir is the stream direction a MAX_STREAMS frame was for.
+func (f sentFrame) dir() int {
+	if f.bidi {
+		return dirBidi
+	}
+	return dirUni
+}
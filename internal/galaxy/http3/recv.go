@@ -0,0 +1,507 @@
+package http3
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"time"
+)
+
+// frameReader reads the fields of a received frame.
+type frameReader struct {
+	b   []byte
+	pos int
+	bad bool
+}
+
+// varint reads a variable-length integer.
+func (r *frameReader) varint() uint64 {
+	v, n := consumeVarint(r.b[r.pos:])
+	if n < 0 {
+		r.bad = true
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+// bytes reads n bytes.
+func (r *frameReader) bytes(n uint64) []byte {
+	if r.bad || uint64(len(r.b)-r.pos) < n {
+		r.bad = true
+		return nil
+	}
+	b := r.b[r.pos : r.pos+int(n)] //nolint:gosec // bounded by the frame length.
+	r.pos += int(n)                //nolint:gosec // bounded by the frame length.
+	return b
+}
+
+// handleDatagram processes the packets coalesced in one datagram.
+func (c *conn) handleDatagram(d []byte, now time.Time) {
+	for len(d) > 0 && c.err == nil {
+		h, err := parseHeader(d, len(c.localCID))
+		if err != nil {
+			return
+		}
+		pkt := d[:h.end]
+		d = d[h.end:]
+		if h.typ != packetOneRTT && h.version != quicVersion1 {
+			if h.version == 0 && c.cfg.client && !c.gotPacket {
+				c.terminate(errVersion)
+			}
+			return
+		}
+		if !bytes.Equal(h.dcid, c.localCID) && (c.cfg.client || !bytes.Equal(h.dcid, c.cfg.initialDCID)) {
+			continue
+		}
+		switch h.typ {
+		case packetRetry:
+			c.handleRetry(h, pkt)
+			return
+		case packetZeroRTT:
+			continue
+		default:
+		}
+		if err := c.handlePacket(h, pkt, now); err != nil {
+			c.closeWithError(err)
+			return
+		}
+	}
+}
+
+// handleRetry restarts the handshake with the token and connection ID of a
+// server's Retry packet (RFC 9000, section 17.2.5).
+func (c *conn) handleRetry(h packetHeader, pkt []byte) {
+	if !c.cfg.client || c.retried || c.gotPacket || len(pkt) < h.pnOffset+aeadTagSize+1 {
+		return
+	}
+	body, tag := pkt[:len(pkt)-aeadTagSize], pkt[len(pkt)-aeadTagSize:]
+	want, err := retryIntegrityTag(c.origDCID, body)
+	if err != nil || subtle.ConstantTimeCompare(tag, want) != 1 {
+		return
+	}
+	send, recv, err := initialKeys(h.scid, true)
+	if err != nil {
+		return
+	}
+	c.retried = true
+	c.token = bytes.Clone(body[h.pnOffset:])
+	c.retrySCID = bytes.Clone(h.scid)
+	c.remoteCID = c.retrySCID
+	sp := &c.spaces[spaceInitial]
+	sp.send, sp.recv = send, recv
+	for _, p := range sp.sent {
+		c.cc.discard(p)
+		for _, f := range p.frames {
+			c.onFrameLost(spaceInitial, f)
+		}
+	}
+	sp.sent = nil
+	c.ptoCount = 0
+}
+
+// handlePacket decrypts and processes one packet.
+func (c *conn) handlePacket(h packetHeader, pkt []byte, now time.Time) error {
+	space := h.typ.space()
+	sp := &c.spaces[space]
+	keys := sp.recv
+	if keys == nil {
+		return nil
+	}
+	n, truncated := unmaskHeader(pkt, h.pnOffset, keys.mask)
+	pn := decodePacketNumber(sp.largestRecv, truncated, n)
+	hdrLen := h.pnOffset + n
+	rotate := h.typ == packetOneRTT && (pkt[0]&0x04 != 0) != c.keyPhase
+	if rotate {
+		if !c.handshakeConfirmed {
+			return nil
+		}
+		keys = c.nextRecv
+	}
+	payload, err := keys.aead.Open(pkt[hdrLen:hdrLen], keys.nonce(pn), pkt[hdrLen:], pkt[:hdrLen])
+	if err != nil {
+		return nil
+	}
+	reserved := byte(0x18)
+	if h.typ != packetOneRTT {
+		reserved = 0x0c
+	}
+	if pkt[0]&reserved != 0 || len(payload) == 0 {
+		return fmt.Errorf("%w: bad packet", errProtocol)
+	}
+	if rotate {
+		if err := c.rotateKeys(); err != nil {
+			return err
+		}
+	}
+	if sp.recvd.contains(pn) {
+		return nil
+	}
+	c.gotPacket = true
+	if c.cfg.client && h.typ == packetInitial && !c.gotInitial {
+		c.gotInitial = true
+		c.remoteCID = bytes.Clone(h.scid)
+	}
+	ackEliciting, err := c.handleFrames(space, payload, now)
+	if err != nil {
+		return err
+	}
+	if !c.cfg.client && h.typ == packetHandshake {
+		c.discardSpace(spaceInitial)
+	}
+	sp.recvd.add(pn, pn+1)
+	if len(sp.recvd) > maxAckRanges {
+		sp.recvd = sp.recvd[len(sp.recvd)-maxAckRanges:]
+	}
+	outOfOrder := int64(pn) < sp.largestRecv //nolint:gosec // packet numbers stay below 2^62.
+	if !outOfOrder {
+		sp.largestRecv, sp.largestRecvTime = int64(pn), now //nolint:gosec // packet numbers stay below 2^62.
+	}
+	if ackEliciting {
+		sp.ackEliciting++
+		if !sp.ackNeeded {
+			sp.ackNeeded = true
+			sp.ackDeadline = now
+			if space == spaceApp {
+				sp.ackDeadline = now.Add(defaultMaxAckDelay)
+			}
+		}
+		if outOfOrder || sp.ackEliciting >= ackElicitingThreshold {
+			sp.ackDeadline = now
+		}
+	}
+	c.lastRecv = now
+	c.idleDeadline = now.Add(c.idleDuration())
+	c.idleArmed = false
+	return nil
+}
+
+// rotateKeys switches to the next 1-RTT keys after the peer did.
+func (c *conn) rotateKeys() error {
+	sp := &c.spaces[spaceApp]
+	send, err := sp.send.next()
+	if err != nil {
+		return err
+	}
+	next, err := c.nextRecv.next()
+	if err != nil {
+		return err
+	}
+	sp.send, sp.recv, c.nextRecv = send, c.nextRecv, next
+	c.keyPhase = !c.keyPhase
+	return nil
+}
+
+// handleFrames processes the frames of a packet's payload and reports
+// whether any of them was ack-eliciting.
+func (c *conn) handleFrames(space int, payload []byte, now time.Time) (bool, error) {
+	r := &frameReader{b: payload}
+	ackEliciting := false
+	for r.pos < len(r.b) && c.err == nil {
+		typ := r.varint()
+		if r.bad {
+			return false, fmt.Errorf("%w: frame type", errFrameEncoding)
+		}
+		switch typ {
+		case framePadding, frameAck, frameAckECN, frameConnCloseTransport, frameConnCloseApp:
+		default:
+			ackEliciting = true
+		}
+		if space != spaceApp {
+			switch typ {
+			case framePadding, framePing, frameAck, frameAckECN, frameCrypto, frameConnCloseTransport:
+			default:
+				return false, fmt.Errorf("%w: frame %#x before the handshake is done", errProtocol, typ)
+			}
+		}
+		if err := c.handleFrame(space, typ, r, now); err != nil {
+			return false, err
+		}
+		if r.bad {
+			return false, fmt.Errorf("%w: frame %#x", errFrameEncoding, typ)
+		}
+	}
+	return ackEliciting, nil
+}
+
+// handleFrame processes one frame whose type r has just read.
+func (c *conn) handleFrame(space int, typ uint64, r *frameReader, now time.Time) error {
+	switch {
+	case typ == framePadding:
+		for r.pos < len(r.b) && r.b[r.pos] == 0 {
+			r.pos++
+		}
+	case typ == framePing:
+	case typ == frameAck || typ == frameAckECN:
+		acked, delay, n, err := parseAckFrame(r.b[r.pos:], typ == frameAckECN)
+		if err != nil {
+			return err
+		}
+		r.pos += n
+		return c.onAck(space, acked, delay, now)
+	case typ == frameCrypto:
+		off, length := r.varint(), r.varint()
+		data := r.bytes(length)
+		if r.bad {
+			return nil
+		}
+		return c.handleCrypto(space, off, data)
+	case typ >= frameStream && typ <= frameStreamMax:
+		id := r.varint()
+		var off uint64
+		if typ&streamFrameOff != 0 {
+			off = r.varint()
+		}
+		length := uint64(len(r.b) - r.pos)
+		if typ&streamFrameLen != 0 {
+			length = r.varint()
+		}
+		data := r.bytes(length)
+		if r.bad {
+			return nil
+		}
+		return c.handleStreamData(id, off, data, typ&streamFrameFin != 0)
+	case typ == frameResetStream:
+		id, code, finalSize := r.varint(), r.varint(), r.varint()
+		if r.bad {
+			return nil
+		}
+		return c.handleResetStream(id, code, finalSize)
+	case typ == frameStopSending:
+		id, code := r.varint(), r.varint()
+		if r.bad {
+			return nil
+		}
+		return c.handleStopSending(id, code)
+	case typ == frameMaxData:
+		c.peerMaxData = max(c.peerMaxData, r.varint())
+	case typ == frameMaxStreamData:
+		id, limit := r.varint(), r.varint()
+		if r.bad {
+			return nil
+		}
+		s, err := c.streamFor(id)
+		if err != nil || s == nil {
+			return err
+		}
+		if !s.hasSend {
+			return fmt.Errorf("%w: MAX_STREAM_DATA for receive-only stream %d", errStreamState, id)
+		}
+		s.sendMax = max(s.sendMax, limit)
+	case typ == frameMaxStreamsBidi || typ == frameMaxStreamsUni:
+		limit := r.varint()
+		if limit > 1<<60 {
+			return fmt.Errorf("%w: MAX_STREAMS %d", errFrameEncoding, limit)
+		}
+		dir := dirBidi
+		if typ == frameMaxStreamsUni {
+			dir = dirUni
+		}
+		c.peerMaxStreams[dir] = max(c.peerMaxStreams[dir], limit)
+	case typ == frameDataBlocked || typ == frameStreamsBlockedBidi || typ == frameStreamsBlockedUni:
+		r.varint()
+	case typ == frameStreamDataBlocked:
+		r.varint()
+		r.varint()
+	case typ == frameNewToken:
+		if !c.cfg.client {
+			return fmt.Errorf("%w: NEW_TOKEN from client", errProtocol)
+		}
+		r.bytes(r.varint())
+	case typ == frameNewConnectionID:
+		return c.handleNewConnID(r)
+	case typ == frameRetireConnectionID:
+		if seq := r.varint(); seq > 0 {
+			return fmt.Errorf("%w: retired connection id %d was never issued", errProtocol, seq)
+		}
+	case typ == framePathChallenge:
+		if data := r.bytes(8); !r.bad && len(c.pathResponses) < 4 {
+			c.pathResponses = append(c.pathResponses, bytes.Clone(data))
+		}
+	case typ == framePathResponse:
+		r.bytes(8)
+	case typ == frameConnCloseTransport || typ == frameConnCloseApp:
+		code := r.varint()
+		if typ == frameConnCloseTransport {
+			r.varint()
+		}
+		reason := r.bytes(r.varint())
+		if !r.bad {
+			c.terminate(fmt.Errorf("%w: code %#x: %s", errPeerClosed, code, reason))
+		}
+	case typ == frameHandshakeDone:
+		if !c.cfg.client {
+			return fmt.Errorf("%w: HANDSHAKE_DONE from client", errProtocol)
+		}
+		c.handshakeConfirmed = true
+		c.peerValidated = true
+		c.discardSpace(spaceHandshake)
+	default:
+		return fmt.Errorf("%w: unknown frame type %#x", errFrameEncoding, typ)
+	}
+	return nil
+}
+
+// handleCrypto passes handshake data to TLS in order.
+func (c *conn) handleCrypto(space int, off uint64, data []byte) error {
+	sp := &c.spaces[space]
+	if off+uint64(len(data)) > sp.cryptoRecv.readOff+maxCryptoBuffer {
+		return errCryptoBuffer
+	}
+	sp.cryptoRecv.push(off, data)
+	if len(sp.cryptoRecv.data) == 0 {
+		return nil
+	}
+	buf := make([]byte, len(sp.cryptoRecv.data))
+	sp.cryptoRecv.read(buf)
+	if err := c.tls.HandleData(spaceLevel(space), buf); err != nil {
+		return fmt.Errorf("%w: %w", errCrypto, err)
+	}
+	return c.handleTLSEvents()
+}
+
+// handleStreamData processes a STREAM frame.
+func (c *conn) handleStreamData(id, off uint64, data []byte, fin bool) error {
+	s, err := c.streamFor(id)
+	if err != nil || s == nil {
+		return err
+	}
+	if !s.hasRecv {
+		return fmt.Errorf("%w: STREAM frame for send-only stream %d", errStreamState, id)
+	}
+	end := off + uint64(len(data))
+	if end > maxVarint {
+		return fmt.Errorf("%w: stream offset", errFrameEncoding)
+	}
+	if s.recv.hasFinal && (end > s.recv.finalSize || fin && end != s.recv.finalSize) ||
+		fin && s.recv.highest > end {
+		return fmt.Errorf("%w: stream %d", errFinalSize, id)
+	}
+	if end > s.recvMax {
+		return fmt.Errorf("%w: stream %d", errFlowControl, id)
+	}
+	if err := c.onStreamBytes(s, end); err != nil {
+		return err
+	}
+	if fin {
+		s.recv.finalSize, s.recv.hasFinal = end, true
+	}
+	if s.readErr != nil {
+		s.recv.highest = max(s.recv.highest, end)
+	} else {
+		s.recv.push(off, data)
+	}
+	c.maybeRemove(s)
+	return nil
+}
+
+// onStreamBytes accounts for the connection flow control of data on s up
+// to end; a stream that is no longer read returns the credit right away.
+func (c *conn) onStreamBytes(s *stream, end uint64) error {
+	if end <= s.recv.highest {
+		return nil
+	}
+	n := end - s.recv.highest
+	c.dataRecvd += n
+	if c.dataRecvd > c.localMaxData {
+		return fmt.Errorf("%w: connection", errFlowControl)
+	}
+	if s.readErr != nil {
+		c.creditConn(n)
+	}
+	return nil
+}
+
+// handleResetStream processes a RESET_STREAM frame.
+func (c *conn) handleResetStream(id, code, finalSize uint64) error {
+	s, err := c.streamFor(id)
+	if err != nil || s == nil {
+		return err
+	}
+	if !s.hasRecv {
+		return fmt.Errorf("%w: RESET_STREAM for send-only stream %d", errStreamState, id)
+	}
+	if s.recv.hasFinal && finalSize != s.recv.finalSize || finalSize < s.recv.highest {
+		return fmt.Errorf("%w: stream %d", errFinalSize, id)
+	}
+	if finalSize > s.recvMax {
+		return fmt.Errorf("%w: stream %d", errFlowControl, id)
+	}
+	if err := c.onStreamBytes(s, finalSize); err != nil {
+		return err
+	}
+	s.recv.finalSize, s.recv.hasFinal = finalSize, true
+	s.needMaxStreamData, s.needStopSending = false, false
+	if s.readErr == nil && !s.eofRead {
+		s.readErr = fmt.Errorf("%w: code %#x", errStreamReset, code)
+		c.creditConn(finalSize - s.recv.readOff)
+		s.recv.data, s.recv.pending = nil, nil
+	}
+	s.recv.highest = finalSize
+	c.maybeRemove(s)
+	return nil
+}
+
+// handleStopSending processes a STOP_SENDING frame.
+func (c *conn) handleStopSending(id, code uint64) error {
+	s, err := c.streamFor(id)
+	if err != nil || s == nil {
+		return err
+	}
+	if !s.hasSend {
+		return fmt.Errorf("%w: STOP_SENDING for receive-only stream %d", errStreamState, id)
+	}
+	if s.writeErr == nil {
+		s.writeErr = fmt.Errorf("%w: stopped with code %#x", errStreamReset, code)
+	}
+	c.resetStream(s, code)
+	return nil
+}
+
+// handleNewConnID processes a NEW_CONNECTION_ID frame.
+func (c *conn) handleNewConnID(r *frameReader) error {
+	seq, retirePriorTo := r.varint(), r.varint()
+	length := r.bytes(1)
+	if r.bad {
+		return nil
+	}
+	cid := r.bytes(uint64(length[0]))
+	r.bytes(16)
+	if r.bad || len(cid) == 0 || len(cid) > maxConnIDLen || retirePriorTo > seq {
+		return fmt.Errorf("%w: NEW_CONNECTION_ID", errFrameEncoding)
+	}
+	if seq < c.retirePriorTo {
+		c.pendingRetire = append(c.pendingRetire, seq)
+		return nil
+	}
+	if _, ok := c.peerCIDs[seq]; !ok && seq != c.remoteCIDSeq {
+		c.peerCIDs[seq] = bytes.Clone(cid)
+	}
+	if retirePriorTo > c.retirePriorTo {
+		c.retirePriorTo = retirePriorTo
+		for old := range c.peerCIDs {
+			if old < retirePriorTo {
+				delete(c.peerCIDs, old)
+				c.pendingRetire = append(c.pendingRetire, old)
+			}
+		}
+		if c.remoteCIDSeq < retirePriorTo {
+			c.pendingRetire = append(c.pendingRetire, c.remoteCIDSeq)
+			next, found := uint64(0), false
+			for s := range c.peerCIDs {
+				if !found || s < next {
+					next, found = s, true
+				}
+			}
+			if !found {
+				return fmt.Errorf("%w: every connection id retired", errProtocol)
+			}
+			c.remoteCID, c.remoteCIDSeq = c.peerCIDs[next], next
+			delete(c.peerCIDs, next)
+		}
+	}
+	if len(c.peerCIDs)+1 > minActiveConnIDLimit {
+		return fmt.Errorf("%w: %d active", errConnIDLimit, len(c.peerCIDs)+1)
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package http3
+
+import (
+	"time"
+)
+
+// minPacketRoom is the smallest payload room worth starting a packet for.
+const minPacketRoom = 48
+
+// outPacket is a packet of a datagram being built, before it is sealed.
+type outPacket struct {
+	space        int
+	payload      []byte
+	frames       []sentFrame
+	ackEliciting bool
+}
+
+// flush sends what is pending, or the CONNECTION_CLOSE of a closing
+// connection.
+func (c *conn) flush(now time.Time) {
+	if c.err != nil {
+		return
+	}
+	if c.closing {
+		c.sendClose()
+		c.terminate(c.closeErr)
+		return
+	}
+	for range maxDatagramsPerWake {
+		d := c.buildDatagram(now)
+		if d == nil {
+			return
+		}
+		if err := c.cfg.send(d); err != nil {
+			c.terminate(err)
+			return
+		}
+	}
+	c.wake()
+}
+
+// packetTypeOf returns the type of the packets sent in space.
+func packetTypeOf(space int) packetType {
+	switch space {
+	case spaceInitial:
+		return packetInitial
+	case spaceHandshake:
+		return packetHandshake
+	default:
+		return packetOneRTT
+	}
+}
+
+// headerLen is the length of the header of a packet sent in space.
+func (c *conn) headerLen(space int) int {
+	if space == spaceApp {
+		return 1 + len(c.remoteCID) + pnLen
+	}
+	return longHeaderLen(packetTypeOf(space), c.remoteCID, c.localCID, c.token)
+}
+
+// buildDatagram coalesces the packets of every space that has something
+// to send into one datagram, or returns nil.
+func (c *conn) buildDatagram(now time.Time) []byte {
+	canSend := c.cc.canSend(maxDatagramSize)
+	if canSend {
+		c.pacingAt = c.cc.refill(now, c.rtt.smoothed)
+		canSend = c.pacingAt.IsZero()
+	}
+	var pkts []outPacket
+	size := 0
+	for space := range numSpaces {
+		sp := &c.spaces[space]
+		if sp.send == nil {
+			continue
+		}
+		room := maxDatagramSize - size - c.headerLen(space) - aeadTagSize
+		if room < minPacketRoom {
+			break
+		}
+		pkt, ok := c.buildPayload(space, room, canSend || sp.probe > 0, now)
+		if !ok {
+			continue
+		}
+		pkts = append(pkts, pkt)
+		size += c.headerLen(space) + len(pkt.payload) + aeadTagSize
+	}
+	if len(pkts) == 0 {
+		return nil
+	}
+	// Datagrams carrying Initial packets are padded to prove the path
+	// takes full-sized datagrams (RFC 9000, section 14.1).
+	if pkts[0].space == spaceInitial && size < maxDatagramSize {
+		last := &pkts[len(pkts)-1]
+		last.payload = append(last.payload, make([]byte, maxDatagramSize-size)...)
+	}
+	d := make([]byte, 0, maxDatagramSize)
+	sentHandshake := false
+	for _, pkt := range pkts {
+		d = c.appendPacket(d, pkt.space, pkt.payload)
+		sp := &c.spaces[pkt.space]
+		if pkt.ackEliciting {
+			p := &sentPacket{pn: sp.nextPN - 1, time: now, size: c.headerLen(pkt.space) + len(pkt.payload) + aeadTagSize, frames: pkt.frames}
+			sp.sent = append(sp.sent, p)
+			sp.lastAckEliciting = now
+			sp.probe = max(sp.probe-1, 0)
+			c.cc.onSent(p.size)
+			if !c.idleArmed {
+				c.idleArmed = true
+				c.idleDeadline = now.Add(c.idleDuration())
+			}
+		}
+		sentHandshake = sentHandshake || pkt.space == spaceHandshake
+	}
+	// A client drops its Initial keys once it sends a Handshake packet
+	// (RFC 9001, section 4.9.1).
+	if c.cfg.client && sentHandshake {
+		c.discardSpace(spaceInitial)
+	}
+	return d
+}
+
+// appendPacket appends a sealed packet carrying payload in space.
+func (c *conn) appendPacket(d []byte, space int, payload []byte) []byte {
+	sp := &c.spaces[space]
+	pn := sp.nextPN
+	sp.nextPN++
+	start := len(d)
+	var pnOffset int
+	if space == spaceApp {
+		d, pnOffset = appendShortHeader(d, c.remoteCID, c.keyPhase, pn)
+	} else {
+		d, pnOffset = appendLongHeader(d, packetTypeOf(space), c.remoteCID, c.localCID, c.token, pn, len(payload), aeadTagSize)
+	}
+	d = append(d, payload...)
+	sealed := sealPacket(d[start:], pnOffset-start, pn, sp.send)
+	return append(d[:start], sealed...)
+}
+
+// buildPayload assembles the frames of one packet in space. Only an ACK
+// is sent when ackEliciting frames are not allowed; ok is false when
+// there is nothing to send.
+func (c *conn) buildPayload(space, room int, allowAckEliciting bool, now time.Time) (outPacket, bool) {
+	sp := &c.spaces[space]
+	pkt := outPacket{space: space}
+	var b []byte
+	if sp.ackNeeded && len(sp.recvd) > 0 {
+		b = appendAckFrame(b, sp.recvd, now.Sub(sp.largestRecvTime), room)
+	}
+	ackLen := len(b)
+	if allowAckEliciting {
+		if space == spaceApp {
+			b, pkt.frames = c.appendControlFrames(b, pkt.frames, room)
+		}
+		b, pkt.frames = c.appendCryptoFrames(space, b, pkt.frames, room)
+		if space == spaceApp {
+			b, pkt.frames = c.appendStreamFrames(b, pkt.frames, room)
+		}
+		if sp.probe > 0 && len(b) == ackLen {
+			b = append(b, framePing)
+		}
+	}
+	pkt.ackEliciting = len(b) > ackLen
+	if !pkt.ackEliciting && (ackLen == 0 || now.Before(sp.ackDeadline)) {
+		return pkt, false
+	}
+	if ackLen > 0 {
+		sp.ackNeeded = false
+		sp.ackEliciting = 0
+	}
+	pkt.payload = b
+	return pkt, true
+}
+
+// appendControlFrames appends the flow control, stream state and
+// connection ID frames that are due.
+func (c *conn) appendControlFrames(b []byte, frames []sentFrame, room int) ([]byte, []sentFrame) {
+	const maxControlFrame = 1 + 3*8
+	fits := func() bool { return len(b)+maxControlFrame <= room }
+	if c.needHandshakeDone && fits() {
+		b = append(b, frameHandshakeDone)
+		frames = append(frames, sentFrame{kind: sentHandshakeDone})
+		c.needHandshakeDone = false
+	}
+	for len(c.pathResponses) > 0 && fits() {
+		b = append(append(b, framePathResponse), c.pathResponses[0]...)
+		c.pathResponses = c.pathResponses[1:]
+	}
+	for len(c.pendingRetire) > 0 && fits() {
+		seq := c.pendingRetire[0]
+		c.pendingRetire = c.pendingRetire[1:]
+		b = appendVarint(appendVarint(b, frameRetireConnectionID), seq)
+		frames = append(frames, sentFrame{kind: sentRetireConnID, offset: seq})
+	}
+	if c.needMaxData && fits() {
+		b = appendVarint(appendVarint(b, frameMaxData), c.localMaxData)
+		frames = append(frames, sentFrame{kind: sentMaxData})
+		c.needMaxData = false
+	}
+	for dir, typ := range []uint64{frameMaxStreamsBidi, frameMaxStreamsUni} {
+		if c.needMaxStreams[dir] && fits() {
+			b = appendVarint(appendVarint(b, typ), c.localMaxStreams[dir])
+			frames = append(frames, sentFrame{kind: sentMaxStreams, bidi: dir == dirBidi})
+			c.needMaxStreams[dir] = false
+		}
+	}
+	for _, s := range c.streams {
+		if s.needReset && fits() {
+			b = appendVarint(appendVarint(b, frameResetStream), s.id)
+			b = appendVarint(appendVarint(b, s.resetCode), s.send.next)
+			frames = append(frames, sentFrame{kind: sentResetStream, stream: s})
+			s.needReset, s.resetSent = false, true
+		}
+		if s.needStopSending && fits() {
+			b = appendVarint(appendVarint(b, frameStopSending), s.id)
+			b = appendVarint(b, s.stopCode)
+			frames = append(frames, sentFrame{kind: sentStopSending, stream: s})
+			s.needStopSending = false
+		}
+		if s.needMaxStreamData && fits() {
+			b = appendVarint(appendVarint(b, frameMaxStreamData), s.id)
+			b = appendVarint(b, s.recvMax)
+			frames = append(frames, sentFrame{kind: sentMaxStreamData, stream: s})
+			s.needMaxStreamData = false
+		}
+	}
+	return b, frames
+}
+
+// appendCryptoFrames appends the handshake data pending in space.
+func (c *conn) appendCryptoFrames(space int, b []byte, frames []sentFrame, room int) ([]byte, []sentFrame) {
+	const overhead = 1 + 8 + lengthFieldLen
+	buf := &c.spaces[space].crypto
+	for buf.hasPending(maxVarint) {
+		off, data, _, ok := buf.nextChunk(room-len(b)-overhead, maxVarint)
+		if !ok {
+			break
+		}
+		b = appendCryptoFrame(b, off, data)
+		frames = append(frames, sentFrame{kind: sentCrypto, offset: off, length: uint64(len(data))})
+	}
+	return b, frames
+}
+
+// appendStreamFrames appends stream data as flow control allows.
+func (c *conn) appendStreamFrames(b []byte, frames []sentFrame, room int) ([]byte, []sentFrame) {
+	for _, s := range c.streams {
+		if !s.hasSend || s.resetting() {
+			continue
+		}
+		for {
+			limit := c.sendLimit(s)
+			if !s.send.hasPending(limit) {
+				break
+			}
+			size := room - len(b) - streamFrameOverhead(s.id, maxVarint, room)
+			if size < 1 {
+				return b, frames
+			}
+			next := s.send.next
+			off, data, fin, ok := s.send.nextChunk(size, limit)
+			if !ok {
+				break
+			}
+			c.dataSent += s.send.next - next
+			b = appendStreamFrame(b, s.id, off, data, fin)
+			frames = append(frames, sentFrame{kind: sentStream, stream: s, offset: off, length: uint64(len(data)), fin: fin})
+		}
+		if room-len(b) < minPacketRoom {
+			break
+		}
+	}
+	return b, frames
+}
+
+// sendClose sends a CONNECTION_CLOSE in each space the peer may be reading.
+// An application close is sent as a transport close before the handshake
+// is confirmed (RFC 9000, section 10.2.3).
+func (c *conn) sendClose() {
+	for space := range numSpaces {
+		if c.spaces[space].send == nil || c.handshakeConfirmed && space != spaceApp {
+			continue
+		}
+		app := c.closeApp && space == spaceApp
+		code, reason := c.closeCode, c.closeReason
+		if c.closeApp && !app {
+			code, reason = errCodeApplication, ""
+		}
+		payload := appendCloseFrame(nil, app, code, reason)
+		if space == spaceInitial {
+			payload = append(payload, make([]byte, maxDatagramSize-c.headerLen(space)-aeadTagSize-len(payload))...)
+		}
+		_ = c.cfg.send(c.appendPacket(nil, space, payload))
+	}
+}
@@ -0,0 +1,312 @@
+package http3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer is a minimal HTTP/3 server for the client tests.
+type testServer struct {
+	t       *testing.T
+	pc      net.PacketConn
+	tls     *tls.Config
+	handler http.Handler
+	// retry makes the server answer tokenless Initials with a Retry.
+	retry bool
+	// drop, if set, drops the outgoing datagrams it returns true for.
+	drop func(n int64) bool
+
+	sent     atomic.Int64
+	canceled atomic.Int64
+
+	mu    sync.Mutex
+	conns map[string]*conn
+}
+
+// newTestServer starts a server on a loopback UDP port and returns it with
+// the client TLS configuration that trusts it.
+func newTestServer(t *testing.T, handler http.Handler, configure func(*testServer)) (*testServer, *tls.Config) {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+	cert, pool := testCertificate(t)
+	s := &testServer{
+		t:       t,
+		pc:      pc,
+		tls:     &tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: []string{"h3"}, MinVersion: tls.VersionTLS13},
+		handler: handler,
+		conns:   make(map[string]*conn),
+	}
+	if configure != nil {
+		configure(s)
+	}
+	go s.serve()
+	t.Cleanup(func() {
+		_ = pc.Close()
+		s.mu.Lock()
+		conns := make([]*conn, 0, len(s.conns))
+		for _, c := range s.conns {
+			conns = append(conns, c)
+		}
+		s.mu.Unlock()
+		for _, c := range conns {
+			c.abort(errConnClosed)
+		}
+	})
+	return s, &tls.Config{RootCAs: pool, ServerName: "localhost", MinVersion: tls.VersionTLS13}
+}
+
+// url returns the https URL of path on the server.
+func (s *testServer) url(path string) string {
+	return "https://" + s.pc.LocalAddr().String() + path
+}
+
+// testCertificate issues a self-signed certificate for localhost.
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+// serve reads datagrams and hands them to their connections.
+func (s *testServer) serve() {
+	buf := make([]byte, maxRecvDatagram)
+	for {
+		n, addr, err := s.pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		s.dispatch(bytes.Clone(buf[:n]), addr)
+	}
+}
+
+// dispatch routes one datagram, accepting new connections.
+func (s *testServer) dispatch(d []byte, addr net.Addr) {
+	h, err := parseHeader(d, connIDLen)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	c := s.conns[string(h.dcid)]
+	s.mu.Unlock()
+	if c != nil {
+		c.receive(d)
+		return
+	}
+	if h.typ != packetInitial || len(d) < maxDatagramSize {
+		return
+	}
+	origDCID, retrySCID := h.dcid, []byte(nil)
+	if s.retry {
+		if len(h.token) == 0 {
+			s.sendRetry(h, addr)
+			return
+		}
+		// The token is the client's original destination connection ID.
+		origDCID, retrySCID = h.token, h.dcid
+	}
+	c, err = newConn(connConfig{
+		tls:            s.tls,
+		send:           func(b []byte) error { return s.send(b, addr) },
+		initialDCID:    h.dcid,
+		origDCID:       origDCID,
+		remoteCID:      h.scid,
+		retrySCID:      retrySCID,
+		maxStreamsBidi: 100,
+		maxStreamsUni:  maxPeerUniStreams,
+	})
+	if err != nil {
+		s.t.Errorf("newConn: %v", err)
+		return
+	}
+	s.mu.Lock()
+	s.conns[string(h.dcid)] = c
+	s.conns[string(c.localCID)] = c
+	s.mu.Unlock()
+	go s.serveConn(c)
+	c.receive(d)
+}
+
+// send writes a datagram unless the test drops it.
+func (s *testServer) send(b []byte, addr net.Addr) error {
+	if n := s.sent.Add(1); s.drop != nil && s.drop(n) {
+		return nil
+	}
+	_, err := s.pc.WriteTo(b, addr)
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// sendRetry answers an Initial with a Retry packet whose token is the
+// client's destination connection ID.
+func (s *testServer) sendRetry(h packetHeader, addr net.Addr) {
+	scid := make([]byte, connIDLen)
+	_, _ = rand.Read(scid)
+	pkt := []byte{0xf0}
+	pkt = binary.BigEndian.AppendUint32(pkt, quicVersion1)
+	pkt = append(append(pkt, byte(len(h.scid))), h.scid...)
+	pkt = append(append(pkt, byte(len(scid))), scid...)
+	pkt = append(pkt, h.dcid...)
+	tag, err := retryIntegrityTag(h.dcid, pkt)
+	if err != nil {
+		s.t.Errorf("retryIntegrityTag: %v", err)
+		return
+	}
+	_ = s.send(append(pkt, tag...), addr)
+}
+
+// serveConn serves the requests of one connection.
+func (s *testServer) serveConn(c *conn) {
+	ctx := context.Background()
+	ctrl, err := c.openStream(ctx, false)
+	if err != nil {
+		return
+	}
+	if _, err := ctrl.Write([]byte{h3StreamControl, h3FrameSettings, 0}); err != nil {
+		return
+	}
+	go func() {
+		for {
+			uni, err := c.acceptStream(ctx, false)
+			if err != nil {
+				return
+			}
+			go func() { _, _ = io.Copy(io.Discard, uni) }()
+		}
+	}()
+	for {
+		st, err := c.acceptStream(ctx, true)
+		if err != nil {
+			return
+		}
+		go s.serveRequest(st)
+	}
+}
+
+// serveRequest reads one request, runs the handler and writes the response.
+func (s *testServer) serveRequest(st *stream) {
+	r := bufio.NewReader(st)
+	typ, length, err := readFrameHeader(r)
+	if err != nil || typ != h3FrameHeaders {
+		st.CancelRead(h3FrameUnexpected)
+		return
+	}
+	block := make([]byte, length)
+	if _, err := io.ReadFull(r, block); err != nil {
+		return
+	}
+	fields, err := qpackDecode(block)
+	if err != nil {
+		s.t.Errorf("qpackDecode: %v", err)
+		return
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/", http.NoBody)
+	if err != nil {
+		return
+	}
+	for _, f := range fields {
+		switch f.name {
+		case ":method":
+			req.Method = f.value
+		case ":authority":
+			req.Host = f.value
+		case ":path":
+			req.URL.Path, req.URL.RawQuery, _ = strings.Cut(f.value, "?")
+			req.RequestURI = f.value
+		case ":scheme":
+		default:
+			req.Header.Add(f.name, f.value)
+		}
+	}
+	var body bytes.Buffer
+	for {
+		typ, length, err := readFrameHeader(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			s.canceled.Add(1)
+			return
+		}
+		dst := io.Discard
+		if typ == h3FrameData {
+			dst = &body
+		}
+		if _, err := io.CopyN(dst, r, int64(length)); err != nil { //nolint:gosec // test frames are small.
+			return
+		}
+	}
+	req.Body = io.NopCloser(&body)
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	out := []qpackEntry{{":status", strconv.Itoa(rec.Code)}}
+	for name, values := range rec.Header() {
+		for _, v := range values {
+			out = append(out, qpackEntry{strings.ToLower(name), v})
+		}
+	}
+	block = qpackEncode(out)
+	frame := appendVarint(appendVarint(nil, h3FrameHeaders), uint64(len(block)))
+	if _, err := st.Write(append(frame, block...)); err != nil {
+		return
+	}
+	data := rec.Body.Bytes()
+	for len(data) > 0 {
+		n := min(len(data), 64<<10)
+		frame := appendVarint(appendVarint(nil, h3FrameData), uint64(n))
+		if _, err := st.Write(append(frame, data[:n]...)); err != nil {
+			s.canceled.Add(1)
+			return
+		}
+		data = data[n:]
+	}
+	_ = st.CloseWrite()
+}
@@ -0,0 +1,306 @@
+package http3
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// stream is one QUIC stream. A side the stream does not have, like the
+// receiving side of a locally opened unidirectional stream, counts as done.
+type stream struct {
+	c  *conn
+	id uint64
+
+	hasSend    bool
+	send       sendBuffer
+	sendMax    uint64
+	writeErr   error
+	resetCode  uint64
+	needReset  bool
+	resetSent  bool
+	resetAcked bool
+
+	hasRecv           bool
+	recv              recvBuffer
+	recvMax           uint64
+	needMaxStreamData bool
+	readErr           error
+	eofRead           bool
+	stopCode          uint64
+	needStopSending   bool
+}
+
+// streamDir returns the direction of a stream ID.
+func streamDir(id uint64) int {
+	if id&0x2 != 0 {
+		return dirUni
+	}
+	return dirBidi
+}
+
+// isLocal reports whether this side opened the stream with ID id.
+func (c *conn) isLocal(id uint64) bool {
+	return (id&0x1 == 0) == c.cfg.client
+}
+
+// newStream adds the stream with ID id.
+func (c *conn) newStream(id uint64) *stream {
+	local, uni := c.isLocal(id), streamDir(id) == dirUni
+	s := &stream{c: c, id: id, hasSend: !uni || local, hasRecv: !uni || !local}
+	if s.hasRecv {
+		s.recvMax = streamWindow
+	}
+	if s.hasSend {
+		switch {
+		case uni:
+			s.sendMax = c.peerParams.maxStreamUni
+		case local:
+			s.sendMax = c.peerParams.maxStreamBidiR
+		default:
+			s.sendMax = c.peerParams.maxStreamBidiL
+		}
+	}
+	c.streams[id] = s
+	return s
+}
+
+// streamFor returns the stream a received frame refers to, opening the
+// peer's streams up to it. It returns nil for a stream that is gone.
+func (c *conn) streamFor(id uint64) (*stream, error) {
+	dir := streamDir(id)
+	n := id >> 2
+	if c.isLocal(id) {
+		if n >= c.opened[dir] {
+			return nil, fmt.Errorf("%w: stream %d was not opened", errStreamState, id)
+		}
+		return c.streams[id], nil
+	}
+	if n >= c.localMaxStreams[dir] {
+		return nil, fmt.Errorf("%w: stream %d", errStreamLimit, id)
+	}
+	for c.peerOpened[dir] <= n {
+		s := c.newStream(c.peerOpened[dir]<<2 | id&0x3)
+		c.peerOpened[dir]++
+		c.accepted[dir] = append(c.accepted[dir], s)
+		c.cond.Broadcast()
+	}
+	return c.streams[id], nil
+}
+
+// openStream opens a stream once the handshake is done and the peer
+// allows another one.
+func (c *conn) openStream(ctx context.Context, bidi bool) (*stream, error) {
+	dir := dirUni
+	if bidi {
+		dir = dirBidi
+	}
+	stop := context.AfterFunc(ctx, c.broadcast)
+	defer stop()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		switch {
+		case c.err != nil:
+			return nil, c.err
+		case c.closing:
+			return nil, errConnClosed
+		case ctx.Err() != nil:
+			return nil, context.Cause(ctx)
+		case c.handshakeComplete && c.opened[dir] < c.peerMaxStreams[dir]:
+			id := c.opened[dir]<<2 | uint64(dir)<<1 //nolint:gosec // dir is 0 or 1.
+			if !c.cfg.client {
+				id |= 0x1
+			}
+			c.opened[dir]++
+			return c.newStream(id), nil
+		}
+		c.cond.Wait()
+	}
+}
+
+// acceptStream returns the next stream the peer opened.
+func (c *conn) acceptStream(ctx context.Context, bidi bool) (*stream, error) {
+	dir := dirUni
+	if bidi {
+		dir = dirBidi
+	}
+	stop := context.AfterFunc(ctx, c.broadcast)
+	defer stop()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		switch {
+		case len(c.accepted[dir]) > 0:
+			s := c.accepted[dir][0]
+			c.accepted[dir] = c.accepted[dir][1:]
+			return s, nil
+		case c.err != nil:
+			return nil, c.err
+		case ctx.Err() != nil:
+			return nil, context.Cause(ctx)
+		}
+		c.cond.Wait()
+	}
+}
+
+// resetting reports whether the sending side was abandoned.
+func (s *stream) resetting() bool {
+	return s.needReset || s.resetSent
+}
+
+// sendLimit is the offset the stream may send new data up to, by its own
+// and the connection's flow control.
+func (c *conn) sendLimit(s *stream) uint64 {
+	return min(s.sendMax, s.send.next+c.peerMaxData-c.dataSent)
+}
+
+// maybeRemove forgets a stream both sides of which are done, and lets the
+// peer open another one in its place.
+func (c *conn) maybeRemove(s *stream) {
+	sendDone := !s.hasSend || s.send.done() || s.resetSent && s.resetAcked
+	recvDone := !s.hasRecv || s.eofRead || s.readErr != nil && s.recv.hasFinal
+	if !sendDone || !recvDone || !c.live(s) {
+		return
+	}
+	delete(c.streams, s.id)
+	if !c.isLocal(s.id) {
+		dir := streamDir(s.id)
+		c.localMaxStreams[dir]++
+		c.needMaxStreams[dir] = true
+		c.wake()
+	}
+}
+
+// resetStream abandons the sending side of s with an error code.
+func (c *conn) resetStream(s *stream, code uint64) {
+	if s.resetting() || s.send.done() {
+		return
+	}
+	s.resetCode = code
+	s.needReset = true
+	s.send.lost = nil
+	c.cond.Broadcast()
+	c.wake()
+}
+
+// consumed credits n bytes read from s back to the peer.
+func (c *conn) consumed(s *stream, n uint64) {
+	if !s.recv.hasFinal && s.recvMax-s.recv.readOff < streamWindow/2 {
+		s.recvMax = s.recv.readOff + streamWindow
+		s.needMaxStreamData = true
+		c.wake()
+	}
+	c.creditConn(n)
+}
+
+// creditConn returns n bytes of connection flow control credit.
+func (c *conn) creditConn(n uint64) {
+	c.dataConsumed += n
+	if c.localMaxData-c.dataConsumed < connWindow/2 {
+		c.localMaxData = c.dataConsumed + connWindow
+		c.needMaxData = true
+		c.wake()
+	}
+}
+
+// Read reads data received on the stream.
+func (s *stream) Read(p []byte) (int, error) {
+	c := s.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		switch {
+		case s.readErr != nil:
+			return 0, s.readErr
+		case len(s.recv.data) > 0:
+			n := s.recv.read(p)
+			c.consumed(s, uint64(n))
+			return n, nil
+		case s.recv.eof():
+			s.eofRead = true
+			c.maybeRemove(s)
+			return 0, io.EOF
+		case c.err != nil:
+			return 0, c.err
+		}
+		c.cond.Wait()
+	}
+}
+
+// Write queues p to be sent, blocking while too much data is unacknowledged.
+func (s *stream) Write(p []byte) (int, error) {
+	c := s.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	written := 0
+	for len(p) > 0 {
+		switch {
+		case s.writeErr != nil:
+			return written, s.writeErr
+		case c.err != nil:
+			return written, c.err
+		case s.send.fin:
+			return written, errWriteClosed
+		case len(s.send.data) >= maxStreamBuffer:
+			c.cond.Wait()
+			continue
+		}
+		n := min(len(p), maxStreamBuffer-len(s.send.data))
+		s.send.write(p[:n])
+		p = p[n:]
+		written += n
+		c.wake()
+	}
+	return written, nil
+}
+
+// CloseWrite ends the sending side of the stream once its data is sent.
+func (s *stream) CloseWrite() error {
+	c := s.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s.writeErr != nil {
+		return s.writeErr
+	}
+	if !s.send.fin {
+		s.send.fin = true
+		c.wake()
+	}
+	return nil
+}
+
+// CancelWrite abandons the sending side of the stream.
+func (s *stream) CancelWrite(code uint64) {
+	c := s.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !s.hasSend || s.send.done() {
+		return
+	}
+	if s.writeErr == nil {
+		s.writeErr = errStreamCanceled
+	}
+	c.resetStream(s, code)
+}
+
+// CancelRead abandons the receiving side of the stream and asks the peer
+// to stop sending.
+func (s *stream) CancelRead(code uint64) {
+	c := s.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !s.hasRecv || s.readErr != nil || s.eofRead {
+		return
+	}
+	s.readErr = errStreamCanceled
+	c.creditConn(s.recv.highest - s.recv.readOff)
+	s.recv.data, s.recv.pending = nil, nil
+	if !s.recv.hasFinal {
+		s.stopCode = code
+		s.needStopSending = true
+	}
+	c.maybeRemove(s)
+	c.cond.Broadcast()
+	c.wake()
+}
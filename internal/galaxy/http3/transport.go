@@ -0,0 +1,655 @@
+package http3
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultUserAgent is sent when a request sets no User-Agent, as net/http does.
+const defaultUserAgent = "Go-http-client/3"
+
+// Transport is an http.RoundTripper that sends https requests over HTTP/3,
+// keeping one QUIC connection per host.
+type Transport struct {
+	// TLSClientConfig configures the TLS handshake; nil uses the defaults.
+	TLSClientConfig *tls.Config
+	// HandshakeTimeout bounds the handshake of a new connection; zero
+	// uses a default.
+	HandshakeTimeout time.Duration
+
+	mu    sync.Mutex
+	conns map[string]*clientConn
+	dials map[string]*dialCall
+}
+
+// dialCall is a connection being set up, shared by the requests waiting
+// for it.
+type dialCall struct {
+	done chan struct{}
+	cc   *clientConn
+	err  error
+}
+
+// RoundTrip sends one request and returns its response. The response body
+// must be closed.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" {
+		closeRequestBody(req)
+		return nil, fmt.Errorf("%w: %s", errNotHTTPS, req.URL.Scheme)
+	}
+	addr := authority(req.URL.Host)
+	for attempt := 0; ; attempt++ {
+		cc, err := t.conn(req.Context(), addr)
+		if err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+		resp, err := cc.roundTrip(req)
+		if errors.Is(err, errConnUnusable) && attempt == 0 {
+			// A pooled connection went away before the request was sent.
+			t.forget(addr, cc)
+			continue
+		}
+		return resp, err
+	}
+}
+
+// CloseIdleConnections closes the connections no request is using.
+func (t *Transport) CloseIdleConnections() {
+	t.mu.Lock()
+	var idle []*clientConn
+	for addr, cc := range t.conns {
+		if cc.idle() {
+			idle = append(idle, cc)
+			delete(t.conns, addr)
+		}
+	}
+	t.mu.Unlock()
+	for _, cc := range idle {
+		cc.qc.close(h3NoError, "")
+	}
+}
+
+// authority returns host with the default https port added when it has none.
+func authority(host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), "443")
+}
+
+// conn returns a usable connection to addr, dialing one if needed.
+func (t *Transport) conn(ctx context.Context, addr string) (*clientConn, error) {
+	t.mu.Lock()
+	if cc := t.conns[addr]; cc != nil {
+		if cc.usable() {
+			t.mu.Unlock()
+			return cc, nil
+		}
+		delete(t.conns, addr)
+	}
+	if t.dials == nil {
+		t.dials = make(map[string]*dialCall)
+	}
+	call := t.dials[addr]
+	if call == nil {
+		call = &dialCall{done: make(chan struct{})}
+		t.dials[addr] = call
+		go t.dial(addr, call)
+	}
+	t.mu.Unlock()
+	select {
+	case <-call.done:
+		return call.cc, call.err
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+// dial sets up a connection to addr for every request waiting on call.
+// It is not bound to one request, so a canceled request does not fail the
+// others.
+func (t *Transport) dial(addr string, call *dialCall) {
+	timeout := t.HandshakeTimeout
+	if timeout <= 0 {
+		timeout = defaultHandshakeTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	call.cc, call.err = dialClientConn(ctx, addr, t.tlsConfig(addr))
+	t.mu.Lock()
+	delete(t.dials, addr)
+	if call.err == nil {
+		if t.conns == nil {
+			t.conns = make(map[string]*clientConn)
+		}
+		t.conns[addr] = call.cc
+	}
+	t.mu.Unlock()
+	close(call.done)
+}
+
+// forget drops cc from the pool.
+func (t *Transport) forget(addr string, cc *clientConn) {
+	t.mu.Lock()
+	if t.conns[addr] == cc {
+		delete(t.conns, addr)
+	}
+	t.mu.Unlock()
+}
+
+// tlsConfig returns the TLS configuration of connections to addr.
+func (t *Transport) tlsConfig(addr string) *tls.Config {
+	var cfg *tls.Config
+	if t.TLSClientConfig != nil {
+		cfg = t.TLSClientConfig.Clone()
+	} else {
+		cfg = &tls.Config{} //nolint:gosec // MinVersion is set below.
+	}
+	if cfg.ServerName == "" {
+		host, _, _ := net.SplitHostPort(addr)
+		cfg.ServerName = host
+	}
+	cfg.NextProtos = []string{"h3"}
+	cfg.MinVersion = tls.VersionTLS13
+	return cfg
+}
+
+// closeRequestBody closes the body of a request that will not be sent.
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// clientConn is the HTTP/3 client side of one QUIC connection.
+type clientConn struct {
+	qc *conn
+
+	mu     sync.Mutex
+	goaway bool
+	active int
+}
+
+// dialClientConn connects to addr over UDP and sets up HTTP/3.
+func dialClientConn(ctx context.Context, addr string, tlsConf *tls.Config) (*clientConn, error) {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	udp, ok := nc.(*net.UDPConn)
+	if !ok {
+		_ = nc.Close()
+		return nil, fmt.Errorf("%w: %T", errNotUDP, nc)
+	}
+	qc, err := newConn(connConfig{
+		client: true,
+		tls:    tlsConf,
+		send: func(b []byte) error {
+			_, err := udp.Write(b)
+			return err
+		},
+		closed:        func() { _ = udp.Close() },
+		maxStreamsUni: maxPeerUniStreams,
+	})
+	if err != nil {
+		_ = udp.Close()
+		return nil, err
+	}
+	go readLoop(udp, qc)
+	if err := qc.waitHandshake(ctx); err != nil {
+		qc.close(h3NoError, "")
+		return nil, err
+	}
+	cc := &clientConn{qc: qc}
+	if err := cc.start(ctx); err != nil {
+		qc.close(h3NoError, "")
+		return nil, err
+	}
+	return cc, nil
+}
+
+// readLoop feeds the datagrams of a socket to a connection.
+func readLoop(pc net.Conn, qc *conn) {
+	buf := make([]byte, maxRecvDatagram)
+	for {
+		n, err := pc.Read(buf)
+		if err != nil {
+			qc.abort(err)
+			return
+		}
+		qc.receive(buf[:n])
+	}
+}
+
+// start opens the control stream and serves the peer's unidirectional
+// streams.
+func (cc *clientConn) start(ctx context.Context) error {
+	ctrl, err := cc.qc.openStream(ctx, false)
+	if err != nil {
+		return err
+	}
+	// Stream type, then an empty SETTINGS frame: the defaults allow the
+	// peer no dynamic table and no pushes.
+	if _, err := ctrl.Write([]byte{h3StreamControl, h3FrameSettings, 0}); err != nil {
+		return err
+	}
+	go func() {
+		for {
+			s, err := cc.qc.acceptStream(context.Background(), false)
+			if err != nil {
+				return
+			}
+			go cc.handleUniStream(s)
+		}
+	}()
+	return nil
+}
+
+// handleUniStream reads a unidirectional stream the server opened.
+func (cc *clientConn) handleUniStream(s *stream) {
+	r := bufio.NewReader(s)
+	typ, err := readVarint(r)
+	if err != nil {
+		return
+	}
+	switch typ {
+	case h3StreamControl:
+		err := cc.readControl(r)
+		if errors.Is(err, errH3Protocol) || errors.Is(err, errH3Frame) || errors.Is(err, errMissingSettings) || errors.Is(err, io.EOF) {
+			cc.qc.close(h3Code(err), "")
+		}
+	case h3StreamEncoder, h3StreamDecoder:
+		// Without a dynamic table there is nothing to act on.
+		_, _ = io.Copy(io.Discard, r)
+	default:
+		// Push streams were never allowed, other types are unknown.
+		s.CancelRead(h3StreamCreationError)
+	}
+}
+
+// readControl reads the server's control stream until it ends.
+func (cc *clientConn) readControl(r *bufio.Reader) error {
+	for first := true; ; first = false {
+		typ, length, err := readFrameHeader(r)
+		if err != nil {
+			return err
+		}
+		switch {
+		case first && typ != h3FrameSettings:
+			return fmt.Errorf("%w: control stream starts with frame %#x", errMissingSettings, typ)
+		case !first && typ == h3FrameSettings, typ == h3FrameData, typ == h3FrameHeaders, typ == h3FramePush, reservedFrame(typ):
+			return fmt.Errorf("%w: frame %#x on the control stream", errH3Protocol, typ)
+		case typ == h3FrameGoaway:
+			cc.mu.Lock()
+			cc.goaway = true
+			cc.mu.Unlock()
+		}
+		if _, err := r.Discard(int(min(length, maxHeaderBytes))); err != nil { //nolint:gosec // capped.
+			return err
+		}
+		if length > maxHeaderBytes {
+			return fmt.Errorf("%w: control frame of %d bytes", errH3Frame, length)
+		}
+	}
+}
+
+// h3Code maps an error to the HTTP/3 error code the connection is closed with.
+func h3Code(err error) uint64 {
+	switch {
+	case errors.Is(err, errMissingSettings):
+		return h3MissingSettings
+	case errors.Is(err, io.EOF):
+		return h3ClosedCriticalStream
+	case errors.Is(err, errH3Frame):
+		return h3FrameError
+	case errors.Is(err, errH3Protocol):
+		return h3FrameUnexpected
+	case errors.Is(err, errQPACK), errors.Is(err, errHuffman):
+		return qpackDecompressionFailed
+	default:
+		return h3GeneralProtocolError
+	}
+}
+
+// reservedFrame reports whether typ is an HTTP/2 frame type HTTP/3 reserves.
+func reservedFrame(typ uint64) bool {
+	switch typ {
+	case 0x02, 0x06, 0x08, 0x09:
+		return true
+	default:
+		return false
+	}
+}
+
+// usable reports whether new requests may use the connection.
+func (cc *clientConn) usable() bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return !cc.goaway && !cc.qc.isClosed()
+}
+
+// idle reports whether no request is using the connection.
+func (cc *clientConn) idle() bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.active == 0
+}
+
+// reserve counts a request against the connection if it takes requests.
+func (cc *clientConn) reserve() bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.goaway || cc.qc.isClosed() {
+		return false
+	}
+	cc.active++
+	return true
+}
+
+// release ends a request reserved on the connection.
+func (cc *clientConn) release() {
+	cc.mu.Lock()
+	cc.active--
+	cc.mu.Unlock()
+}
+
+// roundTrip sends req on a new stream and reads the response headers.
+func (cc *clientConn) roundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !cc.reserve() {
+		return nil, errConnUnusable
+	}
+	s, err := cc.qc.openStream(ctx, true)
+	if err != nil {
+		cc.release()
+		if ctx.Err() != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", errConnUnusable, err)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		s.CancelRead(h3RequestCancelled)
+		s.CancelWrite(h3RequestCancelled)
+	})
+	r := bufio.NewReader(s)
+	resp, err := exchange(s, r, req)
+	if err != nil {
+		stop()
+		s.CancelRead(h3RequestCancelled)
+		s.CancelWrite(h3RequestCancelled)
+		cc.release()
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+		if errors.Is(err, errQPACK) || errors.Is(err, errHuffman) {
+			// A field section that cannot be decoded is a connection
+			// error (RFC 9204, section 2.2).
+			cc.qc.close(h3Code(err), "")
+		}
+		return nil, err
+	}
+	state := cc.qc.connectionState()
+	resp.TLS = &state
+	resp.Body = &responseBody{s: s, r: r, ctx: ctx, release: func() {
+		stop()
+		cc.release()
+	}}
+	return resp, nil
+}
+
+// exchange writes req to s and reads the response headers from r.
+func exchange(s *stream, r *bufio.Reader, req *http.Request) (*http.Response, error) {
+	if err := writeRequest(s, req); err != nil {
+		return nil, err
+	}
+	for {
+		typ, length, err := readFrameHeader(r)
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case typ == h3FrameHeaders:
+			if length > maxHeaderBytes {
+				return nil, fmt.Errorf("%w: %d bytes", errHeaderTooLarge, length)
+			}
+			block := make([]byte, length)
+			if _, err := io.ReadFull(r, block); err != nil {
+				return nil, err
+			}
+			fields, err := qpackDecode(block)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := newResponse(fields, req)
+			if err != nil || resp != nil {
+				return resp, err
+			}
+		case typ == h3FrameData || reservedFrame(typ):
+			return nil, fmt.Errorf("%w: frame %#x before the response headers", errH3Protocol, typ)
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(min(length, 1<<62))); err != nil { //nolint:gosec // capped.
+				return nil, err
+			}
+		}
+	}
+}
+
+// writeRequest sends the headers and body of req and ends the stream.
+func writeRequest(s *stream, req *http.Request) error {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	fields := []qpackEntry{
+		{":method", method},
+		{":scheme", "https"},
+		{":authority", host},
+		{":path", req.URL.RequestURI()},
+	}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		switch name {
+		case "connection", "proxy-connection", "keep-alive", "transfer-encoding", "upgrade", "host":
+			continue
+		case "te":
+			values = []string{"trailers"}
+		}
+		for _, v := range values {
+			fields = append(fields, qpackEntry{name, v})
+		}
+	}
+	if _, ok := req.Header["User-Agent"]; !ok {
+		fields = append(fields, qpackEntry{"user-agent", defaultUserAgent})
+	}
+	if req.ContentLength > 0 {
+		fields = append(fields, qpackEntry{"content-length", strconv.FormatInt(req.ContentLength, 10)})
+	}
+	block := qpackEncode(fields)
+	frame := appendVarint(appendVarint(nil, h3FrameHeaders), uint64(len(block)))
+	if _, err := s.Write(append(frame, block...)); err != nil {
+		return err
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		buf := make([]byte, maxDataFrame)
+		for {
+			n, err := req.Body.Read(buf)
+			if n > 0 {
+				frame := appendVarint(appendVarint(nil, h3FrameData), uint64(n))
+				if _, err := s.Write(append(frame, buf[:n]...)); err != nil {
+					return err
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return s.CloseWrite()
+}
+
+// newResponse builds the response of a decoded header section, or returns
+// nil for an interim 1xx response.
+func newResponse(fields []qpackEntry, req *http.Request) (*http.Response, error) {
+	header := make(http.Header)
+	status := ""
+	for _, f := range fields {
+		if strings.HasPrefix(f.name, ":") {
+			if f.name != ":status" || status != "" {
+				return nil, fmt.Errorf("%w: pseudo-header %s", errH3Protocol, f.name)
+			}
+			status = f.value
+			continue
+		}
+		header.Add(http.CanonicalHeaderKey(f.name), f.value)
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil || code < 100 || code > 999 {
+		return nil, fmt.Errorf("%w: %q", errBadStatus, status)
+	}
+	if code < 200 {
+		return nil, nil //nolint:nilnil // interim responses are skipped.
+	}
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/3.0",
+		ProtoMajor:    3,
+		Header:        header,
+		ContentLength: -1,
+		Request:       req,
+	}
+	if n, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil && n >= 0 {
+		resp.ContentLength = n
+	}
+	return resp, nil
+}
+
+// responseBody reads the DATA frames of a response.
+type responseBody struct {
+	s         *stream
+	r         *bufio.Reader
+	ctx       context.Context
+	release   func()
+	once      sync.Once
+	remaining uint64
+	err       error
+}
+
+// Read reads the body, skipping frames other than DATA.
+func (b *responseBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	for b.remaining == 0 {
+		typ, length, err := readFrameHeader(b.r)
+		if errors.Is(err, io.EOF) {
+			b.err = io.EOF
+			b.once.Do(b.release)
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, b.fail(err)
+		}
+		switch {
+		case typ == h3FrameData:
+			b.remaining = length
+		case reservedFrame(typ):
+			return 0, b.fail(fmt.Errorf("%w: frame %#x in the response body", errH3Protocol, typ))
+		default:
+			// Trailers and unknown frames are skipped.
+			if _, err := io.CopyN(io.Discard, b.r, int64(min(length, 1<<62))); err != nil { //nolint:gosec // capped.
+				return 0, b.fail(err)
+			}
+		}
+	}
+	n, err := b.r.Read(p[:min(uint64(len(p)), b.remaining)])
+	b.remaining -= uint64(n) //nolint:gosec // n is not negative.
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return n, b.fail(err)
+	}
+	return n, nil
+}
+
+// fail ends the body with err, or the request context's error if it is done.
+func (b *responseBody) fail(err error) error {
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	if b.ctx.Err() != nil {
+		err = context.Cause(b.ctx)
+	}
+	b.err = err
+	b.s.CancelRead(h3RequestCancelled)
+	b.once.Do(b.release)
+	return err
+}
+
+// Close abandons what is left of the body.
+func (b *responseBody) Close() error {
+	if b.err == nil {
+		b.err = errBodyClosed
+		b.s.CancelRead(h3RequestCancelled)
+	}
+	b.once.Do(b.release)
+	return nil
+}
+
+// readVarint reads a variable-length integer.
+func readVarint(r io.ByteReader) (uint64, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	v := uint64(first & 0x3f)
+	for range 1<<(first>>6) - 1 {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// readFrameHeader reads the type and length of an HTTP/3 frame. It
+// returns io.EOF only when the stream ends between frames.
+func readFrameHeader(r io.ByteReader) (uint64, uint64, error) {
+	typ, err := readVarint(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	length, err := readVarint(r)
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return typ, length, err
+}
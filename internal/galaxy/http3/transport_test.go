@@ -0,0 +1,206 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+	srv, tlsConf := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Agent", r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(r.URL.RequestURI() + " " + string(body)))
+	}), nil)
+	client := &http.Client{Transport: &Transport{TLSClientConfig: tlsConf}}
+	t.Cleanup(client.CloseIdleConnections)
+
+	resp, err := client.Get(srv.url("/api/v3/collections/?page=2"))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.ProtoMajor != 3 || resp.Header.Get("X-Method") != http.MethodGet {
+		t.Fatalf("unexpected response: %d %s %v", resp.StatusCode, resp.Proto, resp.Header)
+	}
+	if string(body) != "/api/v3/collections/?page=2 " || resp.Header.Get("X-Agent") != defaultUserAgent {
+		t.Fatalf("unexpected body %q, agent %q", body, resp.Header.Get("X-Agent"))
+	}
+	if resp.TLS == nil || resp.TLS.NegotiatedProtocol != "h3" {
+		t.Fatalf("unexpected TLS state: %+v", resp.TLS)
+	}
+
+	resp, err = client.Post(srv.url("/upload"), "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "/upload payload" || resp.Header.Get("X-Method") != http.MethodPost {
+		t.Fatalf("unexpected POST response %q", body)
+	}
+}
+
+func TestRoundTripLargeBodyWithLoss(t *testing.T) {
+	t.Parallel()
+	payload := make([]byte, 3<<20)
+	for i := range payload {
+		payload[i] = byte(i * 7)
+	}
+	srv, tlsConf := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(payload)
+	}), func(s *testServer) {
+		// Drop one datagram in nine once the handshake is through.
+		s.drop = func(n int64) bool { return n > 10 && n%9 == 0 }
+	})
+	client := &http.Client{Transport: &Transport{TLSClientConfig: tlsConf}}
+	t.Cleanup(client.CloseIdleConnections)
+	resp, err := client.Get(srv.url("/big.tar.gz"))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer resp.Body.Close()
+	got := sha256.New()
+	if _, err := io.Copy(got, resp.Body); err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	if want := sha256.Sum256(payload); !bytes.Equal(got.Sum(nil), want[:]) {
+		t.Fatalf("body corrupted in transit")
+	}
+}
+
+func TestRoundTripAfterRetry(t *testing.T) {
+	t.Parallel()
+	srv, tlsConf := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}), func(s *testServer) { s.retry = true })
+	client := &http.Client{Transport: &Transport{TLSClientConfig: tlsConf}}
+	t.Cleanup(client.CloseIdleConnections)
+	resp, err := client.Get(srv.url("/"))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "ok" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
+
+func TestRoundTripReusesConnectionAfterCanceledBody(t *testing.T) {
+	t.Parallel()
+	big := bytes.Repeat([]byte("x"), 2<<20)
+	srv, tlsConf := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/big" {
+			_, _ = w.Write(big)
+			return
+		}
+		_, _ = w.Write([]byte("small"))
+	}), nil)
+	tr := &Transport{TLSClientConfig: tlsConf}
+	client := &http.Client{Transport: tr}
+	t.Cleanup(client.CloseIdleConnections)
+
+	resp, err := client.Get(srv.url("/big"))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if _, err := io.ReadFull(resp.Body, make([]byte, 1024)); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	_ = resp.Body.Close()
+	if _, err := resp.Body.Read(make([]byte, 1)); !errors.Is(err, errBodyClosed) {
+		t.Fatalf("Read after Close: %v", err)
+	}
+
+	resp, err = client.Get(srv.url("/small"))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "small" {
+		t.Fatalf("unexpected body %q", body)
+	}
+	tr.mu.Lock()
+	conns := len(tr.conns)
+	tr.mu.Unlock()
+	if conns != 1 {
+		t.Fatalf("want one pooled connection, have %d", conns)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.canceled.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if srv.canceled.Load() == 0 {
+		t.Fatalf("server never saw the canceled response")
+	}
+}
+
+func TestRoundTripContextCanceled(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	srv, tlsConf := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		_, _ = w.Write([]byte("late"))
+	}), nil)
+	t.Cleanup(func() { close(release) })
+	client := &http.Client{Transport: &Transport{TLSClientConfig: tlsConf}}
+	t.Cleanup(client.CloseIdleConnections)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.url("/slow"), http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	start := time.Now()
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Do: %v, want deadline exceeded", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Fatalf("canceled request took %s", time.Since(start))
+	}
+}
+
+func TestRoundTripFailsWithoutServer(t *testing.T) {
+	t.Parallel()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+	addr := pc.LocalAddr().String()
+	_ = pc.Close()
+	tr := &Transport{HandshakeTimeout: 2 * time.Second}
+	req, err := http.NewRequest(http.MethodGet, "https://"+addr+"/", http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	start := time.Now()
+	if _, err := tr.RoundTrip(req); err == nil {
+		t.Fatalf("RoundTrip succeeded without a server")
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Fatalf("failing dial took %s", time.Since(start))
+	}
+	req, err = http.NewRequest(http.MethodGet, "http://"+addr+"/", http.NoBody)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	if _, err := tr.RoundTrip(req); !errors.Is(err, errNotHTTPS) {
+		t.Fatalf("RoundTrip(http): %v", err)
+	}
+}
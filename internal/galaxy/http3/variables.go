@@ -0,0 +1,91 @@
+package http3
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	errOpen            = errors.New("quic: message authentication failed")
+	errProtocol        = errors.New("quic: protocol violation")
+	errTransportParams = errors.New("quic: invalid transport parameters")
+	errFrameEncoding   = errors.New("quic: malformed frame")
+	errFlowControl     = errors.New("quic: flow control limit exceeded")
+	errStreamState     = errors.New("quic: frame for a stream in the wrong state")
+	errStreamLimit     = errors.New("quic: stream limit exceeded")
+	errFinalSize       = errors.New("quic: inconsistent final size")
+	errConnIDLimit     = errors.New("quic: too many connection ids")
+	errCryptoBuffer    = errors.New("quic: handshake data buffered beyond limit")
+	errCrypto          = errors.New("quic: tls handshake failed")
+	errVersion         = errors.New("quic: server does not support QUIC version 1")
+	errConnClosed      = errors.New("quic: connection closed")
+	errPeerClosed      = errors.New("quic: connection closed by peer")
+	errIdleTimeout     = errors.New("quic: idle timeout")
+	errStreamReset     = errors.New("quic: stream reset by peer")
+	errStreamCanceled  = errors.New("quic: stream canceled")
+	errWriteClosed     = errors.New("quic: write on closed stream")
+	errH3Protocol      = errors.New("http3: protocol error")
+	errH3Frame         = errors.New("http3: malformed frame")
+	errQPACK           = errors.New("http3: qpack decoding failed")
+	errHuffman         = errors.New("http3: invalid huffman string")
+	errHeaderTooLarge  = errors.New("http3: header section too large")
+	errBadStatus       = errors.New("http3: bad response status")
+	errMissingSettings = errors.New("http3: control stream does not start with SETTINGS")
+	errConnUnusable    = errors.New("http3: connection takes no new requests")
+	errBodyClosed      = errors.New("http3: read on closed response body")
+	errNotHTTPS        = errors.New("http3: unsupported protocol scheme")
+	errNotUDP          = errors.New("http3: dialed connection is not UDP")
+)
+
+// Connection tuning.
+const (
+	// idleTimeout is the max_idle_timeout this side advertises.
+	idleTimeout = 30 * time.Second
+	// streamWindow is the receive window of one stream.
+	streamWindow = 4 << 20
+	// connWindow is the receive window of the connection.
+	connWindow = 16 << 20
+	// maxStreamBuffer bounds the unacknowledged data written to one stream.
+	maxStreamBuffer = 1 << 20
+	// maxCryptoBuffer bounds handshake data received ahead of a gap.
+	maxCryptoBuffer = 64 << 10
+	// maxAckRanges bounds the received packet ranges remembered per space.
+	maxAckRanges = 32
+	// ackElicitingThreshold is how many ack-eliciting packets are received
+	// before an ACK is sent without waiting for the ack delay.
+	ackElicitingThreshold = 2
+	// maxDatagramsPerWake bounds the datagrams sent in one pass of the loop.
+	maxDatagramsPerWake = 64
+	// defaultHandshakeTimeout bounds the QUIC handshake of a new connection.
+	defaultHandshakeTimeout = 5 * time.Second
+	// maxHeaderBytes bounds the encoded header section of a response.
+	maxHeaderBytes = 1 << 20
+	// maxDataFrame is the largest DATA frame a request body is sent in.
+	maxDataFrame = 32 << 10
+	// maxPeerUniStreams is how many unidirectional streams a server may
+	// open: its control and QPACK streams, with room to spare.
+	maxPeerUniStreams = 16
+)
+
+// HTTP/3 frame and stream types (RFC 9114) and error codes.
+const (
+	h3FrameData     = 0x00
+	h3FrameHeaders  = 0x01
+	h3FrameSettings = 0x04
+	h3FramePush     = 0x05
+	h3FrameGoaway   = 0x07
+
+	h3StreamControl = 0x00
+	h3StreamEncoder = 0x02
+	h3StreamDecoder = 0x03
+
+	h3NoError                = 0x100
+	h3GeneralProtocolError   = 0x101
+	h3StreamCreationError    = 0x103
+	h3ClosedCriticalStream   = 0x104
+	h3FrameUnexpected        = 0x105
+	h3FrameError             = 0x106
+	h3RequestCancelled       = 0x10c
+	h3MissingSettings        = 0x10a
+	qpackDecompressionFailed = 0x200
+)
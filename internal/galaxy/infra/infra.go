@@ -1,32 +1,112 @@
 package infra
 
 import (
+	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/fetch"
 	"github.com/greeddj/go-galaxy/internal/galaxy/output"
 )
 
 // Infra holds runtime dependencies such as IO and HTTP clients.
 type Infra struct {
-	Output  output.Printer
-	HTTP    *http.Client
+	Output output.Printer
+	Stdout io.Writer
+	// HTTP is the client for servers without settings of their own; see ClientFor.
+	HTTP *http.Client
+	// S3HTTP is the client for the S3 cache backend; nil means HTTP.
+	S3HTTP *http.Client
+	// Mirrors spreads requests for the server over its mirrors; nil without mirrors.
+	Mirrors *fetch.MirrorSet
 	Now     func() time.Time
 	TempDir func() string
+	// sources are the clients of servers with settings of their own.
+	sources []sourceClient
+}
+
+// sourceClient is the HTTP client of one configured server.
+type sourceClient struct {
+	host   string
+	path   string
+	client *http.Client
 }
 
 // New builds Infra with default helpers for time and temp paths.
+// Report output on Stdout is redacted when out masks credentials.
 func New(out output.Printer, httpClient *http.Client) *Infra {
+	var stdout io.Writer = os.Stdout
+	if r, ok := out.(output.Redactor); ok {
+		stdout = output.RedactWriter(stdout, r)
+	}
 	return &Infra{
 		Output:  out,
+		Stdout:  stdout,
 		HTTP:    httpClient,
 		Now:     time.Now,
 		TempDir: os.TempDir,
 	}
 }
 
+// NewFromConfig builds Infra with separately tuned clients for Galaxy and S3.
+func NewFromConfig(out output.Printer, cfg *config.Config) *Infra {
+	runtime := New(out, fetch.New(cfg.Timeout, cfg.HTTP))
+	runtime.S3HTTP = fetch.New(cfg.Timeout, cfg.S3Cache.HTTP)
+	runtime.Mirrors = fetch.NewMirrorSet(cfg.Server, cfg.Mirrors, out.Debugf)
+	runtime.HTTP.Transport = runtime.Mirrors.Wrap(runtime.HTTP.Transport)
+	for _, src := range cfg.Sources {
+		parsed, err := url.Parse(src.URL)
+		if err != nil {
+			continue
+		}
+		client := fetch.NewForSource(cfg.Timeout, cfg.HTTP, src)
+		client.Transport = runtime.Mirrors.Wrap(client.Transport)
+		runtime.sources = append(runtime.sources, sourceClient{
+			host:   parsed.Host,
+			path:   strings.TrimRight(parsed.Path, "/"),
+			client: client,
+		})
+		out.Debugf("server %s (%s) uses its own connection settings", src.Name, src.URL)
+	}
+	if s3Chaos, httpChaos := cfg.S3Cache.HTTP.Chaos, cfg.HTTP.Chaos; s3Chaos.Enabled() || httpChaos.Enabled() || cfg.CacheChaos.Enabled() {
+		out.Printf("⚠️ failure injection on: S3 error rate %v, cache error rate %v, download delay %s",
+			s3Chaos.ErrorRate, cfg.CacheChaos.ErrorRate, httpChaos.SlowDownload)
+	}
+	return runtime
+}
+
+// ClientFor returns the HTTP client for a Galaxy request to rawURL: the
+// client of the configured server on the same host, preferring the one with
+// the longest matching path, or HTTP when no server matches.
+func (i *Infra) ClientFor(rawURL string) *http.Client {
+	if len(i.sources) == 0 {
+		return i.HTTP
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return i.HTTP
+	}
+	client, bestLen := i.HTTP, -2
+	for _, src := range i.sources {
+		if src.host != parsed.Host {
+			continue
+		}
+		// a server on the host whose path does not match still beats HTTP
+		pathLen := -1
+		if parsed.Path == src.path || strings.HasPrefix(parsed.Path, src.path+"/") {
+			pathLen = len(src.path)
+		}
+		if pathLen > bestLen {
+			client, bestLen = src.client, pathLen
+		}
+	}
+	return client
+}
+
 // DebugAnsibleConfig logs which settings were sourced from ansible.cfg.
 func (i *Infra) DebugAnsibleConfig(cfg *config.Config) {
 	if i == nil || i.Output == nil || cfg == nil || cfg.AnsibleConfigPath == "" {
@@ -0,0 +1,140 @@
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+const (
+	// completeTimeout bounds a completion lookup so the shell never hangs.
+	completeTimeout = 2 * time.Second
+	// completeCacheTTL is how long fetched candidates are reused.
+	completeCacheTTL = time.Hour
+	// completeLimit caps the search results read for one lookup.
+	completeLimit = 200
+)
+
+// completeEntry is one cached completion lookup.
+type completeEntry struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Names     []string  `json:"names"`
+}
+
+// Complete returns candidates for shell completion of a collection name:
+// namespaces as "ns." until prefix holds a dot, then the collections of that
+// namespace as "ns.name". Lookups are cached in the cache directory and
+// bounded by a short timeout; any failure yields no candidates.
+func Complete(ctx context.Context, cfg *config.Config, runtime *infra.Infra, prefix string) []string {
+	ctx, cancel := context.WithTimeout(ctx, completeTimeout)
+	defer cancel()
+
+	namespace, _, scoped := strings.Cut(prefix, ".")
+	query := prefix
+	if scoped {
+		query = namespace + "."
+	}
+	key := strings.TrimRight(cfg.Server, "/") + "|" + query
+	cachePath := filepath.Join(cfg.CacheDir, helpers.StoreCompletionFile)
+	entries := loadCompleteCache(cachePath)
+	entry, ok := entries[key]
+	if !ok || time.Since(entry.FetchedAt) > completeCacheTTL {
+		names, err := fetchCompletions(ctx, runtime, cfg.Server, namespace, scoped)
+		if err != nil {
+			runtime.Output.Debugf("completion lookup failed: %s", err.Error())
+			return nil
+		}
+		entry = completeEntry{FetchedAt: time.Now().UTC(), Names: names}
+		entries[key] = entry
+		saveCompleteCache(cachePath, entries)
+	}
+
+	matches := make([]string, 0, len(entry.Names))
+	for _, name := range entry.Names {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
+
+// fetchCompletions reads the server's search index for namespaces matching
+// namespace, or for the collections of namespace when scoped.
+func fetchCompletions(ctx context.Context, runtime *infra.Infra, server, namespace string, scoped bool) ([]string, error) {
+	results, err := searchCollections(ctx, runtime, completeURL(server, namespace, scoped), completeLimit)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(results))
+	for _, r := range results {
+		switch {
+		case scoped && r.Namespace == namespace:
+			names = append(names, r.Namespace+"."+r.Name)
+		case !scoped && strings.HasPrefix(r.Namespace, namespace):
+			names = append(names, r.Namespace+".")
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names), nil
+}
+
+// completeURL builds the search index URL for a completion lookup.
+func completeURL(server, namespace string, scoped bool) string {
+	query := url.Values{}
+	if scoped {
+		query.Set("namespace", namespace)
+	} else if namespace != "" {
+		query.Set("keywords", namespace)
+	}
+	query.Set("is_highest", "true")
+	query.Set("limit", strconv.Itoa(completeLimit))
+	return apiV3Root(server) + "/plugin/ansible/search/collection-versions/?" + query.Encode()
+}
+
+// loadCompleteCache reads cached lookups; a missing or corrupt file is empty.
+func loadCompleteCache(path string) map[string]completeEntry {
+	entries := make(map[string]completeEntry)
+	//nolint:gosec // path is derived from the configured cache directory.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entries
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return make(map[string]completeEntry)
+	}
+	return entries
+}
+
+// saveCompleteCache writes lookups, dropping expired ones. Errors are ignored:
+// the cache only saves a round trip on the next completion.
+func saveCompleteCache(path string, entries map[string]completeEntry) {
+	for key, entry := range entries {
+		if time.Since(entry.FetchedAt) > completeCacheTTL {
+			delete(entries, key)
+		}
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+	}
+}
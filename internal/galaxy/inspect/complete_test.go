@@ -0,0 +1,67 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestCompleteNamespacesAndCollections(t *testing.T) {
+	t.Parallel()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		q := r.URL.Query()
+		switch {
+		case q.Get("namespace") == "community":
+			_, _ = fmt.Fprint(w, `{"data":[{"collection_version":{"namespace":"community","name":"general"}},`+
+				`{"collection_version":{"namespace":"community","name":"docker"}}]}`)
+		case q.Get("keywords") == "comm":
+			_, _ = fmt.Fprint(w, `{"data":[{"collection_version":{"namespace":"community","name":"general"}},`+
+				`{"collection_version":{"namespace":"community","name":"docker"}},`+
+				`{"collection_version":{"namespace":"other","name":"community_tools"}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	cfg := &config.Config{Server: srv.URL, CacheDir: t.TempDir()}
+	ctx := context.Background()
+
+	if got := Complete(ctx, cfg, runtime, "comm"); !slices.Equal(got, []string{"community."}) {
+		t.Fatalf("namespace candidates: %v", got)
+	}
+	if got := Complete(ctx, cfg, runtime, "community.d"); !slices.Equal(got, []string{"community.docker"}) {
+		t.Fatalf("collection candidates: %v", got)
+	}
+	if got := Complete(ctx, cfg, runtime, "community.g"); !slices.Equal(got, []string{"community.general"}) {
+		t.Fatalf("cached collection candidates: %v", got)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("expected the namespace listing to be cached, got %d requests", n)
+	}
+}
+
+func TestCompleteIgnoresServerErrors(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	cfg := &config.Config{Server: srv.URL, CacheDir: t.TempDir()}
+	if got := Complete(context.Background(), cfg, runtime, "a."); len(got) != 0 {
+		t.Fatalf("expected no candidates, got %v", got)
+	}
+}
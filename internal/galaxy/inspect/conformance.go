@@ -0,0 +1,68 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/conformance"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// ConformanceOptions selects the corpus and report format.
+type ConformanceOptions struct {
+	Corpus string
+	Format string
+}
+
+// Conformance resolves the conformance corpus and writes how each case
+// compares with ansible-galaxy to the runtime stdout. It fails when a case
+// diverges without a documented reason.
+func Conformance(ctx context.Context, cfg *config.Config, runtime *infra.Infra, opts ConformanceOptions) error {
+	cases, err := conformance.LoadCorpus(opts.Corpus)
+	if err != nil {
+		return err
+	}
+	runtime.Output.Printf("🧪 run %d conformance cases", len(cases))
+	results := conformance.Run(ctx, cfg, runtime.Output, cases)
+	if f := strings.ToLower(strings.TrimSpace(opts.Format)); f == "" || f == "text" {
+		err = renderConformanceText(runtime.Stdout, results)
+	} else {
+		err = render(runtime.Stdout, results, opts.Format)
+	}
+	if err != nil {
+		return err
+	}
+	if conformance.Diverged(results) {
+		return helpers.ErrConformanceDivergence
+	}
+	return nil
+}
+
+// renderConformanceText prints one line per case and one per divergence.
+func renderConformanceText(w io.Writer, results []conformance.Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "CASE\tSTATUS\tDETAILS\n")
+	for _, r := range results {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Case, r.Status, r.Note)
+		for _, d := range r.Divergences {
+			_, _ = fmt.Fprintf(tw, "\t\t%s: ansible-galaxy %s, go-galaxy %s\n", d.Collection, orNone(d.AnsibleGalaxy), orNone(d.GoGalaxy))
+		}
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(tw, "\t\t%s\n", r.Error)
+		}
+	}
+	return tw.Flush()
+}
+
+// orNone renders a collection a tool did not install.
+func orNone(version string) string {
+	if version == "" {
+		return "-"
+	}
+	return version
+}
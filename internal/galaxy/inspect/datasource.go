@@ -0,0 +1,41 @@
+package inspect
+
+import (
+	"context"
+
+	cacheBackend "github.com/greeddj/go-galaxy/internal/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// DatasourceOptions selects the collection to list releases for.
+type DatasourceOptions struct {
+	Name string
+}
+
+// Datasource writes a Renovate custom datasource document to the runtime stdout.
+func Datasource(ctx context.Context, cfg *config.Config, runtime *infra.Infra, opts DatasourceOptions) error {
+	runtime.Output.Printf("🚀 init cache backend")
+	backend, err := cacheBackend.New(cfg, runtime)
+	if err != nil {
+		return err
+	}
+	if err := backend.Open(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		_ = backend.Close(ctx)
+	}()
+
+	runtime.Output.Printf("🚀 load storage")
+	st, err := backend.LoadStore(ctx)
+	if err != nil {
+		return err
+	}
+	ds, err := collections.BuildDatasource(ctx, cfg, runtime, st, opts.Name)
+	if err != nil {
+		return err
+	}
+	return render(runtime.Stdout, ds, "json")
+}
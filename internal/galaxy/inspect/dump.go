@@ -0,0 +1,75 @@
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	cacheBackend "github.com/greeddj/go-galaxy/internal/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"gopkg.in/yaml.v3"
+)
+
+// DumpOptions selects which store buckets to dump and how to render them.
+type DumpOptions struct {
+	Buckets []string
+	Prefix  string
+	Format  string
+}
+
+// Dump writes the selected store buckets to the runtime stdout.
+func Dump(ctx context.Context, cfg *config.Config, runtime *infra.Infra, opts DumpOptions) error {
+	runtime.Output.Printf("🚀 init cache backend")
+	backend, err := cacheBackend.New(cfg, runtime)
+	if err != nil {
+		return err
+	}
+	if err := backend.Open(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		_ = backend.Close(ctx)
+	}()
+
+	runtime.Output.Printf("🚀 load storage")
+	st, err := backend.LoadStore(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := st.Dump(opts.Buckets, opts.Prefix)
+	if err != nil {
+		return err
+	}
+	return render(runtime.Stdout, data, opts.Format)
+}
+
+// render encodes data to w in the requested format.
+func render(w io.Writer, data any, format string) error {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	case "yaml", "yml":
+		// Round-trip through JSON so YAML keys match the JSON field names.
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		var generic any
+		if err := json.Unmarshal(payload, &generic); err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w)
+		defer func() {
+			_ = enc.Close()
+		}()
+		return enc.Encode(generic)
+	default:
+		return fmt.Errorf("%w: %q", helpers.ErrUnsupportedOutputFormat, format)
+	}
+}
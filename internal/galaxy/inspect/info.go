@@ -0,0 +1,105 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	cacheBackend "github.com/greeddj/go-galaxy/internal/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// InfoOptions controls the info report.
+type InfoOptions struct {
+	Ref      string
+	Versions int
+	Format   string
+}
+
+// Info writes a collection's remote and local state to the runtime stdout.
+func Info(ctx context.Context, cfg *config.Config, runtime *infra.Infra, opts InfoOptions) error {
+	runtime.Output.Printf("🚀 init cache backend")
+	backend, err := cacheBackend.New(cfg, runtime)
+	if err != nil {
+		return err
+	}
+	if err := backend.Open(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		_ = backend.Close(ctx)
+	}()
+
+	runtime.Output.Printf("🚀 load storage")
+	st, err := backend.LoadStore(ctx)
+	if err != nil {
+		return err
+	}
+	runtime.Output.Printf("🔍 look up %s", opts.Ref)
+	info, err := collections.Describe(ctx, cfg, runtime, st, opts.Ref, opts.Versions)
+	if err != nil {
+		return err
+	}
+	if f := strings.ToLower(strings.TrimSpace(opts.Format)); f == "" || f == "text" {
+		return renderInfoText(runtime.Stdout, info)
+	}
+	return render(runtime.Stdout, info, opts.Format)
+}
+
+// renderInfoText prints collection info as an aligned report.
+func renderInfoText(w io.Writer, info *collections.Info) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "name\t%s\n", info.Name)
+	_, _ = fmt.Fprintf(tw, "source\t%s\n", info.Source)
+	_, _ = fmt.Fprintf(tw, "deprecated\t%t\n", info.Deprecated)
+	if info.ReplacedBy != "" {
+		_, _ = fmt.Fprintf(tw, "replaced by\t%s\n", info.ReplacedBy)
+	}
+	versions := strconv.Itoa(info.VersionsTotal)
+	if len(info.Versions) < info.VersionsTotal {
+		versions += fmt.Sprintf(" (newest %d)", len(info.Versions))
+	}
+	if info.VersionsStale {
+		versions += " (cached)"
+	}
+	_, _ = fmt.Fprintf(tw, "versions\t%s\t%s\n", versions, strings.Join(info.Versions, ", "))
+	_, _ = fmt.Fprintf(tw, "selected\t%s\n", info.Selected)
+
+	names := make([]string, 0, len(info.Dependencies))
+	for name := range info.Dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		_, _ = fmt.Fprintf(tw, "dependencies\t-\n")
+	}
+	for i, name := range names {
+		label := ""
+		if i == 0 {
+			label = "dependencies"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", label, name, info.Dependencies[name])
+	}
+
+	if len(info.Installed) == 0 {
+		_, _ = fmt.Fprintf(tw, "installed\t-\n")
+	}
+	for i, inst := range info.Installed {
+		label := ""
+		if i == 0 {
+			label = "installed"
+		}
+		path := inst.InstallPath
+		if !inst.Present {
+			path += " (missing)"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", label, inst.Version, path)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,135 @@
+package inspect
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
+)
+
+const (
+	// LicenseUnknown stands for the license of a collection whose MANIFEST.json
+	// declares neither license nor license_file.
+	LicenseUnknown = "unknown"
+	// LicenseFilePrefix marks the bucket of a collection that only points to a
+	// license file, so a file named like an SPDX identifier is not counted as one.
+	LicenseFilePrefix = "file:"
+)
+
+// LicenseInventory is the license report of the installed collections.
+type LicenseInventory struct {
+	// Total is the number of installed collections.
+	Total int `json:"total"`
+	// Counts maps each license to the number of collections under it. A
+	// collection only pointing to a license file counts as "file:" and the
+	// file name; dual-licensed collections count once per license.
+	Counts map[string]int `json:"counts"`
+	// Unknown lists the collections that declare no license.
+	Unknown []string `json:"unknown"`
+	// Collections are the collections with their declared licenses.
+	Collections []LicensedCollection `json:"collections"`
+}
+
+// LicensedCollection is one row of the license inventory.
+type LicensedCollection struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Licenses    []string `json:"licenses"`
+	LicenseFile string   `json:"license_file,omitempty"`
+}
+
+// buildLicenseInventory groups the listed collections by license.
+func buildLicenseInventory(listed []collections.ListedCollection) LicenseInventory {
+	inv := LicenseInventory{
+		Total:       len(listed),
+		Counts:      make(map[string]int),
+		Unknown:     []string{},
+		Collections: make([]LicensedCollection, 0, len(listed)),
+	}
+	for _, item := range listed {
+		licenses := licenseNames(item)
+		for _, license := range licenses {
+			inv.Counts[license]++
+		}
+		if len(licenses) == 1 && licenses[0] == LicenseUnknown {
+			inv.Unknown = append(inv.Unknown, item.Name)
+		}
+		inv.Collections = append(inv.Collections, LicensedCollection{
+			Name:        item.Name,
+			Version:     item.Version,
+			Licenses:    licenses,
+			LicenseFile: item.LicenseFile,
+		})
+	}
+	return inv
+}
+
+// licenseNames returns the licenses item is counted under.
+func licenseNames(item collections.ListedCollection) []string {
+	var out []string
+	for _, license := range item.Licenses {
+		if license = strings.TrimSpace(license); license != "" && !slices.Contains(out, license) {
+			out = append(out, license)
+		}
+	}
+	if len(out) > 0 {
+		return out
+	}
+	if file := strings.TrimSpace(item.LicenseFile); file != "" {
+		return []string{LicenseFilePrefix + file}
+	}
+	return []string{LicenseUnknown}
+}
+
+// renderLicenses writes the inventory as a table, CSV or JSON/YAML.
+func renderLicenses(w io.Writer, inv LicenseInventory, format string) error {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "text":
+		return renderLicensesText(w, inv)
+	case "csv":
+		return renderLicensesCSV(w, inv)
+	default:
+		return render(w, inv, format)
+	}
+}
+
+// renderLicensesText prints the per-license counts and then every collection.
+func renderLicensesText(w io.Writer, inv LicenseInventory) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "LICENSE\tCOLLECTIONS\n")
+	for _, license := range sortedLicenses(inv.Counts) {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\n", license, inv.Counts[license])
+	}
+	_, _ = fmt.Fprintf(tw, "\nCOLLECTION\tVERSION\tLICENSE\n")
+	for _, item := range inv.Collections {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", item.Name, item.Version, strings.Join(item.Licenses, ", "))
+	}
+	return tw.Flush()
+}
+
+// renderLicensesCSV writes one row per collection; several licenses are
+// joined with semicolons.
+func renderLicensesCSV(w io.Writer, inv LicenseInventory) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"collection", "version", "licenses", "license_file"})
+	for _, item := range inv.Collections {
+		_ = cw.Write([]string{item.Name, item.Version, strings.Join(item.Licenses, ";"), item.LicenseFile})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// sortedLicenses orders licenses by collection count, most used first.
+func sortedLicenses(counts map[string]int) []string {
+	return slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return strings.Compare(a, b)
+	})
+}
@@ -0,0 +1,44 @@
+package inspect
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
+)
+
+func TestLicenseInventory(t *testing.T) {
+	t.Parallel()
+	inv := buildLicenseInventory([]collections.ListedCollection{
+		{Name: "a.b", Version: "1.0.0", Licenses: []string{"GPL-3.0-or-later"}},
+		{Name: "c.d", Version: "2.0.0", Licenses: []string{"GPL-3.0-or-later", "Apache-2.0"}},
+		{Name: "e.f", Version: "3.0.0", LicenseFile: "LICENSE"},
+		{Name: "g.h", Version: "4.0.0"},
+		{Name: "i.j", Version: "5.0.0", LicenseFile: "Apache-2.0"},
+	})
+	if inv.Total != 5 || inv.Counts["GPL-3.0-or-later"] != 2 || inv.Counts["Apache-2.0"] != 1 ||
+		inv.Counts["file:LICENSE"] != 1 || inv.Counts["file:Apache-2.0"] != 1 || inv.Counts[LicenseUnknown] != 1 {
+		t.Fatalf("unexpected counts: %+v", inv)
+	}
+	if !slices.Equal(inv.Unknown, []string{"g.h"}) {
+		t.Fatalf("expected g.h to be unknown, got %v", inv.Unknown)
+	}
+	if got := sortedLicenses(inv.Counts); got[0] != "GPL-3.0-or-later" {
+		t.Fatalf("expected the most used license first, got %v", got)
+	}
+
+	var buf bytes.Buffer
+	if err := renderLicenses(&buf, inv, "csv"); err != nil {
+		t.Fatalf("renderLicenses: %v", err)
+	}
+	want := "collection,version,licenses,license_file\n" +
+		"a.b,1.0.0,GPL-3.0-or-later,\n" +
+		"c.d,2.0.0,GPL-3.0-or-later;Apache-2.0,\n" +
+		"e.f,3.0.0,file:LICENSE,LICENSE\n" +
+		"g.h,4.0.0,unknown,\n" +
+		"i.j,5.0.0,file:Apache-2.0,Apache-2.0\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,92 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	cacheBackend "github.com/greeddj/go-galaxy/internal/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// ListOptions controls the list report.
+type ListOptions struct {
+	Remote bool
+	Format string
+	// Licenses reports the license inventory instead of the collection list.
+	Licenses bool
+}
+
+// List writes the collections installed under the download path to the runtime stdout.
+// With Remote set it fails when any installed version is no longer published.
+func List(ctx context.Context, cfg *config.Config, runtime *infra.Infra, opts ListOptions) error {
+	runtime.Output.Printf("🚀 init cache backend")
+	backend, err := cacheBackend.New(cfg, runtime)
+	if err != nil {
+		return err
+	}
+	if err := backend.Open(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		_ = backend.Close(ctx)
+	}()
+
+	runtime.Output.Printf("🚀 load storage")
+	st, err := backend.LoadStore(ctx)
+	if err != nil {
+		return err
+	}
+	listed, err := collections.ListInstalled(ctx, cfg, runtime, st, opts.Remote)
+	if err != nil {
+		return err
+	}
+	if opts.Licenses {
+		err = renderLicenses(runtime.Stdout, buildLicenseInventory(listed), opts.Format)
+	} else if f := strings.ToLower(strings.TrimSpace(opts.Format)); f == "" || f == "text" {
+		err = renderListText(runtime.Stdout, listed, opts.Remote)
+	} else {
+		err = render(runtime.Stdout, listed, opts.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, item := range listed {
+		if item.Remote == collections.RemoteMissing {
+			missing = append(missing, item.Name+" "+item.Version)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", helpers.ErrVersionUnpublished, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// renderListText prints installed collections as an aligned table.
+func renderListText(w io.Writer, listed []collections.ListedCollection, remote bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if remote {
+		_, _ = fmt.Fprintf(tw, "COLLECTION\tVERSION\tREMOTE\tPATH\n")
+	} else {
+		_, _ = fmt.Fprintf(tw, "COLLECTION\tVERSION\tPATH\n")
+	}
+	for _, item := range listed {
+		if !remote {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", item.Name, item.Version, item.Path)
+			continue
+		}
+		state := item.Remote
+		if item.RemoteError != "" {
+			state += " (" + item.RemoteError + ")"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", item.Name, item.Version, state, item.Path)
+	}
+	return tw.Flush()
+}
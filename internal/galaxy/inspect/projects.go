@@ -0,0 +1,110 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cacheBackend "github.com/greeddj/go-galaxy/internal/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// ProjectsOptions controls the project registry report.
+type ProjectsOptions struct {
+	// Owner is a glob matched against the owner repository, user and user@host.
+	Owner string
+	// TTL marks projects that have not run within it as stale; zero never does.
+	TTL time.Duration
+	// StaleOnly drops projects that are not stale.
+	StaleOnly bool
+	Format    string
+}
+
+// ProjectEntry is one project registry record in the report.
+type ProjectEntry struct {
+	Path             string             `json:"path"`
+	RequirementsFile string             `json:"requirements_file"`
+	CollectionsPath  string             `json:"collections_path"`
+	LastRun          time.Time          `json:"last_run"`
+	Owner            store.ProjectOwner `json:"owner"`
+	Stale            bool               `json:"stale"`
+}
+
+// Projects writes the recorded projects and their owners to the runtime stdout.
+func Projects(ctx context.Context, cfg *config.Config, runtime *infra.Infra, opts ProjectsOptions) error {
+	runtime.Output.Printf("🚀 init cache backend")
+	backend, err := cacheBackend.New(cfg, runtime)
+	if err != nil {
+		return err
+	}
+	if err := backend.Open(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		_ = backend.Close(ctx)
+	}()
+
+	runtime.Output.Printf("🚀 load projects registry")
+	registry, err := backend.LoadProjectRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	entries := projectEntries(registry, opts, time.Now())
+	if f := strings.ToLower(strings.TrimSpace(opts.Format)); f == "" || f == "text" {
+		return renderProjectsText(runtime.Stdout, entries)
+	}
+	return render(runtime.Stdout, entries, opts.Format)
+}
+
+// projectEntries filters registry by opts and sorts the oldest run first.
+func projectEntries(registry *store.ProjectRegistry, opts ProjectsOptions, now time.Time) []ProjectEntry {
+	entries := make([]ProjectEntry, 0, len(registry.Projects))
+	for projectPath, project := range registry.Projects {
+		if !project.Owner.Matches(opts.Owner) {
+			continue
+		}
+		stale := project.Stale(opts.TTL, now)
+		if opts.StaleOnly && !stale {
+			continue
+		}
+		entries = append(entries, ProjectEntry{
+			Path:             projectPath,
+			RequirementsFile: project.RequirementsFile,
+			CollectionsPath:  project.CollectionsPath,
+			LastRun:          project.LastRun,
+			Owner:            project.Owner,
+			Stale:            stale,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].LastRun.Equal(entries[j].LastRun) {
+			return entries[i].LastRun.Before(entries[j].LastRun)
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+// renderProjectsText prints projects as an aligned table.
+func renderProjectsText(w io.Writer, entries []ProjectEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "PROJECT\tOWNER\tLAST RUN\tSTALE\tJOB\n")
+	for _, entry := range entries {
+		owner := entry.Owner.String()
+		if owner == "" {
+			owner = "-"
+		}
+		job := entry.Owner.Job
+		if job == "" {
+			job = "-"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\n", entry.Path, owner, entry.LastRun.Format(time.RFC3339), entry.Stale, job)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,44 @@
+package inspect
+
+import (
+	"context"
+
+	cacheBackend "github.com/greeddj/go-galaxy/internal/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/collections"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// ResolveVersionOptions selects the collection and constraint to resolve.
+type ResolveVersionOptions struct {
+	Name       string
+	Constraint string
+	All        bool
+	Format     string
+}
+
+// ResolveVersion writes the version the resolver would select to the runtime stdout.
+func ResolveVersion(ctx context.Context, cfg *config.Config, runtime *infra.Infra, opts ResolveVersionOptions) error {
+	runtime.Output.Printf("🚀 init cache backend")
+	backend, err := cacheBackend.New(cfg, runtime)
+	if err != nil {
+		return err
+	}
+	if err := backend.Open(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		_ = backend.Close(ctx)
+	}()
+
+	runtime.Output.Printf("🚀 load storage")
+	st, err := backend.LoadStore(ctx)
+	if err != nil {
+		return err
+	}
+	selection, err := collections.ResolveVersion(ctx, cfg, runtime, st, opts.Name, opts.Constraint, opts.All)
+	if err != nil {
+		return err
+	}
+	return render(runtime.Stdout, selection, opts.Format)
+}
@@ -0,0 +1,153 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// searchDescriptionWidth caps descriptions in the text table.
+const searchDescriptionWidth = 80
+
+// SearchOptions controls a collection search.
+type SearchOptions struct {
+	Term   string
+	Limit  int
+	Format string
+}
+
+// SearchResult describes one matching collection at its highest version.
+type SearchResult struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+}
+
+// searchPage is one page of the v3 collection-versions search index.
+type searchPage struct {
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+	Data []struct {
+		CollectionVersion struct {
+			Namespace   string `json:"namespace"`
+			Name        string `json:"name"`
+			Version     string `json:"version"`
+			Description string `json:"description"`
+		} `json:"collection_version"`
+		IsDeprecated bool `json:"is_deprecated"`
+	} `json:"data"`
+}
+
+// Search queries the configured server's search index and writes matches to stdout.
+func Search(ctx context.Context, cfg *config.Config, runtime *infra.Infra, opts SearchOptions) error {
+	term := strings.TrimSpace(opts.Term)
+	if term == "" {
+		return helpers.ErrSearchTermRequired
+	}
+	runtime.Output.Printf("🔍 search %s for %q", cfg.Server, term)
+	results, err := searchCollections(ctx, runtime, searchURL(cfg.Server, term, opts.Limit), opts.Limit)
+	if err != nil {
+		return err
+	}
+	if f := strings.ToLower(strings.TrimSpace(opts.Format)); f == "" || f == "text" {
+		return renderSearchText(runtime.Stdout, results)
+	}
+	return render(runtime.Stdout, results, opts.Format)
+}
+
+// searchCollections follows search pages from first until limit results are collected.
+func searchCollections(ctx context.Context, runtime *infra.Infra, first string, limit int) ([]SearchResult, error) {
+	next := first
+	results := make([]SearchResult, 0)
+	for next != "" && (limit <= 0 || len(results) < limit) {
+		runtime.Output.Debugf("search GET %s", next)
+		var page searchPage
+		if err := cacheManager.FetchJSONWithCachePolicy(ctx, runtime.ClientFor(next), next, nil, &page, cacheManager.Policy{}); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Data {
+			cv := item.CollectionVersion
+			results = append(results, SearchResult{
+				Namespace:   cv.Namespace,
+				Name:        cv.Name,
+				Version:     cv.Version,
+				Description: strings.TrimSpace(cv.Description),
+				Deprecated:  item.IsDeprecated,
+			})
+		}
+		next = resolveNextURL(next, page.Links.Next)
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// searchURL builds the first search page URL for a server.
+func searchURL(server, term string, limit int) string {
+	query := url.Values{}
+	query.Set("keywords", term)
+	query.Set("is_highest", "true")
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	return apiV3Root(server) + "/plugin/ansible/search/collection-versions/?" + query.Encode()
+}
+
+// apiV3Root derives the v3 API root from a configured server URL.
+func apiV3Root(server string) string {
+	trimmed := strings.TrimRight(strings.TrimSpace(strings.Trim(server, "\"")), "/")
+	switch {
+	case strings.HasSuffix(trimmed, "/v3"):
+		return trimmed
+	case strings.Contains(trimmed, "/api"):
+		return trimmed + "/v3"
+	default:
+		return trimmed + "/api/v3"
+	}
+}
+
+// resolveNextURL resolves a pagination link that may be relative.
+func resolveNextURL(current, next string) string {
+	if next == "" {
+		return ""
+	}
+	base, err := url.Parse(current)
+	if err != nil {
+		return ""
+	}
+	ref, err := url.Parse(next)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// renderSearchText prints search results as an aligned table.
+func renderSearchText(w io.Writer, results []SearchResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "NAME\tVERSION\tDESCRIPTION\n")
+	for _, r := range results {
+		desc := strings.Join(strings.Fields(r.Description), " ")
+		if runes := []rune(desc); len(runes) > searchDescriptionWidth {
+			desc = string(runes[:searchDescriptionWidth-3]) + "..."
+		}
+		if r.Deprecated {
+			desc = "(deprecated) " + desc
+		}
+		_, _ = fmt.Fprintf(tw, "%s.%s\t%s\t%s\n", r.Namespace, r.Name, r.Version, desc)
+	}
+	return tw.Flush()
+}
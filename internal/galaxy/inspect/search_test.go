@@ -0,0 +1,44 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+)
+
+func TestSearchFollowsPages(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/plugin/ansible/search/collection-versions/" || r.URL.Query().Get("keywords") != "net" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("offset") == "" {
+			_, _ = fmt.Fprint(w, `{"links":{"next":"/api/v3/plugin/ansible/search/collection-versions/?keywords=net&offset=1"},`+
+				`"data":[{"collection_version":{"namespace":"a","name":"net","version":"1.2.0","description":"Network\nmodules"}}]}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"links":{"next":null},"data":[{"collection_version":{"namespace":"b","name":"net","version":"0.1.0"},"is_deprecated":true}]}`)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	runtime.Stdout = &out
+	cfg := &config.Config{Server: srv.URL}
+	if err := Search(context.Background(), cfg, runtime, SearchOptions{Term: "net", Format: "text"}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	text := out.String()
+	if !strings.Contains(text, "a.net") || !strings.Contains(text, "Network modules") || !strings.Contains(text, "(deprecated)") {
+		t.Fatalf("unexpected output:\n%s", text)
+	}
+}
@@ -0,0 +1,87 @@
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	cacheBackend "github.com/greeddj/go-galaxy/internal/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// Stats writes cache hit/miss counters to the runtime stdout.
+func Stats(ctx context.Context, cfg *config.Config, runtime *infra.Infra, format string) error {
+	runtime.Output.Printf("🚀 init cache backend")
+	backend, err := cacheBackend.New(cfg, runtime)
+	if err != nil {
+		return err
+	}
+	if err := backend.Open(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		_ = backend.Close(ctx)
+	}()
+
+	runtime.Output.Printf("🚀 load storage")
+	st, err := backend.LoadStore(ctx)
+	if err != nil {
+		return err
+	}
+	stats := st.MetaSnapshot().Stats
+	if f := strings.ToLower(strings.TrimSpace(format)); f == "" || f == "text" {
+		return renderStatsText(runtime.Stdout, stats)
+	}
+	return render(runtime.Stdout, stats, format)
+}
+
+// renderStatsText prints counters as an aligned table.
+func renderStatsText(w io.Writer, stats store.CacheStats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "runs\t%d\n", stats.Runs)
+	_, _ = fmt.Fprintf(tw, "\tlast run\ttotal\n")
+	rows := []struct {
+		name        string
+		last, total int64
+	}{
+		{"api cache hits", stats.LastRun.APIHits, stats.Total.APIHits},
+		{"api cache misses", stats.LastRun.APIMisses, stats.Total.APIMisses},
+		{"artifact hits", stats.LastRun.ArtifactHits, stats.Total.ArtifactHits},
+		{"artifact misses", stats.LastRun.ArtifactMisses, stats.Total.ArtifactMisses},
+	}
+	for _, row := range rows {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\n", row.name, row.last, row.total)
+	}
+	_, _ = fmt.Fprintf(tw, "api hit ratio\t%s\t%s\n", ratio(stats.LastRun.APIHits, stats.LastRun.APIMisses), ratio(stats.Total.APIHits, stats.Total.APIMisses))
+	_, _ = fmt.Fprintf(tw, "artifact hit ratio\t%s\t%s\n",
+		ratio(stats.LastRun.ArtifactHits, stats.LastRun.ArtifactMisses), ratio(stats.Total.ArtifactHits, stats.Total.ArtifactMisses))
+	_, _ = fmt.Fprintf(tw, "bytes saved\t%s\t%s\n", humanBytes(stats.LastRun.BytesSaved), humanBytes(stats.Total.BytesSaved))
+	_, _ = fmt.Fprintf(tw, "bytes downloaded\t%s\t%s\n", humanBytes(stats.LastRun.BytesDownloaded), humanBytes(stats.Total.BytesDownloaded))
+	return tw.Flush()
+}
+
+// ratio formats hits/(hits+misses) as a percentage.
+func ratio(hits, misses int64) string {
+	if hits+misses == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(hits)*100/float64(hits+misses))
+}
+
+// humanBytes formats a byte count with a binary unit.
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,84 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+)
+
+// Options controls how the collections tree is packaged and pushed.
+type Options struct {
+	Tag       string
+	Prefix    string
+	Platform  string
+	Username  string
+	Password  string
+	PlainHTTP bool
+}
+
+// Export packages the installed collections tree as a single-layer image and pushes it to opts.Tag.
+func Export(ctx context.Context, cfg *config.Config, runtime *infra.Infra, opts Options) error {
+	err := export(ctx, cfg, runtime, opts)
+	if err != nil {
+		runtime.Output.Errorf("Error: %s", err.Error())
+	}
+	return err
+}
+
+func export(ctx context.Context, cfg *config.Config, runtime *infra.Infra, opts Options) error {
+	ref, err := ParseReference(opts.Tag)
+	if err != nil {
+		return err
+	}
+	platformOS, arch, ok := strings.Cut(opts.Platform, "/")
+	if !ok || platformOS == "" || arch == "" {
+		return fmt.Errorf("%w: platform %q, want os/arch", helpers.ErrInvalidImageReference, opts.Platform)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.DownloadPath, "ansible_collections")); err != nil {
+		return fmt.Errorf("%w: %s", helpers.ErrNothingToExport, cfg.DownloadPath)
+	}
+
+	workDir, err := os.MkdirTemp(cfg.TempDir, "go-galaxy-oci-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(workDir)
+	}()
+
+	runtime.Output.Printf("📦 build layer from %s", cfg.DownloadPath)
+	layerPath := filepath.Join(workDir, "layer.tar.gz")
+	layer, diffID, err := buildLayer(cfg.DownloadPath, opts.Prefix, layerPath)
+	if err != nil {
+		return fmt.Errorf("failed to build layer: %w", err)
+	}
+	img, err := newImage(layerPath, layer, diffID, platformOS, arch, "go-galaxy "+cfg.ToolVersion)
+	if err != nil {
+		return err
+	}
+	runtime.Output.Debugf("layer %s (%d bytes), manifest %s", layer.Digest, layer.Size, img.manifestDigest())
+	if cfg.DryRun {
+		runtime.Output.Printf("🧪 Dry run: %s@%s not pushed", ref, img.manifestDigest())
+		return nil
+	}
+
+	runtime.Output.Printf("📤 push %s", ref)
+	reg := newRegistry(runtime.HTTP, ref, opts)
+	if err := reg.pushBlob(ctx, img.layer, fileBody(img.layerPath)); err != nil {
+		return fmt.Errorf("failed to push layer: %w", err)
+	}
+	if err := reg.pushBlob(ctx, img.configDescriptor(), bytesBody(img.config)); err != nil {
+		return fmt.Errorf("failed to push image config: %w", err)
+	}
+	if err := reg.pushManifest(ctx, ref.Tag, img.manifest); err != nil {
+		return fmt.Errorf("failed to push manifest: %w", err)
+	}
+	runtime.Output.PersistentPrintf("✅ Pushed %s@%s", ref, img.manifestDigest())
+	return nil
+}
@@ -0,0 +1,168 @@
+package oci
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/progress"
+	"github.com/klauspost/pgzip"
+)
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input   string
+		want    Reference
+		wantErr bool
+	}{
+		{input: "ee", want: Reference{Registry: dockerHubRegistry, Repository: "library/ee", Tag: "latest"}},
+		{input: "org/ee:v1", want: Reference{Registry: dockerHubRegistry, Repository: "org/ee", Tag: "v1"}},
+		{input: "quay.io/org/ee:abc", want: Reference{Registry: "quay.io", Repository: "org/ee", Tag: "abc"}},
+		{input: "localhost:5000/ee", want: Reference{Registry: "localhost:5000", Repository: "ee", Tag: "latest"}},
+		{input: "", wantErr: true},
+		{input: "quay.io/Org/ee", wantErr: true},
+		{input: "quay.io/org/ee@sha256:abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseReference(tt.input)
+		if tt.wantErr {
+			if !errors.Is(err, helpers.ErrInvalidImageReference) {
+				t.Fatalf("%q: expected ErrInvalidImageReference, got %v", tt.input, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Fatalf("%q: got %+v, %v; want %+v", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+// fakeRegistry is a minimal distribution API server that requires a bearer token.
+type fakeRegistry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "bot" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"token":"t0ken"}`))
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer t0ken" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="http://`+r.Host+`/token",service="fake"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/v2/org/ee/")
+	switch {
+	case r.Method == http.MethodHead && strings.HasPrefix(path, "blobs/sha256:"):
+		if _, ok := f.blobs[strings.TrimPrefix(path, "blobs/")]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPost && path == "blobs/uploads/":
+		w.Header().Set("Location", "/v2/org/ee/blobs/uploads/1?state=x")
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "blobs/uploads/"):
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		digest := r.URL.Query().Get("digest")
+		if r.URL.Query().Get("state") != "x" || digest != "sha256:"+hex.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.blobs[digest] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && strings.HasPrefix(path, "manifests/"):
+		body, _ := io.ReadAll(r.Body)
+		f.manifests[strings.TrimPrefix(path, "manifests/")] = body
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestExportPushesSingleLayerImage(t *testing.T) {
+	t.Parallel()
+	reg := &fakeRegistry{blobs: make(map[string][]byte), manifests: make(map[string][]byte)}
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	host, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "ansible_collections", "a", "b"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ansible_collections", "a", "b", "MANIFEST.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cfg := &config.Config{DownloadPath: dir, TempDir: t.TempDir()}
+	runtime := infra.New(progress.New(false, true), srv.Client())
+	err = Export(context.Background(), cfg, runtime, Options{
+		Tag:       host.Host + "/org/ee:v1",
+		Prefix:    "/usr/share/ansible/collections",
+		Platform:  "linux/amd64",
+		Username:  "bot",
+		Password:  "secret",
+		PlainHTTP: true,
+	})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	var manifest imageManifest
+	if err := json.Unmarshal(reg.manifests["v1"], &manifest); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if len(manifest.Layers) != 1 || reg.blobs[manifest.Config.Digest] == nil {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	layer := reg.blobs[manifest.Layers[0].Digest]
+	gz, err := pgzip.NewReader(strings.NewReader(string(layer)))
+	if err != nil {
+		t.Fatalf("layer gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	found := false
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("layer tar: %v", err)
+		}
+		if header.Name == "usr/share/ansible/collections/ansible_collections/a/b/MANIFEST.json" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("collection file missing from layer")
+	}
+}
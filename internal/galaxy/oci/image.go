@@ -0,0 +1,152 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/archive"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"github.com/klauspost/pgzip"
+)
+
+const (
+	mediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeConfig   = "application/vnd.oci.image.config.v1+json"
+	mediaTypeLayer    = "application/vnd.oci.image.layer.v1.tar+gzip"
+)
+
+// descriptor references a blob by digest, as in the OCI image spec.
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+type imageManifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        descriptor   `json:"config"`
+	Layers        []descriptor `json:"layers"`
+}
+
+type imageConfig struct {
+	Architecture string         `json:"architecture"`
+	OS           string         `json:"os"`
+	Config       imageRunConfig `json:"config"`
+	RootFS       imageRootFS    `json:"rootfs"`
+	History      []imageHistory `json:"history,omitempty"`
+}
+
+type imageRunConfig struct {
+	Labels map[string]string `json:"Labels,omitempty"`
+}
+
+type imageRootFS struct {
+	Type    string   `json:"type"`
+	DiffIDs []string `json:"diff_ids"`
+}
+
+type imageHistory struct {
+	CreatedBy string `json:"created_by,omitempty"`
+}
+
+// image is a single-layer image ready to push.
+type image struct {
+	layerPath string
+	layer     descriptor
+	config    []byte
+	manifest  []byte
+}
+
+// buildLayer writes the collections tree of srcDir, placed under prefix, as a gzipped tar layer.
+// It returns the layer descriptor and the digest of the uncompressed tar (the diff ID).
+func buildLayer(srcDir, prefix, dstFile string) (descriptor, string, error) {
+	names := make([]string, 0, 2)
+	for _, name := range []string{"ansible_collections", helpers.InstallManifestFile} {
+		if _, err := os.Lstat(filepath.Join(srcDir, name)); err == nil {
+			names = append(names, name)
+		}
+	}
+
+	//nolint:gosec // dstFile is created by the caller in its own temp dir.
+	out, err := os.Create(dstFile)
+	if err != nil {
+		return descriptor{}, "", err
+	}
+	compressed := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(out, compressed)}
+	gz := pgzip.NewWriter(counter)
+	uncompressed := sha256.New()
+	if err := archive.WriteTar(io.MultiWriter(gz, uncompressed), srcDir, prefix, names...); err != nil {
+		_ = out.Close()
+		return descriptor{}, "", err
+	}
+	if err := gz.Close(); err != nil {
+		_ = out.Close()
+		return descriptor{}, "", err
+	}
+	if err := out.Close(); err != nil {
+		return descriptor{}, "", err
+	}
+	layer := descriptor{MediaType: mediaTypeLayer, Digest: digestOf(compressed), Size: counter.n}
+	return layer, digestOf(uncompressed), nil
+}
+
+// newImage builds the config and manifest for a single collections layer.
+func newImage(layerPath string, layer descriptor, diffID, platformOS, arch, tool string) (*image, error) {
+	config, err := json.Marshal(imageConfig{
+		Architecture: arch,
+		OS:           platformOS,
+		Config: imageRunConfig{Labels: map[string]string{
+			"org.opencontainers.image.title": "ansible collections",
+		}},
+		RootFS:  imageRootFS{Type: "layers", DiffIDs: []string{diffID}},
+		History: []imageHistory{{CreatedBy: tool + " export-oci"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	img := &image{layerPath: layerPath, layer: layer, config: config}
+	img.manifest, err = json.Marshal(imageManifest{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeManifest,
+		Config:        img.configDescriptor(),
+		Layers:        []descriptor{layer},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// configDescriptor returns the descriptor of the image config blob.
+func (img *image) configDescriptor() descriptor {
+	sum := sha256.Sum256(img.config)
+	return descriptor{MediaType: mediaTypeConfig, Digest: "sha256:" + hex.EncodeToString(sum[:]), Size: int64(len(img.config))}
+}
+
+// manifestDigest returns the digest the registry will report for the pushed manifest.
+func (img *image) manifestDigest() string {
+	sum := sha256.Sum256(img.manifest)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func digestOf(h hash.Hash) string {
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
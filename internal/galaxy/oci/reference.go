@@ -0,0 +1,58 @@
+package oci
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+const (
+	dockerHubRegistry = "registry-1.docker.io"
+	defaultTag        = "latest"
+)
+
+// Reference identifies an image tag in a registry.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+}
+
+// String returns the reference in registry/repository:tag form.
+func (r Reference) String() string {
+	return r.Registry + "/" + r.Repository + ":" + r.Tag
+}
+
+// ParseReference parses an image reference such as quay.io/org/ee-collections:v1.
+// References without a registry host resolve to Docker Hub, like docker pull does.
+func ParseReference(raw string) (Reference, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.Contains(raw, "@") {
+		return Reference{}, fmt.Errorf("%w: %q", helpers.ErrInvalidImageReference, raw)
+	}
+
+	ref := Reference{Registry: dockerHubRegistry, Tag: defaultTag}
+	rest := raw
+	if host, path, ok := strings.Cut(raw, "/"); ok && isRegistryHost(host) {
+		ref.Registry = host
+		rest = path
+	}
+	if i := strings.LastIndex(rest, ":"); i >= 0 {
+		ref.Tag = rest[i+1:]
+		rest = rest[:i]
+	}
+	ref.Repository = rest
+	if ref.Registry == dockerHubRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if ref.Repository == "" || ref.Tag == "" || ref.Repository != strings.ToLower(ref.Repository) {
+		return Reference{}, fmt.Errorf("%w: %q", helpers.ErrInvalidImageReference, raw)
+	}
+	return ref, nil
+}
+
+// isRegistryHost reports whether the first path component names a registry rather than a namespace.
+func isRegistryHost(component string) bool {
+	return component == "localhost" || strings.ContainsAny(component, ".:")
+}
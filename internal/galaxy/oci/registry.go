@@ -0,0 +1,274 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+// registry pushes blobs and manifests over the OCI distribution API.
+type registry struct {
+	client   *http.Client
+	base     *url.URL
+	repo     string
+	username string
+	password string
+	auth     string
+}
+
+// StatusError describes an unexpected registry response.
+type StatusError struct {
+	Method string
+	URL    string
+	Status string
+	Code   int
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("registry %s %s: %s", e.Method, e.URL, e.Status)
+}
+
+// HTTPStatus returns the response status code.
+func (e *StatusError) HTTPStatus() int {
+	return e.Code
+}
+
+func newRegistry(client *http.Client, ref Reference, opts Options) *registry {
+	scheme := "https"
+	if opts.PlainHTTP {
+		scheme = "http"
+	}
+	return &registry{
+		client:   client,
+		base:     &url.URL{Scheme: scheme, Host: ref.Registry},
+		repo:     ref.Repository,
+		username: opts.Username,
+		password: opts.Password,
+	}
+}
+
+// bodyFunc opens a fresh request body so a request can be replayed after an auth challenge.
+type bodyFunc func() (io.ReadCloser, int64, error)
+
+func bytesBody(data []byte) bodyFunc {
+	return func() (io.ReadCloser, int64, error) {
+		return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+	}
+}
+
+func fileBody(path string) bodyFunc {
+	return func() (io.ReadCloser, int64, error) {
+		//nolint:gosec // path is the layer file written by this package.
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, 0, err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, 0, err
+		}
+		return f, info.Size(), nil
+	}
+}
+
+// pushBlob uploads a blob unless the registry already has it.
+func (r *registry) pushBlob(ctx context.Context, desc descriptor, body bodyFunc) error {
+	blobURL := r.endpoint("blobs", desc.Digest)
+	resp, err := r.do(ctx, http.MethodHead, blobURL, "", nil)
+	if err != nil {
+		return err
+	}
+	drain(resp)
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	uploadURL := r.endpoint("blobs", "uploads") + "/"
+	resp, err = r.do(ctx, http.MethodPost, uploadURL, "", nil)
+	if err != nil {
+		return err
+	}
+	drain(resp)
+	if resp.StatusCode != http.StatusAccepted {
+		return statusError(http.MethodPost, uploadURL, resp)
+	}
+	location, err := r.base.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.Header.Get("Location") == "" {
+		return fmt.Errorf("%w: missing upload location", helpers.ErrRegistryProtocol)
+	}
+	query := location.Query()
+	query.Set("digest", desc.Digest)
+	location.RawQuery = query.Encode()
+
+	resp, err = r.do(ctx, http.MethodPut, location.String(), "application/octet-stream", body)
+	if err != nil {
+		return err
+	}
+	drain(resp)
+	if resp.StatusCode != http.StatusCreated {
+		return statusError(http.MethodPut, location.String(), resp)
+	}
+	return nil
+}
+
+// pushManifest uploads the image manifest under tag.
+func (r *registry) pushManifest(ctx context.Context, tag string, manifest []byte) error {
+	manifestURL := r.endpoint("manifests", tag)
+	resp, err := r.do(ctx, http.MethodPut, manifestURL, mediaTypeManifest, bytesBody(manifest))
+	if err != nil {
+		return err
+	}
+	drain(resp)
+	if resp.StatusCode != http.StatusCreated {
+		return statusError(http.MethodPut, manifestURL, resp)
+	}
+	return nil
+}
+
+func (r *registry) endpoint(kind, name string) string {
+	return r.base.JoinPath("v2", r.repo, kind, name).String()
+}
+
+// do sends a request, answering a single 401 challenge with Basic or Bearer credentials.
+func (r *registry) do(ctx context.Context, method, target, contentType string, body bodyFunc) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			rc, size, err := body()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = rc
+			req.ContentLength = size
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if r.auth != "" {
+			req.Header.Set("Authorization", r.auth)
+		}
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized {
+			return resp, nil
+		}
+		if attempt > 0 {
+			drain(resp)
+			return nil, fmt.Errorf("%w: %w", helpers.ErrRegistryAuth, statusError(method, target, resp))
+		}
+		challenge := resp.Header.Get("WWW-Authenticate")
+		drain(resp)
+		if err := r.authorize(ctx, challenge); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// authorize resolves a WWW-Authenticate challenge into an Authorization header.
+func (r *registry) authorize(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if r.username == "" {
+			return fmt.Errorf("%w: registry requires credentials", helpers.ErrRegistryAuth)
+		}
+		r.auth = "Basic " + basicCredentials(r.username, r.password)
+		return nil
+	case "bearer":
+		return r.fetchToken(ctx, params)
+	default:
+		return fmt.Errorf("%w: unsupported challenge %q", helpers.ErrRegistryAuth, challenge)
+	}
+}
+
+func (r *registry) fetchToken(ctx context.Context, params map[string]string) error {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return fmt.Errorf("%w: bearer challenge without realm", helpers.ErrRegistryAuth)
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", "repository:"+r.repo+":pull,push")
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer drain(resp)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %w", helpers.ErrRegistryAuth, statusError(http.MethodGet, realm.String(), resp))
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("%w: %w", helpers.ErrRegistryAuth, err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return fmt.Errorf("%w: empty token", helpers.ErrRegistryAuth)
+	}
+	r.auth = "Bearer " + token.Token
+	return nil
+}
+
+// parseChallenge splits a WWW-Authenticate header into its scheme and parameters.
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := make(map[string]string)
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key != "" {
+			params[strings.ToLower(strings.TrimSpace(key))] = value
+		}
+	}
+	return strings.ToLower(scheme), params
+}
+
+func basicCredentials(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}
+
+func statusError(method, target string, resp *http.Response) error {
+	return &StatusError{Method: method, URL: target, Status: resp.Status, Code: resp.StatusCode}
+}
+
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
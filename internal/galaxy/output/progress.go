@@ -1,6 +1,9 @@
 package output
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 // Printer defines the progress output interface.
 type Printer interface {
@@ -12,6 +15,29 @@ type Printer interface {
 	DebugSincef(startTime time.Time, format string, args ...any)
 }
 
+// Redactor masks credentials in text; printers that redact their own output implement it.
+type Redactor interface {
+	Redact(text string) string
+}
+
+// RedactWriter returns a writer that masks credentials before writing to w.
+// Each Write is redacted on its own, so callers should write whole lines or documents.
+func RedactWriter(w io.Writer, r Redactor) io.Writer {
+	return redactWriter{w: w, r: r}
+}
+
+type redactWriter struct {
+	w io.Writer
+	r Redactor
+}
+
+func (rw redactWriter) Write(payload []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, rw.r.Redact(string(payload))); err != nil {
+		return 0, err
+	}
+	return len(payload), nil
+}
+
 // Printf proxies formatted output to the printer.
 func Printf(printer Printer, format string, args ...any) {
 	printer.Printf(format, args...)
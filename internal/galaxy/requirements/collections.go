@@ -20,60 +20,115 @@ type CollectionRequirement struct {
 	Source     string
 	Type       string
 	Signatures []string
+	// InstallPath is an optional collections path for this entry, as written in the file.
+	InstallPath string
+	// When is an optional condition on the runner platform; see Select.
+	When string
+}
+
+// PositionError locates a requirements problem in the source file.
+type PositionError struct {
+	Line   int
+	Column int
+	Key    string
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *PositionError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("line %d, column %d: key %q: %v", e.Line, e.Column, e.Key, e.Err)
+	}
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// collectionKeys lists the keys a collection entry may use.
+func collectionKeys() map[string]bool {
+	return map[string]bool{
+		"name": true, "namespace": true, "version": true, "source": true, "type": true, "signatures": true, "install_path": true, "when": true,
+	}
+}
+
+// topLevelKeys lists the keys a requirements mapping may use.
+func topLevelKeys() map[string]bool {
+	return map[string]bool{"collections": true, "roles": true}
 }
 
 // LoadCollections reads and parses requirements from a file.
-func LoadCollections(path, defaultSource string) (Collections, bool, error) {
+// With strict set, unknown keys are reported as errors instead of being ignored.
+func LoadCollections(path, defaultSource string, strict bool) (Collections, bool, error) {
 	//nolint:gosec // path is user-provided requirements file.
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, false, err
 	}
-	return ParseCollections(data, defaultSource)
+	cols, rolesFound, err := ParseCollections(data, defaultSource, strict)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", path, err)
+	}
+	return cols, rolesFound, nil
 }
 
 // ParseCollections parses requirements data and returns collections and roles flag.
-func ParseCollections(data []byte, defaultSource string) (Collections, bool, error) {
-	var raw any
-	if err := yaml.Unmarshal(data, &raw); err != nil {
+func ParseCollections(data []byte, defaultSource string, strict bool) (Collections, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
 		return nil, false, err
 	}
-	return parseCollectionsRaw(raw, defaultSource)
+	if len(doc.Content) == 0 {
+		return nil, false, helpers.ErrUnsupportedRequirementsFormat
+	}
+	return parseCollectionsNode(resolveAlias(doc.Content[0]), defaultSource, strict)
 }
 
-// parseCollectionsRaw parses a decoded requirements payload.
-func parseCollectionsRaw(raw any, defaultSource string) (Collections, bool, error) {
-	switch v := raw.(type) {
-	case map[string]any:
+// parseCollectionsNode parses the root node of a requirements document.
+func parseCollectionsNode(root *yaml.Node, defaultSource string, strict bool) (Collections, bool, error) {
+	switch root.Kind {
+	case yaml.MappingNode:
+		var collectionsNode *yaml.Node
 		rolesFound := false
-		if _, ok := v["roles"]; ok {
-			rolesFound = true
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			key, value := root.Content[i], resolveAlias(root.Content[i+1])
+			if strict && !topLevelKeys()[key.Value] {
+				return nil, false, positionError(key, key.Value, helpers.ErrUnknownRequirementsKey)
+			}
+			switch key.Value {
+			case "roles":
+				rolesFound = true
+			case "collections":
+				collectionsNode = value
+			}
 		}
-		if collectionsRaw, ok := v["collections"]; ok {
-			cols, err := parseCollectionList(collectionsRaw, defaultSource)
+		if collectionsNode != nil {
+			cols, err := parseCollectionList(collectionsNode, defaultSource, strict)
 			return cols, rolesFound, err
 		}
 		if rolesFound {
 			return nil, rolesFound, nil
 		}
-		return nil, rolesFound, helpers.ErrUnsupportedRequirementsFormat
-	case []any:
-		cols, err := parseCollectionList(v, defaultSource)
+		return nil, rolesFound, positionError(root, "", fmt.Errorf("%w: expected a %q or %q key",
+			helpers.ErrUnsupportedRequirementsFormat, "collections", "roles"))
+	case yaml.SequenceNode:
+		cols, err := parseCollectionList(root, defaultSource, strict)
 		return cols, false, err
 	default:
-		return nil, false, helpers.ErrUnsupportedRequirementsFormat
+		return nil, false, positionError(root, "", helpers.ErrUnsupportedRequirementsFormat)
 	}
 }
 
 // parseCollectionList parses a list of collection items.
-func parseCollectionList(raw any, defaultSource string) (Collections, error) {
-	list, ok := raw.([]any)
-	if !ok {
-		return nil, helpers.ErrInvalidCollectionsList
+func parseCollectionList(list *yaml.Node, defaultSource string, strict bool) (Collections, error) {
+	if list.Kind != yaml.SequenceNode {
+		return nil, positionError(list, "collections", helpers.ErrInvalidCollectionsList)
 	}
-	items := make(Collections, 0, len(list))
-	for _, item := range list {
-		req, err := parseCollectionItem(item, defaultSource)
+	items := make(Collections, 0, len(list.Content))
+	for _, item := range list.Content {
+		req, err := parseCollectionItem(resolveAlias(item), defaultSource, strict)
 		if err != nil {
 			return nil, err
 		}
@@ -83,15 +138,49 @@ func parseCollectionList(raw any, defaultSource string) (Collections, error) {
 }
 
 // parseCollectionItem parses a single collection entry.
-func parseCollectionItem(item any, defaultSource string) (CollectionRequirement, error) {
-	switch v := item.(type) {
-	case string:
-		return parseCollectionStringItem(v, defaultSource)
-	case map[string]any:
-		return parseCollectionMapItem(v, defaultSource)
+func parseCollectionItem(item *yaml.Node, defaultSource string, strict bool) (CollectionRequirement, error) {
+	switch item.Kind {
+	case yaml.ScalarNode:
+		req, err := parseCollectionStringItem(item.Value, defaultSource)
+		if err != nil {
+			return CollectionRequirement{}, positionError(item, "", err)
+		}
+		return req, nil
+	case yaml.MappingNode:
+		value := make(map[string]any, len(item.Content)/2)
+		allowed := collectionKeys()
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			key := item.Content[i]
+			if strict && !allowed[key.Value] {
+				return CollectionRequirement{}, positionError(key, key.Value, helpers.ErrUnknownRequirementsKey)
+			}
+			var decoded any
+			if err := item.Content[i+1].Decode(&decoded); err != nil {
+				return CollectionRequirement{}, positionError(item.Content[i+1], key.Value, err)
+			}
+			value[key.Value] = decoded
+		}
+		req, err := parseCollectionMapItem(value, defaultSource)
+		if err != nil {
+			return CollectionRequirement{}, positionError(item, "", err)
+		}
+		return req, nil
 	default:
-		return CollectionRequirement{}, fmt.Errorf("%w: %v", helpers.ErrUnsupportedCollectionFormat, item)
+		return CollectionRequirement{}, positionError(item, "", fmt.Errorf("%w: expected a name or a mapping",
+			helpers.ErrUnsupportedCollectionFormat))
+	}
+}
+
+// resolveAlias follows YAML aliases to the node they reference.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
 	}
+	return node
+}
+
+func positionError(node *yaml.Node, key string, err error) error {
+	return &PositionError{Line: node.Line, Column: node.Column, Key: key, Err: err}
 }
 
 func parseCollectionStringItem(value string, defaultSource string) (CollectionRequirement, error) {
@@ -99,6 +188,9 @@ func parseCollectionStringItem(value string, defaultSource string) (CollectionRe
 	if name == "" {
 		return CollectionRequirement{}, helpers.ErrEmptyCollectionName
 	}
+	if isGitName(name) {
+		return CollectionRequirement{Name: name, Version: "*", Type: "git"}, nil
+	}
 	if looksLikeSourceName(name) {
 		return CollectionRequirement{}, fmt.Errorf("%w %q (only Galaxy API sources are supported)", helpers.ErrUnsupportedCollectionSource, name)
 	}
@@ -116,6 +208,9 @@ func parseCollectionStringItem(value string, defaultSource string) (CollectionRe
 
 func parseCollectionMapItem(value map[string]any, defaultSource string) (CollectionRequirement, error) {
 	req := parseCollectionMapFields(value)
+	if req.Type == "" && isGitName(req.Name) {
+		req.Type = "git"
+	}
 	req = normalizeCollectionName(req)
 	return finalizeCollectionRequirement(req, defaultSource, value)
 }
@@ -140,6 +235,12 @@ func parseCollectionMapFields(value map[string]any) CollectionRequirement {
 	if raw, ok := value["version"]; ok {
 		req.Version = strings.TrimSpace(fmt.Sprint(raw))
 	}
+	if raw, ok := value["install_path"].(string); ok {
+		req.InstallPath = strings.TrimSpace(raw)
+	}
+	if raw, ok := value["when"]; ok {
+		req.When = strings.TrimSpace(fmt.Sprint(raw))
+	}
 	return req
 }
 
@@ -174,15 +275,17 @@ func validateRequirement(req CollectionRequirement, raw any) error {
 	if req.Name == "" {
 		return fmt.Errorf("%w: %v", helpers.ErrInvalidCollectionEntry, raw)
 	}
-	if req.Type == "git" || req.Type == "url" {
-		return fmt.Errorf("%w %q (only galaxy is supported)", helpers.ErrUnsupportedCollectionType, req.Type)
-	}
-	if req.Type != "" && req.Type != "galaxy" {
-		return fmt.Errorf("%w %q (only galaxy is supported)", helpers.ErrUnsupportedCollectionType, req.Type)
+	if req.Type != "" && req.Type != "galaxy" && req.Type != "git" {
+		return fmt.Errorf("%w %q (only galaxy and git are supported)", helpers.ErrUnsupportedCollectionType, req.Type)
 	}
 	if req.Type == "" && looksLikeSourceName(req.Name) {
 		return fmt.Errorf("%w %q (only Galaxy API sources are supported)", helpers.ErrUnsupportedCollectionSource, req.Name)
 	}
+	if req.When != "" {
+		if _, err := parseWhen(req.When); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -239,6 +342,11 @@ func parseStringList(value any) []string {
 	}
 }
 
+// isGitName reports whether name is a git+ URL, which implies type: git.
+func isGitName(name string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(name)), "git+")
+}
+
 // looksLikeSourceName reports whether the value looks like a URL or path.
 func looksLikeSourceName(value string) bool {
 	trimmed := strings.TrimSpace(value)
@@ -10,7 +10,7 @@ import (
 func TestParseCollectionsStringList(t *testing.T) {
 	t.Parallel()
 	input := "- community.general\n- ansible.posix\n"
-	collections, rolesFound, err := ParseCollections([]byte(input), "https://default")
+	collections, rolesFound, err := ParseCollections([]byte(input), "https://default", false)
 	if err != nil {
 		t.Fatalf("ParseCollections error: %v", err)
 	}
@@ -34,7 +34,7 @@ func TestParseCollectionsStringList(t *testing.T) {
 func TestParseCollectionsRolesOnly(t *testing.T) {
 	t.Parallel()
 	input := "roles:\n  - geerlingguy.foo\n"
-	collections, rolesFound, err := ParseCollections([]byte(input), "https://default")
+	collections, rolesFound, err := ParseCollections([]byte(input), "https://default", false)
 	if err != nil {
 		t.Fatalf("ParseCollections error: %v", err)
 	}
@@ -49,7 +49,7 @@ func TestParseCollectionsRolesOnly(t *testing.T) {
 func TestParseCollectionsUnsupportedFormat(t *testing.T) {
 	t.Parallel()
 	input := "foo: bar\n"
-	_, _, err := ParseCollections([]byte(input), "https://default")
+	_, _, err := ParseCollections([]byte(input), "https://default", false)
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -61,7 +61,7 @@ func TestParseCollectionsUnsupportedFormat(t *testing.T) {
 func TestParseCollectionsUnsupportedSource(t *testing.T) {
 	t.Parallel()
 	input := "- https://example.com/collections\n"
-	_, _, err := ParseCollections([]byte(input), "https://default")
+	_, _, err := ParseCollections([]byte(input), "https://default", false)
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -69,3 +69,52 @@ func TestParseCollectionsUnsupportedSource(t *testing.T) {
 		t.Fatalf("expected ErrUnsupportedCollectionSource, got %v", err)
 	}
 }
+
+func TestParseCollectionsGit(t *testing.T) {
+	t.Parallel()
+	input := "collections:\n  - name: https://github.com/org/repo.git\n    type: git\n    version: devel\n" +
+		"  - git+https://github.com/org/other.git\n"
+	collections, _, err := ParseCollections([]byte(input), "https://default", false)
+	if err != nil {
+		t.Fatalf("ParseCollections error: %v", err)
+	}
+	if len(collections) != 2 {
+		t.Fatalf("expected 2 collections, got %d", len(collections))
+	}
+	got := collections[0]
+	if got.Type != "git" || got.Name != "https://github.com/org/repo.git" || got.Version != "devel" || got.Source != "" {
+		t.Fatalf("unexpected collection[0]: %#v", got)
+	}
+	if got = collections[1]; got.Type != "git" || got.Name != "git+https://github.com/org/other.git" || got.Version != "*" {
+		t.Fatalf("unexpected collection[1]: %#v", got)
+	}
+}
+
+func TestParseCollectionsReportsPosition(t *testing.T) {
+	t.Parallel()
+	input := "collections:\n  - name: community.general\n  - name: https://example.com/acme-tools-1.0.0.tar.gz\n    type: url\n"
+	_, _, err := ParseCollections([]byte(input), "https://default", false)
+	if !errors.Is(err, helpers.ErrUnsupportedCollectionType) {
+		t.Fatalf("expected ErrUnsupportedCollectionType, got %v", err)
+	}
+	var posErr *PositionError
+	if !errors.As(err, &posErr) || posErr.Line != 3 || posErr.Column != 5 {
+		t.Fatalf("expected error at line 3, column 5, got %v", err)
+	}
+}
+
+func TestParseCollectionsStrictUnknownKey(t *testing.T) {
+	t.Parallel()
+	input := "collections:\n  - name: community.general\n    verison: 1.0.0\n"
+	if _, _, err := ParseCollections([]byte(input), "https://default", false); err != nil {
+		t.Fatalf("non-strict parse should ignore unknown keys: %v", err)
+	}
+	_, _, err := ParseCollections([]byte(input), "https://default", true)
+	if !errors.Is(err, helpers.ErrUnknownRequirementsKey) {
+		t.Fatalf("expected ErrUnknownRequirementsKey, got %v", err)
+	}
+	var posErr *PositionError
+	if !errors.As(err, &posErr) || posErr.Key != "verison" || posErr.Line != 3 {
+		t.Fatalf("expected key verison at line 3, got %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package requirements
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	"gopkg.in/yaml.v3"
+)
+
+// Input formats recognized by Migrate.
+const (
+	// FormatRequirements is a requirements.yml, possibly in its bare list form.
+	FormatRequirements = "requirements"
+	// FormatBuilder is an ansible-builder execution environment definition.
+	FormatBuilder = "ansible-builder"
+	// FormatText is a plain text list with one collection per line.
+	FormatText = "text"
+)
+
+// Migration is the outcome of converting a legacy requirements input.
+type Migration struct {
+	// Format is the input format that was recognized.
+	Format string
+	// Collections are the collections found in the input.
+	Collections Collections
+	// RolesFound reports roles in the input, which are not migrated.
+	RolesFound bool
+}
+
+// Migrate reads the requirements input at path, recognizes its format and
+// returns the collections it names. ansible-builder definitions are followed
+// to the requirements file their dependencies.galaxy points to.
+func Migrate(path string) (Migration, error) {
+	//nolint:gosec // path is a user-provided requirements input.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Migration{}, err
+	}
+	m, err := migrateData(data, filepath.Dir(path))
+	if err != nil {
+		return Migration{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// migrateData converts data; relative paths in it resolve against baseDir.
+func migrateData(data []byte, baseDir string) (Migration, error) {
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) == nil && len(doc.Content) > 0 {
+		root := resolveAlias(doc.Content[0])
+		if galaxy := builderGalaxyNode(root); galaxy != nil {
+			return migrateBuilder(galaxy, baseDir)
+		}
+		// "ns.name: 1.2.3" lines read as a mapping too; only a mapping with
+		// requirements keys is a requirements file.
+		isRequirements := mappingValue(root, "collections") != nil || mappingValue(root, "roles") != nil
+		if isRequirements || root.Kind == yaml.SequenceNode {
+			cols, rolesFound, err := parseCollectionsNode(root, "", false)
+			if err != nil {
+				return Migration{}, err
+			}
+			return Migration{Format: FormatRequirements, Collections: cols, RolesFound: rolesFound}, nil
+		}
+	}
+	cols, err := parseTextList(data)
+	if err != nil {
+		return Migration{}, err
+	}
+	return Migration{Format: FormatText, Collections: cols}, nil
+}
+
+// builderGalaxyNode returns dependencies.galaxy of an ansible-builder
+// definition, or nil when root is not one.
+func builderGalaxyNode(root *yaml.Node) *yaml.Node {
+	deps := mappingValue(root, "dependencies")
+	if deps == nil {
+		return nil
+	}
+	return mappingValue(deps, "galaxy")
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolveAlias(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+// migrateBuilder converts dependencies.galaxy: a path to a requirements
+// file (all schema versions) or the requirements inline (version 3).
+func migrateBuilder(galaxy *yaml.Node, baseDir string) (Migration, error) {
+	if galaxy.Kind == yaml.ScalarNode {
+		path := galaxy.Value
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		cols, rolesFound, err := LoadCollections(path, "", false)
+		if err != nil {
+			return Migration{}, err
+		}
+		return Migration{Format: FormatBuilder, Collections: cols, RolesFound: rolesFound}, nil
+	}
+	cols, rolesFound, err := parseCollectionsNode(galaxy, "", false)
+	if err != nil {
+		return Migration{}, err
+	}
+	return Migration{Format: FormatBuilder, Collections: cols, RolesFound: rolesFound}, nil
+}
+
+// parseTextList parses one collection per line, optionally followed by a
+// version as in "ns.name==1.2.3", "ns.name:>=1.0.0" or "ns.name 1.2.3".
+// Blank lines and # comments are skipped.
+func parseTextList(data []byte) (Collections, error) {
+	var cols Collections
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		req, err := parseTextLine(text)
+		if err != nil {
+			return nil, &PositionError{Line: line, Column: 1, Err: err}
+		}
+		cols = append(cols, req)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("%w: no collections found", helpers.ErrUnsupportedRequirementsFormat)
+	}
+	return cols, nil
+}
+
+// parseTextLine parses one non-empty line of a text list.
+func parseTextLine(text string) (CollectionRequirement, error) {
+	end := strings.IndexFunc(text, func(r rune) bool {
+		return r != '.' && r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9')
+	})
+	name, version := text, ""
+	if end >= 0 {
+		name = text[:end]
+		version = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text[end:]), ":"))
+	}
+	namespace, collection, ok := helpers.SplitFQDN(name)
+	if !ok {
+		return CollectionRequirement{}, fmt.Errorf("%w: %q", helpers.ErrInvalidCollectionName, text)
+	}
+	version = strings.ReplaceAll(version, " ", "")
+	if version == "" {
+		version = "*"
+	}
+	return CollectionRequirement{Namespace: namespace, Name: collection, Version: version}, nil
+}
+
+// requirementsDocument is the requirements.yml written by MarshalCollections.
+type requirementsDocument struct {
+	Collections []requirementsEntry `yaml:"collections"`
+}
+
+// requirementsEntry is one collection of a written requirements.yml.
+type requirementsEntry struct {
+	Name        string   `yaml:"name"`
+	Version     string   `yaml:"version,omitempty"`
+	Source      string   `yaml:"source,omitempty"`
+	Type        string   `yaml:"type,omitempty"`
+	Signatures  []string `yaml:"signatures,omitempty"`
+	InstallPath string   `yaml:"install_path,omitempty"`
+	When        string   `yaml:"when,omitempty"`
+}
+
+// MarshalCollections writes cols as a requirements.yml. Versions of "*"
+// are left out, as they are the default.
+func MarshalCollections(cols Collections) ([]byte, error) {
+	doc := requirementsDocument{Collections: make([]requirementsEntry, 0, len(cols))}
+	for _, col := range cols {
+		entry := requirementsEntry{
+			Name:        col.Name,
+			Version:     col.Version,
+			Source:      col.Source,
+			Type:        col.Type,
+			Signatures:  col.Signatures,
+			InstallPath: col.InstallPath,
+			When:        col.When,
+		}
+		if col.Namespace != "" {
+			entry.Name = col.Namespace + "." + col.Name
+		}
+		if entry.Version == "*" {
+			entry.Version = ""
+		}
+		doc.Collections = append(doc.Collections, entry)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
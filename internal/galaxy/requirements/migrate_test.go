@@ -0,0 +1,79 @@
+package requirements
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+func TestMigrateTextList(t *testing.T) {
+	t.Parallel()
+	input := "# legacy list\ncommunity.general==8.1.0\nansible.posix:>=1.5.0, <2.0.0\n\ncommunity.docker 3.4.0  # pinned\nkubernetes.core\n"
+	m, err := migrateData([]byte(input), ".")
+	if err != nil {
+		t.Fatalf("migrateData: %v", err)
+	}
+	if m.Format != FormatText {
+		t.Fatalf("expected text format, got %q", m.Format)
+	}
+	data, err := MarshalCollections(m.Collections)
+	if err != nil {
+		t.Fatalf("MarshalCollections: %v", err)
+	}
+	want := `collections:
+  - name: community.general
+    version: ==8.1.0
+  - name: ansible.posix
+    version: '>=1.5.0,<2.0.0'
+  - name: community.docker
+    version: 3.4.0
+  - name: kubernetes.core
+`
+	if string(data) != want {
+		t.Fatalf("unexpected requirements:\n%s", data)
+	}
+	if _, _, err := ParseCollections(data, "https://default", true); err != nil {
+		t.Fatalf("migrated requirements do not parse: %v", err)
+	}
+}
+
+func TestMigrateTextListRejectsBadName(t *testing.T) {
+	t.Parallel()
+	_, err := migrateData([]byte("community.general\nnot-a-collection\n"), ".")
+	var posErr *PositionError
+	if !errors.As(err, &posErr) || posErr.Line != 2 || !errors.Is(err, helpers.ErrInvalidCollectionName) {
+		t.Fatalf("expected ErrInvalidCollectionName on line 2, got %v", err)
+	}
+}
+
+func TestMigrateBuilderDefinition(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	reqs := "collections:\n  - name: community.general\n    version: 8.1.0\nroles:\n  - geerlingguy.docker\n"
+	if err := os.WriteFile(filepath.Join(dir, "requirements.yml"), []byte(reqs), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	byPath := filepath.Join(dir, "execution-environment.yml")
+	if err := os.WriteFile(byPath, []byte("version: 1\ndependencies:\n  galaxy: requirements.yml\n  python: requirements.txt\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	m, err := Migrate(byPath)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if m.Format != FormatBuilder || !m.RolesFound || len(m.Collections) != 1 || m.Collections[0].Version != "8.1.0" {
+		t.Fatalf("unexpected migration: %+v", m)
+	}
+
+	inline := "version: 3\ndependencies:\n  galaxy:\n    collections:\n      - ansible.posix\n      - name: community.docker\n        version: '>=3.0.0'\n"
+	m, err = migrateData([]byte(inline), dir)
+	if err != nil {
+		t.Fatalf("migrateData: %v", err)
+	}
+	if m.Format != FormatBuilder || len(m.Collections) != 2 || m.Collections[1].Version != ">=3.0.0" {
+		t.Fatalf("unexpected migration: %+v", m)
+	}
+}
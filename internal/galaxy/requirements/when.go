@@ -0,0 +1,316 @@
+package requirements
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+// Environment is what a when: condition is evaluated against.
+type Environment struct {
+	// OS and Arch use Go's names, e.g. linux, darwin, amd64, arm64.
+	OS     string
+	Arch   string
+	Getenv func(key string) string
+}
+
+// CurrentEnvironment describes the machine go-galaxy runs on.
+func CurrentEnvironment() Environment {
+	return Environment{OS: runtime.GOOS, Arch: runtime.GOARCH, Getenv: os.Getenv}
+}
+
+// Select returns the collections whose when: condition holds in env;
+// entries without one are always kept.
+func Select(cols Collections, env Environment) (Collections, error) {
+	selected := make(Collections, 0, len(cols))
+	for _, col := range cols {
+		if col.When == "" {
+			selected = append(selected, col)
+			continue
+		}
+		cond, err := parseWhen(col.When)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", col.Namespace, col.Name, err)
+		}
+		if cond(env) {
+			selected = append(selected, col)
+		}
+	}
+	return selected, nil
+}
+
+// condition is a compiled when: expression.
+type condition func(env Environment) bool
+
+// parseWhen compiles a when: expression. The grammar is
+//
+//	expr    = and { "or" and }
+//	and     = unary { "and" unary }
+//	unary   = "not" unary | "(" expr ")" | test
+//	test    = operand [ ("==" | "!=") value | [ "not" ] "in" "[" value { "," value } "]" ]
+//	operand = "os" | "arch" | "env." NAME
+//
+// Values are quoted or bare words. A bare env.NAME is true when the
+// variable is set to a non-empty value.
+func parseWhen(expr string) (condition, error) {
+	tokens, err := tokenizeWhen(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", helpers.ErrInvalidWhenCondition, expr, err)
+	}
+	p := &whenParser{tokens: tokens}
+	cond, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", helpers.ErrInvalidWhenCondition, expr, err)
+	}
+	return cond, nil
+}
+
+// whenToken is a word, a quoted string or one of the symbols == != ( ) [ ] ,.
+type whenToken struct {
+	text   string
+	quoted bool
+}
+
+// tokenizeWhen splits a when: expression into tokens.
+func tokenizeWhen(expr string) ([]whenToken, error) {
+	var tokens []whenToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '=' || c == '!':
+			if i+1 >= len(expr) || expr[i+1] != '=' {
+				return nil, fmt.Errorf("unexpected %q", string(c))
+			}
+			tokens = append(tokens, whenToken{text: expr[i : i+2]})
+			i += 2
+		case strings.IndexByte("()[],", c) >= 0:
+			tokens = append(tokens, whenToken{text: string(c)})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string %s", expr[i:])
+			}
+			tokens = append(tokens, whenToken{text: expr[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		default:
+			start := i
+			for i < len(expr) && isWhenWordByte(expr[i]) {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("unexpected %q", string(c))
+			}
+			tokens = append(tokens, whenToken{text: expr[start:i]})
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	return tokens, nil
+}
+
+// isWhenWordByte reports whether c may appear in a bare word.
+func isWhenWordByte(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	default:
+		return c == '_' || c == '.' || c == '-'
+	}
+}
+
+// whenParser is a recursive descent parser over the tokens of one expression.
+type whenParser struct {
+	tokens []whenToken
+	pos    int
+}
+
+// peek reports whether the next token is the unquoted word or symbol text.
+func (p *whenParser) peek(text string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && p.tokens[p.pos].text == text
+}
+
+// next consumes and returns the next token.
+func (p *whenParser) next() (whenToken, error) {
+	if p.pos >= len(p.tokens) {
+		return whenToken{}, fmt.Errorf("unexpected end of condition")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	return tok, nil
+}
+
+// expect consumes the unquoted word or symbol text.
+func (p *whenParser) expect(text string) error {
+	if !p.peek(text) {
+		if p.pos >= len(p.tokens) {
+			return fmt.Errorf("expected %q at end of condition", text)
+		}
+		return fmt.Errorf("expected %q, got %q", text, p.tokens[p.pos].text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *whenParser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(env Environment) bool { return l(env) || right(env) }
+	}
+	return left, nil
+}
+
+func (p *whenParser) parseAnd() (condition, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(env Environment) bool { return l(env) && right(env) }
+	}
+	return left, nil
+}
+
+func (p *whenParser) parseUnary() (condition, error) {
+	switch {
+	case p.peek("not"):
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(env Environment) bool { return !inner(env) }, nil
+	case p.peek("("):
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	default:
+		return p.parseTest()
+	}
+}
+
+// parseTest parses a comparison, a membership test or a bare env.NAME.
+func (p *whenParser) parseTest() (condition, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	operand, err := whenOperand(tok)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.peek("==") || p.peek("!="):
+		op, _ := p.next()
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		equal := op.text == "=="
+		return func(env Environment) bool { return (operand(env) == value) == equal }, nil
+	case p.peek("in") || p.peek("not"):
+		negate := p.peek("not")
+		if negate {
+			p.pos++
+		}
+		if err := p.expect("in"); err != nil {
+			return nil, err
+		}
+		values, err := p.list()
+		if err != nil {
+			return nil, err
+		}
+		return func(env Environment) bool { return slices.Contains(values, operand(env)) != negate }, nil
+	case strings.HasPrefix(tok.text, "env."):
+		return func(env Environment) bool { return operand(env) != "" }, nil
+	default:
+		return nil, fmt.Errorf("%q needs a comparison", tok.text)
+	}
+}
+
+// whenOperand returns the lookup of os, arch or env.NAME.
+func whenOperand(tok whenToken) (func(env Environment) string, error) {
+	if tok.quoted {
+		return nil, fmt.Errorf("expected os, arch or env.NAME, got %q", tok.text)
+	}
+	switch name, isEnv := strings.CutPrefix(tok.text, "env."); {
+	case tok.text == "os":
+		return func(env Environment) string { return env.OS }, nil
+	case tok.text == "arch":
+		return func(env Environment) string { return env.Arch }, nil
+	case isEnv && name != "":
+		return func(env Environment) string {
+			if env.Getenv == nil {
+				return ""
+			}
+			return env.Getenv(name)
+		}, nil
+	default:
+		return nil, fmt.Errorf("expected os, arch or env.NAME, got %q", tok.text)
+	}
+}
+
+// value parses a quoted string or a bare word.
+func (p *whenParser) value() (string, error) {
+	tok, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if !tok.quoted && (tok.text == "" || !isWhenWordByte(tok.text[0])) {
+		return "", fmt.Errorf("expected a value, got %q", tok.text)
+	}
+	return tok.text, nil
+}
+
+// list parses a bracketed, comma-separated list of values.
+func (p *whenParser) list() ([]string, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	var values []string
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if p.peek("]") {
+			p.pos++
+			return values, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
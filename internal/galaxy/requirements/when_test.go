@@ -0,0 +1,71 @@
+package requirements
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+func TestParseWhen(t *testing.T) {
+	t.Parallel()
+	env := Environment{OS: "linux", Arch: "arm64", Getenv: func(key string) string {
+		return map[string]string{"CI": "true"}[key]
+	}}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`os == "linux"`, true},
+		{`os != linux`, false},
+		{`arch in ["amd64", 'arm64']`, true},
+		{`arch not in [amd64]`, true},
+		{`env.CI`, true},
+		{`env.MISSING`, false},
+		{`env.CI == "true" and os == darwin`, false},
+		{`os == darwin or env.CI and arch == arm64`, true},
+		{`not (os == darwin or arch == amd64)`, true},
+	}
+	for _, tt := range tests {
+		cond, err := parseWhen(tt.expr)
+		if err != nil {
+			t.Fatalf("parseWhen(%q): %v", tt.expr, err)
+		}
+		if got := cond(env); got != tt.want {
+			t.Fatalf("%q: expected %v, got %v", tt.expr, tt.want, got)
+		}
+	}
+	for _, expr := range []string{``, `os`, `os ==`, `os = linux`, `platform == linux`, `(os == linux`, `os == linux and`, `arch in [amd64`} {
+		if _, err := parseWhen(expr); !errors.Is(err, helpers.ErrInvalidWhenCondition) {
+			t.Fatalf("parseWhen(%q): expected ErrInvalidWhenCondition, got %v", expr, err)
+		}
+	}
+}
+
+func TestSelectFiltersByWhen(t *testing.T) {
+	t.Parallel()
+	input := `collections:
+  - name: a.always
+  - name: a.linux
+    when: os == "linux"
+  - name: a.darwin
+    when: os == "darwin"
+`
+	cols, _, err := ParseCollections([]byte(input), "https://default", true)
+	if err != nil {
+		t.Fatalf("ParseCollections: %v", err)
+	}
+	selected, err := Select(cols, Environment{OS: "linux", Arch: "amd64"})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if len(selected) != 2 || selected[0].Name != "always" || selected[1].Name != "linux" {
+		t.Fatalf("expected always and linux, got %+v", selected)
+	}
+
+	_, _, err = ParseCollections([]byte("- name: a.b\n  when: os ==\n"), "https://default", false)
+	var posErr *PositionError
+	if !errors.Is(err, helpers.ErrInvalidWhenCondition) || !errors.As(err, &posErr) || posErr.Line != 1 {
+		t.Fatalf("expected a positioned ErrInvalidWhenCondition, got %v", err)
+	}
+}
@@ -32,7 +32,7 @@ func ClearCacheFiles(cacheDir string) error {
 			return err
 		}
 	}
-	return nil
+	return os.RemoveAll(filepath.Join(cacheDir, helpers.StoreBlobsDir))
 }
 
 func shouldDeleteCacheFile(name string) bool {
@@ -52,6 +52,7 @@ func isDeleteCacheName(name string) bool {
 		helpers.StoreSnapshotVersions,
 		helpers.StoreDBLock,
 		helpers.StoreDBLocal,
+		helpers.StoreCompletionFile,
 	}
 	return slices.Contains(deleteList, name)
 }
@@ -64,6 +65,9 @@ func isKeepCacheName(name string) bool {
 		helpers.StoreSnapshotRoots,
 		helpers.StoreSnapshotResolved,
 		helpers.StoreDBProjects,
+		helpers.StoreJournal,
+		helpers.StoreJournal + helpers.StoreJournalRetiredSuffix,
+		helpers.StoreJSONFile,
 	}
 
 	return slices.Contains(keepList, name)
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+// APICacheSummary describes a cached API entry without its body.
+type APICacheSummary struct {
+	URL          string        `json:"url"`
+	ETag         string        `json:"etag"`
+	LastModified string        `json:"last_modified"`
+	FetchedAt    time.Time     `json:"fetched_at"`
+	TTL          time.Duration `json:"ttl"`
+	Size         int           `json:"size"`
+}
+
+// DumpBuckets lists bucket names supported by Dump in output order.
+func DumpBuckets() []string {
+	return []string{
+		helpers.StoreBucketMeta,
+		helpers.StoreBucketResolved,
+		helpers.StoreBucketGraph,
+		helpers.StoreBucketInstalled,
+		helpers.StoreBucketRequirements,
+		helpers.StoreBucketRoots,
+		helpers.StoreBucketAPICache,
+		helpers.StoreBucketDepsCache,
+		helpers.StoreBucketVersions,
+	}
+}
+
+// Dump returns the selected buckets keyed by bucket name, filtered by key prefix.
+func (m *Store) Dump(buckets []string, prefix string) (map[string]any, error) {
+	if m == nil {
+		return nil, helpers.ErrStoreNil
+	}
+	if len(buckets) == 0 {
+		buckets = DumpBuckets()
+	}
+	data := m.snapshotData()
+	out := make(map[string]any, len(buckets))
+	for _, bucket := range buckets {
+		name := strings.TrimSpace(bucket)
+		switch name {
+		case helpers.StoreBucketMeta:
+			out[name] = data.Meta
+		case helpers.StoreBucketResolved:
+			out[name] = filterByPrefix(data.Resolved, prefix)
+		case helpers.StoreBucketGraph:
+			out[name] = filterByPrefix(data.Graph, prefix)
+		case helpers.StoreBucketInstalled:
+			out[name] = filterByPrefix(data.Installed, prefix)
+		case helpers.StoreBucketRequirements:
+			out[name] = filterByPrefix(data.Requirements, prefix)
+		case helpers.StoreBucketRoots:
+			out[name] = filterByPrefix(data.Roots, prefix)
+		case helpers.StoreBucketAPICache:
+			out[name] = summarizeAPICache(data.APICache, prefix)
+		case helpers.StoreBucketDepsCache:
+			out[name] = filterByPrefix(data.DepsCache, prefix)
+		case helpers.StoreBucketVersions:
+			out[name] = filterByPrefix(data.Versions, prefix)
+		default:
+			return nil, fmt.Errorf("%w: %q", helpers.ErrUnknownStoreBucket, name)
+		}
+	}
+	return out, nil
+}
+
+// filterByPrefix returns entries whose keys start with prefix.
+func filterByPrefix[T any](data map[string]T, prefix string) map[string]T {
+	if prefix == "" {
+		return data
+	}
+	out := make(map[string]T)
+	for key, value := range data {
+		if strings.HasPrefix(key, prefix) {
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// summarizeAPICache strips response bodies from API cache entries.
+// Entries match prefix by either cache key or URL.
+func summarizeAPICache(entries map[string]APICacheEntry, prefix string) map[string]APICacheSummary {
+	out := make(map[string]APICacheSummary, len(entries))
+	for key, entry := range entries {
+		if prefix != "" && !strings.HasPrefix(key, prefix) && !strings.HasPrefix(entry.URL, prefix) {
+			continue
+		}
+		out[key] = APICacheSummary{
+			URL:          entry.URL,
+			ETag:         entry.ETag,
+			LastModified: entry.LastModified,
+			FetchedAt:    entry.FetchedAt,
+			TTL:          entry.TTL,
+			Size:         len(entry.Body),
+		}
+	}
+	return out
+}
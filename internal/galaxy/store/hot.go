@@ -0,0 +1,126 @@
+package store
+
+import (
+	"container/list"
+	"hash/fnv"
+	"sync"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+// hotCache is a sharded LRU index over the store's lookup caches. Hits take
+// only their shard's lock, so many resolver workers reading the store do not
+// contend on the store mutex. Values are never mutated in place: writers
+// replace them, which lets readers share them without copying.
+// A nil hotCache caches nothing.
+type hotCache struct {
+	shards []*hotShard
+}
+
+// hotShard is one LRU partition of a hotCache.
+type hotShard struct {
+	mu      sync.Mutex
+	limit   int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// hotEntry is the list payload of a hotShard.
+type hotEntry struct {
+	key   string
+	value any
+}
+
+// newHotCache returns a hot cache of shards partitions holding up to
+// capacity entries in total.
+func newHotCache(shards, capacity int) *hotCache {
+	c := &hotCache{shards: make([]*hotShard, shards)}
+	for i := range c.shards {
+		c.shards[i] = &hotShard{
+			limit:   max(capacity/shards, 1),
+			order:   list.New(),
+			entries: make(map[string]*list.Element),
+		}
+	}
+	return c
+}
+
+// newStoreHotCache returns the hot cache used by New.
+func newStoreHotCache() *hotCache {
+	return newHotCache(helpers.StoreHotShards, helpers.StoreHotEntries)
+}
+
+// shard returns the partition owning key.
+func (c *hotCache) shard(key string) *hotShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return c.shards[h.Sum32()%uint32(len(c.shards))] //nolint:gosec // shard count is a small positive constant.
+}
+
+// get returns the value cached for key and marks it recently used.
+func (c *hotCache) get(key string) (any, bool) {
+	if c == nil {
+		return nil, false
+	}
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	entry, _ := elem.Value.(*hotEntry)
+	return entry.value, true
+}
+
+// put caches value for key, evicting the least recently used entry of the
+// shard when it is full.
+func (c *hotCache) put(key string, value any) {
+	if c == nil {
+		return
+	}
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[key]; ok {
+		entry, _ := elem.Value.(*hotEntry)
+		entry.value = value
+		s.order.MoveToFront(elem)
+		return
+	}
+	s.entries[key] = s.order.PushFront(&hotEntry{key: key, value: value})
+	if s.order.Len() > s.limit {
+		oldest := s.order.Back()
+		entry, _ := oldest.Value.(*hotEntry)
+		s.order.Remove(oldest)
+		delete(s.entries, entry.key)
+	}
+}
+
+// remove drops key from the cache.
+func (c *hotCache) remove(key string) {
+	if c == nil {
+		return
+	}
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[key]; ok {
+		s.order.Remove(elem)
+		delete(s.entries, key)
+	}
+}
+
+// reset empties every shard.
+func (c *hotCache) reset() {
+	if c == nil {
+		return
+	}
+	for _, s := range c.shards {
+		s.mu.Lock()
+		s.order.Init()
+		s.entries = make(map[string]*list.Element)
+		s.mu.Unlock()
+	}
+}
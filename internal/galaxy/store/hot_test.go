@@ -0,0 +1,72 @@
+package store
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestHotCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+	c := newHotCache(1, 2)
+	c.put("a", 1)
+	c.put("b", 2)
+	if _, ok := c.get("a"); !ok {
+		t.Fatalf("expected a to be cached")
+	}
+	c.put("c", 3)
+	if _, ok := c.get("b"); ok {
+		t.Fatalf("expected b to be evicted")
+	}
+	if v, ok := c.get("a"); !ok || v != 1 {
+		t.Fatalf("expected a=1, got %v %t", v, ok)
+	}
+}
+
+func TestStoreHotCacheFollowsWrites(t *testing.T) {
+	t.Parallel()
+	st := New()
+	st.SetDepsCache("a.b@1.0.0", map[string]string{"c.d": ">=1"})
+	deps, ok := st.GetDepsCache("a.b@1.0.0")
+	if !ok || deps["c.d"] != ">=1" {
+		t.Fatalf("unexpected deps: %v %t", deps, ok)
+	}
+	deps["x.y"] = "*"
+	st.SetDepsCache("a.b@1.0.0", map[string]string{"c.d": ">=2"})
+	if deps, _ := st.GetDepsCache("a.b@1.0.0"); len(deps) != 1 || deps["c.d"] != ">=2" {
+		t.Fatalf("expected updated deps, got %v", deps)
+	}
+
+	st.SetAPICache("k", APICacheEntry{URL: "https://galaxy.example.com/api/v3/collections/a/b/"})
+	if _, ok := st.GetAPICache("k"); !ok {
+		t.Fatalf("expected API entry")
+	}
+	st.ClearCaches()
+	if _, ok := st.GetAPICache("k"); ok {
+		t.Fatalf("expected API entry to be cleared")
+	}
+	if _, ok := st.GetDepsCache("a.b@1.0.0"); ok {
+		t.Fatalf("expected deps entry to be cleared")
+	}
+}
+
+func TestStoreHotCacheConcurrent(t *testing.T) {
+	t.Parallel()
+	st := New()
+	var wg sync.WaitGroup
+	for w := range 16 {
+		wg.Go(func() {
+			for i := range 200 {
+				key := strconv.Itoa(i % 50)
+				if w%4 == 0 {
+					st.SetAPICache(key, APICacheEntry{URL: key})
+					continue
+				}
+				if entry, ok := st.GetAPICache(key); ok && entry.URL != key {
+					t.Errorf("key %s served entry %s", key, entry.URL)
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,214 @@
+package store
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/crypt"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+// Journal operations.
+const (
+	journalInstalled = "installed"
+	journalDeleted   = "deleted"
+)
+
+// journalRecord is one line of the install journal.
+type journalRecord struct {
+	Op    string          `json:"op"`
+	Key   string          `json:"key"`
+	Entry *InstalledEntry `json:"entry,omitempty"`
+}
+
+// Journal appends installed entries to a file as installs complete, so a
+// run that dies before saving its snapshot still leaves its finished
+// installs for the next run. Saving the snapshot retires the journal.
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	retired string
+	file    *os.File
+	cipher  *crypt.Cipher
+}
+
+// OpenJournal returns the install journal of cacheDir. The file is created
+// on the first append. A non-nil cipher encrypts every record.
+func OpenJournal(cacheDir string, cipher *crypt.Cipher) *Journal {
+	path := filepath.Join(cacheDir, helpers.StoreJournal)
+	return &Journal{path: path, retired: path + helpers.StoreJournalRetiredSuffix, cipher: cipher}
+}
+
+// append writes one record. Errors are returned but the journal stays
+// usable; it is a best-effort complement to the snapshot.
+func (j *Journal) append(rec journalRecord) error {
+	if j == nil {
+		return nil
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if j.cipher != nil {
+		sealed, err := j.cipher.Seal(line)
+		if err != nil {
+			return err
+		}
+		line = []byte(base64.StdEncoding.EncodeToString(sealed))
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		//nolint:gosec // the journal path is derived from cacheDir.
+		f, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, helpers.FileMod)
+		if err != nil {
+			return err
+		}
+		j.file = f
+	}
+	_, err = j.file.Write(append(line, '\n'))
+	return err
+}
+
+// Replay applies the retired and current journal to st, in that order, and
+// returns how many records were applied. A torn or undecodable line, e.g.
+// the last one written before a crash, is skipped.
+func (j *Journal) Replay(st *Store) (int, error) {
+	if j == nil || st == nil {
+		return 0, nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var applied int
+	for _, path := range []string{j.retired, j.path} {
+		n, err := j.replayFile(path, st)
+		applied += n
+		if err != nil {
+			return applied, err
+		}
+	}
+	return applied, nil
+}
+
+// replayFile applies the records of one journal file.
+func (j *Journal) replayFile(path string, st *Store) (int, error) {
+	f, err := os.Open(path) //nolint:gosec // the journal path is derived from cacheDir.
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var applied int
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64<<10), 4<<20)
+	for scanner.Scan() {
+		rec, ok := j.decode(scanner.Bytes())
+		if !ok {
+			continue
+		}
+		switch {
+		case rec.Op == journalInstalled && rec.Entry != nil:
+			st.applyInstalled(rec.Key, *rec.Entry)
+		case rec.Op == journalDeleted:
+			st.applyDeleted(rec.Key)
+		default:
+			continue
+		}
+		applied++
+	}
+	return applied, scanner.Err()
+}
+
+// decode parses one journal line.
+func (j *Journal) decode(line []byte) (journalRecord, bool) {
+	if j.cipher != nil {
+		sealed, err := base64.StdEncoding.DecodeString(string(line))
+		if err != nil {
+			return journalRecord{}, false
+		}
+		if line, err = j.cipher.Open(sealed); err != nil {
+			return journalRecord{}, false
+		}
+	}
+	var rec journalRecord
+	if err := json.Unmarshal(line, &rec); err != nil || rec.Key == "" {
+		return journalRecord{}, false
+	}
+	return rec, true
+}
+
+// Retire moves the current journal aside before a snapshot is taken, so
+// records appended while the snapshot is written start a new journal.
+// A journal retired by an earlier save that failed is kept and extended.
+func (j *Journal) Retire() error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file != nil {
+		_ = j.file.Close()
+		j.file = nil
+	}
+	current, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = current.Close()
+	}()
+	//nolint:gosec // the journal path is derived from cacheDir.
+	retired, err := os.OpenFile(j.retired, os.O_CREATE|os.O_WRONLY|os.O_APPEND, helpers.FileMod)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(retired, current); err != nil {
+		_ = retired.Close()
+		return err
+	}
+	if err := retired.Close(); err != nil {
+		return err
+	}
+	return os.Remove(j.path)
+}
+
+// Commit drops the retired journal once the snapshot holding it is saved.
+func (j *Journal) Commit() error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := os.Remove(j.retired); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Close closes the journal file; a later append reopens it.
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+func TestJournalReplaysInstallsAfterCrash(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	journal := OpenJournal(dir, nil)
+	st := New()
+	st.AttachJournal(journal)
+	st.SetInstalled("a.b@1.0.0", InstalledEntry{InstallPath: "/x/a/b", ArtifactSHA256: "abc", Deps: []string{"c.d@2.0.0"}})
+	st.SetInstalled("c.d@2.0.0", InstalledEntry{InstallPath: "/x/c/d"})
+	st.DeleteInstalled("c.d@2.0.0")
+	if err := journal.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// a torn line from a crash mid-write is skipped
+	f, err := os.OpenFile(filepath.Join(dir, helpers.StoreJournal), os.O_WRONLY|os.O_APPEND, helpers.FileMod)
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	_, _ = f.WriteString(`{"op":"installed","key":"e.f@1`)
+	_ = f.Close()
+
+	restored := New()
+	applied, err := OpenJournal(dir, nil).Replay(restored)
+	if err != nil || applied != 3 {
+		t.Fatalf("Replay = %d, %v; want 3 records", applied, err)
+	}
+	if entry, ok := restored.GetInstalled("a.b@1.0.0"); !ok || entry.ArtifactSHA256 != "abc" {
+		t.Fatalf("missing replayed install: %+v", entry)
+	}
+	if _, ok := restored.GetInstalled("c.d@2.0.0"); ok {
+		t.Fatalf("deleted install was replayed")
+	}
+	if deps := restored.GraphSnapshot()["a.b@1.0.0"]; len(deps) != 1 {
+		t.Fatalf("missing replayed graph node: %v", deps)
+	}
+}
+
+func TestJournalRetireAndCommit(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	journal := OpenJournal(dir, nil)
+	st := New()
+	st.AttachJournal(journal)
+	st.SetInstalled("a.b@1.0.0", InstalledEntry{InstallPath: "/x/a/b"})
+	if err := journal.Retire(); err != nil {
+		t.Fatalf("Retire: %v", err)
+	}
+	st.SetInstalled("c.d@2.0.0", InstalledEntry{InstallPath: "/x/c/d"})
+
+	// a save that fails after retiring keeps both journals replayable
+	restored := New()
+	if applied, err := OpenJournal(dir, nil).Replay(restored); err != nil || applied != 2 {
+		t.Fatalf("Replay = %d, %v; want 2 records", applied, err)
+	}
+
+	if err := journal.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	restored = New()
+	if applied, err := OpenJournal(dir, nil).Replay(restored); err != nil || applied != 1 {
+		t.Fatalf("Replay after commit = %d, %v; want 1 record", applied, err)
+	}
+	if _, ok := restored.GetInstalled("c.d@2.0.0"); !ok {
+		t.Fatalf("install appended after retiring was lost")
+	}
+}
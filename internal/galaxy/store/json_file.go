@@ -0,0 +1,115 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/crypt"
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+// JSONFilePath returns the path of the single-file store under cacheDir.
+func JSONFilePath(cacheDir string) string {
+	return filepath.Join(cacheDir, helpers.StoreJSONFile)
+}
+
+// LoadJSONFile reads a store written by SaveJSONFile. A missing file, or one
+// written with a different encryption setting or key, yields an empty store,
+// as unusable Bolt values do.
+func LoadJSONFile(path string, cipher *crypt.Cipher) (*Store, error) {
+	//nolint:gosec // the store path is derived from the cache directory.
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return New(), nil
+		}
+		return nil, err
+	}
+	if cipher != nil {
+		if raw, err = cipher.Open(raw); err != nil {
+			return New(), nil
+		}
+	} else if crypt.IsSealed(raw) {
+		return New(), nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return New(), nil
+	}
+	defer zr.Close()
+	payload, err := io.ReadAll(zr)
+	if err != nil {
+		return New(), nil
+	}
+	st := New()
+	if err := json.Unmarshal(payload, st); err != nil {
+		return New(), nil
+	}
+	if err := validateSnapshotSchema(st.Meta.SchemaVersion); err != nil {
+		return nil, err
+	}
+	dropStaleVersions(st)
+	return st, nil
+}
+
+// SaveJSONFile writes the store as one gzipped JSON document, the same
+// layout the S3 backend uploads, replacing path atomically.
+func SaveJSONFile(path string, cipher *crypt.Cipher, store *Store) error {
+	if store == nil {
+		return helpers.ErrStoreNil
+	}
+	data := store.snapshotData()
+	data.Meta.SchemaVersion = helpers.StoreSnapshotSchemaVersion
+	data.Meta.LastSnapshot = time.Now().UTC()
+	payload, err := json.Marshal(&Store{
+		Meta:         data.Meta,
+		APICache:     data.APICache,
+		DepsCache:    data.DepsCache,
+		Installed:    data.Installed,
+		Graph:        data.Graph,
+		Requirements: data.Requirements,
+		Roots:        data.Roots,
+		Resolved:     data.Resolved,
+		Versions:     data.Versions,
+	})
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(payload); err != nil {
+		_ = zw.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	out := buf.Bytes()
+	if cipher != nil {
+		if out, err = cipher.Seal(out); err != nil {
+			return err
+		}
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".store-")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	if _, err := tmpFile.Write(out); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
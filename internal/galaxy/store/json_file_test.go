@@ -0,0 +1,76 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/crypt"
+)
+
+func TestJSONFileRoundTrip(t *testing.T) {
+	t.Parallel()
+	path := JSONFilePath(t.TempDir())
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := SaveJSONFile(path, nil, buildTestStore(fixed)); err != nil {
+		t.Fatalf("SaveJSONFile error: %v", err)
+	}
+	loaded, err := LoadJSONFile(path, nil)
+	if err != nil {
+		t.Fatalf("LoadJSONFile error: %v", err)
+	}
+	assertMeta(t, loaded)
+	assertAPICache(t, loaded)
+	assertDepsCache(t, loaded)
+	assertInstalled(t, loaded)
+	assertGraph(t, loaded)
+	assertRequirements(t, loaded)
+	assertRoots(t, loaded)
+	assertResolved(t, loaded)
+	assertVersions(t, loaded)
+}
+
+func TestJSONFileEncrypted(t *testing.T) {
+	t.Parallel()
+	path := JSONFilePath(t.TempDir())
+	cipher, err := crypt.New(make([]byte, crypt.KeySize))
+	if err != nil {
+		t.Fatalf("crypt.New error: %v", err)
+	}
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := SaveJSONFile(path, cipher, buildTestStore(fixed)); err != nil {
+		t.Fatalf("SaveJSONFile error: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read store: %v", err)
+	}
+	if !crypt.IsSealed(raw) {
+		t.Fatal("the store file must be encrypted")
+	}
+	loaded, err := LoadJSONFile(path, cipher)
+	if err != nil {
+		t.Fatalf("LoadJSONFile error: %v", err)
+	}
+	assertInstalled(t, loaded)
+
+	plain, err := LoadJSONFile(path, nil)
+	if err != nil {
+		t.Fatalf("LoadJSONFile without key error: %v", err)
+	}
+	if !plain.IsEmpty() {
+		t.Fatal("an encrypted store read without the key must load empty")
+	}
+}
+
+func TestJSONFileMissing(t *testing.T) {
+	t.Parallel()
+	loaded, err := LoadJSONFile(filepath.Join(t.TempDir(), "missing.json.gz"), nil)
+	if err != nil {
+		t.Fatalf("LoadJSONFile error: %v", err)
+	}
+	if !loaded.IsEmpty() {
+		t.Fatal("a missing store file must load empty")
+	}
+}
@@ -19,7 +19,8 @@ type lockInfo struct {
 }
 
 // AcquireLock creates a lock file in cacheDir to prevent concurrent writers.
-func AcquireLock(cacheDir string) (func() error, error) {
+// onSteal, when set, is called with the PID of a stale lock that was removed.
+func AcquireLock(cacheDir string, onSteal func(stalePID int)) (func() error, error) {
 	if cacheDir == "" {
 		return nil, helpers.ErrCacheDirEmpty
 	}
@@ -35,7 +36,7 @@ func AcquireLock(cacheDir string) (func() error, error) {
 		if ok || err != nil {
 			return release, err
 		}
-		if err := handleExistingLock(lockPath); err != nil {
+		if err := handleExistingLock(lockPath, onSteal); err != nil {
 			return nil, err
 		}
 	}
@@ -76,7 +77,7 @@ func writeLockFile(f *os.File, lockPath string, payload []byte) error {
 	return nil
 }
 
-func handleExistingLock(lockPath string) error {
+func handleExistingLock(lockPath string, onSteal func(stalePID int)) error {
 	current, ok, err := readLockInfo(lockPath)
 	if err != nil {
 		return err
@@ -90,6 +91,9 @@ func handleExistingLock(lockPath string) error {
 	if err := os.Remove(lockPath); err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
+	if onSteal != nil {
+		onSteal(current.PID)
+	}
 	return nil
 }
 
@@ -3,7 +3,9 @@ package store
 import (
 	"encoding/json"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
@@ -11,9 +13,93 @@ import (
 
 // ProjectRecord describes a project and its last run metadata.
 type ProjectRecord struct {
-	RequirementsFile string    `json:"requirements_file"`
-	CollectionsPath  string    `json:"collections_path"`
-	LastRun          time.Time `json:"last_run"`
+	RequirementsFile string       `json:"requirements_file"`
+	CollectionsPath  string       `json:"collections_path"`
+	LastRun          time.Time    `json:"last_run"`
+	Owner            ProjectOwner `json:"owner,omitzero"`
+}
+
+// Stale reports whether the project has not run within ttl of now.
+// A zero ttl never expires.
+func (r ProjectRecord) Stale(ttl time.Duration, now time.Time) bool {
+	return ttl > 0 && now.Sub(r.LastRun) > ttl
+}
+
+// ProjectOwner identifies who last ran a project: the CI repository and job
+// when running in a pipeline, and the user and host in any case.
+type ProjectOwner struct {
+	Repository string `json:"repository,omitempty"`
+	Job        string `json:"job,omitempty"`
+	User       string `json:"user,omitempty"`
+	Host       string `json:"host,omitempty"`
+}
+
+// String returns the most specific identity: repository, then user@host.
+func (o ProjectOwner) String() string {
+	switch {
+	case o.Repository != "":
+		return o.Repository
+	case o.User != "" && o.Host != "":
+		return o.User + "@" + o.Host
+	default:
+		return o.User + o.Host
+	}
+}
+
+// Matches reports whether the repository, user or user@host matches the glob
+// pattern. An empty pattern matches every owner, including unknown ones.
+func (o ProjectOwner) Matches(pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+	for _, candidate := range []string{o.Repository, o.User, o.User + "@" + o.Host} {
+		if candidate == "" {
+			continue
+		}
+		if ok, err := filepath.Match(pattern, candidate); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentProjectOwner reads the owner of this run from CI environment
+// variables (GitLab, GitHub Actions, Jenkins, Bitbucket, Azure Pipelines)
+// and the current user and host.
+func CurrentProjectOwner() ProjectOwner {
+	owner := projectOwnerFromEnv(os.Getenv)
+	if owner.User == "" {
+		if current, err := user.Current(); err == nil {
+			owner.User = current.Username
+		}
+	}
+	owner.Host, _ = os.Hostname()
+	return owner
+}
+
+// projectOwnerFromEnv reads the CI identity using getenv.
+func projectOwnerFromEnv(getenv func(string) string) ProjectOwner {
+	first := func(names ...string) string {
+		for _, name := range names {
+			if value := strings.TrimSpace(getenv(name)); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+	owner := ProjectOwner{
+		Repository: first("CI_PROJECT_PATH", "GITHUB_REPOSITORY", "BITBUCKET_REPO_FULL_NAME", "BUILD_REPOSITORY_NAME"),
+		Job:        first("CI_JOB_URL", "BUILD_URL", "JOB_NAME"),
+		User:       first("GITLAB_USER_LOGIN", "GITHUB_ACTOR", "BUILD_REQUESTEDFOR", "BUILD_USER_ID"),
+	}
+	if owner.Job == "" && getenv("GITHUB_RUN_ID") != "" && owner.Repository != "" {
+		server := strings.TrimRight(first("GITHUB_SERVER_URL"), "/")
+		if server == "" {
+			server = "https://github.com"
+		}
+		owner.Job = server + "/" + owner.Repository + "/actions/runs/" + getenv("GITHUB_RUN_ID")
+	}
+	return owner
 }
 
 // ProjectRegistry stores known projects keyed by path.
@@ -21,30 +107,93 @@ type ProjectRegistry struct {
 	Projects map[string]ProjectRecord `json:"projects"`
 }
 
-// RecordProject records or updates a project entry in the registry.
-func RecordProject(cacheDir, requirementsFile, downloadPath string) error {
-	if cacheDir == "" {
-		return nil
-	}
-	absReq, err := filepath.Abs(requirementsFile)
+// ProjectRun describes an install to record in the project registry.
+type ProjectRun struct {
+	RequirementsFile string
+	DownloadPath     string
+	// RelativePaths records the requirements file and collections path
+	// relative to the project directory, so the record survives a checkout
+	// that moves between runs.
+	RelativePaths bool
+}
+
+// NewProjectRecord returns the registry key and record for run.
+func NewProjectRecord(run ProjectRun) (string, ProjectRecord) {
+	absReq, err := filepath.Abs(run.RequirementsFile)
 	if err != nil {
-		absReq = requirementsFile
+		absReq = run.RequirementsFile
 	}
 	projectPath := filepath.Dir(absReq)
-	collectionsPath := resolveCollectionsPath(projectPath, downloadPath)
+	record := ProjectRecord{
+		RequirementsFile: absReq,
+		CollectionsPath:  resolveCollectionsPath(projectPath, run.DownloadPath),
+		LastRun:          time.Now().UTC(),
+		Owner:            CurrentProjectOwner(),
+	}
+	if run.RelativePaths {
+		record.RequirementsFile = filepath.Base(absReq)
+		if rel, err := filepath.Rel(projectPath, record.CollectionsPath); err == nil && record.CollectionsPath != "" {
+			record.CollectionsPath = rel
+		}
+	}
+	return projectPath, record
+}
+
+// ProjectSlot returns the identity Put files record under: the repository
+// and requirements file for a relative record of a known repository, else
+// projectPath. Records with the same slot replace each other.
+func ProjectSlot(projectPath string, record ProjectRecord) string {
+	if record.Relative() && record.Owner.Repository != "" {
+		return record.Owner.Repository + "\x00" + filepath.Base(record.RequirementsFile)
+	}
+	return projectPath
+}
+
+// Put stores record under projectPath. A record with relative paths and a
+// known repository replaces records of the same repository and requirements
+// file found under other paths, so a moving CI checkout keeps one entry.
+func (r *ProjectRegistry) Put(projectPath string, record ProjectRecord) {
+	if r.Projects == nil {
+		r.Projects = make(map[string]ProjectRecord)
+	}
+	if record.Relative() && record.Owner.Repository != "" {
+		for path, existing := range r.Projects {
+			if path != projectPath && existing.Owner.Repository == record.Owner.Repository &&
+				filepath.Base(existing.RequirementsFile) == record.RequirementsFile {
+				delete(r.Projects, path)
+			}
+		}
+	}
+	r.Projects[projectPath] = record
+}
+
+// Relative reports whether the record was written with relative paths.
+func (r ProjectRecord) Relative() bool {
+	return r.RequirementsFile != "" && !filepath.IsAbs(r.RequirementsFile)
+}
+
+// RequirementsPath returns the requirements file, resolving a relative one
+// against projectPath.
+func (r ProjectRecord) RequirementsPath(projectPath string) string {
+	return resolveCollectionsPath(projectPath, r.RequirementsFile)
+}
+
+// CollectionsDir returns the collections path, resolving a relative one
+// against projectPath.
+func (r ProjectRecord) CollectionsDir(projectPath string) string {
+	return resolveCollectionsPath(projectPath, r.CollectionsPath)
+}
 
+// RecordProject records or updates a project entry in the registry.
+func RecordProject(cacheDir string, run ProjectRun) error {
+	if cacheDir == "" {
+		return nil
+	}
 	registry, err := LoadProjectRegistry(cacheDir)
 	if err != nil {
 		return err
 	}
-	if registry.Projects == nil {
-		registry.Projects = make(map[string]ProjectRecord)
-	}
-	registry.Projects[projectPath] = ProjectRecord{
-		RequirementsFile: absReq,
-		CollectionsPath:  collectionsPath,
-		LastRun:          time.Now().UTC(),
-	}
+	registry.Put(NewProjectRecord(run))
 	return saveProjectRegistry(cacheDir, registry)
 }
 
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProjectOwnerFromEnv(t *testing.T) {
+	t.Parallel()
+	env := func(values map[string]string) func(string) string {
+		return func(name string) string { return values[name] }
+	}
+
+	gitlab := projectOwnerFromEnv(env(map[string]string{
+		"CI_PROJECT_PATH":   "infra/ansible",
+		"CI_JOB_URL":        "https://gitlab.example/infra/ansible/-/jobs/42",
+		"GITLAB_USER_LOGIN": "deployer",
+	}))
+	if gitlab.Repository != "infra/ansible" || gitlab.Job != "https://gitlab.example/infra/ansible/-/jobs/42" || gitlab.User != "deployer" {
+		t.Fatalf("unexpected GitLab owner: %+v", gitlab)
+	}
+
+	github := projectOwnerFromEnv(env(map[string]string{
+		"GITHUB_REPOSITORY": "org/playbooks",
+		"GITHUB_RUN_ID":     "7",
+		"GITHUB_ACTOR":      "bot",
+	}))
+	if github.Job != "https://github.com/org/playbooks/actions/runs/7" || github.User != "bot" {
+		t.Fatalf("unexpected GitHub owner: %+v", github)
+	}
+
+	if local := projectOwnerFromEnv(env(nil)); local != (ProjectOwner{}) {
+		t.Fatalf("no CI variables must give an empty CI identity: %+v", local)
+	}
+}
+
+func TestProjectOwnerMatches(t *testing.T) {
+	t.Parallel()
+	owner := ProjectOwner{Repository: "infra/ansible", User: "deployer", Host: "runner-1"}
+	for pattern, want := range map[string]bool{
+		"":                  true,
+		"infra/*":           true,
+		"deployer":          true,
+		"deployer@runner-*": true,
+		"team/*":            false,
+	} {
+		if got := owner.Matches(pattern); got != want {
+			t.Fatalf("Matches(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
+
+func TestProjectRecordStale(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	record := ProjectRecord{LastRun: now.Add(-48 * time.Hour)}
+	if record.Stale(0, now) {
+		t.Fatal("a zero TTL must never expire")
+	}
+	if !record.Stale(24*time.Hour, now) || record.Stale(72*time.Hour, now) {
+		t.Fatal("staleness must follow the TTL")
+	}
+}
+
+func TestProjectRecordRelativePaths(t *testing.T) {
+	t.Parallel()
+	oldCheckout := filepath.Join(t.TempDir(), "builds", "1")
+	newCheckout := filepath.Join(t.TempDir(), "builds", "2")
+
+	projectPath, record := NewProjectRecord(ProjectRun{
+		RequirementsFile: filepath.Join(newCheckout, "requirements.yml"),
+		DownloadPath:     "vendor/collections",
+		RelativePaths:    true,
+	})
+	if projectPath != newCheckout || record.RequirementsFile != "requirements.yml" || record.CollectionsPath != "vendor/collections" {
+		t.Fatalf("unexpected relative record %s: %+v", projectPath, record)
+	}
+	if got := record.RequirementsPath(projectPath); got != filepath.Join(newCheckout, "requirements.yml") {
+		t.Fatalf("RequirementsPath = %s", got)
+	}
+	if got := record.CollectionsDir(projectPath); got != filepath.Join(newCheckout, "vendor", "collections") {
+		t.Fatalf("CollectionsDir = %s", got)
+	}
+
+	record.Owner = ProjectOwner{Repository: "infra/ansible"}
+	registry := &ProjectRegistry{Projects: map[string]ProjectRecord{
+		oldCheckout:  {RequirementsFile: "requirements.yml", Owner: ProjectOwner{Repository: "infra/ansible"}},
+		"/srv/other": {RequirementsFile: "/srv/other/requirements.yml", Owner: ProjectOwner{Repository: "infra/other"}},
+	}}
+	registry.Put(projectPath, record)
+	if _, ok := registry.Projects[oldCheckout]; ok || len(registry.Projects) != 2 {
+		t.Fatalf("a moved checkout of the same repository must replace its old entry: %+v", registry.Projects)
+	}
+}
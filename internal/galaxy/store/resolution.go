@@ -0,0 +1,15 @@
+package store
+
+import "time"
+
+// Resolution is one resolved dependency graph on its own, keyed by the
+// requirements hash it was resolved for. Remote backends share it between
+// runners so a cold store can skip resolution.
+type Resolution struct {
+	RequirementsHash string                     `json:"requirements_hash"`
+	Server           string                     `json:"server"`
+	CreatedAt        time.Time                  `json:"created_at"`
+	Resolved         map[string]ResolvedEntry   `json:"resolved"`
+	Graph            map[string][]string        `json:"graph"`
+	Requirements     map[string]RequirementSpec `json:"requirements"`
+}
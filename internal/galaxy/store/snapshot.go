@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"maps"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,10 +16,42 @@ import (
 
 // SnapshotMeta holds metadata about the cached snapshot.
 type SnapshotMeta struct {
-	SchemaVersion    int       `json:"schema_version"`
-	LastSnapshot     time.Time `json:"last_snapshot"`
-	RequirementsHash string    `json:"requirements_hash"`
-	Server           string    `json:"server"`
+	SchemaVersion    int        `json:"schema_version"`
+	LastSnapshot     time.Time  `json:"last_snapshot"`
+	RequirementsHash string     `json:"requirements_hash"`
+	Server           string     `json:"server"`
+	Stats            CacheStats `json:"stats"`
+	// Mirrors holds the health of each server mirror, keyed by URL.
+	Mirrors map[string]MirrorStats `json:"mirrors,omitempty"`
+}
+
+// CacheCounters counts cache hits, misses, bytes not downloaded thanks to the cache and bytes downloaded.
+type CacheCounters struct {
+	APIHits        int64 `json:"api_hits"`
+	APIMisses      int64 `json:"api_misses"`
+	ArtifactHits   int64 `json:"artifact_hits"`
+	ArtifactMisses int64 `json:"artifact_misses"`
+	BytesSaved     int64 `json:"bytes_saved"`
+	// BytesDownloaded counts artifact bytes fetched from servers.
+	BytesDownloaded int64 `json:"bytes_downloaded"`
+}
+
+// CacheStats holds counters for the last run and cumulative totals across runs.
+type CacheStats struct {
+	Runs    int64         `json:"runs"`
+	LastRun CacheCounters `json:"last_run"`
+	Total   CacheCounters `json:"total"`
+}
+
+// MirrorStats is the recent health of one server mirror.
+type MirrorStats struct {
+	// Latency is a moving average of the time to response headers.
+	Latency   time.Duration `json:"latency"`
+	Successes int64         `json:"successes"`
+	Failures  int64         `json:"failures"`
+	// ConsecutiveFailures is reset by the next success.
+	ConsecutiveFailures int64     `json:"consecutive_failures"`
+	LastFailure         time.Time `json:"last_failure"`
 }
 
 // APICacheEntry stores a cached API response and validation data.
@@ -35,8 +69,16 @@ type InstalledEntry struct {
 	InstallPath    string    `json:"install_path"`
 	Source         string    `json:"source"`
 	ArtifactSHA256 string    `json:"artifact_sha256"`
+	ArtifactID     string    `json:"artifact_id,omitempty"`
 	InstalledAt    time.Time `json:"installed_at"`
 	Deps           []string  `json:"deps"`
+	DepsSource     string    `json:"deps_source,omitempty"`
+	// PathFingerprint is the fingerprint of the collections path after the
+	// run that last installed or confirmed the entry.
+	PathFingerprint string `json:"path_fingerprint,omitempty"`
+	// Modes are the --chmod-files and --chmod-dirs modes the tree was
+	// installed with; empty when it kept the archive's modes.
+	Modes string `json:"modes,omitempty"`
 }
 
 // Store holds cached state for collections and metadata.
@@ -51,8 +93,23 @@ type Store struct {
 	Roots        map[string][]string          `json:"roots"`
 	Resolved     map[string]ResolvedEntry     `json:"resolved"`
 	Versions     map[string][]string          `json:"versions_cache"`
+	// hot serves API and dependency cache hits without taking mu.
+	hot *hotCache
+	// journal records installs as they complete; nil disables it.
+	journal *Journal
+	// skipped lists the buckets LoadWith did not read; Save leaves them alone.
+	skipped map[string]bool
+	// lazyAPI reads API cache entries missing from APICache on first use;
+	// while set, Save merges APICache into the bucket instead of replacing it.
+	lazyAPI *DBs
 }
 
+// Prefixes separating the lookup caches inside Store.hot.
+const (
+	hotAPIPrefix  = "api:"
+	hotDepsPrefix = "deps:"
+)
+
 // New creates an initialized Store with empty maps.
 func New() *Store {
 	return &Store{
@@ -67,6 +124,7 @@ func New() *Store {
 		Roots:        make(map[string][]string),
 		Resolved:     make(map[string]ResolvedEntry),
 		Versions:     make(map[string][]string),
+		hot:          newStoreHotCache(),
 	}
 }
 
@@ -74,6 +132,9 @@ func New() *Store {
 type ResolvedEntry struct {
 	Version string `json:"version"`
 	Source  string `json:"source"`
+	// DepsSource names where the collection's dependency edges came from:
+	// metadata or manifest. Empty in entries recorded before it was tracked.
+	DepsSource string `json:"deps_source,omitempty"`
 }
 
 // RequirementSpec captures a requirement constraint and metadata.
@@ -90,8 +151,10 @@ func (m *Store) SetInstalled(key string, entry InstalledEntry) {
 		return
 	}
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	m.Installed[key] = entry
+	journal := m.journal
+	m.mu.Unlock()
+	_ = journal.append(journalRecord{Op: journalInstalled, Key: key, Entry: &entry})
 }
 
 // DeleteInstalled removes an installed entry by key.
@@ -99,6 +162,36 @@ func (m *Store) DeleteInstalled(key string) {
 	if m == nil {
 		return
 	}
+	m.mu.Lock()
+	delete(m.Installed, key)
+	journal := m.journal
+	m.mu.Unlock()
+	_ = journal.append(journalRecord{Op: journalDeleted, Key: key})
+}
+
+// AttachJournal makes SetInstalled and DeleteInstalled append to j.
+func (m *Store) AttachJournal(j *Journal) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.journal = j
+}
+
+// applyInstalled replays a journaled install: the entry and, when it
+// carries dependencies, its graph node.
+func (m *Store) applyInstalled(key string, entry InstalledEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Installed[key] = entry
+	if entry.Deps != nil {
+		m.Graph[key] = slices.Clone(entry.Deps)
+	}
+}
+
+// applyDeleted replays a journaled removal.
+func (m *Store) applyDeleted(key string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.Installed, key)
@@ -115,14 +208,35 @@ func (m *Store) GetInstalled(key string) (InstalledEntry, bool) {
 	return entry, ok
 }
 
+// InstalledSnapshot returns a copy of all installed entries.
+func (m *Store) InstalledSnapshot() map[string]InstalledEntry {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	clone := make(map[string]InstalledEntry, len(m.Installed))
+	maps.Copy(clone, m.Installed)
+	return clone
+}
+
+// IsEmpty reports whether the store holds no cached or installed state.
+func (m *Store) IsEmpty() bool {
+	if m == nil {
+		return true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.Installed) == 0 && len(m.APICache) == 0 && len(m.Versions) == 0 && len(m.Resolved) == 0
+}
+
 // GetDepsCache returns cached dependency constraints for a key.
+// The caller owns the returned map.
 func (m *Store) GetDepsCache(key string) (map[string]string, bool) {
 	if m == nil {
 		return nil, false
 	}
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	entry, ok := m.DepsCache[key]
+	entry, ok := m.lookupDeps(key)
 	if !ok {
 		return nil, false
 	}
@@ -131,6 +245,23 @@ func (m *Store) GetDepsCache(key string) (map[string]string, bool) {
 	return clone, true
 }
 
+// lookupDeps returns the stored, shared dependency map for key. Misses in
+// the hot cache are filled while holding mu, so a concurrent write cannot
+// be overtaken by the stale value it replaced.
+func (m *Store) lookupDeps(key string) (map[string]string, bool) {
+	if v, ok := m.hot.get(hotDepsPrefix + key); ok {
+		deps, isDeps := v.(map[string]string)
+		return deps, isDeps
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entry, ok := m.DepsCache[key]
+	if ok {
+		m.hot.put(hotDepsPrefix+key, entry)
+	}
+	return entry, ok
+}
+
 // SetDepsCache stores dependency constraints for a key.
 func (m *Store) SetDepsCache(key string, deps map[string]string) {
 	if m == nil {
@@ -141,6 +272,7 @@ func (m *Store) SetDepsCache(key string, deps map[string]string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.DepsCache[key] = clone
+	m.hot.put(hotDepsPrefix+key, clone)
 }
 
 // DeleteDepsCache removes cached dependency data for a key.
@@ -151,6 +283,7 @@ func (m *Store) DeleteDepsCache(key string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.DepsCache, key)
+	m.hot.remove(hotDepsPrefix + key)
 }
 
 // GetAPICache returns a cached API entry by key.
@@ -158,10 +291,68 @@ func (m *Store) GetAPICache(key string) (APICacheEntry, bool) {
 	if m == nil {
 		return APICacheEntry{}, false
 	}
+	if v, ok := m.hot.get(hotAPIPrefix + key); ok {
+		entry, isEntry := v.(APICacheEntry)
+		return entry, isEntry
+	}
 	m.mu.RLock()
-	defer m.mu.RUnlock()
 	entry, ok := m.APICache[key]
-	return entry, ok
+	if ok {
+		m.hot.put(hotAPIPrefix+key, entry)
+	}
+	lazy := m.lazyAPI
+	m.mu.RUnlock()
+	if ok || lazy == nil {
+		return entry, ok
+	}
+	return m.readLazyAPI(lazy, key)
+}
+
+// readLazyAPI reads one API cache entry from disk and keeps it in memory.
+// An entry stored meanwhile, or a cleared cache, wins over the disk copy.
+func (m *Store) readLazyAPI(dbs *DBs, key string) (APICacheEntry, bool) {
+	entry, ok := dbs.apiCacheEntry(key)
+	if !ok {
+		return APICacheEntry{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lazyAPI != dbs {
+		current, ok := m.APICache[key]
+		return current, ok
+	}
+	if current, ok := m.APICache[key]; ok {
+		return current, true
+	}
+	m.APICache[key] = entry
+	m.hot.put(hotAPIPrefix+key, entry)
+	return entry, true
+}
+
+// loadLazyAPI reads the rest of a lazily loaded API cache, for operations
+// that must see every entry. Entries already in memory are kept.
+func (m *Store) loadLazyAPI() {
+	m.mu.RLock()
+	dbs := m.lazyAPI
+	m.mu.RUnlock()
+	if dbs == nil {
+		return
+	}
+	all := New()
+	if err := loadAPICache(dbs, all); err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lazyAPI != dbs {
+		return
+	}
+	for key, entry := range all.APICache {
+		if _, ok := m.APICache[key]; !ok {
+			m.APICache[key] = entry
+		}
+	}
+	m.lazyAPI = nil
 }
 
 // SetAPICache stores a cached API entry.
@@ -172,6 +363,7 @@ func (m *Store) SetAPICache(key string, entry APICacheEntry) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.APICache[key] = entry
+	m.hot.put(hotAPIPrefix+key, entry)
 }
 
 // ClearCaches clears API, dependency, and versions caches.
@@ -184,6 +376,53 @@ func (m *Store) ClearCaches() {
 	m.APICache = make(map[string]APICacheEntry)
 	m.DepsCache = make(map[string]map[string]string)
 	m.Versions = make(map[string][]string)
+	m.lazyAPI = nil
+	m.hot.reset()
+}
+
+// BustCollection drops the API, versions and dependency cache entries of the
+// collection namespace.name and returns the versions the store knows of, so
+// their cached artifacts can be deleted too. When the stored resolution
+// includes the collection, the requirements hash is cleared so the next run
+// resolves again instead of reusing the snapshot.
+func (m *Store) BustCollection(namespace, name string) []string {
+	if m == nil {
+		return nil
+	}
+	fqdn := namespace + "." + name
+	apiPath := "/collections/" + namespace + "/" + name + "/"
+	m.loadLazyAPI()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hot.reset()
+
+	versions := make(map[string]bool)
+	for key, entry := range m.APICache {
+		if strings.Contains(entry.URL, apiPath) {
+			delete(m.APICache, key)
+		}
+	}
+	for key := range m.Versions {
+		if strings.Contains(key, apiPath) {
+			delete(m.Versions, key)
+		}
+	}
+	for key := range m.DepsCache {
+		if version, ok := strings.CutPrefix(key, fqdn+"@"); ok {
+			versions[version] = true
+			delete(m.DepsCache, key)
+		}
+	}
+	for key := range m.Installed {
+		if version, ok := strings.CutPrefix(key, fqdn+"@"); ok {
+			versions[version] = true
+		}
+	}
+	if entry, ok := m.Resolved[fqdn]; ok {
+		versions[entry.Version] = true
+		m.Meta.RequirementsHash = ""
+	}
+	return slices.Sorted(maps.Keys(versions))
 }
 
 // GetVersionsCache returns cached versions for a key.
@@ -256,6 +495,28 @@ func (m *Store) DeleteGraph(key string) {
 	delete(m.Graph, key)
 }
 
+// PruneGraph removes a key from the graph and scrubs it from all dependency lists.
+func (m *Store) PruneGraph(key string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Graph, key)
+	for parent, deps := range m.Graph {
+		if !slices.Contains(deps, key) {
+			continue
+		}
+		out := make([]string, 0, len(deps)-1)
+		for _, dep := range deps {
+			if dep != key {
+				out = append(out, dep)
+			}
+		}
+		m.Graph[parent] = out
+	}
+}
+
 // SetGraphSnapshot replaces the dependency graph.
 func (m *Store) SetGraphSnapshot(graph map[string][]string) {
 	if m == nil {
@@ -322,6 +583,35 @@ func (m *Store) SetRoots(key string, roots []string) {
 	m.Roots[key] = roots
 }
 
+// GetRoots returns the root collection keys stored under a label.
+func (m *Store) GetRoots(key string) []string {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.Roots[key])
+}
+
+// AddRoot adds a root collection key under a label unless it is there already.
+func (m *Store) AddRoot(key, root string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !slices.Contains(m.Roots[key], root) {
+		m.Roots[key] = append(slices.Clone(m.Roots[key]), root)
+	}
+}
+
+// GitRootLabel is the Roots label under which the collections built from a
+// git requirement, as written in the requirements file, are recorded; it
+// lets cleanup map the requirement back to the installed namespace.name.
+func GitRootLabel(name, version string) string {
+	return "git:" + name + "," + version
+}
+
 // MetaSnapshot returns the current snapshot metadata.
 func (m *Store) MetaSnapshot() SnapshotMeta {
 	if m == nil {
@@ -329,7 +619,64 @@ func (m *Store) MetaSnapshot() SnapshotMeta {
 	}
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.Meta
+	meta := m.Meta
+	meta.Mirrors = maps.Clone(m.Meta.Mirrors)
+	return meta
+}
+
+// BeginStatsRun resets the last-run counters and counts a new run.
+func (m *Store) BeginStatsRun() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Meta.Stats.Runs++
+	m.Meta.Stats.LastRun = CacheCounters{}
+}
+
+// AddStats adds delta to both the last-run and cumulative counters.
+func (m *Store) AddStats(delta CacheCounters) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Meta.Stats.LastRun.add(delta)
+	m.Meta.Stats.Total.add(delta)
+}
+
+// add accumulates delta into c.
+func (c *CacheCounters) add(delta CacheCounters) {
+	c.APIHits += delta.APIHits
+	c.APIMisses += delta.APIMisses
+	c.ArtifactHits += delta.ArtifactHits
+	c.ArtifactMisses += delta.ArtifactMisses
+	c.BytesSaved += delta.BytesSaved
+	c.BytesDownloaded += delta.BytesDownloaded
+}
+
+// MirrorStatsSnapshot returns a copy of the recorded mirror health.
+func (m *Store) MirrorStatsSnapshot() map[string]MirrorStats {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return maps.Clone(m.Meta.Mirrors)
+}
+
+// SetMirrorStats records the health of the mirror at url.
+func (m *Store) SetMirrorStats(url string, stats MirrorStats) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Meta.Mirrors == nil {
+		m.Meta.Mirrors = make(map[string]MirrorStats)
+	}
+	m.Meta.Mirrors[url] = stats
 }
 
 // SetMetaRequirements stores the requirements hash and server.
@@ -354,6 +701,10 @@ type snapshotData struct {
 	Roots        map[string][]string
 	Resolved     map[string]ResolvedEntry
 	Versions     map[string][]string
+	// skipped buckets are left untouched by Save.
+	skipped map[string]bool
+	// mergeAPICache adds APICache to the bucket instead of replacing it.
+	mergeAPICache bool
 }
 
 // snapshotData builds a snapshot payload from the store.
@@ -362,16 +713,19 @@ func (m *Store) snapshotData() snapshotData {
 	defer m.mu.RUnlock()
 
 	data := snapshotData{
-		Meta:         m.Meta,
-		APICache:     make(map[string]APICacheEntry, len(m.APICache)),
-		DepsCache:    make(map[string]map[string]string, len(m.DepsCache)),
-		Installed:    make(map[string]InstalledEntry, len(m.Installed)),
-		Graph:        make(map[string][]string, len(m.Graph)),
-		Requirements: make(map[string]RequirementSpec, len(m.Requirements)),
-		Roots:        make(map[string][]string, len(m.Roots)),
-		Resolved:     make(map[string]ResolvedEntry, len(m.Resolved)),
-		Versions:     make(map[string][]string, len(m.Versions)),
+		Meta:          m.Meta,
+		APICache:      make(map[string]APICacheEntry, len(m.APICache)),
+		DepsCache:     make(map[string]map[string]string, len(m.DepsCache)),
+		Installed:     make(map[string]InstalledEntry, len(m.Installed)),
+		Graph:         make(map[string][]string, len(m.Graph)),
+		Requirements:  make(map[string]RequirementSpec, len(m.Requirements)),
+		Roots:         make(map[string][]string, len(m.Roots)),
+		Resolved:      make(map[string]ResolvedEntry, len(m.Resolved)),
+		Versions:      make(map[string][]string, len(m.Versions)),
+		skipped:       maps.Clone(m.skipped),
+		mergeAPICache: m.lazyAPI != nil,
 	}
+	data.Meta.Mirrors = maps.Clone(m.Meta.Mirrors)
 
 	maps.Copy(data.APICache, m.APICache)
 	for key, deps := range m.DepsCache {
@@ -401,8 +755,26 @@ func (m *Store) snapshotData() snapshotData {
 	return data
 }
 
+// LoadOptions selects which parts of the snapshot LoadWith reads.
+type LoadOptions struct {
+	// Buckets limits loading to these buckets (helpers.StoreBucket*); empty
+	// loads all of them. Metadata is always loaded. Save leaves the buckets
+	// that were not loaded untouched.
+	Buckets []string
+	// LazyAPICache reads API cache entries on first use instead of up front.
+	// The databases must stay open while the store is in use.
+	LazyAPICache bool
+}
+
 // Load reads cached state from Bolt databases.
 func Load(dbs *DBs) (*Store, error) {
+	return LoadWith(dbs, LoadOptions{})
+}
+
+// LoadWith reads the parts of the cached state selected by opts, which keeps
+// startup time and memory down on large caches for commands that only need
+// some buckets.
+func LoadWith(dbs *DBs, opts LoadOptions) (*Store, error) {
 	store := New()
 	if dbs == nil {
 		return store, nil
@@ -414,9 +786,26 @@ func Load(dbs *DBs) (*Store, error) {
 	if err := validateSnapshotSchema(store.Meta.SchemaVersion); err != nil {
 		return nil, err
 	}
-	if err := runLoadSteps(dbs, store); err != nil {
+	wanted := func(bucket string) bool {
+		return len(opts.Buckets) == 0 || slices.Contains(opts.Buckets, bucket)
+	}
+	if err := runLoadSteps(dbs, store, func(bucket string) bool {
+		if bucket == helpers.StoreBucketAPICache && opts.LazyAPICache && wanted(bucket) {
+			store.lazyAPI = dbs
+			return false
+		}
+		if !wanted(bucket) {
+			if store.skipped == nil {
+				store.skipped = make(map[string]bool)
+			}
+			store.skipped[bucket] = true
+			return false
+		}
+		return true
+	}); err != nil {
 		return nil, err
 	}
+	dropStaleVersions(store)
 	return store, nil
 }
 
@@ -449,38 +838,52 @@ func validateSnapshotSchema(version int) error {
 	return nil
 }
 
-func runLoadSteps(dbs *DBs, store *Store) error {
-	steps := []func() error{
-		func() error { return loadAPICache(dbs, store) },
-		func() error { return loadInstalled(dbs, store) },
-		func() error { return loadDepsCache(dbs, store) },
-		func() error { return loadGraph(dbs, store) },
-		func() error { return loadRequirements(dbs, store) },
-		func() error { return loadRoots(dbs, store) },
-		func() error { return loadResolved(dbs, store) },
-		func() error { return loadVersions(dbs, store) },
+// bucketStep pairs a snapshot bucket with its load or save function.
+type bucketStep struct {
+	bucket string
+	run    func() error
+}
+
+// runLoadSteps loads every bucket for which load reports true.
+func runLoadSteps(dbs *DBs, store *Store, load func(bucket string) bool) error {
+	steps := []bucketStep{
+		{helpers.StoreBucketAPICache, func() error { return loadAPICache(dbs, store) }},
+		{helpers.StoreBucketInstalled, func() error { return loadInstalled(dbs, store) }},
+		{helpers.StoreBucketDepsCache, func() error { return loadDepsCache(dbs, store) }},
+		{helpers.StoreBucketGraph, func() error { return loadGraph(dbs, store) }},
+		{helpers.StoreBucketRequirements, func() error { return loadRequirements(dbs, store) }},
+		{helpers.StoreBucketRoots, func() error { return loadRoots(dbs, store) }},
+		{helpers.StoreBucketResolved, func() error { return loadResolved(dbs, store) }},
+		{helpers.StoreBucketVersions, func() error { return loadVersions(dbs, store) }},
 	}
 	for _, step := range steps {
-		if err := step(); err != nil {
+		if !load(step.bucket) {
+			continue
+		}
+		if err := step.run(); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// runSaveSteps saves every bucket that was loaded.
 func runSaveSteps(dbs *DBs, data snapshotData) error {
-	steps := []func() error{
-		func() error { return saveAPICache(dbs, data) },
-		func() error { return saveDepsCache(dbs, data) },
-		func() error { return saveInstalled(dbs, data) },
-		func() error { return saveGraph(dbs, data) },
-		func() error { return saveRequirements(dbs, data) },
-		func() error { return saveRoots(dbs, data) },
-		func() error { return saveResolved(dbs, data) },
-		func() error { return saveVersions(dbs, data) },
+	steps := []bucketStep{
+		{helpers.StoreBucketAPICache, func() error { return saveAPICache(dbs, data) }},
+		{helpers.StoreBucketDepsCache, func() error { return saveDepsCache(dbs, data) }},
+		{helpers.StoreBucketInstalled, func() error { return saveInstalled(dbs, data) }},
+		{helpers.StoreBucketGraph, func() error { return saveGraph(dbs, data) }},
+		{helpers.StoreBucketRequirements, func() error { return saveRequirements(dbs, data) }},
+		{helpers.StoreBucketRoots, func() error { return saveRoots(dbs, data) }},
+		{helpers.StoreBucketResolved, func() error { return saveResolved(dbs, data) }},
+		{helpers.StoreBucketVersions, func() error { return saveVersions(dbs, data) }},
 	}
 	for _, step := range steps {
-		if err := step(); err != nil {
+		if data.skipped[step.bucket] {
+			continue
+		}
+		if err := step.run(); err != nil {
 			return err
 		}
 	}
@@ -496,32 +899,40 @@ func loadMeta(dbs *DBs, store *Store) error {
 		if metaBucket == nil {
 			return nil
 		}
-		if v := metaBucket.Get([]byte(helpers.StoreMetaSchemaVersion)); v != nil {
+		if v, ok := dbs.openValue(metaBucket.Get([]byte(helpers.StoreMetaSchemaVersion))); ok {
 			version, err := strconv.Atoi(string(v))
 			if err != nil {
 				return fmt.Errorf("invalid schema version: %w", err)
 			}
 			store.Meta.SchemaVersion = version
 		}
-		if v := metaBucket.Get([]byte(helpers.StoreMetaLastSnapshot)); v != nil {
+		if v, ok := dbs.openValue(metaBucket.Get([]byte(helpers.StoreMetaLastSnapshot))); ok {
 			t, err := time.Parse(time.RFC3339Nano, string(v))
 			if err != nil {
 				return fmt.Errorf("invalid snapshot time: %w", err)
 			}
 			store.Meta.LastSnapshot = t
 		}
-		if v := metaBucket.Get([]byte(helpers.StoreMetaRequirementsHash)); v != nil {
+		if v, ok := dbs.openValue(metaBucket.Get([]byte(helpers.StoreMetaRequirementsHash))); ok {
 			store.Meta.RequirementsHash = string(v)
 		}
-		if v := metaBucket.Get([]byte(helpers.StoreMetaServer)); v != nil {
+		if v, ok := dbs.openValue(metaBucket.Get([]byte(helpers.StoreMetaServer))); ok {
 			store.Meta.Server = string(v)
 		}
+		if v, ok := dbs.openValue(metaBucket.Get([]byte(helpers.StoreMetaStats))); ok {
+			// Stats are informational; a malformed value just restarts the counters.
+			_ = json.Unmarshal(v, &store.Meta.Stats)
+		}
+		if v, ok := dbs.openValue(metaBucket.Get([]byte(helpers.StoreMetaMirrors))); ok {
+			// Mirror health only orders mirrors; a malformed value starts over.
+			_ = json.Unmarshal(v, &store.Meta.Mirrors)
+		}
 		return nil
 	})
 }
 
 func loadAPICache(dbs *DBs, store *Store) error {
-	return loadBucket(dbs.apiCache, helpers.StoreBucketAPICache, func(k, v []byte) error {
+	return loadBucket(dbs, dbs.apiCache, helpers.StoreBucketAPICache, func(k, v []byte) error {
 		var entry APICacheEntry
 		if err := json.Unmarshal(v, &entry); err != nil {
 			return err
@@ -532,7 +943,7 @@ func loadAPICache(dbs *DBs, store *Store) error {
 }
 
 func loadInstalled(dbs *DBs, store *Store) error {
-	return loadBucket(dbs.installed, helpers.StoreBucketInstalled, func(k, v []byte) error {
+	return loadBucket(dbs, dbs.installed, helpers.StoreBucketInstalled, func(k, v []byte) error {
 		var entry InstalledEntry
 		if err := json.Unmarshal(v, &entry); err != nil {
 			return err
@@ -543,7 +954,7 @@ func loadInstalled(dbs *DBs, store *Store) error {
 }
 
 func loadDepsCache(dbs *DBs, store *Store) error {
-	return loadBucket(dbs.depsCache, helpers.StoreBucketDepsCache, func(k, v []byte) error {
+	return loadBucket(dbs, dbs.depsCache, helpers.StoreBucketDepsCache, func(k, v []byte) error {
 		var entry map[string]string
 		if err := json.Unmarshal(v, &entry); err != nil {
 			return err
@@ -554,7 +965,7 @@ func loadDepsCache(dbs *DBs, store *Store) error {
 }
 
 func loadGraph(dbs *DBs, store *Store) error {
-	return loadBucket(dbs.graph, helpers.StoreBucketGraph, func(k, v []byte) error {
+	return loadBucket(dbs, dbs.graph, helpers.StoreBucketGraph, func(k, v []byte) error {
 		var deps []string
 		if err := json.Unmarshal(v, &deps); err != nil {
 			return err
@@ -565,7 +976,7 @@ func loadGraph(dbs *DBs, store *Store) error {
 }
 
 func loadRequirements(dbs *DBs, store *Store) error {
-	return loadBucket(dbs.requirements, helpers.StoreBucketRequirements, func(k, v []byte) error {
+	return loadBucket(dbs, dbs.requirements, helpers.StoreBucketRequirements, func(k, v []byte) error {
 		var spec RequirementSpec
 		if err := json.Unmarshal(v, &spec); err != nil {
 			return err
@@ -576,7 +987,7 @@ func loadRequirements(dbs *DBs, store *Store) error {
 }
 
 func loadRoots(dbs *DBs, store *Store) error {
-	return loadBucket(dbs.roots, helpers.StoreBucketRoots, func(k, v []byte) error {
+	return loadBucket(dbs, dbs.roots, helpers.StoreBucketRoots, func(k, v []byte) error {
 		var roots []string
 		if err := json.Unmarshal(v, &roots); err != nil {
 			return err
@@ -587,7 +998,7 @@ func loadRoots(dbs *DBs, store *Store) error {
 }
 
 func loadResolved(dbs *DBs, store *Store) error {
-	return loadBucket(dbs.resolved, helpers.StoreBucketResolved, func(k, v []byte) error {
+	return loadBucket(dbs, dbs.resolved, helpers.StoreBucketResolved, func(k, v []byte) error {
 		var entry ResolvedEntry
 		if err := json.Unmarshal(v, &entry); err == nil && entry.Version != "" {
 			store.Resolved[string(k)] = entry
@@ -599,12 +1010,11 @@ func loadResolved(dbs *DBs, store *Store) error {
 }
 
 func loadVersions(dbs *DBs, store *Store) error {
-	return loadBucket(dbs.versions, helpers.StoreBucketVersions, func(k, v []byte) error {
-		var entry []string
-		if err := json.Unmarshal(v, &entry); err != nil {
-			return err
+	return loadBucket(dbs, dbs.versions, helpers.StoreBucketVersions, func(k, v []byte) error {
+		// The versions cache is rebuilt from the API; a malformed entry is dropped.
+		if entry, err := decodeVersionList(v); err == nil {
+			store.Versions[string(k)] = entry
 		}
-		store.Versions[string(k)] = entry
 		return nil
 	})
 }
@@ -618,19 +1028,34 @@ func saveMeta(dbs *DBs, meta SnapshotMeta) error {
 		if err != nil {
 			return err
 		}
-		if err := metaBucket.Put([]byte(helpers.StoreMetaSchemaVersion), []byte(strconv.Itoa(meta.SchemaVersion))); err != nil {
-			return err
+		values := map[string]string{
+			helpers.StoreMetaSchemaVersion: strconv.Itoa(meta.SchemaVersion),
+			helpers.StoreMetaLastSnapshot:  meta.LastSnapshot.Format(time.RFC3339Nano),
+		}
+		if meta.RequirementsHash != "" {
+			values[helpers.StoreMetaRequirementsHash] = meta.RequirementsHash
 		}
-		if err := metaBucket.Put([]byte(helpers.StoreMetaLastSnapshot), []byte(meta.LastSnapshot.Format(time.RFC3339Nano))); err != nil {
+		if meta.Server != "" {
+			values[helpers.StoreMetaServer] = meta.Server
+		}
+		stats, err := json.Marshal(&meta.Stats)
+		if err != nil {
 			return err
 		}
-		if meta.RequirementsHash != "" {
-			if err := metaBucket.Put([]byte(helpers.StoreMetaRequirementsHash), []byte(meta.RequirementsHash)); err != nil {
+		values[helpers.StoreMetaStats] = string(stats)
+		if len(meta.Mirrors) > 0 {
+			mirrors, err := json.Marshal(meta.Mirrors)
+			if err != nil {
 				return err
 			}
+			values[helpers.StoreMetaMirrors] = string(mirrors)
 		}
-		if meta.Server != "" {
-			if err := metaBucket.Put([]byte(helpers.StoreMetaServer), []byte(meta.Server)); err != nil {
+		for key, value := range values {
+			sealed, err := dbs.sealValue([]byte(value))
+			if err != nil {
+				return err
+			}
+			if err := metaBucket.Put([]byte(key), sealed); err != nil {
 				return err
 			}
 		}
@@ -639,50 +1064,76 @@ func saveMeta(dbs *DBs, meta SnapshotMeta) error {
 }
 
 func saveAPICache(dbs *DBs, data snapshotData) error {
-	return saveBucket(dbs.apiCache, helpers.StoreBucketAPICache, data.APICache, func(entry APICacheEntry) ([]byte, error) {
+	encode := func(entry APICacheEntry) ([]byte, error) {
 		return json.Marshal(&entry)
+	}
+	if data.mergeAPICache {
+		return mergeBucket(dbs, dbs.apiCache, helpers.StoreBucketAPICache, data.APICache, encode)
+	}
+	return saveBucket(dbs, dbs.apiCache, helpers.StoreBucketAPICache, data.APICache, encode)
+}
+
+// apiCacheEntry reads one API cache entry; unreadable entries are misses.
+func (s *DBs) apiCacheEntry(key string) (APICacheEntry, bool) {
+	if s == nil || s.apiCache == nil {
+		return APICacheEntry{}, false
+	}
+	var entry APICacheEntry
+	var found bool
+	_ = s.apiCache.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(helpers.StoreBucketAPICache))
+		if bucket == nil {
+			return nil
+		}
+		plain, ok := s.openValue(bucket.Get([]byte(key)))
+		if !ok {
+			return nil
+		}
+		found = json.Unmarshal(plain, &entry) == nil
+		return nil
 	})
+	return entry, found
 }
 
 func saveDepsCache(dbs *DBs, data snapshotData) error {
-	return saveBucket(dbs.depsCache, helpers.StoreBucketDepsCache, data.DepsCache, func(entry map[string]string) ([]byte, error) {
+	return saveBucket(dbs, dbs.depsCache, helpers.StoreBucketDepsCache, data.DepsCache, func(entry map[string]string) ([]byte, error) {
 		return json.Marshal(&entry)
 	})
 }
 
 func saveInstalled(dbs *DBs, data snapshotData) error {
-	return saveBucket(dbs.installed, helpers.StoreBucketInstalled, data.Installed, func(entry InstalledEntry) ([]byte, error) {
+	return saveBucket(dbs, dbs.installed, helpers.StoreBucketInstalled, data.Installed, func(entry InstalledEntry) ([]byte, error) {
 		return json.Marshal(&entry)
 	})
 }
 
 func saveGraph(dbs *DBs, data snapshotData) error {
-	return saveBucket(dbs.graph, helpers.StoreBucketGraph, data.Graph, func(entry []string) ([]byte, error) {
+	return saveBucket(dbs, dbs.graph, helpers.StoreBucketGraph, data.Graph, func(entry []string) ([]byte, error) {
 		return json.Marshal(&entry)
 	})
 }
 
 func saveRequirements(dbs *DBs, data snapshotData) error {
-	return saveBucket(dbs.requirements, helpers.StoreBucketRequirements, data.Requirements, func(entry RequirementSpec) ([]byte, error) {
+	return saveBucket(dbs, dbs.requirements, helpers.StoreBucketRequirements, data.Requirements, func(entry RequirementSpec) ([]byte, error) {
 		return json.Marshal(&entry)
 	})
 }
 
 func saveRoots(dbs *DBs, data snapshotData) error {
-	return saveBucket(dbs.roots, helpers.StoreBucketRoots, data.Roots, func(entry []string) ([]byte, error) {
+	return saveBucket(dbs, dbs.roots, helpers.StoreBucketRoots, data.Roots, func(entry []string) ([]byte, error) {
 		return json.Marshal(&entry)
 	})
 }
 
 func saveResolved(dbs *DBs, data snapshotData) error {
-	return saveBucket(dbs.resolved, helpers.StoreBucketResolved, data.Resolved, func(entry ResolvedEntry) ([]byte, error) {
+	return saveBucket(dbs, dbs.resolved, helpers.StoreBucketResolved, data.Resolved, func(entry ResolvedEntry) ([]byte, error) {
 		return json.Marshal(&entry)
 	})
 }
 
 func saveVersions(dbs *DBs, data snapshotData) error {
-	return saveBucket(dbs.versions, helpers.StoreBucketVersions, data.Versions, func(entry []string) ([]byte, error) {
-		return json.Marshal(&entry)
+	return saveBucket(dbs, dbs.versions, helpers.StoreBucketVersions, data.Versions, func(entry []string) ([]byte, error) {
+		return encodeVersionList(entry), nil
 	})
 }
 
@@ -698,7 +1149,8 @@ func ensureEmptyBucket(tx *bolt.Tx, name string) (*bolt.Bucket, error) {
 }
 
 // loadBucket iterates over a bucket and calls fn for each entry.
-func loadBucket(db *bolt.DB, name string, fn func(k, v []byte) error) error {
+// Entries that cannot be decrypted are skipped and treated as cache misses.
+func loadBucket(dbs *DBs, db *bolt.DB, name string, fn func(k, v []byte) error) error {
 	if db == nil {
 		return nil
 	}
@@ -707,17 +1159,39 @@ func loadBucket(db *bolt.DB, name string, fn func(k, v []byte) error) error {
 		if bucket == nil {
 			return nil
 		}
-		return bucket.ForEach(fn)
+		return bucket.ForEach(func(k, v []byte) error {
+			plain, ok := dbs.openValue(v)
+			if !ok {
+				return nil
+			}
+			return fn(k, plain)
+		})
 	})
 }
 
 // saveBucket writes data to a bucket using the encode callback.
-func saveBucket[T any](db *bolt.DB, name string, data map[string]T, encode func(T) ([]byte, error)) error {
+func saveBucket[T any](dbs *DBs, db *bolt.DB, name string, data map[string]T, encode func(T) ([]byte, error)) error {
+	return writeBucket(dbs, db, name, data, encode, true)
+}
+
+// mergeBucket writes data into a bucket, keeping the entries it does not replace.
+func mergeBucket[T any](dbs *DBs, db *bolt.DB, name string, data map[string]T, encode func(T) ([]byte, error)) error {
+	return writeBucket(dbs, db, name, data, encode, false)
+}
+
+// writeBucket writes data to a bucket, emptying it first when replace is set.
+func writeBucket[T any](dbs *DBs, db *bolt.DB, name string, data map[string]T, encode func(T) ([]byte, error), replace bool) error {
 	if db == nil {
 		return nil
 	}
 	return db.Update(func(tx *bolt.Tx) error {
-		bucket, err := ensureEmptyBucket(tx, name)
+		var bucket *bolt.Bucket
+		var err error
+		if replace {
+			bucket, err = ensureEmptyBucket(tx, name)
+		} else {
+			bucket, err = tx.CreateBucketIfNotExists([]byte(name))
+		}
 		if err != nil {
 			return err
 		}
@@ -726,6 +1200,10 @@ func saveBucket[T any](db *bolt.DB, name string, data map[string]T, encode func(
 			if err != nil {
 				return err
 			}
+			encoded, err = dbs.sealValue(encoded)
+			if err != nil {
+				return err
+			}
 			if err := bucket.Put([]byte(key), encoded); err != nil {
 				return err
 			}
@@ -3,6 +3,7 @@ package store
 import (
 	"path/filepath"
 
+	"github.com/greeddj/go-galaxy/internal/galaxy/crypt"
 	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
 	bolt "go.etcd.io/bbolt"
 )
@@ -18,6 +19,7 @@ type DBs struct {
 	roots        *bolt.DB
 	resolved     *bolt.DB
 	versions     *bolt.DB
+	cipher       *crypt.Cipher
 }
 
 // OpenDBs opens all snapshot BoltDB files under cacheDir.
@@ -73,6 +75,35 @@ func OpenDBs(cacheDir string) (*DBs, error) {
 	return dbs, nil
 }
 
+// SetCipher enables at-rest encryption of snapshot values.
+func (s *DBs) SetCipher(c *crypt.Cipher) {
+	s.cipher = c
+}
+
+// sealValue encrypts a value when a cipher is configured.
+func (s *DBs) sealValue(value []byte) ([]byte, error) {
+	if s.cipher == nil {
+		return value, nil
+	}
+	return s.cipher.Seal(value)
+}
+
+// openValue decrypts a value and reports whether it is usable.
+// Values written with a different encryption setting or key are reported as unusable.
+func (s *DBs) openValue(value []byte) ([]byte, bool) {
+	if value == nil {
+		return nil, false
+	}
+	if s.cipher == nil {
+		return value, !crypt.IsSealed(value)
+	}
+	plain, err := s.cipher.Open(value)
+	if err != nil {
+		return nil, false
+	}
+	return plain, true
+}
+
 // Close closes all open BoltDB handles.
 func (s *DBs) Close() error {
 	if s == nil {
@@ -1,10 +1,14 @@
 package store
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/greeddj/go-galaxy/internal/galaxy/crypt"
 	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+	bolt "go.etcd.io/bbolt"
 )
 
 func TestSaveLoadRoundTrip(t *testing.T) {
@@ -25,6 +29,122 @@ func TestSaveLoadRoundTrip(t *testing.T) {
 	assertVersions(t, loaded)
 }
 
+func TestStatsPersistAcrossRuns(t *testing.T) {
+	t.Parallel()
+	dbs := openTestDBs(t)
+	st := New()
+	st.BeginStatsRun()
+	st.AddStats(CacheCounters{APIHits: 2, ArtifactMisses: 1})
+	mustSave(t, dbs, st)
+
+	loaded := mustLoad(t, dbs)
+	loaded.BeginStatsRun()
+	loaded.AddStats(CacheCounters{ArtifactHits: 1, BytesSaved: 10})
+	stats := loaded.MetaSnapshot().Stats
+	if stats.Runs != 2 {
+		t.Fatalf("runs = %d, want 2", stats.Runs)
+	}
+	if stats.LastRun != (CacheCounters{ArtifactHits: 1, BytesSaved: 10}) {
+		t.Fatalf("unexpected last run: %+v", stats.LastRun)
+	}
+	if stats.Total != (CacheCounters{APIHits: 2, ArtifactHits: 1, ArtifactMisses: 1, BytesSaved: 10}) {
+		t.Fatalf("unexpected total: %+v", stats.Total)
+	}
+}
+
+func TestSaveLoadEncryptedRoundTrip(t *testing.T) {
+	t.Parallel()
+	dbs := openTestDBs(t)
+	cipher, err := crypt.New(make([]byte, crypt.KeySize))
+	if err != nil {
+		t.Fatalf("crypt.New error: %v", err)
+	}
+	dbs.SetCipher(cipher)
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mustSave(t, dbs, buildTestStore(fixed))
+	loaded := mustLoad(t, dbs)
+	assertMeta(t, loaded)
+	assertInstalled(t, loaded)
+	assertResolved(t, loaded)
+
+	dbs.SetCipher(nil)
+	plain := mustLoad(t, dbs)
+	if len(plain.Installed) != 0 || plain.Meta.RequirementsHash != "" {
+		t.Fatalf("expected encrypted entries to be skipped without a key")
+	}
+}
+
+func BenchmarkSave(b *testing.B) {
+	for _, size := range []int{100, 1000} {
+		st := buildBenchStore(size, 10)
+		b.Run(fmt.Sprintf("collections=%d", size), func(b *testing.B) {
+			dbs := openBenchDBs(b)
+			for b.Loop() {
+				if err := Save(dbs, st); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkLoad(b *testing.B) {
+	for _, size := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("collections=%d", size), func(b *testing.B) {
+			dbs := openBenchDBs(b)
+			if err := Save(dbs, buildBenchStore(size, 10)); err != nil {
+				b.Fatal(err)
+			}
+			for b.Loop() {
+				if _, err := Load(dbs); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func openBenchDBs(b *testing.B) *DBs {
+	b.Helper()
+	dbs, err := OpenDBs(b.TempDir())
+	if err != nil {
+		b.Fatalf("OpenDBs error: %v", err)
+	}
+	b.Cleanup(func() {
+		_ = dbs.Close()
+	})
+	return dbs
+}
+
+// buildBenchStore fills a store the way a resolution of cols collections
+// with versions releases each leaves it: API, versions and dependency cache
+// entries per release, and one installed and resolved entry per collection.
+func buildBenchStore(cols, versions int) *Store {
+	st := New()
+	fetched := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	resolved := make(map[string]ResolvedEntry, cols)
+	body := make([]byte, 2048)
+	for i := range cols {
+		name := fmt.Sprintf("c%06d", i)
+		fqdn := "bench." + name
+		versionsURL := "https://galaxy.example/api/v3/collections/bench/" + name + "/versions/"
+		list := make([]string, versions)
+		for v := range versions {
+			list[v] = fmt.Sprintf("1.%d.0", v)
+			url := versionsURL + list[v] + "/"
+			st.SetAPICache(url, APICacheEntry{URL: url, FetchedAt: fetched, Body: body})
+			st.SetDepsCache(fqdn+"@"+list[v], map[string]string{fmt.Sprintf("bench.c%06d", i/2): ">=1.0.0"})
+		}
+		st.SetVersionsCache(versionsURL, list)
+		key := fqdn + "@" + list[versions-1]
+		st.SetInstalled(key, InstalledEntry{InstallPath: "/tmp/" + fqdn, ArtifactSHA256: "abc", InstalledAt: fetched})
+		st.SetGraph(key, []string{fmt.Sprintf("bench.c%06d@%s", i/2, list[versions-1])})
+		resolved[fqdn] = ResolvedEntry{Version: list[versions-1], Source: "https://galaxy.example"}
+	}
+	st.SetResolvedAll(resolved)
+	return st
+}
+
 func openTestDBs(t *testing.T) *DBs {
 	t.Helper()
 	dir := t.TempDir()
@@ -169,3 +289,193 @@ func assertVersions(t *testing.T, loaded *Store) {
 		t.Fatalf("unexpected versions cache: %#v", versions)
 	}
 }
+
+func TestPruneGraphScrubsEdges(t *testing.T) {
+	t.Parallel()
+	st := New()
+	st.SetGraph("a.b@1.0.0", []string{"c.d@1.2.3", "e.f@2.0.0"})
+	st.SetGraph("c.d@1.2.3", nil)
+	st.SetGraph("e.f@2.0.0", []string{"c.d@1.2.3"})
+	st.PruneGraph("c.d@1.2.3")
+	graph := st.GraphSnapshot()
+	if _, ok := graph["c.d@1.2.3"]; ok {
+		t.Fatalf("expected pruned node to be removed: %#v", graph)
+	}
+	if deps := graph["a.b@1.0.0"]; len(deps) != 1 || deps[0] != "e.f@2.0.0" {
+		t.Fatalf("unexpected deps for a.b: %#v", deps)
+	}
+	if deps := graph["e.f@2.0.0"]; len(deps) != 0 {
+		t.Fatalf("unexpected deps for e.f: %#v", deps)
+	}
+}
+
+func TestBustCollection(t *testing.T) {
+	t.Parallel()
+	st := New()
+	st.SetAPICache("k1", APICacheEntry{URL: "https://galaxy.example/api/v3/collections/a/b/versions/1.0.0/"})
+	st.SetAPICache("k2", APICacheEntry{URL: "https://galaxy.example/api/v3/collections/a/bc/versions/1.0.0/"})
+	st.SetVersionsCache("https://galaxy.example/api/v3/collections/a/b/versions/", []string{"1.0.0", "2.0.0"})
+	st.SetDepsCache("a.b@1.0.0", map[string]string{})
+	st.SetDepsCache("a.bc@1.0.0", map[string]string{})
+	st.SetInstalled("a.b@2.0.0", InstalledEntry{})
+	st.SetResolvedAll(map[string]ResolvedEntry{"a.b": {Version: "3.0.0"}})
+	st.SetMetaRequirements("hash", "https://galaxy.example")
+
+	versions := st.BustCollection("a", "b")
+	if len(versions) != 3 || versions[0] != "1.0.0" || versions[2] != "3.0.0" {
+		t.Fatalf("unexpected busted versions: %v", versions)
+	}
+	if _, ok := st.GetAPICache("k1"); ok {
+		t.Fatal("the collection's API cache must be dropped")
+	}
+	if _, ok := st.GetAPICache("k2"); !ok {
+		t.Fatal("another collection's API cache must be kept")
+	}
+	if _, ok := st.GetVersionsCache("https://galaxy.example/api/v3/collections/a/b/versions/"); ok {
+		t.Fatal("the collection's versions cache must be dropped")
+	}
+	if _, ok := st.GetDepsCache("a.b@1.0.0"); ok {
+		t.Fatal("the collection's deps cache must be dropped")
+	}
+	if _, ok := st.GetDepsCache("a.bc@1.0.0"); !ok {
+		t.Fatal("another collection's deps cache must be kept")
+	}
+	if st.MetaSnapshot().RequirementsHash != "" {
+		t.Fatal("busting a resolved collection must invalidate the stored resolution")
+	}
+}
+
+func TestLoadWithBucketsLeavesOthersOnSave(t *testing.T) {
+	t.Parallel()
+	dbs := openTestDBs(t)
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mustSave(t, dbs, buildTestStore(fixed))
+
+	partial, err := LoadWith(dbs, LoadOptions{Buckets: []string{helpers.StoreBucketInstalled, helpers.StoreBucketGraph}})
+	if err != nil {
+		t.Fatalf("LoadWith error: %v", err)
+	}
+	if _, ok := partial.GetAPICache("api"); ok {
+		t.Fatal("the API cache must not be loaded")
+	}
+	assertInstalled(t, partial)
+	partial.DeleteInstalled("a.b@1.0.0")
+	mustSave(t, dbs, partial)
+
+	loaded := mustLoad(t, dbs)
+	if _, ok := loaded.GetInstalled("a.b@1.0.0"); ok {
+		t.Fatal("the deletion in a loaded bucket must be saved")
+	}
+	assertAPICache(t, loaded)
+	assertDepsCache(t, loaded)
+	assertRequirements(t, loaded)
+	assertResolved(t, loaded)
+}
+
+func TestLoadWithLazyAPICache(t *testing.T) {
+	t.Parallel()
+	dbs := openTestDBs(t)
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mustSave(t, dbs, buildTestStore(fixed))
+
+	lazy, err := LoadWith(dbs, LoadOptions{LazyAPICache: true})
+	if err != nil {
+		t.Fatalf("LoadWith error: %v", err)
+	}
+	if len(lazy.APICache) != 0 {
+		t.Fatalf("API cache loaded eagerly: %d entries", len(lazy.APICache))
+	}
+	assertAPICache(t, lazy)
+	if _, ok := lazy.GetAPICache("missing"); ok {
+		t.Fatal("unexpected hit for a missing key")
+	}
+	lazy.SetAPICache("new", APICacheEntry{URL: "https://example.com/new"})
+	mustSave(t, dbs, lazy)
+
+	loaded := mustLoad(t, dbs)
+	assertAPICache(t, loaded)
+	if _, ok := loaded.GetAPICache("new"); !ok {
+		t.Fatal("an entry added to a lazy cache must be saved")
+	}
+}
+
+func TestLazyAPICacheBustAndClear(t *testing.T) {
+	t.Parallel()
+	dbs := openTestDBs(t)
+	st := New()
+	st.SetAPICache("k1", APICacheEntry{URL: "https://galaxy.example/api/v3/collections/a/b/versions/1.0.0/"})
+	st.SetAPICache("k2", APICacheEntry{URL: "https://galaxy.example/api/v3/collections/a/bc/versions/1.0.0/"})
+	mustSave(t, dbs, st)
+
+	lazy, err := LoadWith(dbs, LoadOptions{LazyAPICache: true})
+	if err != nil {
+		t.Fatalf("LoadWith error: %v", err)
+	}
+	lazy.BustCollection("a", "b")
+	mustSave(t, dbs, lazy)
+	loaded := mustLoad(t, dbs)
+	if _, ok := loaded.GetAPICache("k1"); ok {
+		t.Fatal("a busted entry must not survive the save")
+	}
+	if _, ok := loaded.GetAPICache("k2"); !ok {
+		t.Fatal("another collection's entry must be kept")
+	}
+
+	lazy, err = LoadWith(dbs, LoadOptions{LazyAPICache: true})
+	if err != nil {
+		t.Fatalf("LoadWith error: %v", err)
+	}
+	lazy.ClearCaches()
+	if _, ok := lazy.GetAPICache("k2"); ok {
+		t.Fatal("a cleared cache must not read entries from disk")
+	}
+	mustSave(t, dbs, lazy)
+	if _, ok := mustLoad(t, dbs).GetAPICache("k2"); ok {
+		t.Fatal("a cleared cache must be saved empty")
+	}
+}
+
+func TestDecodeVersionList(t *testing.T) {
+	t.Parallel()
+	want := []string{"2.0.0", "1.10.0", "1.0.0+build.1", ""}
+	got, err := decodeVersionList(encodeVersionList(want))
+	if err != nil || fmt.Sprint(got) != fmt.Sprint(want) || len(got) != len(want) {
+		t.Fatalf("decodeVersionList = %q, %v; want %q", got, err, want)
+	}
+	encoded := encodeVersionList(want)
+	for _, data := range [][]byte{encoded[:len(encoded)-1], append(encoded, 0), {versionListFormat, 0xff}, []byte(`["1.0.0"]`)} {
+		if _, err := decodeVersionList(data); !errors.Is(err, helpers.ErrVersionsEntryCorrupted) {
+			t.Fatalf("decodeVersionList(%q): expected ErrVersionsEntryCorrupted, got %v", data, err)
+		}
+	}
+}
+
+func TestLoadDropsStaleVersions(t *testing.T) {
+	t.Parallel()
+	dbs := openTestDBs(t)
+	st := New()
+	st.SetVersionsCache("versions", []string{"2.0.0", "1.0.0"})
+	mustSave(t, dbs, st)
+	// a list in the JSON encoding of schema 2 is dropped, not a load error
+	if err := dbs.versions.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(helpers.StoreBucketVersions)).Put([]byte("legacy"), []byte(`["1.0.0"]`))
+	}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	loaded := mustLoad(t, dbs)
+	if _, ok := loaded.GetVersionsCache("legacy"); ok {
+		t.Fatal("expected the undecodable entry to be dropped")
+	}
+	if versions, ok := loaded.GetVersionsCache("versions"); !ok || len(versions) != 2 {
+		t.Fatalf("unexpected versions cache: %#v", versions)
+	}
+
+	if err := dbs.meta.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(helpers.StoreBucketMeta)).Put([]byte(helpers.StoreMetaSchemaVersion), []byte("2"))
+	}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, ok := mustLoad(t, dbs).GetVersionsCache("versions"); ok {
+		t.Fatal("expected a schema 2 versions cache to be dropped")
+	}
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/greeddj/go-galaxy/internal/galaxy/helpers"
+)
+
+// versionListFormat is the first byte of a versions cache entry in the
+// binary encoding: the number of versions, then each version, all as
+// length-prefixed uvarints.
+const versionListFormat byte = 1
+
+// encodeVersionList packs versions in the binary encoding. Resolution keeps
+// them valid and newest first, so loading an entry needs no parsing beyond
+// the lengths.
+func encodeVersionList(versions []string) []byte {
+	size := 1 + binary.MaxVarintLen64
+	for _, version := range versions {
+		size += binary.MaxVarintLen64 + len(version)
+	}
+	out := make([]byte, 0, size)
+	out = append(out, versionListFormat)
+	out = binary.AppendUvarint(out, uint64(len(versions)))
+	for _, version := range versions {
+		out = binary.AppendUvarint(out, uint64(len(version)))
+		out = append(out, version...)
+	}
+	return out
+}
+
+// decodeVersionList reads an entry in the binary encoding.
+func decodeVersionList(data []byte) ([]string, error) {
+	if len(data) == 0 || data[0] != versionListFormat {
+		return nil, fmt.Errorf("%w: unknown encoding", helpers.ErrVersionsEntryCorrupted)
+	}
+	data = data[1:]
+	count, n := binary.Uvarint(data)
+	// every version takes at least its length byte
+	if n <= 0 || count > uint64(len(data)-n) {
+		return nil, helpers.ErrVersionsEntryCorrupted
+	}
+	data = data[n:]
+	versions := make([]string, 0, count)
+	for range count {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || size > uint64(len(data)-n) {
+			return nil, helpers.ErrVersionsEntryCorrupted
+		}
+		versions = append(versions, string(data[n:n+int(size)]))
+		data = data[n+int(size):]
+	}
+	if len(data) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", helpers.ErrVersionsEntryCorrupted, len(data))
+	}
+	return versions, nil
+}
+
+// dropStaleVersions empties the versions cache of a store written before
+// its lists were normalized; the resolver relies on their order.
+func dropStaleVersions(store *Store) {
+	if store.Meta.SchemaVersion < helpers.StoreNormalizedVersionsSchemaVersion {
+		clear(store.Versions)
+	}
+}
@@ -17,6 +17,8 @@ const (
 	ansiReset      = "\x1b[1m\x1b[0m"
 	ok             = ansiGreen + "✔" + ansiReset
 	fail           = ansiRed + "✗" + ansiReset
+	okPlain        = "[OK]"
+	failPlain      = "[FAIL]"
 )
 
 // Progress renders CLI progress output with optional spinner.
@@ -24,15 +26,20 @@ type Progress struct {
 	v bool
 	q bool
 	s *spinner.Spinner
+	r *Redactor
+	// plain replaces emoji markers with ASCII tags; see StylePlain.
+	plain bool
 }
 
 // New creates a Progress printer configured for verbose/quiet output.
 func New(verbose, quiet bool) *Progress {
 	if quiet || verbose {
 		return &Progress{
-			v: verbose,
-			q: quiet,
-			s: nil,
+			v:     verbose,
+			q:     quiet,
+			s:     nil,
+			r:     NewRedactor(),
+			plain: plainStyle(),
 		}
 	}
 
@@ -40,9 +47,11 @@ func New(verbose, quiet bool) *Progress {
 	_ = spin.Color(spinnerColor)
 
 	p := &Progress{
-		v: verbose,
-		q: quiet,
-		s: spin,
+		v:     verbose,
+		q:     quiet,
+		s:     spin,
+		r:     NewRedactor(),
+		plain: plainStyle(),
 	}
 	p.s.Start()
 	return p
@@ -50,63 +59,112 @@ func New(verbose, quiet bool) *Progress {
 
 // Okf prints a success message with a colored marker. For standalone use.
 func Okf(format string, args ...any) {
-	fmt.Printf(ok+" "+format+"\n", args...) //nolint:forbidigo
+	fmt.Println(standalone(ok + " " + Redact(fmt.Sprintf(format, args...)))) //nolint:forbidigo
 }
 
 // Errorf prints an error message with a colored marker. For standalone use.
 func Errorf(format string, args ...any) {
-	fmt.Printf(fail+" "+format+"\n", args...) //nolint:forbidigo
+	fmt.Println(standalone(fail + " " + Redact(fmt.Sprintf(format, args...)))) //nolint:forbidigo
+}
+
+// Hints prints remediation hints under a short heading. For standalone use.
+func Hints(hints []string) {
+	if len(hints) == 0 {
+		return
+	}
+	fmt.Println("Hints:") //nolint:forbidigo
+	for _, hint := range hints {
+		fmt.Println(standalone("  💡 " + Redact(hint))) //nolint:forbidigo
+	}
+}
+
+// standalone applies the output style to a message printed without a Progress.
+func standalone(message string) string {
+	if !plainStyle() {
+		return message
+	}
+	message = strings.ReplaceAll(message, ok, okPlain)
+	return toPlain(strings.ReplaceAll(message, fail, failPlain))
+}
+
+// styled applies the output style of p to a redacted message.
+func (p *Progress) styled(message string) string {
+	if !p.plain {
+		return message
+	}
+	return toPlain(message)
+}
+
+// AddSecrets registers literal credential values to mask in all output.
+func (p *Progress) AddSecrets(values ...string) {
+	p.r.AddSecrets(values...)
+}
+
+// Redact masks credentials in text with the patterns and secrets of p.
+func (p *Progress) Redact(text string) string {
+	return p.r.Redact(text)
 }
 
 // Printf updates the spinner line or prints a log line.
 func (p *Progress) Printf(format string, args ...any) {
+	message := p.styled(p.r.Redact(fmt.Sprintf(format, args...)))
 	if p.s != nil && !p.v {
-		p.s.Suffix = fmt.Sprintf(" "+format, args...)
+		p.s.Suffix = " " + message
 	}
 	if p.v {
-		fmt.Printf(format+"\n", args...) //nolint:forbidigo
+		fmt.Println(message) //nolint:forbidigo
 	}
 }
 
 // PersistentPrintf prints a persistent line that survives spinner updates.
 func (p *Progress) PersistentPrintf(format string, args ...any) {
+	message := p.styled(p.r.Redact(fmt.Sprintf(format, args...)))
 	if p.s != nil && !p.v {
 		p.s.Stop()
-		fmt.Printf("%s\n", fmt.Sprintf(format, args...)) //nolint:forbidigo
+		fmt.Println(message) //nolint:forbidigo
 		p.s.Restart()
 	}
 	if p.v {
-		fmt.Printf("%s\n", fmt.Sprintf(format, args...)) //nolint:forbidigo
+		fmt.Println(message) //nolint:forbidigo
 	}
 }
 
 // Okf prints a success message with a colored marker.
 func (p *Progress) Okf(format string, args ...any) {
+	if p.plain {
+		p.PersistentPrintf(okPlain+" "+format, args...)
+		return
+	}
 	p.PersistentPrintf(ok+" "+format, args...)
 }
 
 // Errorf prints an error message with a colored marker.
 func (p *Progress) Errorf(format string, args ...any) {
+	if p.plain {
+		p.PersistentPrintf(failPlain+" "+format, args...)
+		return
+	}
 	p.PersistentPrintf(fail+" "+format, args...)
 }
 
 // Debugf prints a debug message when verbose mode is enabled.
 func (p *Progress) Debugf(format string, args ...any) {
 	if p.v {
-		fmt.Printf("🚧 Debug: "+format+"\n", args...) //nolint:forbidigo
+		fmt.Println(p.styled("🚧 Debug: " + p.r.Redact(fmt.Sprintf(format, args...)))) //nolint:forbidigo
 	}
 }
 
 // DebugSincef prints a debug message with timing info.
 func (p *Progress) DebugSincef(start time.Time, format string, args ...any) {
 	if p.v {
-		fmt.Printf("⏱️ Debug Timing ("+time.Since(start).Round(time.Millisecond).String()+"): "+format+"\n", args...) //nolint:forbidigo
+		elapsed := time.Since(start).Round(time.Millisecond).String()
+		fmt.Println(p.styled("⏱️ Debug Timing (" + elapsed + "): " + p.r.Redact(fmt.Sprintf(format, args...)))) //nolint:forbidigo
 	}
 }
 
 // Write implements io.Writer for log output integration.
 func (p *Progress) Write(payload []byte) (int, error) {
-	message := strings.TrimRight(string(payload), "\n")
+	message := p.styled(p.r.Redact(strings.TrimRight(string(payload), "\n")))
 	if message == "" {
 		return len(payload), nil
 	}
@@ -0,0 +1,73 @@
+package progress
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// redactedMarker replaces every secret in printed output.
+	redactedMarker = "REDACTED"
+	// minSecretLen keeps short registered values from masking unrelated text.
+	minSecretLen = 4
+)
+
+// Redactor masks credentials in text before it is printed or written.
+type Redactor struct {
+	userinfo *regexp.Regexp
+	params   *regexp.Regexp
+	header   *regexp.Regexp
+	secrets  []string
+}
+
+// builtinRedactor holds the built-in credential patterns, compiled once. It
+// has no registered secrets and is only read, so it is shared freely.
+//
+//nolint:gochecknoglobals // patterns are compiled once per process, not per call.
+var builtinRedactor = &Redactor{
+	// scheme://user:password@ and scheme://token@
+	userinfo: regexp.MustCompile(`(?i)\b([a-z][a-z0-9+.-]*://)[^/\s@"'<>]+@`),
+	// tokens and S3 signatures in query strings and SigV4 headers
+	params: regexp.MustCompile(`(?i)\b((?:access_token|token|password|passwd|secret|api_key|apikey|sig|signature|credential|` +
+		`x-amz-signature|x-amz-credential|x-amz-security-token)=)[^\s&,"'<>]+`),
+	// Authorization: Bearer <token>, Authorization: Basic <creds>
+	header: regexp.MustCompile(`(?i)(authorization["']?\s*[:=]\s*["']?(?:(?:bearer|basic|token)\s+)?)[^\s"',]+`),
+}
+
+// NewRedactor builds a Redactor with the built-in credential patterns.
+func NewRedactor() *Redactor {
+	return &Redactor{
+		userinfo: builtinRedactor.userinfo,
+		params:   builtinRedactor.params,
+		header:   builtinRedactor.header,
+	}
+}
+
+// AddSecrets registers literal values, such as S3 keys or registry passwords,
+// that are masked wherever they appear.
+func (r *Redactor) AddSecrets(values ...string) {
+	for _, value := range values {
+		if len(value) >= minSecretLen {
+			r.secrets = append(r.secrets, value)
+		}
+	}
+}
+
+// Redact returns text with credentials replaced by a marker.
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, redactedMarker)
+	}
+	text = r.userinfo.ReplaceAllString(text, "${1}"+redactedMarker+"@")
+	text = r.params.ReplaceAllString(text, "${1}"+redactedMarker)
+	return r.header.ReplaceAllString(text, "${1}"+redactedMarker)
+}
+
+// Redact masks credentials in text using only the built-in patterns. For
+// standalone use, e.g. values persisted to report files.
+func Redact(text string) string {
+	return builtinRedactor.Redact(text)
+}
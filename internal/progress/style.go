@@ -0,0 +1,70 @@
+package progress
+
+import (
+	"os"
+	"strings"
+	"unicode"
+)
+
+const (
+	// StyleEnv selects the output style; --output-style exports it so that
+	// messages printed before a Progress exists follow the flag too.
+	StyleEnv = "GO_GALAXY_OUTPUT_STYLE"
+	// StyleEmoji marks messages with emoji and colored symbols.
+	StyleEmoji = "emoji"
+	// StylePlain marks messages with ASCII tags and no colors, for log
+	// processors and terminals that garble emoji.
+	StylePlain = "plain"
+)
+
+// plainMarker maps an emoji marker to its ASCII tag.
+type plainMarker struct {
+	emoji string
+	tag   string
+}
+
+// plainMarkers lists the markers with a meaning worth keeping; other emoji
+// are decoration and are dropped.
+func plainMarkers() []plainMarker {
+	return []plainMarker{
+		{emoji: "⚠️", tag: "[WARN]"},
+		{emoji: "⚠", tag: "[WARN]"},
+		{emoji: "✅", tag: "[OK]"},
+		{emoji: "✨", tag: "[OK]"},
+		{emoji: "🤩", tag: "[OK]"},
+		{emoji: "🫡", tag: "[OK]"},
+		{emoji: "❌", tag: "[FAIL]"},
+		{emoji: "💡", tag: "[HINT]"},
+	}
+}
+
+// plainStyle reports whether the plain output style is selected.
+func plainStyle() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv(StyleEnv)), StylePlain)
+}
+
+// toPlain replaces emoji markers in text with ASCII tags and drops the rest
+// of the pictographs together with the space that follows them.
+func toPlain(text string) string {
+	for _, marker := range plainMarkers() {
+		text = strings.ReplaceAll(text, marker.emoji, marker.tag)
+	}
+	var b strings.Builder
+	b.Grow(len(text))
+	dropped := false
+	for _, r := range text {
+		switch {
+		case r == '\uFE0F' || r == '\u200D':
+			continue
+		case unicode.Is(unicode.So, r):
+			dropped = true
+			continue
+		case dropped && r == ' ':
+			dropped = false
+			continue
+		}
+		dropped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,22 @@
+package progress
+
+import "testing"
+
+func TestToPlain(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"⚠️ Failed to record project: boom":      "[WARN] Failed to record project: boom",
+		"⚠ bare warning sign":                    "[WARN] bare warning sign",
+		"🤩 All done. Took 3s":                    "[OK] All done. Took 3s",
+		"🚀 init cache backend":                   "init cache backend",
+		"⏭️ Skipping install, already installed": "Skipping install, already installed",
+		"  💡 retry later":                        "  [HINT] retry later",
+		"a.b: 1.0.0 → 2.0.0":                     "a.b: 1.0.0 → 2.0.0",
+		"no markers at all":                      "no markers at all",
+	}
+	for in, want := range cases {
+		if got := toPlain(in); got != want {
+			t.Errorf("toPlain(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
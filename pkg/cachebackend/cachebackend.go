@@ -0,0 +1,73 @@
+package cachebackend
+
+import (
+	"github.com/greeddj/go-galaxy/internal/cache"
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/internal/galaxy/config"
+	"github.com/greeddj/go-galaxy/internal/galaxy/infra"
+	"github.com/greeddj/go-galaxy/internal/galaxy/store"
+)
+
+// Names of the built-in backends.
+const (
+	Local     = cache.BackendLocal
+	LocalJSON = cache.BackendLocalJSON
+	S3        = cache.BackendS3
+)
+
+// Types a backend implements or receives. They alias the types go-galaxy
+// uses internally, so a backend built against them plugs in unchanged.
+type (
+	// Backend stores the cache state, project registry and artifacts.
+	Backend = cacheManager.Backend
+	// ArtifactStore stores collection tarballs by key.
+	ArtifactStore = cacheManager.ArtifactStore
+	// ArtifactFile is a local copy of a stored artifact.
+	ArtifactFile = cacheManager.ArtifactFile
+	// ArtifactLister is the optional ArtifactStore interface for
+	// enumerating keys, used by cache migration and pruning.
+	ArtifactLister = cacheManager.ArtifactLister
+	// PartialLoader is the optional Backend interface for loading part of
+	// the store.
+	PartialLoader = cacheManager.PartialLoader
+	// ResolutionStore is the optional Backend interface for sharing
+	// resolutions between runners.
+	ResolutionStore = cacheManager.ResolutionStore
+	// AuditSink is the optional Backend interface for keeping an audit journal.
+	AuditSink = cacheManager.AuditSink
+	// AuditEvent is one entry of the audit journal.
+	AuditEvent = cacheManager.AuditEvent
+
+	// Store is the cache state a backend loads and saves.
+	Store = store.Store
+	// LoadOptions selects the parts of the store a PartialLoader loads.
+	LoadOptions = store.LoadOptions
+	// Resolution is a resolved requirements set kept by a ResolutionStore.
+	Resolution = store.Resolution
+	// ProjectRun is the project recorded by RecordProject.
+	ProjectRun = store.ProjectRun
+	// ProjectRegistry is the set of projects LoadProjectRegistry returns.
+	ProjectRegistry = store.ProjectRegistry
+
+	// Config is the parsed go-galaxy configuration handed to a Factory.
+	Config = config.Config
+	// Infra is the runtime (HTTP clients, output, temp dir) handed to a Factory.
+	Infra = infra.Infra
+	// Factory constructs a backend from configuration.
+	Factory = cache.Factory
+)
+
+// Register makes a backend available under name for --cache-backend. Call
+// it from an init function of a package linked into the binary; it panics
+// on an empty name, a nil factory or a name registered twice. go-galaxy
+// wraps the backend with its audit, migration and lease layers, which pass
+// the optional interfaces above through.
+func Register(name string, factory Factory) {
+	cache.Register(name, factory)
+}
+
+// Registered returns the sorted names of the registered backends, built-in
+// ones included.
+func Registered() []string {
+	return cache.Registered()
+}
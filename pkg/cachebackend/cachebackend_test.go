@@ -0,0 +1,89 @@
+package cachebackend_test
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/greeddj/go-galaxy/internal/cache"
+	"github.com/greeddj/go-galaxy/internal/cache/local"
+	cacheManager "github.com/greeddj/go-galaxy/internal/galaxy/cache"
+	"github.com/greeddj/go-galaxy/pkg/cachebackend"
+)
+
+const testBackend = "test-plugin"
+
+// resolvingBackend is a filesystem backend that also keeps resolutions in
+// memory, so every optional interface is present.
+type resolvingBackend struct {
+	*local.Backend
+
+	resolutions map[string]*cachebackend.Resolution
+	audited     []string
+}
+
+func (b *resolvingBackend) LoadResolution(_ context.Context, reqHash string) (*cachebackend.Resolution, error) {
+	return b.resolutions[reqHash], nil
+}
+
+func (b *resolvingBackend) SaveResolution(_ context.Context, res *cachebackend.Resolution) error {
+	b.resolutions[res.RequirementsHash] = res
+	return nil
+}
+
+func (b *resolvingBackend) AppendAudit(ctx context.Context, event cachebackend.AuditEvent) error {
+	b.audited = append(b.audited, event.Op)
+	return b.Backend.AppendAudit(ctx, event)
+}
+
+//nolint:gochecknoglobals // the backend the registered factory hands out.
+var registered = &resolvingBackend{resolutions: map[string]*cachebackend.Resolution{}}
+
+//nolint:gochecknoinits // registers the backend the way a linked-in package does.
+func init() {
+	cachebackend.Register(testBackend, func(cfg *cachebackend.Config, _ *cachebackend.Infra) (cachebackend.Backend, error) {
+		registered.Backend = local.New(filepath.Join(cfg.CacheDir, "plugin"), nil)
+		return registered, nil
+	})
+}
+
+func TestRegisteredBackendKeepsOptionalInterfaces(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	if !slices.Contains(cachebackend.Registered(), testBackend) {
+		t.Fatalf("expected %q in %v", testBackend, cachebackend.Registered())
+	}
+	cfg := &cachebackend.Config{CacheDir: t.TempDir(), CacheBackend: testBackend, CacheMigrateFrom: cachebackend.Local}
+	backend, err := cache.New(cfg, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := backend.Open(ctx); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = backend.Close(ctx)
+	})
+
+	if _, ok := backend.(cachebackend.PartialLoader); !ok {
+		t.Fatalf("%T does not forward LoadStoreWith", backend)
+	}
+	res := &cachebackend.Resolution{RequirementsHash: "req"}
+	if err := cacheManager.SaveResolution(ctx, backend, res); err != nil {
+		t.Fatalf("SaveResolution: %v", err)
+	}
+	if got, err := cacheManager.LoadResolution(ctx, backend, "req"); err != nil || got != res {
+		t.Fatalf("LoadResolution through %T = %v, %v", backend, got, err)
+	}
+	event := cacheManager.NewAuditEvent(cacheManager.AuditLockSteal, "lease", "")
+	if err := cacheManager.AppendAudit(ctx, backend, event); err != nil {
+		t.Fatalf("AppendAudit: %v", err)
+	}
+	if !slices.Contains(registered.audited, cacheManager.AuditLockSteal) {
+		t.Fatalf("audit event did not reach the registered backend: %v", registered.audited)
+	}
+	if _, ok := backend.Artifacts().(cachebackend.ArtifactLister); !ok {
+		t.Fatalf("%T does not forward List", backend.Artifacts())
+	}
+}